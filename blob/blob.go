@@ -5,9 +5,11 @@ import (
 	"errors"
 	fmt "fmt"
 	math "math"
+	"slices"
 	"sort"
 
 	"github.com/celestiaorg/go-square/namespace"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -33,6 +35,19 @@ func New(ns namespace.Namespace, blob []byte, shareVersion uint8) *Blob {
 	}
 }
 
+// NewV1 returns a share version 1 blob whose first share will contain signer.
+// Unlike New it validates the blob so that a blob with a signer that isn't
+// SignerSize bytes is rejected here rather than when it is split into shares.
+// The signer is available through the Signer field or GetSigner.
+func NewV1(ns namespace.Namespace, data, signer []byte) (*Blob, error) {
+	b := New(ns, data, shareVersionOne)
+	b.Signer = signer
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // Namespace returns the namespace of the blob
 func (b *Blob) Namespace() namespace.Namespace {
 	return namespace.Namespace{
@@ -41,28 +56,131 @@ func (b *Blob) Namespace() namespace.Namespace {
 	}
 }
 
-// Validate runs a stateless validity check on the form of the struct.
+// SignerSize is the size in bytes of the signer address of a blob with share
+// version 1.
+const SignerSize = 20
+
+const (
+	shareVersionZero = 0
+	shareVersionOne  = 1
+)
+
+var (
+	// ErrNilBlob is returned when validating a nil blob.
+	ErrNilBlob = errors.New("nil blob")
+	// ErrInvalidNamespace is returned when the namespace of a blob is
+	// malformed or can not be used by a blob.
+	ErrInvalidNamespace = errors.New("invalid blob namespace")
+	// ErrReservedNamespace is returned when a blob uses a reserved namespace.
+	ErrReservedNamespace = errors.New("blob namespace is reserved")
+	// ErrInvalidShareVersion is returned when the share version of a blob
+	// does not fit in a uint8.
+	ErrInvalidShareVersion = errors.New("share version can not be greater than MaxShareVersion")
+	// ErrInvalidSigner is returned when the signer of a blob does not match
+	// what its share version requires.
+	ErrInvalidSigner = errors.New("invalid blob signer")
+	// ErrUnsupportedShareVersion is returned when a blob has a share version
+	// that is not supported. shares.ErrUnsupportedShareVersion is the same
+	// error.
+	ErrUnsupportedShareVersion = errors.New("unsupported share version")
+	// ErrEmptyData is returned when a blob has no data.
+	ErrEmptyData = errors.New("blob data can not be empty")
+	// ErrDataTooLarge is returned when the data of a blob is longer than the
+	// sequence length of its shares can describe.
+	ErrDataTooLarge = errors.New("blob data is too large")
+	// ErrNoBlobs is returned when a blob transaction contains no blobs.
+	ErrNoBlobs = errors.New("blob tx must contain at least one blob")
+	// ErrBlobIndexOutOfRange is returned when a blob index is outside of the
+	// blobs of a blob transaction.
+	ErrBlobIndexOutOfRange = errors.New("blob index out of range")
+	// ErrNotBlobTx is returned by ParseBlobTx when a transaction is not a blob
+	// transaction.
+	ErrNotBlobTx = errors.New("not a blob tx")
+	// ErrMalformedBlobTx is returned by ParseBlobTx when a transaction has the
+	// type ID of a blob transaction but is invalid. It is wrapped together with
+	// the reason, e.g. ErrNoBlobs or ErrTrailingData.
+	ErrMalformedBlobTx = errors.New("malformed blob tx")
+	// ErrTrailingData is returned by ParseBlobTx when a blob transaction
+	// contains data that isn't part of its fields.
+	ErrTrailingData = errors.New("blob tx contains trailing data")
+	// ErrEmptyIndexWrapperTx is returned when an index wrapper wraps an empty
+	// transaction.
+	ErrEmptyIndexWrapperTx = errors.New("index wrapper tx is empty")
+	// ErrNoShareIndexes is returned when an index wrapper has no share indexes.
+	ErrNoShareIndexes = errors.New("index wrapper has no share indexes")
+	// ErrDuplicateShareIndex is returned when two blobs of an index wrapper
+	// start at the same share.
+	ErrDuplicateShareIndex = errors.New("duplicate share index")
+	// ErrShareIndexOutOfRange is returned when a share index of an index
+	// wrapper is outside of the square.
+	ErrShareIndexOutOfRange = errors.New("share index out of range")
+	// ErrShareIndexCountMismatch is returned when the number of share indexes
+	// of an index wrapper doesn't match the number of blobs.
+	ErrShareIndexCountMismatch = errors.New("number of share indexes doesn't match the number of blobs")
+)
+
+// Validate runs a stateless validity check on the form of the struct. Blobs
+// must use a valid, non-reserved namespace (see namespace.ValidateForBlob), a
+// supported share version and carry data of at most math.MaxUint32 bytes. A
+// blob with share version 1 must have a signer of SignerSize bytes and a blob
+// with share version 0 must not have a signer. Errors wrap the typed errors of
+// this package and, for namespace violations, of the namespace package.
 func (b *Blob) Validate() error {
+	return b.validate(false)
+}
+
+// ValidateAllowEmpty is like Validate but accepts a blob without data.
+func (b *Blob) ValidateAllowEmpty() error {
+	return b.validate(true)
+}
+
+func (b *Blob) validate(allowEmpty bool) error {
 	if b == nil {
-		return errors.New("nil blob")
+		return ErrNilBlob
+	}
+	if b.NamespaceVersion > namespace.NamespaceVersionMax {
+		return fmt.Errorf("%w: namespace version can not be greater than MaxNamespaceVersion", ErrInvalidNamespace)
 	}
-	if len(b.NamespaceId) != namespace.NamespaceIDSize {
-		return fmt.Errorf("namespace id must be %d bytes", namespace.NamespaceIDSize)
+	if err := b.Namespace().ValidateForBlob(); err != nil {
+		if errors.Is(err, namespace.ErrReservedNamespace) {
+			return fmt.Errorf("%w: %w", ErrReservedNamespace, err)
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidNamespace, err)
 	}
 	if b.ShareVersion > math.MaxUint8 {
-		return errors.New("share version can not be greater than MaxShareVersion")
+		return ErrInvalidShareVersion
 	}
-	if b.NamespaceVersion > namespace.NamespaceVersionMax {
-		return errors.New("namespace version can not be greater than MaxNamespaceVersion")
+	switch b.ShareVersion {
+	case shareVersionZero:
+		if len(b.Signer) != 0 {
+			return fmt.Errorf("%w: share version 0 blobs can not have a signer", ErrInvalidSigner)
+		}
+	case shareVersionOne:
+		if len(b.Signer) != SignerSize {
+			return fmt.Errorf("%w: share version 1 blobs require a signer of %d bytes, got %d", ErrInvalidSigner, SignerSize, len(b.Signer))
+		}
+	default:
+		return fmt.Errorf("%w: %d", ErrUnsupportedShareVersion, b.ShareVersion)
 	}
-	if len(b.Data) == 0 {
-		return errors.New("blob data can not be empty")
+	if !allowEmpty && len(b.Data) == 0 {
+		return ErrEmptyData
+	}
+	return validateDataLen(uint64(len(b.Data)))
+}
+
+// validateDataLen returns ErrDataTooLarge if n bytes of data don't fit in the
+// sequence length of a share.
+func validateDataLen(n uint64) error {
+	if n > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum sequence length of %d bytes", ErrDataTooLarge, n, uint32(math.MaxUint32))
 	}
 	return nil
 }
 
 // UnmarshalBlobTx attempts to unmarshal a transaction into blob transaction. If an
-// error is thrown, false is returned.
+// error is thrown, false is returned. It doesn't distinguish transactions that
+// aren't blob transactions from malformed blob transactions, use ParseBlobTx
+// for that.
 func UnmarshalBlobTx(tx []byte) (*BlobTx, bool) {
 	bTx := BlobTx{}
 	err := proto.Unmarshal(tx, &bTx)
@@ -84,12 +202,65 @@ func UnmarshalBlobTx(tx []byte) (*BlobTx, bool) {
 	return &bTx, true
 }
 
+// ParseBlobTx is a strict version of UnmarshalBlobTx. It returns an error
+// wrapping ErrNotBlobTx if tx can't be decoded or doesn't have the type ID
+// ProtoBlobTxTypeID, so it can be processed as a normal transaction. It
+// returns an error wrapping ErrMalformedBlobTx and the reason if tx has the
+// type ID but contains bytes that aren't part of a field, contains no blobs or
+// contains a blob that fails Blob.Validate.
+func ParseBlobTx(tx []byte) (*BlobTx, error) {
+	bTx := &BlobTx{}
+	if err := proto.Unmarshal(tx, bTx); err != nil {
+		// a blob tx followed by garbage is malformed rather than not a blob tx
+		prefix := &BlobTx{}
+		if proto.Unmarshal(tx[:fieldsLen(tx)], prefix) == nil && prefix.TypeId == ProtoBlobTxTypeID {
+			return nil, fmt.Errorf("%w: %w: %w", ErrMalformedBlobTx, ErrTrailingData, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrNotBlobTx, err)
+	}
+	if bTx.TypeId != ProtoBlobTxTypeID {
+		return nil, fmt.Errorf("%w: type ID %q", ErrNotBlobTx, bTx.TypeId)
+	}
+	// bytes appended to an encoded blob tx, or to one of its blobs, are
+	// decoded as unknown fields rather than rejected by proto.Unmarshal
+	if len(bTx.ProtoReflect().GetUnknown()) != 0 {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedBlobTx, ErrTrailingData)
+	}
+	for i, b := range bTx.Blobs {
+		if len(b.ProtoReflect().GetUnknown()) != 0 {
+			return nil, fmt.Errorf("%w: blob %d: %w", ErrMalformedBlobTx, i, ErrTrailingData)
+		}
+	}
+	if err := bTx.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedBlobTx, err)
+	}
+	return bTx, nil
+}
+
+// fieldsLen returns the length of the longest prefix of b that consists of
+// well formed protobuf fields.
+func fieldsLen(b []byte) int {
+	n := 0
+	for n < len(b) {
+		_, _, l := protowire.ConsumeField(b[n:])
+		if l < 0 {
+			break
+		}
+		n += l
+	}
+	return n
+}
+
 // MarshalBlobTx creates a BlobTx using a normal transaction and some number of
-// blobs.
+// blobs. It returns an error if any of the blobs fails Validate.
 //
-// NOTE: Any checks on the blobs or the transaction must be performed in the
-// application
+// NOTE: Any checks on the transaction must be performed in the application
 func MarshalBlobTx(tx []byte, blobs ...*Blob) ([]byte, error) {
+	for i, b := range blobs {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid blob %d: %w", i, err)
+		}
+	}
 	bTx := &BlobTx{
 		Tx:     tx,
 		Blobs:  blobs,
@@ -98,16 +269,173 @@ func MarshalBlobTx(tx []byte, blobs ...*Blob) ([]byte, error) {
 	return proto.Marshal(bTx)
 }
 
+// Validate returns an error if the blob transaction doesn't have the type ID
+// ProtoBlobTxTypeID, contains no blobs or contains a blob that fails
+// Blob.Validate.
+func (b *BlobTx) Validate() error {
+	if b.TypeId != ProtoBlobTxTypeID {
+		return fmt.Errorf("blob tx has type ID %q but expected %q", b.TypeId, ProtoBlobTxTypeID)
+	}
+	if len(b.Blobs) == 0 {
+		return ErrNoBlobs
+	}
+	for i, blob := range b.Blobs {
+		if err := blob.Validate(); err != nil {
+			return fmt.Errorf("invalid blob %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// AddBlob validates blob and appends it to the blobs of the blob transaction.
+// The share commitments of the transaction must be updated by the caller.
+func (b *BlobTx) AddBlob(blob *Blob) error {
+	if err := blob.Validate(); err != nil {
+		return err
+	}
+	b.Blobs = append(b.Blobs, blob)
+	return nil
+}
+
+// RemoveBlob removes the blob at index i from the blob transaction. It returns
+// an error wrapping ErrBlobIndexOutOfRange if there is no such blob and
+// ErrNoBlobs if it is the only blob because a blob transaction can't be empty.
+// The blob transaction is not modified if an error is returned. The share
+// commitments of the transaction must be updated by the caller.
+func (b *BlobTx) RemoveBlob(i int) error {
+	if i < 0 || i >= len(b.Blobs) {
+		return fmt.Errorf("%w: index %d, blob tx has %d blobs", ErrBlobIndexOutOfRange, i, len(b.Blobs))
+	}
+	if len(b.Blobs) == 1 {
+		return fmt.Errorf("%w: can not remove the only blob", ErrNoBlobs)
+	}
+	b.Blobs = slices.Delete(b.Blobs, i, i+1)
+	return nil
+}
+
+// Sort sorts the blobs by their namespace. The sort is stable so blobs that
+// share a namespace retain their relative order.
 func Sort(blobs []*Blob) {
 	sort.SliceStable(blobs, func(i, j int) bool {
-		return bytes.Compare(blobs[i].Namespace().Bytes(), blobs[j].Namespace().Bytes()) < 0
+		return compareNamespaces(blobs[i], blobs[j]) < 0
 	})
 }
 
+// SortWithIndex returns the blobs sorted by namespace without modifying the
+// provided slice. The sort is stable so blobs that share a namespace retain
+// their submission order, which the order of the share commitments in a
+// MsgPayForBlobs relies on. originalIndex[i] is the index in blobs of
+// sorted[i].
+func SortWithIndex(blobs []*Blob) (sorted []*Blob, originalIndex []int) {
+	originalIndex = make([]int, len(blobs))
+	for i := range originalIndex {
+		originalIndex[i] = i
+	}
+	sort.SliceStable(originalIndex, func(i, j int) bool {
+		return compareNamespaces(blobs[originalIndex[i]], blobs[originalIndex[j]]) < 0
+	})
+	sorted = make([]*Blob, len(blobs))
+	for i, idx := range originalIndex {
+		sorted[i] = blobs[idx]
+	}
+	return sorted, originalIndex
+}
+
+// IsSorted returns true if the blobs are sorted by namespace.
+func IsSorted(blobs []*Blob) bool {
+	return sort.SliceIsSorted(blobs, func(i, j int) bool {
+		return compareNamespaces(blobs[i], blobs[j]) < 0
+	})
+}
+
+// compareNamespaces compares the namespaces of two blobs without allocating.
+func compareNamespaces(a, b *Blob) int {
+	return a.Namespace().Compare(b.Namespace())
+}
+
+// Equal returns true if both blobs have the same namespace, data, share
+// version and signer. Nil and empty data, or signers, are considered equal. A
+// nil blob is only equal to another nil blob.
+func (b *Blob) Equal(other *Blob) bool {
+	if b == nil || other == nil {
+		return b == other
+	}
+	// the data is compared last because it is by far the largest field
+	return len(b.Data) == len(other.Data) &&
+		b.NamespaceVersion == other.NamespaceVersion &&
+		bytes.Equal(b.NamespaceId, other.NamespaceId) &&
+		b.ShareVersion == other.ShareVersion &&
+		bytes.Equal(b.Signer, other.Signer) &&
+		bytes.Equal(b.Data, other.Data)
+}
+
+// CompareBlobs returns -1, 0 or 1 depending on whether a is less than, equal
+// to or greater than b. Blobs are ordered by namespace, so the order is
+// consistent with Sort, then by data, share version and signer. It returns 0
+// if and only if a.Equal(b). Nil blobs must not be compared.
+func CompareBlobs(a, b *Blob) int {
+	if c := compareNamespaces(a, b); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.Data, b.Data); c != 0 {
+		return c
+	}
+	switch {
+	case a.ShareVersion < b.ShareVersion:
+		return -1
+	case a.ShareVersion > b.ShareVersion:
+		return 1
+	}
+	return bytes.Compare(a.Signer, b.Signer)
+}
+
+// DedupBlobs returns the blobs without the blobs that are Equal to an earlier
+// blob. The order of the remaining blobs is preserved. The provided slice is
+// not modified.
+func DedupBlobs(blobs []*Blob) []*Blob {
+	type key struct {
+		namespace string
+		dataLen   int
+	}
+	// blobs are only compared in full with blobs of the same namespace and
+	// data length
+	seen := make(map[key][]*Blob, len(blobs))
+	deduped := make([]*Blob, 0, len(blobs))
+	for _, b := range blobs {
+		k := key{dataLen: -1}
+		if b != nil {
+			k = key{namespace: string(b.Namespace().Bytes()), dataLen: len(b.Data)}
+		}
+		if slices.ContainsFunc(seen[k], b.Equal) {
+			continue
+		}
+		seen[k] = append(seen[k], b)
+		deduped = append(deduped, b)
+	}
+	return deduped
+}
+
+// GroupBlobsByNamespace splits the provided blobs into contiguous runs of
+// blobs that share the same namespace. The blobs are expected to already be
+// sorted (see Sort). The returned groups alias the provided slice.
+func GroupBlobsByNamespace(blobs []*Blob) [][]*Blob {
+	groups := make([][]*Blob, 0)
+	start := 0
+	for i := 1; i <= len(blobs); i++ {
+		if i == len(blobs) || !bytes.Equal(blobs[i].Namespace().Bytes(), blobs[start].Namespace().Bytes()) {
+			groups = append(groups, blobs[start:i])
+			start = i
+		}
+	}
+	return groups
+}
+
 // UnmarshalIndexWrapper attempts to unmarshal the provided transaction into an
 // IndexWrapper transaction. It returns true if the provided transaction is an
 // IndexWrapper transaction. An IndexWrapper transaction is a transaction that contains
-// a MsgPayForBlob that has been wrapped with a share index.
+// a MsgPayForBlob that has been wrapped with a share index. A transaction with
+// the IndexWrapper type ID that wraps an empty transaction, has no share
+// indexes or has duplicate share indexes is not considered an IndexWrapper.
 //
 // NOTE: protobuf sometimes does not throw an error if the transaction passed is
 // not a IndexWrapper, since the protobuf definition for MsgPayForBlob is
@@ -123,9 +451,67 @@ func UnmarshalIndexWrapper(tx []byte) (*IndexWrapper, bool) {
 	if indexWrapper.TypeId != ProtoIndexWrapperTypeID {
 		return &indexWrapper, false
 	}
+	if validateShareIndexes(indexWrapper.Tx, indexWrapper.ShareIndexes) != nil {
+		return &indexWrapper, false
+	}
 	return &indexWrapper, true
 }
 
+// ValidateIndexWrapper returns an error if iw can't be the index wrapper of a
+// blob transaction with blobCount blobs in a square of width squareSize: the
+// wrapped transaction must not be empty and there must be a distinct share
+// index within the square for every blob. The share indexes follow the order of
+// the blobs in the blob transaction rather than the order of the blobs in the
+// square, so they are only sorted if the blobs are sorted by namespace.
+func ValidateIndexWrapper(iw *IndexWrapper, squareSize, blobCount int) error {
+	if iw == nil {
+		return errors.New("index wrapper is nil")
+	}
+	if iw.TypeId != ProtoIndexWrapperTypeID {
+		return fmt.Errorf("index wrapper has type ID %q but expected %q", iw.TypeId, ProtoIndexWrapperTypeID)
+	}
+	if err := validateShareIndexes(iw.Tx, iw.ShareIndexes); err != nil {
+		return err
+	}
+	if len(iw.ShareIndexes) != blobCount {
+		return fmt.Errorf("%w: %d share indexes for %d blobs", ErrShareIndexCountMismatch, len(iw.ShareIndexes), blobCount)
+	}
+	for i, shareIndex := range iw.ShareIndexes {
+		if uint64(shareIndex) >= uint64(squareSize)*uint64(squareSize) {
+			return fmt.Errorf("%w: share index %d of blob %d in a square of size %d", ErrShareIndexOutOfRange, shareIndex, i, squareSize)
+		}
+	}
+	return nil
+}
+
+// validateShareIndexes performs the checks of ValidateIndexWrapper that don't
+// depend on the square or the blob transaction.
+func validateShareIndexes(tx []byte, shareIndexes []uint32) error {
+	if len(tx) == 0 {
+		return ErrEmptyIndexWrapperTx
+	}
+	if len(shareIndexes) == 0 {
+		return ErrNoShareIndexes
+	}
+	seen := make(map[uint32]struct{}, len(shareIndexes))
+	for _, shareIndex := range shareIndexes {
+		if _, ok := seen[shareIndex]; ok {
+			return fmt.Errorf("%w: %d", ErrDuplicateShareIndex, shareIndex)
+		}
+		seen[shareIndex] = struct{}{}
+	}
+	return nil
+}
+
+// WrapTxWithIndexes is like MarshalIndexWrapper but returns an error if tx is
+// empty, if there are no share indexes or if two share indexes are equal.
+func WrapTxWithIndexes(tx []byte, indexes []uint32) ([]byte, error) {
+	if err := validateShareIndexes(tx, indexes); err != nil {
+		return nil, err
+	}
+	return MarshalIndexWrapper(tx, indexes...)
+}
+
 // MarshalIndexWrapper creates a wrapped Tx that includes the original transaction
 // and the share index of the start of its blob.
 //
@@ -0,0 +1,550 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/go-square/internal/cbor"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSortAndGroupBlobsByNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+
+	blobs := []*Blob{
+		New(ns3, []byte{1}, 0),
+		New(ns1, []byte{2}, 0),
+		New(ns2, []byte{3}, 0),
+		New(ns1, []byte{4}, 0),
+		New(ns3, []byte{5}, 0),
+	}
+	Sort(blobs)
+
+	groups := GroupBlobsByNamespace(blobs)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, []*Blob{New(ns1, []byte{2}, 0), New(ns1, []byte{4}, 0)}, groups[0])
+	assert.Equal(t, []*Blob{New(ns2, []byte{3}, 0)}, groups[1])
+	assert.Equal(t, []*Blob{New(ns3, []byte{1}, 0), New(ns3, []byte{5}, 0)}, groups[2])
+}
+
+func TestSortWithIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	namespaces := []namespace.Namespace{
+		namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize)),
+		namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize)),
+		namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize)),
+	}
+	for i := 0; i < 100; i++ {
+		// the data of every blob is its submission index so that blobs with
+		// the same namespace can be told apart
+		blobs := make([]*Blob, rng.Intn(20))
+		for j := range blobs {
+			blobs[j] = New(namespaces[rng.Intn(len(namespaces))], []byte{byte(j)}, 0)
+		}
+		input := make([]*Blob, len(blobs))
+		copy(input, blobs)
+
+		sorted, originalIndex := SortWithIndex(blobs)
+		assert.Equal(t, input, blobs, "the input must not be modified")
+		assert.True(t, IsSorted(sorted))
+		require.Len(t, originalIndex, len(blobs))
+
+		seen := make([]bool, len(blobs))
+		for j, idx := range originalIndex {
+			require.False(t, seen[idx], "original index %d appears twice", idx)
+			seen[idx] = true
+			assert.Same(t, blobs[idx], sorted[j])
+			if j > 0 && sorted[j-1].Namespace().Equals(sorted[j].Namespace()) {
+				assert.Less(t, originalIndex[j-1], idx, "blobs with the same namespace swapped")
+			}
+		}
+
+		Sort(input)
+		assert.Equal(t, sorted, input)
+	}
+}
+
+func TestIsSorted(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	assert.True(t, IsSorted(nil))
+	assert.True(t, IsSorted([]*Blob{New(ns1, []byte{1}, 0), New(ns1, []byte{1}, 0), New(ns2, []byte{1}, 0)}))
+	assert.False(t, IsSorted([]*Blob{New(ns2, []byte{1}, 0), New(ns1, []byte{1}, 0)}))
+}
+
+func TestGroupBlobsByNamespaceEmpty(t *testing.T) {
+	assert.Empty(t, GroupBlobsByNamespace(nil))
+}
+
+func TestValidate(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+	withSigner := func(b *Blob, signer []byte) *Blob {
+		b.Signer = signer
+		return b
+	}
+
+	type testCase struct {
+		name    string
+		blob    *Blob
+		wantErr error
+	}
+	testCases := []testCase{
+		{name: "valid v0 blob", blob: New(ns, []byte{1}, 0)},
+		{name: "valid v1 blob", blob: withSigner(New(ns, []byte{1}, 1), signer)},
+		{name: "nil blob", blob: nil, wantErr: ErrNilBlob},
+		{name: "short namespace id", blob: &Blob{NamespaceId: []byte{1}, Data: []byte{1}}, wantErr: ErrInvalidNamespace},
+		{name: "unsupported namespace version", blob: &Blob{NamespaceId: ns.ID, NamespaceVersion: 1, Data: []byte{1}}, wantErr: namespace.ErrInvalidVersion},
+		{name: "missing version 0 leading zeros", blob: &Blob{NamespaceId: bytes.Repeat([]byte{1}, namespace.NamespaceIDSize), Data: []byte{1}}, wantErr: namespace.ErrInvalidLeadingZeros},
+		{name: "reserved namespace", blob: New(namespace.TxNamespace, []byte{1}, 0), wantErr: ErrReservedNamespace},
+		{name: "tail padding namespace", blob: New(namespace.TailPaddingNamespace, []byte{1}, 0), wantErr: ErrReservedNamespace},
+		{name: "parity shares namespace", blob: New(namespace.ParitySharesNamespace, []byte{1}, 0), wantErr: namespace.ErrParityOrTailPadding},
+		{name: "primary reserved namespace", blob: New(namespace.PayForBlobNamespace, []byte{1}, 0), wantErr: namespace.ErrReservedNamespace},
+		{name: "unsupported share version", blob: &Blob{NamespaceId: ns.ID, Data: []byte{1}, ShareVersion: 2}, wantErr: ErrUnsupportedShareVersion},
+		{name: "share version too large", blob: &Blob{NamespaceId: ns.ID, Data: []byte{1}, ShareVersion: 256}, wantErr: ErrInvalidShareVersion},
+		{name: "v0 blob with signer", blob: withSigner(New(ns, []byte{1}, 0), signer), wantErr: ErrInvalidSigner},
+		{name: "v1 blob without signer", blob: New(ns, []byte{1}, 1), wantErr: ErrInvalidSigner},
+		{name: "v1 blob with short signer", blob: withSigner(New(ns, []byte{1}, 1), signer[1:]), wantErr: ErrInvalidSigner},
+		{name: "empty data", blob: New(ns, nil, 0), wantErr: ErrEmptyData},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.blob.Validate()
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	assert.NoError(t, New(ns, nil, 0).ValidateAllowEmpty())
+	assert.ErrorIs(t, New(namespace.TxNamespace, nil, 0).ValidateAllowEmpty(), ErrReservedNamespace)
+
+	assert.NoError(t, validateDataLen(math.MaxUint32))
+	assert.ErrorIs(t, validateDataLen(math.MaxUint32+1), ErrDataTooLarge)
+}
+
+func TestNewV1(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+
+	b, err := NewV1(ns, []byte("data"), signer)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(shareVersionOne), b.ShareVersion)
+	assert.Equal(t, signer, b.GetSigner())
+	assert.Equal(t, ns, b.Namespace())
+
+	// the signer survives the protobuf encoding of a blob tx
+	rawTx, err := MarshalBlobTx([]byte("tx"), b)
+	require.NoError(t, err)
+	blobTx, ok := UnmarshalBlobTx(rawTx)
+	require.True(t, ok)
+	require.Len(t, blobTx.Blobs, 1)
+	assert.True(t, proto.Equal(b, blobTx.Blobs[0]))
+
+	_, err = NewV1(ns, []byte("data"), signer[1:])
+	assert.ErrorIs(t, err, ErrInvalidSigner)
+	_, err = NewV1(ns, []byte("data"), nil)
+	assert.ErrorIs(t, err, ErrInvalidSigner)
+	_, err = NewV1(namespace.TxNamespace, []byte("data"), signer)
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+}
+
+func TestMarshalBlobTxValidatesBlobs(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	_, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0))
+	assert.NoError(t, err)
+
+	_, err = MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0), New(namespace.TxNamespace, []byte{1}, 0))
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+	assert.ErrorContains(t, err, "blob 1")
+	_, err = MarshalBlobTx([]byte("tx"), New(ns, nil, 0))
+	assert.ErrorIs(t, err, ErrEmptyData)
+	_, err = MarshalBlobTx([]byte("tx"), nil)
+	assert.ErrorIs(t, err, ErrNilBlob)
+}
+
+func TestBlobTxAddAndRemoveBlob(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	rawTx, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0))
+	require.NoError(t, err)
+	blobTx, isBlobTx := UnmarshalBlobTx(rawTx)
+	require.True(t, isBlobTx)
+	require.NoError(t, blobTx.Validate())
+
+	t.Run("add blob with unsupported share version", func(t *testing.T) {
+		err := blobTx.AddBlob(New(ns, []byte{2}, 2))
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+		assert.Len(t, blobTx.Blobs, 1)
+	})
+	t.Run("remove out of range", func(t *testing.T) {
+		for _, i := range []int{-1, 1} {
+			assert.ErrorIs(t, blobTx.RemoveBlob(i), ErrBlobIndexOutOfRange)
+		}
+		assert.Len(t, blobTx.Blobs, 1)
+	})
+	t.Run("remove only blob", func(t *testing.T) {
+		assert.ErrorIs(t, blobTx.RemoveBlob(0), ErrNoBlobs)
+		assert.Len(t, blobTx.Blobs, 1)
+		assert.ErrorIs(t, (&BlobTx{TypeId: ProtoBlobTxTypeID}).Validate(), ErrNoBlobs)
+	})
+	t.Run("add and remove", func(t *testing.T) {
+		require.NoError(t, blobTx.AddBlob(New(ns, []byte{2}, 0)))
+		require.NoError(t, blobTx.AddBlob(New(ns, []byte{3}, 0)))
+		require.NoError(t, blobTx.RemoveBlob(0))
+		require.NoError(t, blobTx.Validate())
+
+		rawTx, err := proto.Marshal(blobTx)
+		require.NoError(t, err)
+		got, isBlobTx := UnmarshalBlobTx(rawTx)
+		require.True(t, isBlobTx)
+		require.Len(t, got.Blobs, 2)
+		assert.Equal(t, []byte{2}, got.Blobs[0].Data)
+		assert.Equal(t, []byte{3}, got.Blobs[1].Data)
+	})
+}
+
+func TestParseBlobTx(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	valid, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0))
+	require.NoError(t, err)
+	mustMarshal := func(blobTx *BlobTx) []byte {
+		rawTx, err := proto.Marshal(blobTx)
+		require.NoError(t, err)
+		return rawTx
+	}
+
+	testCases := []struct {
+		name    string
+		tx      []byte
+		wantErr []error
+	}{
+		{name: "valid", tx: valid},
+		{name: "not a proto message", tx: []byte{0xff, 0xff}, wantErr: []error{ErrNotBlobTx}},
+		{name: "wrong type ID", tx: mustMarshal(&BlobTx{Tx: []byte("tx"), Blobs: []*Blob{New(ns, []byte{1}, 0)}}), wantErr: []error{ErrNotBlobTx}},
+		{name: "no blobs", tx: mustMarshal(&BlobTx{Tx: []byte("tx"), TypeId: ProtoBlobTxTypeID}), wantErr: []error{ErrMalformedBlobTx, ErrNoBlobs}},
+		{
+			name:    "empty namespace",
+			tx:      mustMarshal(&BlobTx{Tx: []byte("tx"), Blobs: []*Blob{{Data: []byte{1}}}, TypeId: ProtoBlobTxTypeID}),
+			wantErr: []error{ErrMalformedBlobTx, ErrInvalidNamespace},
+		},
+		{
+			name:    "unsupported share version",
+			tx:      mustMarshal(&BlobTx{Tx: []byte("tx"), Blobs: []*Blob{New(ns, []byte{1}, 2)}, TypeId: ProtoBlobTxTypeID}),
+			wantErr: []error{ErrMalformedBlobTx, ErrUnsupportedShareVersion},
+		},
+		{name: "trailing field", tx: append(bytes.Clone(valid), 0x78, 0x01), wantErr: []error{ErrMalformedBlobTx, ErrTrailingData}},
+		{name: "trailing garbage", tx: append(bytes.Clone(valid), 0xff), wantErr: []error{ErrMalformedBlobTx, ErrTrailingData}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseBlobTx(tc.tx)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, []byte("tx"), got.Tx)
+				return
+			}
+			assert.Nil(t, got)
+			for _, wantErr := range tc.wantErr {
+				assert.ErrorIs(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func FuzzParseBlobTx(f *testing.F) {
+	ns := namespace.MustNewV0([]byte("test"))
+	valid, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0), New(ns, []byte{2, 3}, 0))
+	require.NoError(f, err)
+	f.Add(valid)
+	f.Add(valid[:len(valid)-1])
+	f.Add(append(bytes.Clone(valid), 0x78, 0x01))
+	f.Add([]byte("not a blob tx"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, tx []byte) {
+		got, err := ParseBlobTx(tx)
+		notBlobTx := errors.Is(err, ErrNotBlobTx)
+		malformed := errors.Is(err, ErrMalformedBlobTx)
+		if err == nil {
+			require.NoError(t, got.Validate())
+			return
+		}
+		require.Nil(t, got)
+		require.True(t, notBlobTx != malformed, "error %v must be exactly one of ErrNotBlobTx and ErrMalformedBlobTx", err)
+	})
+}
+
+func TestValidateIndexWrapper(t *testing.T) {
+	valid := func() *IndexWrapper {
+		return &IndexWrapper{Tx: []byte("tx"), ShareIndexes: []uint32{9, 4}, TypeId: ProtoIndexWrapperTypeID}
+	}
+	testCases := []struct {
+		name    string
+		mutate  func(iw *IndexWrapper)
+		wantErr error
+	}{
+		{name: "valid", mutate: func(*IndexWrapper) {}},
+		{name: "empty tx", mutate: func(iw *IndexWrapper) { iw.Tx = nil }, wantErr: ErrEmptyIndexWrapperTx},
+		{name: "no share indexes", mutate: func(iw *IndexWrapper) { iw.ShareIndexes = nil }, wantErr: ErrNoShareIndexes},
+		{name: "duplicate share index", mutate: func(iw *IndexWrapper) { iw.ShareIndexes[1] = 9 }, wantErr: ErrDuplicateShareIndex},
+		{name: "out of range", mutate: func(iw *IndexWrapper) { iw.ShareIndexes[0] = 16 }, wantErr: ErrShareIndexOutOfRange},
+		{name: "too many share indexes", mutate: func(iw *IndexWrapper) { iw.ShareIndexes = append(iw.ShareIndexes, 5) }, wantErr: ErrShareIndexCountMismatch},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			iw := valid()
+			tc.mutate(iw)
+			err := ValidateIndexWrapper(iw, 4, 2)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+	assert.Error(t, ValidateIndexWrapper(nil, 4, 2))
+	assert.Error(t, ValidateIndexWrapper(&IndexWrapper{Tx: []byte("tx"), ShareIndexes: []uint32{1}}, 4, 1))
+}
+
+func TestWrapTxWithIndexes(t *testing.T) {
+	rawTx, err := WrapTxWithIndexes([]byte("tx"), []uint32{3, 1})
+	require.NoError(t, err)
+	iw, isIndexWrapper := UnmarshalIndexWrapper(rawTx)
+	require.True(t, isIndexWrapper)
+	assert.Equal(t, []byte("tx"), iw.Tx)
+	assert.Equal(t, []uint32{3, 1}, iw.ShareIndexes)
+
+	_, err = WrapTxWithIndexes(nil, []uint32{1})
+	assert.ErrorIs(t, err, ErrEmptyIndexWrapperTx)
+	_, err = WrapTxWithIndexes([]byte("tx"), []uint32{1, 1})
+	assert.ErrorIs(t, err, ErrDuplicateShareIndex)
+
+	// malformed index wrappers can still be marshalled but aren't recognised
+	for _, shareIndexes := range [][]uint32{nil, {1, 1}} {
+		rawTx, err := MarshalIndexWrapper([]byte("tx"), shareIndexes...)
+		require.NoError(t, err)
+		_, isIndexWrapper := UnmarshalIndexWrapper(rawTx)
+		assert.False(t, isIndexWrapper)
+	}
+}
+
+func TestBlobEqualAndCompare(t *testing.T) {
+	ns1 := namespace.MustNewV0([]byte("ns1"))
+	ns2 := namespace.MustNewV0([]byte("ns2"))
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+	base := func() *Blob {
+		b := New(ns1, []byte{1, 2}, 1)
+		b.Signer = signer
+		return b
+	}
+
+	testCases := []struct {
+		name string
+		b    *Blob
+		want int
+	}{
+		{name: "equal", b: base(), want: 0},
+		{name: "higher namespace with lower data", b: func() *Blob { b := base(); b.NamespaceId = ns2.ID; b.Data = []byte{0}; return b }(), want: 1},
+		{name: "lower data", b: func() *Blob { b := base(); b.Data = []byte{1, 1}; return b }(), want: -1},
+		{name: "shorter data", b: func() *Blob { b := base(); b.Data = []byte{1}; return b }(), want: -1},
+		{name: "lower share version", b: func() *Blob { b := base(); b.ShareVersion = 0; b.Signer = nil; return b }(), want: -1},
+		{name: "higher signer", b: func() *Blob { b := base(); b.Signer = bytes.Repeat([]byte{2}, SignerSize); return b }(), want: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, CompareBlobs(tc.b, base()))
+			assert.Equal(t, -tc.want, CompareBlobs(base(), tc.b))
+			assert.Equal(t, tc.want == 0, tc.b.Equal(base()))
+			assert.Equal(t, tc.want == 0, base().Equal(tc.b))
+		})
+	}
+
+	t.Run("nil and empty data are equal", func(t *testing.T) {
+		assert.True(t, New(ns1, nil, 0).Equal(New(ns1, []byte{}, 0)))
+		assert.Equal(t, 0, CompareBlobs(New(ns1, nil, 0), New(ns1, []byte{}, 0)))
+	})
+	t.Run("nil blobs", func(t *testing.T) {
+		var nilBlob *Blob
+		assert.True(t, nilBlob.Equal(nil))
+		assert.False(t, nilBlob.Equal(base()))
+		assert.False(t, base().Equal(nil))
+	})
+	t.Run("consistent with Sort", func(t *testing.T) {
+		blobs := []*Blob{New(ns2, []byte{0}, 0), New(ns1, []byte{2}, 0), New(ns1, []byte{1}, 0)}
+		slices.SortFunc(blobs, CompareBlobs)
+		assert.True(t, IsSorted(blobs))
+	})
+}
+
+func TestDedupBlobs(t *testing.T) {
+	ns1 := namespace.MustNewV0([]byte("ns1"))
+	ns2 := namespace.MustNewV0([]byte("ns2"))
+	a := New(ns1, []byte{1}, 0)
+	b := New(ns2, []byte{1}, 0)
+	c := New(ns1, []byte{2}, 0)
+	blobs := []*Blob{a, b, New(ns1, []byte{1}, 0), c, nil, New(ns2, []byte{1}, 0), nil}
+
+	deduped := DedupBlobs(blobs)
+	assert.Equal(t, []*Blob{a, b, c, nil}, deduped)
+	assert.Len(t, blobs, 7)
+	assert.Empty(t, DedupBlobs(nil))
+}
+
+func BenchmarkBlobEqual(b *testing.B) {
+	ns1 := namespace.MustNewV0([]byte("ns1"))
+	ns2 := namespace.MustNewV0([]byte("ns2"))
+	data := bytes.Repeat([]byte{1}, 8*1024*1024)
+	blob := New(ns1, data, 0)
+
+	benchmarks := []struct {
+		name  string
+		other *Blob
+	}{
+		{name: "equal", other: New(ns1, bytes.Clone(data), 0)},
+		{name: "different length", other: New(ns1, data[1:], 0)},
+		{name: "different namespace", other: New(ns2, data, 0)},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				blob.Equal(bm.other)
+			}
+		})
+	}
+}
+
+func TestBlobCBORRoundTrip(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	b := New(ns, []byte{1, 2, 3}, 0)
+
+	encoded, err := b.MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"a3646461746143010203696e616d657370616365581d00000000000000000000000000000000000000000000000000746573746d73686172655f76657273696f6e00",
+		hex.EncodeToString(encoded))
+
+	decoded := &Blob{}
+	require.NoError(t, decoded.UnmarshalCBOR(encoded))
+	assert.Equal(t, b, decoded)
+}
+
+// TestCBORGolden pins the exact CBOR encoding of blobs and blob transactions,
+// as hex, so that clients in other languages can match it. Run the test with
+// -update to rewrite the golden files after an intentional change.
+func TestCBORGolden(t *testing.T) {
+	v0, v1 := sampleBlobs(t)
+	type cborMessage interface {
+		proto.Message
+		MarshalCBOR() ([]byte, error)
+		UnmarshalCBOR([]byte) error
+	}
+	testCases := []struct {
+		file         string
+		value, empty cborMessage
+	}{
+		{file: "blob_v0.cbor.hex", value: v0, empty: &Blob{}},
+		{file: "blob_v1.cbor.hex", value: v1, empty: &Blob{}},
+		{file: "blob_tx.cbor.hex", value: &BlobTx{Tx: []byte("tx"), Blobs: []*Blob{v0, v1}, TypeId: ProtoBlobTxTypeID}, empty: &BlobTx{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			encoded, err := tc.value.MarshalCBOR()
+			require.NoError(t, err)
+			got := hex.EncodeToString(encoded) + "\n"
+
+			path := filepath.Join("testdata", tc.file)
+			if *update {
+				require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+
+			require.NoError(t, tc.empty.UnmarshalCBOR(encoded))
+			assert.True(t, proto.Equal(tc.value, tc.empty))
+		})
+	}
+}
+
+func TestBlobTxCBORErrors(t *testing.T) {
+	v0, v1 := sampleBlobs(t)
+	valid, err := (&BlobTx{Tx: []byte("tx"), Blobs: []*Blob{v0, v1}, TypeId: ProtoBlobTxTypeID}).MarshalCBOR()
+	require.NoError(t, err)
+	wrongTypeID := bytes.Replace(valid, []byte(ProtoBlobTxTypeID), []byte(ProtoIndexWrapperTypeID), 1)
+	noBlobs := cbor.AppendText(cbor.AppendText(cbor.AppendArrayHeader(cbor.AppendText(cbor.AppendBytes(cbor.AppendText(cbor.AppendMapHeader(nil, 3), "tx"), []byte("tx")), "blobs"), 0), "type_id"), ProtoBlobTxTypeID)
+	invalidNamespace := bytes.Replace(valid, v0.Namespace().Bytes(), namespace.TxNamespace.Bytes(), 1)
+
+	testCases := map[string]struct {
+		input   []byte
+		wantErr error
+	}{
+		"truncated":         {input: valid[:len(valid)-1]},
+		"trailing bytes":    {input: append(bytes.Clone(valid), 0)},
+		"unknown key":       {input: bytes.Replace(valid, []byte("type_id"), []byte("type_ix"), 1)},
+		"wrong type ID":     {input: wrongTypeID},
+		"no blobs":          {input: noBlobs, wantErr: ErrNoBlobs},
+		"invalid namespace": {input: invalidNamespace, wantErr: ErrReservedNamespace},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := (&BlobTx{}).UnmarshalCBOR(tc.input)
+			require.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+
+	_, err = (&BlobTx{Tx: []byte("tx"), TypeId: ProtoBlobTxTypeID}).MarshalCBOR()
+	assert.ErrorIs(t, err, ErrNoBlobs)
+}
+
+func TestBlobUnmarshalCBORErrors(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	valid, err := New(ns, []byte{1, 2, 3}, 0).MarshalCBOR()
+	require.NoError(t, err)
+
+	emptyData, err := hex.DecodeString(strings.Replace(hex.EncodeToString(valid), "6464617461430102036", "6464617461406", 1))
+	require.NoError(t, err)
+	invalidNamespace := bytes.Replace(valid, ns.Bytes(), bytes.Repeat([]byte{1}, namespace.NamespaceSize), 1)
+	unknownKey := bytes.Replace(valid, []byte("share_version"), []byte("share_versiox"), 1)
+
+	_, v1 := sampleBlobs(t)
+	validV1, err := v1.MarshalCBOR()
+	require.NoError(t, err)
+	signerOfV0 := bytes.Replace(validV1, []byte("share_version\x01"), []byte("share_version\x00"), 1)
+	// a share version 1 blob without the signer key
+	v1WithoutSigner := bytes.Replace(valid, []byte("share_version\x00"), []byte("share_version\x01"), 1)
+
+	testCases := map[string][]byte{
+		"truncated":                 valid[:len(valid)-1],
+		"trailing bytes":            append(append([]byte{}, valid...), 0),
+		"empty data":                emptyData,
+		"invalid namespace":         invalidNamespace,
+		"unknown key":               unknownKey,
+		"signer of share version 0": signerOfV0,
+		"share version 1 no signer": v1WithoutSigner,
+		"short signer":              bytes.Replace(validV1, append([]byte("signer\x54"), v1.Signer...), append([]byte("signer\x53"), v1.Signer[1:]...), 1),
+	}
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, (&Blob{}).UnmarshalCBOR(input))
+		})
+	}
+}
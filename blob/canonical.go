@@ -0,0 +1,154 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNonCanonicalEncoding is returned by UnmarshalBlobTxStrict when a blob
+// transaction isn't encoded the way MarshalBlobTx encodes it.
+var ErrNonCanonicalEncoding = errors.New("non-canonical blob tx encoding")
+
+// UnmarshalBlobTxStrict is like UnmarshalBlobTx but only accepts the canonical
+// encoding of a blob transaction, i.e. the bytes MarshalBlobTx returns for the
+// decoded transaction. Protobuf decoding accepts many encodings of the same
+// message, with fields out of order, padded varints, explicit zero values or
+// repeated fields, so without this check the bytes of a blob transaction, and
+// therefore its hash, can be changed without changing its content.
+//
+// It returns an error wrapping ErrNotBlobTx if UnmarshalBlobTx doesn't accept
+// tx and an error wrapping ErrNonCanonicalEncoding if tx, or one of its blobs,
+// contains unknown fields or if re-encoding the decoded transaction doesn't
+// reproduce tx.
+func UnmarshalBlobTxStrict(tx []byte) (*BlobTx, error) {
+	bTx, isBlobTx := UnmarshalBlobTx(tx)
+	if !isBlobTx {
+		return nil, ErrNotBlobTx
+	}
+	if len(bTx.ProtoReflect().GetUnknown()) != 0 {
+		return nil, fmt.Errorf("%w: unknown fields", ErrNonCanonicalEncoding)
+	}
+	for i, b := range bTx.Blobs {
+		if len(b.ProtoReflect().GetUnknown()) != 0 {
+			return nil, fmt.Errorf("%w: blob %d: unknown fields", ErrNonCanonicalEncoding, i)
+		}
+	}
+	encoded, err := proto.Marshal(bTx)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(encoded, tx) {
+		return nil, fmt.Errorf("%w: re-encoding the blob tx doesn't reproduce it", ErrNonCanonicalEncoding)
+	}
+	return bTx, nil
+}
+
+// IsCanonicalBlobTx reports whether UnmarshalBlobTxStrict accepts tx. It walks
+// the protobuf encoding of tx instead of decoding and re-encoding it, so it
+// doesn't allocate.
+func IsCanonicalBlobTx(tx []byte) bool {
+	var last protowire.Number
+	var typeID []byte
+	blobs := 0
+	for b := tx; len(b) > 0; {
+		num, typ, n := consumeCanonicalTag(b)
+		if n < 0 || typ != protowire.BytesType {
+			return false
+		}
+		// fields are encoded in field number order and only blobs repeat
+		if num < last || (num == last && num != 2) {
+			return false
+		}
+		last = num
+		b = b[n:]
+		value, n := consumeCanonicalBytes(b)
+		if n < 0 {
+			return false
+		}
+		b = b[n:]
+		switch num {
+		case 1, 3:
+			// empty fields are omitted
+			if len(value) == 0 {
+				return false
+			}
+			if num == 3 {
+				typeID = value
+			}
+		case 2:
+			if !isCanonicalBlob(value) {
+				return false
+			}
+			blobs++
+		default:
+			return false
+		}
+	}
+	return string(typeID) == ProtoBlobTxTypeID && blobs > 0
+}
+
+// isCanonicalBlob reports whether b is the canonical encoding of a blob with a
+// namespace ID of namespace.NamespaceIDSize bytes.
+func isCanonicalBlob(b []byte) bool {
+	var last protowire.Number
+	hasNamespaceID := false
+	for len(b) > 0 {
+		num, typ, n := consumeCanonicalTag(b)
+		if n < 0 || num <= last {
+			return false
+		}
+		last = num
+		b = b[n:]
+		switch {
+		case (num == 1 || num == 2 || num == 5) && typ == protowire.BytesType:
+			var value []byte
+			value, n = consumeCanonicalBytes(b)
+			if n < 0 || len(value) == 0 {
+				return false
+			}
+			if num == 1 {
+				if len(value) != namespace.NamespaceIDSize {
+					return false
+				}
+				hasNamespaceID = true
+			}
+		case (num == 3 || num == 4) && typ == protowire.VarintType:
+			var value uint64
+			value, n = protowire.ConsumeVarint(b)
+			// uint32 fields are truncated when they are decoded
+			if n != protowire.SizeVarint(value) || value == 0 || value > math.MaxUint32 {
+				return false
+			}
+		default:
+			return false
+		}
+		b = b[n:]
+	}
+	return hasNamespaceID
+}
+
+// consumeCanonicalTag is like protowire.ConsumeTag but returns a negative
+// length if the tag isn't minimally encoded.
+func consumeCanonicalTag(b []byte) (protowire.Number, protowire.Type, int) {
+	num, typ, n := protowire.ConsumeTag(b)
+	if n < 0 || n != protowire.SizeTag(num) {
+		return 0, 0, -1
+	}
+	return num, typ, n
+}
+
+// consumeCanonicalBytes is like protowire.ConsumeBytes but returns a negative
+// length if the length prefix isn't minimally encoded.
+func consumeCanonicalBytes(b []byte) ([]byte, int) {
+	value, n := protowire.ConsumeBytes(b)
+	if n < 0 || n != protowire.SizeBytes(len(value)) {
+		return nil, -1
+	}
+	return value, n
+}
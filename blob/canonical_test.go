@@ -0,0 +1,115 @@
+package blob
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// appendPaddedVarint appends v encoded with one more byte than necessary.
+func appendPaddedVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v)|0x80, 0x00)
+}
+
+func TestUnmarshalBlobTxStrict(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	v1, err := NewV1(ns, []byte{4, 5, 6}, bytes.Repeat([]byte{7}, SignerSize))
+	require.NoError(t, err)
+	canonical, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1, 2, 3}, 0), v1)
+	require.NoError(t, err)
+	encodedBlob, err := MarshalBlobTx(nil, New(ns, []byte{1, 2, 3}, 0))
+	require.NoError(t, err)
+	_, _, n := protowire.ConsumeTag(encodedBlob)
+	blob0, _ := protowire.ConsumeBytes(encodedBlob[n:])
+
+	appendBytesField := func(b []byte, num protowire.Number, v []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, v)
+	}
+	reordered := appendBytesField(nil, 3, []byte(ProtoBlobTxTypeID))
+	reordered = appendBytesField(reordered, 2, blob0)
+	reordered = appendBytesField(reordered, 1, []byte("tx"))
+
+	paddedLen := protowire.AppendTag(nil, 1, protowire.BytesType)
+	paddedLen = appendPaddedVarint(paddedLen, 2)
+	paddedLen = append(paddedLen, "tx"...)
+	paddedLen = appendBytesField(paddedLen, 2, blob0)
+	paddedLen = appendBytesField(paddedLen, 3, []byte(ProtoBlobTxTypeID))
+
+	paddedTag := appendPaddedVarint(nil, uint64(protowire.EncodeTag(1, protowire.BytesType)))
+	paddedTag = protowire.AppendBytes(paddedTag, []byte("tx"))
+	paddedTag = appendBytesField(paddedTag, 2, blob0)
+	paddedTag = appendBytesField(paddedTag, 3, []byte(ProtoBlobTxTypeID))
+
+	zeroShareVersion := protowire.AppendTag(bytes.Clone(blob0), 3, protowire.VarintType)
+	zeroShareVersion = protowire.AppendVarint(zeroShareVersion, 0)
+	explicitZero := appendBytesField(nil, 1, []byte("tx"))
+	explicitZero = appendBytesField(explicitZero, 2, zeroShareVersion)
+	explicitZero = appendBytesField(explicitZero, 3, []byte(ProtoBlobTxTypeID))
+
+	duplicateTx := appendBytesField(nil, 1, []byte("xx"))
+	duplicateTx = appendBytesField(duplicateTx, 1, []byte("tx"))
+	duplicateTx = appendBytesField(duplicateTx, 2, blob0)
+	duplicateTx = appendBytesField(duplicateTx, 3, []byte(ProtoBlobTxTypeID))
+
+	testCases := []struct {
+		name    string
+		tx      []byte
+		wantErr error
+	}{
+		{name: "canonical", tx: canonical},
+		{name: "reordered fields", tx: reordered, wantErr: ErrNonCanonicalEncoding},
+		{name: "padded length", tx: paddedLen, wantErr: ErrNonCanonicalEncoding},
+		{name: "padded tag", tx: paddedTag, wantErr: ErrNonCanonicalEncoding},
+		{name: "explicit zero share version", tx: explicitZero, wantErr: ErrNonCanonicalEncoding},
+		{name: "duplicate tx", tx: duplicateTx, wantErr: ErrNonCanonicalEncoding},
+		{name: "unknown field", tx: append(bytes.Clone(canonical), 0x78, 0x01), wantErr: ErrNonCanonicalEncoding},
+		{name: "not a blob tx", tx: []byte("not a blob tx"), wantErr: ErrNotBlobTx},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := UnmarshalBlobTxStrict(tc.tx)
+			assert.Equal(t, tc.wantErr == nil, IsCanonicalBlobTx(tc.tx))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []byte("tx"), got.Tx)
+			assert.Len(t, got.Blobs, 2)
+		})
+	}
+
+	// the non-canonical encodings decode to the same blob tx
+	for _, tx := range [][]byte{reordered, paddedLen, paddedTag, explicitZero} {
+		got, isBlobTx := UnmarshalBlobTx(tx)
+		require.True(t, isBlobTx)
+		assert.Equal(t, []byte("tx"), got.Tx)
+	}
+}
+
+func FuzzIsCanonicalBlobTx(f *testing.F) {
+	ns := namespace.MustNewV0([]byte("test"))
+	v1, err := NewV1(ns, []byte{4, 5, 6}, bytes.Repeat([]byte{7}, SignerSize))
+	require.NoError(f, err)
+	valid, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0), v1)
+	require.NoError(f, err)
+	f.Add(valid)
+	f.Add(valid[:len(valid)-1])
+	f.Add(append(bytes.Clone(valid), 0x78, 0x01))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, tx []byte) {
+		_, err := UnmarshalBlobTxStrict(tx)
+		require.Equal(t, err == nil, IsCanonicalBlobTx(tx), "strict unmarshal error: %v", err)
+	})
+}
@@ -0,0 +1,221 @@
+package blob
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/go-square/internal/cbor"
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// The CBOR map keys of an encoded blob and blob transaction in canonical
+// order, i.e. ordered by length first.
+const (
+	cborKeyData         = "data"
+	cborKeySigner       = "signer"
+	cborKeyNamespace    = "namespace"
+	cborKeyShareVersion = "share_version"
+
+	cborKeyTx     = "tx"
+	cborKeyBlobs  = "blobs"
+	cborKeyTypeID = "type_id"
+)
+
+// MarshalCBOR encodes the blob as a canonical CBOR map containing the data,
+// the signer of a share version 1 blob, the namespace (version and ID) as 29
+// raw bytes and the share version. Blobs of share version 0 have no signer
+// key. It returns an error if the blob fails Validate. It implements the
+// cbor.Marshaler interface.
+func (b *Blob) MarshalCBOR() ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return b.appendCBOR(nil), nil
+}
+
+// appendCBOR appends the encoding of the valid blob b to dst.
+func (b *Blob) appendCBOR(dst []byte) []byte {
+	if len(b.Signer) == 0 {
+		dst = cbor.AppendMapHeader(dst, 3)
+	} else {
+		dst = cbor.AppendMapHeader(dst, 4)
+	}
+	dst = cbor.AppendText(dst, cborKeyData)
+	dst = cbor.AppendBytes(dst, b.Data)
+	if len(b.Signer) != 0 {
+		dst = cbor.AppendText(dst, cborKeySigner)
+		dst = cbor.AppendBytes(dst, b.Signer)
+	}
+	dst = cbor.AppendText(dst, cborKeyNamespace)
+	dst = cbor.AppendBytes(dst, b.Namespace().Bytes())
+	dst = cbor.AppendText(dst, cborKeyShareVersion)
+	return cbor.AppendUint(dst, uint64(b.ShareVersion))
+}
+
+// UnmarshalCBOR decodes a blob encoded by MarshalCBOR. Keys must appear in
+// canonical order and unknown keys are rejected. The decoded blob must pass
+// Validate. It implements the cbor.Unmarshaler interface.
+func (b *Blob) UnmarshalCBOR(data []byte) error {
+	d := cbor.NewDecoder(data)
+	blob, err := decodeBlobCBOR(d)
+	if err != nil {
+		return err
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	b.NamespaceId = blob.NamespaceId
+	b.NamespaceVersion = blob.NamespaceVersion
+	b.Data = blob.Data
+	b.ShareVersion = blob.ShareVersion
+	b.Signer = blob.Signer
+	return nil
+}
+
+// decodeBlobCBOR reads a blob encoded by MarshalCBOR from d.
+func decodeBlobCBOR(d *cbor.Decoder) (*Blob, error) {
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{cborKeyData, cborKeyNamespace, cborKeyShareVersion}
+	switch n {
+	case 3:
+	case 4:
+		keys = []string{cborKeyData, cborKeySigner, cborKeyNamespace, cborKeyShareVersion}
+	default:
+		return nil, fmt.Errorf("expected 3 or 4 blob fields but got %d", n)
+	}
+
+	var (
+		rawData      []byte
+		rawSigner    []byte
+		rawNamespace []byte
+		shareVersion uint64
+	)
+	for _, key := range keys {
+		got, err := d.ReadText()
+		if err != nil {
+			return nil, err
+		}
+		if got != key {
+			return nil, fmt.Errorf("expected blob field %q but got %q", key, got)
+		}
+		switch key {
+		case cborKeyData:
+			rawData, err = d.ReadBytes()
+		case cborKeySigner:
+			rawSigner, err = d.ReadBytes()
+		case cborKeyNamespace:
+			rawNamespace, err = d.ReadBytes()
+		case cborKeyShareVersion:
+			shareVersion, err = d.ReadUint()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding blob field %q: %w", key, err)
+		}
+	}
+
+	ns, err := namespace.From(rawNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if shareVersion > math.MaxUint8 {
+		return nil, fmt.Errorf("share version %d can not be greater than %d", shareVersion, math.MaxUint8)
+	}
+	blob := New(ns, bytes.Clone(rawData), uint8(shareVersion))
+	if n == 4 {
+		// an empty signer is rejected by Validate rather than dropped
+		blob.Signer = append([]byte{}, rawSigner...)
+	}
+	if err := blob.Validate(); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// MarshalCBOR encodes the blob transaction as a canonical CBOR map containing
+// the transaction, an array of the blobs encoded like Blob.MarshalCBOR and the
+// type ID. It returns an error if the blob transaction fails Validate. It
+// implements the cbor.Marshaler interface.
+func (b *BlobTx) MarshalCBOR() ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	out := cbor.AppendMapHeader(nil, 3)
+	out = cbor.AppendText(out, cborKeyTx)
+	out = cbor.AppendBytes(out, b.Tx)
+	out = cbor.AppendText(out, cborKeyBlobs)
+	out = cbor.AppendArrayHeader(out, len(b.Blobs))
+	for _, blob := range b.Blobs {
+		out = blob.appendCBOR(out)
+	}
+	out = cbor.AppendText(out, cborKeyTypeID)
+	out = cbor.AppendText(out, b.TypeId)
+	return out, nil
+}
+
+// UnmarshalCBOR decodes a blob transaction encoded by MarshalCBOR. Keys must
+// appear in canonical order and unknown keys are rejected. The decoded blob
+// transaction must pass Validate. It implements the cbor.Unmarshaler
+// interface.
+func (b *BlobTx) UnmarshalCBOR(data []byte) error {
+	d := cbor.NewDecoder(data)
+	n, err := d.ReadMapHeader()
+	if err != nil {
+		return err
+	}
+	if n != 3 {
+		return fmt.Errorf("expected 3 blob tx fields but got %d", n)
+	}
+
+	blobTx := &BlobTx{}
+	for _, key := range []string{cborKeyTx, cborKeyBlobs, cborKeyTypeID} {
+		got, err := d.ReadText()
+		if err != nil {
+			return err
+		}
+		if got != key {
+			return fmt.Errorf("expected blob tx field %q but got %q", key, got)
+		}
+		switch key {
+		case cborKeyTx:
+			var tx []byte
+			tx, err = d.ReadBytes()
+			blobTx.Tx = bytes.Clone(tx)
+		case cborKeyBlobs:
+			err = decodeBlobsCBOR(d, blobTx)
+		case cborKeyTypeID:
+			blobTx.TypeId, err = d.ReadText()
+		}
+		if err != nil {
+			return fmt.Errorf("decoding blob tx field %q: %w", key, err)
+		}
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	if err := blobTx.Validate(); err != nil {
+		return err
+	}
+	b.Tx = blobTx.Tx
+	b.Blobs = blobTx.Blobs
+	b.TypeId = blobTx.TypeId
+	return nil
+}
+
+// decodeBlobsCBOR reads an array of blobs from d into the blobs of blobTx.
+func decodeBlobsCBOR(d *cbor.Decoder, blobTx *BlobTx) error {
+	n, err := d.ReadArrayHeader()
+	if err != nil {
+		return err
+	}
+	blobTx.Blobs = make([]*Blob, n)
+	for i := range blobTx.Blobs {
+		if blobTx.Blobs[i], err = decodeBlobCBOR(d); err != nil {
+			return fmt.Errorf("blob %d: %w", i, err)
+		}
+	}
+	return nil
+}
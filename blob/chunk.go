@@ -0,0 +1,108 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// The share layout constants of the shares package, which imports this
+// package, needed to compute how much data fits in a square.
+const (
+	shareSize                          = 512
+	shareInfoBytes                     = 1
+	sequenceLenBytes                   = 4
+	firstSparseShareContentSize        = shareSize - namespace.NamespaceSize - shareInfoBytes - sequenceLenBytes
+	continuationSparseShareContentSize = shareSize - namespace.NamespaceSize - shareInfoBytes
+)
+
+// MaxDataSizeInSquare returns the size in bytes of the largest blob of
+// shareVersion whose shares fill a square of squareSize rows and columns,
+// capped at math.MaxUint32, the largest blob the sequence length of its shares
+// can describe. It returns 0 if squareSize isn't positive.
+func MaxDataSizeInSquare(squareSize int, shareVersion uint8) uint64 {
+	if squareSize <= 0 {
+		return 0
+	}
+	shareCount := uint64(squareSize) * uint64(squareSize)
+	size := firstSparseShareContentSize + (shareCount-1)*continuationSparseShareContentSize
+	if shareVersion == shareVersionOne {
+		size -= SignerSize
+	}
+	return min(size, math.MaxUint32)
+}
+
+// ChunkData splits data into consecutive blobs of the provided namespace and
+// share version that hold at most maxBlobSize bytes each. Only the last blob
+// may be smaller. maxBlobSize must be positive and at most
+// MaxDataSizeInSquare of squareSizeUpperBound, so that every blob fits in a
+// square of the maximum size. Share version 1 blobs are returned without a
+// signer, the caller must set the Signer of every blob before they are valid.
+// Use JoinBlobs to recover data.
+func ChunkData(ns namespace.Namespace, data []byte, maxBlobSize int, shareVersion uint8, squareSizeUpperBound int) ([]*Blob, error) {
+	if maxBlobSize <= 0 {
+		return nil, fmt.Errorf("max blob size must be positive, got %d", maxBlobSize)
+	}
+	if squareSizeUpperBound <= 0 {
+		return nil, fmt.Errorf("square size upper bound must be positive, got %d", squareSizeUpperBound)
+	}
+	if maxSize := MaxDataSizeInSquare(squareSizeUpperBound, shareVersion); uint64(maxBlobSize) > maxSize {
+		return nil, fmt.Errorf("%w: max blob size %d exceeds the %d bytes that fit in a square of size %d", ErrDataTooLarge, maxBlobSize, maxSize, squareSizeUpperBound)
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyData
+	}
+
+	// every blob shares the namespace and share version of the first one so
+	// it is the only one that needs to be validated
+	first := New(ns, data[:min(maxBlobSize, len(data))], shareVersion)
+	if shareVersion == shareVersionOne {
+		first.Signer = make([]byte, SignerSize)
+	}
+	if err := first.Validate(); err != nil {
+		return nil, err
+	}
+
+	blobs := make([]*Blob, 0, (len(data)+maxBlobSize-1)/maxBlobSize)
+	for len(data) > 0 {
+		chunk := data[:min(maxBlobSize, len(data))]
+		blobs = append(blobs, New(ns, chunk, shareVersion))
+		data = data[len(chunk):]
+	}
+	return blobs, nil
+}
+
+// JoinBlobs returns the concatenated data of the blobs. It is the inverse of
+// ChunkData and returns an error if there are no blobs or if the blobs don't
+// all have the namespace, share version and signer of the first blob.
+func JoinBlobs(blobs []*Blob) ([]byte, error) {
+	if len(blobs) == 0 {
+		return nil, errors.New("no blobs to join")
+	}
+	if slices.Contains(blobs, nil) {
+		return nil, ErrNilBlob
+	}
+	first := blobs[0]
+	size := 0
+	for i, b := range blobs {
+		switch {
+		case !b.Namespace().Equals(first.Namespace()):
+			return nil, fmt.Errorf("blob %d has namespace %s but expected %s", i, b.Namespace(), first.Namespace())
+		case b.ShareVersion != first.ShareVersion:
+			return nil, fmt.Errorf("blob %d has share version %d but expected %d", i, b.ShareVersion, first.ShareVersion)
+		case !bytes.Equal(b.Signer, first.Signer):
+			return nil, fmt.Errorf("blob %d has a different signer than blob 0", i)
+		}
+		size += len(b.Data)
+	}
+
+	data := make([]byte, 0, size)
+	for _, b := range blobs {
+		data = append(data, b.Data...)
+	}
+	return data, nil
+}
@@ -0,0 +1,141 @@
+package blob
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkDataRoundTrip(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	const (
+		maxBlobSize = 100
+		squareSize  = 128
+	)
+
+	testCases := []struct {
+		size      int
+		wantBlobs int
+	}{
+		{size: 1, wantBlobs: 1},
+		{size: maxBlobSize - 1, wantBlobs: 1},
+		{size: maxBlobSize, wantBlobs: 1},
+		{size: maxBlobSize + 1, wantBlobs: 2},
+		{size: 2 * maxBlobSize, wantBlobs: 2},
+		{size: 2*maxBlobSize + 1, wantBlobs: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d bytes", tc.size), func(t *testing.T) {
+			data := make([]byte, tc.size)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			blobs, err := ChunkData(ns, data, maxBlobSize, shareVersionZero, squareSize)
+			require.NoError(t, err)
+			require.Len(t, blobs, tc.wantBlobs)
+			for i, b := range blobs {
+				require.NoError(t, b.Validate())
+				assert.True(t, b.Namespace().Equals(ns))
+				if i < len(blobs)-1 {
+					assert.Len(t, b.Data, maxBlobSize)
+				}
+			}
+
+			joined, err := JoinBlobs(blobs)
+			require.NoError(t, err)
+			assert.Equal(t, data, joined)
+		})
+	}
+
+	t.Run("share version 1", func(t *testing.T) {
+		data := bytes.Repeat([]byte{1}, maxBlobSize+1)
+		blobs, err := ChunkData(ns, data, maxBlobSize, shareVersionOne, squareSize)
+		require.NoError(t, err)
+		require.Len(t, blobs, 2)
+		for _, b := range blobs {
+			b.Signer = bytes.Repeat([]byte{2}, SignerSize)
+			require.NoError(t, b.Validate())
+		}
+		joined, err := JoinBlobs(blobs)
+		require.NoError(t, err)
+		assert.Equal(t, data, joined)
+	})
+}
+
+func TestChunkDataSquareSizeBound(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	const squareSize = 4
+
+	for _, shareVersion := range []uint8{shareVersionZero, shareVersionOne} {
+		t.Run(fmt.Sprintf("share version %d", shareVersion), func(t *testing.T) {
+			maxSize := int(MaxDataSizeInSquare(squareSize, shareVersion))
+			for _, size := range []int{maxSize, maxSize + 1, 2*maxSize + 1} {
+				data := bytes.Repeat([]byte{1}, size)
+				blobs, err := ChunkData(ns, data, maxSize, shareVersion, squareSize)
+				require.NoError(t, err)
+				require.Len(t, blobs, (size+maxSize-1)/maxSize)
+				joined, err := JoinBlobs(blobs)
+				require.NoError(t, err)
+				assert.Equal(t, data, joined)
+			}
+
+			_, err := ChunkData(ns, []byte{1}, maxSize+1, shareVersion, squareSize)
+			assert.ErrorIs(t, err, ErrDataTooLarge)
+		})
+	}
+	assert.Less(t, MaxDataSizeInSquare(squareSize, shareVersionOne), MaxDataSizeInSquare(squareSize, shareVersionZero))
+	assert.Equal(t, uint64(math.MaxUint32), MaxDataSizeInSquare(4096, shareVersionZero))
+	assert.Zero(t, MaxDataSizeInSquare(0, shareVersionZero))
+}
+
+func TestChunkDataErrors(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	const squareSize = 128
+
+	_, err := ChunkData(ns, []byte{1}, 0, shareVersionZero, squareSize)
+	assert.Error(t, err)
+	_, err = ChunkData(ns, []byte{1}, 10, shareVersionZero, 0)
+	assert.Error(t, err)
+	if tooLarge := uint64(math.MaxUint32) + 1; tooLarge <= math.MaxInt {
+		_, err = ChunkData(ns, []byte{1}, int(tooLarge), shareVersionZero, 4096)
+		assert.ErrorIs(t, err, ErrDataTooLarge)
+	}
+	_, err = ChunkData(ns, nil, 10, shareVersionZero, squareSize)
+	assert.ErrorIs(t, err, ErrEmptyData)
+	_, err = ChunkData(namespace.TxNamespace, []byte{1}, 10, shareVersionZero, squareSize)
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+	_, err = ChunkData(ns, []byte{1}, 10, 2, squareSize)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+}
+
+func TestJoinBlobsErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0([]byte("ns1"))
+	ns2 := namespace.MustNewV0([]byte("ns2"))
+	v1 := func(signer byte) *Blob {
+		b := New(ns1, []byte{1}, shareVersionOne)
+		b.Signer = bytes.Repeat([]byte{signer}, SignerSize)
+		return b
+	}
+
+	testCases := []struct {
+		name  string
+		blobs []*Blob
+	}{
+		{name: "no blobs", blobs: nil},
+		{name: "nil blob", blobs: []*Blob{New(ns1, []byte{1}, 0), nil}},
+		{name: "different namespaces", blobs: []*Blob{New(ns1, []byte{1}, 0), New(ns2, []byte{1}, 0)}},
+		{name: "different share versions", blobs: []*Blob{New(ns1, []byte{1}, 0), v1(1)}},
+		{name: "different signers", blobs: []*Blob{v1(1), v1(2)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := JoinBlobs(tc.blobs)
+			assert.Error(t, err)
+		})
+	}
+}
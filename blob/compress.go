@@ -0,0 +1,192 @@
+package blob
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Codec identifies the compression algorithm of a compressed blob. Its value
+// is recorded in the prefix of the data so it must never change.
+type Codec uint8
+
+const (
+	// CodecAuto makes DecompressBlob use the codec recorded in the blob. It
+	// can't be used to compress.
+	CodecAuto Codec = 0
+	// CodecGzip compresses the data with gzip (RFC 1952).
+	CodecGzip Codec = 1
+	// CodecFlate compresses the data with raw DEFLATE (RFC 1951).
+	CodecFlate Codec = 2
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecAuto:
+		return "auto"
+	case CodecGzip:
+		return "gzip"
+	case CodecFlate:
+		return "flate"
+	default:
+		return fmt.Sprintf("Codec(%d)", uint8(c))
+	}
+}
+
+// CompressionMagic starts the data of a compressed blob. The data of a
+// compressed blob is:
+//
+//	| magic (4 bytes) | codec (1 byte) | uncompressed length (4 bytes, big endian) | compressed data |
+//
+// This is a convention of the blob payload only: compressed blobs are split
+// into shares and committed to like any other blob.
+const CompressionMagic = "\xc0\x5a\xb1\x0b"
+
+// compressionPrefixLen is the length of the prefix of a compressed blob.
+const compressionPrefixLen = len(CompressionMagic) + 1 + 4
+
+var (
+	// ErrIncompressible is returned by CompressBlob when compressing the blob
+	// doesn't make it smaller.
+	ErrIncompressible = errors.New("blob data is incompressible")
+	// ErrNotCompressed is returned by DecompressBlob when the blob doesn't
+	// start with a compression prefix.
+	ErrNotCompressed = errors.New("blob data is not compressed")
+	// ErrUnsupportedCodec is returned for a codec that isn't CodecGzip or
+	// CodecFlate.
+	ErrUnsupportedCodec = errors.New("unsupported compression codec")
+	// ErrInvalidCompressedData is returned by DecompressBlob when the
+	// compressed data can't be decompressed or doesn't have the length
+	// recorded in the prefix.
+	ErrInvalidCompressedData = errors.New("invalid compressed blob data")
+)
+
+// CompressBlob returns a copy of b whose data is compressed with codec and
+// prefixed as described by CompressionMagic. The namespace, share version and
+// signer are kept. A blob that is already compressed is returned as is so
+// that retrying doesn't compress twice.
+//
+// If the compressed blob wouldn't be smaller than b, compression is skipped:
+// b is returned together with an error wrapping ErrIncompressible, so the
+// caller can submit b uncompressed. It returns an error if b fails Validate
+// or codec is not supported.
+func CompressBlob(b *Blob, codec Codec) (*Blob, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	if IsCompressed(b) {
+		return b, nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString(CompressionMagic)
+	buf.WriteByte(byte(codec))
+	buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(b.Data))))
+	w, err := newCompressor(&buf, codec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b.Data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(b.Data) {
+		return b, fmt.Errorf("%w: %d bytes compress to %d bytes with %s", ErrIncompressible, len(b.Data), buf.Len(), codec)
+	}
+	return b.withData(buf.Bytes()), nil
+}
+
+// DecompressBlob returns a copy of the compressed blob b with its original
+// data. The codec recorded in b is used; if codec is not CodecAuto it must be
+// the recorded codec. It returns an error wrapping ErrNotCompressed if b isn't
+// compressed, ErrUnsupportedCodec if the codecs don't match or aren't
+// supported and ErrInvalidCompressedData if the data can't be decompressed to
+// the recorded length.
+func DecompressBlob(b *Blob, codec Codec) (*Blob, error) {
+	if b == nil {
+		return nil, ErrNilBlob
+	}
+	if !hasCompressionPrefix(b.Data) {
+		return nil, ErrNotCompressed
+	}
+	recorded := Codec(b.Data[len(CompressionMagic)])
+	if codec != CodecAuto && codec != recorded {
+		return nil, fmt.Errorf("%w: blob is compressed with %s but %s was expected", ErrUnsupportedCodec, recorded, codec)
+	}
+	length := int64(binary.BigEndian.Uint32(b.Data[len(CompressionMagic)+1:]))
+	r, err := newDecompressor(bytes.NewReader(b.Data[compressionPrefixLen:]), recorded)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// the recorded length bounds the read so that a small blob can't expand
+	// without limit
+	data, err := io.ReadAll(io.LimitReader(r, length+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCompressedData, err)
+	}
+	if int64(len(data)) != length {
+		return nil, fmt.Errorf("%w: decompressed %d bytes but %d were recorded", ErrInvalidCompressedData, len(data), length)
+	}
+	return b.withData(data), nil
+}
+
+// IsCompressed reports whether the data of b starts with the prefix of a blob
+// compressed with a supported codec. It doesn't check that the rest of the
+// data can be decompressed.
+func IsCompressed(b *Blob) bool {
+	return b != nil && hasCompressionPrefix(b.Data)
+}
+
+func hasCompressionPrefix(data []byte) bool {
+	if len(data) < compressionPrefixLen || string(data[:len(CompressionMagic)]) != CompressionMagic {
+		return false
+	}
+	codec := Codec(data[len(CompressionMagic)])
+	return codec == CodecGzip || codec == CodecFlate
+}
+
+// withData returns a copy of b with data.
+func (b *Blob) withData(data []byte) *Blob {
+	return &Blob{
+		NamespaceId:      slices.Clone(b.NamespaceId),
+		Data:             data,
+		ShareVersion:     b.ShareVersion,
+		NamespaceVersion: b.NamespaceVersion,
+		Signer:           slices.Clone(b.Signer),
+	}
+}
+
+func newCompressor(w io.Writer, codec Codec) (io.WriteCloser, error) {
+	switch codec {
+	case CodecGzip:
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	case CodecFlate:
+		return flate.NewWriter(w, flate.BestCompression)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCodec, codec)
+	}
+}
+
+func newDecompressor(r io.Reader, codec Codec) (io.ReadCloser, error) {
+	switch codec {
+	case CodecGzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCompressedData, err)
+		}
+		// a gzip stream can hold several members but a blob holds one
+		zr.Multistream(false)
+		return zr, nil
+	case CodecFlate:
+		return flate.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCodec, codec)
+	}
+}
@@ -0,0 +1,112 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressBlob(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("rollup"))
+	json := []byte(strings.Repeat(`{"height":1,"txs":["0xdeadbeef","0xcafe"]},`, 200))
+	v1, err := NewV1(ns, json, bytes.Repeat([]byte{7}, SignerSize))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name  string
+		blob  *Blob
+		codec Codec
+	}{
+		{name: "gzip", blob: New(ns, json, 0), codec: CodecGzip},
+		{name: "flate", blob: New(ns, json, 0), codec: CodecFlate},
+		{name: "share version 1", blob: v1, codec: CodecFlate},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			compressed, err := CompressBlob(tc.blob, tc.codec)
+			require.NoError(t, err)
+			require.NoError(t, compressed.Validate())
+			assert.Less(t, len(compressed.Data), len(tc.blob.Data))
+			assert.True(t, IsCompressed(compressed))
+			assert.False(t, IsCompressed(tc.blob))
+			assert.Equal(t, tc.blob.Namespace(), compressed.Namespace())
+			assert.Equal(t, tc.blob.Signer, compressed.Signer)
+
+			// compressing again doesn't compress twice
+			again, err := CompressBlob(compressed, tc.codec)
+			require.NoError(t, err)
+			assert.Same(t, compressed, again)
+
+			for _, codec := range []Codec{CodecAuto, tc.codec} {
+				decompressed, err := DecompressBlob(compressed, codec)
+				require.NoError(t, err)
+				assert.True(t, tc.blob.Equal(decompressed))
+			}
+		})
+	}
+}
+
+func TestCompressBlobIncompressible(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("rollup"))
+	data := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(data)
+	b := New(ns, data, 0)
+
+	for _, codec := range []Codec{CodecGzip, CodecFlate} {
+		got, err := CompressBlob(b, codec)
+		assert.ErrorIs(t, err, ErrIncompressible)
+		// the uncompressed blob is returned so it can be submitted as is
+		assert.Same(t, b, got)
+	}
+}
+
+func TestCompressionPrefix(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("rollup"))
+	compressed, err := CompressBlob(New(ns, bytes.Repeat([]byte{'a'}, 1000), 0), CodecFlate)
+	require.NoError(t, err)
+	// the prefix is part of the payload format and must not change
+	assert.Equal(t, "c05ab10b02000003e8", hex.EncodeToString(compressed.Data[:compressionPrefixLen]))
+}
+
+func TestDecompressBlobErrors(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("rollup"))
+	compressed, err := CompressBlob(New(ns, bytes.Repeat([]byte{'a'}, 1000), 0), CodecGzip)
+	require.NoError(t, err)
+	withData := func(data []byte) *Blob {
+		return compressed.withData(data)
+	}
+	wrongLength := bytes.Clone(compressed.Data)
+	wrongLength[compressionPrefixLen-1]++
+	unknownCodec := bytes.Clone(compressed.Data)
+	unknownCodec[len(CompressionMagic)] = 3
+
+	testCases := []struct {
+		name    string
+		blob    *Blob
+		codec   Codec
+		wantErr error
+	}{
+		{name: "nil blob", blob: nil, wantErr: ErrNilBlob},
+		{name: "not compressed", blob: New(ns, []byte("plain"), 0), wantErr: ErrNotCompressed},
+		{name: "unknown codec", blob: withData(unknownCodec), wantErr: ErrNotCompressed},
+		{name: "codec mismatch", blob: compressed, codec: CodecFlate, wantErr: ErrUnsupportedCodec},
+		{name: "wrong length", blob: withData(wrongLength), wantErr: ErrInvalidCompressedData},
+		{name: "truncated", blob: withData(compressed.Data[:len(compressed.Data)-4]), wantErr: ErrInvalidCompressedData},
+		{name: "corrupt", blob: withData(append(compressed.Data[:compressionPrefixLen:compressionPrefixLen], 0xff, 0xff)), wantErr: ErrInvalidCompressedData},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecompressBlob(tc.blob, tc.codec)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Nil(t, got)
+		})
+	}
+
+	_, err = CompressBlob(New(ns, []byte("data"), 0), CodecAuto)
+	assert.ErrorIs(t, err, ErrUnsupportedCodec)
+}
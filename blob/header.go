@@ -0,0 +1,228 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrDuplicateField is returned by ParseBlobTxHeader when a field that isn't
+// repeated occurs more than once in a blob transaction or one of its blobs.
+var ErrDuplicateField = errors.New("duplicate field")
+
+// BlobTxHeader is the metadata of an encoded blob transaction. It is returned
+// by ParseBlobTxHeader and references the encoded transaction instead of
+// copying it, so the encoded transaction must not be modified while the
+// header is in use.
+type BlobTxHeader struct {
+	// Tx is the transaction paying for the blobs.
+	Tx []byte
+	// Blobs are the metadata of the blobs in the order of the transaction.
+	Blobs []BlobHeader
+
+	raw []byte
+}
+
+// BlobHeader is the metadata of a blob of an encoded blob transaction.
+type BlobHeader struct {
+	Namespace    namespace.Namespace
+	ShareVersion uint8
+	// Signer is nil for share version 0 blobs.
+	Signer []byte
+	// DataOffset is the index of the first byte of the data of the blob in
+	// the encoded blob transaction and DataLen the length of the data.
+	DataOffset int
+	DataLen    int
+}
+
+// ParseBlobTxHeader is like ParseBlobTx but doesn't decode the data of the
+// blobs. It walks the protobuf encoding of tx and records where the data of
+// every blob is located in tx, so inspecting the namespaces, share versions,
+// signers and data sizes of a blob transaction doesn't copy its payload. Use
+// BlobTxHeader.Blob to decode a blob.
+//
+// It returns the errors of ParseBlobTx for the same inputs, with an error
+// wrapping ErrMalformedBlobTx and ErrDuplicateField if a field that isn't
+// repeated occurs more than once, which ParseBlobTx accepts.
+func ParseBlobTxHeader(tx []byte) (BlobTxHeader, error) {
+	if err := checkBlobTxTypeID(tx); err != nil {
+		return BlobTxHeader{}, err
+	}
+
+	header := BlobTxHeader{raw: tx}
+	var hasTx, hasTypeID bool
+	for b := tx; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			if hasTx {
+				return BlobTxHeader{}, fmt.Errorf("%w: %w: tx", ErrMalformedBlobTx, ErrDuplicateField)
+			}
+			header.Tx, n = protowire.ConsumeBytes(b)
+			hasTx = true
+		case num == 2 && typ == protowire.BytesType:
+			var encoded []byte
+			encoded, n = protowire.ConsumeBytes(b)
+			offset := len(tx) - len(b) + n - len(encoded)
+			blob, err := parseBlobHeader(encoded, offset)
+			if err != nil {
+				return BlobTxHeader{}, fmt.Errorf("%w: invalid blob %d: %w", ErrMalformedBlobTx, len(header.Blobs), err)
+			}
+			header.Blobs = append(header.Blobs, blob)
+		case num == 3 && typ == protowire.BytesType:
+			if hasTypeID {
+				return BlobTxHeader{}, fmt.Errorf("%w: %w: type ID", ErrMalformedBlobTx, ErrDuplicateField)
+			}
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			hasTypeID = true
+		default:
+			return BlobTxHeader{}, fmt.Errorf("%w: %w: field %d", ErrMalformedBlobTx, ErrTrailingData, num)
+		}
+		// the fields were checked by checkBlobTxTypeID
+		b = b[n:]
+	}
+	if len(header.Blobs) == 0 {
+		return BlobTxHeader{}, fmt.Errorf("%w: %w", ErrMalformedBlobTx, ErrNoBlobs)
+	}
+	return header, nil
+}
+
+// checkBlobTxTypeID returns an error wrapping ErrNotBlobTx if tx doesn't have
+// the type ID ProtoBlobTxTypeID and an error wrapping ErrMalformedBlobTx if
+// it has but isn't a sequence of well formed protobuf fields.
+func checkBlobTxTypeID(tx []byte) error {
+	var typeID []byte
+	n := 0
+	for b := tx; len(b) > 0; {
+		num, typ, tagLen := protowire.ConsumeTag(b)
+		if tagLen < 0 {
+			n = tagLen
+			break
+		}
+		if num == 3 && typ == protowire.BytesType {
+			// the last occurrence takes precedence when tx is decoded
+			var id []byte
+			id, n = protowire.ConsumeBytes(b[tagLen:])
+			if n >= 0 {
+				typeID = id
+			}
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b[tagLen:])
+		}
+		if n < 0 {
+			break
+		}
+		b = b[tagLen+n:]
+	}
+	if string(typeID) != ProtoBlobTxTypeID {
+		return fmt.Errorf("%w: type ID %q", ErrNotBlobTx, typeID)
+	}
+	if n < 0 {
+		return fmt.Errorf("%w: %w: %w", ErrMalformedBlobTx, ErrTrailingData, protowire.ParseError(n))
+	}
+	return nil
+}
+
+// parseBlobHeader returns the metadata of the encoded Blob message blob, which
+// starts at offset in the encoded blob transaction.
+func parseBlobHeader(blob []byte, offset int) (BlobHeader, error) {
+	var (
+		header                  BlobHeader
+		shareVersion, nsVersion uint64
+		seen                    [6]bool
+		nsID, signer            []byte
+	)
+	for b := blob; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return BlobHeader{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num < 1 || num > 5 {
+			return BlobHeader{}, fmt.Errorf("%w: field %d", ErrTrailingData, num)
+		}
+		if seen[num] {
+			return BlobHeader{}, fmt.Errorf("%w: field %d", ErrDuplicateField, num)
+		}
+		seen[num] = true
+		wantTyp := protowire.BytesType
+		if num == 3 || num == 4 {
+			wantTyp = protowire.VarintType
+		}
+		if typ != wantTyp {
+			return BlobHeader{}, fmt.Errorf("field %d has wire type %d but expected %d", num, typ, wantTyp)
+		}
+		switch num {
+		case 1:
+			nsID, n = protowire.ConsumeBytes(b)
+		case 2:
+			var data []byte
+			data, n = protowire.ConsumeBytes(b)
+			header.DataOffset = offset + len(blob) - len(b) + n - len(data)
+			header.DataLen = len(data)
+		case 3:
+			shareVersion, n = protowire.ConsumeVarint(b)
+		case 4:
+			nsVersion, n = protowire.ConsumeVarint(b)
+		case 5:
+			signer, n = protowire.ConsumeBytes(b)
+		}
+		if n < 0 {
+			return BlobHeader{}, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	if shareVersion > math.MaxUint32 || nsVersion > math.MaxUint32 {
+		return BlobHeader{}, fmt.Errorf("varint of share version %d or namespace version %d overflows uint32", shareVersion, nsVersion)
+	}
+
+	// validate the metadata like Blob.Validate, without the data
+	b := Blob{
+		NamespaceId:      nsID,
+		ShareVersion:     uint32(shareVersion),
+		NamespaceVersion: uint32(nsVersion),
+		Signer:           signer,
+	}
+	if err := b.validate(true); err != nil {
+		return BlobHeader{}, err
+	}
+	if header.DataLen == 0 {
+		return BlobHeader{}, ErrEmptyData
+	}
+	if uint64(header.DataLen) > math.MaxUint32 {
+		return BlobHeader{}, fmt.Errorf("%w: %d bytes exceeds the maximum sequence length of %d bytes", ErrDataTooLarge, header.DataLen, uint32(math.MaxUint32))
+	}
+	header.Namespace = b.Namespace()
+	header.ShareVersion = uint8(shareVersion)
+	header.Signer = signer
+	return header, nil
+}
+
+// Data returns the data of the blob at index i without copying it. It returns
+// an error wrapping ErrBlobIndexOutOfRange if there is no such blob.
+func (h BlobTxHeader) Data(i int) ([]byte, error) {
+	if i < 0 || i >= len(h.Blobs) {
+		return nil, fmt.Errorf("%w: index %d, blob tx has %d blobs", ErrBlobIndexOutOfRange, i, len(h.Blobs))
+	}
+	blob := h.Blobs[i]
+	return h.raw[blob.DataOffset : blob.DataOffset+blob.DataLen : blob.DataOffset+blob.DataLen], nil
+}
+
+// Blob decodes the blob at index i. The blob doesn't reference the encoded
+// blob transaction. It returns an error wrapping ErrBlobIndexOutOfRange if
+// there is no such blob.
+func (h BlobTxHeader) Blob(i int) (*Blob, error) {
+	data, err := h.Data(i)
+	if err != nil {
+		return nil, err
+	}
+	header := h.Blobs[i]
+	blob := New(namespace.Namespace{Version: header.Namespace.Version, ID: bytes.Clone(header.Namespace.ID)}, bytes.Clone(data), header.ShareVersion)
+	blob.Signer = bytes.Clone(header.Signer)
+	return blob, nil
+}
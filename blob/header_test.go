@@ -0,0 +1,175 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseBlobTxHeader(t *testing.T) {
+	ns1 := namespace.MustNewV0([]byte("test1"))
+	ns2 := namespace.MustNewV0([]byte("test2"))
+	v1, err := NewV1(ns2, bytes.Repeat([]byte{2}, 1000), bytes.Repeat([]byte{5}, SignerSize))
+	require.NoError(t, err)
+	blobs := []*Blob{New(ns1, []byte{1, 2, 3}, 0), v1}
+	rawTx, err := MarshalBlobTx([]byte("tx"), blobs...)
+	require.NoError(t, err)
+
+	header, err := ParseBlobTxHeader(rawTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("tx"), header.Tx)
+	require.Len(t, header.Blobs, len(blobs))
+	for i, want := range blobs {
+		got := header.Blobs[i]
+		assert.Equal(t, want.Namespace(), got.Namespace)
+		assert.Equal(t, uint8(want.ShareVersion), got.ShareVersion)
+		assert.Equal(t, want.Signer, got.Signer)
+		assert.Equal(t, len(want.Data), got.DataLen)
+		assert.Equal(t, want.Data, rawTx[got.DataOffset:got.DataOffset+got.DataLen])
+
+		data, err := header.Data(i)
+		require.NoError(t, err)
+		assert.Equal(t, want.Data, data)
+		blob, err := header.Blob(i)
+		require.NoError(t, err)
+		assert.True(t, want.Equal(blob), "blob %d", i)
+	}
+
+	// the header references the encoded tx but decoded blobs don't
+	blob, err := header.Blob(0)
+	require.NoError(t, err)
+	data, err := header.Data(0)
+	require.NoError(t, err)
+	rawTx[header.Blobs[0].DataOffset] = 0xFF
+	assert.Equal(t, byte(0xFF), data[0])
+	assert.Equal(t, []byte{1, 2, 3}, blob.Data)
+
+	for _, i := range []int{-1, 2} {
+		_, err := header.Blob(i)
+		assert.ErrorIs(t, err, ErrBlobIndexOutOfRange)
+		_, err = header.Data(i)
+		assert.ErrorIs(t, err, ErrBlobIndexOutOfRange)
+	}
+}
+
+func TestParseBlobTxHeaderErrors(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	field := func(b []byte, num protowire.Number, value []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, value)
+	}
+	varint := func(b []byte, num protowire.Number, value uint64) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, value)
+	}
+	blob := field(field(nil, 1, ns.ID), 2, []byte{1})
+	typeID := field(nil, 3, []byte(ProtoBlobTxTypeID))
+	blobTx := func(fields ...[]byte) []byte {
+		return bytes.Join(fields, nil)
+	}
+
+	testCases := []struct {
+		name    string
+		tx      []byte
+		wantErr []error
+	}{
+		{name: "empty", tx: nil, wantErr: []error{ErrNotBlobTx}},
+		{name: "not a proto message", tx: []byte{0xff, 0xff}, wantErr: []error{ErrNotBlobTx}},
+		{name: "wrong type ID", tx: blobTx(field(nil, 1, []byte("tx")), field(nil, 2, blob), field(nil, 3, []byte("INDX"))), wantErr: []error{ErrNotBlobTx}},
+		{name: "no blobs", tx: blobTx(field(nil, 1, []byte("tx")), typeID), wantErr: []error{ErrMalformedBlobTx, ErrNoBlobs}},
+		{
+			name:    "truncated blob",
+			tx:      blobTx(typeID, field(nil, 2, blob)[:len(blob)]),
+			wantErr: []error{ErrMalformedBlobTx},
+		},
+		{
+			name:    "truncated field of a blob",
+			tx:      blobTx(field(nil, 2, append(field(nil, 1, ns.ID), 0x12, 0x05, 1)), typeID),
+			wantErr: []error{ErrMalformedBlobTx},
+		},
+		{name: "trailing field", tx: blobTx(field(nil, 2, blob), typeID, []byte{0x78, 0x01}), wantErr: []error{ErrMalformedBlobTx, ErrTrailingData}},
+		{name: "trailing garbage", tx: blobTx(field(nil, 2, blob), typeID, []byte{0xff}), wantErr: []error{ErrMalformedBlobTx, ErrTrailingData}},
+		{name: "unknown field of a blob", tx: blobTx(field(nil, 2, field(blob, 6, nil)), typeID), wantErr: []error{ErrMalformedBlobTx, ErrTrailingData}},
+		{name: "duplicate tx", tx: blobTx(field(nil, 1, []byte("tx")), field(nil, 1, []byte("tx")), field(nil, 2, blob), typeID), wantErr: []error{ErrMalformedBlobTx, ErrDuplicateField}},
+		{name: "duplicate type ID", tx: blobTx(typeID, field(nil, 2, blob), typeID), wantErr: []error{ErrMalformedBlobTx, ErrDuplicateField}},
+		{name: "duplicate data", tx: blobTx(field(nil, 2, field(blob, 2, []byte{2})), typeID), wantErr: []error{ErrMalformedBlobTx, ErrDuplicateField}},
+		{name: "duplicate share version", tx: blobTx(field(nil, 2, varint(varint(blob, 3, 0), 3, 0)), typeID), wantErr: []error{ErrMalformedBlobTx, ErrDuplicateField}},
+		{name: "wrong wire type", tx: blobTx(field(nil, 2, field(blob, 3, []byte{0})), typeID), wantErr: []error{ErrMalformedBlobTx}},
+		{name: "share version overflows uint32", tx: blobTx(field(nil, 2, varint(blob, 3, 1<<32)), typeID), wantErr: []error{ErrMalformedBlobTx}},
+		{name: "empty namespace", tx: blobTx(field(nil, 2, field(nil, 2, []byte{1})), typeID), wantErr: []error{ErrMalformedBlobTx, ErrInvalidNamespace}},
+		{name: "empty data", tx: blobTx(field(nil, 2, field(nil, 1, ns.ID)), typeID), wantErr: []error{ErrMalformedBlobTx, ErrEmptyData}},
+		{name: "unsupported share version", tx: blobTx(field(nil, 2, varint(blob, 3, 2)), typeID), wantErr: []error{ErrMalformedBlobTx, ErrUnsupportedShareVersion}},
+		{name: "share version 1 without signer", tx: blobTx(field(nil, 2, varint(blob, 3, 1)), typeID), wantErr: []error{ErrMalformedBlobTx, ErrInvalidSigner}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseBlobTxHeader(tc.tx)
+			require.Error(t, err)
+			for _, wantErr := range tc.wantErr {
+				assert.ErrorIs(t, err, wantErr)
+			}
+		})
+	}
+}
+
+func FuzzParseBlobTxHeader(f *testing.F) {
+	ns := namespace.MustNewV0([]byte("test"))
+	valid, err := MarshalBlobTx([]byte("tx"), New(ns, []byte{1}, 0), New(ns, []byte{2, 3}, 0))
+	require.NoError(f, err)
+	f.Add(valid)
+	f.Add(valid[:len(valid)-1])
+	f.Add(append(bytes.Clone(valid), 0x78, 0x01))
+	f.Add([]byte("not a blob tx"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, tx []byte) {
+		header, err := ParseBlobTxHeader(tx)
+		blobTx, parseErr := ParseBlobTx(tx)
+		if err != nil {
+			require.True(t, errors.Is(err, ErrNotBlobTx) != errors.Is(err, ErrMalformedBlobTx), "error %v must be exactly one of ErrNotBlobTx and ErrMalformedBlobTx", err)
+			// ParseBlobTx accepts duplicate fields
+			if !errors.Is(err, ErrDuplicateField) {
+				require.Error(t, parseErr)
+			}
+			return
+		}
+		require.NoError(t, parseErr)
+		require.Equal(t, blobTx.Tx, header.Tx)
+		require.Len(t, header.Blobs, len(blobTx.Blobs))
+		for i, want := range blobTx.Blobs {
+			got, err := header.Blob(i)
+			require.NoError(t, err)
+			require.True(t, want.Equal(got), "blob %d", i)
+		}
+	})
+}
+
+func BenchmarkParseBlobTxHeader(b *testing.B) {
+	ns := namespace.MustNewV0([]byte("test"))
+	blobs := make([]*Blob, 4)
+	for i := range blobs {
+		blobs[i] = New(ns, bytes.Repeat([]byte{byte(i)}, 512*1024), 0)
+	}
+	rawTx, err := MarshalBlobTx(bytes.Repeat([]byte{0xAB}, 300), blobs...)
+	require.NoError(b, err)
+
+	b.Run("header", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := ParseBlobTxHeader(rawTx)
+			require.NoError(b, err)
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := ParseBlobTx(rawTx)
+			require.NoError(b, err)
+		}
+	})
+}
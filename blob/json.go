@@ -0,0 +1,135 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// blobJSON is the JSON form of a blob. The namespace is encoded as 0x-prefixed
+// hex of its version and ID, the data as base64 and the signer, which is only
+// present for share version 1 blobs, as 0x-prefixed hex.
+type blobJSON struct {
+	Namespace    namespace.Namespace `json:"namespace"`
+	Data         []byte              `json:"data"`
+	ShareVersion *uint32             `json:"share_version"`
+	Signer       string              `json:"signer,omitempty"`
+}
+
+// blobTxJSON is the JSON form of a blob transaction. The transaction is
+// encoded as base64.
+type blobTxJSON struct {
+	Tx     []byte  `json:"tx"`
+	Blobs  []*Blob `json:"blobs"`
+	TypeID string  `json:"type_id"`
+}
+
+// MarshalJSON encodes a valid blob as a JSON object with the fields
+// namespace, data, share_version and, for share version 1 blobs, signer. It
+// implements the json.Marshaler interface.
+func (b *Blob) MarshalJSON() ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	shareVersion := b.ShareVersion
+	out := blobJSON{
+		Namespace:    b.Namespace(),
+		Data:         b.Data,
+		ShareVersion: &shareVersion,
+	}
+	if len(b.Signer) > 0 {
+		out.Signer = "0x" + hex.EncodeToString(b.Signer)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a blob encoded by MarshalJSON and validates it like
+// Validate. Unknown fields are rejected so that a misspelled field doesn't
+// silently leave the blob incomplete. It implements the json.Unmarshaler
+// interface.
+func (b *Blob) UnmarshalJSON(data []byte) error {
+	var in blobJSON
+	if err := decodeJSONStrict(data, &in); err != nil {
+		return fmt.Errorf("decoding blob JSON: %w", err)
+	}
+	if in.ShareVersion == nil {
+		return errors.New("blob JSON must contain a share_version")
+	}
+	var signer []byte
+	if in.Signer != "" {
+		rawSigner, ok := strings.CutPrefix(in.Signer, "0x")
+		if !ok {
+			return fmt.Errorf("%w: signer %q must be 0x-prefixed hex", ErrInvalidSigner, in.Signer)
+		}
+		var err error
+		signer, err = hex.DecodeString(rawSigner)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSigner, err)
+		}
+	}
+	blob := &Blob{
+		NamespaceId:      in.Namespace.ID,
+		NamespaceVersion: uint32(in.Namespace.Version),
+		Data:             in.Data,
+		ShareVersion:     *in.ShareVersion,
+		Signer:           signer,
+	}
+	if err := blob.Validate(); err != nil {
+		return err
+	}
+	b.NamespaceId = blob.NamespaceId
+	b.NamespaceVersion = blob.NamespaceVersion
+	b.Data = blob.Data
+	b.ShareVersion = blob.ShareVersion
+	b.Signer = blob.Signer
+	return nil
+}
+
+// MarshalJSON encodes the blob transaction as a JSON object with the fields
+// tx, blobs and type_id. Every blob must be valid. It implements the
+// json.Marshaler interface.
+func (b *BlobTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(blobTxJSON{
+		Tx:     b.Tx,
+		Blobs:  b.Blobs,
+		TypeID: b.TypeId,
+	})
+}
+
+// UnmarshalJSON decodes a blob transaction encoded by MarshalJSON. Like
+// UnmarshalBlobTx it requires the type ID to be ProtoBlobTxTypeID and at least
+// one blob, and every blob is validated. Unknown fields are rejected. It
+// implements the json.Unmarshaler interface.
+func (b *BlobTx) UnmarshalJSON(data []byte) error {
+	var in blobTxJSON
+	if err := decodeJSONStrict(data, &in); err != nil {
+		return fmt.Errorf("decoding blob tx JSON: %w", err)
+	}
+	if in.TypeID != ProtoBlobTxTypeID {
+		return fmt.Errorf("blob tx JSON has type_id %q but expected %q", in.TypeID, ProtoBlobTxTypeID)
+	}
+	if len(in.Blobs) == 0 {
+		return errors.New("blob tx JSON must contain at least one blob")
+	}
+	for i, blob := range in.Blobs {
+		if blob == nil {
+			return fmt.Errorf("blob %d: %w", i, ErrNilBlob)
+		}
+	}
+	b.Tx = in.Tx
+	b.Blobs = in.Blobs
+	b.TypeId = in.TypeID
+	return nil
+}
+
+// decodeJSONStrict decodes data into v and rejects unknown fields.
+func decodeJSONStrict(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
@@ -0,0 +1,123 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func sampleBlobs(t *testing.T) (v0, v1 *Blob) {
+	ns := namespace.MustNewV0([]byte("sample"))
+	v0 = New(ns, []byte("hello, world"), shareVersionZero)
+	v1, err := NewV1(ns, []byte{0, 1, 2, 3, 0xfe, 0xff}, bytes.Repeat([]byte{0xab}, SignerSize))
+	require.NoError(t, err)
+	return v0, v1
+}
+
+// TestJSONGolden pins the exact JSON encoding of blobs and blob transactions
+// so that clients in other languages can match it. Run the test with -update
+// to rewrite the golden files after an intentional change.
+func TestJSONGolden(t *testing.T) {
+	v0, v1 := sampleBlobs(t)
+	testCases := []struct {
+		file  string
+		value any
+		empty func() any
+	}{
+		{file: "blob_v0.json", value: v0, empty: func() any { return &Blob{} }},
+		{file: "blob_v1.json", value: v1, empty: func() any { return &Blob{} }},
+		{file: "blob_tx.json", value: &BlobTx{Tx: []byte("tx"), Blobs: []*Blob{v0, v1}, TypeId: ProtoBlobTxTypeID}, empty: func() any { return &BlobTx{} }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			got, err := json.MarshalIndent(tc.value, "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", tc.file)
+			if *update {
+				require.NoError(t, os.WriteFile(path, got, 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+
+			decoded := tc.empty()
+			require.NoError(t, json.Unmarshal(want, decoded))
+			assert.True(t, proto.Equal(tc.value.(proto.Message), decoded.(proto.Message)))
+		})
+	}
+}
+
+func TestBlobUnmarshalJSONErrors(t *testing.T) {
+	valid := `"namespace":"0x000000000000000000000000000000000000000000000073616d706c65","data":"aGVsbG8=","share_version":0`
+	testCases := []struct {
+		name    string
+		json    string
+		wantErr error
+	}{
+		{name: "unknown field", json: `{` + valid + `,"namesapce":"0x00"}`},
+		{name: "missing namespace", json: `{"data":"aGVsbG8=","share_version":0}`, wantErr: ErrInvalidNamespace},
+		{name: "missing share version", json: `{"namespace":"0x000000000000000000000000000000000000000000000073616d706c65","data":"aGVsbG8="}`},
+		{name: "missing data", json: `{"namespace":"0x000000000000000000000000000000000000000000000073616d706c65","share_version":0}`, wantErr: ErrEmptyData},
+		{name: "reserved namespace", json: `{"namespace":"0x0000000000000000000000000000000000000000000000000000000001","data":"aGVsbG8=","share_version":0}`, wantErr: ErrReservedNamespace},
+		{name: "invalid namespace", json: `{"namespace":"0x01","data":"aGVsbG8=","share_version":0}`, wantErr: namespace.ErrInvalidLength},
+		{name: "unsupported share version", json: `{` + valid[:len(valid)-1] + `2}`, wantErr: ErrUnsupportedShareVersion},
+		{name: "v0 blob with signer", json: `{` + valid + `,"signer":"0xabababababababababababababababababababab"}`, wantErr: ErrInvalidSigner},
+		{name: "v1 blob without signer", json: `{` + valid[:len(valid)-1] + `1}`, wantErr: ErrInvalidSigner},
+		{name: "signer without 0x prefix", json: `{` + valid[:len(valid)-1] + `1,"signer":"abababababababababababababababababababab"}`, wantErr: ErrInvalidSigner},
+		{name: "short signer", json: `{` + valid[:len(valid)-1] + `1,"signer":"0xabab"}`, wantErr: ErrInvalidSigner},
+		{name: "not an object", json: `"blob"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b Blob
+			err := json.Unmarshal([]byte(tc.json), &b)
+			assert.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlobTxUnmarshalJSONErrors(t *testing.T) {
+	v0, _ := sampleBlobs(t)
+	blobJSON, err := json.Marshal(v0)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		json    string
+		wantErr error
+	}{
+		{name: "unknown field", json: `{"tx":"dHg=","blobs":[` + string(blobJSON) + `],"type_id":"BLOB","extra":1}`},
+		{name: "wrong type id", json: `{"tx":"dHg=","blobs":[` + string(blobJSON) + `],"type_id":"INDX"}`},
+		{name: "no blobs", json: `{"tx":"dHg=","blobs":[],"type_id":"BLOB"}`},
+		{name: "nil blob", json: `{"tx":"dHg=","blobs":[null],"type_id":"BLOB"}`, wantErr: ErrNilBlob},
+		{name: "invalid blob", json: `{"tx":"dHg=","blobs":[{"namespace":"0x01","data":"aGVsbG8=","share_version":0}],"type_id":"BLOB"}`, wantErr: namespace.ErrInvalidLength},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b BlobTx
+			err := json.Unmarshal([]byte(tc.json), &b)
+			assert.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+
+	_, err = json.Marshal(New(namespace.TxNamespace, []byte{1}, shareVersionZero))
+	assert.ErrorIs(t, err, ErrReservedNamespace)
+}
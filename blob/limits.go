@@ -0,0 +1,168 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	// ErrTooManyBlobs is returned when a blob transaction contains more blobs
+	// than Limits.MaxBlobs.
+	ErrTooManyBlobs = errors.New("too many blobs")
+	// ErrBlobTooLarge is returned when the data of a blob is larger than
+	// Limits.MaxBlobSize.
+	ErrBlobTooLarge = errors.New("blob too large")
+	// ErrBlobTxTooLarge is returned when an encoded blob transaction is larger
+	// than Limits.MaxTotalSize.
+	ErrBlobTxTooLarge = errors.New("blob tx too large")
+)
+
+// Limits bounds the size of blob transactions accepted by
+// MarshalBlobTxWithLimits and UnmarshalBlobTxWithLimits. A limit of zero, or
+// less, is not enforced so the zero value doesn't limit anything.
+type Limits struct {
+	// MaxBlobs is the maximum number of blobs of a blob transaction.
+	MaxBlobs int
+	// MaxBlobSize is the maximum size in bytes of the data of a blob.
+	MaxBlobSize int
+	// MaxTotalSize is the maximum size in bytes of an encoded blob
+	// transaction.
+	MaxTotalSize int
+}
+
+func (l Limits) checkBlobCount(count int) error {
+	if l.MaxBlobs > 0 && count > l.MaxBlobs {
+		return fmt.Errorf("%w: %d blobs exceed the limit of %d by %d", ErrTooManyBlobs, count, l.MaxBlobs, count-l.MaxBlobs)
+	}
+	return nil
+}
+
+func (l Limits) checkBlobSize(index, size int) error {
+	if l.MaxBlobSize > 0 && size > l.MaxBlobSize {
+		return fmt.Errorf("%w: blob %d of %d bytes exceeds the limit of %d bytes by %d", ErrBlobTooLarge, index, size, l.MaxBlobSize, size-l.MaxBlobSize)
+	}
+	return nil
+}
+
+func (l Limits) checkTotalSize(size int) error {
+	if l.MaxTotalSize > 0 && size > l.MaxTotalSize {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes by %d", ErrBlobTxTooLarge, size, l.MaxTotalSize, size-l.MaxTotalSize)
+	}
+	return nil
+}
+
+// MarshalBlobTxWithLimits is like MarshalBlobTx but returns an error wrapping
+// ErrTooManyBlobs, ErrBlobTooLarge or ErrBlobTxTooLarge if the blob
+// transaction exceeds limits.
+func MarshalBlobTxWithLimits(tx []byte, limits Limits, blobs ...*Blob) ([]byte, error) {
+	if err := limits.checkBlobCount(len(blobs)); err != nil {
+		return nil, err
+	}
+	for i, b := range blobs {
+		if b == nil {
+			continue // rejected by MarshalBlobTx
+		}
+		if err := limits.checkBlobSize(i, len(b.Data)); err != nil {
+			return nil, err
+		}
+	}
+	rawTx, err := MarshalBlobTx(tx, blobs...)
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.checkTotalSize(len(rawTx)); err != nil {
+		return nil, err
+	}
+	return rawTx, nil
+}
+
+// UnmarshalBlobTxWithLimits is like UnmarshalBlobTx but returns an error
+// wrapping ErrTooManyBlobs, ErrBlobTooLarge or ErrBlobTxTooLarge if tx is a
+// blob transaction that exceeds limits. The limits are checked against the
+// encoded lengths of the fields before tx is decoded.
+func UnmarshalBlobTxWithLimits(tx []byte, limits Limits) (*BlobTx, bool, error) {
+	isBlobTx, err := checkEncodedBlobTx(tx, limits)
+	if !isBlobTx {
+		return &BlobTx{}, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	blobTx, isBlobTx := UnmarshalBlobTx(tx)
+	return blobTx, isBlobTx, nil
+}
+
+// checkEncodedBlobTx checks the encoded blob transaction tx against limits
+// without decoding it. It returns false if tx isn't a well formed protobuf
+// message with the type ID of a blob transaction.
+func checkEncodedBlobTx(tx []byte, limits Limits) (isBlobTx bool, err error) {
+	err = limits.checkTotalSize(len(tx))
+	var typeID []byte
+	blobs := 0
+	for b := tx; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return false, nil
+		}
+		b = b[n:]
+		switch {
+		case num == 2 && typ == protowire.BytesType:
+			blob, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return false, nil
+			}
+			if err == nil {
+				err = limits.checkBlobCount(blobs + 1)
+			}
+			if err == nil {
+				err = limits.checkBlobSize(blobs, encodedDataLen(blob))
+			}
+			blobs++
+			b = b[n:]
+		case num == 3 && typ == protowire.BytesType:
+			// the last occurrence of a field that isn't repeated takes
+			// precedence, as it does when the message is decoded
+			typeID, n = protowire.ConsumeBytes(b)
+			if n < 0 {
+				return false, nil
+			}
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return false, nil
+			}
+			b = b[n:]
+		}
+	}
+	return string(typeID) == ProtoBlobTxTypeID, err
+}
+
+// encodedDataLen returns the length of the data field of the encoded Blob
+// message blob, or 0 if blob is malformed because decoding it fails anyway.
+func encodedDataLen(blob []byte) (dataLen int) {
+	for len(blob) > 0 {
+		num, typ, n := protowire.ConsumeTag(blob)
+		if n < 0 {
+			return 0
+		}
+		blob = blob[n:]
+		if num == 2 && typ == protowire.BytesType {
+			data, n := protowire.ConsumeBytes(blob)
+			if n < 0 {
+				return 0
+			}
+			dataLen = len(data)
+			blob = blob[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, blob)
+		if n < 0 {
+			return 0
+		}
+		blob = blob[n:]
+	}
+	return dataLen
+}
@@ -0,0 +1,61 @@
+package blob
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobTxLimits(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	blobs := []*Blob{New(ns, bytes.Repeat([]byte{1}, 10), 0), New(ns, bytes.Repeat([]byte{2}, 20), 0)}
+	rawTx, err := MarshalBlobTx([]byte("tx"), blobs...)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		limits  Limits
+		wantErr error
+	}{
+		{name: "no limits", limits: Limits{}},
+		{name: "at every limit", limits: Limits{MaxBlobs: 2, MaxBlobSize: 20, MaxTotalSize: len(rawTx)}},
+		{name: "one blob too many", limits: Limits{MaxBlobs: 1}, wantErr: ErrTooManyBlobs},
+		{name: "blob one byte too large", limits: Limits{MaxBlobSize: 19}, wantErr: ErrBlobTooLarge},
+		{name: "tx one byte too large", limits: Limits{MaxTotalSize: len(rawTx) - 1}, wantErr: ErrBlobTxTooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			marshalled, err := MarshalBlobTxWithLimits([]byte("tx"), tc.limits, blobs...)
+			blobTx, isBlobTx, unmarshalErr := UnmarshalBlobTxWithLimits(rawTx, tc.limits)
+			assert.True(t, isBlobTx)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.ErrorIs(t, unmarshalErr, tc.wantErr)
+				assert.ErrorContains(t, unmarshalErr, "by 1")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, unmarshalErr)
+			assert.Equal(t, rawTx, marshalled)
+			require.Len(t, blobTx.Blobs, 2)
+			assert.Equal(t, blobs[1].Data, blobTx.Blobs[1].Data)
+		})
+	}
+}
+
+func TestUnmarshalBlobTxWithLimitsNotBlobTx(t *testing.T) {
+	limits := Limits{MaxBlobs: 1, MaxBlobSize: 1, MaxTotalSize: 1}
+	for _, tx := range [][]byte{[]byte("not a blob tx"), {0xff}} {
+		_, isBlobTx, err := UnmarshalBlobTxWithLimits(tx, limits)
+		assert.NoError(t, err)
+		assert.False(t, isBlobTx)
+	}
+	rawTx, err := MarshalIndexWrapper([]byte("tx"), 1, 2)
+	require.NoError(t, err)
+	_, isBlobTx, err := UnmarshalBlobTxWithLimits(rawTx, limits)
+	assert.NoError(t, err)
+	assert.False(t, isBlobTx)
+}
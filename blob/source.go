@@ -0,0 +1,200 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrSourceLength is returned when the reader of a BlobSource doesn't provide
+// exactly as many bytes as the source declares.
+var ErrSourceLength = errors.New("blob source length mismatch")
+
+// sourceBufferSize is the size of the buffer CopySource copies data through.
+const sourceBufferSize = 512
+
+// BlobSource describes a blob whose data is read from an io.Reader instead of
+// being held in memory, e.g. a blob that is stored in a file. The length of
+// the data must be known up front because it is written to the first share of
+// the blob, and the reader returned by Open must provide exactly Len bytes.
+type BlobSource interface {
+	// Namespace returns the namespace of the blob.
+	Namespace() namespace.Namespace
+	// ShareVersion returns the share version of the blob.
+	ShareVersion() uint8
+	// Signer returns the signer of a share version 1 blob and nil otherwise.
+	Signer() []byte
+	// Len returns the length of the data of the blob in bytes.
+	Len() int
+	// Open returns a reader of the data of the blob. If the reader implements
+	// io.Closer it is closed once the data has been read.
+	Open() (io.Reader, error)
+}
+
+// NewSource returns a BlobSource of length bytes that reads the data of the
+// blob from the readers returned by open.
+func NewSource(ns namespace.Namespace, shareVersion uint8, signer []byte, length int, open func() (io.Reader, error)) BlobSource {
+	return &source{ns: ns, shareVersion: shareVersion, signer: signer, length: length, open: open}
+}
+
+type source struct {
+	ns           namespace.Namespace
+	shareVersion uint8
+	signer       []byte
+	length       int
+	open         func() (io.Reader, error)
+}
+
+func (s *source) Namespace() namespace.Namespace { return s.ns }
+func (s *source) ShareVersion() uint8            { return s.shareVersion }
+func (s *source) Signer() []byte                 { return s.signer }
+func (s *source) Len() int                       { return s.length }
+func (s *source) Open() (io.Reader, error)       { return s.open() }
+
+// ValidateSource runs the checks of Blob.Validate on src without reading its
+// data.
+func ValidateSource(src BlobSource) error {
+	if src == nil {
+		return ErrNilBlob
+	}
+	b := New(src.Namespace(), nil, src.ShareVersion())
+	b.Signer = src.Signer()
+	if err := b.ValidateAllowEmpty(); err != nil {
+		return err
+	}
+	if src.Len() <= 0 {
+		return ErrEmptyData
+	}
+	if uint64(src.Len()) > math.MaxUint32 {
+		return fmt.Errorf("%w: %d bytes exceeds the maximum sequence length of %d bytes", ErrDataTooLarge, src.Len(), uint32(math.MaxUint32))
+	}
+	return nil
+}
+
+// CopySource writes the data of src to dst through a small buffer. It returns
+// an error wrapping ErrSourceLength if the reader of src provides more or fewer
+// than src.Len() bytes.
+func CopySource(dst io.Writer, src BlobSource) (err error) {
+	r, err := src.Open()
+	if err != nil {
+		return err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer func() {
+			err = errors.Join(err, closer.Close())
+		}()
+	}
+
+	n, err := io.CopyBuffer(dst, io.LimitReader(r, int64(src.Len())), make([]byte, sourceBufferSize))
+	if err != nil {
+		return err
+	}
+	if n < int64(src.Len()) {
+		return fmt.Errorf("%w: read %d bytes but expected %d", ErrSourceLength, n, src.Len())
+	}
+	var extra [1]byte
+	m, err := io.ReadFull(r, extra[:])
+	if m != 0 {
+		return fmt.Errorf("%w: read more than the expected %d bytes", ErrSourceLength, src.Len())
+	}
+	if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// MarshalBlobTxFromSources is like MarshalBlobTx but reads the data of the
+// blobs from sources. The data is read directly into the returned encoding so
+// it is not copied in memory. The encoding is identical to the one
+// MarshalBlobTx returns for the same blobs.
+func MarshalBlobTxFromSources(tx []byte, sources ...BlobSource) ([]byte, error) {
+	size := 0
+	if len(tx) != 0 {
+		size += protowire.SizeTag(1) + protowire.SizeBytes(len(tx))
+	}
+	blobSizes := make([]int, len(sources))
+	for i, src := range sources {
+		if err := ValidateSource(src); err != nil {
+			return nil, fmt.Errorf("invalid blob %d: %w", i, err)
+		}
+		blobSizes[i] = sourceMessageSize(src)
+		size += protowire.SizeTag(2) + protowire.SizeBytes(blobSizes[i])
+	}
+	size += protowire.SizeTag(3) + protowire.SizeBytes(len(ProtoBlobTxTypeID))
+
+	// the fields are written in the order of their field numbers, as
+	// proto.Marshal does, and fields with zero values are omitted
+	out := make([]byte, 0, size)
+	if len(tx) != 0 {
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, tx)
+	}
+	for i, src := range sources {
+		out = protowire.AppendTag(out, 2, protowire.BytesType)
+		out = protowire.AppendVarint(out, uint64(blobSizes[i]))
+		ns := src.Namespace()
+		if len(ns.ID) != 0 {
+			out = protowire.AppendTag(out, 1, protowire.BytesType)
+			out = protowire.AppendBytes(out, ns.ID)
+		}
+		out = protowire.AppendTag(out, 2, protowire.BytesType)
+		out = protowire.AppendVarint(out, uint64(src.Len()))
+		w := &sliceWriter{buf: out[len(out) : len(out)+src.Len()]}
+		if err := CopySource(w, src); err != nil {
+			return nil, fmt.Errorf("blob %d: %w", i, err)
+		}
+		out = out[:len(out)+src.Len()]
+		if src.ShareVersion() != 0 {
+			out = protowire.AppendTag(out, 3, protowire.VarintType)
+			out = protowire.AppendVarint(out, uint64(src.ShareVersion()))
+		}
+		if ns.Version != 0 {
+			out = protowire.AppendTag(out, 4, protowire.VarintType)
+			out = protowire.AppendVarint(out, uint64(ns.Version))
+		}
+		if len(src.Signer()) != 0 {
+			out = protowire.AppendTag(out, 5, protowire.BytesType)
+			out = protowire.AppendBytes(out, src.Signer())
+		}
+	}
+	out = protowire.AppendTag(out, 3, protowire.BytesType)
+	out = protowire.AppendString(out, ProtoBlobTxTypeID)
+	return out, nil
+}
+
+// sourceMessageSize returns the size of the encoding of the Blob message of
+// src.
+func sourceMessageSize(src BlobSource) int {
+	size := protowire.SizeTag(2) + protowire.SizeBytes(src.Len())
+	if ns := src.Namespace(); len(ns.ID) != 0 {
+		size += protowire.SizeTag(1) + protowire.SizeBytes(len(ns.ID))
+	}
+	if src.ShareVersion() != 0 {
+		size += protowire.SizeTag(3) + protowire.SizeVarint(uint64(src.ShareVersion()))
+	}
+	if src.Namespace().Version != 0 {
+		size += protowire.SizeTag(4) + protowire.SizeVarint(uint64(src.Namespace().Version))
+	}
+	if len(src.Signer()) != 0 {
+		size += protowire.SizeTag(5) + protowire.SizeBytes(len(src.Signer()))
+	}
+	return size
+}
+
+// sliceWriter writes into a fixed size buffer.
+type sliceWriter struct {
+	buf []byte
+}
+
+func (w *sliceWriter) Write(p []byte) (int, error) {
+	if len(p) > len(w.buf) {
+		return 0, io.ErrShortWrite
+	}
+	n := copy(w.buf, p)
+	w.buf = w.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,94 @@
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blobSource(b *Blob) BlobSource {
+	return NewSource(b.Namespace(), uint8(b.ShareVersion), b.Signer, len(b.Data), func() (io.Reader, error) {
+		return bytes.NewReader(b.Data), nil
+	})
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestMarshalBlobTxFromSources(t *testing.T) {
+	ns1 := namespace.MustNewV0([]byte("ns1"))
+	ns2 := namespace.MustNewV0([]byte("ns2"))
+	v1, err := NewV1(ns2, bytes.Repeat([]byte{2}, 1000), bytes.Repeat([]byte{3}, SignerSize))
+	require.NoError(t, err)
+	blobs := []*Blob{New(ns1, []byte{1}, 0), v1, New(ns1, bytes.Repeat([]byte{4}, 300), 0)}
+
+	want, err := MarshalBlobTx([]byte("tx"), blobs...)
+	require.NoError(t, err)
+	sources := make([]BlobSource, len(blobs))
+	for i, b := range blobs {
+		sources[i] = blobSource(b)
+	}
+	got, err := MarshalBlobTxFromSources([]byte("tx"), sources...)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, len(got), cap(got))
+}
+
+func TestMarshalBlobTxFromSourcesErrors(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	data := []byte("data")
+	withLength := func(length int) BlobSource {
+		return NewSource(ns, 0, nil, length, func() (io.Reader, error) {
+			return bytes.NewReader(data), nil
+		})
+	}
+
+	testCases := []struct {
+		name    string
+		src     BlobSource
+		wantErr error
+	}{
+		{name: "nil source", src: nil, wantErr: ErrNilBlob},
+		{name: "no data", src: withLength(0), wantErr: ErrEmptyData},
+		{name: "reserved namespace", src: NewSource(namespace.TxNamespace, 0, nil, 1, nil), wantErr: ErrReservedNamespace},
+		{name: "missing signer", src: NewSource(ns, 1, nil, 1, nil), wantErr: ErrInvalidSigner},
+		{name: "shorter than declared", src: withLength(len(data) + 1), wantErr: ErrSourceLength},
+		{name: "longer than declared", src: withLength(len(data) - 1), wantErr: ErrSourceLength},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MarshalBlobTxFromSources([]byte("tx"), tc.src)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	t.Run("open error", func(t *testing.T) {
+		openErr := errors.New("open")
+		src := NewSource(ns, 0, nil, 1, func() (io.Reader, error) { return nil, openErr })
+		_, err := MarshalBlobTxFromSources([]byte("tx"), src)
+		assert.ErrorIs(t, err, openErr)
+	})
+}
+
+func TestCopySourceClosesReader(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("test"))
+	r := &closeRecorder{Reader: bytes.NewReader([]byte("data"))}
+	src := NewSource(ns, 0, nil, 4, func() (io.Reader, error) { return r, nil })
+
+	var buf bytes.Buffer
+	require.NoError(t, CopySource(&buf, src))
+	assert.Equal(t, "data", buf.String())
+	assert.True(t, r.closed)
+}
@@ -2,40 +2,252 @@ package inclusion
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/celestiaorg/go-square/blob"
 	ns "github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/params"
 	sh "github.com/celestiaorg/go-square/shares"
 	"github.com/celestiaorg/nmt"
 )
 
 type MerkleRootFn func([][]byte) []byte
 
+var (
+	// ErrInvalidSubtreeRootThreshold is returned when the subtree root
+	// threshold isn't strictly positive.
+	ErrInvalidSubtreeRootThreshold = errors.New("subtree root threshold must be strictly positive")
+	// ErrCommitmentCountMismatch is returned by VerifyCommitments when the
+	// number of commitments doesn't match the number of blobs.
+	ErrCommitmentCountMismatch = errors.New("number of commitments doesn't match the number of blobs")
+	// ErrNotSingleSequence is returned by CreateCommitmentFromShares when the
+	// shares aren't exactly one sparse sequence of a blob.
+	ErrNotSingleSequence = errors.New("shares aren't a single blob sequence")
+	// ErrInvalidBlobShareLen is returned when the number of shares of a blob
+	// isn't strictly positive.
+	ErrInvalidBlobShareLen = errors.New("blob share length must be strictly positive")
+)
+
+// CommitmentOption configures how share commitments are created.
+type CommitmentOption func(*commitmentConfig)
+
+type commitmentConfig struct {
+	cacheSize int
+	newHash   func() hash.Hash
+}
+
+// newCommitmentConfig returns the configuration set by opts.
+func newCommitmentConfig(opts []CommitmentOption) commitmentConfig {
+	config := commitmentConfig{newHash: sha256.New}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// WithHasher makes the subtree roots of share commitments be computed with
+// the hash function returned by newHash instead of SHA-256. The shape of the
+// trees and the namespacing of their nodes don't change, only the digests do.
+// The hash function of the commitment over the subtree roots is the one of
+// the MerkleRootFn. A nil newHash selects SHA-256, which is the default.
+func WithHasher(newHash func() hash.Hash) CommitmentOption {
+	return func(c *commitmentConfig) {
+		if newHash == nil {
+			newHash = sha256.New
+		}
+		c.newHash = newHash
+	}
+}
+
+// WithCommitmentCache makes CreateCommitments and the functions over many
+// blobs that use it remember up to size commitments
+// by the blob they are computed over and up to size subtree roots by the
+// namespace and shares they are computed over, so that blobs, or runs of
+// shares, with identical content are only hashed once. When the cache is full
+// the oldest entry is evicted. A size of zero or less disables the cache,
+// which is the default.
+func WithCommitmentCache(size int) CommitmentOption {
+	return func(c *commitmentConfig) {
+		c.cacheSize = size
+	}
+}
+
 // CreateCommitment generates the share commitment for a given blob.
 // See [data square layout rationale] and [blob share commitment rules].
 //
 // [data square layout rationale]: ../../specs/src/specs/data_square_layout.md
 // [blob share commitment rules]: ../../specs/src/specs/data_square_layout.md#blob-share-commitment-rules
-func CreateCommitment(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int) ([]byte, error) {
+//
+// The leaves of the commitment are the shares of the blob in the layout of its
+// share version, so the signer in the first share of a share version 1 blob is
+// committed to. It returns an error wrapping blob.ErrUnsupportedShareVersion
+// for any other share version than 0 and 1.
+//
+// The options select e.g. the hash function of the subtree roots, see
+// WithHasher. A commitment cache has no effect on a single blob.
+func CreateCommitment(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([]byte, error) {
+	commitment, _, err := CreateCommitmentWithSubtreeRoots(blob, merkleRootFn, subtreeRootThreshold, opts...)
+	return commitment, err
+}
+
+// CreateCommitmentWithParams is like CreateCommitment but takes the subtree
+// root threshold from p, e.g. the params.GetParams of the app version the blob
+// is committed to in. It returns an error wrapping
+// blob.ErrUnsupportedShareVersion if p doesn't support the share version of
+// the blob.
+func CreateCommitmentWithParams(b *blob.Blob, merkleRootFn MerkleRootFn, p params.Params, opts ...CommitmentOption) ([]byte, error) {
+	if b != nil && (b.ShareVersion > math.MaxUint8 || !p.SupportsShareVersion(uint8(b.ShareVersion))) {
+		return nil, fmt.Errorf("%w: share version %d but app version %d supports %v", blob.ErrUnsupportedShareVersion, b.ShareVersion, p.AppVersion, p.SupportedShareVersions)
+	}
+	return CreateCommitment(b, merkleRootFn, p.SubtreeRootThreshold, opts...)
+}
+
+// CreateCommitmentWithSubtreeRoots is like CreateCommitment but also returns
+// the subtree roots that the commitment is the merkle root of, in the order
+// they were passed to merkleRootFn. The subtree roots are the roots of the
+// trees of the merkle mountain range over the shares of the blob, see
+// MerkleMountainRangeSizes and SubTreeWidth.
+func CreateCommitmentWithSubtreeRoots(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) (commitment []byte, subtreeRoots [][]byte, err error) {
+	return createCommitment(blob, merkleRootFn, subtreeRootThreshold, newCommitmentConfig(opts).newHash, nil)
+}
+
+// createCommitment is CreateCommitmentWithSubtreeRoots but hashes the subtree
+// roots with newHash and looks up and adds them to cache if it isn't nil.
+func createCommitment(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int, newHash func() hash.Hash, cache *fifoCache) ([]byte, [][]byte, error) {
+	if subtreeRootThreshold <= 0 {
+		return nil, nil, fmt.Errorf("%w: %d", ErrInvalidSubtreeRootThreshold, subtreeRootThreshold)
+	}
 	if err := blob.Validate(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	namespace := blob.Namespace()
+	shares, err := sh.SplitBlobs(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commitmentFromShares(blob.Namespace(), shares, merkleRootFn, subtreeRootThreshold, newHash, cache)
+}
 
+// minParallelShares is the number of shares below which
+// CreateCommitmentParallel computes the subtree roots sequentially because
+// the goroutines would cost more than they save.
+const minParallelShares = 128
+
+// CreateCommitmentParallel is like CreateCommitment but computes the subtree
+// roots of the commitment on up to workers goroutines. A value of 0 or less
+// uses runtime.GOMAXPROCS. Blobs of fewer than 128 shares are hashed
+// sequentially. The commitment is identical to the one of CreateCommitment
+// with the same options.
+func CreateCommitmentParallel(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold, workers int, opts ...CommitmentOption) ([]byte, error) {
+	if subtreeRootThreshold <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSubtreeRootThreshold, subtreeRootThreshold)
+	}
+	if err := blob.Validate(); err != nil {
+		return nil, err
+	}
 	shares, err := sh.SplitBlobs(blob)
 	if err != nil {
 		return nil, err
 	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	commitment, _, err := commitmentFromSharesParallel(blob.Namespace(), shares, merkleRootFn, subtreeRootThreshold, newCommitmentConfig(opts).newHash, nil, workers)
+	return commitment, err
+}
 
-	// the commitment is the root of a merkle mountain range with max tree size
-	// determined by the number of roots required to create a share commitment
-	// over that blob. The size of the tree is only increased if the number of
-	// subtree roots surpasses a constant threshold.
-	subTreeWidth := SubTreeWidth(len(shares), subtreeRootThreshold)
-	treeSizes, err := MerkleMountainRangeSizes(uint64(len(shares)), uint64(subTreeWidth))
+// CreateCommitmentFromShares is like CreateCommitment but takes the shares of
+// the blob, e.g. as split by square.Builder, instead of splitting the blob
+// again. The shares must be exactly one sequence of a blob: they must all
+// have the same namespace, which must be valid for a blob, and share version,
+// only the first share may start a sequence and the sequence length must
+// need exactly len(s) shares. Otherwise, including for padding shares, it
+// returns an error wrapping ErrNotSingleSequence.
+func CreateCommitmentFromShares(s []sh.Share, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([]byte, error) {
+	if subtreeRootThreshold <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSubtreeRootThreshold, subtreeRootThreshold)
+	}
+	namespace, err := validateBlobSequence(s)
 	if err != nil {
 		return nil, err
 	}
+	commitment, _, err := commitmentFromShares(namespace, s, merkleRootFn, subtreeRootThreshold, newCommitmentConfig(opts).newHash, nil)
+	return commitment, err
+}
+
+// validateBlobSequence returns the namespace of s if s is exactly one sparse
+// sequence of a blob.
+func validateBlobSequence(s []sh.Share) (ns.Namespace, error) {
+	if len(s) == 0 {
+		return ns.Namespace{}, fmt.Errorf("%w: no shares", ErrNotSingleSequence)
+	}
+	namespace, err := s[0].Namespace()
+	if err != nil {
+		return ns.Namespace{}, err
+	}
+	if err := namespace.ValidateForBlob(); err != nil {
+		return ns.Namespace{}, fmt.Errorf("%w: %w", ErrNotSingleSequence, err)
+	}
+	version, err := s[0].Version()
+	if err != nil {
+		return ns.Namespace{}, err
+	}
+	for i := range s {
+		if s[i].CompareNamespace(namespace) != 0 {
+			return ns.Namespace{}, fmt.Errorf("%w: share %d has another namespace than share 0", ErrNotSingleSequence, i)
+		}
+		if v, err := s[i].Version(); err != nil {
+			return ns.Namespace{}, err
+		} else if v != version {
+			return ns.Namespace{}, fmt.Errorf("%w: share %d has share version %d but share 0 has %d", ErrNotSingleSequence, i, v, version)
+		}
+		isStart, err := s[i].IsSequenceStart()
+		if err != nil {
+			return ns.Namespace{}, err
+		}
+		if isStart != (i == 0) {
+			if i == 0 {
+				return ns.Namespace{}, fmt.Errorf("%w: share 0 doesn't start a sequence", ErrNotSingleSequence)
+			}
+			return ns.Namespace{}, fmt.Errorf("%w: share %d starts another sequence", ErrNotSingleSequence, i)
+		}
+	}
+	sequenceLen, err := s[0].SequenceLen()
+	if err != nil {
+		return ns.Namespace{}, err
+	}
+	if sequenceLen == 0 {
+		return ns.Namespace{}, fmt.Errorf("%w: share 0 is namespace padding", ErrNotSingleSequence)
+	}
+	if want := sh.SparseSharesNeededForVersion(sequenceLen, version); want != len(s) {
+		return ns.Namespace{}, fmt.Errorf("%w: sequence length %d needs %d shares, got %d", ErrNotSingleSequence, sequenceLen, want, len(s))
+	}
+	return namespace, nil
+}
+
+// commitmentFromShares returns the commitment and subtree roots of the blob
+// split into shares, hashing the subtree roots with newHash and looking up and
+// adding them to cache if it isn't nil.
+func commitmentFromShares(namespace ns.Namespace, shares []sh.Share, merkleRootFn MerkleRootFn, subtreeRootThreshold int, newHash func() hash.Hash, cache *fifoCache) ([]byte, [][]byte, error) {
+	return commitmentFromSharesParallel(namespace, shares, merkleRootFn, subtreeRootThreshold, newHash, cache, 1)
+}
+
+// commitmentFromSharesParallel is commitmentFromShares but computes the
+// subtree roots on up to workers goroutines if there is no cache and there
+// are at least minParallelShares shares.
+func commitmentFromSharesParallel(namespace ns.Namespace, shares []sh.Share, merkleRootFn MerkleRootFn, subtreeRootThreshold int, newHash func() hash.Hash, cache *fifoCache, workers int) ([]byte, [][]byte, error) {
+	treeSizes, err := SubtreeSizes(len(shares), subtreeRootThreshold)
+	if err != nil {
+		return nil, nil, err
+	}
 	leafSets := make([][][]byte, len(treeSizes))
 	cursor := uint64(0)
 	for i, treeSize := range treeSizes {
@@ -43,49 +255,244 @@ func CreateCommitment(blob *blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThr
 		cursor = cursor + treeSize
 	}
 
+	if workers = min(workers, len(leafSets)); cache == nil && workers > 1 && len(shares) >= minParallelShares {
+		subTreeRoots, err := parallelSubtreeRoots(newHash, namespace, leafSets, workers)
+		if err != nil {
+			return nil, nil, err
+		}
+		return merkleRootFn(subTreeRoots), subTreeRoots, nil
+	}
+
 	// create the commitments by pushing each leaf set onto an nmt
 	subTreeRoots := make([][]byte, len(leafSets))
 	for i, set := range leafSets {
-		// create the nmt todo(evan) use nmt wrapper
-		tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(ns.NamespaceSize), nmt.IgnoreMaxNamespace(true))
-		for _, leaf := range set {
-			// the namespace must be added again here even though it is already
-			// included in the leaf to ensure that the hash will match that of
-			// the nmt wrapper (pkg/wrapper). Each namespace is added to keep
-			// the namespace in the share, and therefore the parity data, while
-			// also allowing for the manual addition of the parity namespace to
-			// the parity data.
-			nsLeaf := make([]byte, 0)
-			nsLeaf = append(nsLeaf, namespace.Bytes()...)
-			nsLeaf = append(nsLeaf, leaf...)
-
-			err = tree.Push(nsLeaf)
-			if err != nil {
-				return nil, err
+		var key string
+		if cache != nil {
+			key = subtreeRootKey(namespace, set)
+			if root, ok := cache.get(key); ok {
+				subTreeRoots[i] = root
+				continue
 			}
 		}
-		// add the root
-		root, err := tree.Root()
+		root, err := subtreeRoot(newHash, namespace, set)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if cache != nil {
+			cache.add(key, root)
 		}
 		subTreeRoots[i] = root
 	}
-	return merkleRootFn(subTreeRoots), nil
+	return merkleRootFn(subTreeRoots), subTreeRoots, nil
+}
+
+// parallelSubtreeRoots returns the subtree root of every leaf set, computed on
+// a pool of workers goroutines.
+func parallelSubtreeRoots(newHash func() hash.Hash, namespace ns.Namespace, leafSets [][][]byte, workers int) ([][]byte, error) {
+	roots := make([][]byte, len(leafSets))
+	errs := make([]error, len(leafSets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				roots[i], errs[i] = subtreeRoot(newHash, namespace, leafSets[i])
+			}
+		}()
+	}
+	for i := range leafSets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// subtreeRoot returns the root of an nmt, hashed with newHash, over the shares
+// in set, which belong to namespace.
+func subtreeRoot(newHash func() hash.Hash, namespace ns.Namespace, set [][]byte) ([]byte, error) {
+	// create the nmt todo(evan) use nmt wrapper
+	tree := nmt.New(newHash(), nmt.NamespaceIDSize(ns.NamespaceSize), nmt.IgnoreMaxNamespace(true))
+	for _, leaf := range set {
+		// the namespace must be added again here even though it is already
+		// included in the leaf to ensure that the hash will match that of
+		// the nmt wrapper (pkg/wrapper). Each namespace is added to keep
+		// the namespace in the share, and therefore the parity data, while
+		// also allowing for the manual addition of the parity namespace to
+		// the parity data.
+		nsLeaf := make([]byte, 0)
+		nsLeaf = append(nsLeaf, namespace.Bytes()...)
+		nsLeaf = append(nsLeaf, leaf...)
+
+		if err := tree.Push(nsLeaf); err != nil {
+			return nil, err
+		}
+	}
+	return tree.Root()
+}
+
+// subtreeRootKey returns the cache key of the subtree root over set. Shares
+// have a fixed size so the concatenation is unambiguous.
+func subtreeRootKey(namespace ns.Namespace, set [][]byte) string {
+	var b strings.Builder
+	b.Grow(ns.NamespaceSize + len(set)*sh.ShareSize)
+	b.Write(namespace.Bytes())
+	for _, leaf := range set {
+		b.Write(leaf)
+	}
+	return b.String()
+}
+
+// blobKey returns the cache key of the commitment of blob. Every field is
+// length prefixed so that invalid blobs never share the key of a valid blob.
+func blobKey(blob *blob.Blob) string {
+	var b strings.Builder
+	b.Grow(4*5 + len(blob.NamespaceId) + len(blob.Signer) + len(blob.Data))
+	for _, v := range []uint32{blob.NamespaceVersion, uint32(len(blob.NamespaceId)), blob.ShareVersion, uint32(len(blob.Signer)), uint32(len(blob.Data))} {
+		b.Write(binary.BigEndian.AppendUint32(nil, v))
+	}
+	b.Write(blob.NamespaceId)
+	b.Write(blob.Signer)
+	b.Write(blob.Data)
+	return b.String()
+}
+
+// fifoCache holds up to size values and evicts the oldest value first.
+type fifoCache struct {
+	size   int
+	values map[string][]byte
+	// keys are the keys of values from oldest to newest.
+	keys []string
+}
+
+func newFIFOCache(size int) *fifoCache {
+	return &fifoCache{
+		size:   size,
+		values: make(map[string][]byte, size),
+		keys:   make([]string, 0, size),
+	}
+}
+
+func (c *fifoCache) get(key string) ([]byte, bool) {
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *fifoCache) add(key string, value []byte) {
+	if _, ok := c.values[key]; ok {
+		return
+	}
+	if len(c.keys) == c.size {
+		delete(c.values, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.values[key] = value
+	c.keys = append(c.keys, key)
 }
 
-func CreateCommitments(blobs []*blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int) ([][]byte, error) {
+// CreateCommitments returns the share commitment of every blob. See
+// WithCommitmentCache to avoid hashing blobs with identical content more than
+// once.
+func CreateCommitments(blobs []*blob.Blob, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([][]byte, error) {
+	config := newCommitmentConfig(opts)
+	var cachedCommitments, subtreeRoots *fifoCache
+	if config.cacheSize > 0 {
+		cachedCommitments = newFIFOCache(config.cacheSize)
+		subtreeRoots = newFIFOCache(config.cacheSize)
+	}
+
 	commitments := make([][]byte, len(blobs))
 	for i, blob := range blobs {
-		commitment, err := CreateCommitment(blob, merkleRootFn, subtreeRootThreshold)
+		var key string
+		if cachedCommitments != nil && blob != nil {
+			key = blobKey(blob)
+			if commitment, ok := cachedCommitments.get(key); ok {
+				commitments[i] = slices.Clone(commitment)
+				continue
+			}
+		}
+		commitment, _, err := createCommitment(blob, merkleRootFn, subtreeRootThreshold, config.newHash, subtreeRoots)
 		if err != nil {
 			return nil, err
 		}
+		if cachedCommitments != nil {
+			cachedCommitments.add(key, commitment)
+		}
 		commitments[i] = commitment
 	}
 	return commitments, nil
 }
 
+// VerifyCommitment returns true if commitment is the share commitment of blob.
+// The commitment is recomputed with CreateCommitment and compared in constant
+// time, so a commitment of the wrong length is a mismatch rather than an
+// error. It returns an error if the commitment can't be computed, e.g. because
+// the blob is invalid or subtreeRootThreshold isn't strictly positive.
+func VerifyCommitment(blob *blob.Blob, commitment []byte, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) (bool, error) {
+	want, err := CreateCommitment(blob, merkleRootFn, subtreeRootThreshold, opts...)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(want, commitment) == 1, nil
+}
+
+// VerifyCommitments is the batch form of VerifyCommitment. It returns whether
+// commitments[i] is the share commitment of blobs[i] for every blob. It
+// returns an error wrapping ErrCommitmentCountMismatch if there isn't one
+// commitment per blob. The options are those of CreateCommitments.
+func VerifyCommitments(blobs []*blob.Blob, commitments [][]byte, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([]bool, error) {
+	if len(blobs) != len(commitments) {
+		return nil, fmt.Errorf("%w: %d blobs and %d commitments", ErrCommitmentCountMismatch, len(blobs), len(commitments))
+	}
+	want, err := CreateCommitments(blobs, merkleRootFn, subtreeRootThreshold, opts...)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]bool, len(blobs))
+	for i := range want {
+		matches[i] = subtle.ConstantTimeCompare(want[i], commitments[i]) == 1
+	}
+	return matches, nil
+}
+
+// SubtreeSizes returns the number of shares of every subtree that the share
+// commitment of a blob of blobShareLen shares is computed over, in the order
+// the subtree roots are combined into the commitment. It returns an error
+// wrapping ErrInvalidBlobShareLen or ErrInvalidSubtreeRootThreshold if either
+// argument isn't strictly positive.
+func SubtreeSizes(blobShareLen, subtreeRootThreshold int) ([]uint64, error) {
+	if blobShareLen <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidBlobShareLen, blobShareLen)
+	}
+	if subtreeRootThreshold <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSubtreeRootThreshold, subtreeRootThreshold)
+	}
+	// the commitment is the root of a merkle mountain range with max tree size
+	// determined by the number of roots required to create a share commitment
+	// over that blob. The size of the tree is only increased if the number of
+	// subtree roots surpasses a constant threshold.
+	subTreeWidth := SubTreeWidth(blobShareLen, subtreeRootThreshold)
+	return MerkleMountainRangeSizes(uint64(blobShareLen), uint64(subTreeWidth))
+}
+
+// SubtreeRootCount returns the number of subtree roots that the share
+// commitment of a blob of blobShareLen shares is computed over, which is the
+// number of NMT range proofs needed to prove the inclusion of the blob. It
+// returns the errors of SubtreeSizes.
+func SubtreeRootCount(blobShareLen, subtreeRootThreshold int) (int, error) {
+	sizes, err := SubtreeSizes(blobShareLen, subtreeRootThreshold)
+	if err != nil {
+		return 0, err
+	}
+	return len(sizes), nil
+}
+
 // MerkleMountainRangeSizes returns the sizes (number of leaf nodes) of the
 // trees in a merkle mountain range constructed for a given totalSize and
 // maxTreeSize.
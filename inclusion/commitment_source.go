@@ -0,0 +1,66 @@
+package inclusion
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/celestiaorg/go-square/blob"
+	ns "github.com/celestiaorg/go-square/namespace"
+	sh "github.com/celestiaorg/go-square/shares"
+)
+
+// CreateCommitmentFromReader is like CreateCommitment for a blob without a
+// signer whose size bytes of data are read from r. See
+// CreateCommitmentFromSource.
+func CreateCommitmentFromReader(namespace ns.Namespace, shareVersion uint8, size int, r io.Reader, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([]byte, error) {
+	// hide a Close method of r so that the reader of the caller isn't closed
+	reader := struct{ io.Reader }{r}
+	src := blob.NewSource(namespace, shareVersion, nil, size, func() (io.Reader, error) {
+		return reader, nil
+	})
+	return CreateCommitmentFromSource(src, merkleRootFn, subtreeRootThreshold, opts...)
+}
+
+// CreateCommitmentFromSource is like CreateCommitment but reads the data of
+// the blob from src. The shares of the blob are hashed as they are split so
+// that at most the shares of one subtree are held in memory. It returns an
+// error wrapping blob.ErrSourceLength if src doesn't provide exactly src.Len()
+// bytes.
+func CreateCommitmentFromSource(src blob.BlobSource, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) ([]byte, error) {
+	if err := blob.ValidateSource(src); err != nil {
+		return nil, err
+	}
+	shareCount := sh.SparseSharesNeededForVersion(uint32(src.Len()), src.ShareVersion())
+	treeSizes, err := SubtreeSizes(shareCount, subtreeRootThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	newHash := newCommitmentConfig(opts).newHash
+	namespace := src.Namespace()
+	subTreeRoots := make([][]byte, 0, len(treeSizes))
+	var set [][]byte
+	err = sh.SplitSourceFunc(src, func(share sh.Share) error {
+		if len(subTreeRoots) == len(treeSizes) {
+			return fmt.Errorf("%w: more than the %d shares of %d bytes", blob.ErrSourceLength, shareCount, src.Len())
+		}
+		set = append(set, share.ToBytes())
+		if uint64(len(set)) < treeSizes[len(subTreeRoots)] {
+			return nil
+		}
+		root, err := subtreeRoot(newHash, namespace, set)
+		if err != nil {
+			return err
+		}
+		subTreeRoots = append(subTreeRoots, root)
+		set = set[:0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(subTreeRoots) != len(treeSizes) {
+		return nil, fmt.Errorf("%w: fewer than the %d shares of %d bytes", blob.ErrSourceLength, shareCount, src.Len())
+	}
+	return merkleRootFn(subTreeRoots), nil
+}
@@ -0,0 +1,68 @@
+package inclusion_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/merkle"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCommitmentFromReader(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	rng := rand.New(rand.NewSource(1))
+	sizes := []int{1, shares.FirstSparseShareContentSize, shares.FirstSparseShareContentSize + 1}
+	for _, shareCount := range []int{63, 64, 65, 128, 129, 300} {
+		sizes = append(sizes, shares.AvailableBytesFromSparseShares(shareCount), shares.AvailableBytesFromSparseShares(shareCount)+1)
+	}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		_, _ = rng.Read(data)
+		for _, subtreeRootThreshold := range []int{1, defaultSubtreeRootThreshold} {
+			want, err := inclusion.CreateCommitment(blob.New(ns1, data, shares.ShareVersionZero), merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+			got, err := inclusion.CreateCommitmentFromReader(ns1, shares.ShareVersionZero, size, bytes.NewReader(data), merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "%d bytes with threshold %d", size, subtreeRootThreshold)
+		}
+	}
+
+	t.Run("share version 1 source", func(t *testing.T) {
+		signer := bytes.Repeat([]byte{0x5}, blob.SignerSize)
+		data := bytes.Repeat([]byte{0xAB}, 10_000)
+		b := blob.New(ns1, data, shares.ShareVersionOne)
+		b.Signer = signer
+		want, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		src := blob.NewSource(ns1, shares.ShareVersionOne, signer, len(data), func() (io.Reader, error) {
+			return bytes.NewReader(data), nil
+		})
+		got, err := inclusion.CreateCommitmentFromSource(src, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("reader length mismatch", func(t *testing.T) {
+		data := bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(65))
+		for _, size := range []int{len(data) - 1, len(data) + 1, len(data) + shares.ShareSize} {
+			_, err := inclusion.CreateCommitmentFromReader(ns1, shares.ShareVersionZero, size, bytes.NewReader(data), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+			assert.ErrorIs(t, err, blob.ErrSourceLength, "size %d", size)
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := inclusion.CreateCommitmentFromReader(ns1, shares.ShareVersionZero, 0, bytes.NewReader(nil), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		assert.ErrorIs(t, err, blob.ErrEmptyData)
+		_, err = inclusion.CreateCommitmentFromReader(namespace.TxNamespace, shares.ShareVersionZero, 1, bytes.NewReader([]byte{1}), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		assert.ErrorIs(t, err, blob.ErrReservedNamespace)
+		_, err = inclusion.CreateCommitmentFromReader(ns1, shares.ShareVersionZero, 1, bytes.NewReader([]byte{1}), merkle.HashFromByteSlices, 0)
+		assert.ErrorIs(t, err, inclusion.ErrInvalidSubtreeRootThreshold)
+	})
+}
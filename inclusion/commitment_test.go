@@ -3,11 +3,22 @@ package inclusion_test
 import (
 	"bytes"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/merkle"
 	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/params"
 	"github.com/celestiaorg/go-square/shares"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -70,7 +81,7 @@ func TestCreateCommitment(t *testing.T) {
 		namespace    namespace.Namespace
 		blob         []byte
 		expected     []byte
-		expectErr    bool
+		expectErr    error
 		shareVersion uint8
 	}
 	tests := []test{
@@ -85,8 +96,15 @@ func TestCreateCommitment(t *testing.T) {
 			name:         "blob with unsupported share version should return error",
 			namespace:    ns1,
 			blob:         bytes.Repeat([]byte{0xFF}, shares.AvailableBytesFromSparseShares(2)),
-			expectErr:    true,
-			shareVersion: uint8(1), // unsupported share version
+			expectErr:    blob.ErrUnsupportedShareVersion,
+			shareVersion: uint8(2), // unsupported share version
+		},
+		{
+			name:         "share version 1 blob without a signer should return error",
+			namespace:    ns1,
+			blob:         bytes.Repeat([]byte{0xFF}, shares.AvailableBytesFromSparseShares(2)),
+			expectErr:    blob.ErrInvalidSigner,
+			shareVersion: shares.ShareVersionOne,
 		},
 	}
 	for _, tt := range tests {
@@ -98,8 +116,8 @@ func TestCreateCommitment(t *testing.T) {
 				NamespaceVersion: uint32(tt.namespace.Version),
 			}
 			res, err := inclusion.CreateCommitment(blob, twoLeafMerkleRoot, defaultSubtreeRootThreshold)
-			if tt.expectErr {
-				assert.Error(t, err)
+			if tt.expectErr != nil {
+				assert.ErrorIs(t, err, tt.expectErr)
 				return
 			}
 			assert.NoError(t, err)
@@ -117,3 +135,468 @@ func twoLeafMerkleRoot(data [][]byte) []byte {
 	sum := sha256.Sum256(append(h1[:], h2[:]...))
 	return sum[:]
 }
+
+func TestCreateCommitmentsCache(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{0x2}, namespace.NamespaceVersionZeroIDSize))
+	heartbeat := bytes.Repeat([]byte{0xAB}, 100_000)
+	blobs := []*blob.Blob{
+		blob.New(ns1, heartbeat, shares.ShareVersionZero),
+		blob.New(ns1, bytes.Repeat([]byte{0xAB}, 100_000), shares.ShareVersionZero),
+		// the same data in another namespace has another commitment
+		blob.New(ns2, heartbeat, shares.ShareVersionZero),
+		blob.New(ns1, []byte{0x1}, shares.ShareVersionZero),
+		blob.New(ns1, heartbeat, shares.ShareVersionZero),
+		// only the first subtree root differs from the heartbeat
+		blob.New(ns1, append(bytes.Repeat([]byte{0xAB}, 100_000), 0xCD), shares.ShareVersionZero),
+	}
+
+	uncached, err := inclusion.CreateCommitments(blobs, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	// the commitments must not change with or without the cache
+	golden := []string{
+		"cba369e282674efcd1854e7aa420d4767c4a4a8dada9eeb9c1b2767ef8386904",
+		"cba369e282674efcd1854e7aa420d4767c4a4a8dada9eeb9c1b2767ef8386904",
+		"8d2e62d1d04d7f2b9e76019b58b71d71d7efbc410740c63a9cc4b92713fb2a28",
+		"19232e7f4598c553b581a2c1696d6ec44cdc3b84eff0896889359e7710da523f",
+		"cba369e282674efcd1854e7aa420d4767c4a4a8dada9eeb9c1b2767ef8386904",
+		"c1ca785cd7dba03db370685a5bebf64ee18b30765cf7637df26a35496a8bee48",
+	}
+	for i, commitment := range uncached {
+		assert.Equal(t, golden[i], hex.EncodeToString(commitment), "blob %d", i)
+	}
+
+	for _, size := range []int{-1, 0, 1, 2, 100} {
+		cached, err := inclusion.CreateCommitments(blobs, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithCommitmentCache(size))
+		require.NoError(t, err)
+		assert.Equal(t, uncached, cached, "cache size %d", size)
+	}
+
+	// only valid blobs are cached
+	invalid := blob.New(ns1, heartbeat, shares.ShareVersionZero)
+	invalid.ShareVersion = 7
+	_, err = inclusion.CreateCommitments(append(blobs, invalid), merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithCommitmentCache(100))
+	assert.ErrorIs(t, err, blob.ErrUnsupportedShareVersion)
+}
+
+func BenchmarkCreateCommitmentsIdenticalBlobs(b *testing.B) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	blobs := make([]*blob.Blob, 100)
+	for i := range blobs {
+		blobs[i] = blob.New(ns1, bytes.Repeat([]byte{0xAB}, 100_000), shares.ShareVersionZero)
+	}
+	for _, size := range []int{0, 256} {
+		b.Run(fmt.Sprintf("cache size %d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := inclusion.CreateCommitments(blobs, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithCommitmentCache(size))
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func TestVerifyCommitment(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	data := bytes.Repeat([]byte{0xAB}, 10_000)
+	b := blob.New(ns1, data, shares.ShareVersionZero)
+	commitment, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+
+	changed := bytes.Clone(data)
+	changed[len(changed)/2] ^= 0x01
+
+	testCases := []struct {
+		name                 string
+		blob                 *blob.Blob
+		commitment           []byte
+		subtreeRootThreshold int
+		want                 bool
+		wantErr              error
+	}{
+		{"matching commitment", b, commitment, defaultSubtreeRootThreshold, true, nil},
+		{"data differs by one byte", blob.New(ns1, changed, shares.ShareVersionZero), commitment, defaultSubtreeRootThreshold, false, nil},
+		{"other threshold", b, commitment, 1, false, nil},
+		{"truncated commitment", b, commitment[:len(commitment)-1], defaultSubtreeRootThreshold, false, nil},
+		{"extended commitment", b, append(bytes.Clone(commitment), 0), defaultSubtreeRootThreshold, false, nil},
+		{"nil commitment", b, nil, defaultSubtreeRootThreshold, false, nil},
+		{"empty data", blob.New(ns1, nil, shares.ShareVersionZero), commitment, defaultSubtreeRootThreshold, false, blob.ErrEmptyData},
+		{"reserved namespace", blob.New(namespace.TxNamespace, data, shares.ShareVersionZero), commitment, defaultSubtreeRootThreshold, false, blob.ErrReservedNamespace},
+		{"zero threshold", b, commitment, 0, false, inclusion.ErrInvalidSubtreeRootThreshold},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := inclusion.VerifyCommitment(tc.blob, tc.commitment, merkle.HashFromByteSlices, tc.subtreeRootThreshold)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ok)
+		})
+	}
+}
+
+func TestVerifyCommitments(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	blobs := []*blob.Blob{
+		blob.New(ns1, []byte{1}, shares.ShareVersionZero),
+		blob.New(ns1, bytes.Repeat([]byte{2}, 5000), shares.ShareVersionZero),
+		blob.New(ns1, []byte{1}, shares.ShareVersionZero),
+	}
+	commitments, err := inclusion.CreateCommitments(blobs, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+
+	matches, err := inclusion.VerifyCommitments(blobs, commitments, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithCommitmentCache(8))
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, true}, matches)
+
+	swapped := [][]byte{commitments[1], commitments[0], commitments[2][:4]}
+	matches, err = inclusion.VerifyCommitments(blobs, swapped, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false}, matches)
+
+	_, err = inclusion.VerifyCommitments(blobs, commitments[:2], merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	assert.ErrorIs(t, err, inclusion.ErrCommitmentCountMismatch)
+	_, err = inclusion.VerifyCommitments(append(blobs, blob.New(ns1, nil, shares.ShareVersionZero)), append(commitments, nil), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	assert.ErrorIs(t, err, blob.ErrEmptyData)
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestCreateCommitmentWithSubtreeRoots pins the subtree roots of blobs around
+// the boundaries at which the subtree width doubles. Run the test with -update
+// to rewrite the golden file after an intentional change.
+func TestCreateCommitmentWithSubtreeRoots(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	var got strings.Builder
+	for _, shareCount := range []int{1, 2, 3, 64, 65, 128, 129} {
+		data := bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(shareCount))
+		b := blob.New(ns1, data, shares.ShareVersionZero)
+		commitment, subtreeRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+
+		// the roots are the leaves of the commitment, one per tree of the
+		// merkle mountain range
+		assert.Equal(t, merkle.HashFromByteSlices(subtreeRoots), commitment)
+		treeSizes, err := inclusion.MerkleMountainRangeSizes(uint64(shareCount), uint64(inclusion.SubTreeWidth(shareCount, defaultSubtreeRootThreshold)))
+		require.NoError(t, err)
+		assert.Len(t, subtreeRoots, len(treeSizes))
+		want, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.Equal(t, want, commitment)
+
+		fmt.Fprintf(&got, "shares %d trees %v commitment %x\n", shareCount, treeSizes, commitment)
+		for _, root := range subtreeRoots {
+			fmt.Fprintf(&got, "%x\n", root)
+		}
+	}
+
+	path := filepath.Join("testdata", "subtree_roots.golden")
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got.String()), 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), got.String())
+
+	_, _, err = inclusion.CreateCommitmentWithSubtreeRoots(blob.New(ns1, nil, shares.ShareVersionZero), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+	assert.ErrorIs(t, err, blob.ErrEmptyData)
+}
+
+func TestCreateCommitmentWithParams(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	v0 := blob.New(ns1, bytes.Repeat([]byte{0xAB}, 100_000), shares.ShareVersionZero)
+	v1, err := blob.NewV1(ns1, bytes.Repeat([]byte{0xAB}, 100_000), bytes.Repeat([]byte{0x5}, blob.SignerSize))
+	require.NoError(t, err)
+
+	latest, err := params.GetParams(params.LatestAppVersion)
+	require.NoError(t, err)
+	for _, b := range []*blob.Blob{v0, v1} {
+		want, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		got, err := inclusion.CreateCommitmentWithParams(b, merkle.HashFromByteSlices, latest)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	v2, err := params.GetParams(2)
+	require.NoError(t, err)
+	_, err = inclusion.CreateCommitmentWithParams(v0, merkle.HashFromByteSlices, v2)
+	require.NoError(t, err)
+	_, err = inclusion.CreateCommitmentWithParams(v1, merkle.HashFromByteSlices, v2)
+	assert.ErrorIs(t, err, blob.ErrUnsupportedShareVersion)
+	_, err = inclusion.CreateCommitmentWithParams(v0, merkle.HashFromByteSlices, params.Params{SupportedShareVersions: []uint8{0}})
+	assert.ErrorIs(t, err, inclusion.ErrInvalidSubtreeRootThreshold)
+}
+
+func TestCreateCommitmentShareVersionOne(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	signer := bytes.Repeat([]byte{0x5}, blob.SignerSize)
+	var got strings.Builder
+	for _, shareCount := range []int{1, 2, 65, 129} {
+		data := bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(shareCount)-shares.SignerSize)
+		b := blob.New(ns1, data, shares.ShareVersionOne)
+		b.Signer = signer
+		commitment, subtreeRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+
+		// the commitment is over the share version 1 shares of the blob
+		blobShares, err := shares.SplitBlobs(b)
+		require.NoError(t, err)
+		require.Len(t, blobShares, shareCount)
+		signerOfShare, err := blobShares[0].Signer()
+		require.NoError(t, err)
+		assert.Equal(t, signer, signerOfShare)
+		fromShares, err := inclusion.CreateCommitmentFromShares(blobShares, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.Equal(t, commitment, fromShares)
+
+		// the same data without a signer has another commitment
+		v0, err := inclusion.CreateCommitment(blob.New(ns1, data, shares.ShareVersionZero), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.NotEqual(t, v0, commitment)
+
+		// changing only the signer changes the commitment
+		other := blob.New(ns1, data, shares.ShareVersionOne)
+		other.Signer = bytes.Repeat([]byte{0x6}, blob.SignerSize)
+		otherCommitment, err := inclusion.CreateCommitment(other, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.NotEqual(t, commitment, otherCommitment)
+
+		fmt.Fprintf(&got, "shares %d signer %x commitment %x\n", shareCount, signer, commitment)
+		for _, root := range subtreeRoots {
+			fmt.Fprintf(&got, "%x\n", root)
+		}
+	}
+
+	path := filepath.Join("testdata", "commitment_v1.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(path, []byte(got.String()), 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), got.String())
+}
+
+func TestCreateCommitmentWithHasher(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	for _, shareCount := range []int{1, 3, 65, 129} {
+		data := bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(shareCount))
+		b := blob.New(ns1, data, shares.ShareVersionZero)
+		want, wantRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+
+		// SHA-256 is the default
+		for _, opt := range []inclusion.CommitmentOption{inclusion.WithHasher(sha256.New), inclusion.WithHasher(nil)} {
+			got, gotRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, opt)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+			assert.Equal(t, wantRoots, gotRoots)
+		}
+
+		// another hash function changes the digests but not the trees
+		got, gotRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithHasher(sha512.New512_256))
+		require.NoError(t, err)
+		assert.NotEqual(t, want, got)
+		require.Len(t, gotRoots, len(wantRoots))
+		for i := range gotRoots {
+			require.Len(t, gotRoots[i], len(wantRoots[i]))
+			assert.Equal(t, wantRoots[i][:2*namespace.NamespaceSize], gotRoots[i][:2*namespace.NamespaceSize])
+			assert.NotEqual(t, wantRoots[i][2*namespace.NamespaceSize:], gotRoots[i][2*namespace.NamespaceSize:])
+		}
+		assert.Equal(t, merkle.HashFromByteSlices(gotRoots), got)
+
+		// every entry point honors the hash function
+		withHasher := inclusion.WithHasher(sha512.New512_256)
+		commitment, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, withHasher)
+		require.NoError(t, err)
+		assert.Equal(t, got, commitment)
+		commitment, err = inclusion.CreateCommitmentParallel(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, 2, withHasher)
+		require.NoError(t, err)
+		assert.Equal(t, got, commitment)
+		commitment, err = inclusion.CreateCommitmentFromReader(ns1, shares.ShareVersionZero, len(data), bytes.NewReader(data), merkle.HashFromByteSlices, defaultSubtreeRootThreshold, withHasher)
+		require.NoError(t, err)
+		assert.Equal(t, got, commitment)
+		blobShares, err := shares.SplitBlobs(b)
+		require.NoError(t, err)
+		commitment, err = inclusion.CreateCommitmentFromShares(blobShares, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, withHasher)
+		require.NoError(t, err)
+		assert.Equal(t, got, commitment)
+		commitments, err := inclusion.CreateCommitments([]*blob.Blob{b, b}, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, inclusion.WithCommitmentCache(2), withHasher)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{got, got}, commitments)
+		ok, err := inclusion.VerifyCommitment(b, got, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, withHasher)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = inclusion.VerifyCommitment(b, got, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestCreateCommitmentFromShares(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		ns := namespace.MustNewV0(append(make([]byte, namespace.NamespaceVersionZeroIDSize-2), byte(1+rng.Intn(200)), 0))
+		data := make([]byte, 1+rng.Intn(100_000))
+		_, _ = rng.Read(data)
+		b := blob.New(ns, data, shares.ShareVersionZero)
+		if rng.Intn(2) == 0 {
+			b = blob.New(ns, data, shares.ShareVersionOne)
+			b.Signer = bytes.Repeat([]byte{0x5}, blob.SignerSize)
+		}
+		blobShares, err := shares.SplitBlobs(b)
+		require.NoError(t, err)
+		for _, subtreeRootThreshold := range []int{1, defaultSubtreeRootThreshold} {
+			want, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+			got, err := inclusion.CreateCommitmentFromShares(blobShares, merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "blob %d of %d bytes", i, len(data))
+		}
+	}
+}
+
+func TestCreateCommitmentFromSharesErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{0x2}, namespace.NamespaceVersionZeroIDSize))
+	split := func(blobs ...*blob.Blob) []shares.Share {
+		s, err := shares.SplitBlobs(blobs...)
+		require.NoError(t, err)
+		return s
+	}
+	threeShares := split(blob.New(ns1, bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(3)), shares.ShareVersionZero))
+	other := split(blob.New(ns2, bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(3)), shares.ShareVersionZero))
+	txShares, _, _, err := shares.SplitTxs([][]byte{[]byte("tx")})
+	require.NoError(t, err)
+	padding, err := shares.NamespacePaddingShare(ns1, shares.ShareVersionZero)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		shares []shares.Share
+	}{
+		{"no shares", nil},
+		{"two sequences", split(blob.New(ns1, []byte{1}, shares.ShareVersionZero), blob.New(ns1, []byte{2}, shares.ShareVersionZero))},
+		{"missing last share", threeShares[:2]},
+		{"missing first share", threeShares[1:]},
+		{"extra share", append(slices.Clone(threeShares), threeShares[2])},
+		{"mixed namespaces", append(slices.Clone(threeShares[:2]), other[2])},
+		{"namespace padding", []shares.Share{padding}},
+		{"blob followed by padding", append(slices.Clone(threeShares), padding)},
+		{"reserved padding", []shares.Share{shares.ReservedPaddingShare()}},
+		{"tail padding", []shares.Share{shares.TailPaddingShare()}},
+		{"compact shares", txShares},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := inclusion.CreateCommitmentFromShares(tc.shares, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+			assert.ErrorIs(t, err, inclusion.ErrNotSingleSequence)
+		})
+	}
+
+	_, err = inclusion.CreateCommitmentFromShares(threeShares, merkle.HashFromByteSlices, 0)
+	assert.ErrorIs(t, err, inclusion.ErrInvalidSubtreeRootThreshold)
+}
+
+func TestSubtreeRootCount(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	// the subtree width doubles after every multiple of the threshold that is
+	// a power of two
+	shareCounts := []int{1, 2, 3, 4, 5, 7, 8, 9, 31, 32, 33}
+	for _, boundary := range []int{64, 128, 256, 512, 1024} {
+		shareCounts = append(shareCounts, boundary-1, boundary, boundary+1)
+	}
+	for _, subtreeRootThreshold := range []int{1, 2, 16, defaultSubtreeRootThreshold} {
+		for _, shareCount := range shareCounts {
+			b := blob.New(ns1, bytes.Repeat([]byte{0xAB}, shares.AvailableBytesFromSparseShares(shareCount)), shares.ShareVersionZero)
+			_, subtreeRoots, err := inclusion.CreateCommitmentWithSubtreeRoots(b, merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+
+			count, err := inclusion.SubtreeRootCount(shareCount, subtreeRootThreshold)
+			require.NoError(t, err)
+			assert.Equal(t, len(subtreeRoots), count, "%d shares with threshold %d", shareCount, subtreeRootThreshold)
+
+			sizes, err := inclusion.SubtreeSizes(shareCount, subtreeRootThreshold)
+			require.NoError(t, err)
+			require.Len(t, sizes, count)
+			total := uint64(0)
+			for _, size := range sizes {
+				assert.True(t, shares.IsPowerOfTwo(size))
+				total += size
+			}
+			assert.Equal(t, uint64(shareCount), total)
+		}
+	}
+
+	testCases := []struct {
+		name                 string
+		blobShareLen         int
+		subtreeRootThreshold int
+		wantErr              error
+	}{
+		{"zero shares", 0, defaultSubtreeRootThreshold, inclusion.ErrInvalidBlobShareLen},
+		{"negative shares", -1, defaultSubtreeRootThreshold, inclusion.ErrInvalidBlobShareLen},
+		{"zero threshold", 1, 0, inclusion.ErrInvalidSubtreeRootThreshold},
+		{"negative threshold", 1, -64, inclusion.ErrInvalidSubtreeRootThreshold},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := inclusion.SubtreeRootCount(tc.blobShareLen, tc.subtreeRootThreshold)
+			assert.ErrorIs(t, err, tc.wantErr)
+			_, err = inclusion.SubtreeSizes(tc.blobShareLen, tc.subtreeRootThreshold)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestCreateCommitmentParallel(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	rng := rand.New(rand.NewSource(1))
+	sizes := []int{1, 1000, shares.AvailableBytesFromSparseShares(127), shares.AvailableBytesFromSparseShares(128), 512 * 1024}
+	for i := 0; i < 5; i++ {
+		sizes = append(sizes, 1+rng.Intn(1_000_000))
+	}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		_, _ = rng.Read(data)
+		b := blob.New(ns1, data, shares.ShareVersionZero)
+		for _, subtreeRootThreshold := range []int{1, defaultSubtreeRootThreshold} {
+			want, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, subtreeRootThreshold)
+			require.NoError(t, err)
+			for _, workers := range []int{-1, 0, 1, 2, 8} {
+				got, err := inclusion.CreateCommitmentParallel(b, merkle.HashFromByteSlices, subtreeRootThreshold, workers)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, "%d bytes with threshold %d and %d workers", size, subtreeRootThreshold, workers)
+			}
+		}
+	}
+
+	_, err := inclusion.CreateCommitmentParallel(blob.New(ns1, nil, shares.ShareVersionZero), merkle.HashFromByteSlices, defaultSubtreeRootThreshold, 4)
+	assert.ErrorIs(t, err, blob.ErrEmptyData)
+	_, err = inclusion.CreateCommitmentParallel(blob.New(ns1, []byte{1}, shares.ShareVersionZero), merkle.HashFromByteSlices, 0, 4)
+	assert.ErrorIs(t, err, inclusion.ErrInvalidSubtreeRootThreshold)
+}
+
+func BenchmarkCreateCommitmentParallel(b *testing.B) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	for _, size := range []int{512 * 1024, 2 * 1024 * 1024, 8 * 1024 * 1024} {
+		blb := blob.New(ns1, bytes.Repeat([]byte{0xAB}, size), shares.ShareVersionZero)
+		b.Run(fmt.Sprintf("%d KB sequential", size/1024), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := inclusion.CreateCommitment(blb, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+				require.NoError(b, err)
+			}
+		})
+		for _, workers := range []int{2, 8} {
+			b.Run(fmt.Sprintf("%d KB %d workers", size/1024, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, err := inclusion.CreateCommitmentParallel(blb, merkle.HashFromByteSlices, defaultSubtreeRootThreshold, workers)
+					require.NoError(b, err)
+				}
+			})
+		}
+	}
+}
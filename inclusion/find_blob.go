@@ -0,0 +1,60 @@
+package inclusion
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/celestiaorg/go-square/blob"
+	sh "github.com/celestiaorg/go-square/shares"
+)
+
+// ErrCommitmentNotFound is returned by FindBlobByCommitment when no blob of
+// the square has the share commitment.
+var ErrCommitmentNotFound = errors.New("no blob matches the share commitment")
+
+// FindBlobByCommitment returns the first blob of the data square s whose share
+// commitment is commitment, together with the range of shares it spans. The
+// blobs are located by parsing the shares after the compact shares, so the
+// share indexes of the PFBs aren't trusted. Blobs with identical content have
+// identical commitments: matches is the number of blobs in s with the
+// commitment. A square.Square can be passed as s. It returns an error wrapping
+// ErrCommitmentNotFound if no blob matches. The options are those of
+// CreateCommitments.
+func FindBlobByCommitment(s []sh.Share, commitment []byte, merkleRootFn MerkleRootFn, subtreeRootThreshold int, opts ...CommitmentOption) (b *blob.Blob, shareRange sh.Range, matches int, err error) {
+	start := 0
+	for ; start < len(s); start++ {
+		isCompact, err := s[start].IsCompactShare()
+		if err != nil {
+			return nil, sh.Range{}, 0, err
+		}
+		if !isCompact {
+			break
+		}
+	}
+	positions, err := sh.ParseBlobsWithPositions(s[start:])
+	if err != nil {
+		return nil, sh.Range{}, 0, err
+	}
+
+	blobs := make([]*blob.Blob, len(positions))
+	for i, position := range positions {
+		blobs[i] = position.Blob
+	}
+	commitments, err := CreateCommitments(blobs, merkleRootFn, subtreeRootThreshold, append([]CommitmentOption{WithCommitmentCache(len(blobs))}, opts...)...)
+	if err != nil {
+		return nil, sh.Range{}, 0, err
+	}
+	for i, candidate := range commitments {
+		if subtle.ConstantTimeCompare(candidate, commitment) != 1 {
+			continue
+		}
+		if matches == 0 {
+			b, shareRange = positions[i].Blob, positions[i].ShareRange.Shift(start)
+		}
+		matches++
+	}
+	if matches == 0 {
+		return nil, sh.Range{}, 0, ErrCommitmentNotFound
+	}
+	return b, shareRange, matches, nil
+}
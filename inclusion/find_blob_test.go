@@ -0,0 +1,71 @@
+package inclusion_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/internal/test"
+	"github.com/celestiaorg/go-square/merkle"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindBlobByCommitment(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{0x2}, namespace.NamespaceVersionZeroIDSize))
+	heartbeat := blob.New(ns2, bytes.Repeat([]byte{0xAB}, 2000), shares.ShareVersionZero)
+	decoy := blob.New(ns1, bytes.Repeat([]byte{0xCD}, 2000), shares.ShareVersionZero)
+	missing := blob.New(ns2, bytes.Repeat([]byte{0xEF}, 2000), shares.ShareVersionZero)
+
+	blobTx := func(b *blob.Blob) []byte {
+		tx, err := blob.MarshalBlobTx(test.MockPFB([]uint32{uint32(len(b.Data))}), b)
+		require.NoError(t, err)
+		return tx
+	}
+	txs := append(test.GenerateTxs(100, 200, 3), blobTx(heartbeat), blobTx(decoy), blobTx(heartbeat))
+	builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold, txs...)
+	require.NoError(t, err)
+	dataSquare, err := builder.Export()
+	require.NoError(t, err)
+	_, blobRanges, err := builder.ShareRanges()
+	require.NoError(t, err)
+
+	commitment := func(b *blob.Blob) []byte {
+		c, err := inclusion.CreateCommitment(b, merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("duplicate blobs return the first occurrence", func(t *testing.T) {
+		found, shareRange, matches, err := inclusion.FindBlobByCommitment(dataSquare, commitment(heartbeat), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.True(t, heartbeat.Equal(found))
+		assert.Equal(t, 2, matches)
+		// blobs are ordered by namespace so the first heartbeat follows the
+		// decoy
+		assert.Equal(t, blobRanges[3][0], shareRange)
+		assert.Less(t, blobRanges[4][0].Start, shareRange.Start)
+		assert.Less(t, shareRange.Start, blobRanges[5][0].Start)
+	})
+	t.Run("single blob", func(t *testing.T) {
+		found, shareRange, matches, err := inclusion.FindBlobByCommitment(dataSquare, commitment(decoy), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.True(t, decoy.Equal(found))
+		assert.Equal(t, 1, matches)
+		assert.Equal(t, blobRanges[4][0], shareRange)
+	})
+	t.Run("not found", func(t *testing.T) {
+		found, _, matches, err := inclusion.FindBlobByCommitment(dataSquare, commitment(missing), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		assert.ErrorIs(t, err, inclusion.ErrCommitmentNotFound)
+		assert.Nil(t, found)
+		assert.Zero(t, matches)
+
+		_, _, _, err = inclusion.FindBlobByCommitment(square.EmptySquare(), commitment(heartbeat), merkle.HashFromByteSlices, defaultSubtreeRootThreshold)
+		assert.ErrorIs(t, err, inclusion.ErrCommitmentNotFound)
+	})
+}
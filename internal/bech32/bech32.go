@@ -0,0 +1,200 @@
+// Package bech32 implements the bech32 encoding of BIP-173 for byte strings.
+// Strings are encoded in lower case; decoding accepts all lower case or all
+// upper case strings and rejects mixed case ones.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxLength is the maximum length of a bech32 string.
+const MaxLength = 90
+
+const (
+	charset       = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	separator     = '1'
+	checksumLen   = 6
+	checksumConst = 1
+)
+
+var (
+	// ErrMixedCase is returned when a string contains both lower and upper
+	// case characters.
+	ErrMixedCase = errors.New("bech32: mixed case")
+	// ErrInvalidChecksum is returned when the checksum of a string doesn't
+	// match its human-readable part and data.
+	ErrInvalidChecksum = errors.New("bech32: invalid checksum")
+	// ErrInvalidLength is returned when a string is longer than MaxLength or
+	// too short to hold a separator and a checksum.
+	ErrInvalidLength = errors.New("bech32: invalid length")
+	// ErrInvalidCharacter is returned when a string contains a character that
+	// isn't allowed in its part.
+	ErrInvalidCharacter = errors.New("bech32: invalid character")
+	// ErrInvalidPadding is returned when the data doesn't convert back to
+	// whole bytes with zero padding.
+	ErrInvalidPadding = errors.New("bech32: invalid padding")
+)
+
+// charsetRev maps a character of charset to its value and every other byte
+// to -1.
+var charsetRev = func() (rev [256]int8) {
+	for i := range rev {
+		rev[i] = -1
+	}
+	for i, c := range charset {
+		rev[c] = int8(i)
+	}
+	return rev
+}()
+
+// Encode returns the bech32 encoding of data with the human-readable part
+// hrp. It returns an error if hrp is empty or contains invalid characters or
+// if the encoding is longer than MaxLength.
+func Encode(hrp string, data []byte) (string, error) {
+	if err := checkHRP(hrp); err != nil {
+		return "", err
+	}
+	hrp = strings.ToLower(hrp)
+	values := convertBits(data, 8, 5, true)
+	length := len(hrp) + 1 + len(values) + checksumLen
+	if length > MaxLength {
+		return "", fmt.Errorf("%w: encoding of %d bytes with a human-readable part of %d characters is %d characters long but the maximum is %d", ErrInvalidLength, len(data), len(hrp), length, MaxLength)
+	}
+	var sb strings.Builder
+	sb.Grow(length)
+	sb.WriteString(hrp)
+	sb.WriteByte(separator)
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	for _, v := range checksum(hrp, values) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String(), nil
+}
+
+// Decode returns the lower case human-readable part and the data of the
+// bech32 string s.
+func Decode(s string) (hrp string, data []byte, err error) {
+	if len(s) > MaxLength {
+		return "", nil, fmt.Errorf("%w: %d characters but the maximum is %d", ErrInvalidLength, len(s), MaxLength)
+	}
+	var hasLower, hasUpper bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			return "", nil, fmt.Errorf("%w: %q at position %d", ErrInvalidCharacter, c, i)
+		}
+		hasLower = hasLower || ('a' <= c && c <= 'z')
+		hasUpper = hasUpper || ('A' <= c && c <= 'Z')
+	}
+	if hasLower && hasUpper {
+		return "", nil, ErrMixedCase
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, separator)
+	if sep < 1 || sep+1+checksumLen > len(s) {
+		return "", nil, fmt.Errorf("%w: %q has no human-readable part or checksum", ErrInvalidLength, s)
+	}
+	hrp = s[:sep]
+	if err := checkHRP(hrp); err != nil {
+		return "", nil, err
+	}
+	values := make([]byte, len(s)-sep-1)
+	for i := range values {
+		c := s[sep+1+i]
+		v := charsetRev[c]
+		if v < 0 {
+			return "", nil, fmt.Errorf("%w: %q at position %d", ErrInvalidCharacter, c, sep+1+i)
+		}
+		values[i] = byte(v)
+	}
+	if polymod(hrp, values) != checksumConst {
+		return "", nil, ErrInvalidChecksum
+	}
+	values = values[:len(values)-checksumLen]
+	data = convertBits(values, 5, 8, false)
+	if data == nil {
+		return "", nil, ErrInvalidPadding
+	}
+	return hrp, data, nil
+}
+
+// checkHRP returns an error if hrp is empty or contains characters outside of
+// the US-ASCII range 33 to 126.
+func checkHRP(hrp string) error {
+	if len(hrp) == 0 {
+		return fmt.Errorf("%w: empty human-readable part", ErrInvalidLength)
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return fmt.Errorf("%w: %q in the human-readable part", ErrInvalidCharacter, hrp[i])
+		}
+	}
+	return nil
+}
+
+// checksum returns the checksum of hrp and the 5 bit values.
+func checksum(hrp string, values []byte) []byte {
+	mod := polymod(hrp, append(values[:len(values):len(values)], make([]byte, checksumLen)...)) ^ checksumConst
+	sum := make([]byte, checksumLen)
+	for i := range sum {
+		sum[i] = byte(mod>>(5*(checksumLen-1-i))) & 31
+	}
+	return sum
+}
+
+// polymod returns the BCH checksum of the expanded hrp followed by values.
+func polymod(hrp string, values []byte) uint32 {
+	chk := uint32(1)
+	step := func(v byte) {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3} {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] >> 5)
+	}
+	step(0)
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] & 31)
+	}
+	for _, v := range values {
+		step(v)
+	}
+	return chk
+}
+
+// convertBits regroups the fromBits wide values of data into toBits wide
+// values. With pad the last value is padded with zero bits, otherwise nil is
+// returned if the leftover bits are more than fromBits - 1 or not zero.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var (
+		acc  uint32
+		bits uint
+		out  = make([]byte, 0, (len(data)*int(fromBits)+int(toBits)-1)/int(toBits))
+		mask = uint32(1)<<toBits - 1
+	)
+	for _, v := range data {
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&mask))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&mask))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&mask != 0 {
+		return nil
+	}
+	return out
+}
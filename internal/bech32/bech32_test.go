@@ -0,0 +1,95 @@
+package bech32
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The test vectors of BIP-173.
+func TestValidChecksums(t *testing.T) {
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11" + strings.Repeat("q", 82) + "c8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	} {
+		t.Run(s, func(t *testing.T) {
+			hrp, data, err := Decode(s)
+			if errors.Is(err, ErrInvalidPadding) {
+				// the checksum is valid but the data isn't whole bytes
+				return
+			}
+			require.NoError(t, err)
+			encoded, err := Encode(hrp, data)
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToLower(s), encoded)
+		})
+	}
+}
+
+func TestInvalidStrings(t *testing.T) {
+	testCases := []struct {
+		s       string
+		wantErr error
+	}{
+		{"\x201nwldj5", ErrInvalidCharacter},
+		{"\x7f1axkwrx", ErrInvalidCharacter},
+		{"\x801eym55h", ErrInvalidCharacter},
+		{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", ErrInvalidLength},
+		{"pzry9x0s0muk", ErrInvalidLength},
+		{"1pzry9x0s0muk", ErrInvalidLength},
+		{"x1b4n0q5v", ErrInvalidCharacter},
+		{"li1dgmt3", ErrInvalidLength},
+		{"de1lg7wt\xff", ErrInvalidCharacter},
+		{"A1G7SGD8", ErrInvalidChecksum},
+		{"10a06t8", ErrInvalidLength},
+		{"1qzzfhee", ErrInvalidLength},
+		{"A12uEL5L", ErrMixedCase},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			_, _, err := Decode(tc.s)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	encoded, err := Encode("ABC", []byte{0x00, 0xff, 0x10})
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToLower(encoded), encoded)
+	hrp, data, err := Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", hrp)
+	assert.Equal(t, []byte{0x00, 0xff, 0x10}, data)
+
+	_, err = Encode("", []byte{1})
+	assert.ErrorIs(t, err, ErrInvalidLength)
+	_, err = Encode("a b", []byte{1})
+	assert.ErrorIs(t, err, ErrInvalidCharacter)
+	_, err = Encode("a", make([]byte, 60))
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestDecodeInvalidPadding(t *testing.T) {
+	// a single 5 bit value can't hold a byte
+	encoded := "a1" + string(charset[1])
+	encoded += encodeChecksum("a", []byte{1})
+	_, _, err := Decode(encoded)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+}
+
+func encodeChecksum(hrp string, values []byte) string {
+	var sb strings.Builder
+	for _, v := range checksum(hrp, values) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+// Package cbor implements the small subset of canonical CBOR (RFC 8949) needed
+// to encode go-square types: unsigned integers, byte strings, text strings,
+// arrays and maps. Only definite-length items with minimally encoded heads are produced
+// and accepted so that every value has exactly one encoding.
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	majorUint  = 0
+	majorBytes = 2
+	majorText  = 3
+	majorArray = 4
+	majorMap   = 5
+)
+
+var (
+	// ErrUnexpectedEOF is returned when the input ends in the middle of an item.
+	ErrUnexpectedEOF = errors.New("cbor: unexpected end of input")
+	// ErrNonCanonical is returned when an item is not encoded canonically.
+	ErrNonCanonical = errors.New("cbor: non-canonical encoding")
+)
+
+// AppendUint appends v encoded as a CBOR unsigned integer to dst.
+func AppendUint(dst []byte, v uint64) []byte {
+	return appendHead(dst, majorUint, v)
+}
+
+// AppendBytes appends b encoded as a definite-length CBOR byte string to dst.
+func AppendBytes(dst []byte, b []byte) []byte {
+	dst = appendHead(dst, majorBytes, uint64(len(b)))
+	return append(dst, b...)
+}
+
+// AppendText appends s encoded as a definite-length CBOR text string to dst.
+func AppendText(dst []byte, s string) []byte {
+	dst = appendHead(dst, majorText, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// AppendMapHeader appends the head of a definite-length CBOR map with n
+// entries to dst. The caller is responsible for appending the n key/value
+// pairs in canonical key order.
+func AppendMapHeader(dst []byte, n int) []byte {
+	return appendHead(dst, majorMap, uint64(n))
+}
+
+// AppendArrayHeader appends the head of a definite-length CBOR array with n
+// items to dst. The caller is responsible for appending the n items.
+func AppendArrayHeader(dst []byte, n int) []byte {
+	return appendHead(dst, majorArray, uint64(n))
+}
+
+func appendHead(dst []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch {
+	case arg < 24:
+		return append(dst, m|byte(arg))
+	case arg <= 0xff:
+		return append(dst, m|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(dst, m|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(dst, m|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, m|27), arg)
+	}
+}
+
+// Decoder reads canonical CBOR items from a byte slice.
+type Decoder struct {
+	data []byte
+}
+
+// NewDecoder returns a decoder that reads from data.
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+// ReadUint reads a CBOR unsigned integer.
+func (d *Decoder) ReadUint() (uint64, error) {
+	return d.readHead(majorUint)
+}
+
+// ReadBytes reads a definite-length CBOR byte string. The returned slice
+// aliases the decoder's input.
+func (d *Decoder) ReadBytes() ([]byte, error) {
+	n, err := d.readHead(majorBytes)
+	if err != nil {
+		return nil, err
+	}
+	return d.readN(n)
+}
+
+// ReadText reads a definite-length CBOR text string.
+func (d *Decoder) ReadText() (string, error) {
+	n, err := d.readHead(majorText)
+	if err != nil {
+		return "", err
+	}
+	b, err := d.readN(n)
+	return string(b), err
+}
+
+// ReadMapHeader reads the head of a definite-length CBOR map and returns the
+// number of key/value pairs that follow.
+func (d *Decoder) ReadMapHeader() (int, error) {
+	n, err := d.readHead(majorMap)
+	if err != nil {
+		return 0, err
+	}
+	// every entry needs at least two bytes so this bounds n by the input size
+	if n > uint64(len(d.data)/2) {
+		return 0, ErrUnexpectedEOF
+	}
+	return int(n), nil
+}
+
+// ReadArrayHeader reads the head of a definite-length CBOR array and returns
+// the number of items that follow.
+func (d *Decoder) ReadArrayHeader() (int, error) {
+	n, err := d.readHead(majorArray)
+	if err != nil {
+		return 0, err
+	}
+	// every item needs at least one byte so this bounds n by the input size
+	if n > uint64(len(d.data)) {
+		return 0, ErrUnexpectedEOF
+	}
+	return int(n), nil
+}
+
+// Finish returns an error if there are unread bytes left in the input.
+func (d *Decoder) Finish() error {
+	if len(d.data) != 0 {
+		return fmt.Errorf("cbor: %d trailing bytes", len(d.data))
+	}
+	return nil
+}
+
+func (d *Decoder) readN(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)) {
+		return nil, ErrUnexpectedEOF
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *Decoder) readHead(major byte) (uint64, error) {
+	if len(d.data) == 0 {
+		return 0, ErrUnexpectedEOF
+	}
+	initial := d.data[0]
+	if initial>>5 != major {
+		return 0, fmt.Errorf("cbor: expected major type %d but got %d", major, initial>>5)
+	}
+	info := initial & 0x1f
+	d.data = d.data[1:]
+
+	var arg, min uint64
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		b, err := d.readN(1)
+		if err != nil {
+			return 0, err
+		}
+		arg, min = uint64(b[0]), 24
+	case info == 25:
+		b, err := d.readN(2)
+		if err != nil {
+			return 0, err
+		}
+		arg, min = uint64(binary.BigEndian.Uint16(b)), 0x100
+	case info == 26:
+		b, err := d.readN(4)
+		if err != nil {
+			return 0, err
+		}
+		arg, min = uint64(binary.BigEndian.Uint32(b)), 0x10000
+	case info == 27:
+		b, err := d.readN(8)
+		if err != nil {
+			return 0, err
+		}
+		arg, min = binary.BigEndian.Uint64(b), 0x100000000
+	default:
+		// indefinite lengths (31) and reserved values (28-30) are not canonical
+		return 0, ErrNonCanonical
+	}
+	if arg < min {
+		return 0, ErrNonCanonical
+	}
+	return arg, nil
+}
@@ -0,0 +1,113 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendUint(t *testing.T) {
+	testCases := []struct {
+		value uint64
+		want  string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{255, "18ff"},
+		{256, "190100"},
+		{65536, "1a00010000"},
+		{1 << 32, "1b0000000100000000"},
+	}
+	for _, tc := range testCases {
+		got := AppendUint(nil, tc.value)
+		assert.Equal(t, tc.want, hex.EncodeToString(got))
+
+		v, err := NewDecoder(got).ReadUint()
+		require.NoError(t, err)
+		assert.Equal(t, tc.value, v)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab}, 512)
+	b := AppendMapHeader(nil, 2)
+	b = AppendText(b, "data")
+	b = AppendBytes(b, data)
+	b = AppendText(b, "n")
+	b = AppendUint(b, 7)
+
+	d := NewDecoder(b)
+	n, err := d.ReadMapHeader()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	key, err := d.ReadText()
+	require.NoError(t, err)
+	assert.Equal(t, "data", key)
+	got, err := d.ReadBytes()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	key, err = d.ReadText()
+	require.NoError(t, err)
+	assert.Equal(t, "n", key)
+	v, err := d.ReadUint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), v)
+	assert.NoError(t, d.Finish())
+}
+
+func TestDecoderErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		read  func(d *Decoder) error
+	}{
+		{"empty input", "", func(d *Decoder) error { _, err := d.ReadUint(); return err }},
+		{"wrong major type", "40", func(d *Decoder) error { _, err := d.ReadUint(); return err }},
+		{"non minimal head", "1817", func(d *Decoder) error { _, err := d.ReadUint(); return err }},
+		{"indefinite length", "5f", func(d *Decoder) error { _, err := d.ReadBytes(); return err }},
+		{"truncated byte string", "43aabb", func(d *Decoder) error { _, err := d.ReadBytes(); return err }},
+		{"map larger than input", "b8ff", func(d *Decoder) error { _, err := d.ReadMapHeader(); return err }},
+		{"trailing bytes", "0000", func(d *Decoder) error {
+			if _, err := d.ReadUint(); err != nil {
+				return err
+			}
+			return d.Finish()
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input, err := hex.DecodeString(tc.input)
+			require.NoError(t, err)
+			assert.Error(t, tc.read(NewDecoder(input)))
+		})
+	}
+}
+
+func TestArray(t *testing.T) {
+	b := AppendArrayHeader(nil, 2)
+	b = AppendUint(b, 1)
+	b = AppendText(b, "a")
+	assert.Equal(t, "82016161", hex.EncodeToString(b))
+
+	d := NewDecoder(b)
+	n, err := d.ReadArrayHeader()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	v, err := d.ReadUint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), v)
+	text, err := d.ReadText()
+	require.NoError(t, err)
+	assert.Equal(t, "a", text)
+	assert.NoError(t, d.Finish())
+
+	// an array can't have more items than bytes left
+	_, err = NewDecoder([]byte{0x83, 0x01, 0x01}).ReadArrayHeader()
+	assert.ErrorIs(t, err, ErrUnexpectedEOF)
+	_, err = NewDecoder([]byte{0xa1, 0x01, 0x01}).ReadArrayHeader()
+	assert.Error(t, err)
+}
@@ -2,13 +2,12 @@ package test
 
 import (
 	crand "crypto/rand"
-	"encoding/binary"
-	"fmt"
 	"math/rand"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/testutil"
 )
 
 var DefaultTestNamespace = namespace.MustNewV0([]byte("test"))
@@ -69,30 +68,15 @@ func GenerateBlobTxs(numTxs, blobsPerPfb, blobSize int) [][]byte {
 	return txs
 }
 
-const mockPFBExtraBytes = 329
-
+// MockPFB returns a stand-in for a PFB transaction that pays for blobs of the
+// provided sizes. See testutil.MockPFB.
 func MockPFB(blobSizes []uint32) []byte {
-	if len(blobSizes) == 0 {
-		panic("must have at least one blob")
-	}
-	tx := make([]byte, len(blobSizes)*4)
-	for i, size := range blobSizes {
-		binary.BigEndian.PutUint32(tx[i*4:], uint32(size))
-	}
-
-	return append(RandomBytes(mockPFBExtraBytes), tx...)
+	return testutil.MockPFB(rand.New(rand.NewSource(rand.Int63())), blobSizes)
 }
 
+// DecodeMockPFB returns the blob sizes of a PFB returned by MockPFB.
 func DecodeMockPFB(pfb []byte) ([]uint32, error) {
-	if len(pfb) < mockPFBExtraBytes+4 {
-		return nil, fmt.Errorf("must have a length of at least %d bytes, got %d", mockPFBExtraBytes+4, len(pfb))
-	}
-	pfb = pfb[mockPFBExtraBytes:]
-	blobSizes := make([]uint32, len(pfb)/4)
-	for i := 0; i < len(blobSizes); i++ {
-		blobSizes[i] = binary.BigEndian.Uint32(pfb[i*4 : (i+1)*4])
-	}
-	return blobSizes, nil
+	return testutil.DecodeMockPFB(pfb)
 }
 
 func toUint32(arr []int) []uint32 {
@@ -0,0 +1,55 @@
+package merkle
+
+// IncrementalHasher computes the root of HashFromByteSlices over leaves that
+// are added one at a time. Since the tree splits at the largest power of two
+// less than the number of leaves, it is made of perfect subtrees of decreasing
+// size, one per set bit of the leaf count. Only the roots of those subtrees
+// are kept so the memory used is logarithmic in the number of leaves.
+//
+// The zero value is an IncrementalHasher without leaves.
+type IncrementalHasher struct {
+	// peaks are the roots of the perfect subtrees from the largest to the
+	// smallest, heights[i] is the height of peaks[i].
+	peaks   [][]byte
+	heights []int
+	count   int
+}
+
+// NewIncrementalHasher returns an IncrementalHasher without leaves.
+func NewIncrementalHasher() *IncrementalHasher {
+	return &IncrementalHasher{}
+}
+
+// Add appends leaf to the leaves of the tree.
+func (h *IncrementalHasher) Add(leaf []byte) {
+	peak, height := leafHash(leaf), 0
+	// merge the subtrees of equal size, like the carries of a binary increment
+	for len(h.peaks) > 0 && h.heights[len(h.heights)-1] == height {
+		last := len(h.peaks) - 1
+		peak = innerHash(h.peaks[last], peak)
+		height++
+		h.peaks, h.heights = h.peaks[:last], h.heights[:last]
+	}
+	h.peaks = append(h.peaks, peak)
+	h.heights = append(h.heights, height)
+	h.count++
+}
+
+// Count returns the number of leaves added.
+func (h *IncrementalHasher) Count() int {
+	return h.count
+}
+
+// Root returns the root of the tree over the leaves added so far. It is equal
+// to HashFromByteSlices of those leaves. Leaves can still be added afterwards.
+func (h *IncrementalHasher) Root() []byte {
+	if len(h.peaks) == 0 {
+		return emptyHash()
+	}
+	// every subtree is the left child of the tree over the smaller ones
+	root := h.peaks[len(h.peaks)-1]
+	for i := len(h.peaks) - 2; i >= 0; i-- {
+		root = innerHash(h.peaks[i], root)
+	}
+	return root
+}
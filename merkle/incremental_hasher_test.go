@@ -0,0 +1,60 @@
+package merkle
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementalHasher(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	items := make([][]byte, 3000)
+	for i := range items {
+		items[i] = make([]byte, rng.Intn(64))
+		_, _ = rng.Read(items[i])
+	}
+
+	h := NewIncrementalHasher()
+	assert.Zero(t, h.Count())
+	assert.Equal(t, HashFromByteSlices(nil), h.Root())
+	for i, item := range items {
+		h.Add(item)
+		require.Equal(t, i+1, h.Count())
+		require.Equal(t, HashFromByteSlices(items[:i+1]), h.Root(), "%d leaves", i+1)
+		// Root doesn't change the state of the hasher
+		require.Equal(t, HashFromByteSlices(items[:i+1]), h.Root(), "%d leaves", i+1)
+		// one peak per set bit of the leaf count
+		require.Len(t, h.peaks, bits.OnesCount(uint(i+1)))
+	}
+
+	t.Run("zero value", func(t *testing.T) {
+		var h IncrementalHasher
+		assert.Equal(t, emptyHash(), h.Root())
+		h.Add(items[0])
+		assert.Equal(t, leafHash(items[0]), h.Root())
+	})
+}
+
+func BenchmarkIncrementalHasher(b *testing.B) {
+	items := make([][]byte, 1<<14)
+	for i := range items {
+		items[i] = randBytes(512)
+	}
+	b.Run("incremental", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h := NewIncrementalHasher()
+			for _, item := range items {
+				h.Add(item)
+			}
+			_ = h.Root()
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = HashFromByteSlices(items)
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MultiProofVersion is the version byte that starts the binary encoding of a
+// MultiProof.
+const MultiProofVersion byte = 1
+
+// MultiProof proves that several leaves are included under the root of one
+// tree. Siblings that the proofs of the leaves would have in common are only
+// included once, so for leaves that are close to each other the proof is much
+// smaller than the sum of their single proofs.
+type MultiProof struct {
+	// Total is the number of leaves of the tree.
+	Total int64 `json:"total"`
+	// Indexes are the indexes of the proven leaves in ascending order.
+	Indexes []int64 `json:"indexes"`
+	// Siblings are the roots of the largest subtrees that contain none of
+	// the proven leaves in left to right order.
+	Siblings [][]byte `json:"siblings"`
+}
+
+// MultiProofFromByteSlices returns the root of the tree over items and a proof
+// that the items at indexes are included under it. The indexes may be in any
+// order and contain duplicates. It returns an error if there are no items or
+// indexes or if an index is out of range.
+func MultiProofFromByteSlices(items [][]byte, indexes []int) (root []byte, mp *MultiProof, err error) {
+	if len(items) == 0 {
+		return nil, nil, errors.New("cannot prove leaves of an empty tree")
+	}
+	if len(indexes) == 0 {
+		return nil, nil, errors.New("no indexes to prove")
+	}
+	sorted := append([]int(nil), indexes...)
+	sort.Ints(sorted)
+	mp = &MultiProof{Total: int64(len(items))}
+	for i, index := range sorted {
+		if index < 0 || index >= len(items) {
+			return nil, nil, fmt.Errorf("index %d out of range for %d items", index, len(items))
+		}
+		if i > 0 && index == sorted[i-1] {
+			continue
+		}
+		mp.Indexes = append(mp.Indexes, int64(index))
+	}
+	multiSiblings(items, 0, mp.Indexes, &mp.Siblings)
+	return HashFromByteSlices(items), mp, nil
+}
+
+// Verify checks that the proof proves that leaves, keyed by their index, are
+// included under root. The leaves must be exactly the leaves at the indexes
+// of the proof.
+func (mp *MultiProof) Verify(root []byte, leaves map[int][]byte) error {
+	if root == nil {
+		return errors.New("invalid root hash: cannot be nil")
+	}
+	if err := mp.ValidateBasic(); err != nil {
+		return err
+	}
+	if len(leaves) != len(mp.Indexes) {
+		return fmt.Errorf("proof is for %d leaves but %d were provided", len(mp.Indexes), len(leaves))
+	}
+	hashes := make([][]byte, len(mp.Indexes))
+	for i, index := range mp.Indexes {
+		leaf, ok := leaves[int(index)]
+		if !ok {
+			return fmt.Errorf("missing leaf at index %d", index)
+		}
+		hashes[i] = leafHash(leaf)
+	}
+	computed, rest, err := multiRootRec(mp.Total, 0, mp.Indexes, hashes, mp.Siblings)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("%d unused siblings", len(rest))
+	}
+	if !bytes.Equal(computed, root) {
+		return fmt.Errorf("invalid root hash: wanted %X got %X", root, computed)
+	}
+	return nil
+}
+
+// ValidateBasic performs basic validation: the indexes must be non-empty,
+// strictly ascending and less than Total, and every sibling must be of size
+// sha256.Size.
+func (mp *MultiProof) ValidateBasic() error {
+	if mp.Total <= 0 {
+		return errors.New("proof total must be positive")
+	}
+	if len(mp.Indexes) == 0 {
+		return errors.New("proof has no indexes")
+	}
+	for i, index := range mp.Indexes {
+		if index < 0 || index >= mp.Total {
+			return fmt.Errorf("index %d out of range for %d leaves", index, mp.Total)
+		}
+		if i > 0 && index <= mp.Indexes[i-1] {
+			return fmt.Errorf("indexes are not strictly ascending: %d after %d", index, mp.Indexes[i-1])
+		}
+	}
+	for i, sibling := range mp.Siblings {
+		if len(sibling) != sha256.Size {
+			return fmt.Errorf("expected Siblings#%d size to be %d, got %d", i, sha256.Size, len(sibling))
+		}
+	}
+	return nil
+}
+
+// MarshalBinary encodes the proof as MultiProofVersion followed by the uvarint
+// encoded total, the number of indexes, the indexes as deltas from the
+// previous index, the number of siblings and the siblings.
+func (mp *MultiProof) MarshalBinary() ([]byte, error) {
+	if err := mp.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	bz := make([]byte, 0, 1+binary.MaxVarintLen64*(3+len(mp.Indexes))+len(mp.Siblings)*sha256.Size)
+	bz = append(bz, MultiProofVersion)
+	bz = binary.AppendUvarint(bz, uint64(mp.Total))
+	bz = binary.AppendUvarint(bz, uint64(len(mp.Indexes)))
+	prev := int64(0)
+	for _, index := range mp.Indexes {
+		bz = binary.AppendUvarint(bz, uint64(index-prev))
+		prev = index
+	}
+	bz = binary.AppendUvarint(bz, uint64(len(mp.Siblings)))
+	for _, sibling := range mp.Siblings {
+		bz = append(bz, sibling...)
+	}
+	return bz, nil
+}
+
+// UnmarshalBinary decodes a proof encoded by MarshalBinary. It returns an
+// error if the version is unknown, the encoding is truncated or has trailing
+// bytes, or the decoded proof fails ValidateBasic.
+func (mp *MultiProof) UnmarshalBinary(bz []byte) error {
+	if len(bz) == 0 {
+		return errors.New("empty multiproof")
+	}
+	if bz[0] != MultiProofVersion {
+		return fmt.Errorf("unsupported multiproof version %d", bz[0])
+	}
+	bz = bz[1:]
+	readUvarint := func(name string) (uint64, error) {
+		v, n := binary.Uvarint(bz)
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid %s", name)
+		}
+		bz = bz[n:]
+		return v, nil
+	}
+
+	total, err := readUvarint("total")
+	if err != nil {
+		return err
+	}
+	count, err := readUvarint("number of indexes")
+	if err != nil {
+		return err
+	}
+	// every index takes at least one byte
+	if count > uint64(len(bz)) {
+		return fmt.Errorf("%d indexes don't fit in %d bytes", count, len(bz))
+	}
+	decoded := MultiProof{Total: int64(total), Indexes: make([]int64, count)}
+	prev := uint64(0)
+	for i := range decoded.Indexes {
+		delta, err := readUvarint("index")
+		if err != nil {
+			return err
+		}
+		if delta > total-prev {
+			return fmt.Errorf("index delta %d out of range for %d leaves", delta, total)
+		}
+		prev += delta
+		decoded.Indexes[i] = int64(prev)
+	}
+	count, err = readUvarint("number of siblings")
+	if err != nil {
+		return err
+	}
+	if count > uint64(len(bz))/sha256.Size || count*sha256.Size != uint64(len(bz)) {
+		return fmt.Errorf("expected %d siblings, got %d bytes", count, len(bz))
+	}
+	for i := uint64(0); i < count; i++ {
+		decoded.Siblings = append(decoded.Siblings, append([]byte(nil), bz[i*sha256.Size:(i+1)*sha256.Size]...))
+	}
+	if err := decoded.ValidateBasic(); err != nil {
+		return err
+	}
+	*mp = decoded
+	return nil
+}
+
+// multiSiblings appends to siblings the roots of the largest subtrees of the
+// tree over items that contain none of the indexes, in left to right order.
+// The indexes are sorted and offset is the index of items[0].
+func multiSiblings(items [][]byte, offset int64, indexes []int64, siblings *[][]byte) {
+	if len(indexes) == 0 {
+		*siblings = append(*siblings, HashFromByteSlices(items))
+		return
+	}
+	if len(items) == 1 {
+		return
+	}
+	k := getSplitPoint(int64(len(items)))
+	split := sort.Search(len(indexes), func(i int) bool { return indexes[i] >= offset+k })
+	multiSiblings(items[:k], offset, indexes[:split], siblings)
+	multiSiblings(items[k:], offset+k, indexes[split:], siblings)
+}
+
+// multiRootRec returns the root of a subtree of total leaves starting at
+// offset given the sorted indexes of the proven leaves in it, their hashes and
+// the siblings returned by multiSiblings. It also returns the siblings it
+// didn't use.
+func multiRootRec(total, offset int64, indexes []int64, hashes [][]byte, siblings [][]byte) ([]byte, [][]byte, error) {
+	if len(indexes) == 0 {
+		if len(siblings) == 0 {
+			return nil, nil, errors.New("missing sibling")
+		}
+		return siblings[0], siblings[1:], nil
+	}
+	if total == 1 {
+		return hashes[0], siblings, nil
+	}
+	k := getSplitPoint(total)
+	split := sort.Search(len(indexes), func(i int) bool { return indexes[i] >= offset+k })
+	left, siblings, err := multiRootRec(k, offset, indexes[:split], hashes[:split], siblings)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, siblings, err := multiRootRec(total-k, offset+k, indexes[split:], hashes[split:], siblings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return innerHash(left, right), siblings, nil
+}
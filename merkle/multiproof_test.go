@@ -0,0 +1,179 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomItems returns n random 32 byte items.
+func randomItems(rng *rand.Rand, n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = make([]byte, 32)
+		_, _ = rng.Read(items[i])
+	}
+	return items
+}
+
+// leavesAt returns the items at indexes keyed by their index.
+func leavesAt(items [][]byte, indexes []int) map[int][]byte {
+	leaves := make(map[int][]byte, len(indexes))
+	for _, index := range indexes {
+		leaves[index] = items[index]
+	}
+	return leaves
+}
+
+func TestMultiProof(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	items := randomItems(rng, 100)
+	_, proofs := ProofsFromByteSlices(items)
+
+	testCases := []struct {
+		name    string
+		indexes []int
+		// wantSmaller is whether the multiproof must be smaller than half of
+		// the aunts of the single proofs
+		wantSmaller bool
+	}{
+		{name: "single leaf", indexes: []int{42}},
+		{name: "clustered", indexes: []int{32, 33, 34, 35, 36, 37, 38, 39}, wantSmaller: true},
+		{name: "clustered unsorted with duplicates", indexes: []int{39, 33, 32, 36, 34, 35, 37, 38, 33}, wantSmaller: true},
+		{name: "scattered", indexes: []int{0, 13, 27, 50, 77, 99}},
+		{name: "all leaves", indexes: func() []int {
+			all := make([]int, len(items))
+			for i := range all {
+				all[i] = i
+			}
+			return all
+		}(), wantSmaller: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, mp, err := MultiProofFromByteSlices(items, tc.indexes)
+			require.NoError(t, err)
+			assert.Equal(t, HashFromByteSlices(items), root)
+			require.NoError(t, mp.Verify(root, leavesAt(items, tc.indexes)))
+
+			bz, err := mp.MarshalBinary()
+			require.NoError(t, err)
+			var decoded MultiProof
+			require.NoError(t, decoded.UnmarshalBinary(bz))
+			assert.Equal(t, *mp, decoded)
+			require.NoError(t, decoded.Verify(root, leavesAt(items, tc.indexes)))
+
+			// the single proofs of the unique indexes
+			singleBytes := 0
+			for index := range leavesAt(items, tc.indexes) {
+				singleBytes += len(proofs[index].Aunts) * sha256.Size
+			}
+			assert.LessOrEqual(t, len(mp.Siblings)*sha256.Size, singleBytes)
+			if tc.wantSmaller {
+				assert.Less(t, len(bz), singleBytes/2, "multiproof of %d bytes isn't much smaller than %d bytes of single proofs", len(bz), singleBytes)
+			}
+		})
+	}
+}
+
+func TestMultiProofExhaustive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for total := 1; total <= 9; total++ {
+		items := randomItems(rng, total)
+		for set := 1; set < 1<<total; set++ {
+			var indexes []int
+			for i := 0; i < total; i++ {
+				if set&(1<<i) != 0 {
+					indexes = append(indexes, i)
+				}
+			}
+			root, mp, err := MultiProofFromByteSlices(items, indexes)
+			require.NoError(t, err)
+			require.NoError(t, mp.Verify(root, leavesAt(items, indexes)), "total %d indexes %v", total, indexes)
+		}
+	}
+}
+
+func TestMultiProofFromByteSlicesErrors(t *testing.T) {
+	items := randomItems(rand.New(rand.NewSource(1)), 4)
+	for _, indexes := range [][]int{nil, {-1}, {4}, {0, 5}} {
+		_, _, err := MultiProofFromByteSlices(items, indexes)
+		assert.Error(t, err, indexes)
+	}
+	_, _, err := MultiProofFromByteSlices(nil, []int{0})
+	assert.Error(t, err)
+}
+
+func TestMultiProofVerifyInvalidProofs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	items := randomItems(rng, 16)
+	indexes := []int{2, 3, 9, 14}
+	root, valid, err := MultiProofFromByteSlices(items, indexes)
+	require.NoError(t, err)
+	require.NoError(t, valid.Verify(root, leavesAt(items, indexes)))
+
+	testCases := []struct {
+		name   string
+		mutate func(mp *MultiProof, leaves map[int][]byte)
+	}{
+		{"swapped siblings", func(mp *MultiProof, _ map[int][]byte) {
+			mp.Siblings[0], mp.Siblings[1] = mp.Siblings[1], mp.Siblings[0]
+		}},
+		{"wrong sibling", func(mp *MultiProof, _ map[int][]byte) { mp.Siblings[2] = make([]byte, sha256.Size) }},
+		{"missing sibling", func(mp *MultiProof, _ map[int][]byte) { mp.Siblings = mp.Siblings[1:] }},
+		{"extra sibling", func(mp *MultiProof, _ map[int][]byte) { mp.Siblings = append(mp.Siblings, mp.Siblings[0]) }},
+		{"short sibling", func(mp *MultiProof, _ map[int][]byte) { mp.Siblings[0] = mp.Siblings[0][1:] }},
+		{"wrong leaf", func(_ *MultiProof, leaves map[int][]byte) { leaves[9] = items[10] }},
+		{"missing leaf", func(_ *MultiProof, leaves map[int][]byte) { delete(leaves, 14) }},
+		{"extra leaf", func(_ *MultiProof, leaves map[int][]byte) { leaves[15] = items[15] }},
+		{"leaf at other index", func(_ *MultiProof, leaves map[int][]byte) { leaves[10] = leaves[9]; delete(leaves, 9) }},
+		{"wrong total", func(mp *MultiProof, _ map[int][]byte) { mp.Total = 17 }},
+		{"zero total", func(mp *MultiProof, _ map[int][]byte) { mp.Total = 0 }},
+		{"unsorted indexes", func(mp *MultiProof, _ map[int][]byte) { mp.Indexes[0], mp.Indexes[1] = mp.Indexes[1], mp.Indexes[0] }},
+		{"index out of range", func(mp *MultiProof, leaves map[int][]byte) { mp.Indexes[3] = 16; leaves[16] = leaves[14] }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, mp, err := MultiProofFromByteSlices(items, indexes)
+			require.NoError(t, err)
+			leaves := leavesAt(items, indexes)
+			tc.mutate(mp, leaves)
+			assert.Error(t, mp.Verify(root, leaves))
+		})
+	}
+	assert.Error(t, valid.Verify(nil, leavesAt(items, indexes)))
+	assert.Error(t, valid.Verify(HashFromByteSlices(randomItems(rng, 16)), leavesAt(items, indexes)))
+}
+
+func TestMultiProofUnmarshalBinaryErrors(t *testing.T) {
+	items := randomItems(rand.New(rand.NewSource(1)), 16)
+	_, mp, err := MultiProofFromByteSlices(items, []int{1, 5})
+	require.NoError(t, err)
+	bz, err := mp.MarshalBinary()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		bz   []byte
+	}{
+		{"empty", nil},
+		{"unknown version", append([]byte{MultiProofVersion + 1}, bz[1:]...)},
+		{"truncated", bz[:len(bz)-1]},
+		{"trailing bytes", append(append([]byte(nil), bz...), 0)},
+		{"truncated header", bz[:3]},
+		{"too many indexes", []byte{MultiProofVersion, 16, 100}},
+		{"index out of range", []byte{MultiProofVersion, 16, 1, 16, 0}},
+		{"no indexes", []byte{MultiProofVersion, 16, 0, 0}},
+		{"repeated index", []byte{MultiProofVersion, 16, 2, 1, 0, 0}},
+		{"zero total", []byte{MultiProofVersion, 0, 1, 0, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var decoded MultiProof
+			assert.Error(t, decoded.UnmarshalBinary(tc.bz))
+		})
+	}
+}
@@ -53,12 +53,21 @@ func (sp *Proof) Verify(rootHash []byte, leaf []byte) error {
 	if rootHash == nil {
 		return fmt.Errorf("invalid root hash: cannot be nil")
 	}
-	if sp.Total < 0 {
+	if sp.Total <= 0 {
 		return errors.New("proof total must be positive")
 	}
 	if sp.Index < 0 {
 		return errors.New("proof index cannot be negative")
 	}
+	if sp.Index >= sp.Total {
+		return fmt.Errorf("proof index %d out of range for total %d", sp.Index, sp.Total)
+	}
+	if len(sp.Aunts) > MaxAunts {
+		return fmt.Errorf("expected no more than %d aunts, got %d", MaxAunts, len(sp.Aunts))
+	}
+	if want := auntCount(sp.Index, sp.Total); len(sp.Aunts) != want {
+		return fmt.Errorf("expected %d aunts for index %d of total %d, got %d", want, sp.Index, sp.Total, len(sp.Aunts))
+	}
 	leafHash := leafHash(leaf)
 	if !bytes.Equal(sp.LeafHash, leafHash) {
 		return fmt.Errorf("invalid leaf hash: wanted %X got %X", leafHash, sp.LeafHash)
@@ -160,6 +169,23 @@ func ProofFromProto(pb *wire.Proof) (*Proof, error) {
 	return sp, sp.ValidateBasic()
 }
 
+// auntCount returns the number of aunts of a proof for the leaf at index in a
+// tree of total leaves, which is the depth of the leaf.
+func auntCount(index, total int64) int {
+	count := 0
+	for total > 1 {
+		numLeft := getSplitPoint(total)
+		if index < numLeft {
+			total = numLeft
+		} else {
+			index -= numLeft
+			total -= numLeft
+		}
+		count++
+	}
+	return count
+}
+
 // Use the leafHash and innerHashes to get the root merkle hash.
 // If the length of the innerHashes slice isn't exactly correct, the result is nil.
 // Recursive impl.
@@ -0,0 +1,185 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// RangeProof proves that a contiguous range of shares is included under the
+// root of a square, i.e. the root returned by HashFromRows for the rows of the
+// square. The range is end exclusive and indexes the shares of the square in
+// row-major order, so it spans several rows if the shares do.
+//
+// The proof only holds the hashes of the subtrees that are disjoint from the
+// range: the siblings of the shares in the first and last row of the range,
+// which may be partially covered, and the siblings of the roots of the rows
+// that the range covers.
+type RangeProof struct {
+	// RowCount is the number of rows of the square and RowLength the number
+	// of shares in a row.
+	RowCount  int64 `json:"row_count"`
+	RowLength int64 `json:"row_length"`
+	// From and To are the end exclusive bounds of the range of shares.
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// RowSiblings are, for every row the range covers, the hashes of the
+	// subtrees of the row that are disjoint from the range in left to right
+	// order. They are empty for rows that the range covers completely.
+	RowSiblings [][][]byte `json:"row_siblings"`
+	// RootSiblings are the hashes of the subtrees of the tree over the row
+	// roots that are disjoint from the rows the range covers in left to
+	// right order.
+	RootSiblings [][]byte `json:"root_siblings"`
+}
+
+// HashFromRows returns the root of a square whose rows are the leaves of
+// rows: the HashFromByteSlices of the HashFromByteSlices of every row.
+func HashFromRows(rows [][][]byte) []byte {
+	rowRoots := make([][]byte, len(rows))
+	for i, row := range rows {
+		rowRoots[i] = HashFromByteSlices(row)
+	}
+	return HashFromByteSlices(rowRoots)
+}
+
+// ProveShareRange returns a proof that the shares in the end exclusive range
+// [from, to) of rows, in row-major order, are included under HashFromRows(rows).
+// It returns an error if the rows don't all have the same non-zero length or if
+// the range is empty or out of bounds.
+func ProveShareRange(rows [][][]byte, from, to int) (RangeProof, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return RangeProof{}, errors.New("cannot prove a range of an empty square")
+	}
+	rowLength := len(rows[0])
+	for i, row := range rows {
+		if len(row) != rowLength {
+			return RangeProof{}, fmt.Errorf("row %d has %d shares but row 0 has %d", i, len(row), rowLength)
+		}
+	}
+	if from < 0 || from >= to || to > len(rows)*rowLength {
+		return RangeProof{}, fmt.Errorf("invalid range [%d, %d) of a square of %d shares", from, to, len(rows)*rowLength)
+	}
+
+	proof := RangeProof{
+		RowCount:  int64(len(rows)),
+		RowLength: int64(rowLength),
+		From:      int64(from),
+		To:        int64(to),
+	}
+	firstRow, lastRow := from/rowLength, (to-1)/rowLength
+	rowRoots := make([][]byte, len(rows))
+	for i, row := range rows {
+		rowRoots[i] = HashFromByteSlices(row)
+	}
+	for row := firstRow; row <= lastRow; row++ {
+		start, end := max(from, row*rowLength), min(to, (row+1)*rowLength)
+		var siblings [][]byte
+		rangeSiblings(rows[row], start-row*rowLength, end-row*rowLength, &siblings)
+		proof.RowSiblings = append(proof.RowSiblings, siblings)
+	}
+	rangeSiblings(rowRoots, firstRow, lastRow+1, &proof.RootSiblings)
+	return proof, nil
+}
+
+// Verify checks that the proof proves that shares are the shares in the end
+// exclusive range [from, to) of a square with the provided root. It returns an
+// error if the range doesn't match the range of the proof or the number of
+// shares.
+func (rp RangeProof) Verify(root []byte, shares [][]byte, from, to int) error {
+	if root == nil {
+		return errors.New("invalid root hash: cannot be nil")
+	}
+	if int64(from) != rp.From || int64(to) != rp.To {
+		return fmt.Errorf("proof is for the range [%d, %d) but the range [%d, %d) was claimed", rp.From, rp.To, from, to)
+	}
+	if rp.RowCount <= 0 || rp.RowLength <= 0 || rp.RowLength > math.MaxInt64/rp.RowCount {
+		return fmt.Errorf("invalid square of %d rows of %d shares", rp.RowCount, rp.RowLength)
+	}
+	if rp.From < 0 || rp.From >= rp.To || rp.To > rp.RowCount*rp.RowLength {
+		return fmt.Errorf("invalid range [%d, %d) of a square of %d shares", rp.From, rp.To, rp.RowCount*rp.RowLength)
+	}
+	if int64(len(shares)) != rp.To-rp.From {
+		return fmt.Errorf("expected %d shares for the range [%d, %d), got %d", rp.To-rp.From, rp.From, rp.To, len(shares))
+	}
+	firstRow, lastRow := rp.From/rp.RowLength, (rp.To-1)/rp.RowLength
+	if int64(len(rp.RowSiblings)) != lastRow-firstRow+1 {
+		return fmt.Errorf("expected siblings for %d rows, got %d", lastRow-firstRow+1, len(rp.RowSiblings))
+	}
+
+	rowRoots := make([][]byte, 0, len(rp.RowSiblings))
+	for i, siblings := range rp.RowSiblings {
+		row := firstRow + int64(i)
+		start, end := max(rp.From, row*rp.RowLength), min(rp.To, (row+1)*rp.RowLength)
+		rowRoot, err := rangeRoot(rp.RowLength, start-row*rp.RowLength, end-row*rp.RowLength, shares[start-rp.From:end-rp.From], siblings)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		rowRoots = append(rowRoots, rowRoot)
+	}
+	computed, err := rangeRoot(rp.RowCount, firstRow, lastRow+1, rowRoots, rp.RootSiblings)
+	if err != nil {
+		return fmt.Errorf("row roots: %w", err)
+	}
+	if !bytes.Equal(computed, root) {
+		return fmt.Errorf("invalid root hash: wanted %X got %X", root, computed)
+	}
+	return nil
+}
+
+// rangeSiblings appends to siblings the roots of the largest subtrees of the
+// tree over items that are disjoint from the end exclusive range [from, to),
+// in left to right order.
+func rangeSiblings(items [][]byte, from, to int, siblings *[][]byte) {
+	if to <= 0 || from >= len(items) {
+		*siblings = append(*siblings, HashFromByteSlices(items))
+		return
+	}
+	if from <= 0 && to >= len(items) {
+		return
+	}
+	k := int(getSplitPoint(int64(len(items))))
+	rangeSiblings(items[:k], from, to, siblings)
+	rangeSiblings(items[k:], from-k, to-k, siblings)
+}
+
+// rangeRoot returns the root of a tree of total leaves given the leaves in
+// the end exclusive range [from, to) and the siblings returned by
+// rangeSiblings. It returns an error if there are too few or too many
+// siblings.
+func rangeRoot(total, from, to int64, leaves [][]byte, siblings [][]byte) ([]byte, error) {
+	root, rest, err := rangeRootRec(total, from, to, leaves, siblings)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d unused siblings", len(rest))
+	}
+	return root, nil
+}
+
+// rangeRootRec is like rangeRoot but returns the siblings it didn't use.
+func rangeRootRec(total, from, to int64, leaves [][]byte, siblings [][]byte) ([]byte, [][]byte, error) {
+	if to <= 0 || from >= total {
+		if len(siblings) == 0 {
+			return nil, nil, errors.New("missing sibling")
+		}
+		return siblings[0], siblings[1:], nil
+	}
+	if total == 1 {
+		return leafHash(leaves[0]), siblings, nil
+	}
+	k := getSplitPoint(total)
+	// the number of leaves in the left subtree
+	split := min(max(min(to, k)-max(from, 0), 0), int64(len(leaves)))
+	left, siblings, err := rangeRootRec(k, from, to, leaves[:split], siblings)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, siblings, err := rangeRootRec(total-k, from-k, to-k, leaves[split:], siblings)
+	if err != nil {
+		return nil, nil, err
+	}
+	return innerHash(left, right), siblings, nil
+}
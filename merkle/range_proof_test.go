@@ -0,0 +1,136 @@
+package merkle
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomRows returns rowCount rows of rowLength random shares.
+func randomRows(rng *rand.Rand, rowCount, rowLength int) [][][]byte {
+	rows := make([][][]byte, rowCount)
+	for i := range rows {
+		rows[i] = make([][]byte, rowLength)
+		for j := range rows[i] {
+			rows[i][j] = make([]byte, 32)
+			_, _ = rng.Read(rows[i][j])
+		}
+	}
+	return rows
+}
+
+// flatten returns the shares of rows in row-major order.
+func flatten(rows [][][]byte) [][]byte {
+	var shares [][]byte
+	for _, row := range rows {
+		shares = append(shares, row...)
+	}
+	return shares
+}
+
+func TestProveShareRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	rows := randomRows(rng, 8, 8)
+	shares := flatten(rows)
+	root := HashFromRows(rows)
+
+	testCases := []struct {
+		name     string
+		from, to int
+		// wantRowSiblings is the number of siblings of every row
+		wantRowSiblings []int
+	}{
+		{name: "inside one row", from: 10, to: 13, wantRowSiblings: []int{3}},
+		{name: "single share", from: 63, to: 64, wantRowSiblings: []int{3}},
+		{name: "whole row", from: 16, to: 24, wantRowSiblings: []int{0}},
+		{name: "spanning two rows", from: 13, to: 19, wantRowSiblings: []int{2, 2}},
+		{name: "spanning three rows", from: 7, to: 17, wantRowSiblings: []int{3, 0, 3}},
+		{name: "full square", from: 0, to: 64, wantRowSiblings: []int{0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proof, err := ProveShareRange(rows, tc.from, tc.to)
+			require.NoError(t, err)
+			require.NoError(t, proof.Verify(root, shares[tc.from:tc.to], tc.from, tc.to))
+			require.Len(t, proof.RowSiblings, len(tc.wantRowSiblings))
+			for i, want := range tc.wantRowSiblings {
+				assert.Len(t, proof.RowSiblings[i], want, "row %d", i)
+			}
+			if tc.from == 0 && tc.to == len(shares) {
+				assert.Empty(t, proof.RootSiblings)
+			}
+
+			// ranges that don't match the claimed indexes are rejected
+			if tc.to < len(shares) {
+				assert.Error(t, proof.Verify(root, shares[tc.from+1:tc.to+1], tc.from+1, tc.to+1))
+				assert.Error(t, proof.Verify(root, shares[tc.from+1:tc.to+1], tc.from, tc.to))
+			}
+			assert.Error(t, proof.Verify(root, shares[tc.from:tc.to-1], tc.from, tc.to-1))
+			assert.Error(t, proof.Verify(root, shares[tc.from:tc.to-1], tc.from, tc.to))
+			assert.Error(t, proof.Verify(HashFromRows(randomRows(rng, 8, 8)), shares[tc.from:tc.to], tc.from, tc.to))
+		})
+	}
+}
+
+func TestProveShareRangeExhaustive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, dims := range [][2]int{{1, 1}, {1, 5}, {3, 3}, {4, 4}, {5, 6}} {
+		rows := randomRows(rng, dims[0], dims[1])
+		shares := flatten(rows)
+		root := HashFromRows(rows)
+		for from := 0; from < len(shares); from++ {
+			for to := from + 1; to <= len(shares); to++ {
+				proof, err := ProveShareRange(rows, from, to)
+				require.NoError(t, err)
+				require.NoError(t, proof.Verify(root, shares[from:to], from, to), "%dx%d [%d, %d)", dims[0], dims[1], from, to)
+			}
+		}
+	}
+}
+
+func TestProveShareRangeErrors(t *testing.T) {
+	rows := randomRows(rand.New(rand.NewSource(1)), 4, 4)
+	for _, r := range [][2]int{{-1, 2}, {2, 2}, {3, 2}, {0, 17}} {
+		_, err := ProveShareRange(rows, r[0], r[1])
+		assert.Error(t, err, fmt.Sprint(r))
+	}
+	_, err := ProveShareRange(nil, 0, 1)
+	assert.Error(t, err)
+	_, err = ProveShareRange(append(rows, rows[0][:3]), 0, 1)
+	assert.Error(t, err)
+}
+
+func TestRangeProofVerifyInvalidProofs(t *testing.T) {
+	rows := randomRows(rand.New(rand.NewSource(1)), 4, 4)
+	shares := flatten(rows)
+	root := HashFromRows(rows)
+	valid, err := ProveShareRange(rows, 3, 9)
+	require.NoError(t, err)
+	require.NoError(t, valid.Verify(root, shares[3:9], 3, 9))
+
+	testCases := []struct {
+		name   string
+		mutate func(p *RangeProof)
+	}{
+		{"missing row siblings", func(p *RangeProof) { p.RowSiblings = p.RowSiblings[:1] }},
+		{"missing sibling", func(p *RangeProof) { p.RowSiblings[0] = p.RowSiblings[0][1:] }},
+		{"extra sibling", func(p *RangeProof) { p.RowSiblings[2] = append(p.RowSiblings[2], p.RowSiblings[2][0]) }},
+		{"extra root sibling", func(p *RangeProof) { p.RootSiblings = append(p.RootSiblings, p.RootSiblings[0]) }},
+		{"wrong sibling", func(p *RangeProof) { p.RootSiblings[0] = p.RowSiblings[0][0] }},
+		{"wrong row length", func(p *RangeProof) { p.RowLength = 8; p.RowCount = 2 }},
+		{"zero rows", func(p *RangeProof) { p.RowCount = 0 }},
+		{"overflowing square", func(p *RangeProof) { p.RowCount, p.RowLength = 1<<40, 1<<40 }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proof, err := ProveShareRange(rows, 3, 9)
+			require.NoError(t, err)
+			tc.mutate(&proof)
+			assert.Error(t, proof.Verify(root, shares[3:9], 3, 9))
+		})
+	}
+	assert.Error(t, valid.Verify(nil, shares[3:9], 3, 9))
+}
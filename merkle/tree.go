@@ -2,8 +2,15 @@ package merkle
 
 import (
 	"math/bits"
+	"runtime"
+	"sync"
 )
 
+// minParallelLeaves is the number of leaves below which
+// HashFromByteSlicesParallel hashes a subtree on the calling goroutine because
+// another goroutine would cost more than it saves.
+const minParallelLeaves = 256
+
 // HashFromByteSlices computes a Merkle tree where the leaves are the byte slice,
 // in the provided order. It follows RFC-6962.
 func HashFromByteSlices(items [][]byte) []byte {
@@ -20,6 +27,51 @@ func HashFromByteSlices(items [][]byte) []byte {
 	}
 }
 
+// HashFromByteSlicesParallel is like HashFromByteSlices but hashes the
+// subtrees of the two halves of the tree concurrently, on up to workers
+// goroutines. A value of 0 or less uses runtime.GOMAXPROCS. Subtrees of fewer
+// than 256 leaves are hashed sequentially so small inputs are hashed like by
+// HashFromByteSlices. The root is identical to the one of HashFromByteSlices.
+func HashFromByteSlicesParallel(items [][]byte, workers int) []byte {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || len(items) < minParallelLeaves {
+		return HashFromByteSlices(items)
+	}
+	// the calling goroutine is one of the workers
+	tokens := make(chan struct{}, workers-1)
+	return hashFromByteSlicesParallel(items, tokens)
+}
+
+// hashFromByteSlicesParallel hashes the left half of items on a new goroutine
+// if a token is free and there are enough leaves, and the right half on the
+// calling goroutine.
+func hashFromByteSlicesParallel(items [][]byte, tokens chan struct{}) []byte {
+	if len(items) < minParallelLeaves {
+		return HashFromByteSlices(items)
+	}
+	k := getSplitPoint(int64(len(items)))
+	var left []byte
+	select {
+	case tokens <- struct{}{}:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			left = hashFromByteSlicesParallel(items[:k], tokens)
+			<-tokens
+		}()
+		right := hashFromByteSlicesParallel(items[k:], tokens)
+		wg.Wait()
+		return innerHash(left, right)
+	default:
+		left = hashFromByteSlicesParallel(items[:k], tokens)
+		right := hashFromByteSlicesParallel(items[k:], tokens)
+		return innerHash(left, right)
+	}
+}
+
 // HashFromByteSlicesIterative is an iterative alternative to
 // HashFromByteSlice motivated by potential performance improvements.
 // (#2611) had suggested that an iterative version of
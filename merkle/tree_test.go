@@ -4,6 +4,7 @@ import (
 	crand "crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"math/rand"
 	"testing"
 
@@ -98,6 +99,84 @@ func TestProof(t *testing.T) {
 	}
 }
 
+func TestProofEmptyAndSingleLeaf(t *testing.T) {
+	rootHash, proofs := ProofsFromByteSlices(nil)
+	assert.Equal(t, HashFromByteSlices(nil), rootHash)
+	assert.Empty(t, proofs)
+	// nothing can be proven against the root of the empty tree
+	err := (&Proof{Total: 0, Index: 0, LeafHash: leafHash(nil)}).Verify(rootHash, nil)
+	assert.Error(t, err)
+
+	leaf := []byte{1, 2, 3}
+	rootHash, proofs = ProofsFromByteSlices([][]byte{leaf})
+	require.Len(t, proofs, 1)
+	assert.Equal(t, HashFromByteSlices([][]byte{leaf}), rootHash)
+	assert.Equal(t, &Proof{Total: 1, Index: 0, LeafHash: leafHash(leaf), Aunts: [][]byte{}}, proofs[0])
+	// the root of a single leaf tree is the leaf hash
+	assert.Equal(t, leafHash(leaf), rootHash)
+	require.NoError(t, proofs[0].Verify(rootHash, leaf))
+	assert.Error(t, proofs[0].Verify(rootHash, []byte{1, 2}))
+}
+
+func TestProofVerifyInvalidProofs(t *testing.T) {
+	items := make([][]byte, 7)
+	for i := range items {
+		items[i] = randBytes(sha256.Size)
+	}
+	rootHash, proofs := ProofsFromByteSlices(items)
+	// the leaf at index 6 is one level higher than the others
+	require.Len(t, proofs[6].Aunts, 2)
+	require.Len(t, proofs[0].Aunts, 3)
+
+	testCases := map[string]func(p *Proof){
+		"zero total":        func(p *Proof) { p.Total = 0 },
+		"negative total":    func(p *Proof) { p.Total = -1 },
+		"total too small":   func(p *Proof) { p.Total = 6 },
+		"total too large":   func(p *Proof) { p.Total = 8 },
+		"negative index":    func(p *Proof) { p.Index = -1 },
+		"index of total":    func(p *Proof) { p.Index = p.Total },
+		"index too large":   func(p *Proof) { p.Index = 100 },
+		"other index":       func(p *Proof) { p.Index = 5 },
+		"missing aunt":      func(p *Proof) { p.Aunts = p.Aunts[:1] },
+		"extra aunt":        func(p *Proof) { p.Aunts = append(p.Aunts, randBytes(sha256.Size)) },
+		"no aunts":          func(p *Proof) { p.Aunts = nil },
+		"too many aunts":    func(p *Proof) { p.Aunts = make([][]byte, MaxAunts+1) },
+		"swapped aunts":     func(p *Proof) { p.Aunts[0], p.Aunts[1] = p.Aunts[1], p.Aunts[0] },
+		"mutated aunt":      func(p *Proof) { p.Aunts[0] = mutateByteSlice(p.Aunts[0]) },
+		"mutated leaf hash": func(p *Proof) { p.LeafHash = mutateByteSlice(p.LeafHash) },
+	}
+	for name, mutate := range testCases {
+		mutate := mutate
+		t.Run(name, func(t *testing.T) {
+			proof := *proofs[6]
+			proof.Aunts = append([][]byte{}, proofs[6].Aunts...)
+			proof.LeafHash = append([]byte{}, proofs[6].LeafHash...)
+			require.NoError(t, proof.Verify(rootHash, items[6]))
+			mutate(&proof)
+			assert.Error(t, proof.Verify(rootHash, items[6]))
+		})
+	}
+}
+
+func TestProofsMatchHashFromByteSlices(t *testing.T) {
+	for total := 1; total <= 70; total++ {
+		items := make([][]byte, total)
+		for i := range items {
+			items[i] = randBytes(rand.Intn(100))
+		}
+		want := HashFromByteSlices(items)
+		rootHash, proofs := ProofsFromByteSlices(items)
+		require.Equal(t, want, rootHash, "%d items", total)
+		require.Len(t, proofs, total)
+		for i, proof := range proofs {
+			// the proof path hashes to the root of HashFromByteSlices
+			assert.Equal(t, want, proof.ComputeRootHash(), "item %d of %d", i, total)
+			assert.Len(t, proof.Aunts, auntCount(int64(i), int64(total)))
+			assert.NoError(t, proof.Verify(want, items[i]), "item %d of %d", i, total)
+		}
+	}
+}
+
 func TestHashAlternatives(t *testing.T) {
 	total := 100
 
@@ -133,6 +212,46 @@ func BenchmarkHashAlternatives(b *testing.B) {
 	})
 }
 
+func TestHashFromByteSlicesParallel(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	totals := []int{0, 1, 2, 255, 256, 257, 512, 1000, 4096}
+	for i := 0; i < 5; i++ {
+		totals = append(totals, rng.Intn(20_000))
+	}
+	for _, total := range totals {
+		items := make([][]byte, total)
+		for i := range items {
+			items[i] = make([]byte, rng.Intn(64))
+			_, _ = rng.Read(items[i])
+		}
+		want := HashFromByteSlices(items)
+		for _, workers := range []int{-1, 0, 1, 2, 3, 8} {
+			assert.Equal(t, want, HashFromByteSlicesParallel(items, workers), "%d items with %d workers", total, workers)
+		}
+	}
+}
+
+func BenchmarkHashFromByteSlicesParallel(b *testing.B) {
+	for _, total := range []int{1 << 10, 1 << 14, 1 << 16} {
+		items := make([][]byte, total)
+		for i := range items {
+			items[i] = randBytes(512)
+		}
+		b.Run(fmt.Sprintf("%d leaves sequential", total), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = HashFromByteSlices(items)
+			}
+		})
+		for _, workers := range []int{2, 4, 8} {
+			b.Run(fmt.Sprintf("%d leaves %d workers", total, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_ = HashFromByteSlicesParallel(items, workers)
+				}
+			})
+		}
+	}
+}
+
 func Test_getSplitPoint(t *testing.T) {
 	tests := []struct {
 		length int64
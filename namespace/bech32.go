@@ -0,0 +1,48 @@
+package namespace
+
+import (
+	"github.com/celestiaorg/go-square/internal/bech32"
+)
+
+// Bech32 returns the namespace as a bech32 (BIP-173) string with the
+// human-readable part hrp, e.g. for display by a wallet or an explorer. The
+// encoded data is the version followed by the ID, as returned by Bytes. The
+// string is in lower case. It returns an error if the namespace fails
+// Validate or hrp is not a valid human-readable part.
+func (n Namespace) Bech32(hrp string) (string, error) {
+	if err := n.Validate(); err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, n.Bytes())
+}
+
+// DecodeBech32 decodes a namespace encoded by Bech32 and returns it together
+// with the lower case human-readable part. The string must be all lower case
+// or all upper case and have a valid checksum. The namespace is validated like
+// From, so reserved namespaces are accepted: use FromBech32 for namespaces
+// entered by a user for a blob.
+func DecodeBech32(s string) (hrp string, ns Namespace, err error) {
+	hrp, data, err := bech32.Decode(s)
+	if err != nil {
+		return "", Namespace{}, err
+	}
+	ns, err = From(data)
+	if err != nil {
+		return "", Namespace{}, err
+	}
+	return hrp, ns, nil
+}
+
+// FromBech32 is like DecodeBech32 but also requires the namespace to pass
+// ValidateForBlob, so a reserved namespace with a valid checksum is rejected.
+// The human-readable part is not checked.
+func FromBech32(s string) (Namespace, error) {
+	_, ns, err := DecodeBech32(s)
+	if err != nil {
+		return Namespace{}, err
+	}
+	if err := ns.ValidateForBlob(); err != nil {
+		return Namespace{}, err
+	}
+	return ns, nil
+}
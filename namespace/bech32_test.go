@@ -0,0 +1,96 @@
+package namespace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/go-square/internal/bech32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The golden vectors pin the encoding so that clients in other languages
+// produce the same strings.
+func TestBech32Golden(t *testing.T) {
+	testCases := []struct {
+		name string
+		ns   Namespace
+		want string
+	}{
+		{name: "tx namespace", ns: TxNamespace, want: "ns1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqgmznwk4"},
+		{name: "user namespace", ns: MustNewV0([]byte("myrollup")), want: "ns1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqpkhjun0d3k82uqvk3kyw"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.ns.Bech32("ns")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			hrp, decoded, err := DecodeBech32(got)
+			require.NoError(t, err)
+			assert.Equal(t, "ns", hrp)
+			assert.True(t, tc.ns.Equals(decoded))
+		})
+	}
+}
+
+func TestBech32Case(t *testing.T) {
+	ns := MustNewV0([]byte("myrollup"))
+	encoded, err := ns.Bech32("NS")
+	require.NoError(t, err)
+	// the encoding is always lower case
+	assert.Equal(t, "ns1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqpkhjun0d3k82uqvk3kyw", encoded)
+
+	// an all upper case string is the same namespace
+	got, err := FromBech32(strings.ToUpper(encoded))
+	require.NoError(t, err)
+	assert.True(t, ns.Equals(got))
+
+	// mixed case is rejected even with a valid checksum
+	mixed := "NS" + encoded[2:]
+	_, err = FromBech32(mixed)
+	assert.ErrorIs(t, err, bech32.ErrMixedCase)
+	_, _, err = DecodeBech32(mixed)
+	assert.ErrorIs(t, err, bech32.ErrMixedCase)
+}
+
+func TestFromBech32Errors(t *testing.T) {
+	user, err := MustNewV0([]byte("myrollup")).Bech32("ns")
+	require.NoError(t, err)
+	tx, err := TxNamespace.Bech32("ns")
+	require.NoError(t, err)
+	// flip the last character of the checksum
+	badChecksum := user[:len(user)-1] + "q"
+	short, err := bech32.Encode("ns", TxNamespace.Bytes()[:NamespaceSize-1])
+	require.NoError(t, err)
+	badPrefix, err := bech32.Encode("ns", append([]byte{NamespaceVersionZero}, invalidPrefixID[:NamespaceIDSize]...))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		s          string
+		wantErr    error
+		displayErr error
+	}{
+		{name: "reserved namespace", s: tx, wantErr: ErrReservedNamespace},
+		{name: "invalid checksum", s: badChecksum, wantErr: bech32.ErrInvalidChecksum, displayErr: bech32.ErrInvalidChecksum},
+		{name: "invalid length", s: short, wantErr: ErrInvalidLength, displayErr: ErrInvalidLength},
+		{name: "invalid version 0 ID", s: badPrefix, wantErr: ErrInvalidLeadingZeros, displayErr: ErrInvalidLeadingZeros},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromBech32(tc.s)
+			assert.ErrorIs(t, err, tc.wantErr)
+
+			_, _, err = DecodeBech32(tc.s)
+			if tc.displayErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.displayErr)
+			}
+		})
+	}
+
+	_, err = Namespace{Version: NamespaceVersionZero, ID: tooShortID}.Bech32("ns")
+	assert.ErrorIs(t, err, ErrInvalidIDLength)
+}
@@ -64,6 +64,29 @@ var (
 	// ParitySharesNamespace is the namespace reserved for erasure coded data.
 	ParitySharesNamespace = secondaryReservedNamespace(0xFF)
 
+	// PrimaryReservedNamespaces lists the primary reserved namespaces that are
+	// defined, in ascending order. See Classify for the role of each.
+	PrimaryReservedNamespaces = []Namespace{
+		TxNamespace,
+		IntermediateStateRootsNamespace,
+		PayForBlobNamespace,
+		PrimaryReservedPaddingNamespace,
+	}
+
+	// SecondaryReservedNamespaces lists the secondary reserved namespaces that
+	// are defined, in ascending order. See Classify for the role of each.
+	SecondaryReservedNamespaces = []Namespace{
+		TailPaddingNamespace,
+		ParitySharesNamespace,
+	}
+
+	// SupportedNamespaceVersions is a list of namespace versions that are
+	// defined. Version 0 is used by blobs and by the primary reserved
+	// namespaces, version 255 by the secondary reserved namespaces. No rules
+	// have been defined for other versions so namespaces with those versions
+	// are rejected rather than being treated like version 0.
+	SupportedNamespaceVersions = []uint8{NamespaceVersionZero, NamespaceVersionMax}
+
 	// SupportedBlobNamespaceVersions is a list of namespace versions that can be specified by a user for blobs.
 	SupportedBlobNamespaceVersions = []uint8{NamespaceVersionZero}
 )
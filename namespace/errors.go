@@ -0,0 +1,32 @@
+package namespace
+
+import "errors"
+
+var (
+	// ErrInvalidVersion is returned when a namespace has a version that is not
+	// one of SupportedNamespaceVersions, or a version that can't be used for
+	// blobs. It is about the namespace version, not the share version.
+	ErrInvalidVersion = errors.New("invalid namespace version")
+	// ErrInvalidIDLength is returned when a namespace ID isn't NamespaceIDSize
+	// bytes long.
+	ErrInvalidIDLength = errors.New("invalid namespace id length")
+	// ErrInvalidLeadingZeros is returned when the ID of a version 0 namespace
+	// doesn't start with NamespaceVersionZeroPrefix.
+	ErrInvalidLeadingZeros = errors.New("namespace id is missing the leading zeros required by version 0")
+	// ErrInvalidLength is returned when an encoded namespace isn't
+	// NamespaceSize bytes long.
+	ErrInvalidLength = errors.New("invalid namespace length")
+	// ErrReservedNamespace is returned when a namespace reserved for protocol
+	// use is used for a blob.
+	ErrReservedNamespace = errors.New("namespace is reserved for protocol use")
+	// ErrParityOrTailPadding is returned when the parity shares namespace or
+	// the tail padding namespace is used for a blob. Errors wrapping it also
+	// wrap ErrReservedNamespace.
+	ErrParityOrTailPadding = errors.New("namespace is the parity shares or tail padding namespace")
+	// ErrNamespaceOverflow is returned when namespace arithmetic exceeds the
+	// largest namespace.
+	ErrNamespaceOverflow = errors.New("namespace overflow")
+	// ErrNamespaceUnderflow is returned when namespace arithmetic goes below
+	// the smallest namespace.
+	ErrNamespaceUnderflow = errors.New("namespace underflow")
+)
@@ -0,0 +1,74 @@
+package namespace
+
+// NamespaceKind classifies a namespace by the role it plays in a data square.
+type NamespaceKind uint8
+
+const (
+	// InvalidKind is the kind of namespaces that are malformed or that are
+	// neither reserved nor usable by blobs, e.g. a version 255 namespace below
+	// MinSecondaryReservedNamespace.
+	InvalidKind NamespaceKind = iota
+	// UserBlobKind is the kind of namespaces that can be used by blobs.
+	UserBlobKind
+	// TxKind is the kind of TxNamespace.
+	TxKind
+	// PayForBlobKind is the kind of PayForBlobNamespace.
+	PayForBlobKind
+	// PaddingKind is the kind of PrimaryReservedPaddingNamespace and
+	// TailPaddingNamespace.
+	PaddingKind
+	// ParityKind is the kind of ParitySharesNamespace.
+	ParityKind
+	// ReservedUnusedKind is the kind of reserved namespaces that have no role
+	// in the data square, e.g. IntermediateStateRootsNamespace.
+	ReservedUnusedKind
+)
+
+func (k NamespaceKind) String() string {
+	switch k {
+	case UserBlobKind:
+		return "user blob"
+	case TxKind:
+		return "tx"
+	case PayForBlobKind:
+		return "pay for blob"
+	case PaddingKind:
+		return "padding"
+	case ParityKind:
+		return "parity"
+	case ReservedUnusedKind:
+		return "reserved unused"
+	default:
+		return "invalid"
+	}
+}
+
+// IsCompact returns true if namespaces of this kind are stored in compact
+// shares.
+func (k NamespaceKind) IsCompact() bool {
+	return k == TxKind || k == PayForBlobKind
+}
+
+// Classify returns the kind of the namespace.
+func Classify(ns Namespace) NamespaceKind {
+	switch {
+	case ns.IsTx():
+		return TxKind
+	case ns.IsPayForBlob():
+		return PayForBlobKind
+	case ns.IsPrimaryReservedPadding(), ns.IsTailPadding():
+		return PaddingKind
+	case ns.IsParityShares():
+		return ParityKind
+	}
+	if ns.Validate() != nil {
+		return InvalidKind
+	}
+	if ns.IsReserved() {
+		return ReservedUnusedKind
+	}
+	if isBlobNamespace(ns) {
+		return UserBlobKind
+	}
+	return InvalidKind
+}
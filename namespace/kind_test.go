@@ -0,0 +1,69 @@
+package namespace
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	mustAdd := func(ns Namespace, delta int) Namespace {
+		got, err := ns.AddInt(delta)
+		if err != nil {
+			panic(err)
+		}
+		return got
+	}
+	maxV0 := MustNew(NamespaceVersionZero, append(NamespaceVersionZeroPrefix, bytes.Repeat([]byte{0xFF}, NamespaceVersionZeroIDSize)...))
+
+	type testCase struct {
+		name string
+		ns   Namespace
+		want NamespaceKind
+	}
+	testCases := []testCase{
+		{name: "min namespace", ns: MustNew(NamespaceVersionZero, make([]byte, NamespaceIDSize)), want: ReservedUnusedKind},
+		{name: "tx", ns: TxNamespace, want: TxKind},
+		{name: "intermediate state roots", ns: IntermediateStateRootsNamespace, want: ReservedUnusedKind},
+		{name: "between intermediate state roots and pay for blob", ns: primaryReservedNamespace(0x03), want: ReservedUnusedKind},
+		{name: "pay for blob", ns: PayForBlobNamespace, want: PayForBlobKind},
+		{name: "below primary reserved padding", ns: mustAdd(PrimaryReservedPaddingNamespace, -1), want: ReservedUnusedKind},
+		{name: "primary reserved padding", ns: PrimaryReservedPaddingNamespace, want: PaddingKind},
+		{name: "first user namespace", ns: mustAdd(MaxPrimaryReservedNamespace, 1), want: UserBlobKind},
+		{name: "last version 0 namespace", ns: maxV0, want: UserBlobKind},
+		{name: "version 255 below the secondary reserved range", ns: mustAdd(MinSecondaryReservedNamespace, -1), want: InvalidKind},
+		{name: "min secondary reserved", ns: MinSecondaryReservedNamespace, want: ReservedUnusedKind},
+		{name: "below tail padding", ns: mustAdd(TailPaddingNamespace, -1), want: ReservedUnusedKind},
+		{name: "tail padding", ns: TailPaddingNamespace, want: PaddingKind},
+		{name: "parity shares", ns: ParitySharesNamespace, want: ParityKind},
+		{name: "unsupported version", ns: Namespace{Version: 1, ID: maxV0.ID}, want: InvalidKind},
+		{name: "missing leading zeros", ns: Namespace{Version: NamespaceVersionZero, ID: bytes.Repeat([]byte{1}, NamespaceIDSize)}, want: InvalidKind},
+		{name: "zero value", ns: Namespace{}, want: InvalidKind},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Classify(tc.ns)
+			assert.Equal(t, tc.want, got, "got %s", got)
+			assert.Equal(t, tc.want == UserBlobKind, tc.ns.ValidateForBlob() == nil)
+			assert.Equal(t, tc.want == TxKind || tc.want == PayForBlobKind, got.IsCompact())
+		})
+	}
+}
+
+func TestReservedNamespaceRegistry(t *testing.T) {
+	for _, list := range [][]Namespace{PrimaryReservedNamespaces, SecondaryReservedNamespaces} {
+		assert.True(t, sort.IsSorted(Namespaces(list)))
+	}
+	for _, ns := range PrimaryReservedNamespaces {
+		assert.True(t, ns.IsPrimaryReserved(), ns.String())
+		assert.NotEqual(t, UserBlobKind, Classify(ns))
+	}
+	for _, ns := range SecondaryReservedNamespaces {
+		assert.True(t, ns.IsSecondaryReserved(), ns.String())
+		assert.NotEqual(t, UserBlobKind, Classify(ns))
+	}
+	assert.Equal(t, "tx", TxKind.String())
+	assert.Equal(t, "invalid", NamespaceKind(255).String())
+}
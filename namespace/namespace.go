@@ -2,7 +2,17 @@ package namespace
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Namespace struct {
@@ -43,7 +53,7 @@ func MustNew(version uint8, id []byte) Namespace {
 // to fill 10 bytes.
 func NewV0(subID []byte) (Namespace, error) {
 	if lenSubID := len(subID); lenSubID > NamespaceVersionZeroIDSize {
-		return Namespace{}, fmt.Errorf("subID must be <= %v, but it was %v bytes", NamespaceVersionZeroIDSize, lenSubID)
+		return Namespace{}, fmt.Errorf("%w: subID %x must be <= %v bytes, but it was %v bytes", ErrInvalidIDLength, subID, NamespaceVersionZeroIDSize, lenSubID)
 	}
 
 	subID = leftPad(subID, NamespaceVersionZeroIDSize)
@@ -68,10 +78,97 @@ func MustNewV0(subID []byte) Namespace {
 	return ns
 }
 
+// NewV0FromString returns a version 0 namespace whose sub ID is the UTF-8
+// encoding of s left-padded with zeros, e.g. "myrollup" becomes the sub ID
+// 0x00006d79726f6c6c7570. It returns an error wrapping ErrInvalidIDLength if s
+// is longer than NamespaceVersionZeroIDSize bytes and an error wrapping
+// ErrReservedNamespace if the namespace is reserved, which is the case for
+// strings shorter than two bytes.
+func NewV0FromString(s string) (Namespace, error) {
+	ns, err := NewV0([]byte(s))
+	if err != nil {
+		return Namespace{}, fmt.Errorf("namespace string %q: %w", s, err)
+	}
+	if err := ns.ValidateForBlob(); err != nil {
+		return Namespace{}, fmt.Errorf("namespace string %q: %w", s, err)
+	}
+	return ns, nil
+}
+
+// ParseNamespace parses a namespace from its NamespaceSize byte encoding, e.g.
+// as read out of a share. It enforces the same rules as New and returns an
+// error wrapping ErrInvalidLength, ErrInvalidVersion, ErrInvalidIDLength or
+// ErrInvalidLeadingZeros depending on the rule that failed. Unlike From, the
+// returned namespace doesn't alias b.
+func ParseNamespace(b []byte) (Namespace, error) {
+	ns, err := From(b)
+	if err != nil {
+		return Namespace{}, fmt.Errorf("invalid namespace %x: %w", b, err)
+	}
+	return ns.deepCopy(), nil
+}
+
+// uint64Marker is the byte that precedes the big-endian integer in the sub ID
+// of namespaces created by NewV0FromUint64. It keeps the namespaces of small
+// integers out of the primary reserved range.
+const uint64Marker = 0x01
+
+// NewV0FromUint64 returns a version 0 blob namespace that encodes n. The sub ID
+// is 0x00 || 0x01 || n as a big-endian uint64, so the namespaces of all
+// integers, including 0, are outside of the reserved ranges and n < m implies
+// that the namespace of n is less than the namespace of m. AsUint64 is the
+// inverse.
+func NewV0FromUint64(n uint64) (Namespace, error) {
+	subID := make([]byte, NamespaceVersionZeroIDSize)
+	subID[1] = uint64Marker
+	binary.BigEndian.PutUint64(subID[2:], n)
+	return NewV0(subID)
+}
+
+// AsUint64 returns the integer encoded in a namespace created by
+// NewV0FromUint64. It returns false if the namespace doesn't have that shape.
+func (n Namespace) AsUint64() (uint64, bool) {
+	subID := n.ID0()
+	if subID == nil || !bytes.Equal(n.ID[:NamespaceVersionZeroPrefixSize], NamespaceVersionZeroPrefix) || subID[0] != 0 || subID[1] != uint64Marker {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(subID[2:]), true
+}
+
+// DeriveV0 derives a stable version 0 blob namespace from input of any length,
+// e.g. a domain name or a chain ID. The derivation is:
+//
+//	subID = SHA-256(input)[:NamespaceVersionZeroIDSize]
+//	namespace = 0x00 || NamespaceVersionZeroPrefix || subID
+//
+// If the namespace is reserved, i.e. the first 9 bytes of subID are zero,
+// subID is recomputed as SHA-256(input || counter)[:NamespaceVersionZeroIDSize]
+// where counter is a 4 byte big-endian integer starting at 1 and incremented
+// until the namespace is not reserved.
+func DeriveV0(input []byte) Namespace {
+	for counter := uint32(0); ; counter++ {
+		ns := MustNewV0(derivedSubID(input, counter))
+		if !ns.IsReserved() {
+			return ns
+		}
+	}
+}
+
+// derivedSubID returns the sub ID DeriveV0 derives from input in attempt
+// counter. The counter is only appended to input after the first attempt.
+func derivedSubID(input []byte, counter uint32) []byte {
+	h := sha256.New()
+	h.Write(input)
+	if counter > 0 {
+		_ = binary.Write(h, binary.BigEndian, counter)
+	}
+	return h.Sum(nil)[:NamespaceVersionZeroIDSize]
+}
+
 // From returns a namespace from the provided byte slice.
 func From(b []byte) (Namespace, error) {
 	if len(b) != NamespaceSize {
-		return Namespace{}, fmt.Errorf("invalid namespace length: %v must be %v", len(b), NamespaceSize)
+		return Namespace{}, fmt.Errorf("%w: namespace %x is %v bytes but must be %v", ErrInvalidLength, b, len(b), NamespaceSize)
 	}
 	rawVersion := b[0]
 	rawNamespace := b[1:]
@@ -83,10 +180,212 @@ func (n Namespace) Bytes() []byte {
 	return append([]byte{n.Version}, n.ID...)
 }
 
+// MarshalBinary returns the namespace as a byte slice. It implements the
+// encoding.BinaryMarshaler interface.
+func (n Namespace) MarshalBinary() ([]byte, error) {
+	return n.Bytes(), nil
+}
+
+// UnmarshalBinary sets the namespace from the provided bytes after validating
+// them. It implements the encoding.BinaryUnmarshaler interface.
+func (n *Namespace) UnmarshalBinary(data []byte) error {
+	ns, err := From(append([]byte(nil), data...))
+	if err != nil {
+		return err
+	}
+	*n = ns
+	return nil
+}
+
+// ID0 returns the user-specified portion of a version 0 namespace ID, i.e. the
+// ID without the NamespaceVersionZeroPrefix. It returns nil if the namespace is
+// not a version 0 namespace.
+func (n Namespace) ID0() []byte {
+	if n.Version != NamespaceVersionZero || len(n.ID) != NamespaceIDSize {
+		return nil
+	}
+	return n.ID[NamespaceVersionZeroPrefixSize:]
+}
+
+// String returns the namespace formatted as v<version>:<hex-encoded id>.
+func (n Namespace) String() string {
+	return fmt.Sprintf("v%d:%x", n.Version, n.ID)
+}
+
+// TrimmedString returns the ID of the namespace with its leading zeros
+// stripped for display, e.g. "myrollup" for a namespace created by
+// NewV0FromString("myrollup"). Printable characters are kept and all other
+// bytes, as well as backslashes, are escaped as \xNN.
+func (n Namespace) TrimmedString() string {
+	id := bytes.TrimLeft(n.ID, "\x00")
+	var sb strings.Builder
+	for len(id) > 0 {
+		r, size := utf8.DecodeRune(id)
+		if r == utf8.RuneError || r == '\\' || !unicode.IsPrint(r) {
+			fmt.Fprintf(&sb, "\\x%02x", id[0])
+			id = id[1:]
+			continue
+		}
+		sb.WriteRune(r)
+		id = id[size:]
+	}
+	return sb.String()
+}
+
+// MarshalText returns the namespace as 0x-prefixed hex of its version and ID
+// bytes. It implements the encoding.TextMarshaler interface.
+func (n Namespace) MarshalText() ([]byte, error) {
+	text := make([]byte, 2+hex.EncodedLen(NamespaceVersionSize+len(n.ID)))
+	copy(text, "0x")
+	hex.Encode(text[2:], n.Bytes())
+	return text, nil
+}
+
+// UnmarshalText parses a namespace in the form returned by MarshalText or by
+// String and validates it. It implements the encoding.TextUnmarshaler
+// interface.
+func (n *Namespace) UnmarshalText(text []byte) error {
+	var (
+		ns  Namespace
+		err error
+	)
+	if hexBytes, ok := bytes.CutPrefix(text, []byte("0x")); ok {
+		ns, err = parseHex(hexBytes)
+	} else {
+		ns, err = Parse(string(text))
+	}
+	if err != nil {
+		return err
+	}
+	*n = ns
+	return nil
+}
+
+// parseHex decodes hex encoded version and ID bytes into a validated
+// namespace.
+func parseHex(text []byte) (Namespace, error) {
+	b := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(b, text); err != nil {
+		return Namespace{}, fmt.Errorf("invalid namespace hex %q: %w", text, err)
+	}
+	return From(b)
+}
+
+// namespaceJSON is the JSON object form of a namespace with an explicit
+// version and a 0x-prefixed hex encoded ID.
+type namespaceJSON struct {
+	Version *uint8 `json:"version"`
+	ID      string `json:"id"`
+}
+
+// MarshalJSON returns the namespace as a JSON string in the form returned by
+// MarshalText. It implements the json.Marshaler interface.
+func (n Namespace) MarshalJSON() ([]byte, error) {
+	text, err := n.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON parses a namespace from either a JSON string accepted by
+// UnmarshalText or a JSON object with a version and a 0x-prefixed hex encoded
+// ID, e.g. {"version":0,"id":"0x00…01"}. The namespace is validated like New.
+// It implements the json.Unmarshaler interface.
+func (n *Namespace) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return n.UnmarshalText([]byte(text))
+	}
+
+	var obj namespaceJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("invalid namespace JSON: expected a string or an object with a version and an id: %w", err)
+	}
+	if obj.Version == nil {
+		return fmt.Errorf("invalid namespace JSON %s: missing version", data)
+	}
+	hexID, ok := strings.CutPrefix(obj.ID, "0x")
+	if !ok {
+		return fmt.Errorf("invalid namespace JSON %s: id must be 0x-prefixed hex", data)
+	}
+	id, err := hex.DecodeString(hexID)
+	if err != nil {
+		return fmt.Errorf("invalid namespace id in %s: %w", data, err)
+	}
+	ns, err := New(*obj.Version, id)
+	if err != nil {
+		return err
+	}
+	*n = ns
+	return nil
+}
+
+// Parse parses a namespace formatted as v<version>:<hex-encoded id>.
+func Parse(s string) (Namespace, error) {
+	rawVersion, rawID, ok := strings.Cut(s, ":")
+	if !ok || !strings.HasPrefix(rawVersion, "v") {
+		return Namespace{}, fmt.Errorf("invalid namespace %q: expected v<version>:<hex id>", s)
+	}
+	version, err := strconv.ParseUint(rawVersion[1:], 10, 8)
+	if err != nil {
+		return Namespace{}, fmt.Errorf("invalid namespace version in %q: %w", s, err)
+	}
+	id, err := hex.DecodeString(rawID)
+	if err != nil {
+		return Namespace{}, fmt.Errorf("invalid namespace id in %q: %w", s, err)
+	}
+	return New(uint8(version), id)
+}
+
+// Set parses the namespace from s. Together with String and Type it allows a
+// *Namespace to be used as a command line flag value.
+func (n *Namespace) Set(s string) error {
+	return n.UnmarshalText([]byte(s))
+}
+
+// Type returns the name of the flag value type.
+func (n *Namespace) Type() string {
+	return "namespace"
+}
+
+// Validate returns an error if the namespace is malformed, i.e. if its version
+// is not supported or its ID doesn't meet the requirements of its version. It
+// doesn't check whether the namespace may be used for a blob, see
+// ValidateForBlob.
+func (n Namespace) Validate() error {
+	if err := validateVersionSupported(n.Version); err != nil {
+		return err
+	}
+	return validateID(n.Version, n.ID)
+}
+
+// ValidateForBlob returns an error if the namespace can't be used for a
+// user-submitted blob. On top of Validate it requires a namespace outside of
+// the reserved ranges with a version listed in SupportedBlobNamespaceVersions.
+func (n Namespace) ValidateForBlob() error {
+	if err := n.Validate(); err != nil {
+		return err
+	}
+	if n.IsParityShares() || n.IsTailPadding() {
+		return fmt.Errorf("%w: %w: namespace %x", ErrReservedNamespace, ErrParityOrTailPadding, n.Bytes())
+	}
+	if n.IsPrimaryReserved() {
+		return fmt.Errorf("%w: namespace %x is <= MaxPrimaryReservedNamespace", ErrReservedNamespace, n.Bytes())
+	}
+	if n.IsSecondaryReserved() {
+		return fmt.Errorf("%w: namespace %x is >= MinSecondaryReservedNamespace", ErrReservedNamespace, n.Bytes())
+	}
+	if !slices.Contains(SupportedBlobNamespaceVersions, n.Version) {
+		return fmt.Errorf("%w: namespace %x has namespace version %v but blobs support namespace versions %v", ErrInvalidVersion, n.Bytes(), n.Version, SupportedBlobNamespaceVersions)
+	}
+	return nil
+}
+
 // validateVersionSupported returns an error if the version is not supported.
 func validateVersionSupported(version uint8) error {
-	if version != NamespaceVersionZero && version != NamespaceVersionMax {
-		return fmt.Errorf("unsupported namespace version %v", version)
+	if !slices.Contains(SupportedNamespaceVersions, version) {
+		return fmt.Errorf("%w: namespace version %v is not one of the supported namespace versions %v", ErrInvalidVersion, version, SupportedNamespaceVersions)
 	}
 	return nil
 }
@@ -95,11 +394,11 @@ func validateVersionSupported(version uint8) error {
 // for the provided version.
 func validateID(version uint8, id []byte) error {
 	if len(id) != NamespaceIDSize {
-		return fmt.Errorf("unsupported namespace id length: id %v must be %v bytes but it was %v bytes", id, NamespaceIDSize, len(id))
+		return fmt.Errorf("%w: namespace %x has an id of %v bytes but it must be %v bytes", ErrInvalidIDLength, append([]byte{version}, id...), len(id), NamespaceIDSize)
 	}
 
 	if version == NamespaceVersionZero && !bytes.HasPrefix(id, NamespaceVersionZeroPrefix) {
-		return fmt.Errorf("unsupported namespace id with version %v. ID %v must start with %v leading zeros", version, id, len(NamespaceVersionZeroPrefix))
+		return fmt.Errorf("%w: namespace %x must start with %v leading zeros after the version", ErrInvalidLeadingZeros, append([]byte{version}, id...), len(NamespaceVersionZeroPrefix))
 	}
 	return nil
 }
@@ -109,32 +408,42 @@ func (n Namespace) IsReserved() bool {
 	return n.IsPrimaryReserved() || n.IsSecondaryReserved()
 }
 
+// IsPrimaryReserved returns true if the namespace is less than or equal to
+// MaxPrimaryReservedNamespace.
 func (n Namespace) IsPrimaryReserved() bool {
 	return n.IsLessOrEqualThan(MaxPrimaryReservedNamespace)
 }
 
+// IsSecondaryReserved returns true if the namespace is greater than or equal to
+// MinSecondaryReservedNamespace.
 func (n Namespace) IsSecondaryReserved() bool {
 	return n.IsGreaterOrEqualThan(MinSecondaryReservedNamespace)
 }
 
+// IsParityShares returns true if the namespace is ParitySharesNamespace.
 func (n Namespace) IsParityShares() bool {
-	return bytes.Equal(n.Bytes(), ParitySharesNamespace.Bytes())
+	return n.Equals(ParitySharesNamespace)
 }
 
+// IsTailPadding returns true if the namespace is TailPaddingNamespace.
 func (n Namespace) IsTailPadding() bool {
-	return bytes.Equal(n.Bytes(), TailPaddingNamespace.Bytes())
+	return n.Equals(TailPaddingNamespace)
 }
 
+// IsPrimaryReservedPadding returns true if the namespace is
+// PrimaryReservedPaddingNamespace.
 func (n Namespace) IsPrimaryReservedPadding() bool {
-	return bytes.Equal(n.Bytes(), PrimaryReservedPaddingNamespace.Bytes())
+	return n.Equals(PrimaryReservedPaddingNamespace)
 }
 
+// IsTx returns true if the namespace is TxNamespace.
 func (n Namespace) IsTx() bool {
-	return bytes.Equal(n.Bytes(), TxNamespace.Bytes())
+	return n.Equals(TxNamespace)
 }
 
+// IsPayForBlob returns true if the namespace is PayForBlobNamespace.
 func (n Namespace) IsPayForBlob() bool {
-	return bytes.Equal(n.Bytes(), PayForBlobNamespace.Bytes())
+	return n.Equals(PayForBlobNamespace)
 }
 
 func (n Namespace) Repeat(times int) []Namespace {
@@ -146,23 +455,133 @@ func (n Namespace) Repeat(times int) []Namespace {
 }
 
 func (n Namespace) Equals(n2 Namespace) bool {
-	return bytes.Equal(n.Bytes(), n2.Bytes())
+	return n.Version == n2.Version && bytes.Equal(n.ID, n2.ID)
+}
+
+// Compare compares the namespaces in the order of their serialized form, i.e.
+// by version and then by ID. The result is 0 if n == n2, -1 if n < n2 and +1
+// if n > n2. Unlike comparing the results of Bytes, it doesn't allocate.
+func (n Namespace) Compare(n2 Namespace) int {
+	switch {
+	case n.Version < n2.Version:
+		return -1
+	case n.Version > n2.Version:
+		return 1
+	}
+	return bytes.Compare(n.ID, n2.ID)
 }
 
 func (n Namespace) IsLessThan(n2 Namespace) bool {
-	return bytes.Compare(n.Bytes(), n2.Bytes()) == -1
+	return n.Compare(n2) == -1
 }
 
 func (n Namespace) IsLessOrEqualThan(n2 Namespace) bool {
-	return bytes.Compare(n.Bytes(), n2.Bytes()) < 1
+	return n.Compare(n2) < 1
 }
 
 func (n Namespace) IsGreaterThan(n2 Namespace) bool {
-	return bytes.Compare(n.Bytes(), n2.Bytes()) == 1
+	return n.Compare(n2) == 1
 }
 
 func (n Namespace) IsGreaterOrEqualThan(n2 Namespace) bool {
-	return bytes.Compare(n.Bytes(), n2.Bytes()) > -1
+	return n.Compare(n2) > -1
+}
+
+// IsAboveMax returns true if the namespace is greater than maxNs. A namespace
+// equal to maxNs is not above it.
+func (n Namespace) IsAboveMax(maxNs Namespace) bool {
+	return n.IsGreaterThan(maxNs)
+}
+
+// IsBelowMin returns true if the namespace is less than minNs. A namespace
+// equal to minNs is not below it.
+func (n Namespace) IsBelowMin(minNs Namespace) bool {
+	return n.IsLessThan(minNs)
+}
+
+// Min returns the lesser of the two namespaces or a if they are equal.
+func Min(a, b Namespace) Namespace {
+	if b.IsLessThan(a) {
+		return b
+	}
+	return a
+}
+
+// Max returns the greater of the two namespaces or a if they are equal.
+func Max(a, b Namespace) Namespace {
+	if b.IsGreaterThan(a) {
+		return b
+	}
+	return a
+}
+
+// AddInt returns the namespace delta steps away from n in the order defined by
+// Compare, treating the version and ID bytes as a big-endian integer. It
+// returns ErrNamespaceOverflow or ErrNamespaceUnderflow instead of wrapping
+// around and an error wrapping ErrInvalidLeadingZeros or ErrInvalidVersion if
+// the result is not a valid namespace, e.g. when incrementing the largest
+// version 0 namespace.
+func (n Namespace) AddInt(delta int) (Namespace, error) {
+	if err := n.Validate(); err != nil {
+		return Namespace{}, err
+	}
+	b := n.Bytes()
+	if delta >= 0 {
+		carry := uint64(delta)
+		for i := len(b) - 1; i >= 0 && carry > 0; i-- {
+			sum := uint64(b[i]) + carry
+			b[i] = byte(sum)
+			carry = sum >> 8
+		}
+		if carry > 0 {
+			return Namespace{}, fmt.Errorf("%w: adding %d to namespace %x", ErrNamespaceOverflow, delta, n.Bytes())
+		}
+	} else {
+		// -(delta+1)+1 avoids overflowing on math.MinInt
+		borrow := uint64(-(delta + 1)) + 1
+		for i := len(b) - 1; i >= 0 && borrow > 0; i-- {
+			v, sub := uint64(b[i]), borrow&0xFF
+			borrow >>= 8
+			if v < sub {
+				v += 1 << 8
+				borrow++
+			}
+			b[i] = byte(v - sub)
+		}
+		if borrow > 0 {
+			return Namespace{}, fmt.Errorf("%w: adding %d to namespace %x", ErrNamespaceUnderflow, delta, n.Bytes())
+		}
+	}
+	ns, err := From(b)
+	if err != nil {
+		return Namespace{}, fmt.Errorf("adding %d to namespace %x leaves the valid namespaces: %w", delta, n.Bytes(), err)
+	}
+	return ns, nil
+}
+
+// Next returns the smallest namespace greater than n. See AddInt for the
+// errors it returns.
+func (n Namespace) Next() (Namespace, error) {
+	return n.AddInt(1)
+}
+
+// Prev returns the largest namespace less than n. See AddInt for the errors it
+// returns.
+func (n Namespace) Prev() (Namespace, error) {
+	return n.AddInt(-1)
+}
+
+// Namespaces attaches the methods of sort.Interface to a slice of namespaces,
+// sorting them in the order defined by Compare.
+type Namespaces []Namespace
+
+func (ns Namespaces) Len() int           { return len(ns) }
+func (ns Namespaces) Less(i, j int) bool { return ns[i].IsLessThan(ns[j]) }
+func (ns Namespaces) Swap(i, j int)      { ns[i], ns[j] = ns[j], ns[i] }
+
+// Sort sorts the namespaces in the order defined by Compare.
+func Sort(namespaces []Namespace) {
+	sort.Sort(Namespaces(namespaces))
 }
 
 // leftPad returns a new byte slice with the provided byte slice left-padded to the provided size.
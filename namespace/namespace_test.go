@@ -2,11 +2,18 @@ package namespace
 
 import (
 	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
 	"math"
+	"math/rand"
 	"reflect"
+	"slices"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -297,3 +304,522 @@ func TestIsReserved(t *testing.T) {
 		assert.Equal(t, tc.want, got)
 	}
 }
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "v0:00000000000000000000000000000000000000000000000000000001", TxNamespace.String())
+	assert.Equal(t, "v255:fffffffffffffffffffffffffffffffffffffffffffffffffffffffe", TailPaddingNamespace.String())
+	assert.Equal(t, "v0:00000000000000000000000000000000000000000000000074657374", MustNewV0([]byte("test")).String())
+}
+
+func TestID0(t *testing.T) {
+	subID := bytes.Repeat([]byte{1}, NamespaceVersionZeroIDSize)
+	assert.Equal(t, subID, MustNewV0(subID).ID0())
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, TxNamespace.ID0())
+	assert.Nil(t, ParitySharesNamespace.ID0())
+}
+
+func TestNamespaceBinaryRoundTrip(t *testing.T) {
+	type wrapper struct {
+		Namespace Namespace
+	}
+	want := wrapper{Namespace: MustNewV0([]byte("test"))}
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(want))
+	var got wrapper
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&got))
+	assert.Equal(t, want, got)
+
+	var ns Namespace
+	assert.Error(t, ns.UnmarshalBinary(TxNamespace.Bytes()[1:]))
+	assert.Error(t, ns.UnmarshalBinary(append(TxNamespace.Bytes(), 0)))
+	assert.Error(t, ns.UnmarshalBinary(append([]byte{1}, TxNamespace.ID...)))
+}
+
+func TestNamespaceTextRoundTrip(t *testing.T) {
+	type wrapper struct {
+		Namespace Namespace `json:"namespace"`
+	}
+	want := wrapper{Namespace: MustNewV0([]byte("test"))}
+
+	bz, err := json.Marshal(want)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"namespace":"0x0000000000000000000000000000000000000000000000000074657374"}`, string(bz))
+	var got wrapper
+	assert.NoError(t, json.Unmarshal(bz, &got))
+	assert.Equal(t, want, got)
+
+	for _, want := range []Namespace{TxNamespace, TailPaddingNamespace, MustNewV0([]byte("test"))} {
+		text, err := want.MarshalText()
+		assert.NoError(t, err)
+		var got Namespace
+		assert.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, want, got)
+		// the String form is accepted too
+		assert.NoError(t, got.UnmarshalText([]byte(want.String())))
+		assert.Equal(t, want, got)
+	}
+
+	var ns Namespace
+	assert.NoError(t, ns.Set(TailPaddingNamespace.String()))
+	assert.Equal(t, TailPaddingNamespace, ns)
+	assert.Equal(t, "namespace", ns.Type())
+
+	invalid := []string{
+		"",
+		"00000000000000000000000000000000000000000000000074657374",
+		"x0:00000000000000000000000000000000000000000000000074657374",
+		"v256:00000000000000000000000000000000000000000000000074657374",
+		"v1:00000000000000000000000000000000000000000000000074657374",
+		"v0:zz",
+		"v0:0000000000000074657374",
+		"v0:01000000000000000000000000000000000000000000000074657374",
+		"0x",
+		"0x0000000000000000000000000000000000000000000000000074657374zz",
+		"0x00000000000000000000000000000000000000000000000074657374",
+		"0x0001000000000000000000000000000000000000000000000074657374",
+		"0x0100000000000000000000000000000000000000000000000074657374",
+		"0X0000000000000000000000000000000000000000000000000074657374",
+	}
+	for _, s := range invalid {
+		assert.Error(t, ns.UnmarshalText([]byte(s)), s)
+	}
+}
+
+func TestNamespaceJSON(t *testing.T) {
+	ns := MustNewV0([]byte("test"))
+	idHex := "0x00000000000000000000000000000000000000000000000074657374"
+
+	type testCase struct {
+		name    string
+		json    string
+		want    Namespace
+		wantErr error
+	}
+	testCases := []testCase{
+		{name: "full form", json: `"0x0000000000000000000000000000000000000000000000000074657374"`, want: ns},
+		{name: "string form", json: `"v0:00000000000000000000000000000000000000000000000074657374"`, want: ns},
+		{name: "id form", json: `{"version":0,"id":"` + idHex + `"}`, want: ns},
+		{name: "id form max version", json: `{"version":255,"id":"0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffe"}`, want: TailPaddingNamespace},
+		{name: "full form too short", json: `"0x00000000000000000000000000000000000000000000000074657374"`, wantErr: ErrInvalidLength},
+		{name: "full form unsupported version", json: `"0x0100000000000000000000000000000000000000000000000074657374"`, wantErr: ErrInvalidVersion},
+		{name: "full form missing version zero prefix", json: `"0x0001000000000000000000000000000000000000000000000074657374"`, wantErr: ErrInvalidLeadingZeros},
+		{name: "id form unsupported version", json: `{"version":1,"id":"` + idHex + `"}`, wantErr: ErrInvalidVersion},
+		{name: "id form too short", json: `{"version":0,"id":"0x000000000000000000000000000000000000000000000074657374"}`, wantErr: ErrInvalidIDLength},
+		{name: "id form full length", json: `{"version":0,"id":"0x0000000000000000000000000000000000000000000000000074657374"}`, wantErr: ErrInvalidIDLength},
+		{name: "id form missing version zero prefix", json: `{"version":0,"id":"0x01000000000000000000000000000000000000000000000074657374"}`, wantErr: ErrInvalidLeadingZeros},
+		{name: "id form missing version", json: `{"id":"` + idHex + `"}`},
+		{name: "id form without 0x prefix", json: `{"version":0,"id":"` + idHex[2:] + `"}`},
+		{name: "id form version out of range", json: `{"version":256,"id":"` + idHex + `"}`},
+		{name: "number", json: `1`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Namespace
+			err := json.Unmarshal([]byte(tc.json), &got)
+			if tc.want.ID == nil {
+				assert.Error(t, err)
+				if tc.wantErr != nil {
+					assert.ErrorIs(t, err, tc.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNamespaceJSONEmbedded(t *testing.T) {
+	type config struct {
+		Name       string      `json:"name"`
+		Namespace  Namespace   `json:"namespace"`
+		Namespaces []Namespace `json:"namespaces"`
+	}
+	want := config{
+		Name:       "test",
+		Namespace:  MustNewV0([]byte("test")),
+		Namespaces: []Namespace{TxNamespace, ParitySharesNamespace},
+	}
+
+	bz, err := json.Marshal(want)
+	assert.NoError(t, err)
+	var got config
+	assert.NoError(t, json.Unmarshal(bz, &got))
+	assert.Equal(t, want, got)
+
+	wantKeys := map[string]Namespace{"tx": TxNamespace}
+	bz, err = json.Marshal(wantKeys)
+	assert.NoError(t, err)
+	var gotKeys map[string]Namespace
+	assert.NoError(t, json.Unmarshal(bz, &gotKeys))
+	assert.Equal(t, wantKeys, gotKeys)
+
+	err = json.Unmarshal([]byte(`{"name":"test","namespace":"0x01"}`), &got)
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
+
+func TestValidationErrors(t *testing.T) {
+	_, err := New(1, validID)
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+	_, err = New(NamespaceVersionZero, validID[1:])
+	assert.ErrorIs(t, err, ErrInvalidIDLength)
+	_, err = New(NamespaceVersionZero, bytes.Repeat([]byte{1}, NamespaceIDSize))
+	assert.ErrorIs(t, err, ErrInvalidLeadingZeros)
+	_, err = From(TxNamespace.Bytes()[1:])
+	assert.ErrorIs(t, err, ErrInvalidLength)
+}
+
+// randomNamespaces returns n namespaces with few distinct versions and IDs so
+// that equal namespaces and namespaces that only differ in version occur.
+func randomNamespaces(rng *rand.Rand, n int) []Namespace {
+	namespaces := make([]Namespace, n)
+	for i := range namespaces {
+		id := make([]byte, NamespaceIDSize)
+		id[rng.Intn(2)] = byte(rng.Intn(3))
+		id[NamespaceIDSize-1] = byte(rng.Intn(3))
+		namespaces[i] = Namespace{Version: []uint8{0, 1, math.MaxUint8}[rng.Intn(3)], ID: id}
+	}
+	return namespaces
+}
+
+func TestCompareIsTotalOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	namespaces := randomNamespaces(rng, 50)
+	for _, a := range namespaces {
+		for _, b := range namespaces {
+			cmp := a.Compare(b)
+			assert.Equal(t, bytes.Compare(a.Bytes(), b.Bytes()), cmp)
+			assert.Equal(t, -cmp, b.Compare(a))
+			assert.Equal(t, cmp == 0, a.Equals(b))
+			assert.Equal(t, cmp < 0, a.IsLessThan(b))
+			assert.Equal(t, cmp <= 0, a.IsLessOrEqualThan(b))
+			assert.Equal(t, cmp > 0, a.IsGreaterThan(b))
+			assert.Equal(t, cmp >= 0, a.IsGreaterOrEqualThan(b))
+			for _, c := range namespaces {
+				if cmp <= 0 && b.Compare(c) <= 0 {
+					assert.LessOrEqual(t, a.Compare(c), 0)
+				}
+			}
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		namespaces := randomNamespaces(rng, 100)
+		Sort(namespaces)
+		assert.True(t, sort.IsSorted(Namespaces(namespaces)))
+		for j := 1; j < len(namespaces); j++ {
+			assert.LessOrEqual(t, bytes.Compare(namespaces[j-1].Bytes(), namespaces[j].Bytes()), 0)
+		}
+	}
+	Sort(nil)
+}
+
+func TestRangePredicates(t *testing.T) {
+	low := MustNewV0(bytes.Repeat([]byte{1}, NamespaceVersionZeroIDSize))
+	high := MustNewV0(bytes.Repeat([]byte{2}, NamespaceVersionZeroIDSize))
+
+	assert.False(t, low.IsAboveMax(low))
+	assert.False(t, low.IsBelowMin(low))
+	assert.True(t, high.IsAboveMax(low))
+	assert.False(t, low.IsAboveMax(high))
+	assert.True(t, low.IsBelowMin(high))
+	assert.False(t, high.IsBelowMin(low))
+
+	assert.Equal(t, low, Min(low, high))
+	assert.Equal(t, low, Min(high, low))
+	assert.Equal(t, high, Max(low, high))
+	assert.Equal(t, high, Max(high, low))
+
+	// equal namespaces return the first argument
+	lowCopy := low.deepCopy()
+	assert.Same(t, &low.ID[0], &Min(low, lowCopy).ID[0])
+	assert.Same(t, &low.ID[0], &Max(low, lowCopy).ID[0])
+}
+
+func TestValidateForBlob(t *testing.T) {
+	// the namespace directly after MaxPrimaryReservedNamespace
+	firstUserID := append(bytes.Repeat([]byte{0}, NamespaceIDSize-2), 1, 0)
+	firstUser := MustNew(NamespaceVersionZero, firstUserID)
+	lastUser := MustNew(NamespaceVersionZero, append(NamespaceVersionZeroPrefix, bytes.Repeat([]byte{0xFF}, NamespaceVersionZeroIDSize)...))
+	maxVersionUser := MustNew(NamespaceVersionMax, bytes.Repeat([]byte{0}, NamespaceIDSize))
+
+	type testCase struct {
+		name              string
+		ns                Namespace
+		wantValidateErr   error
+		wantForBlobErrs   []error
+		wantNotForBlobErr error
+	}
+	testCases := []testCase{
+		{name: "first user namespace", ns: firstUser},
+		{name: "last version 0 user namespace", ns: lastUser},
+		{name: "tx namespace", ns: TxNamespace, wantForBlobErrs: []error{ErrReservedNamespace}, wantNotForBlobErr: ErrParityOrTailPadding},
+		{name: "max primary reserved namespace", ns: MaxPrimaryReservedNamespace, wantForBlobErrs: []error{ErrReservedNamespace}, wantNotForBlobErr: ErrParityOrTailPadding},
+		{name: "min secondary reserved namespace", ns: MinSecondaryReservedNamespace, wantForBlobErrs: []error{ErrReservedNamespace}, wantNotForBlobErr: ErrParityOrTailPadding},
+		{name: "tail padding namespace", ns: TailPaddingNamespace, wantForBlobErrs: []error{ErrReservedNamespace, ErrParityOrTailPadding}},
+		{name: "parity shares namespace", ns: ParitySharesNamespace, wantForBlobErrs: []error{ErrReservedNamespace, ErrParityOrTailPadding}},
+		{name: "unsupported blob version", ns: maxVersionUser, wantForBlobErrs: []error{ErrInvalidVersion}, wantNotForBlobErr: ErrReservedNamespace},
+		{name: "invalid version", ns: Namespace{Version: 1, ID: firstUserID}, wantValidateErr: ErrInvalidVersion},
+		{name: "invalid id length", ns: Namespace{Version: NamespaceVersionZero, ID: firstUserID[1:]}, wantValidateErr: ErrInvalidIDLength},
+		{name: "missing leading zeros", ns: Namespace{Version: NamespaceVersionZero, ID: bytes.Repeat([]byte{1}, NamespaceIDSize)}, wantValidateErr: ErrInvalidLeadingZeros},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.ns.Validate()
+			if tc.wantValidateErr != nil {
+				assert.ErrorIs(t, err, tc.wantValidateErr)
+				assert.ErrorIs(t, tc.ns.ValidateForBlob(), tc.wantValidateErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = tc.ns.ValidateForBlob()
+			if len(tc.wantForBlobErrs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, want := range tc.wantForBlobErrs {
+				assert.ErrorIs(t, err, want)
+			}
+			if tc.wantNotForBlobErr != nil {
+				assert.NotErrorIs(t, err, tc.wantNotForBlobErr)
+			}
+			assert.Contains(t, err.Error(), hex.EncodeToString(tc.ns.Bytes()))
+		})
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	namespaces := append(randomNamespaces(rng, 100), RandomBlobNamespaces(rng, 100)...)
+	for _, ns := range namespaces {
+		if err := ns.Validate(); err != nil {
+			continue
+		}
+		next, err := ns.Next()
+		if err == nil {
+			assert.Less(t, ns.Compare(next), 0)
+			prev, err := next.Prev()
+			assert.NoError(t, err)
+			assert.Equal(t, ns, prev)
+		}
+		prev, err := ns.Prev()
+		if err == nil {
+			assert.Greater(t, ns.Compare(prev), 0)
+			next, err := prev.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, ns, next)
+		}
+	}
+}
+
+func TestAddInt(t *testing.T) {
+	minV0 := MustNew(NamespaceVersionZero, make([]byte, NamespaceIDSize))
+	maxV0 := MustNew(NamespaceVersionZero, append(NamespaceVersionZeroPrefix, bytes.Repeat([]byte{0xFF}, NamespaceVersionZeroIDSize)...))
+	minV255 := MustNew(NamespaceVersionMax, make([]byte, NamespaceIDSize))
+
+	type testCase struct {
+		name    string
+		ns      Namespace
+		delta   int
+		want    Namespace
+		wantErr error
+	}
+	testCases := []testCase{
+		{name: "zero", ns: TxNamespace, delta: 0, want: TxNamespace},
+		{name: "increment", ns: TxNamespace, delta: 1, want: primaryReservedNamespace(0x02)},
+		{name: "carry", ns: MaxPrimaryReservedNamespace, delta: 1, want: MustNew(NamespaceVersionZero, append(bytes.Repeat([]byte{0}, NamespaceIDSize-2), 1, 0))},
+		{name: "borrow", ns: MustNew(NamespaceVersionZero, append(bytes.Repeat([]byte{0}, NamespaceIDSize-2), 1, 0)), delta: -1, want: MaxPrimaryReservedNamespace},
+		{name: "multi byte delta", ns: minV0, delta: 0x010203, want: MustNew(NamespaceVersionZero, append(bytes.Repeat([]byte{0}, NamespaceIDSize-3), 1, 2, 3))},
+		{name: "multi byte negative delta", ns: MustNew(NamespaceVersionZero, append(bytes.Repeat([]byte{0}, NamespaceIDSize-3), 1, 2, 3)), delta: -0x010203, want: minV0},
+		{name: "tail padding to parity", ns: TailPaddingNamespace, delta: 1, want: ParitySharesNamespace},
+		{name: "overflow past the largest namespace", ns: ParitySharesNamespace, delta: 1, wantErr: ErrNamespaceOverflow},
+		{name: "overflow with max int", ns: TailPaddingNamespace, delta: math.MaxInt, wantErr: ErrNamespaceOverflow},
+		{name: "underflow below the smallest namespace", ns: minV0, delta: -1, wantErr: ErrNamespaceUnderflow},
+		{name: "underflow with min int", ns: TxNamespace, delta: math.MinInt, wantErr: ErrNamespaceUnderflow},
+		{name: "leaves version 0", ns: maxV0, delta: 1, wantErr: ErrInvalidLeadingZeros},
+		{name: "below version 255", ns: minV255, delta: -1, wantErr: ErrInvalidVersion},
+		{name: "invalid namespace", ns: Namespace{Version: NamespaceVersionZero, ID: []byte{1}}, delta: 1, wantErr: ErrInvalidIDLength},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.ns.AddInt(tc.delta)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSupportedNamespaceVersions(t *testing.T) {
+	for version := 0; version <= math.MaxUint8; version++ {
+		_, err := New(uint8(version), validID)
+		if slices.Contains(SupportedNamespaceVersions, uint8(version)) {
+			assert.NoError(t, err, "version %d", version)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidVersion, "version %d", version)
+		assert.Contains(t, err.Error(), "namespace version")
+	}
+}
+
+func TestNewV0FromString(t *testing.T) {
+	type testCase struct {
+		name    string
+		s       string
+		want    string
+		wantErr error
+	}
+	testCases := []testCase{
+		{name: "myrollup", s: "myrollup", want: "0x0000000000000000000000000000000000000000006d79726f6c6c7570"},
+		{name: "max length", s: "celestia10", want: "0x0000000000000000000000000000000000000063656c65737469613130"},
+		{name: "two bytes", s: "ab", want: "0x0000000000000000000000000000000000000000000000000000006162"},
+		{name: "multi byte utf-8", s: "ñ", want: "0x000000000000000000000000000000000000000000000000000000c3b1"},
+		{name: "too long", s: "celestia100", wantErr: ErrInvalidIDLength},
+		{name: "empty", s: "", wantErr: ErrReservedNamespace},
+		{name: "single byte", s: "a", wantErr: ErrReservedNamespace},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewV0FromString(tc.s)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			text, err := got.MarshalText()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, string(text))
+			assert.Equal(t, tc.s, got.TrimmedString())
+		})
+	}
+}
+
+func TestTrimmedString(t *testing.T) {
+	assert.Equal(t, `\x01`, TxNamespace.TrimmedString())
+	assert.Equal(t, `\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe`, TailPaddingNamespace.TrimmedString())
+	assert.Equal(t, "", MustNew(NamespaceVersionZero, make([]byte, NamespaceIDSize)).TrimmedString())
+	assert.Equal(t, `a\x00b\x5cc\x0a`, MustNewV0([]byte("a\x00b\\c\n")).TrimmedString())
+}
+
+func TestParseNamespace(t *testing.T) {
+	valid := MustNewV0([]byte("test")).Bytes()
+	got, err := ParseNamespace(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, MustNewV0([]byte("test")), got)
+	// the namespace doesn't alias the input
+	valid[len(valid)-1] = 0
+	assert.Equal(t, MustNewV0([]byte("test")), got)
+
+	type testCase struct {
+		name    string
+		b       []byte
+		wantErr error
+	}
+	testCases := []testCase{
+		{name: "too short", b: TxNamespace.Bytes()[1:], wantErr: ErrInvalidLength},
+		{name: "too long", b: append(TxNamespace.Bytes(), 0), wantErr: ErrInvalidLength},
+		{name: "unsupported version", b: append([]byte{1}, TxNamespace.ID...), wantErr: ErrInvalidVersion},
+		{name: "missing version 0 leading zeros", b: append([]byte{0}, bytes.Repeat([]byte{1}, NamespaceIDSize)...), wantErr: ErrInvalidLeadingZeros},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseNamespace(tc.b)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Contains(t, err.Error(), hex.EncodeToString(tc.b))
+		})
+	}
+}
+
+func FuzzParseNamespace(f *testing.F) {
+	f.Add(TxNamespace.Bytes())
+	f.Add(TailPaddingNamespace.Bytes())
+	f.Add(append([]byte{0}, bytes.Repeat([]byte{1}, NamespaceIDSize)...))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		got, err := ParseNamespace(b)
+		if len(b) != NamespaceSize {
+			require.Error(t, err)
+			return
+		}
+		want, wantErr := New(b[0], b[1:])
+		if wantErr != nil {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.True(t, want.Equals(got))
+		require.Equal(t, b, got.Bytes())
+	})
+}
+
+func TestDeriveV0(t *testing.T) {
+	// test vectors computed independently of this package that other
+	// implementations of DeriveV0 must agree with
+	vectors := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: "0x00000000000000000000000000000000000000e3b0c44298fc1c149afb"},
+		{input: "celestia", want: "0x0000000000000000000000000000000000000013569e54470584904205"},
+		{input: "mocha-4", want: "0x00000000000000000000000000000000000000f5f7f7dbf175fa0aca03"},
+		{input: "rollup.example.com", want: "0x00000000000000000000000000000000000000cc2e884a87c5c217cec4"},
+	}
+	for _, v := range vectors {
+		got := DeriveV0([]byte(v.input))
+		text, err := got.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, v.want, string(text), "input %q", v.input)
+		assert.NoError(t, got.ValidateForBlob())
+	}
+
+	// the sub ID of a retry appends the big-endian counter to the input
+	assert.Equal(t, "7d8dab1897f5e0cf9046", hex.EncodeToString(derivedSubID([]byte("celestia"), 1)))
+}
+
+func TestNewV0FromUint64(t *testing.T) {
+	for _, n := range []uint64{0, 1, 255, 256, 1000, math.MaxUint32, math.MaxUint64} {
+		ns, err := NewV0FromUint64(n)
+		require.NoError(t, err)
+		assert.NoError(t, ns.ValidateForBlob(), "n %d", n)
+		got, ok := ns.AsUint64()
+		assert.True(t, ok)
+		assert.Equal(t, n, got)
+	}
+
+	ns, err := NewV0FromUint64(0x0102030405060708)
+	require.NoError(t, err)
+	assert.Equal(t, "v0:00000000000000000000000000000000000000010102030405060708", ns.String())
+
+	for _, ns := range []Namespace{TxNamespace, TailPaddingNamespace, MustNewV0([]byte("test")), DeriveV0([]byte("celestia")), {}} {
+		_, ok := ns.AsUint64()
+		assert.False(t, ok, ns.String())
+	}
+}
+
+func TestNewV0FromUint64Order(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		n, m := rng.Uint64()>>uint(rng.Intn(64)), rng.Uint64()>>uint(rng.Intn(64))
+		nsN, err := NewV0FromUint64(n)
+		require.NoError(t, err)
+		nsM, err := NewV0FromUint64(m)
+		require.NoError(t, err)
+		want := 0
+		switch {
+		case n < m:
+			want = -1
+		case n > m:
+			want = 1
+		}
+		assert.Equal(t, want, nsN.Compare(nsM), "n %d, m %d", n, m)
+	}
+}
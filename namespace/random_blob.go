@@ -2,6 +2,7 @@ package namespace
 
 import (
 	"crypto/rand"
+	mrand "math/rand"
 
 	"golang.org/x/exp/slices"
 )
@@ -15,16 +16,38 @@ func RandomBlobNamespaceID() []byte {
 	return namespace
 }
 
-func RandomBlobNamespace() Namespace {
+// RandomBlobNamespace returns a random version 0 namespace that can be used
+// for a blob, i.e. one outside of the reserved ranges. Namespaces that fall
+// into a reserved range are discarded and redrawn so the result is
+// deterministic for the state of r.
+func RandomBlobNamespace(r *mrand.Rand) Namespace {
+	subID := make([]byte, NamespaceVersionZeroIDSize)
 	for {
-		id := RandomBlobNamespaceID()
-		namespace := MustNewV0(id)
+		_, _ = r.Read(subID)
+		namespace := MustNewV0(subID)
 		if isBlobNamespace(namespace) {
 			return namespace
 		}
 	}
 }
 
+// RandomBlobNamespaces returns count distinct namespaces drawn like
+// RandomBlobNamespace. Duplicates are redrawn, which is cheap because count is
+// expected to be tiny compared to the 2^80 possible version 0 namespaces.
+func RandomBlobNamespaces(r *mrand.Rand, count int) []Namespace {
+	namespaces := make([]Namespace, 0, count)
+	seen := make(map[string]struct{}, count)
+	for len(namespaces) < count {
+		namespace := RandomBlobNamespace(r)
+		if _, ok := seen[string(namespace.ID)]; ok {
+			continue
+		}
+		seen[string(namespace.ID)] = struct{}{}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
 // isBlobNamespace returns an true if this namespace is a valid user-specifiable
 // blob namespace.
 func isBlobNamespace(ns Namespace) bool {
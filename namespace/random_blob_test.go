@@ -0,0 +1,62 @@
+package namespace
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomBlobNamespacesPinned(t *testing.T) {
+	// pinned to catch accidental changes to the generated distribution
+	want := []string{
+		"v0:00000000000000000000000000000000000052fdfc072182654f163f",
+		"v0:0000000000000000000000000000000000005f0f9a621d729566c74d",
+		"v0:00000000000000000000000000000000000010037c4d7bbb0407d1e2",
+	}
+	got := RandomBlobNamespaces(rand.New(rand.NewSource(1)), len(want))
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i], got[i].String())
+	}
+	assert.Equal(t, got[0], RandomBlobNamespace(rand.New(rand.NewSource(1))))
+}
+
+func TestRandomBlobNamespaces(t *testing.T) {
+	const count = 1000
+	got := RandomBlobNamespaces(rand.New(rand.NewSource(2)), count)
+	assert.Equal(t, got, RandomBlobNamespaces(rand.New(rand.NewSource(2)), count))
+
+	seen := make(map[string]bool, count)
+	for _, ns := range got {
+		assert.NoError(t, ns.ValidateForBlob())
+		assert.Equal(t, NamespaceVersionZero, ns.Version)
+		assert.False(t, seen[string(ns.ID)], "duplicate namespace %s", ns)
+		seen[string(ns.ID)] = true
+	}
+	assert.Empty(t, RandomBlobNamespaces(rand.New(rand.NewSource(2)), 0))
+}
+
+// zeroSource returns zeros for the first n values and then defers to source.
+type zeroSource struct {
+	rand.Source
+	n int
+}
+
+func (s *zeroSource) Int63() int64 {
+	if s.n > 0 {
+		s.n--
+		return 0
+	}
+	return s.Source.Int63()
+}
+
+func TestRandomBlobNamespaceSkipsReserved(t *testing.T) {
+	// the first two values fill the sub ID with zeros, which is a primary
+	// reserved namespace
+	r := rand.New(&zeroSource{Source: rand.NewSource(1), n: 2})
+	ns := RandomBlobNamespace(r)
+	assert.NoError(t, ns.ValidateForBlob())
+	assert.NotEqual(t, make([]byte, NamespaceVersionZeroIDSize), ns.ID0())
+}
@@ -0,0 +1,57 @@
+package namespace
+
+import "fmt"
+
+// Range is the inclusive interval of namespaces [Min, Max]. The zero value is
+// the empty range.
+type Range struct {
+	Min Namespace
+	Max Namespace
+}
+
+// NewRange returns the range [minNs, maxNs]. It returns an error if either
+// namespace is invalid or if minNs is greater than maxNs. A range of a single
+// namespace is created by passing it as both minNs and maxNs.
+func NewRange(minNs, maxNs Namespace) (Range, error) {
+	if err := minNs.Validate(); err != nil {
+		return Range{}, fmt.Errorf("invalid min namespace: %w", err)
+	}
+	if err := maxNs.Validate(); err != nil {
+		return Range{}, fmt.Errorf("invalid max namespace: %w", err)
+	}
+	if minNs.IsGreaterThan(maxNs) {
+		return Range{}, fmt.Errorf("min namespace %x is greater than max namespace %x", minNs.Bytes(), maxNs.Bytes())
+	}
+	return Range{Min: minNs, Max: maxNs}, nil
+}
+
+// IsEmpty returns true if the range contains no namespace, i.e. if it is the
+// zero value or Min is greater than Max.
+func (r Range) IsEmpty() bool {
+	return r.Min.ID == nil || r.Max.ID == nil || r.Min.IsGreaterThan(r.Max)
+}
+
+// Contains returns true if ns is within the range, including its endpoints.
+func (r Range) Contains(ns Namespace) bool {
+	return !r.IsEmpty() && !ns.IsBelowMin(r.Min) && !ns.IsAboveMax(r.Max)
+}
+
+// Overlaps returns true if the ranges have at least one namespace in common.
+// Ranges that only touch at an endpoint overlap.
+func (r Range) Overlaps(other Range) bool {
+	_, ok := r.Intersect(other)
+	return ok
+}
+
+// Intersect returns the namespaces that are in both ranges. It returns false
+// and the empty range if the ranges don't overlap.
+func (r Range) Intersect(other Range) (Range, bool) {
+	if r.IsEmpty() || other.IsEmpty() {
+		return Range{}, false
+	}
+	intersection := Range{Min: Max(r.Min, other.Min), Max: Min(r.Max, other.Max)}
+	if intersection.IsEmpty() {
+		return Range{}, false
+	}
+	return intersection, true
+}
@@ -0,0 +1,94 @@
+package namespace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRange(t *testing.T) {
+	ns1 := MustNewV0(bytes.Repeat([]byte{1}, NamespaceVersionZeroIDSize))
+	ns2 := MustNewV0(bytes.Repeat([]byte{2}, NamespaceVersionZeroIDSize))
+
+	r, err := NewRange(ns1, ns2)
+	require.NoError(t, err)
+	assert.Equal(t, Range{Min: ns1, Max: ns2}, r)
+	assert.False(t, r.IsEmpty())
+
+	r, err = NewRange(ns1, ns1)
+	require.NoError(t, err)
+	assert.False(t, r.IsEmpty())
+
+	_, err = NewRange(ns2, ns1)
+	assert.Error(t, err)
+	_, err = NewRange(Namespace{Version: 1, ID: ns1.ID}, ns2)
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+	_, err = NewRange(ns1, Namespace{ID: ns2.ID[1:]})
+	assert.ErrorIs(t, err, ErrInvalidIDLength)
+
+	assert.True(t, Range{}.IsEmpty())
+	assert.True(t, Range{Min: ns2, Max: ns1}.IsEmpty())
+}
+
+func TestRangeContains(t *testing.T) {
+	ns1 := MustNewV0(bytes.Repeat([]byte{1}, NamespaceVersionZeroIDSize))
+	ns2 := MustNewV0(bytes.Repeat([]byte{2}, NamespaceVersionZeroIDSize))
+	ns3 := MustNewV0(bytes.Repeat([]byte{3}, NamespaceVersionZeroIDSize))
+	minNs := MustNew(NamespaceVersionZero, make([]byte, NamespaceIDSize))
+
+	single := Range{Min: ns2, Max: ns2}
+	assert.True(t, single.Contains(ns2))
+	assert.False(t, single.Contains(ns1))
+	assert.False(t, single.Contains(ns3))
+
+	r := Range{Min: ns1, Max: ns3}
+	assert.True(t, r.Contains(ns1))
+	assert.True(t, r.Contains(ns2))
+	assert.True(t, r.Contains(ns3))
+	assert.False(t, r.Contains(TxNamespace))
+	assert.False(t, r.Contains(TailPaddingNamespace))
+
+	full := Range{Min: minNs, Max: ParitySharesNamespace}
+	for _, ns := range []Namespace{minNs, TxNamespace, ns2, MinSecondaryReservedNamespace, TailPaddingNamespace, ParitySharesNamespace} {
+		assert.True(t, full.Contains(ns), ns.String())
+	}
+
+	assert.False(t, Range{}.Contains(ns1))
+}
+
+func TestRangeIntersect(t *testing.T) {
+	ns1 := MustNewV0(bytes.Repeat([]byte{1}, NamespaceVersionZeroIDSize))
+	ns2 := MustNewV0(bytes.Repeat([]byte{2}, NamespaceVersionZeroIDSize))
+	ns3 := MustNewV0(bytes.Repeat([]byte{3}, NamespaceVersionZeroIDSize))
+	ns4 := MustNewV0(bytes.Repeat([]byte{4}, NamespaceVersionZeroIDSize))
+	minNs := MustNew(NamespaceVersionZero, make([]byte, NamespaceIDSize))
+	full := Range{Min: minNs, Max: ParitySharesNamespace}
+
+	type testCase struct {
+		name   string
+		a, b   Range
+		want   Range
+		wantOk bool
+	}
+	testCases := []testCase{
+		{name: "disjoint", a: Range{Min: ns1, Max: ns2}, b: Range{Min: ns3, Max: ns4}},
+		{name: "touching at an endpoint", a: Range{Min: ns1, Max: ns2}, b: Range{Min: ns2, Max: ns4}, want: Range{Min: ns2, Max: ns2}, wantOk: true},
+		{name: "partial overlap", a: Range{Min: ns1, Max: ns3}, b: Range{Min: ns2, Max: ns4}, want: Range{Min: ns2, Max: ns3}, wantOk: true},
+		{name: "contained", a: Range{Min: ns1, Max: ns4}, b: Range{Min: ns2, Max: ns3}, want: Range{Min: ns2, Max: ns3}, wantOk: true},
+		{name: "single namespace", a: Range{Min: ns2, Max: ns2}, b: Range{Min: ns2, Max: ns2}, want: Range{Min: ns2, Max: ns2}, wantOk: true},
+		{name: "full range", a: full, b: Range{Min: ns1, Max: TailPaddingNamespace}, want: Range{Min: ns1, Max: TailPaddingNamespace}, wantOk: true},
+		{name: "empty", a: Range{}, b: full},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, pair := range [][2]Range{{tc.a, tc.b}, {tc.b, tc.a}} {
+				got, ok := pair[0].Intersect(pair[1])
+				assert.Equal(t, tc.wantOk, ok)
+				assert.Equal(t, tc.want, got)
+				assert.Equal(t, tc.wantOk, pair[0].Overlaps(pair[1]))
+			}
+		})
+	}
+}
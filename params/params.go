@@ -0,0 +1,89 @@
+// Package params defines the consensus parameters of data squares for every
+// app version of the network, so that the square size bound, subtree root
+// threshold and share versions of an app version are taken from one place
+// instead of being combined by hand.
+package params
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/celestiaorg/go-square/shares"
+)
+
+// LatestAppVersion is the most recent app version with known parameters.
+const LatestAppVersion = 3
+
+// ErrUnknownAppVersion is returned by GetParams for an app version without
+// known parameters.
+var ErrUnknownAppVersion = errors.New("unknown app version")
+
+// Params are the parameters that square construction and share commitments
+// depend on.
+type Params struct {
+	// AppVersion is the app version the parameters apply to.
+	AppVersion uint64
+	// SquareSizeUpperBound is the maximum number of rows (or columns) of the
+	// original data square.
+	SquareSizeUpperBound int
+	// SubtreeRootThreshold bounds the number of subtree roots of a blob's
+	// share commitment, see inclusion.SubTreeWidth.
+	SubtreeRootThreshold int
+	// SupportedShareVersions are the share versions blobs can use.
+	SupportedShareVersions []uint8
+}
+
+// registry holds the parameters of every known app version.
+var registry = map[uint64]Params{
+	1: {
+		AppVersion:             1,
+		SquareSizeUpperBound:   128,
+		SubtreeRootThreshold:   64,
+		SupportedShareVersions: []uint8{shares.ShareVersionZero},
+	},
+	2: {
+		AppVersion:             2,
+		SquareSizeUpperBound:   128,
+		SubtreeRootThreshold:   64,
+		SupportedShareVersions: []uint8{shares.ShareVersionZero},
+	},
+	3: {
+		AppVersion:             3,
+		SquareSizeUpperBound:   128,
+		SubtreeRootThreshold:   64,
+		SupportedShareVersions: []uint8{shares.ShareVersionZero, shares.ShareVersionOne},
+	},
+}
+
+// GetParams returns the parameters of appVersion. It returns an error
+// wrapping ErrUnknownAppVersion if appVersion has no known parameters rather
+// than falling back to those of another version.
+func GetParams(appVersion uint64) (Params, error) {
+	p, ok := registry[appVersion]
+	if !ok {
+		return Params{}, fmt.Errorf("%w: %d, known versions are 1 to %d", ErrUnknownAppVersion, appVersion, LatestAppVersion)
+	}
+	p.SupportedShareVersions = slices.Clone(p.SupportedShareVersions)
+	return p, nil
+}
+
+// Validate returns an error if the parameters can't be used to construct a
+// square, e.g. because they are the zero value.
+func (p Params) Validate() error {
+	if p.SquareSizeUpperBound <= 0 || !shares.IsPowerOfTwo(p.SquareSizeUpperBound) {
+		return fmt.Errorf("square size upper bound %d must be a strictly positive power of two", p.SquareSizeUpperBound)
+	}
+	if p.SubtreeRootThreshold <= 0 {
+		return fmt.Errorf("subtree root threshold %d must be strictly positive", p.SubtreeRootThreshold)
+	}
+	if len(p.SupportedShareVersions) == 0 {
+		return errors.New("no supported share versions")
+	}
+	return nil
+}
+
+// SupportsShareVersion returns true if blobs can use shareVersion.
+func (p Params) SupportsShareVersion(shareVersion uint8) bool {
+	return slices.Contains(p.SupportedShareVersions, shareVersion)
+}
@@ -0,0 +1,72 @@
+package params_test
+
+import (
+	"testing"
+
+	"github.com/celestiaorg/go-square/params"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParams(t *testing.T) {
+	testCases := []params.Params{
+		{AppVersion: 1, SquareSizeUpperBound: 128, SubtreeRootThreshold: 64, SupportedShareVersions: []uint8{0}},
+		{AppVersion: 2, SquareSizeUpperBound: 128, SubtreeRootThreshold: 64, SupportedShareVersions: []uint8{0}},
+		{AppVersion: 3, SquareSizeUpperBound: 128, SubtreeRootThreshold: 64, SupportedShareVersions: []uint8{0, 1}},
+	}
+	for _, want := range testCases {
+		got, err := params.GetParams(want.AppVersion)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.NoError(t, got.Validate())
+	}
+
+	latest, err := params.GetParams(params.LatestAppVersion)
+	require.NoError(t, err)
+	assert.Equal(t, shares.SupportedShareVersions, latest.SupportedShareVersions)
+
+	for _, appVersion := range []uint64{0, params.LatestAppVersion + 1, 100} {
+		_, err := params.GetParams(appVersion)
+		assert.ErrorIs(t, err, params.ErrUnknownAppVersion, "app version %d", appVersion)
+	}
+
+	t.Run("returned params can't change the registry", func(t *testing.T) {
+		p, err := params.GetParams(3)
+		require.NoError(t, err)
+		p.SupportedShareVersions[0] = 7
+		p, err = params.GetParams(3)
+		require.NoError(t, err)
+		assert.Equal(t, []uint8{0, 1}, p.SupportedShareVersions)
+	})
+}
+
+func TestParamsValidate(t *testing.T) {
+	valid := params.Params{SquareSizeUpperBound: 64, SubtreeRootThreshold: 64, SupportedShareVersions: []uint8{0}}
+	require.NoError(t, valid.Validate())
+	testCases := map[string]func(p *params.Params){
+		"zero square size":           func(p *params.Params) { p.SquareSizeUpperBound = 0 },
+		"square size not power of 2": func(p *params.Params) { p.SquareSizeUpperBound = 100 },
+		"zero threshold":             func(p *params.Params) { p.SubtreeRootThreshold = 0 },
+		"no share versions":          func(p *params.Params) { p.SupportedShareVersions = nil },
+	}
+	for name, mutate := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p := valid
+			mutate(&p)
+			assert.Error(t, p.Validate())
+		})
+	}
+	assert.Error(t, params.Params{}.Validate())
+}
+
+func TestSupportsShareVersion(t *testing.T) {
+	v1, err := params.GetParams(1)
+	require.NoError(t, err)
+	assert.True(t, v1.SupportsShareVersion(shares.ShareVersionZero))
+	assert.False(t, v1.SupportsShareVersion(shares.ShareVersionOne))
+	v3, err := params.GetParams(3)
+	require.NoError(t, err)
+	assert.True(t, v3.SupportsShareVersion(shares.ShareVersionOne))
+	assert.False(t, v3.SupportsShareVersion(2))
+}
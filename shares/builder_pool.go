@@ -0,0 +1,44 @@
+package shares
+
+import (
+	"sync"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// BuilderPool is a pool of share builders that can be reused to reduce
+// allocations when building many shares. The zero value is ready to use.
+type BuilderPool struct {
+	pool sync.Pool
+}
+
+// Get returns a builder for a share with the provided namespace, share version
+// and sequence start indicator. The builder is identical to one returned by
+// NewBuilder.
+func (p *BuilderPool) Get(ns namespace.Namespace, shareVersion uint8, isFirstShare bool) (*Builder, error) {
+	b, ok := p.pool.Get().(*Builder)
+	if !ok {
+		return NewBuilder(ns, shareVersion, isFirstShare)
+	}
+	if err := b.Reset(ns, shareVersion, isFirstShare); err != nil {
+		p.Put(b)
+		return nil, err
+	}
+	return b, nil
+}
+
+// Put clears the builder and returns it to the pool.
+func (p *BuilderPool) Put(b *Builder) {
+	if b == nil {
+		return
+	}
+	clear(b.rawShareData[:cap(b.rawShareData)])
+	b.rawShareData = b.rawShareData[:0]
+	b.namespace = namespace.Namespace{}
+	b.shareVersion = 0
+	b.isFirstShare = false
+	b.isCompactShare = false
+	b.forceCompact = false
+	b.isPadded = false
+	p.pool.Put(b)
+}
@@ -0,0 +1,87 @@
+package shares
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilderPool(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	var pool BuilderPool
+
+	type testCase struct {
+		ns           namespace.Namespace
+		isFirstShare bool
+	}
+	testCases := []testCase{
+		{ns: ns1, isFirstShare: true},
+		{ns: namespace.TxNamespace, isFirstShare: false},
+		{ns: namespace.TxNamespace, isFirstShare: true},
+		{ns: ns1, isFirstShare: false},
+	}
+	for _, tc := range testCases {
+		b, err := pool.Get(tc.ns, ShareVersionZero, tc.isFirstShare)
+		require.NoError(t, err)
+		want := mustNewBuilder(t, tc.ns, ShareVersionZero, tc.isFirstShare)
+		assert.Equal(t, want, b)
+
+		// fill the builder so that a reused builder must not leak the data
+		b.AddData(bytes.Repeat([]byte{0xff}, ShareSize))
+		pool.Put(b)
+	}
+
+	b, err := pool.Get(ns1, ShareVersionZero, true)
+	require.NoError(t, err)
+	b.ZeroPadIfNecessary()
+	share, err := b.Build()
+	require.NoError(t, err)
+	isPadding, err := share.IsPadding()
+	require.NoError(t, err)
+	assert.True(t, isPadding)
+
+	_, err = pool.Get(ns1, MaxShareVersion+1, true)
+	assert.Error(t, err)
+
+	// a reused builder rejects invalid namespaces like a new one
+	invalid := namespace.Namespace{Version: 7, ID: ns1.ID}
+	pool.Put(b)
+	_, err = pool.Get(invalid, ShareVersionZero, true)
+	assert.ErrorIs(t, err, ErrInvalidShareNamespace)
+
+	b = mustNewBuilder(t, ns1, ShareVersionZero, true)
+	want := b.Clone()
+	assert.ErrorIs(t, b.Reset(invalid, ShareVersionZero, false), ErrInvalidShareNamespace)
+	assert.Equal(t, want, b)
+}
+
+func BenchmarkBuilderPool(b *testing.B) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	data := bytes.Repeat([]byte{0xf}, FirstSparseShareContentSize)
+
+	b.Run("NewBuilder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			builder, err := NewBuilder(ns1, ShareVersionZero, true)
+			if err != nil {
+				b.Fatal(err)
+			}
+			builder.AddData(data)
+		}
+	})
+	b.Run("BuilderPool", func(b *testing.B) {
+		var pool BuilderPool
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			builder, err := pool.Get(ns1, ShareVersionZero, true)
+			if err != nil {
+				b.Fatal(err)
+			}
+			builder.AddData(data)
+			pool.Put(builder)
+		}
+	})
+}
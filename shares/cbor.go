@@ -0,0 +1,34 @@
+package shares
+
+import (
+	"github.com/celestiaorg/go-square/internal/cbor"
+)
+
+// MarshalCBOR encodes the share as a CBOR byte string containing the raw share
+// bytes. It implements the cbor.Marshaler interface.
+func (s *Share) MarshalCBOR() ([]byte, error) {
+	return cbor.AppendBytes(make([]byte, 0, ShareSize+3), s.data[:]), nil
+}
+
+// UnmarshalCBOR decodes a share encoded by MarshalCBOR. It implements the
+// cbor.Unmarshaler interface. It returns an error if the share has an invalid
+// namespace or an unsupported share version.
+func (s *Share) UnmarshalCBOR(data []byte) error {
+	d := cbor.NewDecoder(data)
+	raw, err := d.ReadBytes()
+	if err != nil {
+		return err
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	share, err := NewShare(raw)
+	if err != nil {
+		return err
+	}
+	if err := share.validateFull(); err != nil {
+		return err
+	}
+	*s = *share
+	return nil
+}
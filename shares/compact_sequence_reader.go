@@ -0,0 +1,270 @@
+package shares
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// continuationCompactShareHeaderLen is the number of bytes that precede the
+// raw data of a compact share that doesn't start a sequence.
+const continuationCompactShareHeaderLen = ShareSize - ContinuationCompactShareContentSize
+
+// CompactSequenceReader provides random access to the units (transactions,
+// PFB transactions, intermediate state roots) of compact shares. Instead of
+// extracting the raw data of every share it hops from one unit length
+// delimiter to the next and skips the shares in between, so the cost of
+// seeking depends on the number of units skipped rather than on their size.
+//
+// The reserved bytes are used to locate the first unit in the shares, which
+// need not start a sequence, and are checked for every share in which a unit
+// starts. Shares that are skipped over are not validated.
+type CompactSequenceReader struct {
+	shares []Share
+	// start is the position of the first unit in shares.
+	start compactCursor
+	// unit is the index of the unit that starts at cursor.
+	unit   int
+	cursor compactCursor
+	// prevShare is the index of the share in which the unit before unit
+	// starts or -1 if there is none.
+	prevShare int
+}
+
+// compactCursor is the position of a byte in compact shares.
+type compactCursor struct {
+	share  int
+	offset int
+}
+
+// NewCompactSequenceReader returns a reader for the units contained in the
+// provided compact shares. Units are counted from the first unit that starts in
+// shares as indicated by the reserved bytes.
+func NewCompactSequenceReader(shares []Share) (*CompactSequenceReader, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+	r := &CompactSequenceReader{
+		shares:    shares,
+		prevShare: -1,
+	}
+
+	// skip the shares that don't contain the start of a unit
+	r.start = compactCursor{share: len(shares)}
+	for i := range shares {
+		if err := r.checkShare(i); err != nil {
+			return nil, err
+		}
+		reserved, err := r.reservedBytes(i)
+		if err != nil {
+			return nil, err
+		}
+		if reserved != 0 {
+			r.start = compactCursor{share: i, offset: reserved}
+			break
+		}
+	}
+	r.cursor = r.start
+	return r, nil
+}
+
+// SeekToUnit returns the unit at index n without its unit length delimiter.
+// Seeking forward continues from the last unit returned; seeking backward
+// starts over from the first unit. It returns ErrUnitNotFound if the shares
+// contain n or fewer complete units.
+func (r *CompactSequenceReader) SeekToUnit(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("unit index %d must not be negative", n)
+	}
+	if n < r.unit {
+		r.unit, r.cursor, r.prevShare = 0, r.start, -1
+	}
+	for {
+		cursor, err := r.normalize(r.cursor)
+		if err != nil {
+			return nil, err
+		}
+		r.cursor = cursor
+		unitLen, dataStart, err := r.readDelimiter(r.cursor)
+		if err != nil {
+			return nil, err
+		}
+		// the rest of the shares is padding or only contains part of the next
+		// unit
+		if unitLen == 0 || unitLen > uint64(r.remaining(dataStart)) {
+			return nil, fmt.Errorf("%w: index %d, shares contain %d units", ErrUnitNotFound, n, r.unit)
+		}
+		if r.cursor.share != r.prevShare {
+			if err := r.checkUnitStart(r.cursor); err != nil {
+				return nil, err
+			}
+		}
+		if r.unit == n {
+			return r.read(dataStart, int(unitLen))
+		}
+		r.prevShare = r.cursor.share
+		r.cursor = advance(dataStart, int(unitLen))
+		r.unit++
+	}
+}
+
+// checkShare returns an error if the share at index can't be part of the
+// compact shares being read.
+func (r *CompactSequenceReader) checkShare(index int) error {
+	share := &r.shares[index]
+	if index == 0 {
+		if err := share.validateFull(); err != nil {
+			return newParseError(index, share, err)
+		}
+		isCompact, err := share.IsCompactShare()
+		if err != nil {
+			return newParseError(index, share, err)
+		}
+		if !isCompact {
+			return newParseError(index, share, ErrNotCompactShare)
+		}
+		return nil
+	}
+	// comparing the raw namespace avoids copying it for every share
+	if !bytes.Equal(share.data[:namespace.NamespaceSize], r.shares[0].data[:namespace.NamespaceSize]) {
+		return newParseError(index, share, ErrNamespaceMismatch)
+	}
+	if err := share.DoesSupportVersions(SupportedShareVersions); err != nil {
+		return newParseError(index, share, err)
+	}
+	isStart, err := share.IsSequenceStart()
+	if err != nil {
+		return newParseError(index, share, err)
+	}
+	if isStart {
+		return newParseError(index, share, ErrUnexpectedSequenceStart)
+	}
+	return nil
+}
+
+// reservedBytes returns the index of the first unit that starts in the share
+// at index or 0 if no unit starts in it. It returns an error if the reserved
+// bytes point into the header of the share or past ShareSize.
+func (r *CompactSequenceReader) reservedBytes(index int) (int, error) {
+	share := &r.shares[index]
+	headerLen := continuationCompactShareHeaderLen
+	if index == 0 {
+		isStart, err := share.IsSequenceStart()
+		if err != nil {
+			return 0, newParseError(index, share, err)
+		}
+		if isStart {
+			headerLen += SequenceLenBytes
+		}
+	}
+	start := headerLen - CompactShareReservedBytes
+	reserved := binary.BigEndian.Uint32(share.data[start:headerLen])
+	if reserved == 0 {
+		return 0, nil
+	}
+	if reserved < uint32(headerLen) || reserved >= ShareSize {
+		return 0, newParseError(index, share, fmt.Errorf("%w: %d is not in the range [%d, %d)", ErrInvalidReservedBytes, reserved, headerLen, ShareSize))
+	}
+	return int(reserved), nil
+}
+
+// checkUnitStart returns an error if the reserved bytes of the share at c
+// don't point to c. It must only be called for the first unit that starts in a
+// share.
+func (r *CompactSequenceReader) checkUnitStart(c compactCursor) error {
+	reserved, err := r.reservedBytes(c.share)
+	if err != nil {
+		return err
+	}
+	if reserved != c.offset {
+		return newParseError(c.share, &r.shares[c.share], fmt.Errorf("%w: %d but the first unit starts at %d", ErrInvalidReservedBytes, reserved, c.offset))
+	}
+	return nil
+}
+
+// readDelimiter reads the unit length delimiter at c. It returns the unit
+// length and the position of the first byte of the unit. A delimiter that is
+// cut off by the end of the shares is read as if it were zero padded.
+func (r *CompactSequenceReader) readDelimiter(c compactCursor) (unitLen uint64, dataStart compactCursor, err error) {
+	start := c
+	// bytes that are not read remain zero
+	var delimiter [binary.MaxVarintLen64]byte
+	for i := 0; i < len(delimiter); i++ {
+		c, err = r.normalize(c)
+		if err != nil {
+			return 0, c, err
+		}
+		if c.share >= len(r.shares) {
+			break
+		}
+		delimiter[i] = r.shares[c.share].data[c.offset]
+		c.offset++
+		if delimiter[i] < 0x80 {
+			break
+		}
+	}
+	unitLen, n := binary.Uvarint(delimiter[:])
+	if n <= 0 {
+		return 0, c, newParseError(start.share, &r.shares[start.share], fmt.Errorf("%w at byte %d", ErrDelimiterOverflow, start.offset))
+	}
+	return unitLen, c, nil
+}
+
+// read returns the n bytes starting at c.
+func (r *CompactSequenceReader) read(c compactCursor, n int) (data []byte, err error) {
+	data = make([]byte, 0, n)
+	for len(data) < n {
+		c, err = r.normalize(c)
+		if err != nil {
+			return nil, err
+		}
+		end := c.offset + n - len(data)
+		if end > ShareSize {
+			end = ShareSize
+		}
+		data = append(data, r.shares[c.share].data[c.offset:end]...)
+		c.offset = end
+	}
+	return data, nil
+}
+
+// remaining returns the number of bytes of raw data from c to the end of the
+// shares.
+func (r *CompactSequenceReader) remaining(c compactCursor) int {
+	if c.share >= len(r.shares) {
+		return 0
+	}
+	return ShareSize - c.offset + (len(r.shares)-c.share-1)*ContinuationCompactShareContentSize
+}
+
+// normalize moves c to the start of the raw data of the next share if it
+// points past the end of a share and checks the share it moved to.
+func (r *CompactSequenceReader) normalize(c compactCursor) (compactCursor, error) {
+	if c.offset < ShareSize {
+		return c, nil
+	}
+	c = compactCursor{share: c.share + 1, offset: continuationCompactShareHeaderLen}
+	if c.share < len(r.shares) {
+		if err := r.checkShare(c.share); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// advance returns the position n bytes of raw data after c. The returned
+// position may point past the end of a share.
+func advance(c compactCursor, n int) compactCursor {
+	available := ShareSize - c.offset
+	if n <= available {
+		c.offset += n
+		return c
+	}
+	n -= available
+	c.share += 1 + n/ContinuationCompactShareContentSize
+	c.offset = continuationCompactShareHeaderLen + n%ContinuationCompactShareContentSize
+	return c
+}
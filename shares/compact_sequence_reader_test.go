@@ -0,0 +1,170 @@
+package shares
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactSequenceReaderSeekToUnit(t *testing.T) {
+	type testCase struct {
+		name string
+		txs  [][]byte
+	}
+	testCases := []testCase{
+		{
+			name: "randomly sized txs",
+			txs:  GenerateRandomlySizedTxs(200, 2000),
+		},
+		{
+			name: "delimiter straddles a share boundary",
+			txs: [][]byte{
+				bytes.Repeat([]byte{1}, FirstCompactShareContentSize-3),
+				bytes.Repeat([]byte{2}, 300),
+				bytes.Repeat([]byte{3}, 10),
+			},
+		},
+		{
+			name: "tx ends at the end of a share",
+			txs: [][]byte{
+				bytes.Repeat([]byte{1}, FirstCompactShareContentSize-2),
+				bytes.Repeat([]byte{2}, 10),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			txShares, _, _, err := SplitTxs(tc.txs)
+			require.NoError(t, err)
+			r, err := NewCompactSequenceReader(txShares)
+			require.NoError(t, err)
+
+			for i, tx := range tc.txs {
+				got, err := r.SeekToUnit(i)
+				require.NoError(t, err)
+				assert.Equal(t, tx, got)
+			}
+			for i := len(tc.txs) - 1; i >= 0; i-- {
+				got, err := r.SeekToUnit(i)
+				require.NoError(t, err)
+				assert.Equal(t, tc.txs[i], got)
+			}
+
+			_, err = r.SeekToUnit(len(tc.txs))
+			assert.ErrorIs(t, err, ErrUnitNotFound)
+			_, err = r.SeekToUnit(-1)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCompactSequenceReaderOutOfContextShares(t *testing.T) {
+	txs := GenerateRandomlySizedTxs(100, 1500)
+	txShares, _, _, err := SplitTxs(txs)
+	require.NoError(t, err)
+
+	for start := range txShares {
+		// ParseTxs only handles out of context shares if a unit starts in the
+		// first share
+		rawData, err := txShares[start].RawDataUsingReserved()
+		require.NoError(t, err)
+		if len(rawData) == 0 {
+			continue
+		}
+		want, err := ParseTxs(txShares[start:])
+		require.NoError(t, err)
+		if len(want) == 0 {
+			continue
+		}
+
+		r, err := NewCompactSequenceReader(txShares[start:])
+		require.NoError(t, err)
+		got, err := r.SeekToUnit(len(want) - 1)
+		require.NoError(t, err)
+		assert.Equal(t, want[len(want)-1], got)
+	}
+}
+
+func TestCompactSequenceReaderInvalidReservedBytes(t *testing.T) {
+	txs := [][]byte{
+		bytes.Repeat([]byte{1}, 600),
+		bytes.Repeat([]byte{2}, 100),
+		bytes.Repeat([]byte{3}, 100),
+	}
+	txShares, _, _, err := SplitTxs(txs)
+	require.NoError(t, err)
+	require.Len(t, txShares, 2)
+
+	// withReserved returns a copy of txShares where the reserved bytes of the
+	// second share have been set to reserved.
+	withReserved := func(reserved uint32) []Share {
+		out := make([]Share, len(txShares))
+		copy(out, txShares)
+		data := out[1].ToBytes()
+		binary.BigEndian.PutUint32(data[namespace.NamespaceSize+ShareInfoBytes:], reserved)
+		out[1] = shareFromBytes(data)
+		return out
+	}
+
+	type testCase struct {
+		name     string
+		reserved uint32
+	}
+	testCases := []testCase{
+		{name: "points into the header", reserved: 5},
+		{name: "points past share size", reserved: ShareSize},
+		{name: "points into a unit", reserved: continuationCompactShareHeaderLen + 200},
+		{name: "no unit starts in the share", reserved: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewCompactSequenceReader(withReserved(tc.reserved))
+			require.NoError(t, err)
+
+			_, err = r.SeekToUnit(0)
+			require.NoError(t, err)
+			_, err = r.SeekToUnit(2)
+			assert.ErrorIs(t, err, ErrInvalidReservedBytes)
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, 1, parseErr.Index)
+		})
+	}
+}
+
+func BenchmarkCompactSequenceReader(b *testing.B) {
+	for _, count := range []int{100, 1000, 10000} {
+		txShares, _, _, err := SplitTxs(GenerateRandomTxs(count, 500))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("ParseTxs %d txs", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				txs, err := ParseTxs(txShares)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = txs[count-1]
+			}
+		})
+		b.Run(fmt.Sprintf("SeekToUnit %d txs", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r, err := NewCompactSequenceReader(txShares)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := r.SeekToUnit(count - 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
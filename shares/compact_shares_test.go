@@ -1,9 +1,12 @@
 package shares
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -230,3 +233,130 @@ func Test_parseCompactSharesErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTxsWithRanges(t *testing.T) {
+	// the first tx and its two byte delimiter leave one byte in the first
+	// share so the delimiter of the second tx straddles the share boundary.
+	txs := [][]byte{
+		bytes.Repeat([]byte{1}, FirstCompactShareContentSize-3),
+		bytes.Repeat([]byte{2}, 300),
+		bytes.Repeat([]byte{3}, 10),
+		bytes.Repeat([]byte{4}, 1000),
+	}
+	txShares, _, shareRanges, err := SplitTxs(txs)
+	require.NoError(t, err)
+
+	positions, err := ParseTxsWithRanges(txShares)
+	require.NoError(t, err)
+	require.Len(t, positions, len(txs))
+
+	firstShareHeaderLen := ShareSize - FirstCompactShareContentSize
+	shareHeaderLen := ShareSize - ContinuationCompactShareContentSize
+	want := []TxPosition{
+		{Tx: txs[0], ShareRange: NewRange(0, 1), StartByte: firstShareHeaderLen},
+		{Tx: txs[1], ShareRange: NewRange(0, 2), StartByte: ShareSize - 1},
+		{Tx: txs[2], ShareRange: NewRange(1, 2), StartByte: shareHeaderLen + 1 + len(txs[1])},
+		{Tx: txs[3], ShareRange: NewRange(1, 4), StartByte: shareHeaderLen + 1 + len(txs[1]) + 1 + len(txs[2])},
+	}
+	assert.Equal(t, want, positions)
+
+	for _, position := range positions {
+		assert.Equal(t, shareRanges[sha256.Sum256(position.Tx)], position.ShareRange)
+	}
+
+	// the last share is zero padded after the final tx
+	rawData, err := txShares[len(txShares)-1].RawData()
+	require.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(rawData, make([]byte, 10)))
+
+	txsFromShares, err := ParseTxs(txShares)
+	require.NoError(t, err)
+	assert.Equal(t, txs, txsFromShares)
+}
+
+func TestParseTxsMalformedDelimiter(t *testing.T) {
+	txs := [][]byte{bytes.Repeat([]byte{1}, 100), bytes.Repeat([]byte{2}, ShareSize)}
+	txShares, _, _, err := SplitTxs(txs)
+	require.NoError(t, err)
+	require.Len(t, txShares, 2)
+
+	firstUnit := ShareSize - FirstCompactShareContentSize
+	secondUnit := firstUnit + DelimLen(100) + 100
+	withDelimiter := func(offset int, delimiter []byte) []Share {
+		shares := append([]Share(nil), txShares...)
+		copy(shares[0].data[offset:], delimiter)
+		return shares
+	}
+	uvarint := func(v uint64) []byte {
+		return binary.AppendUvarint(nil, v)
+	}
+
+	testCases := []struct {
+		name      string
+		shares    []Share
+		wantErr   error
+		wantIndex int
+		wantByte  int
+	}{
+		{
+			name:     "delimiter overflows",
+			shares:   withDelimiter(firstUnit, bytes.Repeat([]byte{0xFF}, binary.MaxVarintLen64)),
+			wantErr:  ErrDelimiterOverflow,
+			wantByte: firstUnit,
+		},
+		{
+			name:     "unit longer than the sequence",
+			shares:   withDelimiter(secondUnit, uvarint(math.MaxUint64)),
+			wantErr:  ErrTruncatedUnit,
+			wantByte: secondUnit,
+		},
+		{
+			name:     "unit one byte longer than the sequence",
+			shares:   withDelimiter(secondUnit, uvarint(ShareSize+1)),
+			wantErr:  ErrTruncatedUnit,
+			wantByte: secondUnit,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTxs(tc.shares)
+			assert.ErrorIs(t, err, tc.wantErr)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.wantIndex, parseErr.Index)
+			assert.Contains(t, err.Error(), fmt.Sprintf("at byte %d", tc.wantByte))
+		})
+	}
+
+	t.Run("incomplete unit in a subset of the shares", func(t *testing.T) {
+		got, err := ParseTxs(txShares[:1])
+		require.NoError(t, err)
+		assert.Equal(t, txs[:1], got)
+	})
+}
+
+func FuzzParseTxs(f *testing.F) {
+	txShares, _, _, err := SplitTxs(GenerateRandomTxs(5, 300))
+	require.NoError(f, err)
+	f.Add(bytes.Join(ToBytes(txShares), nil))
+	f.Add(bytes.Repeat([]byte{0xFF}, 2*ShareSize))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var shares []Share
+		for len(data) > 0 {
+			var share Share
+			copy(share.data[:], namespace.TxNamespace.Bytes())
+			n := copy(share.data[namespace.NamespaceSize:], data)
+			data = data[n:]
+			shares = append(shares, share)
+		}
+		txs, err := ParseTxs(shares)
+		if err != nil {
+			return
+		}
+		for _, tx := range txs {
+			// units are sub-slices of the shares so they can't be larger
+			require.LessOrEqual(t, len(tx), len(shares)*ShareSize)
+		}
+	})
+}
@@ -1,7 +1,11 @@
 package shares
 
 import (
+	"fmt"
+
+	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -19,6 +23,14 @@ const (
 	// ShareVersionZero is the first share version format.
 	ShareVersionZero = uint8(0)
 
+	// ShareVersionOne is the share version format that includes the signer of
+	// a blob in the first share of its sequence.
+	ShareVersionOne = uint8(1)
+
+	// SignerSize is the number of bytes of the signer address that follows
+	// the sequence length in the first share of a share version 1 sequence.
+	SignerSize = blob.SignerSize
+
 	// DefaultShareVersion is the defacto share version. Use this if you are
 	// unsure of which version to use.
 	DefaultShareVersion = ShareVersionZero
@@ -39,6 +51,10 @@ const (
 	// first sparse share of a sequence.
 	FirstSparseShareContentSize = ShareSize - namespace.NamespaceSize - ShareInfoBytes - SequenceLenBytes
 
+	// FirstSparseShareWithSignerContentSize is the number of bytes usable for
+	// data in the first sparse share of a share version 1 sequence.
+	FirstSparseShareWithSignerContentSize = FirstSparseShareContentSize - SignerSize
+
 	// ContinuationSparseShareContentSize is the number of bytes usable for data
 	// in a continuation sparse share of a sequence.
 	ContinuationSparseShareContentSize = ShareSize - namespace.NamespaceSize - ShareInfoBytes
@@ -55,4 +71,19 @@ const (
 )
 
 // SupportedShareVersions is a list of supported share versions.
-var SupportedShareVersions = []uint8{ShareVersionZero}
+var SupportedShareVersions = []uint8{ShareVersionZero, ShareVersionOne}
+
+// IsSupportedShareVersion returns true if version is one of
+// SupportedShareVersions.
+func IsSupportedShareVersion(version uint8) bool {
+	return slices.Contains(SupportedShareVersions, version)
+}
+
+// checkShareVersion returns an error wrapping ErrUnsupportedShareVersion if
+// version is not one of supportedShareVersions.
+func checkShareVersion(version uint8, supportedShareVersions []uint8) error {
+	if !slices.Contains(supportedShareVersions, version) {
+		return fmt.Errorf("%w %d is not present in the list of supported share versions %v", ErrUnsupportedShareVersion, version, supportedShareVersions)
+	}
+	return nil
+}
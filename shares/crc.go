@@ -0,0 +1,40 @@
+package shares
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// CRCSize is the number of bytes of the CRC32C checksum appended by
+// ToBytesWithCRC.
+const CRCSize = crc32.Size
+
+// ErrCRCMismatch is returned by FromBytesWithCRC when the checksum does not
+// match the share bytes.
+var ErrCRCMismatch = errors.New("share checksum mismatch")
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ToBytesWithCRC returns the share bytes followed by a big endian CRC32C
+// checksum of those bytes. This is a storage envelope that allows detecting
+// corrupted or truncated shares and is not part of the share format.
+func (s *Share) ToBytesWithCRC() []byte {
+	out := make([]byte, 0, ShareSize+CRCSize)
+	out = append(out, s.data[:]...)
+	return binary.BigEndian.AppendUint32(out, crc32.Checksum(s.data[:], castagnoliTable))
+}
+
+// FromBytesWithCRC verifies and strips the checksum appended by
+// ToBytesWithCRC. It returns ErrCRCMismatch if the checksum doesn't match.
+func FromBytesWithCRC(b []byte) (*Share, error) {
+	if len(b) != ShareSize+CRCSize {
+		return nil, fmt.Errorf("share with checksum must be %d bytes, got %d", ShareSize+CRCSize, len(b))
+	}
+	data, checksum := b[:ShareSize], binary.BigEndian.Uint32(b[ShareSize:])
+	if crc32.Checksum(data, castagnoliTable) != checksum {
+		return nil, ErrCRCMismatch
+	}
+	return NewShare(data)
+}
@@ -0,0 +1,36 @@
+package shares
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareCRCRoundTrip(t *testing.T) {
+	share := TailPaddingShare()
+	encoded := share.ToBytesWithCRC()
+	require.Len(t, encoded, ShareSize+CRCSize)
+
+	decoded, err := FromBytesWithCRC(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, share, *decoded)
+
+	t.Run("corrupted share", func(t *testing.T) {
+		corrupted := append([]byte{}, encoded...)
+		corrupted[100] ^= 0x01
+		_, err := FromBytesWithCRC(corrupted)
+		assert.ErrorIs(t, err, ErrCRCMismatch)
+	})
+	t.Run("corrupted checksum", func(t *testing.T) {
+		corrupted := append([]byte{}, encoded...)
+		corrupted[len(corrupted)-1] ^= 0x01
+		_, err := FromBytesWithCRC(corrupted)
+		assert.ErrorIs(t, err, ErrCRCMismatch)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		_, err := FromBytesWithCRC(encoded[:len(encoded)-1])
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCRCMismatch)
+	})
+}
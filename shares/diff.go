@@ -0,0 +1,171 @@
+package shares
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// ShareRegion is a labeled region of the bytes of a share.
+type ShareRegion uint8
+
+// The regions of a share in the order in which they appear in a share. Not
+// every share contains every region.
+const (
+	RegionNamespace ShareRegion = iota
+	RegionInfoByte
+	RegionSequenceLen
+	RegionSigner
+	RegionReservedBytes
+	RegionPayload
+)
+
+func (r ShareRegion) String() string {
+	switch r {
+	case RegionNamespace:
+		return "namespace"
+	case RegionInfoByte:
+		return "info byte"
+	case RegionSequenceLen:
+		return "sequence length"
+	case RegionSigner:
+		return "signer"
+	case RegionReservedBytes:
+		return "reserved bytes"
+	case RegionPayload:
+		return "payload"
+	default:
+		return fmt.Sprintf("ShareRegion(%d)", uint8(r))
+	}
+}
+
+// regionAt returns the region that contains the byte at offset in a share
+// with layout l.
+func (l shareLayout) regionAt(offset int) ShareRegion {
+	in := func(start, size int) bool {
+		return start != -1 && start <= offset && offset < start+size
+	}
+	switch {
+	case offset < namespace.NamespaceSize:
+		return RegionNamespace
+	case offset == namespace.NamespaceSize:
+		return RegionInfoByte
+	case in(l.sequenceLenIdx, SequenceLenBytes):
+		return RegionSequenceLen
+	case in(l.signerIdx, SignerSize):
+		return RegionSigner
+	case in(l.reservedIdx, CompactShareReservedBytes):
+		return RegionReservedBytes
+	default:
+		return RegionPayload
+	}
+}
+
+// RegionBounds returns the end exclusive bounds of region in the share. The
+// bounds are derived from the raw share bytes so they are available for
+// malformed shares too. It returns false if the share doesn't contain the
+// region, e.g. the sequence length of a continuation share.
+func (s *Share) RegionBounds(region ShareRegion) (start, end int, ok bool) {
+	l := s.layout()
+	bounds := func(start, size int) (int, int, bool) {
+		if start == -1 {
+			return 0, 0, false
+		}
+		return start, start + size, true
+	}
+	switch region {
+	case RegionNamespace:
+		return 0, namespace.NamespaceSize, true
+	case RegionInfoByte:
+		return namespace.NamespaceSize, namespace.NamespaceSize + ShareInfoBytes, true
+	case RegionSequenceLen:
+		return bounds(l.sequenceLenIdx, SequenceLenBytes)
+	case RegionSigner:
+		return bounds(l.signerIdx, SignerSize)
+	case RegionReservedBytes:
+		return bounds(l.reservedIdx, CompactShareReservedBytes)
+	case RegionPayload:
+		return l.payloadIdx, ShareSize, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// ShareDiff describes how the shares at the same index of two share slices
+// differ.
+type ShareDiff struct {
+	// Index is the index of the share in both slices.
+	Index int
+	// Regions are the regions of the share that contain differing bytes,
+	// ordered by their position in the share. A byte that belongs to
+	// different regions in the two shares, e.g. because their info bytes
+	// differ, is reported in both regions. It is empty if the share is only
+	// present in one of the slices.
+	Regions []ShareRegion
+	// FirstByte is the offset of the first byte that differs within the share
+	// or -1 if the share is only present in one of the slices.
+	FirstByte int
+	// OnlyInA is true if the share is present in a but not in b.
+	OnlyInA bool
+	// OnlyInB is true if the share is present in b but not in a.
+	OnlyInB bool
+}
+
+// String returns a single line description of the diff.
+func (d ShareDiff) String() string {
+	switch {
+	case d.OnlyInA:
+		return fmt.Sprintf("share %d is only in a", d.Index)
+	case d.OnlyInB:
+		return fmt.Sprintf("share %d is only in b", d.Index)
+	}
+	regions := make([]string, len(d.Regions))
+	for i, region := range d.Regions {
+		regions[i] = region.String()
+	}
+	return fmt.Sprintf("share %d differs in %s from byte %d", d.Index, strings.Join(regions, ", "), d.FirstByte)
+}
+
+// DiffShares compares two sets of shares index by index and returns a diff for
+// every index at which they differ. Indexes that are present in only one of
+// the sets are reported as such. It returns nil if both sets are equal. The
+// regions of each share are derived from its namespace and info byte so
+// DiffShares does not fail on malformed shares.
+func DiffShares(a, b []Share) []ShareDiff {
+	var diffs []ShareDiff
+	for i := 0; i < max(len(a), len(b)); i++ {
+		switch {
+		case i >= len(b):
+			diffs = append(diffs, ShareDiff{Index: i, FirstByte: -1, OnlyInA: true})
+		case i >= len(a):
+			diffs = append(diffs, ShareDiff{Index: i, FirstByte: -1, OnlyInB: true})
+		case a[i].data != b[i].data:
+			diffs = append(diffs, diffShare(i, &a[i], &b[i]))
+		}
+	}
+	return diffs
+}
+
+// diffShare returns the diff of two shares that differ.
+func diffShare(index int, a, b *Share) ShareDiff {
+	layoutA, layoutB := a.layout(), b.layout()
+	diff := ShareDiff{Index: index, FirstByte: -1}
+	var differs [RegionPayload + 1]bool
+	for i := range a.data {
+		if a.data[i] == b.data[i] {
+			continue
+		}
+		if diff.FirstByte == -1 {
+			diff.FirstByte = i
+		}
+		differs[layoutA.regionAt(i)] = true
+		differs[layoutB.regionAt(i)] = true
+	}
+	for region, ok := range differs {
+		if ok {
+			diff.Regions = append(diff.Regions, ShareRegion(region))
+		}
+	}
+	return diff
+}
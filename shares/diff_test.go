@@ -0,0 +1,216 @@
+package shares
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffShares(t *testing.T) {
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xf}, 2*ShareSize), ShareVersionZero))
+	require.NoError(t, err)
+	txShares, _, _, err := SplitTxs([][]byte{bytes.Repeat([]byte{0xf}, 100)})
+	require.NoError(t, err)
+
+	// modify returns a copy of shares where the share at index has been changed
+	// by fn.
+	modify := func(shares []Share, index int, fn func(data []byte) []byte) []Share {
+		out := make([]Share, len(shares))
+		copy(out, shares)
+		out[index] = shareFromBytes(fn(shares[index].ToBytes()))
+		return out
+	}
+
+	type testCase struct {
+		name       string
+		a, b       []Share
+		want       []ShareDiff
+		wantString []string
+	}
+	testCases := []testCase{
+		{
+			name: "namespace",
+			a:    blobShares,
+			b: modify(blobShares, 1, func(data []byte) []byte {
+				copy(data, ns2.Bytes())
+				return data
+			}),
+			want:       []ShareDiff{{Index: 1, Regions: []ShareRegion{RegionNamespace}, FirstByte: 19}},
+			wantString: []string{"share 1 differs in namespace from byte 19"},
+		},
+		{
+			name: "info byte",
+			a:    blobShares,
+			b: modify(blobShares, 2, func(data []byte) []byte {
+				data[namespace.NamespaceSize] = 1
+				return data
+			}),
+			want:       []ShareDiff{{Index: 2, Regions: []ShareRegion{RegionInfoByte}, FirstByte: namespace.NamespaceSize}},
+			wantString: []string{"share 2 differs in info byte from byte 29"},
+		},
+		{
+			name: "sequence length",
+			a:    blobShares,
+			b: modify(blobShares, 0, func(data []byte) []byte {
+				data[namespace.NamespaceSize+ShareInfoBytes+SequenceLenBytes-1]++
+				return data
+			}),
+			want:       []ShareDiff{{Index: 0, Regions: []ShareRegion{RegionSequenceLen}, FirstByte: 33}},
+			wantString: []string{"share 0 differs in sequence length from byte 33"},
+		},
+		{
+			name: "reserved bytes",
+			a:    txShares,
+			b: modify(txShares, 0, func(data []byte) []byte {
+				data[namespace.NamespaceSize+ShareInfoBytes+SequenceLenBytes+CompactShareReservedBytes-1]++
+				return data
+			}),
+			want:       []ShareDiff{{Index: 0, Regions: []ShareRegion{RegionReservedBytes}, FirstByte: 37}},
+			wantString: []string{"share 0 differs in reserved bytes from byte 37"},
+		},
+		{
+			name: "payload",
+			a:    blobShares,
+			b: modify(blobShares, 1, func(data []byte) []byte {
+				data[100] = 0
+				return data
+			}),
+			want:       []ShareDiff{{Index: 1, Regions: []ShareRegion{RegionPayload}, FirstByte: 100}},
+			wantString: []string{"share 1 differs in payload from byte 100"},
+		},
+		{
+			name: "multiple regions and shares",
+			a:    blobShares,
+			b: modify(modify(blobShares, 0, func(data []byte) []byte {
+				data[0] = 1
+				data[ShareSize-1] = 0
+				return data
+			}), 2, func(data []byte) []byte {
+				data[ShareSize-1] = 1
+				return data
+			}),
+			want: []ShareDiff{
+				{Index: 0, Regions: []ShareRegion{RegionNamespace, RegionPayload}, FirstByte: 0},
+				{Index: 2, Regions: []ShareRegion{RegionPayload}, FirstByte: ShareSize - 1},
+			},
+			wantString: []string{"share 0 differs in namespace, payload from byte 0", "share 2 differs in payload from byte 511"},
+		},
+		{
+			name: "sequence start changes the layout",
+			a:    blobShares,
+			b: modify(blobShares, 1, func(data []byte) []byte {
+				data[namespace.NamespaceSize] = 1
+				// the bytes that would hold the sequence length
+				data[namespace.NamespaceSize+ShareInfoBytes] = 0
+				return data
+			}),
+			want:       []ShareDiff{{Index: 1, Regions: []ShareRegion{RegionInfoByte, RegionSequenceLen, RegionPayload}, FirstByte: namespace.NamespaceSize}},
+			wantString: []string{"share 1 differs in info byte, sequence length, payload from byte 29"},
+		},
+		{
+			name:       "shares only in a",
+			a:          blobShares,
+			b:          blobShares[:1],
+			want:       []ShareDiff{{Index: 1, FirstByte: -1, OnlyInA: true}, {Index: 2, FirstByte: -1, OnlyInA: true}},
+			wantString: []string{"share 1 is only in a", "share 2 is only in a"},
+		},
+		{
+			name:       "shares only in b",
+			a:          nil,
+			b:          blobShares[:1],
+			want:       []ShareDiff{{Index: 0, FirstByte: -1, OnlyInB: true}},
+			wantString: []string{"share 0 is only in b"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DiffShares(tc.a, tc.b)
+			assert.Equal(t, tc.want, got)
+			gotString := make([]string, len(got))
+			for i, diff := range got {
+				gotString[i] = diff.String()
+			}
+			assert.Equal(t, tc.wantString, gotString)
+		})
+	}
+
+	assert.Nil(t, DiffShares(blobShares, blobShares))
+	assert.Nil(t, DiffShares(nil, nil))
+}
+
+func TestShareRegionBounds(t *testing.T) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 200))
+	require.NoError(t, err)
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+	b := blob.New(ns1, bytes.Repeat([]byte{2}, 1000), ShareVersionOne)
+	b.Signer = signer
+	blobShares, err := SplitBlobs(b)
+	require.NoError(t, err)
+
+	type bounds struct {
+		start, end int
+		ok         bool
+	}
+	header := namespace.NamespaceSize + ShareInfoBytes
+	testCases := []struct {
+		name  string
+		share Share
+		want  map[ShareRegion]bounds
+	}{
+		{
+			name:  "first compact share",
+			share: txShares[0],
+			want: map[ShareRegion]bounds{
+				RegionNamespace:     {0, namespace.NamespaceSize, true},
+				RegionInfoByte:      {namespace.NamespaceSize, header, true},
+				RegionSequenceLen:   {header, header + SequenceLenBytes, true},
+				RegionSigner:        {0, 0, false},
+				RegionReservedBytes: {header + SequenceLenBytes, header + SequenceLenBytes + CompactShareReservedBytes, true},
+				RegionPayload:       {ShareSize - FirstCompactShareContentSize, ShareSize, true},
+			},
+		},
+		{
+			name:  "continuation compact share",
+			share: txShares[1],
+			want: map[ShareRegion]bounds{
+				RegionSequenceLen:   {0, 0, false},
+				RegionReservedBytes: {header, header + CompactShareReservedBytes, true},
+				RegionPayload:       {ShareSize - ContinuationCompactShareContentSize, ShareSize, true},
+			},
+		},
+		{
+			name:  "first share version 1 sparse share",
+			share: blobShares[0],
+			want: map[ShareRegion]bounds{
+				RegionSigner:        {header + SequenceLenBytes, header + SequenceLenBytes + SignerSize, true},
+				RegionReservedBytes: {0, 0, false},
+				RegionPayload:       {ShareSize - FirstSparseShareWithSignerContentSize, ShareSize, true},
+			},
+		},
+		{
+			name:  "continuation sparse share",
+			share: blobShares[1],
+			want: map[ShareRegion]bounds{
+				RegionSequenceLen: {0, 0, false},
+				RegionSigner:      {0, 0, false},
+				RegionPayload:     {header, ShareSize, true},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for region, want := range tc.want {
+				start, end, ok := tc.share.RegionBounds(region)
+				assert.Equal(t, want, bounds{start, end, ok}, region.String())
+			}
+		})
+	}
+
+	_, _, ok := txShares[0].RegionBounds(ShareRegion(100))
+	assert.False(t, ok)
+}
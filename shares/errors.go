@@ -0,0 +1,90 @@
+package shares
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+var (
+	// ErrInvalidShareSize is returned when share data is not ShareSize bytes.
+	ErrInvalidShareSize = errors.New("invalid share size")
+	// ErrInvalidShareNamespace is returned when the namespace of a share is
+	// malformed.
+	ErrInvalidShareNamespace = errors.New("invalid share namespace")
+	// ErrUnsupportedShareVersion is returned when a share has a share version
+	// that is not supported. It is the same error as
+	// blob.ErrUnsupportedShareVersion so errors.Is works regardless of which
+	// package reported it.
+	ErrUnsupportedShareVersion = blob.ErrUnsupportedShareVersion
+	// ErrInvalidSequenceLen is returned when the sequence length of a sequence
+	// does not match the number of shares in the sequence.
+	ErrInvalidSequenceLen = errors.New("invalid sequence length")
+	// ErrMissingSequenceStart is returned when a continuation share is not
+	// preceded by the start of its sequence.
+	ErrMissingSequenceStart = errors.New("continuation share without a sequence start share")
+	// ErrUnexpectedSequenceStart is returned when a share other than the first
+	// share of a sequence is marked as the start of a sequence.
+	ErrUnexpectedSequenceStart = errors.New("share unexpectedly starts a new sequence")
+	// ErrShareVersionMismatch is returned when a share has a different share
+	// version than the sequence it belongs to.
+	ErrShareVersionMismatch = errors.New("share version does not match the share version of its sequence")
+	// ErrNamespaceMismatch is returned when a continuation share has a
+	// different namespace than the sequence it continues.
+	ErrNamespaceMismatch = errors.New("share namespace does not match the namespace of its sequence")
+	// ErrNamespaceOrder is returned when shares are not sorted by namespace.
+	ErrNamespaceOrder = errors.New("shares are not sorted by namespace")
+	// ErrInvalidReservedBytes is returned when the reserved bytes of a compact
+	// share don't point to the first unit that starts in the share.
+	ErrInvalidReservedBytes = errors.New("invalid reserved bytes")
+	// ErrNonZeroPadding is returned when the padding that follows the data of
+	// a sequence contains a non-zero byte.
+	ErrNonZeroPadding = errors.New("non-zero byte in padding")
+	// ErrUnitNotFound is returned when a compact share sequence does not
+	// contain the requested unit.
+	ErrUnitNotFound = errors.New("unit not found")
+	// ErrDelimiterOverflow is returned when a unit length delimiter of a
+	// compact share doesn't fit in a uint64.
+	ErrDelimiterOverflow = errors.New("unit length delimiter overflows a uint64")
+	// ErrTruncatedUnit is returned when a unit length delimiter of a compact
+	// share declares more bytes than remain in its sequence.
+	ErrTruncatedUnit = errors.New("unit is longer than the rest of its sequence")
+	// ErrInvalidPaddingShare is returned when a share is in a padding
+	// namespace but is not a padding share or when a padding share is in a
+	// namespace that can't be padded.
+	ErrInvalidPaddingShare = errors.New("invalid padding share")
+)
+
+// ParseError is returned when parsing shares fails. It identifies the share at
+// which parsing failed. Use errors.Is on a ParseError to determine the kind of
+// failure, e.g. ErrInvalidSequenceLen.
+type ParseError struct {
+	// Index is the index of the offending share in the shares that were
+	// parsed. For errors that concern a whole sequence it is the index of the
+	// first share of the sequence.
+	Index int
+	// Namespace is the raw namespace of the offending share. It may be
+	// malformed if the error is ErrInvalidShareNamespace.
+	Namespace namespace.Namespace
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("share %d with namespace %x: %v", e.Index, e.Namespace.Bytes(), e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a ParseError for the share at index. The namespace is
+// read from the raw share bytes so it is available even if it is malformed.
+func newParseError(index int, share *Share, err error) *ParseError {
+	return &ParseError{
+		Index:     index,
+		Namespace: share.RawNamespace(),
+		Err:       err,
+	}
+}
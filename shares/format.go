@@ -0,0 +1,143 @@
+package shares
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// hexdumpRowSize is the number of bytes per row in the verbose format of a
+// share.
+const hexdumpRowSize = 32
+
+// shareLayout describes where the fields of a share are located. Fields that
+// the share doesn't contain have an index of -1.
+type shareLayout struct {
+	infoByte       InfoByte
+	compact        bool
+	sequenceLenIdx int
+	signerIdx      int
+	reservedIdx    int
+	payloadIdx     int
+}
+
+// layout returns the layout of the share. It reads the raw share bytes
+// directly so it does not fail on malformed shares.
+func (s *Share) layout() shareLayout {
+	ns := namespace.Namespace{Version: s.data[0], ID: s.data[1:namespace.NamespaceSize]}
+	l := shareLayout{
+		infoByte:       InfoByte(s.data[namespace.NamespaceSize]),
+		compact:        isCompactShare(ns),
+		sequenceLenIdx: -1,
+		signerIdx:      -1,
+		reservedIdx:    -1,
+	}
+	cursor := namespace.NamespaceSize + ShareInfoBytes
+	if l.infoByte.IsSequenceStart() {
+		l.sequenceLenIdx = cursor
+		cursor += SequenceLenBytes
+	}
+	if containsSigner(l.infoByte.Version(), l.infoByte.IsSequenceStart(), l.compact) {
+		l.signerIdx = cursor
+		cursor += SignerSize
+	}
+	if l.compact {
+		l.reservedIdx = cursor
+		cursor += CompactShareReservedBytes
+	}
+	l.payloadIdx = cursor
+	return l
+}
+
+// payloadLen returns the length of the payload without trailing zero padding.
+func (s *Share) payloadLen(l shareLayout) int {
+	end := ShareSize
+	for end > l.payloadIdx && s.data[end-1] == 0 {
+		end--
+	}
+	return end - l.payloadIdx
+}
+
+// String returns a human readable summary of the share's fields. It reads the
+// raw share bytes directly so it does not fail on malformed shares.
+func (s *Share) String() string {
+	l := s.layout()
+	seqLen := "-"
+	if l.sequenceLenIdx != -1 {
+		seqLen = strconv.FormatUint(uint64(binary.BigEndian.Uint32(s.data[l.sequenceLenIdx:])), 10)
+	}
+	reserved := "-"
+	if l.reservedIdx != -1 {
+		reserved = strconv.FormatUint(uint64(binary.BigEndian.Uint32(s.data[l.reservedIdx:])), 10)
+	}
+	return fmt.Sprintf("namespace=%x version=%d first=%t compact=%t seqlen=%s reserved=%s datalen=%d payloadlen=%d",
+		s.data[:namespace.NamespaceSize], l.infoByte.Version(), l.infoByte.IsSequenceStart(), l.compact, seqLen, reserved,
+		ShareSize-l.payloadIdx, s.payloadLen(l))
+}
+
+// Format implements fmt.Formatter. The %v and %s verbs print the summary
+// returned by String. The %+v verb additionally prints every header field on
+// its own line and a hexdump of the payload in rows of 32 bytes, where trailing
+// zero padding is collapsed into a single line. The %x verb prints the raw
+// share bytes in hex.
+func (s *Share) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprint(f, s.verbose())
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(f, s.String())
+	case verb == 'x' || verb == 'X':
+		fmt.Fprintf(f, "%"+string(verb), s.data[:])
+	default:
+		fmt.Fprintf(f, "%%!%c(shares.Share=%s)", verb, s.String())
+	}
+}
+
+// verbose returns the multi-line representation of the share.
+func (s *Share) verbose() string {
+	l := s.layout()
+	var sb strings.Builder
+	sb.WriteString(s.String())
+	sb.WriteString("\n")
+
+	field := func(name string, start, end int, detail string) {
+		fmt.Fprintf(&sb, "  %-14s [%3d:%3d] %x", name, start, end, s.data[start:end])
+		if detail != "" {
+			fmt.Fprintf(&sb, " (%s)", detail)
+		}
+		sb.WriteString("\n")
+	}
+	field("namespace", 0, namespace.NamespaceSize, "")
+	field("info byte", namespace.NamespaceSize, namespace.NamespaceSize+ShareInfoBytes,
+		fmt.Sprintf("version %d, sequence start %t", l.infoByte.Version(), l.infoByte.IsSequenceStart()))
+	if l.sequenceLenIdx != -1 {
+		field("sequence len", l.sequenceLenIdx, l.sequenceLenIdx+SequenceLenBytes,
+			strconv.FormatUint(uint64(binary.BigEndian.Uint32(s.data[l.sequenceLenIdx:])), 10))
+	}
+	if l.signerIdx != -1 {
+		field("signer", l.signerIdx, l.signerIdx+SignerSize, "")
+	}
+	if l.reservedIdx != -1 {
+		field("reserved bytes", l.reservedIdx, l.reservedIdx+CompactShareReservedBytes,
+			strconv.FormatUint(uint64(binary.BigEndian.Uint32(s.data[l.reservedIdx:])), 10))
+	}
+
+	fmt.Fprintf(&sb, "  %-14s [%3d:%3d]\n", "payload", l.payloadIdx, ShareSize)
+	end := l.payloadIdx + s.payloadLen(l)
+	row := l.payloadIdx
+	for row < end {
+		rowEnd := row + hexdumpRowSize
+		if rowEnd > ShareSize {
+			rowEnd = ShareSize
+		}
+		fmt.Fprintf(&sb, "    %04x  % x\n", row, s.data[row:rowEnd])
+		row = rowEnd
+	}
+	if row < ShareSize {
+		fmt.Fprintf(&sb, "    %04x  %d zero bytes\n", row, ShareSize-row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,51 @@
+package shares
+
+import (
+	"math"
+	"math/bits"
+)
+
+// BytesPerBlobInfo is the number of bytes a blob adds to the size of a
+// MsgPayForBlobs transaction for its namespace, size, share version and share
+// commitment. It is charged at the transaction size cost by EstimateGas.
+const BytesPerBlobInfo = 70
+
+// GasToConsume returns the gas consumed by blobs of the provided sizes. The
+// consensus rules charge gasPerBlobByte for every byte of every share a blob
+// occupies, so a blob is charged for the padding of its last share and for the
+// header bytes of its shares. Blobs of size zero occupy no shares and consume
+// no gas. The result saturates at math.MaxUint64 instead of overflowing.
+func GasToConsume(blobSizes []uint32, gasPerBlobByte uint32) uint64 {
+	var totalShares uint64
+	for _, size := range blobSizes {
+		totalShares += uint64(SparseSharesNeeded(size))
+	}
+	return mulSaturating(mulSaturating(totalShares, ShareSize), uint64(gasPerBlobByte))
+}
+
+// EstimateGas returns an estimate of the gas used by a MsgPayForBlobs
+// transaction with blobs of the provided sizes. It is the gas consumed by the
+// blobs, see GasToConsume, plus txSizeCost for BytesPerBlobInfo bytes per blob
+// plus fixedCost, which covers the rest of the transaction, e.g. signature
+// verification. The result saturates at math.MaxUint64 instead of
+// overflowing.
+func EstimateGas(blobSizes []uint32, gasPerBlobByte uint32, txSizeCost, fixedCost uint64) uint64 {
+	blobInfoGas := mulSaturating(mulSaturating(txSizeCost, BytesPerBlobInfo), uint64(len(blobSizes)))
+	return addSaturatingUint64(addSaturatingUint64(GasToConsume(blobSizes, gasPerBlobByte), blobInfoGas), fixedCost)
+}
+
+func mulSaturating(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
+func addSaturatingUint64(a, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+	return sum
+}
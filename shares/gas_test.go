@@ -0,0 +1,45 @@
+package shares
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGas(t *testing.T) {
+	const (
+		gasPerBlobByte = 8
+		txSizeCost     = 10
+		fixedCost      = 75_000
+	)
+	// golden values that other implementations of the gas calculation must
+	// agree with
+	testCases := []struct {
+		name      string
+		blobSizes []uint32
+		wantGas   uint64
+		wantTotal uint64
+	}{
+		{name: "no blobs", blobSizes: nil, wantGas: 0, wantTotal: 75_000},
+		{name: "empty blob", blobSizes: []uint32{0}, wantGas: 0, wantTotal: 75_700},
+		{name: "one byte", blobSizes: []uint32{1}, wantGas: 4096, wantTotal: 79_796},
+		{name: "full first share", blobSizes: []uint32{478}, wantGas: 4096, wantTotal: 79_796},
+		{name: "one byte into the second share", blobSizes: []uint32{479}, wantGas: 8192, wantTotal: 83_892},
+		{name: "two blobs", blobSizes: []uint32{1000, 10_000}, wantGas: 98_304, wantTotal: 174_704},
+		{name: "max blob size", blobSizes: []uint32{math.MaxUint32}, wantGas: 36_498_313_216, wantTotal: 36_498_388_916},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantGas, GasToConsume(tc.blobSizes, gasPerBlobByte))
+			assert.Equal(t, tc.wantTotal, EstimateGas(tc.blobSizes, gasPerBlobByte, txSizeCost, fixedCost))
+		})
+	}
+}
+
+func TestGasSaturates(t *testing.T) {
+	blobSizes := []uint32{math.MaxUint32}
+	assert.Equal(t, uint64(math.MaxUint64), GasToConsume(blobSizes, math.MaxUint32))
+	assert.Equal(t, uint64(math.MaxUint64), EstimateGas(blobSizes, 1, math.MaxUint64, 0))
+	assert.Equal(t, uint64(math.MaxUint64), EstimateGas(blobSizes, 1, 0, math.MaxUint64))
+}
@@ -4,15 +4,28 @@ import (
 	"fmt"
 )
 
+const (
+	// InfoByteVersionMask selects the bits of an info byte that hold the share
+	// version.
+	InfoByteVersionMask = 0b1111_1110
+	// InfoByteSequenceStartMask selects the bit of an info byte that holds the
+	// sequence start indicator.
+	InfoByteSequenceStartMask = 0b0000_0001
+)
+
 // InfoByte is a byte with the following structure: the first 7 bits are
 // reserved for version information in big endian form (initially `0000000`).
 // The last bit is a "sequence start indicator", that is `1` if this is the
-// first share of a sequence and `0` if this is a continuation share.
+// first share of a sequence and `0` if this is a continuation share. No bits
+// are reserved for future use.
 type InfoByte byte
 
+// NewInfoByte returns the info byte for the provided share version and
+// sequence start indicator. It returns an error if version doesn't fit in the
+// 7 version bits, i.e. if it is greater than MaxShareVersion.
 func NewInfoByte(version uint8, isSequenceStart bool) (InfoByte, error) {
 	if version > MaxShareVersion {
-		return 0, fmt.Errorf("version %d must be less than or equal to %d", version, MaxShareVersion)
+		return 0, fmt.Errorf("version %d can't be represented in the 7 version bits of the info byte, the maximum is %d", version, MaxShareVersion)
 	}
 
 	prefix := version << 1
@@ -34,8 +47,31 @@ func (i InfoByte) IsSequenceStart() bool {
 	return uint(i)%2 == 1
 }
 
+// ParseInfoByte parses an info byte. Every byte is a valid info byte so it
+// never returns an error; use ParseInfoByteStrict to reject share versions that
+// are not supported.
 func ParseInfoByte(i byte) (InfoByte, error) {
 	isSequenceStart := i%2 == 1
 	version := i >> 1
 	return NewInfoByte(version, isSequenceStart)
 }
+
+// ParseInfoByteStrict is like ParseInfoByte but it returns an error wrapping
+// ErrUnsupportedShareVersion if the version of the info byte is not one of
+// SupportedShareVersions.
+func ParseInfoByteStrict(i byte) (InfoByte, error) {
+	return parseInfoByteStrict(i, SupportedShareVersions)
+}
+
+// parseInfoByteStrict is like ParseInfoByteStrict but it checks the version
+// against supportedShareVersions.
+func parseInfoByteStrict(i byte, supportedShareVersions []uint8) (InfoByte, error) {
+	infoByte, err := ParseInfoByte(i)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkShareVersion(infoByte.Version(), supportedShareVersions); err != nil {
+		return 0, fmt.Errorf("info byte %#08b: %w", i, err)
+	}
+	return infoByte, nil
+}
@@ -1,6 +1,9 @@
 package shares
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestInfoByte(t *testing.T) {
 	blobStart := true
@@ -101,3 +104,66 @@ func TestParseInfoByte(t *testing.T) {
 		}
 	}
 }
+
+func TestParseInfoByteStrict(t *testing.T) {
+	type testCase struct {
+		name                string
+		b                   byte
+		wantVersion         uint8
+		wantisSequenceStart bool
+		wantErr             error
+	}
+
+	tests := []testCase{
+		{"version 0 sequence start", 0b00000001, 0, true, nil},
+		{"version 0 continuation", 0b00000000, 0, false, nil},
+		{"version 1 sequence start", 0b00000011, 1, true, nil},
+		{"version 63 sequence start", 0x7F, 63, true, ErrUnsupportedShareVersion},
+		{"version 64 continuation", 0x80, 64, false, ErrUnsupportedShareVersion},
+		{"version 127 sequence start", 0xFF, 127, true, ErrUnsupportedShareVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lenient, err := ParseInfoByte(test.b)
+			if err != nil {
+				t.Fatalf("ParseInfoByte: got %v want no error", err)
+			}
+			if lenient.Version() != test.wantVersion {
+				t.Errorf("got version %v want %v", lenient.Version(), test.wantVersion)
+			}
+			if lenient.IsSequenceStart() != test.wantisSequenceStart {
+				t.Errorf("got IsSequenceStart %v want %v", lenient.IsSequenceStart(), test.wantisSequenceStart)
+			}
+
+			strict, err := ParseInfoByteStrict(test.b)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("ParseInfoByteStrict: got error %v want %v", err, test.wantErr)
+			}
+			if err == nil && strict != lenient {
+				t.Errorf("got %v want %v", strict, lenient)
+			}
+		})
+	}
+}
+
+func TestInfoByteMasks(t *testing.T) {
+	if InfoByteVersionMask|InfoByteSequenceStartMask != 0xFF {
+		t.Errorf("masks don't cover every bit of the info byte")
+	}
+	if InfoByteVersionMask&InfoByteSequenceStartMask != 0 {
+		t.Errorf("masks overlap")
+	}
+	for i := 0; i <= 0xFF; i++ {
+		infoByte, err := ParseInfoByte(byte(i))
+		if err != nil {
+			t.Fatalf("got %v want no error", err)
+		}
+		if got, want := infoByte.Version(), uint8(i&InfoByteVersionMask)>>1; got != want {
+			t.Errorf("info byte %#x: got version %v want %v", i, got, want)
+		}
+		if got, want := infoByte.IsSequenceStart(), i&InfoByteSequenceStartMask != 0; got != want {
+			t.Errorf("info byte %#x: got IsSequenceStart %v want %v", i, got, want)
+		}
+	}
+}
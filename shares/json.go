@@ -0,0 +1,170 @@
+package shares
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// shareJSON is the JSON representation of a share. The raw field contains the
+// complete share so that it round trips losslessly; the other fields are a
+// breakdown of the raw share for humans and tooling. Optional fields are
+// pointers so that unmarshalling can tell whether they were provided.
+type shareJSON struct {
+	Namespace string `json:"namespace,omitempty"`
+	Version   *uint8 `json:"version,omitempty"`
+	// UnsupportedVersion is set if Version is not one of
+	// SupportedShareVersions, in which case the fields following the info
+	// byte are read as if the share had share version zero.
+	UnsupportedVersion bool    `json:"unsupported_version,omitempty"`
+	SequenceStart      *bool   `json:"sequence_start,omitempty"`
+	SequenceLen        *uint32 `json:"sequence_len,omitempty"`
+	Signer             string  `json:"signer,omitempty"`
+	ReservedBytes      *uint32 `json:"reserved_bytes,omitempty"`
+	Payload            string  `json:"payload,omitempty"`
+	Raw                string  `json:"raw,omitempty"`
+}
+
+// MarshalJSON encodes the share as a JSON object with a breakdown of its
+// fields and the complete raw share. It reads the raw share bytes directly so
+// it does not fail on malformed shares or shares with an unsupported share
+// version. It implements the json.Marshaler interface.
+func (s *Share) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+// UnmarshalJSON decodes a share encoded by MarshalJSON. Either the raw field
+// or the breakdown of the share, i.e. namespace, version, sequence_start,
+// sequence_len, signer, reserved_bytes and payload, must be provided. If both
+// are provided they must be consistent. It implements the json.Unmarshaler
+// interface.
+func (s *Share) UnmarshalJSON(data []byte) error {
+	var in shareJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	var raw []byte
+	var err error
+	if in.Raw != "" {
+		raw, err = hex.DecodeString(in.Raw)
+		if err != nil {
+			return fmt.Errorf("invalid raw share: %w", err)
+		}
+	} else {
+		raw, err = in.assemble()
+		if err != nil {
+			return err
+		}
+	}
+	share, err := NewShare(raw)
+	if err != nil {
+		return err
+	}
+	if err := in.checkConsistency(share.toJSON()); err != nil {
+		return err
+	}
+	*s = *share
+	return nil
+}
+
+// toJSON returns the JSON representation of the share.
+func (s *Share) toJSON() shareJSON {
+	l := s.layout()
+	version := l.infoByte.Version()
+	isStart := l.infoByte.IsSequenceStart()
+
+	out := shareJSON{
+		Namespace:          hex.EncodeToString(s.data[:namespace.NamespaceSize]),
+		Version:            &version,
+		UnsupportedVersion: !IsSupportedShareVersion(version),
+		SequenceStart:      &isStart,
+		Payload:            hex.EncodeToString(s.data[l.payloadIdx:]),
+		Raw:                hex.EncodeToString(s.data[:]),
+	}
+	if l.sequenceLenIdx != -1 {
+		sequenceLen := binary.BigEndian.Uint32(s.data[l.sequenceLenIdx:])
+		out.SequenceLen = &sequenceLen
+	}
+	if l.signerIdx != -1 {
+		out.Signer = hex.EncodeToString(s.data[l.signerIdx : l.signerIdx+SignerSize])
+	}
+	if l.reservedIdx != -1 {
+		reserved := binary.BigEndian.Uint32(s.data[l.reservedIdx:])
+		out.ReservedBytes = &reserved
+	}
+	return out
+}
+
+// assemble returns the raw share described by the breakdown of the share.
+func (in shareJSON) assemble() ([]byte, error) {
+	if in.Namespace == "" || in.Version == nil || in.SequenceStart == nil {
+		return nil, errors.New("share JSON must contain either raw or namespace, version and sequence_start")
+	}
+	raw, err := hex.DecodeString(in.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	if len(raw) != namespace.NamespaceSize {
+		return nil, fmt.Errorf("namespace must be %d bytes, got %d", namespace.NamespaceSize, len(raw))
+	}
+	infoByte, err := NewInfoByte(*in.Version, *in.SequenceStart)
+	if err != nil {
+		return nil, err
+	}
+	raw = append(raw, byte(infoByte))
+	if in.SequenceLen != nil {
+		raw = binary.BigEndian.AppendUint32(raw, *in.SequenceLen)
+	}
+	signer, err := hex.DecodeString(in.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer: %w", err)
+	}
+	raw = append(raw, signer...)
+	if in.ReservedBytes != nil {
+		raw = binary.BigEndian.AppendUint32(raw, *in.ReservedBytes)
+	}
+	payload, err := hex.DecodeString(in.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	return append(raw, payload...), nil
+}
+
+// checkConsistency returns an error if a field that was provided in the JSON
+// differs from the same field of want, which was derived from the raw share.
+func (in shareJSON) checkConsistency(want shareJSON) error {
+	inconsistent := func(field string) error {
+		return fmt.Errorf("share JSON field %s is inconsistent with the raw share", field)
+	}
+	if in.Namespace != "" && !strings.EqualFold(in.Namespace, want.Namespace) {
+		return inconsistent("namespace")
+	}
+	if in.Version != nil && *in.Version != *want.Version {
+		return inconsistent("version")
+	}
+	if in.UnsupportedVersion && !want.UnsupportedVersion {
+		return inconsistent("unsupported_version")
+	}
+	if in.SequenceStart != nil && *in.SequenceStart != *want.SequenceStart {
+		return inconsistent("sequence_start")
+	}
+	if in.SequenceLen != nil && (want.SequenceLen == nil || *in.SequenceLen != *want.SequenceLen) {
+		return inconsistent("sequence_len")
+	}
+	if in.Signer != "" && !strings.EqualFold(in.Signer, want.Signer) {
+		return inconsistent("signer")
+	}
+	if in.ReservedBytes != nil && (want.ReservedBytes == nil || *in.ReservedBytes != *want.ReservedBytes) {
+		return inconsistent("reserved_bytes")
+	}
+	if in.Payload != "" && !strings.EqualFold(in.Payload, want.Payload) {
+		return inconsistent("payload")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package shares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareJSONRoundTrip(t *testing.T) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 300))
+	require.NoError(t, err)
+	blobShares, err := SplitBlobs(
+		blob.New(ns1, bytes.Repeat([]byte{0xf}, 600), ShareVersionZero),
+		&blob.Blob{NamespaceVersion: 0, NamespaceId: ns1.ID, Data: bytes.Repeat([]byte{0xe}, 600), ShareVersion: uint32(ShareVersionOne), Signer: bytes.Repeat([]byte{1}, SignerSize)},
+	)
+	require.NoError(t, err)
+
+	shares := append(txShares, blobShares...)
+	shares = append(shares, TailPaddingShare(), ReservedPaddingShare())
+	for i := 0; i < 100; i++ {
+		raw := make([]byte, ShareSize)
+		_, err := rand.Read(raw)
+		require.NoError(t, err)
+		shares = append(shares, shareFromBytes(raw))
+	}
+
+	for _, share := range shares {
+		share := share
+		data, err := json.Marshal(&share)
+		require.NoError(t, err)
+
+		var got Share
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, share, got)
+
+		// the breakdown alone is enough to reconstruct the share
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(data, &fields))
+		delete(fields, "raw")
+		data, err = json.Marshal(fields)
+		require.NoError(t, err)
+		got = Share{}
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, share, got)
+	}
+}
+
+func TestShareMarshalJSON(t *testing.T) {
+	share := TailPaddingShare()
+	data, err := json.Marshal(&share)
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, hex.EncodeToString(namespace.TailPaddingNamespace.Bytes()), fields["namespace"])
+	assert.Equal(t, float64(0), fields["version"])
+	assert.Equal(t, true, fields["sequence_start"])
+	assert.Equal(t, float64(0), fields["sequence_len"])
+	assert.NotContains(t, fields, "reserved_bytes")
+	assert.NotContains(t, fields, "unsupported_version")
+
+	txShares, _, _, err := SplitTxs([][]byte{{1, 2, 3}})
+	require.NoError(t, err)
+	data, err = json.Marshal(&txShares[0])
+	require.NoError(t, err)
+	fields = nil
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, float64(ShareSize-FirstCompactShareContentSize), fields["reserved_bytes"])
+
+	// shares with an unsupported share version are flagged
+	raw := txShares[0].ToBytes()
+	raw[namespace.NamespaceSize] = 2 << 1
+	unsupported := shareFromBytes(raw)
+	data, err = json.Marshal(&unsupported)
+	require.NoError(t, err)
+	fields = nil
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, float64(2), fields["version"])
+	assert.Equal(t, true, fields["unsupported_version"])
+}
+
+func TestShareUnmarshalJSONErrors(t *testing.T) {
+	share := TailPaddingShare()
+	data, err := json.Marshal(&share)
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	// modify returns the JSON of share with its fields changed by fn.
+	modify := func(fn func(fields map[string]any)) []byte {
+		out := make(map[string]any, len(fields))
+		for k, v := range fields {
+			out[k] = v
+		}
+		fn(out)
+		data, err := json.Marshal(out)
+		require.NoError(t, err)
+		return data
+	}
+
+	type testCase struct {
+		name string
+		data []byte
+	}
+	testCases := []testCase{
+		{
+			name: "inconsistent namespace",
+			data: modify(func(f map[string]any) { f["namespace"] = "00" + f["namespace"].(string)[2:56] + "00" }),
+		},
+		{
+			name: "inconsistent sequence start",
+			data: modify(func(f map[string]any) { f["sequence_start"] = false }),
+		},
+		{
+			name: "inconsistent sequence length",
+			data: modify(func(f map[string]any) { f["sequence_len"] = 1 }),
+		},
+		{
+			name: "reserved bytes on a sparse share",
+			data: modify(func(f map[string]any) { f["reserved_bytes"] = 0 }),
+		},
+		{
+			name: "inconsistent payload",
+			data: modify(func(f map[string]any) { f["payload"] = "01" + f["payload"].(string)[2:] }),
+		},
+		{
+			name: "raw share too short",
+			data: modify(func(f map[string]any) { f["raw"] = f["raw"].(string)[2:] }),
+		},
+		{
+			name: "raw share not hex",
+			data: modify(func(f map[string]any) { f["raw"] = "zz" }),
+		},
+		{
+			name: "breakdown missing sequence length",
+			data: modify(func(f map[string]any) {
+				delete(f, "raw")
+				delete(f, "sequence_len")
+			}),
+		},
+		{
+			name: "breakdown missing namespace",
+			data: modify(func(f map[string]any) {
+				delete(f, "raw")
+				delete(f, "namespace")
+			}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Share
+			assert.Error(t, json.Unmarshal(tc.data, &got))
+		})
+	}
+}
@@ -1,11 +1,87 @@
 package shares
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/celestiaorg/go-square/namespace"
+	"golang.org/x/exp/slices"
 )
 
+// IsSortedByNamespace reports whether the shares are sorted by namespace, i.e.
+// no share has a lower namespace than the share before it. If they aren't, it
+// also returns the index of the first share with a lower namespace than its
+// predecessor; otherwise the index is -1. Namespaces are compared as raw bytes
+// and are not validated.
+func IsSortedByNamespace(s []Share) (bool, int) {
+	for i := 1; i < len(s); i++ {
+		if compareNamespaces(&s[i-1], &s[i]) > 0 {
+			return false, i
+		}
+	}
+	return true, -1
+}
+
+// ValidateNamespaceOrder is a strict version of IsSortedByNamespace for
+// shares that consist of whole sequences, such as the shares of a square or
+// the shares of a namespace returned by GetSharesByNamespace. On top of
+// requiring the shares to be sorted, returning an error wrapping
+// ErrNamespaceOrder if they aren't, it checks that the sequences aren't
+// interleaved. Every sequence must start with a sequence start share followed
+// by exactly as many continuation shares of its namespace as its sequence
+// length requires. The error is a *ParseError wrapping ErrMissingSequenceStart,
+// ErrUnexpectedSequenceStart, ErrNamespaceMismatch or ErrInvalidSequenceLen
+// if that is not the case, or the error of a share that is malformed.
+func ValidateNamespaceOrder(s []Share) error {
+	if sorted, i := IsSortedByNamespace(s); !sorted {
+		return newParseError(i, &s[i], fmt.Errorf("%w: namespace is lower than the namespace of share %d", ErrNamespaceOrder, i-1))
+	}
+	// start is the index of the first share of the current sequence and
+	// remaining the number of its continuation shares that are still
+	// expected
+	start, remaining := 0, 0
+	for i := range s {
+		share := &s[i]
+		if err := share.validateFull(); err != nil {
+			return newParseError(i, share, err)
+		}
+		isStart := share.layout().infoByte.IsSequenceStart()
+		if remaining > 0 {
+			if compareNamespaces(&s[start], share) != 0 {
+				return newParseError(i, share, fmt.Errorf("%w: the sequence that starts at share %d needs %d more shares", ErrNamespaceMismatch, start, remaining))
+			}
+			if isStart {
+				return newParseError(i, share, fmt.Errorf("%w: the sequence that starts at share %d needs %d more shares", ErrUnexpectedSequenceStart, start, remaining))
+			}
+			remaining--
+			continue
+		}
+		if !isStart {
+			return newParseError(i, share, ErrMissingSequenceStart)
+		}
+		needed, err := numberOfSharesNeeded(*share)
+		if err != nil {
+			return newParseError(i, share, err)
+		}
+		// a padding share has a sequence length of zero but is a share
+		start, remaining = i, max(needed, 1)-1
+	}
+	if remaining > 0 {
+		return newParseError(start, &s[start], fmt.Errorf("%w: the sequence needs %d more shares than there are", ErrInvalidSequenceLen, remaining))
+	}
+	return nil
+}
+
+// checkSorted returns an error wrapping ErrNamespaceOrder if the shares are
+// not sorted.
+func checkSorted(shares []Share) error {
+	if sorted, i := IsSortedByNamespace(shares); !sorted {
+		return newParseError(i, &shares[i], fmt.Errorf("%w: namespace is lower than the namespace of share %d", ErrNamespaceOrder, i-1))
+	}
+	return nil
+}
+
 // GetShareRangeForNamespace returns all shares that belong to a given
 // namespace. It will return an empty range if the namespace could not be
 // found. This assumes that the slice of shares are lexicographically
@@ -14,31 +90,29 @@ func GetShareRangeForNamespace(shares []Share, ns namespace.Namespace) (Range, e
 	if len(shares) == 0 {
 		return EmptyRange(), nil
 	}
-	n0, err := shares[0].Namespace()
-	if err != nil {
+	if err := shares[0].validateNamespace(); err != nil {
 		return EmptyRange(), err
 	}
-	if ns.IsLessThan(n0) {
+	if shares[0].CompareNamespace(ns) > 0 {
 		return EmptyRange(), nil
 	}
-	n1, err := shares[len(shares)-1].Namespace()
-	if err != nil {
+	if err := shares[len(shares)-1].validateNamespace(); err != nil {
 		return EmptyRange(), err
 	}
-	if ns.IsGreaterThan(n1) {
+	if shares[len(shares)-1].CompareNamespace(ns) < 0 {
 		return EmptyRange(), nil
 	}
 
 	start := -1
-	for i, share := range shares {
-		shareNS, err := share.Namespace()
-		if err != nil {
+	for i := range shares {
+		if err := shares[i].validateNamespace(); err != nil {
 			return EmptyRange(), fmt.Errorf("failed to get namespace from share %d: %w", i, err)
 		}
-		if shareNS.IsGreaterThan(ns) && start != -1 {
+		cmp := shares[i].CompareNamespace(ns)
+		if cmp > 0 && start != -1 {
 			return Range{start, i}, nil
 		}
-		if ns.Equals(shareNS) && start == -1 {
+		if cmp == 0 && start == -1 {
 			start = i
 		}
 	}
@@ -47,3 +121,156 @@ func GetShareRangeForNamespace(shares []Share, ns namespace.Namespace) (Range, e
 	}
 	return Range{start, len(shares)}, nil
 }
+
+// GetNamespaceRange returns the range of namespaces spanned by the provided
+// shares, i.e. the namespaces of the first and the last share. The shares must
+// be sorted by namespace and an error is returned if they are not or if there
+// are no shares.
+func GetNamespaceRange(shares []Share) (namespace.Range, error) {
+	if len(shares) == 0 {
+		return namespace.Range{}, errors.New("no shares to get the namespace range of")
+	}
+	for i := range shares {
+		if err := shares[i].validateNamespace(); err != nil {
+			return namespace.Range{}, fmt.Errorf("failed to get namespace from share %d: %w", i, err)
+		}
+		if i > 0 && compareNamespaces(&shares[i-1], &shares[i]) > 0 {
+			return namespace.Range{}, fmt.Errorf("shares are not sorted by namespace: share %d has a lower namespace than share %d", i, i-1)
+		}
+	}
+	minNs, err := shares[0].Namespace()
+	if err != nil {
+		return namespace.Range{}, err
+	}
+	maxNs, err := shares[len(shares)-1].Namespace()
+	if err != nil {
+		return namespace.Range{}, err
+	}
+	return namespace.NewRange(minNs, maxNs)
+}
+
+// SharesInRange returns the bounds of the shares whose namespace falls within
+// the inclusive range [start, end] such that shares[lo:hi] are exactly those
+// shares. The provided shares must be lexicographically sorted by namespace.
+// Every namespace inspected during the binary search is checked for ordering
+// so an error is returned if the shares are detected to not be sorted. Note
+// that this check is not exhaustive, see SharesInRangeStrict.
+func SharesInRange(shares []Share, start, end namespace.Namespace) (lo, hi int, err error) {
+	return sharesInRange(shares, start, end, false)
+}
+
+// SharesInRangeStrict is like SharesInRange but checks that all of the
+// provided shares are sorted with IsSortedByNamespace before searching them,
+// instead of only the shares inspected by the binary search. It is linear
+// rather than logarithmic in the number of shares so it is meant for tests and
+// debugging.
+func SharesInRangeStrict(shares []Share, start, end namespace.Namespace) (lo, hi int, err error) {
+	return sharesInRange(shares, start, end, true)
+}
+
+// sharesInRange is SharesInRange, or SharesInRangeStrict if strict is set.
+func sharesInRange(shares []Share, start, end namespace.Namespace, strict bool) (lo, hi int, err error) {
+	if end.IsLessThan(start) {
+		return 0, 0, fmt.Errorf("start namespace %v must not be greater than end namespace %v", start.Bytes(), end.Bytes())
+	}
+	if strict {
+		if err := checkSorted(shares); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var probes []int
+	search := func(pred func(share *Share) bool) int {
+		return sort.Search(len(shares), func(i int) bool {
+			if err != nil {
+				return true
+			}
+			if nsErr := shares[i].validateNamespace(); nsErr != nil {
+				err = fmt.Errorf("failed to get namespace from share %d: %w", i, nsErr)
+				return true
+			}
+			probes = append(probes, i)
+			return pred(&shares[i])
+		})
+	}
+
+	lo = search(func(share *Share) bool { return share.CompareNamespace(start) >= 0 })
+	hi = search(func(share *Share) bool { return share.CompareNamespace(end) > 0 })
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sort.Ints(probes)
+	for i := 1; i < len(probes); i++ {
+		if compareNamespaces(&shares[probes[i]], &shares[probes[i-1]]) < 0 {
+			return 0, 0, newParseError(probes[i], &shares[probes[i]], fmt.Errorf("%w: namespace is lower than the namespace of share %d", ErrNamespaceOrder, probes[i-1]))
+		}
+	}
+	return lo, hi, nil
+}
+
+// SortByNamespace sorts the shares by namespace without allocating. The sort
+// is stable so shares of the same namespace keep their relative order.
+func SortByNamespace(shares []Share) {
+	slices.SortStableFunc(shares, func(a, b Share) int {
+		return compareNamespaces(&a, &b)
+	})
+}
+
+// GetShareRangeForNamespaces is like SharesInRange but it returns the bounds
+// of the shares as a Range.
+func GetShareRangeForNamespaces(shares []Share, start, end namespace.Namespace) (Range, error) {
+	lo, hi, err := SharesInRange(shares, start, end)
+	if err != nil {
+		return EmptyRange(), err
+	}
+	return NewRange(lo, hi), nil
+}
+
+// GetSharesByNamespace returns the range of the shares that belong to ns and
+// the sub-slice of shares in that range. It returns an empty range and no
+// shares if ns is not present. The provided shares must be lexicographically
+// sorted by namespace. The shares are located with a binary search; the shares
+// in the returned range and their neighbours are checked to belong to ns and
+// to be sorted respectively, so an error wrapping ErrNamespaceOrder is
+// returned instead of a partial range if the shares are not sorted.
+func GetSharesByNamespace(shares []Share, ns namespace.Namespace) (Range, []Share, error) {
+	return getSharesByNamespace(shares, ns, false)
+}
+
+// GetSharesByNamespaceStrict is like GetSharesByNamespace but, like
+// SharesInRangeStrict, checks that all of the provided shares are sorted.
+func GetSharesByNamespaceStrict(shares []Share, ns namespace.Namespace) (Range, []Share, error) {
+	return getSharesByNamespace(shares, ns, true)
+}
+
+// getSharesByNamespace is GetSharesByNamespace, or GetSharesByNamespaceStrict
+// if strict is set.
+func getSharesByNamespace(shares []Share, ns namespace.Namespace, strict bool) (Range, []Share, error) {
+	lo, hi, err := sharesInRange(shares, ns, ns, strict)
+	if err != nil {
+		return EmptyRange(), nil, err
+	}
+	if lo == hi {
+		return EmptyRange(), nil, nil
+	}
+
+	compare := func(i int) int {
+		return shares[i].CompareNamespace(ns)
+	}
+	orderErr := func(i int) error {
+		return newParseError(i, &shares[i], fmt.Errorf("%w: share %d is out of order around the shares of namespace %x", ErrNamespaceOrder, i, ns.Bytes()))
+	}
+	if lo > 0 && compare(lo-1) >= 0 {
+		return EmptyRange(), nil, orderErr(lo - 1)
+	}
+	for i := lo; i < hi; i++ {
+		if compare(i) != 0 {
+			return EmptyRange(), nil, orderErr(i)
+		}
+	}
+	if hi < len(shares) && compare(hi) <= 0 {
+		return EmptyRange(), nil, orderErr(hi)
+	}
+	return NewRange(lo, hi), shares[lo:hi], nil
+}
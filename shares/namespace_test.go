@@ -0,0 +1,324 @@
+package shares
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharesInRange(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+	ns4 := namespace.MustNewV0(bytes.Repeat([]byte{4}, namespace.NamespaceVersionZeroIDSize))
+
+	sorted := append(append(append(
+		mustNamespacePaddingShares(t, namespace.TxNamespace, 2),
+		mustNamespacePaddingShares(t, ns1, 3)...),
+		mustNamespacePaddingShares(t, ns3, 2)...),
+		TailPaddingShares(2)...)
+
+	type testCase struct {
+		name       string
+		start, end namespace.Namespace
+		wantLo     int
+		wantHi     int
+	}
+	testCases := []testCase{
+		{"single namespace", ns1, ns1, 2, 5},
+		{"multiple namespaces", namespace.TxNamespace, ns3, 0, 7},
+		{"range between present namespaces", ns2, ns2, 5, 5},
+		{"range covering a gap", ns2, ns4, 5, 7},
+		{"everything", namespace.TxNamespace, namespace.TailPaddingNamespace, 0, 9},
+		{"tail padding", namespace.TailPaddingNamespace, namespace.TailPaddingNamespace, 7, 9},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lo, hi, err := SharesInRange(sorted, tc.start, tc.end)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantLo, lo)
+			assert.Equal(t, tc.wantHi, hi)
+		})
+	}
+
+	t.Run("start greater than end", func(t *testing.T) {
+		_, _, err := SharesInRange(sorted, ns3, ns1)
+		assert.Error(t, err)
+	})
+	t.Run("empty shares", func(t *testing.T) {
+		lo, hi, err := SharesInRange(nil, ns1, ns3)
+		require.NoError(t, err)
+		assert.Equal(t, 0, lo)
+		assert.Equal(t, 0, hi)
+	})
+	t.Run("unsorted shares", func(t *testing.T) {
+		unsorted := append(mustNamespacePaddingShares(t, ns3, 4), mustNamespacePaddingShares(t, ns1, 4)...)
+		_, _, err := SharesInRange(unsorted, ns1, ns1)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetSharesByNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+
+	sorted := append(append(append(
+		mustNamespacePaddingShares(t, namespace.TxNamespace, 2),
+		mustNamespacePaddingShares(t, ns1, 1)...),
+		mustNamespacePaddingShares(t, ns3, 2)...),
+		TailPaddingShares(3)...)
+
+	type testCase struct {
+		name string
+		ns   namespace.Namespace
+		want Range
+	}
+	testCases := []testCase{
+		{"namespace at the start", namespace.TxNamespace, NewRange(0, 2)},
+		{"namespace occupying a single share", ns1, NewRange(2, 3)},
+		{"namespace at the end", namespace.TailPaddingNamespace, NewRange(5, 8)},
+		{"namespace between present namespaces", ns2, EmptyRange()},
+		{"namespace before all shares", namespace.MustNewV0(bytes.Repeat([]byte{0}, namespace.NamespaceVersionZeroIDSize)), EmptyRange()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, shares, err := GetSharesByNamespace(sorted, tc.ns)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Len(t, shares, tc.want.End-tc.want.Start)
+			for _, share := range shares {
+				ns, err := share.Namespace()
+				require.NoError(t, err)
+				assert.True(t, tc.ns.Equals(ns))
+			}
+		})
+	}
+
+	t.Run("unsorted shares", func(t *testing.T) {
+		unsorted := append(append(
+			mustNamespacePaddingShares(t, ns1, 3),
+			mustNamespacePaddingShares(t, ns3, 1)...),
+			mustNamespacePaddingShares(t, ns1, 1)...)
+		_, shares, err := GetSharesByNamespace(unsorted, ns1)
+		assert.ErrorIs(t, err, ErrNamespaceOrder)
+		assert.Nil(t, shares)
+	})
+}
+
+func mustNamespacePaddingShares(t *testing.T, ns namespace.Namespace, n int) []Share {
+	shares, err := NamespacePaddingShares(ns, ShareVersionZero, n)
+	require.NoError(t, err)
+	return shares
+}
+
+func TestGetShareRangeForNamespaces(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+	sorted := append(append(
+		mustNamespacePaddingShares(t, namespace.TxNamespace, 2),
+		mustNamespacePaddingShares(t, ns1, 1)...),
+		mustNamespacePaddingShares(t, ns3, 2)...)
+
+	got, err := GetShareRangeForNamespaces(sorted, ns1, ns3)
+	require.NoError(t, err)
+	assert.Equal(t, NewRange(2, 5), got)
+
+	_, err = GetShareRangeForNamespaces(sorted, ns3, ns1)
+	assert.Error(t, err)
+}
+
+func TestCompareNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	share := mustNamespacePaddingShares(t, ns1, 1)[0]
+
+	assert.Equal(t, ns1.Bytes(), share.NamespaceBytes())
+	assert.Equal(t, 0, share.CompareNamespace(ns1))
+	assert.Equal(t, -1, share.CompareNamespace(ns2))
+	assert.Equal(t, 1, share.CompareNamespace(namespace.TxNamespace))
+	assert.Equal(t, -1, share.CompareNamespace(namespace.ParitySharesNamespace))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		share.CompareNamespace(ns2)
+		share.NamespaceBytes()
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestSortByNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	first := mustNamespacePaddingShares(t, ns2, 1)[0]
+	second := shareWithData(ns2, true, 1, []byte{1})
+	shares := []Share{first, mustNamespacePaddingShares(t, ns1, 1)[0], second, mustNamespacePaddingShares(t, namespace.TxNamespace, 1)[0]}
+
+	SortByNamespace(shares)
+
+	want := []namespace.Namespace{namespace.TxNamespace, ns1, ns2, ns2}
+	for i, share := range shares {
+		assert.Equal(t, 0, share.CompareNamespace(want[i]), "share %d", i)
+	}
+	// shares of the same namespace keep their relative order
+	assert.Equal(t, first, shares[2])
+	assert.Equal(t, second, shares[3])
+}
+
+func BenchmarkSortByNamespace(b *testing.B) {
+	const numShares = 16384
+	unsorted := make([]Share, numShares)
+	for i := range unsorted {
+		id := make([]byte, namespace.NamespaceVersionZeroIDSize)
+		binary.BigEndian.PutUint32(id[len(id)-4:], uint32(numShares-i))
+		shares, err := NamespacePaddingShares(namespace.MustNewV0(id), ShareVersionZero, 1)
+		require.NoError(b, err)
+		unsorted[i] = shares[0]
+	}
+	shares := make([]Share, numShares)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(shares, unsorted)
+		SortByNamespace(shares)
+	}
+}
+
+func TestGetNamespaceRange(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+
+	sorted := append(append(
+		mustNamespacePaddingShares(t, ns1, 2),
+		mustNamespacePaddingShares(t, ns3, 2)...),
+		TailPaddingShares(2)...)
+	got, err := GetNamespaceRange(sorted)
+	require.NoError(t, err)
+	assert.Equal(t, namespace.Range{Min: ns1, Max: namespace.TailPaddingNamespace}, got)
+
+	got, err = GetNamespaceRange(mustNamespacePaddingShares(t, ns3, 3))
+	require.NoError(t, err)
+	assert.Equal(t, namespace.Range{Min: ns3, Max: ns3}, got)
+
+	_, err = GetNamespaceRange(nil)
+	assert.Error(t, err)
+	_, err = GetNamespaceRange(append(mustNamespacePaddingShares(t, ns3, 1), mustNamespacePaddingShares(t, ns1, 1)...))
+	assert.Error(t, err)
+}
+
+func TestIsSortedByNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+
+	testCases := []struct {
+		name       string
+		shares     []Share
+		wantSorted bool
+		wantIndex  int
+	}{
+		{"no shares", nil, true, -1},
+		{"single share", mustNamespacePaddingShares(t, ns1, 1), true, -1},
+		{"sorted", append(mustNamespacePaddingShares(t, ns1, 2), mustNamespacePaddingShares(t, ns2, 2)...), true, -1},
+		{"unsorted", append(append(mustNamespacePaddingShares(t, ns1, 1), mustNamespacePaddingShares(t, ns2, 2)...), mustNamespacePaddingShares(t, ns1, 1)...), false, 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted, index := IsSortedByNamespace(tc.shares)
+			assert.Equal(t, tc.wantSorted, sorted)
+			assert.Equal(t, tc.wantIndex, index)
+		})
+	}
+}
+
+func TestValidateNamespaceOrder(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	// each blob takes two shares
+	size := FirstSparseShareContentSize + 1
+	blobA, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, size))
+	require.NoError(t, err)
+	blobB, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, size))
+	require.NoError(t, err)
+	blobC, err := SplitBlobs(generateRandomBlobWithNamespace(ns2, size))
+	require.NoError(t, err)
+	concat := func(shares ...[]Share) []Share {
+		var out []Share
+		for _, s := range shares {
+			out = append(out, s...)
+		}
+		return out
+	}
+
+	testCases := []struct {
+		name      string
+		shares    []Share
+		wantErr   error
+		wantIndex int
+	}{
+		{name: "no shares", shares: nil},
+		{name: "whole sequences", shares: concat(mustNamespacePaddingShares(t, namespace.TxNamespace, 1), blobA, blobB, mustNamespacePaddingShares(t, ns1, 1), blobC, TailPaddingShares(2))},
+		{name: "unsorted", shares: concat(blobC, blobA), wantErr: ErrNamespaceOrder, wantIndex: 2},
+		{
+			// the namespaces are sorted but a sequence starts before the
+			// previous one ended
+			name:      "interleaved sequences",
+			shares:    []Share{blobA[0], blobB[0], blobA[1], blobB[1]},
+			wantErr:   ErrUnexpectedSequenceStart,
+			wantIndex: 1,
+		},
+		{name: "continuation without start", shares: []Share{blobA[1], blobB[0], blobB[1]}, wantErr: ErrMissingSequenceStart, wantIndex: 0},
+		{name: "sequence cut by another namespace", shares: concat(blobA[:1], blobC), wantErr: ErrNamespaceMismatch, wantIndex: 1},
+		{name: "truncated sequence", shares: concat(blobA, blobB[:1]), wantErr: ErrInvalidSequenceLen, wantIndex: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted, _ := IsSortedByNamespace(tc.shares)
+			err := ValidateNamespaceOrder(tc.shares)
+			if tc.wantErr == nil {
+				assert.True(t, sorted)
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.wantIndex, parseErr.Index)
+		})
+	}
+}
+
+func TestStrictNamespaceLookups(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+	// the swapped shares at the end are never probed by a search for ns1
+	shares := append(append(append(
+		mustNamespacePaddingShares(t, ns1, 4),
+		mustNamespacePaddingShares(t, ns2, 4)...),
+		mustNamespacePaddingShares(t, ns3, 1)...),
+		mustNamespacePaddingShares(t, ns2, 1)...)
+
+	_, _, err := GetSharesByNamespace(shares, ns1)
+	require.NoError(t, err)
+
+	_, _, err = GetSharesByNamespaceStrict(shares, ns1)
+	assert.ErrorIs(t, err, ErrNamespaceOrder)
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 9, parseErr.Index)
+	_, _, err = SharesInRangeStrict(shares, ns1, ns2)
+	assert.ErrorIs(t, err, ErrNamespaceOrder)
+
+	// sorted shares give the same results as the non strict lookups
+	sorted := shares[:9]
+	wantRange, wantShares, err := GetSharesByNamespace(sorted, ns2)
+	require.NoError(t, err)
+	gotRange, gotShares, err := GetSharesByNamespaceStrict(sorted, ns2)
+	require.NoError(t, err)
+	assert.Equal(t, wantRange, gotRange)
+	assert.Equal(t, wantShares, gotShares)
+}
@@ -3,6 +3,7 @@ package shares
 import (
 	"bytes"
 	"errors"
+	"fmt"
 
 	"github.com/celestiaorg/go-square/namespace"
 )
@@ -11,7 +12,8 @@ import (
 // shares follow a blob so that the next blob may start at an index that
 // conforms to blob share commitment rules. The ns and shareVersion parameters
 // provided should be the namespace and shareVersion of the blob that precedes
-// this padding in the data square.
+// this padding in the data square. An error is returned if shareVersion is not
+// one of SupportedShareVersions.
 func NamespacePaddingShare(ns namespace.Namespace, shareVersion uint8) (Share, error) {
 	b, err := NewBuilder(ns, shareVersion, true)
 	if err != nil {
@@ -20,8 +22,10 @@ func NamespacePaddingShare(ns namespace.Namespace, shareVersion uint8) (Share, e
 	if err := b.WriteSequenceLen(0); err != nil {
 		return Share{}, err
 	}
-	padding := bytes.Repeat([]byte{0}, FirstSparseShareContentSize)
-	b.AddData(padding)
+	padding := bytes.Repeat([]byte{0}, b.AvailableBytes())
+	if _, _, err := b.AddData(padding); err != nil {
+		return Share{}, err
+	}
 
 	share, err := b.Build()
 	if err != nil {
@@ -31,6 +35,26 @@ func NamespacePaddingShare(ns namespace.Namespace, shareVersion uint8) (Share, e
 	return *share, nil
 }
 
+// ValidatePaddingShare returns an error if the provided share is not a padding
+// share or if the data following its sequence length is not all zero.
+func ValidatePaddingShare(share Share) error {
+	isPadding, err := share.IsPadding()
+	if err != nil {
+		return err
+	}
+	if !isPadding {
+		return errors.New("share is not a padding share")
+	}
+	rawData, err := share.RawData()
+	if err != nil {
+		return err
+	}
+	if !IsAllZero(rawData) {
+		return errors.New("padding share contains non-zero data")
+	}
+	return nil
+}
+
 // NamespacePaddingShares returns n namespace padding shares.
 func NamespacePaddingShares(ns namespace.Namespace, shareVersion uint8, n int) ([]Share, error) {
 	var err error
@@ -61,13 +85,20 @@ func ReservedPaddingShare() Share {
 
 // ReservedPaddingShares returns n reserved padding shares.
 func ReservedPaddingShares(n int) []Share {
-	shares, err := NamespacePaddingShares(namespace.PrimaryReservedPaddingNamespace, ShareVersionZero, n)
+	shares, err := ReservedPaddingSharesForVersion(ShareVersionZero, n)
 	if err != nil {
 		panic(err)
 	}
 	return shares
 }
 
+// ReservedPaddingSharesForVersion returns n reserved padding shares with the
+// provided share version. An error is returned if shareVersion is not one of
+// SupportedShareVersions.
+func ReservedPaddingSharesForVersion(shareVersion uint8, n int) ([]Share, error) {
+	return NamespacePaddingShares(namespace.PrimaryReservedPaddingNamespace, shareVersion, n)
+}
+
 // TailPaddingShare is a share that is used to pad a data square to the desired
 // square size. Tail padding shares follow the last blob share in the data
 // square.
@@ -81,9 +112,77 @@ func TailPaddingShare() Share {
 
 // TailPaddingShares returns n tail padding shares.
 func TailPaddingShares(n int) []Share {
-	shares, err := NamespacePaddingShares(namespace.TailPaddingNamespace, ShareVersionZero, n)
+	shares, err := TailPaddingSharesForVersion(ShareVersionZero, n)
 	if err != nil {
 		panic(err)
 	}
 	return shares
 }
+
+// TailPaddingSharesForVersion returns n tail padding shares with the provided
+// share version. An error is returned if shareVersion is not one of
+// SupportedShareVersions.
+func TailPaddingSharesForVersion(shareVersion uint8, n int) ([]Share, error) {
+	return NamespacePaddingShares(namespace.TailPaddingNamespace, shareVersion, n)
+}
+
+// CountPaddingShares classifies the padding shares in shares and returns the
+// number of tail padding shares, namespace padding shares, i.e. sequences of
+// length 0 in a blob namespace, and primary reserved padding shares. Every
+// other share is assumed to hold data. Errors are returned as a *ParseError
+// wrapping ErrInvalidPaddingShare for shares that can't be classified, e.g. a
+// share in a padding namespace with data or a sequence of length 0 in the
+// transaction namespace.
+func CountPaddingShares(shares []Share) (tail, namespacePadding, reserved int, err error) {
+	for i := range shares {
+		share := &shares[i]
+		if err := share.validateFull(); err != nil {
+			return 0, 0, 0, newParseError(i, share, err)
+		}
+		ns, err := share.Namespace()
+		if err != nil {
+			return 0, 0, 0, newParseError(i, share, fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err))
+		}
+		isPadding, err := share.isNamespacePadding()
+		if err != nil {
+			return 0, 0, 0, newParseError(i, share, err)
+		}
+		isPaddingNamespace := namespace.Classify(ns) == namespace.PaddingKind
+		switch {
+		case isPaddingNamespace && !isPadding:
+			return 0, 0, 0, newParseError(i, share, fmt.Errorf("%w: share in namespace %s is not a sequence of length 0", ErrInvalidPaddingShare, ns))
+		case !isPadding:
+			continue
+		case ns.IsReserved() && !isPaddingNamespace:
+			return 0, 0, 0, newParseError(i, share, fmt.Errorf("%w: sequence of length 0 in reserved namespace %s", ErrInvalidPaddingShare, ns))
+		}
+		if err := ValidatePaddingShare(*share); err != nil {
+			return 0, 0, 0, newParseError(i, share, fmt.Errorf("%w: %v", ErrInvalidPaddingShare, err))
+		}
+		switch {
+		case ns.IsTailPadding():
+			tail++
+		case ns.IsPrimaryReservedPadding():
+			reserved++
+		default:
+			namespacePadding++
+		}
+	}
+	return tail, namespacePadding, reserved, nil
+}
+
+// UtilizationRatio returns the ratio of shares that are not padding shares to
+// the total number of shares, e.g. 0.75 if a quarter of the shares of a data
+// square are padding. It returns 0 for no shares. Padding shares are
+// classified by CountPaddingShares.
+func UtilizationRatio(shares []Share) (float64, error) {
+	if len(shares) == 0 {
+		return 0, nil
+	}
+	tail, namespacePadding, reserved, err := CountPaddingShares(shares)
+	if err != nil {
+		return 0, err
+	}
+	used := len(shares) - tail - namespacePadding - reserved
+	return float64(used) / float64(len(shares)), nil
+}
@@ -44,6 +44,20 @@ func TestNamespacePaddingShare(t *testing.T) {
 	assert.Equal(t, nsOnePadding, got.ToBytes())
 }
 
+func TestValidatePaddingShare(t *testing.T) {
+	for _, share := range []Share{TailPaddingShare(), ReservedPaddingShare(), shareFromBytes(nsOnePadding)} {
+		assert.NoError(t, ValidatePaddingShare(share))
+	}
+
+	dirty := append([]byte{}, tailPadding...)
+	dirty[len(dirty)-1] = 1
+	assert.Error(t, ValidatePaddingShare(shareFromBytes(dirty)))
+	assert.Error(t, ShareSequence{Namespace: namespace.TailPaddingNamespace, Shares: []Share{shareFromBytes(dirty)}}.validSequenceLen())
+
+	notPadding := shareWithData(ns1, true, 1, []byte{1})
+	assert.Error(t, ValidatePaddingShare(notPadding))
+}
+
 func TestNamespacePaddingShares(t *testing.T) {
 	shares, err := NamespacePaddingShares(ns1, ShareVersionZero, 2)
 	assert.NoError(t, err)
@@ -83,3 +97,139 @@ func TestTailPaddingShares(t *testing.T) {
 		}
 	})
 }
+
+func TestPaddingSharesForVersion(t *testing.T) {
+	type testCase struct {
+		name   string
+		ns     namespace.Namespace
+		shares func(shareVersion uint8, n int) ([]Share, error)
+	}
+	testCases := []testCase{
+		{
+			name: "namespace padding",
+			ns:   ns1,
+			shares: func(shareVersion uint8, n int) ([]Share, error) {
+				return NamespacePaddingShares(ns1, shareVersion, n)
+			},
+		},
+		{
+			name:   "reserved padding",
+			ns:     namespace.PrimaryReservedPaddingNamespace,
+			shares: ReservedPaddingSharesForVersion,
+		},
+		{
+			name:   "tail padding",
+			ns:     namespace.TailPaddingNamespace,
+			shares: TailPaddingSharesForVersion,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, shareVersion := range SupportedShareVersions {
+				shares, err := tc.shares(shareVersion, 2)
+				require.NoError(t, err)
+				require.Len(t, shares, 2)
+				for _, share := range shares {
+					ns, err := share.Namespace()
+					require.NoError(t, err)
+					assert.Equal(t, tc.ns, ns)
+					version, err := share.Version()
+					require.NoError(t, err)
+					assert.Equal(t, shareVersion, version)
+					sequenceLen, err := share.SequenceLen()
+					require.NoError(t, err)
+					assert.Zero(t, sequenceLen)
+					assert.NoError(t, ValidatePaddingShare(share))
+				}
+			}
+
+			_, err := tc.shares(ShareVersionOne+1, 1)
+			assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+		})
+	}
+}
+
+func TestCountPaddingShares(t *testing.T) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 200))
+	require.NoError(t, err)
+	blobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, 1000))
+	require.NoError(t, err)
+	nsPadding := mustNamespacePaddingShares(t, ns1, 2)
+	concat := func(shares ...[]Share) (result []Share) {
+		for _, s := range shares {
+			result = append(result, s...)
+		}
+		return result
+	}
+	withData := func(share Share) Share {
+		share.data[len(share.data)-1] = 1
+		return share
+	}
+
+	testCases := []struct {
+		name             string
+		shares           []Share
+		wantTail         int
+		wantNamespace    int
+		wantReserved     int
+		wantUtilization  float64
+		wantErr          error
+		wantInvalidIndex int
+	}{
+		{name: "no shares"},
+		{name: "only tail padding", shares: TailPaddingShares(4), wantTail: 4},
+		{name: "no padding", shares: concat(txShares, blobShares), wantUtilization: 1},
+		{
+			name:            "every kind of padding",
+			shares:          concat(txShares, ReservedPaddingShares(3), blobShares, nsPadding, TailPaddingShares(4)),
+			wantTail:        4,
+			wantNamespace:   2,
+			wantReserved:    3,
+			wantUtilization: float64(len(txShares)+len(blobShares)) / float64(len(txShares)+len(blobShares)+9),
+		},
+		{
+			name:             "tail padding share with data",
+			shares:           concat(blobShares, []Share{withData(TailPaddingShare())}),
+			wantErr:          ErrInvalidPaddingShare,
+			wantInvalidIndex: len(blobShares),
+		},
+		{
+			name:             "namespace padding share with data",
+			shares:           []Share{withData(nsPadding[0])},
+			wantErr:          ErrInvalidPaddingShare,
+			wantInvalidIndex: 0,
+		},
+		{
+			name:             "reserved padding share with data",
+			shares:           concat(txShares, []Share{withData(ReservedPaddingShare())}),
+			wantErr:          ErrInvalidPaddingShare,
+			wantInvalidIndex: len(txShares),
+		},
+		{
+			name:             "sequence of length 0 in the tx namespace",
+			shares:           concat(txShares, mustNamespacePaddingShares(t, namespace.TxNamespace, 1)),
+			wantErr:          ErrInvalidPaddingShare,
+			wantInvalidIndex: len(txShares),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tail, namespacePadding, reserved, err := CountPaddingShares(tc.shares)
+			utilization, utilizationErr := UtilizationRatio(tc.shares)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.ErrorIs(t, utilizationErr, tc.wantErr)
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				assert.Equal(t, tc.wantInvalidIndex, parseErr.Index)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, utilizationErr)
+			assert.Equal(t, tc.wantTail, tail)
+			assert.Equal(t, tc.wantNamespace, namespacePadding)
+			assert.Equal(t, tc.wantReserved, reserved)
+			assert.InDelta(t, tc.wantUtilization, utilization, 1e-9)
+		})
+	}
+}
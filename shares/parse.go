@@ -2,25 +2,61 @@ package shares
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
 )
 
-// ParseTxs collects all of the transactions from the shares provided
-func ParseTxs(shares []Share) ([][]byte, error) {
-	// parse the shares
-	rawTxs, err := parseCompactShares(shares, SupportedShareVersions)
+// ParseTxs collects all of the transactions from the shares provided. Errors
+// are returned as a *ParseError that identifies the offending share.
+func ParseTxs(shares []Share, opts ...ParseOption) ([][]byte, error) {
+	positions, err := ParseTxsWithRanges(shares, opts...)
 	if err != nil {
 		return nil, err
 	}
+	if positions == nil {
+		return nil, nil
+	}
+
+	txs := make([][]byte, len(positions))
+	for i, position := range positions {
+		txs[i] = position.Tx
+	}
+	return txs, nil
+}
+
+// TxPosition is a transaction together with its location in the shares it was
+// parsed from.
+type TxPosition struct {
+	// Tx is the raw transaction without its unit length delimiter.
+	Tx []byte
+	// ShareRange is the range of shares that the transaction, including its
+	// unit length delimiter, spans. The range is end exclusive and relative to
+	// the shares that were parsed.
+	ShareRange Range
+	// StartByte is the index of the first byte of the unit length delimiter
+	// in the share at ShareRange.Start.
+	StartByte int
+}
 
-	return rawTxs, nil
+// ParseTxsWithRanges is like ParseTxs but it also returns the location of
+// every transaction in the shares provided. Errors are returned as a
+// *ParseError that identifies the offending share.
+func ParseTxsWithRanges(shares []Share, opts ...ParseOption) ([]TxPosition, error) {
+	config := newParseConfig(opts)
+	return parseCompactSharePositions(shares, config.supportedShareVersions)
 }
 
-// ParseBlobs collects all blobs from the shares provided
-func ParseBlobs(shares []Share) ([]*blob.Blob, error) {
-	blobList, err := parseSparseShares(shares, SupportedShareVersions)
+// ParseBlobs collects all blobs from the shares provided. Errors are returned
+// as a *ParseError that identifies the offending share.
+func ParseBlobs(shares []Share, opts ...ParseOption) ([]*blob.Blob, error) {
+	config := newParseConfig(opts)
+	blobList, err := parseSparseShares(shares, config.supportedShareVersions)
 	if err != nil {
 		return []*blob.Blob{}, err
 	}
@@ -28,62 +64,525 @@ func ParseBlobs(shares []Share) ([]*blob.Blob, error) {
 	return blobList, nil
 }
 
+// BlobPosition is a blob together with its location in the shares it was
+// parsed from.
+type BlobPosition struct {
+	Blob *blob.Blob
+	// ShareRange is the range of shares that the blob spans. The range is end
+	// exclusive and relative to the shares that were parsed.
+	ShareRange Range
+	// SequenceLen is the sequence length written in the first share of the
+	// blob, i.e. the length of its data.
+	SequenceLen uint32
+}
+
+// ParseBlobsWithPositions is like ParseBlobs but it also returns the location
+// of every blob in the shares provided. Padding shares are skipped but still
+// counted so that the share ranges are indexes into shares.
+func ParseBlobsWithPositions(shares []Share, opts ...ParseOption) ([]BlobPosition, error) {
+	config := newParseConfig(opts)
+	sequences, err := parseSparseSequences(shares, config.supportedShareVersions)
+	if err != nil {
+		return nil, err
+	}
+	positions := make([]BlobPosition, len(sequences))
+	for i, sequence := range sequences {
+		positions[i] = BlobPosition{
+			Blob:        sequence.blob,
+			ShareRange:  NewRange(sequence.startIndex, sequence.endIndex),
+			SequenceLen: sequence.sequenceLen,
+		}
+	}
+	return positions, nil
+}
+
+// GroupByNamespace groups blob positions by the namespace of their blob,
+// keyed by the String form of the namespace. The positions of a namespace
+// retain their relative order.
+func GroupByNamespace(positions []BlobPosition) map[string][]BlobPosition {
+	groups := make(map[string][]BlobPosition)
+	for _, position := range positions {
+		key := position.Blob.Namespace().String()
+		groups[key] = append(groups[key], position)
+	}
+	return groups
+}
+
+// ParseOption configures ParseShares, ParseTxs, ParseTxsWithRanges,
+// ParseBlobs and ParseBlobsWithPositions.
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	skipSequenceValidation bool
+	supportedShareVersions []uint8
+}
+
+// newParseConfig returns the configuration described by opts.
+func newParseConfig(opts []ParseOption) parseConfig {
+	config := parseConfig{supportedShareVersions: SupportedShareVersions}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// WithSupportedShareVersions restricts the share versions that are accepted
+// while parsing to the provided versions, e.g. for a chain that doesn't
+// support all of SupportedShareVersions yet. Versions that are not in
+// SupportedShareVersions are ignored. Shares with any other version are
+// rejected with an error wrapping ErrUnsupportedShareVersion.
+func WithSupportedShareVersions(versions ...uint8) ParseOption {
+	return func(c *parseConfig) {
+		c.supportedShareVersions = make([]uint8, 0, len(versions))
+		for _, version := range versions {
+			if IsSupportedShareVersion(version) {
+				c.supportedShareVersions = append(c.supportedShareVersions, version)
+			}
+		}
+	}
+}
+
+// WithoutSequenceValidation makes ParseShares return sequences without
+// validating them, e.g. without checking that their sequence length matches
+// the number of shares. It is meant for callers that need raw access to
+// malformed sequences.
+func WithoutSequenceValidation() ParseOption {
+	return func(c *parseConfig) {
+		c.skipSequenceValidation = true
+	}
+}
+
 // ParseShares parses the shares provided and returns a list of ShareSequences.
 // If ignorePadding is true then the returned ShareSequences will not contain
-// any padding sequences.
-func ParseShares(shares []Share, ignorePadding bool) ([]ShareSequence, error) {
-	sequences := []ShareSequence{}
-	currentSequence := ShareSequence{}
+// any padding sequences. Every sequence is validated with
+// ShareSequence.Validate, the share version of every share is checked against
+// the supported share versions and the reserved bytes of compact sequences are
+// checked with ValidateReservedBytes unless WithoutSequenceValidation is
+// provided. Errors are returned as a *ParseError that identifies the offending
+// share.
+func ParseShares(shares []Share, ignorePadding bool, opts ...ParseOption) ([]ShareSequence, error) {
+	config := newParseConfig(opts)
+	sequences, starts, err := splitSequences(shares, config)
+	if err != nil {
+		return sequences, err
+	}
+	if !config.skipSequenceValidation {
+		for i := range sequences {
+			if err := validateSequence(shares, sequences[i], starts[i]); err != nil {
+				return sequences, err
+			}
+		}
+	}
+	return filterPadding(shares, sequences, starts, ignorePadding)
+}
 
-	for _, share := range shares {
+// ParseSharesParallel is like ParseShares but it validates the sequences
+// concurrently on up to GOMAXPROCS goroutines, which is faster for large sets
+// of shares such as a full data square. The sequences and errors it returns
+// are identical to those of ParseShares.
+func ParseSharesParallel(shares []Share, ignorePadding bool, opts ...ParseOption) ([]ShareSequence, error) {
+	config := newParseConfig(opts)
+	sequences, starts, err := splitSequences(shares, config)
+	if err != nil {
+		return sequences, err
+	}
+	if !config.skipSequenceValidation {
+		errs := make([]error, len(sequences))
+		next := atomic.Int64{}
+		var wg sync.WaitGroup
+		for w := 0; w < min(runtime.GOMAXPROCS(0), len(sequences)); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := int(next.Add(1) - 1); i < len(sequences); i = int(next.Add(1) - 1) {
+					errs[i] = validateSequence(shares, sequences[i], starts[i])
+				}
+			}()
+		}
+		wg.Wait()
+		// return the error of the first invalid sequence like ParseShares
+		for _, err := range errs {
+			if err != nil {
+				return sequences, err
+			}
+		}
+	}
+	return filterPadding(shares, sequences, starts, ignorePadding)
+}
+
+// splitSequences splits shares into sequences at every sequence start. It
+// checks the info byte and namespace of every share but it doesn't validate
+// the sequences. It returns the sequences and the index of the first share of
+// each sequence. On error it returns the sequences that were completed before
+// the offending share.
+func splitSequences(shares []Share, config parseConfig) (sequences []ShareSequence, starts []int, err error) {
+	sequences = []ShareSequence{}
+	current := ShareSequence{}
+	// the shares of a sequence are copied at once when the sequence ends
+	closeSequence := func(end int) {
+		if len(starts) > 0 {
+			current.Shares = append([]Share(nil), shares[starts[len(starts)-1]:end]...)
+			sequences = append(sequences, current)
+		}
+	}
+
+	for i := range shares {
+		share := &shares[i]
 		if err := share.Validate(); err != nil {
-			return sequences, err
+			return sequences, starts, newParseError(i, share, err)
+		}
+		if !config.skipSequenceValidation {
+			if _, err := parseInfoByteStrict(share.data[namespace.NamespaceSize], config.supportedShareVersions); err != nil {
+				return sequences, starts, newParseError(i, share, err)
+			}
 		}
 		isStart, err := share.IsSequenceStart()
 		if err != nil {
-			return sequences, err
+			return sequences, starts, newParseError(i, share, err)
+		}
+		// continuation shares only need their namespace decoded if it differs
+		// from the namespace of the sequence
+		if !isStart && len(starts) > 0 && share.CompareNamespace(current.Namespace) == 0 {
+			continue
 		}
 		ns, err := share.Namespace()
 		if err != nil {
-			return sequences, err
+			return sequences, starts, newParseError(i, share, fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err))
 		}
-		if isStart {
-			if len(currentSequence.Shares) > 0 {
-				sequences = append(sequences, currentSequence)
-			}
-			currentSequence = ShareSequence{
-				Shares:    []Share{share},
-				Namespace: ns,
-			}
-		} else {
-			if !bytes.Equal(currentSequence.Namespace.Bytes(), ns.Bytes()) {
-				return sequences, fmt.Errorf("share sequence %v has inconsistent namespace IDs with share %v", currentSequence, share)
+		if !isStart {
+			if len(starts) == 0 {
+				return sequences, starts, newParseError(i, share, ErrMissingSequenceStart)
 			}
-			currentSequence.Shares = append(currentSequence.Shares, share)
+			return sequences, starts, newParseError(i, share, fmt.Errorf("%w: share sequence has namespace %x but the share has namespace %x", ErrNamespaceMismatch, current.Namespace.Bytes(), ns.Bytes()))
 		}
+		closeSequence(i)
+		starts = append(starts, i)
+		current = ShareSequence{Namespace: ns}
 	}
+	closeSequence(len(shares))
+	return sequences, starts, nil
+}
 
-	if len(currentSequence.Shares) > 0 {
-		sequences = append(sequences, currentSequence)
+// validateSequence validates the sequence whose first share is at index start
+// of shares. The index of the returned *ParseError is relative to shares.
+func validateSequence(shares []Share, sequence ShareSequence, start int) error {
+	if index, err := sequence.validate(); err != nil {
+		return newParseError(start+index, &shares[start+index], err)
 	}
-
-	for _, sequence := range sequences {
-		if err := sequence.validSequenceLen(); err != nil {
-			return sequences, err
+	if isCompactShare(sequence.Namespace) {
+		if err := ValidateReservedBytes(sequence.Shares); err != nil {
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				parseErr.Index += start
+			}
+			return err
 		}
 	}
+	return nil
+}
 
+// filterPadding returns the sequences without the padding sequences if
+// ignorePadding is true.
+func filterPadding(shares []Share, sequences []ShareSequence, starts []int, ignorePadding bool) ([]ShareSequence, error) {
 	result := []ShareSequence{}
-	for _, sequence := range sequences {
+	for i, sequence := range sequences {
 		isPadding, err := sequence.isPadding()
 		if err != nil {
-			return nil, err
+			return nil, newParseError(starts[i], &shares[starts[i]], err)
 		}
 		if ignorePadding && isPadding {
 			continue
 		}
 		result = append(result, sequence)
 	}
-
 	return result, nil
 }
+
+// ParseSharesWithoutTailPadding is like ParseShares with ignorePadding set to
+// false but it strips the tail padding shares from the end of shares. It
+// returns the remaining sequences and the number of tail padding shares that
+// were removed. Tail padding shares that are followed by shares of another
+// namespace are a protocol violation and result in a *ParseError wrapping
+// ErrNamespaceOrder.
+func ParseSharesWithoutTailPadding(shares []Share, opts ...ParseOption) (sequences []ShareSequence, tailPadding int, err error) {
+	tailPaddingNamespace := namespace.TailPaddingNamespace.Bytes()
+	isTailPadding := func(i int) bool {
+		return bytes.Equal(shares[i].data[:namespace.NamespaceSize], tailPaddingNamespace)
+	}
+	start := len(shares)
+	for start > 0 && isTailPadding(start-1) {
+		start--
+	}
+	for i := 0; i < start; i++ {
+		if isTailPadding(i) {
+			return nil, 0, newParseError(i, &shares[i], fmt.Errorf("%w: tail padding share precedes shares of other namespaces", ErrNamespaceOrder))
+		}
+	}
+
+	sequences, err = ParseShares(shares, false, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	// the tail padding shares are parsed as well so that they are validated
+	for len(sequences) > 0 && sequences[len(sequences)-1].Namespace.Equals(namespace.TailPaddingNamespace) {
+		tailPadding += len(sequences[len(sequences)-1].Shares)
+		sequences = sequences[:len(sequences)-1]
+	}
+	return sequences, tailPadding, nil
+}
+
+// SequenceWindow is a share sequence, or the part of it, that lies within a
+// window of shares taken from a larger set of shares such as a data square.
+type SequenceWindow struct {
+	// Sequence holds the shares of the sequence that are in the window.
+	Sequence ShareSequence
+	// StartsBeforeWindow is true if the first share of the sequence precedes
+	// the window.
+	StartsBeforeWindow bool
+	// EndsAfterWindow is true if the sequence continues after the window. For
+	// a sequence that starts before the window and reaches the end of the
+	// window it is always true because its length is unknown.
+	EndsAfterWindow bool
+	// Range is the absolute, end exclusive range of the shares of the
+	// sequence that are in the window.
+	Range Range
+}
+
+// IsComplete returns true if the window contains every share of the sequence.
+func (w SequenceWindow) IsComplete() bool {
+	return !w.StartsBeforeWindow && !w.EndsAfterWindow
+}
+
+// ParseShareSequencesInWindow parses a window of shares whose first share is
+// at index firstAbsoluteIndex of a larger set of shares, e.g. a data square.
+// Unlike ParseShares, the window may begin with continuation shares of a
+// sequence and end before the last share of a sequence; such sequences are
+// returned with StartsBeforeWindow and EndsAfterWindow set respectively. The
+// sequences that are complete are validated like ParseShares does unless
+// WithoutSequenceValidation is provided. Errors are returned as a *ParseError
+// whose index is relative to shares.
+func ParseShareSequencesInWindow(shares []Share, firstAbsoluteIndex int, opts ...ParseOption) ([]SequenceWindow, error) {
+	if firstAbsoluteIndex < 0 {
+		return nil, fmt.Errorf("first absolute index %d must not be negative", firstAbsoluteIndex)
+	}
+	config := newParseConfig(opts)
+
+	windows := []SequenceWindow{}
+	// starts holds the index of the first share of each window
+	starts := []int{}
+	for i := range shares {
+		share := &shares[i]
+		if !config.skipSequenceValidation {
+			if _, err := parseInfoByteStrict(share.data[namespace.NamespaceSize], config.supportedShareVersions); err != nil {
+				return nil, newParseError(i, share, err)
+			}
+		}
+		isStart, err := share.IsSequenceStart()
+		if err != nil {
+			return nil, newParseError(i, share, err)
+		}
+		if isStart || i == 0 {
+			ns, err := share.Namespace()
+			if err != nil {
+				return nil, newParseError(i, share, fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err))
+			}
+			windows = append(windows, SequenceWindow{
+				Sequence:           ShareSequence{Namespace: ns},
+				StartsBeforeWindow: !isStart,
+			})
+			starts = append(starts, i)
+		}
+		current := &windows[len(windows)-1]
+		if share.CompareNamespace(current.Sequence.Namespace) != 0 {
+			return nil, newParseError(i, share, fmt.Errorf("%w: the sequence has namespace %x", ErrNamespaceMismatch, current.Sequence.Namespace.Bytes()))
+		}
+		current.Sequence.Shares = append(current.Sequence.Shares, *share)
+	}
+
+	for i := range windows {
+		window := &windows[i]
+		end := starts[i] + len(window.Sequence.Shares)
+		window.Range = NewRange(firstAbsoluteIndex+starts[i], firstAbsoluteIndex+end)
+		if window.StartsBeforeWindow {
+			window.EndsAfterWindow = end == len(shares)
+			continue
+		}
+
+		sharesNeeded, err := numberOfSharesNeeded(window.Sequence.Shares[0])
+		if err != nil {
+			return nil, newParseError(starts[i], &shares[starts[i]], err)
+		}
+		// padding shares have a sequence length of 0
+		sharesNeeded = max(sharesNeeded, 1)
+		// only the last sequence in the window can be cut off
+		if len(window.Sequence.Shares) < sharesNeeded && end == len(shares) {
+			window.EndsAfterWindow = true
+			continue
+		}
+		if config.skipSequenceValidation {
+			continue
+		}
+		if err := validateSequence(shares, window.Sequence, starts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return windows, nil
+}
+
+// ParseSharesLenient is like ParseShares but it does not stop at the first
+// error. Shares that are invalid, i.e. have a malformed namespace or an
+// unsupported share version, are skipped along with the continuation shares of
+// a sequence whose first share is invalid. Sequences that are missing shares,
+// whose sequence length doesn't match the number of shares, or whose namespace
+// is lower than the namespace of the previously parsed sequence are skipped as
+// well. It returns the sequences that were parsed successfully together with a
+// *ParseError for every share or sequence that was skipped.
+func ParseSharesLenient(shares []Share, ignorePadding bool) (sequences []ShareSequence, errs []error) {
+	sequences = []ShareSequence{}
+	var (
+		current *ShareSequence
+		// currentStart is the index of the first share of current
+		currentStart int
+		// broken is true if a share of current was skipped
+		broken bool
+		// skipping is true while continuation shares without a valid
+		// sequence start share are dropped
+		skipping bool
+		lastNs   *namespace.Namespace
+	)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		sequence := *current
+		current = nil
+		if broken {
+			errs = append(errs, newParseError(currentStart, &shares[currentStart], fmt.Errorf("%w: sequence contains a share that was skipped", ErrInvalidSequenceLen)))
+			return
+		}
+		if index, err := sequence.validate(); err != nil {
+			errs = append(errs, newParseError(currentStart+index, &shares[currentStart+index], err))
+			return
+		}
+		if lastNs != nil && sequence.Namespace.IsLessThan(*lastNs) {
+			errs = append(errs, newParseError(currentStart, &shares[currentStart], fmt.Errorf("%w: namespace is lower than the namespace %x of the previous sequence", ErrNamespaceOrder, lastNs.Bytes())))
+			return
+		}
+		lastNs = &sequence.Namespace
+		isPadding, err := sequence.isPadding()
+		if err != nil {
+			errs = append(errs, newParseError(currentStart, &shares[currentStart], err))
+			return
+		}
+		if ignorePadding && isPadding {
+			return
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	for i, share := range shares {
+		isStart, err := share.IsSequenceStart()
+		if err != nil {
+			errs = append(errs, newParseError(i, &shares[i], err))
+			continue
+		}
+		if err := share.validateFull(); err != nil {
+			errs = append(errs, newParseError(i, &shares[i], err))
+			if isStart {
+				flush()
+				skipping = true
+			} else if current != nil {
+				broken = true
+			}
+			continue
+		}
+		ns, err := share.Namespace()
+		if err != nil {
+			errs = append(errs, newParseError(i, &shares[i], err))
+			continue
+		}
+
+		if isStart {
+			flush()
+			current = &ShareSequence{Namespace: ns, Shares: []Share{share}}
+			currentStart = i
+			broken = false
+			skipping = false
+			continue
+		}
+
+		switch {
+		case skipping:
+		case current == nil:
+			errs = append(errs, newParseError(i, &shares[i], ErrMissingSequenceStart))
+			skipping = true
+		case !current.Namespace.Equals(ns):
+			errs = append(errs, newParseError(i, &shares[i], fmt.Errorf("%w: the sequence has namespace %x", ErrNamespaceMismatch, current.Namespace.Bytes())))
+			broken = true
+		default:
+			current.Shares = append(current.Shares, share)
+		}
+	}
+	flush()
+
+	return sequences, errs
+}
+
+// WalkSequences groups the provided shares into sequences and calls fn once
+// per sequence with its namespace and the sub-slice of shares that make up the
+// sequence. Padding shares are skipped. It returns an error if a continuation
+// share is not preceded by the start of a sequence in the same namespace, if
+// the number of shares in a sequence doesn't match its sequence length, or if
+// fn returns an error, in which case walking stops.
+func WalkSequences(shares []Share, fn func(ns namespace.Namespace, shares []Share) error) error {
+	start := -1
+	var currentNs namespace.Namespace
+
+	flush := func(end int) error {
+		if start < 0 {
+			return nil
+		}
+		sequence := ShareSequence{Namespace: currentNs, Shares: shares[start:end]}
+		if err := sequence.validSequenceLen(); err != nil {
+			return newParseError(start, &shares[start], err)
+		}
+		isPadding, err := sequence.isPadding()
+		if err != nil {
+			return newParseError(start, &shares[start], err)
+		}
+		if isPadding {
+			return nil
+		}
+		return fn(currentNs, sequence.Shares)
+	}
+
+	for i, share := range shares {
+		if err := share.Validate(); err != nil {
+			return newParseError(i, &shares[i], err)
+		}
+		isStart, err := share.IsSequenceStart()
+		if err != nil {
+			return newParseError(i, &shares[i], err)
+		}
+		ns, err := share.Namespace()
+		if err != nil {
+			return newParseError(i, &shares[i], fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err))
+		}
+		if !isStart {
+			if start < 0 {
+				return newParseError(i, &shares[i], ErrMissingSequenceStart)
+			}
+			if !currentNs.Equals(ns) {
+				return newParseError(i, &shares[i], fmt.Errorf("%w: the sequence has namespace %s", ErrNamespaceMismatch, currentNs))
+			}
+			continue
+		}
+		if err := flush(i); err != nil {
+			return err
+		}
+		start = i
+		currentNs = ns
+	}
+	return flush(len(shares))
+}
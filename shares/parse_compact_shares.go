@@ -1,5 +1,11 @@
 package shares
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
 // parseCompactShares returns data (transactions or intermediate state roots
 // based on the contents of rawShares and supportedShareVersions. If rawShares
 // contains a share with a version that isn't present in supportedShareVersions,
@@ -7,6 +13,24 @@ package shares
 // info bytes, data length delimiter, or unit length delimiters and are ready to
 // be unmarshalled.
 func parseCompactShares(shares []Share, supportedShareVersions []uint8) (data [][]byte, err error) {
+	positions, err := parseCompactSharePositions(shares, supportedShareVersions)
+	if err != nil {
+		return nil, err
+	}
+	if positions == nil {
+		return nil, nil
+	}
+
+	data = make([][]byte, len(positions))
+	for i, position := range positions {
+		data[i] = position.Tx
+	}
+	return data, nil
+}
+
+// parseCompactSharePositions is like parseCompactShares but it also returns
+// the location of every unit in the shares.
+func parseCompactSharePositions(shares []Share, supportedShareVersions []uint8) (positions []TxPosition, err error) {
 	if len(shares) == 0 {
 		return nil, nil
 	}
@@ -16,17 +40,43 @@ func parseCompactShares(shares []Share, supportedShareVersions []uint8) (data []
 		return nil, err
 	}
 
-	rawData, err := extractRawData(shares)
+	rawData, shareOffsets, dataStarts, err := extractRawData(shares)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err = parseRawData(rawData)
+	// shareAt returns the index of the share that contains the byte at
+	// offset in rawData and the index of that byte in the share.
+	shareAt := func(offset int) (shareIndex, byteIndex int) {
+		shareIndex = sort.Search(len(shareOffsets), func(i int) bool { return shareOffsets[i] > offset }) - 1
+		return shareIndex, dataStarts[shareIndex] + offset - shareOffsets[shareIndex]
+	}
+
+	sequenceLen, err := rawSequenceLen(&shares[0], dataStarts[0])
 	if err != nil {
-		return nil, err
+		return nil, newParseError(0, &shares[0], err)
+	}
+	units, spans, err := parseRawData(rawData, sequenceLen)
+	if err != nil {
+		var unitErr *unitError
+		if !errors.As(err, &unitErr) {
+			return nil, err
+		}
+		shareIndex, byteIndex := shareAt(unitErr.offset)
+		return nil, newParseError(shareIndex, &shares[shareIndex], fmt.Errorf("%w at byte %d", unitErr.err, byteIndex))
 	}
 
-	return data, nil
+	positions = make([]TxPosition, len(units))
+	for i, unit := range units {
+		startShare, startByte := shareAt(spans[i].start)
+		endShare, _ := shareAt(spans[i].end - 1)
+		positions[i] = TxPosition{
+			Tx:         unit,
+			ShareRange: NewRange(startShare, endShare+1),
+			StartByte:  startByte,
+		}
+	}
+	return positions, nil
 }
 
 // validateShareVersions returns an error if the shares contain a share with an
@@ -35,41 +85,102 @@ func parseCompactShares(shares []Share, supportedShareVersions []uint8) (data []
 func validateShareVersions(shares []Share, supportedShareVersions []uint8) error {
 	for i := 0; i < len(shares); i++ {
 		if err := shares[i].DoesSupportVersions(supportedShareVersions); err != nil {
-			return err
+			return newParseError(i, &shares[i], err)
 		}
 	}
 	return nil
 }
 
+// unitSpan is the location of a unit in raw data. Start is the offset of the
+// unit length delimiter and end is the offset right after the unit.
+type unitSpan struct {
+	start, end int
+}
+
+// unitError is an error about the unit whose delimiter is at offset in raw
+// data.
+type unitError struct {
+	offset int
+	err    error
+}
+
+func (e *unitError) Error() string {
+	return fmt.Sprintf("unit at offset %d: %v", e.offset, e.err)
+}
+
+func (e *unitError) Unwrap() error {
+	return e.err
+}
+
+// rawSequenceLen returns the number of bytes of raw data, starting at index
+// dataStart of the first share, that belong to the sequence. It returns -1 if
+// the first share doesn't start the sequence because the length of the
+// sequence is unknown then.
+func rawSequenceLen(first *Share, dataStart int) (int, error) {
+	isStart, err := first.IsSequenceStart()
+	if err != nil {
+		return 0, err
+	}
+	if !isStart {
+		return -1, nil
+	}
+	sequenceLen, err := first.SequenceLen()
+	if err != nil {
+		return 0, err
+	}
+	skipped := dataStart - (ShareSize - FirstCompactShareContentSize)
+	return max(int(sequenceLen)-skipped, 0), nil
+}
+
 // parseRawData returns the units (transactions, PFB transactions, intermediate
 // state roots) contained in raw data by parsing the unit length delimiter
-// prefixed to each unit.
-func parseRawData(rawData []byte) (units [][]byte, err error) {
+// prefixed to each unit. It also returns the span of every unit in rawData.
+// If sequenceLen isn't negative, it is the number of bytes of rawData that
+// belong to the sequence: the rest is padding and a unit that doesn't fit in
+// the sequence results in ErrTruncatedUnit. Raw data that ends within a unit is
+// otherwise assumed to come from a subset of the shares of the sequence, so
+// the incomplete unit is ignored.
+func parseRawData(rawData []byte, sequenceLen int) (units [][]byte, spans []unitSpan, err error) {
 	units = make([][]byte, 0)
+	offset := 0
 	for {
+		if sequenceLen >= 0 && offset >= sequenceLen {
+			return units, spans, nil
+		}
 		actualData, unitLen, err := ParseDelimiter(rawData)
 		if err != nil {
-			return nil, err
+			return nil, nil, &unitError{offset: offset, err: err}
 		}
 		// the rest of raw data is padding
 		if unitLen == 0 {
-			return units, nil
+			return units, spans, nil
+		}
+		dataStart := offset + len(rawData) - len(actualData)
+		if sequenceLen >= 0 && unitLen > uint64(max(sequenceLen-dataStart, 0)) {
+			return nil, nil, &unitError{offset: offset, err: fmt.Errorf("%w: %d bytes declared but %d bytes left", ErrTruncatedUnit, unitLen, max(sequenceLen-dataStart, 0))}
 		}
 		// the rest of actual data contains only part of the next transaction so
 		// we stop parsing raw data
 		if unitLen > uint64(len(actualData)) {
-			return units, nil
+			return units, spans, nil
 		}
-		rawData = actualData[unitLen:]
+		end := dataStart + int(unitLen)
+		spans = append(spans, unitSpan{start: offset, end: end})
 		units = append(units, actualData[:unitLen])
+		rawData = actualData[unitLen:]
+		offset = end
 	}
 }
 
 // extractRawData returns the raw data representing complete transactions
 // contained in the shares. The raw data does not contain the namespace, info
 // byte, sequence length, or reserved bytes. Starts reading raw data based on
-// the reserved bytes in the first share.
-func extractRawData(shares []Share) (rawData []byte, err error) {
+// the reserved bytes in the first share. For every share it also returns the
+// offset in rawData at which the data of the share begins and the index in
+// the share at which that data is located.
+func extractRawData(shares []Share) (rawData []byte, shareOffsets, dataStarts []int, err error) {
+	shareOffsets = make([]int, len(shares))
+	dataStarts = make([]int, len(shares))
 	for i := 0; i < len(shares); i++ {
 		var raw []byte
 		if i == 0 {
@@ -78,9 +189,11 @@ func extractRawData(shares []Share) (rawData []byte, err error) {
 			raw, err = shares[i].RawData()
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, newParseError(i, &shares[i], err)
 		}
+		shareOffsets[i] = len(rawData)
+		dataStarts[i] = ShareSize - len(raw)
 		rawData = append(rawData, raw...)
 	}
-	return rawData, nil
+	return rawData, shareOffsets, dataStarts, nil
 }
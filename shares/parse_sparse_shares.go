@@ -1,7 +1,6 @@
 package shares
 
 import (
-	"bytes"
 	"fmt"
 
 	"github.com/celestiaorg/go-square/blob"
@@ -10,29 +9,46 @@ import (
 type sequence struct {
 	blob        *blob.Blob
 	sequenceLen uint32
+	// startIndex is the index of the first share of the sequence
+	startIndex int
+	// endIndex is the index after the last share of the sequence
+	endIndex int
 }
 
 // parseSparseShares iterates through rawShares and parses out individual
 // blobs. It returns an error if a rawShare contains a share version that
 // isn't present in supportedShareVersions.
 func parseSparseShares(shares []Share, supportedShareVersions []uint8) (blobs []*blob.Blob, err error) {
+	sequences, err := parseSparseSequences(shares, supportedShareVersions)
+	if err != nil {
+		return nil, err
+	}
+	for _, sequence := range sequences {
+		blobs = append(blobs, sequence.blob)
+	}
+	return blobs, nil
+}
+
+// parseSparseSequences is like parseSparseShares but it returns the sequences
+// the blobs were parsed from. Padding shares are skipped.
+func parseSparseSequences(shares []Share, supportedShareVersions []uint8) ([]sequence, error) {
 	if len(shares) == 0 {
 		return nil, nil
 	}
 	sequences := make([]sequence, 0)
 
-	for _, share := range shares {
+	for i, share := range shares {
 		version, err := share.Version()
 		if err != nil {
-			return nil, err
+			return nil, newParseError(i, &shares[i], err)
 		}
-		if !bytes.Contains(supportedShareVersions, []byte{version}) {
-			return nil, fmt.Errorf("unsupported share version %v is not present in supported share versions %v", version, supportedShareVersions)
+		if err := checkShareVersion(version, supportedShareVersions); err != nil {
+			return nil, newParseError(i, &shares[i], err)
 		}
 
 		isPadding, err := share.IsPadding()
 		if err != nil {
-			return nil, err
+			return nil, newParseError(i, &shares[i], err)
 		}
 		if isPadding {
 			continue
@@ -40,44 +56,56 @@ func parseSparseShares(shares []Share, supportedShareVersions []uint8) (blobs []
 
 		isStart, err := share.IsSequenceStart()
 		if err != nil {
-			return nil, err
+			return nil, newParseError(i, &shares[i], err)
 		}
 
 		if isStart {
 			sequenceLen, err := share.SequenceLen()
 			if err != nil {
-				return nil, err
+				return nil, newParseError(i, &shares[i], err)
 			}
 			data, err := share.RawData()
 			if err != nil {
-				return nil, err
+				return nil, newParseError(i, &shares[i], err)
 			}
 			ns, err := share.Namespace()
 			if err != nil {
-				return nil, err
+				return nil, newParseError(i, &shares[i], fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err))
 			}
 			blob := blob.New(ns, data, version)
+			if version == ShareVersionOne {
+				signer, err := share.Signer()
+				if err != nil {
+					return nil, newParseError(i, &shares[i], err)
+				}
+				blob.Signer = signer
+			}
 			sequences = append(sequences, sequence{
 				blob:        blob,
 				sequenceLen: sequenceLen,
+				startIndex:  i,
+				endIndex:    i + 1,
 			})
 		} else { // continuation share
 			if len(sequences) == 0 {
-				return nil, fmt.Errorf("continuation share %v without a sequence start share", share)
+				return nil, newParseError(i, &shares[i], ErrMissingSequenceStart)
 			}
 			prev := &sequences[len(sequences)-1]
 			data, err := share.RawData()
 			if err != nil {
-				return nil, err
+				return nil, newParseError(i, &shares[i], err)
 			}
 			prev.blob.Data = append(prev.blob.Data, data...)
+			prev.endIndex = i + 1
 		}
 	}
 	for _, sequence := range sequences {
+		if int(sequence.sequenceLen) > len(sequence.blob.Data) {
+			return nil, newParseError(sequence.startIndex, &shares[sequence.startIndex], fmt.Errorf("%w: sequence length %d exceeds the %d bytes of data in the sequence", ErrInvalidSequenceLen, sequence.sequenceLen, len(sequence.blob.Data)))
+		}
 		// trim any padding from the end of the sequence
 		sequence.blob.Data = sequence.blob.Data[:sequence.sequenceLen]
-		blobs = append(blobs, sequence.blob)
 	}
 
-	return blobs, nil
+	return sequences, nil
 }
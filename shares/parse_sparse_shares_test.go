@@ -149,7 +149,8 @@ func Test_parseSparseSharesWithNamespacedPadding(t *testing.T) {
 	err = sss.WriteNamespacePaddingShares(10)
 	require.NoError(t, err)
 
-	shares := sss.Export()
+	shares, err := sss.Export()
+	require.NoError(t, err)
 	pblobs, err := parseSparseShares(shares, SupportedShareVersions)
 	require.NoError(t, err)
 	require.Equal(t, blobs, pblobs)
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,9 +33,6 @@ func TestParseShares(t *testing.T) {
 	blobTwoStart := blobTwoShares[0]
 	blobTwoContinuation := blobTwoShares[1]
 
-	// invalidShare is longer than the length of a valid share
-	invalidShare := Share{data: append(generateRawShare(t, ns1, true, 1), []byte{0}...)}
-
 	// tooLargeSequenceLen is a single share with too large of a sequence len
 	// because it takes more than one share to store a sequence of 1000 bytes
 	tooLargeSequenceLen := generateRawShare(t, ns1, true, uint32(1000))
@@ -116,13 +115,6 @@ func TestParseShares(t *testing.T) {
 			},
 			expectErr: false,
 		},
-		{
-			name:          "one share with invalid size",
-			shares:        []Share{invalidShare},
-			ignorePadding: false,
-			want:          []ShareSequence{},
-			expectErr:     true,
-		},
 		{
 			name:          "blob one start followed by blob two continuation",
 			shares:        []Share{blobOneStart, blobTwoContinuation},
@@ -132,7 +124,7 @@ func TestParseShares(t *testing.T) {
 		},
 		{
 			name:          "one share with too large sequence length",
-			shares:        []Share{{data: tooLargeSequenceLen}},
+			shares:        []Share{shareFromBytes(tooLargeSequenceLen)},
 			ignorePadding: false,
 			want:          []ShareSequence{},
 			expectErr:     true,
@@ -240,3 +232,581 @@ func generateRandomTxs(count, size int) [][]byte {
 	}
 	return txs
 }
+
+func TestWalkSequences(t *testing.T) {
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	txShares, _, _, err := SplitTxs([][]byte{bytes.Repeat([]byte{0xf}, 600)})
+	require.NoError(t, err)
+	blob1Shares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xf}, 2*ShareSize), ShareVersionZero))
+	require.NoError(t, err)
+	blob2Shares, err := SplitBlobs(blob.New(ns2, []byte{0xf}, ShareVersionZero))
+	require.NoError(t, err)
+	nsPadding, err := NamespacePaddingShares(ns1, ShareVersionZero, 2)
+	require.NoError(t, err)
+
+	square := append([]Share{}, txShares...)
+	square = append(square, ReservedPaddingShare())
+	square = append(square, blob1Shares...)
+	square = append(square, nsPadding...)
+	square = append(square, blob2Shares...)
+	square = append(square, TailPaddingShares(3)...)
+
+	type sequence struct {
+		ns     namespace.Namespace
+		shares []Share
+	}
+	var got []sequence
+	err = WalkSequences(square, func(ns namespace.Namespace, shares []Share) error {
+		got = append(got, sequence{ns: ns, shares: shares})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []sequence{
+		{ns: namespace.TxNamespace, shares: txShares},
+		{ns: ns1, shares: blob1Shares},
+		{ns: ns2, shares: blob2Shares},
+	}, got)
+
+	t.Run("stops when fn returns an error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := WalkSequences(square, func(namespace.Namespace, []Share) error {
+			calls++
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("continuation share without start", func(t *testing.T) {
+		err := WalkSequences(blob1Shares[1:], func(namespace.Namespace, []Share) error { return nil })
+		assert.Error(t, err)
+	})
+
+	t.Run("continuation share with different namespace", func(t *testing.T) {
+		shares := append([]Share{blob2Shares[0]}, blob1Shares[1:]...)
+		err := WalkSequences(shares, func(namespace.Namespace, []Share) error { return nil })
+		assert.Error(t, err)
+	})
+
+	t.Run("sequence missing shares", func(t *testing.T) {
+		err := WalkSequences(blob1Shares[:2], func(namespace.Namespace, []Share) error { return nil })
+		assert.Error(t, err)
+	})
+}
+
+func TestParseErrorIdentifiesShare(t *testing.T) {
+	const squareSize = 64 * 64
+	const corruptIndex = 4093
+
+	// generateSquare returns the shares of a square with transactions, a blob
+	// and tail padding.
+	generateSquare := func() []Share {
+		txShares, _, _, err := SplitTxs(generateRandomTxs(10, 200))
+		require.NoError(t, err)
+		blobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, 10000))
+		require.NoError(t, err)
+		square := append(txShares, blobShares...)
+		return append(square, TailPaddingShares(squareSize-len(square))...)
+	}
+
+	t.Run("invalid sequence length", func(t *testing.T) {
+		square := generateSquare()
+		square[corruptIndex] = shareWithData(ns1, true, 10000, []byte{1})
+
+		_, err := ParseShares(square, false)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, corruptIndex, parseErr.Index)
+		assert.Equal(t, ns1, parseErr.Namespace)
+		assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+		assert.Contains(t, err.Error(), "share 4093")
+	})
+
+	t.Run("unsupported share version", func(t *testing.T) {
+		square := generateSquare()
+		square[corruptIndex].data[namespace.NamespaceSize] = 0xFE
+
+		_, err := ParseBlobs(square)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, corruptIndex, parseErr.Index)
+		assert.Equal(t, namespace.TailPaddingNamespace, parseErr.Namespace)
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+		_, err = ParseTxs(square[corruptIndex-1:])
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, 1, parseErr.Index)
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	})
+
+	t.Run("unsupported share version without sequence validation", func(t *testing.T) {
+		square := generateSquare()
+		// version 64, continuation share of the tail padding sequence
+		square[corruptIndex].data[namespace.NamespaceSize] = 0x80
+
+		_, err := ParseShares(square, false)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, corruptIndex, parseErr.Index)
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+		_, err = ParseShares(square, false, WithoutSequenceValidation())
+		assert.NoError(t, err)
+	})
+
+	t.Run("continuation share without sequence start", func(t *testing.T) {
+		square := generateSquare()
+		square[corruptIndex] = shareWithData(ns1, false, 0, []byte{1})
+
+		_, err := ParseShares(square, false)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, corruptIndex, parseErr.Index)
+		assert.ErrorIs(t, err, ErrNamespaceMismatch)
+
+		_, err = ParseShares(square[corruptIndex:], false)
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, 0, parseErr.Index)
+		assert.ErrorIs(t, err, ErrMissingSequenceStart)
+	})
+}
+
+func TestParseSharesLenient(t *testing.T) {
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+	blobShares := func(ns namespace.Namespace) []Share {
+		shares, err := SplitBlobs(generateRandomBlobWithNamespace(ns, 1000))
+		require.NoError(t, err)
+		return shares
+	}
+	blob1, blob2, blob3 := blobShares(ns1), blobShares(ns2), blobShares(ns3)
+	concat := func(shares ...[]Share) (result []Share) {
+		for _, s := range shares {
+			result = append(result, s...)
+		}
+		return result
+	}
+	sequence := func(ns namespace.Namespace, shares []Share) ShareSequence {
+		return ShareSequence{Namespace: ns, Shares: shares}
+	}
+
+	type wantErr struct {
+		index int
+		kind  error
+	}
+	type testCase struct {
+		name          string
+		shares        []Share
+		wantSequences []ShareSequence
+		wantErrs      []wantErr
+	}
+	testCases := []testCase{
+		{
+			name:          "valid shares",
+			shares:        concat(blob1, blob2, TailPaddingShares(1)),
+			wantSequences: []ShareSequence{sequence(ns1, blob1), sequence(ns2, blob2)},
+		},
+		{
+			name:          "bad namespace ordering",
+			shares:        concat(blob2, blob1, blob3),
+			wantSequences: []ShareSequence{sequence(ns2, blob2), sequence(ns3, blob3)},
+			wantErrs:      []wantErr{{3, ErrNamespaceOrder}},
+		},
+		{
+			name: "corrupted info byte",
+			shares: func() []Share {
+				shares := concat(blob1, blob2, blob3)
+				shares[4].data[namespace.NamespaceSize] = 0xFE
+				return shares
+			}(),
+			wantSequences: []ShareSequence{sequence(ns1, blob1), sequence(ns3, blob3)},
+			wantErrs:      []wantErr{{4, ErrUnsupportedShareVersion}, {3, ErrInvalidSequenceLen}},
+		},
+		{
+			name:          "declared sequence length exceeds the available shares",
+			shares:        concat(blob1, blob2[:2], blob3),
+			wantSequences: []ShareSequence{sequence(ns1, blob1), sequence(ns3, blob3)},
+			wantErrs:      []wantErr{{3, ErrInvalidSequenceLen}},
+		},
+		{
+			name:          "continuation shares without a sequence start",
+			shares:        concat(blob1[1:], blob2),
+			wantSequences: []ShareSequence{sequence(ns2, blob2)},
+			wantErrs:      []wantErr{{0, ErrMissingSequenceStart}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sequences, errs := ParseSharesLenient(tc.shares, true)
+			assert.Equal(t, tc.wantSequences, sequences)
+			require.Len(t, errs, len(tc.wantErrs))
+			for i, want := range tc.wantErrs {
+				var parseErr *ParseError
+				require.ErrorAs(t, errs[i], &parseErr)
+				assert.Equal(t, want.index, parseErr.Index)
+				assert.ErrorIs(t, errs[i], want.kind)
+			}
+		})
+	}
+
+	// the strict mode remains the default
+	_, err := ParseShares(concat(blob1, blob2[:2], blob3), true)
+	assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+}
+
+func TestParseUnsupportedShareVersion(t *testing.T) {
+	blobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, 1000))
+	require.NoError(t, err)
+	// craft a share with share version 5 that otherwise looks like the first
+	// share of the blob
+	blobShares[0].data[namespace.NamespaceSize] = 5<<1 | 1
+
+	_, err = ParseShares(blobShares, false)
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 0, parseErr.Index)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	assert.ErrorContains(t, err, "5 is not present")
+
+	_, err = ParseBlobs(blobShares)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 100))
+	require.NoError(t, err)
+	txShares[0].data[namespace.NamespaceSize] = 5<<1 | 1
+	_, err = ParseTxs(txShares)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+}
+
+func TestParseWithSupportedShareVersions(t *testing.T) {
+	v1Blob := blob.New(ns1, bytes.Repeat([]byte{0xf}, 1000), ShareVersionOne)
+	v1Blob.Signer = bytes.Repeat([]byte{0xaa}, SignerSize)
+	v1Shares, err := SplitBlobs(v1Blob)
+	require.NoError(t, err)
+
+	_, err = ParseBlobs(v1Shares)
+	require.NoError(t, err)
+	_, err = ParseShares(v1Shares, false)
+	require.NoError(t, err)
+
+	onlyV0 := WithSupportedShareVersions(ShareVersionZero)
+	_, err = ParseBlobs(v1Shares, onlyV0)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	_, err = ParseShares(v1Shares, false, onlyV0)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	// versions that aren't supported by the package can't be allowed
+	_, err = ParseShares(v1Shares, false, WithSupportedShareVersions(ShareVersionOne, 5))
+	require.NoError(t, err)
+	v1Shares[0].data[namespace.NamespaceSize] = 5<<1 | 1
+	_, err = ParseShares(v1Shares, false, WithSupportedShareVersions(ShareVersionOne, 5))
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 100))
+	require.NoError(t, err)
+	_, err = ParseTxs(txShares, onlyV0)
+	require.NoError(t, err)
+	_, err = ParseTxs(txShares, WithSupportedShareVersions(ShareVersionOne))
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+}
+
+func TestParseSharesWithoutTailPadding(t *testing.T) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 200))
+	require.NoError(t, err)
+	blobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, 1000))
+	require.NoError(t, err)
+	data := append(append([]Share{}, txShares...), blobShares...)
+	concat := func(shares ...[]Share) (result []Share) {
+		for _, s := range shares {
+			result = append(result, s...)
+		}
+		return result
+	}
+
+	type testCase struct {
+		name            string
+		shares          []Share
+		wantSequences   int
+		wantTailPadding int
+		wantErr         error
+	}
+	testCases := []testCase{
+		{"no shares", nil, 0, 0, nil},
+		{"only tail padding", TailPaddingShares(16), 0, 16, nil},
+		{"no tail padding", data, 2, 0, nil},
+		{"data followed by tail padding", concat(data, TailPaddingShares(7)), 2, 7, nil},
+		{"reserved padding is not stripped", concat(txShares, ReservedPaddingShares(2), blobShares, TailPaddingShares(1)), 4, 1, nil},
+		{"tail padding before data", concat(txShares, TailPaddingShares(1), blobShares), 0, 0, ErrNamespaceOrder},
+		{"tail padding at the start and the end", concat(TailPaddingShares(2), data, TailPaddingShares(2)), 0, 0, ErrNamespaceOrder},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sequences, tailPadding, err := ParseSharesWithoutTailPadding(tc.shares)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				assert.Equal(t, namespace.TailPaddingNamespace, parseErr.Namespace)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, sequences, tc.wantSequences)
+			assert.Equal(t, tc.wantTailPadding, tailPadding)
+			for _, sequence := range sequences {
+				assert.False(t, sequence.Namespace.Equals(namespace.TailPaddingNamespace))
+			}
+		})
+	}
+
+	t.Run("invalid tail padding share", func(t *testing.T) {
+		shares := concat(data, TailPaddingShares(2))
+		shares[len(shares)-1].data[namespace.NamespaceSize] = 5<<1 | 1
+		_, _, err := ParseSharesWithoutTailPadding(shares)
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	})
+}
+
+func TestParseShareSequencesInWindow(t *testing.T) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(5, 200))
+	require.NoError(t, err)
+	blobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns1, 1000))
+	require.NoError(t, err)
+	require.Len(t, blobShares, 3)
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	otherBlobShares, err := SplitBlobs(generateRandomBlobWithNamespace(ns2, 100))
+	require.NoError(t, err)
+	var square []Share
+	for _, shares := range [][]Share{txShares, blobShares, otherBlobShares, TailPaddingShares(2)} {
+		square = append(square, shares...)
+	}
+	blobStart := len(txShares)
+	const offset = 2000
+
+	type wantWindow struct {
+		namespace          namespace.Namespace
+		startsBeforeWindow bool
+		endsAfterWindow    bool
+		shareRange         Range
+	}
+	testCases := []struct {
+		name   string
+		window Range
+		want   []wantWindow
+	}{
+		{
+			name:   "window beginning mid-sequence",
+			window: NewRange(blobStart+1, blobStart+4),
+			want: []wantWindow{
+				{ns1, true, false, NewRange(offset, offset+2)},
+				{ns2, false, false, NewRange(offset+2, offset+3)},
+			},
+		},
+		{
+			name:   "window ending mid-sequence",
+			window: NewRange(0, blobStart+2),
+			want: []wantWindow{
+				{namespace.TxNamespace, false, false, NewRange(offset, offset+blobStart)},
+				{ns1, false, true, NewRange(offset+blobStart, offset+blobStart+2)},
+			},
+		},
+		{
+			name:   "window with exactly one sequence",
+			window: NewRange(blobStart, blobStart+3),
+			want: []wantWindow{
+				{ns1, false, false, NewRange(offset, offset+3)},
+			},
+		},
+		{
+			name:   "window within one sequence",
+			window: NewRange(blobStart+1, blobStart+2),
+			want: []wantWindow{
+				{ns1, true, true, NewRange(offset, offset+1)},
+			},
+		},
+		{
+			name:   "window ending with tail padding",
+			window: NewRange(blobStart+3, len(square)),
+			want: []wantWindow{
+				{ns2, false, false, NewRange(offset, offset+1)},
+				{namespace.TailPaddingNamespace, false, false, NewRange(offset+1, offset+2)},
+				{namespace.TailPaddingNamespace, false, false, NewRange(offset+2, offset+3)},
+			},
+		},
+		{
+			name:   "empty window",
+			window: NewRange(0, 0),
+			want:   []wantWindow{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shares := square[tc.window.Start:tc.window.End]
+			got, err := ParseShareSequencesInWindow(shares, offset)
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.want))
+			for i, want := range tc.want {
+				assert.Equal(t, want.namespace, got[i].Sequence.Namespace, "window %d", i)
+				assert.Equal(t, want.startsBeforeWindow, got[i].StartsBeforeWindow, "window %d", i)
+				assert.Equal(t, want.endsAfterWindow, got[i].EndsAfterWindow, "window %d", i)
+				assert.Equal(t, want.shareRange, got[i].Range, "window %d", i)
+				assert.Equal(t, !want.startsBeforeWindow && !want.endsAfterWindow, got[i].IsComplete(), "window %d", i)
+				assert.Equal(t, shares[want.shareRange.Start-offset:want.shareRange.End-offset], got[i].Sequence.Shares, "window %d", i)
+			}
+		})
+	}
+
+	t.Run("sequence missing a share within the window", func(t *testing.T) {
+		shares := append(append([]Share{}, blobShares[:2]...), otherBlobShares...)
+		_, err := ParseShareSequencesInWindow(shares, offset)
+		assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+	})
+	t.Run("namespace changes without a sequence start", func(t *testing.T) {
+		shares := append(append([]Share{}, txShares...), blobShares[1:]...)
+		_, err := ParseShareSequencesInWindow(shares, offset)
+		assert.ErrorIs(t, err, ErrNamespaceMismatch)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, len(txShares), parseErr.Index)
+	})
+	t.Run("negative first absolute index", func(t *testing.T) {
+		_, err := ParseShareSequencesInWindow(square, -1)
+		assert.Error(t, err)
+	})
+}
+
+// generateFullSquare returns a square of squareSize * squareSize shares that
+// holds transactions and many small blobs followed by tail padding.
+func generateFullSquare(t testing.TB, squareSize int) []Share {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(100, 200))
+	require.NoError(t, err)
+	total := squareSize * squareSize
+	blobs := make([]*blob.Blob, 0)
+	for count := len(txShares); count < total-10; count += 2 {
+		id := make([]byte, namespace.NamespaceVersionZeroIDSize)
+		id[0] = 1
+		binary.BigEndian.PutUint32(id[len(id)-4:], uint32(len(blobs)))
+		blobs = append(blobs, generateRandomBlobWithNamespace(namespace.MustNewV0(id), FirstSparseShareContentSize+100))
+	}
+	blobShares, err := SplitBlobs(blobs...)
+	require.NoError(t, err)
+	square := append(txShares, blobShares...)
+	return append(square, TailPaddingShares(total-len(square))...)
+}
+
+func TestParseSharesParallel(t *testing.T) {
+	square := generateFullSquare(t, 32)
+
+	for _, ignorePadding := range []bool{false, true} {
+		want, err := ParseShares(square, ignorePadding)
+		require.NoError(t, err)
+		got, err := ParseSharesParallel(square, ignorePadding)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	corrupt := func(indexes ...int) []Share {
+		corrupted := append([]Share(nil), square...)
+		for _, i := range indexes {
+			corrupted[i].data[namespace.NamespaceSize+ShareInfoBytes]++
+		}
+		return corrupted
+	}
+	invalidNamespace := corrupt()
+	invalidNamespace[len(invalidNamespace)-1].data[0] = 0xFE
+
+	testCases := []struct {
+		name   string
+		shares []Share
+	}{
+		{"invalid sequence lengths", corrupt(len(square)/2, len(square)/4, len(square)/3)},
+		{"invalid sequence length and namespace", append(corrupt(len(square)/4), invalidNamespace[len(square)/4+1:]...)},
+		{"invalid namespace", invalidNamespace},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wantSequences, wantErr := ParseShares(tc.shares, false)
+			require.Error(t, wantErr)
+			gotSequences, gotErr := ParseSharesParallel(tc.shares, false)
+			assert.Equal(t, wantErr, gotErr)
+			assert.Equal(t, wantSequences, gotSequences)
+		})
+	}
+}
+
+func BenchmarkParseShares(b *testing.B) {
+	square := generateFullSquare(b, 128)
+	benchmarks := []struct {
+		name  string
+		parse func([]Share, bool, ...ParseOption) ([]ShareSequence, error)
+	}{
+		{"sequential", ParseShares},
+		{"parallel", ParseSharesParallel},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.parse(square, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBlobsWithPositions(t *testing.T) {
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	blobA := generateRandomBlobWithNamespace(ns1, FirstSparseShareContentSize+1)
+	blobB := generateRandomBlobWithNamespace(ns2, 10)
+	blobC := generateRandomBlobWithNamespace(ns2, FirstSparseShareContentSize+2*ContinuationSparseShareContentSize)
+
+	// blob A spans shares [0, 2), namespace padding [2, 4), blob B [4, 5),
+	// namespace padding [5, 6), blob C [6, 9) and tail padding [9, 11)
+	var input []Share
+	input = append(input, mustSplitBlobs(t, blobA)...)
+	input = append(input, mustNamespacePaddingShares(t, ns1, 2)...)
+	input = append(input, mustSplitBlobs(t, blobB)...)
+	input = append(input, mustNamespacePaddingShares(t, ns2, 1)...)
+	input = append(input, mustSplitBlobs(t, blobC)...)
+	input = append(input, TailPaddingShares(2)...)
+	require.Len(t, input, 11)
+
+	positions, err := ParseBlobsWithPositions(input)
+	require.NoError(t, err)
+	require.Len(t, positions, 3)
+
+	want := []struct {
+		blob       *blob.Blob
+		shareRange Range
+	}{
+		{blobA, NewRange(0, 2)},
+		{blobB, NewRange(4, 5)},
+		{blobC, NewRange(6, 9)},
+	}
+	for i, w := range want {
+		assert.Equal(t, w.blob.Data, positions[i].Blob.Data, "blob %d", i)
+		assert.Equal(t, w.blob.Namespace(), positions[i].Blob.Namespace(), "blob %d", i)
+		assert.Equal(t, w.shareRange, positions[i].ShareRange, "blob %d", i)
+		assert.Equal(t, uint32(len(w.blob.Data)), positions[i].SequenceLen, "blob %d", i)
+
+		parsed, err := ParseBlobs(input[w.shareRange.Start:w.shareRange.End])
+		require.NoError(t, err)
+		require.Len(t, parsed, 1)
+		assert.Equal(t, w.blob.Data, parsed[0].Data)
+	}
+
+	groups := GroupByNamespace(positions)
+	require.Len(t, groups, 2)
+	assert.Equal(t, positions[:1], groups[ns1.String()])
+	assert.Equal(t, positions[1:], groups[ns2.String()])
+
+	positions, err = ParseBlobsWithPositions(nil)
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	_, err = ParseBlobsWithPositions(input[1:])
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 0, parseErr.Index)
+}
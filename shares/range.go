@@ -1,5 +1,7 @@
 package shares
 
+import "fmt"
+
 // Range is an end exclusive set of share indexes.
 type Range struct {
 	// Start is the index of the first share occupied by this range.
@@ -16,11 +18,51 @@ func EmptyRange() Range {
 	return Range{Start: 0, End: 0}
 }
 
+// IsEmpty returns true if the range doesn't contain any share index.
 func (r Range) IsEmpty() bool {
-	return r.Start == 0 && r.End == 0
+	return r.Len() == 0
+}
+
+// Len returns the number of share indexes in the range.
+func (r Range) Len() int {
+	return max(r.End-r.Start, 0)
 }
 
+// Contains returns true if the share index i is in the range.
+func (r Range) Contains(i int) bool {
+	return r.Start <= i && i < r.End
+}
+
+// Intersect returns the share indexes that are in both r and other. It returns
+// false if the ranges don't overlap, in which case the returned range is
+// empty. Adjacent ranges don't overlap.
+func (r Range) Intersect(other Range) (Range, bool) {
+	start, end := max(r.Start, other.Start), min(r.End, other.End)
+	if start >= end {
+		return EmptyRange(), false
+	}
+	return NewRange(start, end), true
+}
+
+// Shift returns the range moved by delta share indexes.
+func (r Range) Shift(delta int) Range {
+	return NewRange(r.Start+delta, r.End+delta)
+}
+
+// Add moves the range by value share indexes in place.
 func (r *Range) Add(value int) {
 	r.Start += value
 	r.End += value
 }
+
+// Validate returns an error if the range has a negative start or if its end
+// precedes its start.
+func (r Range) Validate() error {
+	if r.Start < 0 {
+		return fmt.Errorf("range start %d must not be negative", r.Start)
+	}
+	if r.End < r.Start {
+		return fmt.Errorf("range end %d must not be less than range start %d", r.End, r.Start)
+	}
+	return nil
+}
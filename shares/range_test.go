@@ -0,0 +1,85 @@
+package shares
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	type testCase struct {
+		name      string
+		r         Range
+		wantLen   int
+		wantEmpty bool
+		contains  []int
+		excludes  []int
+	}
+	testCases := []testCase{
+		{"empty range", EmptyRange(), 0, true, nil, []int{-1, 0, 1}},
+		{"empty range not at zero", NewRange(3, 3), 0, true, nil, []int{2, 3, 4}},
+		{"single share", NewRange(3, 4), 1, false, []int{3}, []int{2, 4}},
+		{"multiple shares", NewRange(0, 4), 4, false, []int{0, 1, 2, 3}, []int{-1, 4}},
+		{"end before start", NewRange(4, 2), 0, true, nil, []int{2, 3, 4}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantLen, tc.r.Len())
+			assert.Equal(t, tc.wantEmpty, tc.r.IsEmpty())
+			for _, i := range tc.contains {
+				assert.True(t, tc.r.Contains(i), "range %v should contain %d", tc.r, i)
+			}
+			for _, i := range tc.excludes {
+				assert.False(t, tc.r.Contains(i), "range %v should not contain %d", tc.r, i)
+			}
+		})
+	}
+}
+
+func TestRangeIntersect(t *testing.T) {
+	type testCase struct {
+		name   string
+		a, b   Range
+		want   Range
+		wantOk bool
+	}
+	testCases := []testCase{
+		{"identical ranges", NewRange(1, 4), NewRange(1, 4), NewRange(1, 4), true},
+		{"overlapping ranges", NewRange(1, 4), NewRange(3, 6), NewRange(3, 4), true},
+		{"nested ranges", NewRange(0, 10), NewRange(3, 6), NewRange(3, 6), true},
+		{"adjacent ranges", NewRange(1, 4), NewRange(4, 6), EmptyRange(), false},
+		{"disjoint ranges", NewRange(1, 2), NewRange(5, 6), EmptyRange(), false},
+		{"empty range", NewRange(2, 2), NewRange(0, 4), EmptyRange(), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.a.Intersect(tc.b)
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.want, got)
+
+			// intersection is symmetric
+			got, ok = tc.b.Intersect(tc.a)
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRangeShift(t *testing.T) {
+	r := NewRange(2, 5)
+	assert.Equal(t, NewRange(7, 10), r.Shift(5))
+	assert.Equal(t, NewRange(0, 3), r.Shift(-2))
+	// Shift doesn't modify the range
+	assert.Equal(t, NewRange(2, 5), r)
+
+	r.Add(5)
+	assert.Equal(t, r.Shift(0), NewRange(7, 10))
+}
+
+func TestRangeValidate(t *testing.T) {
+	assert.NoError(t, EmptyRange().Validate())
+	assert.NoError(t, NewRange(3, 3).Validate())
+	assert.NoError(t, NewRange(3, 5).Validate())
+	assert.Error(t, NewRange(-1, 5).Validate())
+	assert.Error(t, NewRange(5, 3).Validate())
+}
@@ -29,3 +29,84 @@ func ParseReservedBytes(reservedBytes []byte) (uint32, error) {
 	}
 	return byteIndex, nil
 }
+
+// ValidateReservedBytes checks that the reserved bytes of the provided compact
+// shares are consistent with the unit length delimiters. It replays the
+// delimiters across the shares and verifies that the reserved bytes of every
+// share are the index of the first unit that starts in the share, or zero if
+// no unit starts in it. If the first share starts a sequence, its first unit
+// must start at its first payload byte. Otherwise the shares are read from the
+// first share with non-zero reserved bytes, as the shares before it can't be
+// checked. Errors are returned as a *ParseError wrapping
+// ErrInvalidReservedBytes that identifies the offending share.
+func ValidateReservedBytes(shares []Share) error {
+	r, err := NewCompactSequenceReader(shares)
+	if err != nil {
+		return err
+	}
+
+	first := &shares[0]
+	isStart, err := first.IsSequenceStart()
+	if err != nil {
+		return newParseError(0, first, err)
+	}
+	if isStart {
+		sequenceLen, err := first.SequenceLen()
+		if err != nil {
+			return newParseError(0, first, err)
+		}
+		headerLen := continuationCompactShareHeaderLen + SequenceLenBytes
+		if sequenceLen > 0 && r.start != (compactCursor{share: 0, offset: headerLen}) {
+			reserved := binary.BigEndian.Uint32(first.data[headerLen-CompactShareReservedBytes : headerLen])
+			return newParseError(0, first, fmt.Errorf("%w: %d but the first unit starts at %d", ErrInvalidReservedBytes, reserved, headerLen))
+		}
+	}
+
+	// unitStarts holds the index of the first unit that starts in every share
+	// or zero if no unit starts in it
+	unitStarts := make([]int, len(shares))
+	cursor := r.start
+	for {
+		if cursor, err = r.normalize(cursor); err != nil {
+			return err
+		}
+		if cursor.share >= len(shares) {
+			break
+		}
+		r.cursor = cursor
+		unitLen, dataStart, err := r.readDelimiter(cursor)
+		if err != nil {
+			return err
+		}
+		// the rest of the shares is padding
+		if unitLen == 0 {
+			break
+		}
+		if unitStarts[cursor.share] == 0 {
+			unitStarts[cursor.share] = cursor.offset
+		}
+		// the rest of the shares only contains part of the last unit
+		if unitLen > uint64(r.remaining(dataStart)) {
+			break
+		}
+		cursor = advance(dataStart, int(unitLen))
+	}
+
+	for i := r.start.share; i < len(shares); i++ {
+		if err := r.checkShare(i); err != nil {
+			return err
+		}
+		reserved, err := r.reservedBytes(i)
+		if err != nil {
+			return err
+		}
+		switch {
+		case reserved == unitStarts[i]:
+		case unitStarts[i] == 0:
+			return newParseError(i, &shares[i], fmt.Errorf("%w: %d but no unit starts in the share", ErrInvalidReservedBytes, reserved))
+		default:
+			return newParseError(i, &shares[i], fmt.Errorf("%w: %d but the first unit starts at %d", ErrInvalidReservedBytes, reserved, unitStarts[i]))
+		}
+	}
+	return nil
+}
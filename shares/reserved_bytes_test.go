@@ -1,9 +1,13 @@
 package shares
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 
+	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseReservedBytes(t *testing.T) {
@@ -82,3 +86,112 @@ func TestNewReservedBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReservedBytes(t *testing.T) {
+	firstHeaderLen := namespace.NamespaceSize + ShareInfoBytes + SequenceLenBytes + CompactShareReservedBytes
+	continuationHeaderLen := namespace.NamespaceSize + ShareInfoBytes + CompactShareReservedBytes
+	// setReserved returns a copy of shares where the reserved bytes of the
+	// share at index are set to reserved.
+	setReserved := func(shares []Share, index int, reserved uint32) []Share {
+		out := append([]Share{}, shares...)
+		headerLen := continuationHeaderLen
+		if index == 0 {
+			headerLen = firstHeaderLen
+		}
+		binary.BigEndian.PutUint32(out[index].data[headerLen-CompactShareReservedBytes:], reserved)
+		return out
+	}
+	split := func(txs ...[]byte) []Share {
+		shares, _, _, err := SplitTxs(txs)
+		require.NoError(t, err)
+		return shares
+	}
+
+	// the second tx spans four shares so no unit starts in the second and
+	// third share
+	multiShareTx := split(bytes.Repeat([]byte{1}, 100), bytes.Repeat([]byte{2}, 3*ShareSize), []byte{3})
+	require.Len(t, multiShareTx, 4)
+	// the second tx starts exactly at the first payload byte of the second
+	// share
+	boundary := split(generateTx(1), []byte{2})
+	require.Len(t, boundary, 2)
+
+	type testCase struct {
+		name      string
+		shares    []Share
+		wantIndex int
+		wantErr   string
+	}
+	testCases := []testCase{
+		{name: "multi share tx", shares: multiShareTx},
+		{name: "unit at the first payload byte", shares: boundary},
+		{name: "continuation shares", shares: multiShareTx[2:]},
+		{name: "single share", shares: split([]byte{1})},
+		{
+			name:      "first share doesn't point at the first payload byte",
+			shares:    setReserved(multiShareTx, 0, uint32(firstHeaderLen+1)),
+			wantIndex: 0,
+			wantErr:   "39 but the first unit starts at 38",
+		},
+		{
+			name:      "reserved bytes point into the middle of a tx",
+			shares:    setReserved(multiShareTx, 3, 100),
+			wantIndex: 3,
+			wantErr:   "100 but the first unit starts at",
+		},
+		{
+			name:      "share where no unit starts",
+			shares:    setReserved(multiShareTx, 2, 100),
+			wantIndex: 2,
+			wantErr:   "100 but no unit starts in the share",
+		},
+		{
+			name:      "missing reserved bytes for a unit at the first payload byte",
+			shares:    setReserved(boundary, 1, 0),
+			wantIndex: 1,
+			wantErr:   "0 but the first unit starts at 34",
+		},
+		{
+			name:      "reserved bytes point into the header",
+			shares:    setReserved(boundary, 1, 10),
+			wantIndex: 1,
+			wantErr:   "10 is not in the range",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateReservedBytes(tc.shares)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidReservedBytes)
+			assert.ErrorContains(t, err, tc.wantErr)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.wantIndex, parseErr.Index)
+		})
+	}
+
+	t.Run("ParseShares", func(t *testing.T) {
+		css := NewCompactShareSplitter(namespace.PayForBlobNamespace, ShareVersionZero)
+		require.NoError(t, css.WriteTx(bytes.Repeat([]byte{4}, 2*ShareSize)))
+		require.NoError(t, css.WriteTx([]byte{5}))
+		pfbShares, err := css.Export()
+		require.NoError(t, err)
+		square := append(append(append([]Share{}, boundary...), pfbShares...), TailPaddingShares(2)...)
+		_, err = ParseShares(square, false)
+		require.NoError(t, err)
+
+		square = append(append(append([]Share{}, boundary...), setReserved(pfbShares, 2, 100)...), TailPaddingShares(2)...)
+		_, err = ParseShares(square, false)
+		assert.ErrorIs(t, err, ErrInvalidReservedBytes)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, len(boundary)+2, parseErr.Index)
+		assert.Equal(t, namespace.PayForBlobNamespace, parseErr.Namespace)
+
+		_, err = ParseShares(square, false, WithoutSequenceValidation())
+		assert.NoError(t, err)
+	})
+}
@@ -1,20 +1,61 @@
 package shares
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/celestiaorg/go-square/namespace"
 )
 
+var (
+	// ErrNotCompactShare is returned when an operation that only applies to
+	// compact shares is performed on a sparse share.
+	ErrNotCompactShare = errors.New("this is not a compact share")
+	// ErrNotFirstShare is returned when an operation that only applies to the
+	// first share of a sequence is performed on a continuation share.
+	ErrNotFirstShare = errors.New("not the first share")
+	// ErrNilBuilder is returned when a method is called on a nil builder.
+	ErrNilBuilder = errors.New("the builder object is not initialized (is nil)")
+	// ErrBuilderUninitialized is returned when data is added to a builder
+	// whose share header has not been written, e.g. one returned by
+	// NewEmptyBuilder.
+	ErrBuilderUninitialized = errors.New("the builder does not contain a share header")
+	// ErrBuilderPadded is returned when data is added to a share that has
+	// already been zero padded.
+	ErrBuilderPadded = errors.New("the share has already been padded")
+	// ErrBuilderFull is returned when data is added to a share that is full.
+	ErrBuilderFull = errors.New("the share is full")
+	// ErrSequenceLenAfterData is returned by WriteSequenceLen when data has
+	// already been added to the share. Use RewriteSequenceLen to update the
+	// sequence length of a share that contains data.
+	ErrSequenceLenAfterData = errors.New("the sequence length must be written before data is added to the share")
+)
+
 type Builder struct {
 	namespace      namespace.Namespace
 	shareVersion   uint8
 	isFirstShare   bool
 	isCompactShare bool
+	forceCompact   bool
+	isPadded       bool
 	rawShareData   []byte
 }
 
+// BuilderOption configures a Builder created by NewBuilderWithOptions.
+type BuilderOption func(*Builder)
+
+// WithForceCompact makes the builder construct compact shares, i.e. shares
+// with reserved bytes, even if the namespace is not one of the namespaces that
+// use compact shares by default. Note that shares parsed from raw bytes are
+// still considered compact based on their namespace only.
+func WithForceCompact(forceCompact bool) BuilderOption {
+	return func(b *Builder) {
+		b.forceCompact = forceCompact
+	}
+}
+
 func NewEmptyBuilder() *Builder {
 	return &Builder{
 		rawShareData: make([]byte, 0, ShareSize),
@@ -23,44 +64,234 @@ func NewEmptyBuilder() *Builder {
 
 // NewBuilder returns a new share builder.
 func NewBuilder(ns namespace.Namespace, shareVersion uint8, isFirstShare bool) (*Builder, error) {
+	return NewBuilderWithOptions(ns, shareVersion, isFirstShare)
+}
+
+// NewBuilderWithOptions returns a new share builder configured by the
+// provided options. It returns an error wrapping ErrUnsupportedShareVersion if
+// shareVersion is not one of SupportedShareVersions and an error wrapping
+// ErrInvalidShareNamespace if ns is not a valid namespace, e.g. because its
+// namespace version is not one of namespace.SupportedNamespaceVersions.
+func NewBuilderWithOptions(ns namespace.Namespace, shareVersion uint8, isFirstShare bool, opts ...BuilderOption) (*Builder, error) {
+	if err := checkShareVersion(shareVersion, SupportedShareVersions); err != nil {
+		return nil, err
+	}
+	if err := ns.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err)
+	}
+	b := Builder{
+		namespace:    ns,
+		shareVersion: shareVersion,
+		isFirstShare: isFirstShare,
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	b.isCompactShare = b.forceCompact || isCompactShare(ns)
+	if err := b.init(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// NewResumableBuilder returns a builder that continues writing into a
+// partially filled share, e.g. one that was checkpointed. The write cursor is
+// recovered from the share contents and any trailing padding is truncated so
+// that subsequent calls to AddData append right after the existing data.
+//
+// For compact shares the cursor is found by walking the length delimited units
+// starting at the index in the reserved bytes. For sparse shares and compact
+// shares in which no unit starts, trailing zero bytes are assumed to be
+// padding because they can't be distinguished from data.
+func NewResumableBuilder(share *Share) (*Builder, error) {
+	if err := share.validateFull(); err != nil {
+		return nil, err
+	}
+	ns, err := share.Namespace()
+	if err != nil {
+		return nil, err
+	}
+	infoByte, err := share.InfoByte()
+	if err != nil {
+		return nil, err
+	}
 	b := Builder{
 		namespace:      ns,
-		shareVersion:   shareVersion,
-		isFirstShare:   isFirstShare,
+		shareVersion:   infoByte.Version(),
+		isFirstShare:   infoByte.IsSequenceStart(),
 		isCompactShare: isCompactShare(ns),
 	}
-	if err := b.init(); err != nil {
+	cursor, err := b.resumeCursor(share.data[:])
+	if err != nil {
 		return nil, err
 	}
+	b.rawShareData = append(make([]byte, 0, ShareSize), share.data[:cursor]...)
 	return &b, nil
 }
 
+// resumeCursor returns the index in data right after the last byte that was
+// written to the share.
+func (b *Builder) resumeCursor(data []byte) (int, error) {
+	headerLen := b.headerLen()
+	if b.isCompactShare {
+		indexOfReservedBytes := b.indexOfReservedBytes()
+		reserved, err := ParseReservedBytes(data[indexOfReservedBytes : indexOfReservedBytes+CompactShareReservedBytes])
+		if err != nil {
+			return 0, err
+		}
+		if reserved != 0 {
+			if int(reserved) < headerLen {
+				return 0, fmt.Errorf("reserved bytes %d point into the share header", reserved)
+			}
+			return unitsEnd(data, int(reserved))
+		}
+	}
+	return headerLen + len(bytes.TrimRight(data[headerLen:], "\x00")), nil
+}
+
+// unitsEnd walks the length delimited units in data starting at cursor and
+// returns the index right after the last unit. A zero length delimiter marks
+// the start of padding.
+func unitsEnd(data []byte, cursor int) (int, error) {
+	for cursor < len(data) {
+		rest := data[cursor:]
+		withoutDelimiter, unitLen, err := ParseDelimiter(rest)
+		if err != nil {
+			return 0, err
+		}
+		if unitLen == 0 {
+			break
+		}
+		if unitLen >= uint64(len(data)) {
+			// the unit spills over into the next share
+			return len(data), nil
+		}
+		cursor += len(rest) - len(withoutDelimiter) + int(unitLen)
+	}
+	return min(cursor, len(data)), nil
+}
+
 // init initializes the share builder by populating rawShareData.
 func (b *Builder) init() error {
+	b.isPadded = false
 	if b.isCompactShare {
 		return b.prepareCompactShare()
 	}
 	return b.prepareSparseShare()
 }
 
+// Reset re-initializes the builder for a new share with the provided
+// namespace, share version and sequence start indicator. The result is
+// identical to a builder returned by NewBuilderWithOptions with the options
+// the builder was created with but the buffer of the builder is reused. Like
+// NewBuilderWithOptions it returns an error if the namespace is invalid, in
+// which case the builder is left unchanged.
+func (b *Builder) Reset(ns namespace.Namespace, shareVersion uint8, isFirstShare bool) error {
+	if b == nil {
+		return ErrNilBuilder
+	}
+	if err := checkShareVersion(shareVersion, SupportedShareVersions); err != nil {
+		return err
+	}
+	if err := ns.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err)
+	}
+	b.namespace = ns
+	b.shareVersion = shareVersion
+	b.isFirstShare = isFirstShare
+	b.isCompactShare = b.forceCompact || isCompactShare(ns)
+	return b.init()
+}
+
+// Clone returns a deep copy of the builder. Mutating the clone does not affect
+// the original builder and vice versa.
+func (b *Builder) Clone() *Builder {
+	rawShareData := make([]byte, len(b.rawShareData), ShareSize)
+	copy(rawShareData, b.rawShareData)
+	return &Builder{
+		namespace:      b.namespace,
+		shareVersion:   b.shareVersion,
+		isFirstShare:   b.isFirstShare,
+		isCompactShare: b.isCompactShare,
+		forceCompact:   b.forceCompact,
+		isPadded:       b.isPadded,
+		rawShareData:   rawShareData,
+	}
+}
+
 func (b *Builder) AvailableBytes() int {
 	return ShareSize - len(b.rawShareData)
 }
 
-func (b *Builder) ImportRawShare(rawBytes []byte) *Builder {
-	b.rawShareData = rawBytes
-	return b
+// ImportRawShare replaces the pending share of the builder with a copy of
+// rawBytes. The namespace, share version, sequence start indicator and whether
+// the share is compact are derived from the imported bytes. It returns an error
+// if rawBytes is longer than a share, too short to contain the share header or
+// if its share version is not one of SupportedShareVersions.
+func (b *Builder) ImportRawShare(rawBytes []byte) (*Builder, error) {
+	if b == nil {
+		return nil, ErrNilBuilder
+	}
+	if len(rawBytes) > ShareSize {
+		return nil, fmt.Errorf("raw share of %d bytes exceeds the share size of %d bytes", len(rawBytes), ShareSize)
+	}
+	if len(rawBytes) < namespace.NamespaceSize+ShareInfoBytes {
+		return nil, fmt.Errorf("raw share of %d bytes is too short to contain a namespace and info byte", len(rawBytes))
+	}
+	ns, err := namespace.From(append([]byte(nil), rawBytes[:namespace.NamespaceSize]...))
+	if err != nil {
+		return nil, err
+	}
+	infoByte, err := ParseInfoByteStrict(rawBytes[namespace.NamespaceSize])
+	if err != nil {
+		return nil, err
+	}
+
+	imported := Builder{
+		namespace:      ns,
+		shareVersion:   infoByte.Version(),
+		isFirstShare:   infoByte.IsSequenceStart(),
+		isCompactShare: b.forceCompact || isCompactShare(ns),
+		forceCompact:   b.forceCompact,
+	}
+	if headerLen := imported.headerLen(); len(rawBytes) < headerLen {
+		return nil, fmt.Errorf("raw share of %d bytes is too short to contain a share header of %d bytes", len(rawBytes), headerLen)
+	}
+	imported.rawShareData = append(make([]byte, 0, ShareSize), rawBytes...)
+	*b = imported
+	return b, nil
 }
 
-func (b *Builder) AddData(rawData []byte) (rawDataLeftOver []byte) {
+// AddData appends as much of rawData to the pending share as fits. It returns
+// the number of bytes written to the share and the remainder of rawData that
+// didn't fit. An error is returned if the share header hasn't been written, if
+// the share has been padded or if the share is already full. Builders allocate
+// their share buffer with a capacity of ShareSize up front so AddData never
+// allocates.
+func (b *Builder) AddData(rawData []byte) (written int, rawDataLeftOver []byte, err error) {
+	if b == nil {
+		return 0, rawData, ErrNilBuilder
+	}
+	if len(b.rawShareData) < b.headerLen() {
+		return 0, rawData, ErrBuilderUninitialized
+	}
+	if b.isPadded {
+		return 0, rawData, ErrBuilderPadded
+	}
+	if len(rawData) == 0 {
+		return 0, nil, nil
+	}
 	// find the len left in the pending share
 	pendingLeft := ShareSize - len(b.rawShareData)
+	if pendingLeft <= 0 {
+		return 0, rawData, ErrBuilderFull
+	}
 
 	// if we can simply add the tx to the share without creating a new
 	// pending share, do so and return
 	if len(rawData) <= pendingLeft {
 		b.rawShareData = append(b.rawShareData, rawData...)
-		return nil
+		return len(rawData), nil, nil
 	}
 
 	// if we can only add a portion of the rawData to the pending share,
@@ -70,33 +301,138 @@ func (b *Builder) AddData(rawData []byte) (rawDataLeftOver []byte) {
 
 	// We need to finish this share and start a new one
 	// so we return the leftover to be written into a new share
-	return rawData[pendingLeft:]
+	return pendingLeft, rawData[pendingLeft:], nil
+}
+
+// AddTransaction writes tx prefixed with its uvarint length delimiter to the
+// pending compact share. If no unit has started in the share yet, the reserved
+// bytes are set to point at the delimiter. It returns the part of the
+// delimited tx that didn't fit in the share, which should be written to
+// continuation shares with AddData. If the delimiter itself spans the share
+// boundary, leftover starts with the rest of the delimiter.
+func (b *Builder) AddTransaction(tx []byte) (leftover []byte, err error) {
+	if b == nil {
+		return nil, ErrNilBuilder
+	}
+	if b.isPadded {
+		return nil, ErrBuilderPadded
+	}
+	if err := b.MaybeWriteReservedBytes(); err != nil {
+		return nil, err
+	}
+
+	var delimiter [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(delimiter[:], uint64(len(tx)))
+	_, delimiterLeftOver, err := b.AddData(delimiter[:n])
+	if err != nil {
+		return nil, err
+	}
+	if len(delimiterLeftOver) > 0 {
+		leftover = make([]byte, 0, len(delimiterLeftOver)+len(tx))
+		return append(append(leftover, delimiterLeftOver...), tx...), nil
+	}
+	if b.AvailableBytes() == 0 {
+		// the delimiter ended exactly at the end of the share
+		return tx, nil
+	}
+	_, leftover, err = b.AddData(tx)
+	return leftover, err
+}
+
+// AddDataOrSpill writes all of the provided data to the builder. Whenever the
+// pending share is full it is built and the builder is re-initialized as a
+// continuation share with the same namespace and share version. It returns
+// the shares that were completed while the builder retains the last, partially
+// filled, share.
+func (b *Builder) AddDataOrSpill(data []byte) (finalized []*Share, err error) {
+	for {
+		_, data, err = b.AddData(data)
+		if err != nil {
+			return finalized, err
+		}
+		if b.AvailableBytes() > 0 {
+			return finalized, nil
+		}
+
+		share, err := b.Build()
+		if err != nil {
+			return finalized, err
+		}
+		finalized = append(finalized, share)
+
+		// start a new continuation share
+		b.isFirstShare = false
+		if err := b.init(); err != nil {
+			return finalized, err
+		}
+		if len(data) == 0 {
+			return finalized, nil
+		}
+	}
 }
 
 func (b *Builder) Build() (*Share, error) {
 	return NewShare(b.rawShareData)
 }
 
+// build is like Build but it returns the share by value which avoids a heap
+// allocation per share in the splitters.
+func (b *Builder) build() (share Share, err error) {
+	if err := validateSize(b.rawShareData); err != nil {
+		return share, err
+	}
+	copy(share.data[:], b.rawShareData)
+	return share, nil
+}
+
 // IsEmptyShare returns true if no data has been written to the share
 func (b *Builder) IsEmptyShare() bool {
-	expectedLen := namespace.NamespaceSize + ShareInfoBytes
+	return len(b.rawShareData) == b.headerLen()
+}
+
+// DataLen returns the number of data bytes written to the share so far. It
+// excludes the namespace, info byte, sequence length and reserved bytes.
+// DataLen() + RemainingCapacityForData() is the number of data bytes that fit
+// in the share.
+func (b *Builder) DataLen() int {
+	return len(b.rawShareData) - b.headerLen()
+}
+
+// RemainingCapacityForData returns the number of data bytes that can still be
+// written to the share.
+func (b *Builder) RemainingCapacityForData() int {
+	return ShareSize - len(b.rawShareData)
+}
+
+// headerLen returns the number of bytes that precede the data in the share.
+func (b *Builder) headerLen() int {
+	headerLen := namespace.NamespaceSize + ShareInfoBytes
 	if b.isCompactShare {
-		expectedLen += CompactShareReservedBytes
+		headerLen += CompactShareReservedBytes
 	}
 	if b.isFirstShare {
-		expectedLen += SequenceLenBytes
+		headerLen += SequenceLenBytes
 	}
-	return len(b.rawShareData) == expectedLen
+	if containsSigner(b.shareVersion, b.isFirstShare, b.isCompactShare) {
+		headerLen += SignerSize
+	}
+	return headerLen
 }
 
 func (b *Builder) ZeroPadIfNecessary() (bytesOfPadding int) {
 	b.rawShareData, bytesOfPadding = zeroPadIfNecessary(b.rawShareData, ShareSize)
+	if bytesOfPadding > 0 {
+		b.isPadded = true
+	}
 	return bytesOfPadding
 }
 
 // isEmptyReservedBytes returns true if the reserved bytes are empty.
 func (b *Builder) isEmptyReservedBytes() (bool, error) {
 	indexOfReservedBytes := b.indexOfReservedBytes()
+	if len(b.rawShareData) < indexOfReservedBytes+CompactShareReservedBytes {
+		return false, ErrBuilderUninitialized
+	}
 	reservedBytes, err := ParseReservedBytes(b.rawShareData[indexOfReservedBytes : indexOfReservedBytes+CompactShareReservedBytes])
 	if err != nil {
 		return false, err
@@ -122,10 +458,18 @@ func (b *Builder) indexOfInfoBytes() int {
 
 // MaybeWriteReservedBytes will be a no-op if the reserved bytes
 // have already been populated. If the reserved bytes are empty, it will write
-// the location of the next unit of data to the reserved bytes.
+// the location of the next unit of data to the reserved bytes. It returns
+// ErrBuilderFull if the share is full because the next unit then starts in a
+// following share, whose reserved bytes must point to it instead.
 func (b *Builder) MaybeWriteReservedBytes() error {
+	if b == nil {
+		return ErrNilBuilder
+	}
 	if !b.isCompactShare {
-		return errors.New("this is not a compact share")
+		return ErrNotCompactShare
+	}
+	if len(b.rawShareData) < b.headerLen() {
+		return ErrBuilderUninitialized
 	}
 
 	empty, err := b.isEmptyReservedBytes()
@@ -137,6 +481,9 @@ func (b *Builder) MaybeWriteReservedBytes() error {
 	}
 
 	byteIndexOfNextUnit := len(b.rawShareData)
+	if byteIndexOfNextUnit >= ShareSize {
+		return ErrBuilderFull
+	}
 	reservedBytes, err := NewReservedBytes(uint32(byteIndexOfNextUnit))
 	if err != nil {
 		return err
@@ -150,49 +497,118 @@ func (b *Builder) MaybeWriteReservedBytes() error {
 	return nil
 }
 
-// WriteSequenceLen writes the sequence length to the first share.
+// WriteSequenceLen writes the sequence length to the first share. It must be
+// called before any data is added to the share; it returns
+// ErrSequenceLenAfterData otherwise.
 func (b *Builder) WriteSequenceLen(sequenceLen uint32) error {
+	if err := b.checkSequenceLen(); err != nil {
+		return err
+	}
+	if !b.IsEmptyShare() {
+		return ErrSequenceLenAfterData
+	}
+	b.putSequenceLen(sequenceLen)
+	return nil
+}
+
+// RewriteSequenceLen overwrites the sequence length of the first share
+// regardless of whether data has been added to it. It is meant for fixing up
+// the sequence length once all the data of the sequence is known.
+func (b *Builder) RewriteSequenceLen(sequenceLen uint32) error {
+	if err := b.checkSequenceLen(); err != nil {
+		return err
+	}
+	b.putSequenceLen(sequenceLen)
+	return nil
+}
+
+// checkSequenceLen returns an error if the share of the builder doesn't have a
+// sequence length.
+func (b *Builder) checkSequenceLen() error {
 	if b == nil {
-		return errors.New("the builder object is not initialized (is nil)")
+		return ErrNilBuilder
+	}
+	if len(b.rawShareData) < b.headerLen() {
+		return ErrBuilderUninitialized
 	}
 	if !b.isFirstShare {
-		return errors.New("not the first share")
+		return ErrNotFirstShare
 	}
-	sequenceLenBuf := make([]byte, SequenceLenBytes)
-	binary.BigEndian.PutUint32(sequenceLenBuf, sequenceLen)
+	return nil
+}
+
+func (b *Builder) putSequenceLen(sequenceLen uint32) {
+	binary.BigEndian.PutUint32(b.rawShareData[namespace.NamespaceSize+ShareInfoBytes:], sequenceLen)
+}
 
-	for i := 0; i < SequenceLenBytes; i++ {
-		b.rawShareData[namespace.NamespaceSize+ShareInfoBytes+i] = sequenceLenBuf[i]
+// WriteSigner writes the signer to the first share of a share version 1 sparse
+// sequence. The signer must be SignerSize bytes long.
+func (b *Builder) WriteSigner(signer []byte) error {
+	if b == nil {
+		return ErrNilBuilder
 	}
+	if !b.isFirstShare {
+		return ErrNotFirstShare
+	}
+	if b.isCompactShare {
+		return errors.New("compact shares do not contain a signer")
+	}
+	if b.shareVersion != ShareVersionOne {
+		return fmt.Errorf("share version %d does not contain a signer", b.shareVersion)
+	}
+	if len(signer) != SignerSize {
+		return fmt.Errorf("signer must be %d bytes but it was %d bytes", SignerSize, len(signer))
+	}
+	copy(b.rawShareData[namespace.NamespaceSize+ShareInfoBytes+SequenceLenBytes:], signer)
+	return nil
+}
 
+// SetNamespace overwrites the namespace of the pending share in place. It
+// returns an error if the new namespace would change whether the share is a
+// compact share because the layout of the share would no longer be valid.
+func (b *Builder) SetNamespace(ns namespace.Namespace) error {
+	if b == nil {
+		return ErrNilBuilder
+	}
+	if len(ns.ID) != namespace.NamespaceIDSize {
+		return fmt.Errorf("namespace id must be %d bytes but it was %d bytes", namespace.NamespaceIDSize, len(ns.ID))
+	}
+	if len(b.rawShareData) < namespace.NamespaceSize {
+		return errors.New("the builder does not contain a namespace")
+	}
+	if (b.forceCompact || isCompactShare(ns)) != b.isCompactShare {
+		return fmt.Errorf("namespace %s would change the compactness of the share", ns)
+	}
+	b.rawShareData[0] = ns.Version
+	copy(b.rawShareData[1:namespace.NamespaceSize], ns.ID)
+	b.namespace = ns
 	return nil
 }
 
-// FlipSequenceStart flips the sequence start indicator of the share provided
-func (b *Builder) FlipSequenceStart() {
+// FlipSequenceStart flips the sequence start indicator of the share provided.
+// It returns ErrBuilderUninitialized if the share doesn't contain an info byte.
+func (b *Builder) FlipSequenceStart() error {
+	if b == nil {
+		return ErrNilBuilder
+	}
 	infoByteIndex := b.indexOfInfoBytes()
+	if len(b.rawShareData) <= infoByteIndex {
+		return ErrBuilderUninitialized
+	}
 
 	// the sequence start indicator is the last bit of the info byte so flip the
 	// last bit
-	b.rawShareData[infoByteIndex] = b.rawShareData[infoByteIndex] ^ 0x01
+	b.rawShareData[infoByteIndex] = b.rawShareData[infoByteIndex] ^ InfoByteSequenceStartMask
+	return nil
 }
 
 func (b *Builder) prepareCompactShare() error {
-	shareData := make([]byte, 0, ShareSize)
-	infoByte, err := NewInfoByte(b.shareVersion, b.isFirstShare)
+	shareData, err := b.prepareShareHeader()
 	if err != nil {
 		return err
 	}
-	placeholderSequenceLen := make([]byte, SequenceLenBytes)
 	placeholderReservedBytes := make([]byte, CompactShareReservedBytes)
 
-	shareData = append(shareData, b.namespace.Bytes()...)
-	shareData = append(shareData, byte(infoByte))
-
-	if b.isFirstShare {
-		shareData = append(shareData, placeholderSequenceLen...)
-	}
-
 	shareData = append(shareData, placeholderReservedBytes...)
 
 	b.rawShareData = shareData
@@ -201,24 +617,43 @@ func (b *Builder) prepareCompactShare() error {
 }
 
 func (b *Builder) prepareSparseShare() error {
-	shareData := make([]byte, 0, ShareSize)
-	infoByte, err := NewInfoByte(b.shareVersion, b.isFirstShare)
+	shareData, err := b.prepareShareHeader()
 	if err != nil {
 		return err
 	}
+	if containsSigner(b.shareVersion, b.isFirstShare, b.isCompactShare) {
+		placeholderSigner := make([]byte, SignerSize)
+		shareData = append(shareData, placeholderSigner...)
+	}
+
+	b.rawShareData = shareData
+	return nil
+}
+
+// prepareShareHeader returns the namespace, info byte and, for the first
+// share, the placeholder sequence length. The existing buffer of the builder is
+// reused if it can hold a full share.
+func (b *Builder) prepareShareHeader() ([]byte, error) {
+	infoByte, err := NewInfoByte(b.shareVersion, b.isFirstShare)
+	if err != nil {
+		return nil, err
+	}
+	shareData := b.rawShareData[:0]
+	if cap(shareData) < ShareSize {
+		shareData = make([]byte, 0, ShareSize)
+	}
 	placeholderSequenceLen := make([]byte, SequenceLenBytes)
 
-	shareData = append(shareData, b.namespace.Bytes()...)
+	shareData = append(shareData, b.namespace.Version)
+	shareData = append(shareData, b.namespace.ID...)
 	shareData = append(shareData, byte(infoByte))
 
 	if b.isFirstShare {
 		shareData = append(shareData, placeholderSequenceLen...)
 	}
-
-	b.rawShareData = shareData
-	return nil
+	return shareData, nil
 }
 
 func isCompactShare(ns namespace.Namespace) bool {
-	return ns.IsTx() || ns.IsPayForBlob()
+	return namespace.Classify(ns).IsCompact()
 }
@@ -2,9 +2,11 @@ package shares
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"testing"
 
+	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -145,6 +147,37 @@ func TestShareBuilderWriteSequenceLen(t *testing.T) {
 	}
 }
 
+func TestShareBuilderWriteSequenceLenErrors(t *testing.T) {
+	t.Run("uninitialized builder", func(t *testing.T) {
+		b := NewEmptyBuilder()
+		assert.ErrorIs(t, b.WriteSequenceLen(1), ErrBuilderUninitialized)
+		assert.ErrorIs(t, b.RewriteSequenceLen(1), ErrBuilderUninitialized)
+	})
+
+	t.Run("after AddData", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true)
+		require.NoError(t, b.WriteSequenceLen(1))
+		_, _, err := b.AddData([]byte{1, 2, 3})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, b.WriteSequenceLen(2), ErrSequenceLenAfterData)
+		require.NoError(t, b.RewriteSequenceLen(3))
+
+		b.ZeroPadIfNecessary()
+		share, err := b.Build()
+		require.NoError(t, err)
+		sequenceLen, err := share.SequenceLen()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), sequenceLen)
+		assert.Equal(t, []byte{1, 2, 3}, share.data[b.headerLen():b.headerLen()+3])
+	})
+
+	t.Run("continuation share", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		assert.ErrorIs(t, b.RewriteSequenceLen(1), ErrNotFirstShare)
+	})
+}
+
 func TestShareBuilderAddData(t *testing.T) {
 	type testCase struct {
 		name    string
@@ -213,12 +246,54 @@ func TestShareBuilderAddData(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := tc.builder.AddData(tc.data)
+			written, got, err := tc.builder.AddData(tc.data)
+			require.NoError(t, err)
 			assert.Equal(t, tc.want, got)
+			assert.Equal(t, len(tc.data)-len(tc.want), written)
 		})
 	}
 }
 
+func TestShareBuilderAddDataErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	data := []byte{1, 2, 3}
+
+	t.Run("uninitialized builder", func(t *testing.T) {
+		written, leftover, err := NewEmptyBuilder().AddData(data)
+		assert.ErrorIs(t, err, ErrBuilderUninitialized)
+		assert.Zero(t, written)
+		assert.Equal(t, data, leftover)
+	})
+
+	t.Run("full share", func(t *testing.T) {
+		b := mustNewBuilder(t, ns1, ShareVersionZero, false)
+		_, _, err := b.AddData(bytes.Repeat([]byte{0xf}, ShareSize))
+		require.NoError(t, err)
+		written, leftover, err := b.AddData(data)
+		assert.ErrorIs(t, err, ErrBuilderFull)
+		assert.Zero(t, written)
+		assert.Equal(t, data, leftover)
+	})
+
+	t.Run("AddData after ZeroPadIfNecessary", func(t *testing.T) {
+		b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+		_, _, err := b.AddData(data)
+		require.NoError(t, err)
+		b.ZeroPadIfNecessary()
+		written, leftover, err := b.AddData(data)
+		assert.ErrorIs(t, err, ErrBuilderPadded)
+		assert.Zero(t, written)
+		assert.Equal(t, data, leftover)
+		assert.Len(t, b.rawShareData, ShareSize)
+
+		// resetting the builder allows data to be added again
+		require.NoError(t, b.Reset(ns1, ShareVersionZero, false))
+		written, _, err = b.AddData(data)
+		require.NoError(t, err)
+		assert.Equal(t, len(data), written)
+	})
+}
+
 func TestShareBuilderImportRawData(t *testing.T) {
 	type testCase struct {
 		name       string
@@ -286,11 +361,21 @@ func TestShareBuilderImportRawData(t *testing.T) {
 			shareBytes: oversizedImport,
 			wantErr:    true,
 		},
+		{
+			name:       "truncated import",
+			shareBytes: firstCompactShare[:namespace.NamespaceSize+ShareInfoBytes+SequenceLenBytes],
+			wantErr:    true,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			b := NewEmptyBuilder().ImportRawShare(tc.shareBytes)
+			b, err := NewEmptyBuilder().ImportRawShare(tc.shareBytes)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
 			b.ZeroPadIfNecessary()
 			builtShare, err := b.Build()
 			if tc.wantErr {
@@ -311,9 +396,564 @@ func TestShareBuilderImportRawData(t *testing.T) {
 	}
 }
 
+func TestShareBuilderImportRawShare(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	t.Run("tx share", func(t *testing.T) {
+		txShares, _, _, err := SplitTxs([][]byte{bytes.Repeat([]byte{0xa}, 100)})
+		require.NoError(t, err)
+		raw := txShares[0].ToBytes()
+
+		b, err := NewEmptyBuilder().ImportRawShare(raw)
+		require.NoError(t, err)
+		assert.True(t, b.isCompactShare)
+		assert.True(t, b.isFirstShare)
+		assert.Equal(t, namespace.TxNamespace, b.namespace)
+		require.NoError(t, b.MaybeWriteReservedBytes())
+		require.NoError(t, b.RewriteSequenceLen(7))
+
+		// the builder must not alias the imported bytes
+		assert.Equal(t, txShares[0].ToBytes(), raw)
+		share, err := b.Build()
+		require.NoError(t, err)
+		sequenceLen, err := share.SequenceLen()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(7), sequenceLen)
+	})
+
+	t.Run("blob share mid-sequence", func(t *testing.T) {
+		blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xb}, 2*ShareSize), ShareVersionZero))
+		require.NoError(t, err)
+
+		b, err := NewEmptyBuilder().ImportRawShare(blobShares[1].ToBytes())
+		require.NoError(t, err)
+		assert.False(t, b.isCompactShare)
+		assert.False(t, b.isFirstShare)
+		assert.Equal(t, ns1, b.namespace)
+		assert.ErrorIs(t, b.WriteSequenceLen(1), ErrNotFirstShare)
+		assert.ErrorIs(t, b.MaybeWriteReservedBytes(), ErrNotCompactShare)
+
+		share, err := b.Build()
+		require.NoError(t, err)
+		assert.Equal(t, blobShares[1], *share)
+	})
+
+	t.Run("truncated buffer", func(t *testing.T) {
+		_, err := NewEmptyBuilder().ImportRawShare(ns1.Bytes())
+		assert.Error(t, err)
+
+		b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+		_, err = b.ImportRawShare(append(ns1.Bytes(), 1, 0, 0))
+		assert.Error(t, err)
+		// a failed import leaves the builder untouched
+		assert.True(t, b.isFirstShare)
+		assert.Equal(t, ns1, b.namespace)
+	})
+}
+
+func TestShareBuilderWriteSigner(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	signer := bytes.Repeat([]byte{0xaa}, SignerSize)
+
+	b := mustNewBuilder(t, ns1, ShareVersionOne, true)
+	assert.True(t, b.IsEmptyShare())
+	assert.Equal(t, FirstSparseShareWithSignerContentSize, b.AvailableBytes())
+	require.NoError(t, b.WriteSequenceLen(1))
+	require.NoError(t, b.WriteSigner(signer))
+	assert.True(t, b.IsEmptyShare())
+	_, _, err := b.AddData([]byte{0xf})
+	require.NoError(t, err)
+	b.ZeroPadIfNecessary()
+
+	share, err := b.Build()
+	require.NoError(t, err)
+	gotSigner, err := share.Signer()
+	require.NoError(t, err)
+	assert.Equal(t, signer, gotSigner)
+	rawData, err := share.RawData()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0xf), rawData[0])
+
+	type testCase struct {
+		name    string
+		builder *Builder
+		signer  []byte
+	}
+	testCases := []testCase{
+		{
+			name:    "continuation share",
+			builder: mustNewBuilder(t, ns1, ShareVersionOne, false),
+			signer:  signer,
+		},
+		{
+			name:    "share version 0",
+			builder: mustNewBuilder(t, ns1, ShareVersionZero, true),
+			signer:  signer,
+		},
+		{
+			name:    "compact share",
+			builder: mustNewBuilder(t, namespace.TxNamespace, ShareVersionOne, true),
+			signer:  signer,
+		},
+		{
+			name:    "invalid signer length",
+			builder: mustNewBuilder(t, ns1, ShareVersionOne, true),
+			signer:  signer[1:],
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, tc.builder.WriteSigner(tc.signer))
+		})
+	}
+}
+
+func TestShareBuilderClone(t *testing.T) {
+	b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true)
+	b.AddData([]byte{1, 2, 3})
+	original := append([]byte{}, b.rawShareData...)
+
+	clone := b.Clone()
+	assert.Equal(t, b, clone)
+
+	clone.AddData([]byte{4, 5, 6})
+	require.NoError(t, clone.RewriteSequenceLen(10))
+	require.NoError(t, clone.MaybeWriteReservedBytes())
+	assert.Equal(t, original, b.rawShareData)
+	assert.NotEqual(t, b.rawShareData, clone.rawShareData)
+}
+
+func TestShareBuilderAddDataDoesNotAllocate(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	data := bytes.Repeat([]byte{0xaa}, 100)
+	for _, ns := range []namespace.Namespace{ns1, namespace.TxNamespace} {
+		b := mustNewBuilder(t, ns, ShareVersionZero, true)
+		header := append([]byte{}, b.rawShareData...)
+		allocs := testing.AllocsPerRun(100, func() {
+			b.rawShareData = append(b.rawShareData[:0], header...)
+			for {
+				if _, leftover, _ := b.AddData(data); leftover != nil {
+					break
+				}
+			}
+		})
+		assert.Zero(t, allocs)
+	}
+}
+
+func BenchmarkShareBuilderAddData(b *testing.B) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	data := bytes.Repeat([]byte{0xaa}, 32)
+	builder, err := NewBuilder(ns1, ShareVersionZero, true)
+	require.NoError(b, err)
+	header := append([]byte{}, builder.rawShareData...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		builder.rawShareData = append(builder.rawShareData[:0], header...)
+		for {
+			if _, leftover, _ := builder.AddData(data); leftover != nil {
+				break
+			}
+		}
+	}
+}
+
+func TestNewResumableBuilder(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	tx, err := MarshalDelimitedTx([]byte{1, 2, 0, 0})
+	require.NoError(t, err)
+
+	type testCase struct {
+		name    string
+		builder *Builder
+		// write populates the builder before it is checkpointed
+		write func(b *Builder)
+	}
+	testCases := []testCase{
+		{
+			name:    "first compact share with trailing zeros in unit",
+			builder: mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true),
+			write: func(b *Builder) {
+				require.NoError(t, b.MaybeWriteReservedBytes())
+				b.AddData(tx)
+				b.AddData(tx)
+			},
+		},
+		{
+			name:    "continuation compact share",
+			builder: mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false),
+			write: func(b *Builder) {
+				b.AddData([]byte{7, 7})
+				require.NoError(t, b.MaybeWriteReservedBytes())
+				b.AddData(tx)
+			},
+		},
+		{
+			name:    "empty compact share",
+			builder: mustNewBuilder(t, namespace.PayForBlobNamespace, ShareVersionZero, true),
+			write:   func(*Builder) {},
+		},
+		{
+			name:    "first sparse share",
+			builder: mustNewBuilder(t, ns1, ShareVersionZero, true),
+			write: func(b *Builder) {
+				require.NoError(t, b.WriteSequenceLen(1000))
+				b.AddData([]byte{9, 0, 9})
+			},
+		},
+		{
+			name:    "continuation sparse share",
+			builder: mustNewBuilder(t, ns1, ShareVersionZero, false),
+			write: func(b *Builder) {
+				b.AddData([]byte{9, 9, 9})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.write(tc.builder)
+			checkpoint := tc.builder.Clone()
+			checkpoint.ZeroPadIfNecessary()
+			share, err := checkpoint.Build()
+			require.NoError(t, err)
+
+			resumed, err := NewResumableBuilder(share)
+			require.NoError(t, err)
+			assert.Equal(t, tc.builder.rawShareData, resumed.rawShareData)
+			assert.Equal(t, tc.builder.AvailableBytes(), resumed.AvailableBytes())
+
+			resumed.AddData(tx)
+			tc.builder.AddData(tx)
+			assert.Equal(t, tc.builder.rawShareData, resumed.rawShareData)
+		})
+	}
+
+	t.Run("invalid share", func(t *testing.T) {
+		invalidShare := TailPaddingShare()
+		invalidShare.data[namespace.NamespaceSize] = 0xFF
+		_, err := NewResumableBuilder(&invalidShare)
+		assert.Error(t, err)
+	})
+}
+
+func TestShareBuilderSentinelErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	var nilBuilder *Builder
+
+	assert.ErrorIs(t, mustNewBuilder(t, ns1, ShareVersionZero, true).MaybeWriteReservedBytes(), ErrNotCompactShare)
+	assert.ErrorIs(t, nilBuilder.MaybeWriteReservedBytes(), ErrNilBuilder)
+	fullBuilder := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+	_, _, err := fullBuilder.AddData(bytes.Repeat([]byte{1}, ContinuationCompactShareContentSize))
+	require.NoError(t, err)
+	assert.ErrorIs(t, fullBuilder.MaybeWriteReservedBytes(), ErrBuilderFull)
+	assert.ErrorIs(t, mustNewBuilder(t, ns1, ShareVersionZero, false).WriteSequenceLen(1), ErrNotFirstShare)
+	assert.ErrorIs(t, mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false).WriteSequenceLen(1), ErrNotFirstShare)
+	assert.ErrorIs(t, nilBuilder.WriteSequenceLen(1), ErrNilBuilder)
+	assert.ErrorIs(t, nilBuilder.FlipSequenceStart(), ErrNilBuilder)
+	assert.ErrorIs(t, NewEmptyBuilder().FlipSequenceStart(), ErrBuilderUninitialized)
+	assert.ErrorIs(t, NewEmptyBuilder().MaybeWriteReservedBytes(), ErrNotCompactShare)
+	assert.ErrorIs(t, (&Builder{isCompactShare: true}).MaybeWriteReservedBytes(), ErrBuilderUninitialized)
+}
+
+func TestNewBuilderUnsupportedShareVersion(t *testing.T) {
+	_, err := NewBuilder(ns1, 5, true)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+	assert.ErrorIs(t, b.Reset(ns1, 5, true), ErrUnsupportedShareVersion)
+
+	padding := TailPaddingShare()
+	raw := padding.ToBytes()
+	raw[namespace.NamespaceSize] = 5 << 1
+	_, err = NewEmptyBuilder().ImportRawShare(raw)
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	for _, version := range SupportedShareVersions {
+		assert.True(t, IsSupportedShareVersion(version))
+	}
+	assert.False(t, IsSupportedShareVersion(5))
+	assert.False(t, IsSupportedShareVersion(MaxShareVersion))
+}
+
+func TestNewBuilderWithForceCompact(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	b, err := NewBuilderWithOptions(ns1, ShareVersionZero, true, WithForceCompact(true))
+	require.NoError(t, err)
+	assert.Equal(t, FirstCompactShareContentSize, b.AvailableBytes())
+	assert.True(t, b.IsEmptyShare())
+	require.NoError(t, b.MaybeWriteReservedBytes())
+
+	require.NoError(t, b.Reset(ns1, ShareVersionZero, false))
+	assert.Equal(t, ContinuationCompactShareContentSize, b.AvailableBytes())
+	assert.Equal(t, ContinuationCompactShareContentSize, b.Clone().AvailableBytes())
+
+	b, err = NewBuilderWithOptions(ns1, ShareVersionZero, true, WithForceCompact(false))
+	require.NoError(t, err)
+	assert.Equal(t, mustNewBuilder(t, ns1, ShareVersionZero, true), b)
+	assert.ErrorIs(t, b.MaybeWriteReservedBytes(), ErrNotCompactShare)
+}
+
+func TestShareBuilderDataLen(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	type testCase struct {
+		ns           namespace.Namespace
+		isFirstShare bool
+		capacity     int
+	}
+	testCases := []testCase{
+		{ns: ns1, isFirstShare: true, capacity: FirstSparseShareContentSize},
+		{ns: ns1, isFirstShare: false, capacity: ContinuationSparseShareContentSize},
+		{ns: namespace.TxNamespace, isFirstShare: true, capacity: FirstCompactShareContentSize},
+		{ns: namespace.TxNamespace, isFirstShare: false, capacity: ContinuationCompactShareContentSize},
+	}
+	for _, tc := range testCases {
+		b := mustNewBuilder(t, tc.ns, ShareVersionZero, tc.isFirstShare)
+		assert.Equal(t, 0, b.DataLen())
+		assert.Equal(t, tc.capacity, b.RemainingCapacityForData())
+
+		b.AddData(bytes.Repeat([]byte{0xf}, 10))
+		assert.Equal(t, 10, b.DataLen())
+		assert.Equal(t, tc.capacity, b.DataLen()+b.RemainingCapacityForData())
+
+		b.AddData(bytes.Repeat([]byte{0xf}, ShareSize))
+		assert.Equal(t, tc.capacity, b.DataLen())
+		assert.Equal(t, 0, b.RemainingCapacityForData())
+	}
+}
+
+func TestShareBuilderSetNamespace(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+
+	b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+	require.NoError(t, b.WriteSequenceLen(0))
+	b.ZeroPadIfNecessary()
+	require.NoError(t, b.SetNamespace(ns2))
+	share, err := b.Build()
+	require.NoError(t, err)
+	want, err := NamespacePaddingShare(ns2, ShareVersionZero)
+	require.NoError(t, err)
+	assert.Equal(t, want, *share)
+
+	assert.Error(t, b.SetNamespace(namespace.TxNamespace))
+	assert.Error(t, b.SetNamespace(namespace.Namespace{ID: []byte{1}}))
+	assert.Error(t, mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true).SetNamespace(ns1))
+	assert.NoError(t, mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true).SetNamespace(namespace.PayForBlobNamespace))
+	assert.Error(t, NewEmptyBuilder().SetNamespace(ns1))
+
+	forced, err := NewBuilderWithOptions(ns1, ShareVersionZero, true, WithForceCompact(true))
+	require.NoError(t, err)
+	assert.NoError(t, forced.SetNamespace(namespace.TxNamespace))
+	assert.NoError(t, forced.SetNamespace(ns2))
+}
+
+func TestShareBuilderReset(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+
+	type testCase struct {
+		ns           namespace.Namespace
+		isFirstShare bool
+	}
+	testCases := []testCase{
+		{ns: namespace.TxNamespace, isFirstShare: true},
+		{ns: namespace.TxNamespace, isFirstShare: false},
+		{ns: ns1, isFirstShare: false},
+		{ns: ns1, isFirstShare: true},
+	}
+	for _, tc := range testCases {
+		b.AddData(bytes.Repeat([]byte{0xff}, ShareSize))
+		require.NoError(t, b.Reset(tc.ns, ShareVersionZero, tc.isFirstShare))
+		assert.Equal(t, mustNewBuilder(t, tc.ns, ShareVersionZero, tc.isFirstShare), b)
+
+		// the padding must not contain data of the previous share
+		b.ZeroPadIfNecessary()
+		share, err := b.Build()
+		require.NoError(t, err)
+		rawData, err := share.RawData()
+		require.NoError(t, err)
+		assert.True(t, IsAllZero(rawData))
+	}
+
+	var nilBuilder *Builder
+	assert.ErrorIs(t, nilBuilder.Reset(ns1, ShareVersionZero, true), ErrNilBuilder)
+}
+
 // mustNewBuilder returns a new builder with the given parameters. It fails the test if an error is encountered.
 func mustNewBuilder(t *testing.T, ns namespace.Namespace, shareVersion uint8, isFirstShare bool) *Builder {
 	b, err := NewBuilder(ns, shareVersion, isFirstShare)
 	require.NoError(t, err)
 	return b
 }
+
+func TestShareBuilderAddDataOrSpill(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	data := bytes.Repeat([]byte{0xaa}, FirstSparseShareContentSize+ContinuationSparseShareContentSize+10)
+
+	b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+	finalized, err := b.AddDataOrSpill(data)
+	require.NoError(t, err)
+	require.Len(t, finalized, 2)
+
+	for i, share := range finalized {
+		ns, err := share.Namespace()
+		require.NoError(t, err)
+		assert.Equal(t, ns1, ns)
+		version, err := share.Version()
+		require.NoError(t, err)
+		assert.Equal(t, ShareVersionZero, version)
+		isStart, err := share.IsSequenceStart()
+		require.NoError(t, err)
+		assert.Equal(t, i == 0, isStart)
+	}
+	assert.False(t, b.isFirstShare)
+	assert.Equal(t, ContinuationSparseShareContentSize-10, b.AvailableBytes())
+
+	t.Run("data exactly filling a share starts a new empty share", func(t *testing.T) {
+		b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+		finalized, err := b.AddDataOrSpill(bytes.Repeat([]byte{0xaa}, FirstSparseShareContentSize))
+		require.NoError(t, err)
+		assert.Len(t, finalized, 1)
+		assert.True(t, b.IsEmptyShare())
+	})
+
+	t.Run("data fitting in the pending share is not finalized", func(t *testing.T) {
+		b := mustNewBuilder(t, ns1, ShareVersionZero, true)
+		finalized, err := b.AddDataOrSpill([]byte{1, 2, 3})
+		require.NoError(t, err)
+		assert.Empty(t, finalized)
+		assert.False(t, b.IsEmptyShare())
+	})
+}
+
+func TestShareBuilderFlipSequenceStart(t *testing.T) {
+	b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true)
+	require.NoError(t, b.FlipSequenceStart())
+	b.ZeroPadIfNecessary()
+	share, err := b.Build()
+	require.NoError(t, err)
+	isStart, err := share.IsSequenceStart()
+	require.NoError(t, err)
+	assert.False(t, isStart)
+}
+
+// FuzzImportRawShare checks that builders holding arbitrary imported bytes
+// return errors instead of panicking.
+func FuzzImportRawShare(f *testing.F) {
+	txShares, _, _, err := SplitTxs(generateRandomTxs(3, 600))
+	require.NoError(f, err)
+	blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xb}, 2*ShareSize), ShareVersionZero))
+	require.NoError(f, err)
+	for _, share := range append(txShares, blobShares...) {
+		raw := share.ToBytes()
+		f.Add(raw)
+		f.Add(raw[:namespace.NamespaceSize+ShareInfoBytes])
+		f.Add(raw[:namespace.NamespaceSize+ShareInfoBytes+SequenceLenBytes])
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		b, err := NewEmptyBuilder().ImportRawShare(raw)
+		if err != nil {
+			b = NewEmptyBuilder()
+		}
+		_ = b.MaybeWriteReservedBytes()
+		_ = b.FlipSequenceStart()
+		_ = b.WriteSequenceLen(1)
+		_ = b.RewriteSequenceLen(1)
+		_, _, _ = b.AddData([]byte{1, 2, 3})
+		_ = b.IsEmptyShare()
+		_ = b.DataLen()
+		b.ZeroPadIfNecessary()
+		_, _ = b.Build()
+	})
+}
+
+func TestShareBuilderAddTransaction(t *testing.T) {
+	continuationHeaderLen := namespace.NamespaceSize + ShareInfoBytes + CompactShareReservedBytes
+	reservedBytes := func(t *testing.T, b *Builder) uint32 {
+		index := b.indexOfReservedBytes()
+		reserved, err := ParseReservedBytes(b.rawShareData[index : index+CompactShareReservedBytes])
+		require.NoError(t, err)
+		return reserved
+	}
+
+	t.Run("tx fits in the share", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		tx := []byte{1, 2, 3}
+		leftover, err := b.AddTransaction(tx)
+		require.NoError(t, err)
+		assert.Empty(t, leftover)
+		assert.Equal(t, uint32(continuationHeaderLen), reservedBytes(t, b))
+		assert.Equal(t, []byte{3, 1, 2, 3}, b.rawShareData[continuationHeaderLen:])
+
+		// the reserved bytes keep pointing at the first tx
+		_, err = b.AddTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(continuationHeaderLen), reservedBytes(t, b))
+	})
+
+	t.Run("delimiter straddles the share boundary", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		_, _, err := b.AddData(bytes.Repeat([]byte{0xf}, ContinuationCompactShareContentSize-1))
+		require.NoError(t, err)
+
+		// 200 needs a two byte uvarint delimiter
+		tx := bytes.Repeat([]byte{0xa}, 200)
+		delimiter := binary.AppendUvarint(nil, uint64(len(tx)))
+		require.Len(t, delimiter, 2)
+
+		leftover, err := b.AddTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(ShareSize-1), reservedBytes(t, b))
+		assert.Equal(t, delimiter[0], b.rawShareData[ShareSize-1])
+		assert.Equal(t, append(delimiter[1:], tx...), leftover)
+	})
+
+	t.Run("delimiter ends at the share boundary", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		_, _, err := b.AddData(bytes.Repeat([]byte{0xf}, ContinuationCompactShareContentSize-1))
+		require.NoError(t, err)
+
+		tx := []byte{1, 2, 3}
+		leftover, err := b.AddTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, byte(len(tx)), b.rawShareData[ShareSize-1])
+		assert.Equal(t, tx, leftover)
+	})
+
+	t.Run("tx larger than one share", func(t *testing.T) {
+		b := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, true)
+		tx := bytes.Repeat([]byte{0xb}, 2*ShareSize)
+		leftover, err := b.AddTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, b.AvailableBytes())
+		assert.Equal(t, uint32(b.headerLen()), reservedBytes(t, b))
+		assert.Equal(t, tx[FirstCompactShareContentSize-DelimLen(uint64(len(tx))):], leftover)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var nilBuilder *Builder
+		_, err := nilBuilder.AddTransaction([]byte{1})
+		assert.ErrorIs(t, err, ErrNilBuilder)
+
+		_, err = mustNewBuilder(t, ns1, ShareVersionZero, true).AddTransaction([]byte{1})
+		assert.ErrorIs(t, err, ErrNotCompactShare)
+
+		full := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		_, _, err = full.AddData(bytes.Repeat([]byte{1}, ContinuationCompactShareContentSize))
+		require.NoError(t, err)
+		_, err = full.AddTransaction([]byte{1})
+		assert.ErrorIs(t, err, ErrBuilderFull)
+
+		padded := mustNewBuilder(t, namespace.TxNamespace, ShareVersionZero, false)
+		padded.ZeroPadIfNecessary()
+		_, err = padded.AddTransaction([]byte{1})
+		assert.ErrorIs(t, err, ErrBuilderPadded)
+	})
+}
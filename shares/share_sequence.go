@@ -1,7 +1,10 @@
 package shares
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/celestiaorg/go-square/namespace"
 )
@@ -14,6 +17,65 @@ type ShareSequence struct {
 	Shares    []Share
 }
 
+// shareSequenceHeaderSize is the size of the header that precedes the shares
+// of a binary encoded ShareSequence: the namespace followed by the number of
+// shares as a big endian uint32.
+const shareSequenceHeaderSize = namespace.NamespaceSize + 4
+
+// MarshalBinary encodes the share sequence as its namespace, the number of
+// shares as a big endian uint32 and the concatenated raw shares. It returns an
+// error if a share doesn't belong to the namespace of the sequence. It
+// implements the encoding.BinaryMarshaler interface.
+func (s ShareSequence) MarshalBinary() ([]byte, error) {
+	nsBytes := s.Namespace.Bytes()
+	if len(nsBytes) != namespace.NamespaceSize {
+		return nil, fmt.Errorf("invalid namespace length: %v must be %v", len(nsBytes), namespace.NamespaceSize)
+	}
+	if uint64(len(s.Shares)) > math.MaxUint32 {
+		return nil, fmt.Errorf("share sequence of %d shares is too long to encode", len(s.Shares))
+	}
+	data := make([]byte, 0, shareSequenceHeaderSize+len(s.Shares)*ShareSize)
+	data = append(data, nsBytes...)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(s.Shares)))
+	for i := range s.Shares {
+		if !bytes.Equal(s.Shares[i].NamespaceBytes(), nsBytes) {
+			return nil, newParseError(i, &s.Shares[i], fmt.Errorf("%w: share sequence namespace is %x", ErrNamespaceMismatch, nsBytes))
+		}
+		data = append(data, s.Shares[i].data[:]...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a share sequence encoded by MarshalBinary. It
+// returns an error if the data is truncated, has trailing bytes or contains a
+// share that doesn't belong to the namespace in the header. It implements the
+// encoding.BinaryUnmarshaler interface.
+func (s *ShareSequence) UnmarshalBinary(data []byte) error {
+	if len(data) < shareSequenceHeaderSize {
+		return fmt.Errorf("share sequence header is truncated: got %d bytes, want %d", len(data), shareSequenceHeaderSize)
+	}
+	ns, err := namespace.From(append([]byte(nil), data[:namespace.NamespaceSize]...))
+	if err != nil {
+		return err
+	}
+	count := uint64(binary.BigEndian.Uint32(data[namespace.NamespaceSize:shareSequenceHeaderSize]))
+	body := data[shareSequenceHeaderSize:]
+	if uint64(len(body)) != count*ShareSize {
+		return fmt.Errorf("share sequence of %d shares must have %d bytes of shares, got %d", count, count*ShareSize, len(body))
+	}
+
+	shares := make([]Share, count)
+	for i := range shares {
+		copy(shares[i].data[:], body[i*ShareSize:(i+1)*ShareSize])
+		if shares[i].CompareNamespace(ns) != 0 {
+			return newParseError(i, &shares[i], fmt.Errorf("%w: share sequence namespace is %x", ErrNamespaceMismatch, ns.Bytes()))
+		}
+	}
+	s.Namespace = ns
+	s.Shares = shares
+	return nil
+}
+
 // RawData returns the raw share data of this share sequence. The raw data does
 // not contain the namespace ID, info byte, sequence length, or reserved bytes.
 func (s ShareSequence) RawData() (data []byte, err error) {
@@ -33,6 +95,26 @@ func (s ShareSequence) RawData() (data []byte, err error) {
 	return data[:sequenceLen], nil
 }
 
+// RawDataStrict is like RawData but it returns an error wrapping
+// ErrNonZeroPadding instead of silently discarding the padding of the last
+// share if the padding contains a non-zero byte. See Unpad.
+func (s ShareSequence) RawDataStrict() ([]byte, error) {
+	var data []byte
+	for _, share := range s.Shares {
+		raw, err := share.RawData()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, raw...)
+	}
+
+	sequenceLen, err := s.SequenceLen()
+	if err != nil {
+		return nil, err
+	}
+	return Unpad(data, uint64(sequenceLen))
+}
+
 func (s ShareSequence) SequenceLen() (uint32, error) {
 	if len(s.Shares) == 0 {
 		return 0, fmt.Errorf("invalid sequence length because share sequence %v has no shares", s)
@@ -41,20 +123,117 @@ func (s ShareSequence) SequenceLen() (uint32, error) {
 	return firstShare.SequenceLen()
 }
 
+// SegmentInfo describes the part of the data of a share sequence that is
+// carried by one share of the sequence.
+type SegmentInfo struct {
+	// ShareIndex is the index of the share in the sequence.
+	ShareIndex int
+	// ShareDataStart and ShareDataEnd are the end exclusive bounds of the data
+	// within the share. The bounds exclude the share header and the padding.
+	ShareDataStart int
+	ShareDataEnd   int
+	// BlobByteStart and BlobByteEnd are the end exclusive bounds of the data
+	// carried by the share within the data of the sequence.
+	BlobByteStart int
+	BlobByteEnd   int
+}
+
+// PayloadLayout returns, for every share of the sequence, where the data of
+// the sequence is located in the share and which bytes of the data of the
+// sequence it carries. The data of the last share ends at the sequence
+// length. For compact sequences the data includes the unit length delimiters.
+// It returns an error if the sequence length doesn't match the number of
+// shares.
+func (s ShareSequence) PayloadLayout() ([]SegmentInfo, error) {
+	if err := s.validSequenceLen(); err != nil {
+		return nil, err
+	}
+	sequenceLen, err := s.SequenceLen()
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]SegmentInfo, len(s.Shares))
+	offset := 0
+	for i := range s.Shares {
+		start := s.Shares[i].layout().payloadIdx
+		n := min(ShareSize-start, int(sequenceLen)-offset)
+		segments[i] = SegmentInfo{
+			ShareIndex:     i,
+			ShareDataStart: start,
+			ShareDataEnd:   start + n,
+			BlobByteStart:  offset,
+			BlobByteEnd:    offset + n,
+		}
+		offset += n
+	}
+	return segments, nil
+}
+
+// ExpectedSequenceLen re-derives the sequence length of a contiguous run of
+// shares that make up one sequence. It sums the raw data capacity of every
+// share, taking the first share and compact share overhead into account, and
+// subtracts the trailing zero padding of the last share. The result can be
+// compared against the sequence length declared in the first share to detect
+// a forged length. Note that zero bytes at the end of the original data can't
+// be distinguished from padding so the declared length may exceed the result
+// by the number of such bytes.
+func ExpectedSequenceLen(shares []Share) (uint32, error) {
+	if len(shares) == 0 {
+		return 0, fmt.Errorf("cannot derive sequence length from zero shares")
+	}
+	ns, err := shares[0].Namespace()
+	if err != nil {
+		return 0, err
+	}
+	sequenceLen := 0
+	for i, share := range shares {
+		if err := share.validateFull(); err != nil {
+			return 0, newParseError(i, &shares[i], err)
+		}
+		shareNamespace, err := share.Namespace()
+		if err != nil {
+			return 0, err
+		}
+		if !shareNamespace.Equals(ns) {
+			return 0, newParseError(i, &shares[i], fmt.Errorf("%w: sequence has namespace %x", ErrNamespaceMismatch, ns.Bytes()))
+		}
+		isStart, err := share.IsSequenceStart()
+		if err != nil {
+			return 0, err
+		}
+		if isStart != (i == 0) {
+			if i == 0 {
+				return 0, newParseError(i, &shares[i], ErrMissingSequenceStart)
+			}
+			return 0, newParseError(i, &shares[i], ErrUnexpectedSequenceStart)
+		}
+		rawData, err := share.RawData()
+		if err != nil {
+			return 0, err
+		}
+		if i == len(shares)-1 {
+			rawData = bytes.TrimRight(rawData, "\x00")
+		}
+		sequenceLen += len(rawData)
+	}
+	return uint32(sequenceLen), nil
+}
+
 // validSequenceLen extracts the sequenceLen written to the first share
 // and returns an error if the number of shares needed to store a sequence of
 // length sequenceLen doesn't match the number of shares in this share
 // sequence. Returns nil if there is no error.
 func (s ShareSequence) validSequenceLen() error {
 	if len(s.Shares) == 0 {
-		return fmt.Errorf("invalid sequence length because share sequence %v has no shares", s)
+		return fmt.Errorf("%w: share sequence %v has no shares", ErrInvalidSequenceLen, s)
 	}
 	isPadding, err := s.isPadding()
 	if err != nil {
 		return err
 	}
 	if isPadding {
-		return nil
+		return ValidatePaddingShare(s.Shares[0])
 	}
 
 	firstShare := s.Shares[0]
@@ -64,11 +243,103 @@ func (s ShareSequence) validSequenceLen() error {
 	}
 
 	if len(s.Shares) != sharesNeeded {
-		return fmt.Errorf("share sequence has %d shares but needed %d shares", len(s.Shares), sharesNeeded)
+		return fmt.Errorf("%w: share sequence has %d shares but needed %d shares", ErrInvalidSequenceLen, len(s.Shares), sharesNeeded)
+	}
+	return nil
+}
+
+// Validate checks the invariants of the share sequence: only the first share
+// is the start of a sequence, every share has the namespace and share version
+// of the first share, the sequence length matches the number of shares and the
+// bytes following the sequence length in the last share are zero padding.
+// Errors are returned as a *ParseError whose index is relative to s.Shares.
+func (s ShareSequence) Validate() error {
+	index, err := s.validate()
+	if err != nil {
+		return newParseError(index, &s.Shares[index], err)
 	}
 	return nil
 }
 
+// validate is like Validate but it returns the index of the violating share
+// alongside an unwrapped error.
+func (s ShareSequence) validate() (index int, err error) {
+	if len(s.Shares) == 0 {
+		return 0, fmt.Errorf("%w: share sequence has no shares", ErrInvalidSequenceLen)
+	}
+	first := &s.Shares[0]
+	ns, err := first.Namespace()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err)
+	}
+	if len(s.Namespace.ID) != 0 && !s.Namespace.Equals(ns) {
+		return 0, fmt.Errorf("%w: the sequence has namespace %x", ErrNamespaceMismatch, s.Namespace.Bytes())
+	}
+	infoByte, err := first.InfoByte()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range s.Shares {
+		share := &s.Shares[i]
+		if err := share.validateFull(); err != nil {
+			return i, err
+		}
+		shareNs, err := share.Namespace()
+		if err != nil {
+			return i, err
+		}
+		if !shareNs.Equals(ns) {
+			return i, fmt.Errorf("%w: the sequence has namespace %x", ErrNamespaceMismatch, ns.Bytes())
+		}
+		shareInfoByte, err := share.InfoByte()
+		if err != nil {
+			return i, err
+		}
+		if shareInfoByte.Version() != infoByte.Version() {
+			return i, fmt.Errorf("%w: share version %d differs from the share version %d of the sequence", ErrShareVersionMismatch, shareInfoByte.Version(), infoByte.Version())
+		}
+		if i == 0 && !shareInfoByte.IsSequenceStart() {
+			return i, ErrMissingSequenceStart
+		}
+		if i > 0 && shareInfoByte.IsSequenceStart() {
+			return i, ErrUnexpectedSequenceStart
+		}
+	}
+
+	if err := s.validSequenceLen(); err != nil {
+		return 0, err
+	}
+	isPadding, err := s.isPadding()
+	if err != nil || isPadding {
+		// padding shares are validated by validSequenceLen
+		return 0, err
+	}
+
+	sequenceLen, err := first.SequenceLen()
+	if err != nil {
+		return 0, err
+	}
+	last := len(s.Shares) - 1
+	dataLen := 0
+	for i := 0; i < last; i++ {
+		rawData, err := s.Shares[i].RawData()
+		if err != nil {
+			return i, err
+		}
+		dataLen += len(rawData)
+	}
+	rawData, err := s.Shares[last].RawData()
+	if err != nil {
+		return last, err
+	}
+	// the number of shares matches so the sequence length ends in the last share
+	if !IsAllZero(rawData[int(sequenceLen)-dataLen:]) {
+		return last, fmt.Errorf("%w: the last share contains data past the sequence length %d", ErrInvalidSequenceLen, sequenceLen)
+	}
+	return 0, nil
+}
+
 func (s ShareSequence) isPadding() (bool, error) {
 	if len(s.Shares) != 1 {
 		return false, nil
@@ -96,7 +367,11 @@ func numberOfSharesNeeded(firstShare Share) (sharesUsed int, err error) {
 	if isCompact {
 		return CompactSharesNeeded(int(sequenceLen)), nil
 	}
-	return SparseSharesNeeded(sequenceLen), nil
+	version, err := firstShare.Version()
+	if err != nil {
+		return 0, err
+	}
+	return SparseSharesNeededForVersion(sequenceLen, version), nil
 }
 
 // CompactSharesNeeded returns the number of compact shares needed to store a
@@ -121,21 +396,73 @@ func CompactSharesNeeded(sequenceLen int) (sharesNeeded int) {
 }
 
 // SparseSharesNeeded returns the number of shares needed to store a sequence of
-// length sequenceLen.
+// length sequenceLen with share version 0.
 func SparseSharesNeeded(sequenceLen uint32) (sharesNeeded int) {
+	return SparseSharesNeededForVersion(sequenceLen, ShareVersionZero)
+}
+
+// SparseSharesNeededForVersion returns the number of shares needed to store a
+// sequence of length sequenceLen with the provided share version. The first
+// share of a share version 1 sequence has less room for data because it
+// contains the signer.
+func SparseSharesNeededForVersion(sequenceLen uint32, shareVersion uint8) (sharesNeeded int) {
 	if sequenceLen == 0 {
 		return 0
 	}
 
-	if sequenceLen < FirstSparseShareContentSize {
+	firstShareContentSize := FirstSparseShareContentSize
+	if containsSigner(shareVersion, true, false) {
+		firstShareContentSize = FirstSparseShareWithSignerContentSize
+	}
+	if sequenceLen <= uint32(firstShareContentSize) {
 		return 1
 	}
 
-	bytesAvailable := FirstSparseShareContentSize
-	sharesNeeded++
-	for uint32(bytesAvailable) < sequenceLen {
-		bytesAvailable += ContinuationSparseShareContentSize
-		sharesNeeded++
+	// computed in closed form because accumulating the bytes available in
+	// every share overflows for sequences close to math.MaxUint32 bytes
+	remaining := uint64(sequenceLen) - uint64(firstShareContentSize)
+	return 1 + int((remaining+ContinuationSparseShareContentSize-1)/ContinuationSparseShareContentSize)
+}
+
+// TotalSparseSharesNeeded returns the number of shares needed to store blobs
+// of the provided sizes with share version 0. Every blob starts a new share so
+// this is the sum of SparseSharesNeeded over blobSizes. It does not account for
+// any padding between the blobs. The result saturates at math.MaxInt instead
+// of overflowing.
+func TotalSparseSharesNeeded(blobSizes []uint32) (sharesNeeded int) {
+	for _, size := range blobSizes {
+		sharesNeeded = addSaturating(sharesNeeded, SparseSharesNeeded(size))
 	}
 	return sharesNeeded
 }
+
+// TotalSparseSharesNeededWithPadding is like TotalSparseSharesNeeded but it
+// also accounts for one namespace padding share at every boundary between the
+// blobs of two different namespaces. namespaces[i] is the namespace of the
+// blob of size blobSizes[i] and the blobs must be sorted by namespace. The
+// actual amount of padding depends on the layout of the square so the result
+// is an estimate rather than an upper bound.
+func TotalSparseSharesNeededWithPadding(namespaces []namespace.Namespace, blobSizes []uint32) (sharesNeeded int, err error) {
+	if len(namespaces) != len(blobSizes) {
+		return 0, fmt.Errorf("got %d namespaces for %d blob sizes", len(namespaces), len(blobSizes))
+	}
+	sharesNeeded = TotalSparseSharesNeeded(blobSizes)
+	for i := 1; i < len(namespaces); i++ {
+		switch {
+		case namespaces[i].IsLessThan(namespaces[i-1]):
+			return 0, fmt.Errorf("%w: namespace of blob %d is lower than the namespace of blob %d", ErrNamespaceOrder, i, i-1)
+		case !namespaces[i].Equals(namespaces[i-1]):
+			sharesNeeded = addSaturating(sharesNeeded, 1)
+		}
+	}
+	return sharesNeeded, nil
+}
+
+// addSaturating returns a + b for non-negative a and b or math.MaxInt if the
+// sum overflows.
+func addSaturating(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
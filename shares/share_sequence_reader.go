@@ -0,0 +1,137 @@
+package shares
+
+import (
+	"fmt"
+	"io"
+)
+
+// shareSequenceReader streams the data of a share sequence share by share. It
+// implements io.Reader and io.WriterTo.
+type shareSequenceReader struct {
+	shares []Share
+	// remaining is the number of bytes of the sequence that haven't been read.
+	remaining int
+	// share is the index of the share that is being read and offset the
+	// position in it of the next byte to read or 0 if the share hasn't been
+	// checked yet.
+	share  int
+	offset int
+	err    error
+}
+
+// Reader returns a reader of the raw data of the share sequence, i.e. the data
+// returned by RawData, that reads the data from the shares as it is consumed
+// instead of concatenating it up front. The reader stops at the sequence
+// length so the padding of the last share and any shares past the sequence
+// length are never read. Shares are checked as they are reached: a malformed
+// share, a share that is in another namespace or starts another sequence, or
+// running out of shares before the sequence length fails the read with a
+// *ParseError. The returned reader also implements io.WriterTo. It returns an
+// error if the first share can't start a sequence.
+func (s ShareSequence) Reader() (io.Reader, error) {
+	if len(s.Shares) == 0 {
+		return nil, fmt.Errorf("%w: share sequence has no shares", ErrInvalidSequenceLen)
+	}
+	r := &shareSequenceReader{shares: s.Shares}
+	if err := r.checkShare(); err != nil {
+		return nil, err
+	}
+	sequenceLen, err := s.Shares[0].SequenceLen()
+	if err != nil {
+		return nil, newParseError(0, &s.Shares[0], err)
+	}
+	r.remaining = int(sequenceLen)
+	return r, nil
+}
+
+// Read implements io.Reader.
+func (r *shareSequenceReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		segment, err := r.next()
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], segment)
+		r.advance(copied)
+		n += copied
+	}
+	return n, nil
+}
+
+// WriteTo writes the rest of the data of the sequence to w without an
+// intermediate buffer. It implements io.WriterTo.
+func (r *shareSequenceReader) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for {
+		segment, err := r.next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		written, err := w.Write(segment)
+		r.advance(written)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+		if written != len(segment) {
+			return n, io.ErrShortWrite
+		}
+	}
+}
+
+// next returns the unread data of the current share up to the sequence length,
+// moving to and checking the next share if the current one has been read. It
+// returns io.EOF once the sequence length has been read.
+func (r *shareSequenceReader) next() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.remaining == 0 {
+		return nil, io.EOF
+	}
+	if r.offset == ShareSize {
+		r.share++
+		r.offset = 0
+	}
+	if r.offset == 0 {
+		if err := r.checkShare(); err != nil {
+			r.err = err
+			return nil, err
+		}
+	}
+	data := r.shares[r.share].data[r.offset:]
+	return data[:min(len(data), r.remaining)], nil
+}
+
+// advance marks n bytes of the current share as read.
+func (r *shareSequenceReader) advance(n int) {
+	r.offset += n
+	r.remaining -= n
+}
+
+// checkShare checks the current share and positions the reader at its data.
+func (r *shareSequenceReader) checkShare() error {
+	if r.share == len(r.shares) {
+		return newParseError(0, &r.shares[0], fmt.Errorf("%w: %d shares end %d bytes before the sequence length", ErrInvalidSequenceLen, len(r.shares), r.remaining))
+	}
+	share := &r.shares[r.share]
+	if err := share.validateFull(); err != nil {
+		return newParseError(r.share, share, err)
+	}
+	if share.CompareNamespace(r.shares[0].RawNamespace()) != 0 {
+		return newParseError(r.share, share, fmt.Errorf("%w: the sequence has namespace %x", ErrNamespaceMismatch, r.shares[0].RawNamespace().Bytes()))
+	}
+	layout := share.layout()
+	switch {
+	case r.share == 0 && !layout.infoByte.IsSequenceStart():
+		return newParseError(r.share, share, ErrMissingSequenceStart)
+	case r.share > 0 && layout.infoByte.IsSequenceStart():
+		return newParseError(r.share, share, ErrUnexpectedSequenceStart)
+	}
+	r.offset = layout.payloadIdx
+	return nil
+}
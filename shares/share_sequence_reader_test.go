@@ -0,0 +1,168 @@
+package shares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareSequenceReader(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+	sizes := []int{1, FirstSparseShareWithSignerContentSize, FirstSparseShareContentSize, FirstSparseShareContentSize + ContinuationSparseShareContentSize}
+	for i := 0; i < 20; i++ {
+		sizes = append(sizes, 1+rng.Intn(20_000))
+	}
+
+	var sequences []ShareSequence
+	for _, shareVersion := range []uint8{ShareVersionZero, ShareVersionOne} {
+		for _, size := range sizes {
+			b := generateRandomBlobWithNamespace(ns1, size)
+			b.ShareVersion = uint32(shareVersion)
+			if shareVersion == ShareVersionOne {
+				b.Signer = signer
+			}
+			sequences = append(sequences, ShareSequence{Namespace: ns1, Shares: mustSplitBlobs(t, b)})
+		}
+	}
+	txShares, _, _, err := SplitTxs(generateRandomTxs(10, 200))
+	require.NoError(t, err)
+	sequences = append(sequences,
+		ShareSequence{Namespace: namespace.TxNamespace, Shares: txShares},
+		ShareSequence{Namespace: ns1, Shares: mustNamespacePaddingShares(t, ns1, 1)},
+		// the sequence length ends exactly on a share boundary and the final
+		// share is nothing but padding
+		ShareSequence{Namespace: ns1, Shares: []Share{
+			shareWithData(ns1, true, FirstSparseShareContentSize, bytes.Repeat([]byte{0xf}, FirstSparseShareContentSize)),
+			shareWithData(ns1, false, 0, nil),
+		}},
+	)
+
+	for i, sequence := range sequences {
+		want, err := sequence.RawData()
+		require.NoError(t, err)
+
+		r, err := sequence.Reader()
+		require.NoError(t, err)
+		require.NoError(t, iotest.TestReader(r, want), "sequence %d", i)
+
+		r, err = sequence.Reader()
+		require.NoError(t, err)
+		got, err := io.ReadAll(iotest.OneByteReader(r))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "sequence %d", i)
+
+		r, err = sequence.Reader()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(want)), n)
+		assert.Equal(t, string(want), buf.String(), "sequence %d", i)
+	}
+}
+
+func TestShareSequenceReaderErrors(t *testing.T) {
+	_, err := ShareSequence{Namespace: ns1}.Reader()
+	assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+
+	newSequence := func() ShareSequence {
+		return ShareSequence{Namespace: ns1, Shares: mustSplitBlobs(t, generateRandomBlobWithNamespace(ns1, 2000))}
+	}
+	sequence := newSequence()
+	_, err = ShareSequence{Namespace: ns1, Shares: sequence.Shares[1:]}.Reader()
+	assert.ErrorIs(t, err, ErrMissingSequenceStart)
+
+	testCases := []struct {
+		name      string
+		corrupt   func(s *ShareSequence)
+		wantErr   error
+		wantIndex int
+	}{
+		{
+			name:      "missing share",
+			corrupt:   func(s *ShareSequence) { s.Shares = s.Shares[:len(s.Shares)-1] },
+			wantErr:   ErrInvalidSequenceLen,
+			wantIndex: 0,
+		},
+		{
+			name:      "unexpected sequence start",
+			corrupt:   func(s *ShareSequence) { s.Shares[2].data[namespace.NamespaceSize] |= 1 },
+			wantErr:   ErrUnexpectedSequenceStart,
+			wantIndex: 2,
+		},
+		{
+			name:      "unsupported share version",
+			corrupt:   func(s *ShareSequence) { s.Shares[1].data[namespace.NamespaceSize] = 5 << 1 },
+			wantErr:   ErrUnsupportedShareVersion,
+			wantIndex: 1,
+		},
+		{
+			name:      "namespace mismatch",
+			corrupt:   func(s *ShareSequence) { s.Shares[3].data[namespace.NamespaceSize-1]++ },
+			wantErr:   ErrNamespaceMismatch,
+			wantIndex: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, copyFn := range []func(io.Reader) ([]byte, error){
+				io.ReadAll,
+				func(r io.Reader) ([]byte, error) {
+					var buf bytes.Buffer
+					_, err := r.(io.WriterTo).WriteTo(&buf)
+					return buf.Bytes(), err
+				},
+			} {
+				sequence := newSequence()
+				want, err := sequence.RawData()
+				require.NoError(t, err)
+				tc.corrupt(&sequence)
+				r, err := sequence.Reader()
+				require.NoError(t, err)
+				got, err := copyFn(r)
+				require.ErrorIs(t, err, tc.wantErr)
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+				assert.Equal(t, tc.wantIndex, parseErr.Index)
+				// the data before the malformed share is returned
+				assert.Equal(t, want[:len(got)], got)
+
+				// the error is sticky
+				_, err = r.Read(make([]byte, 1))
+				assert.True(t, errors.Is(err, tc.wantErr))
+			}
+		})
+	}
+}
+
+func BenchmarkShareSequenceReader(b *testing.B) {
+	blob := generateRandomBlobWithNamespace(ns1, 2*1024*1024)
+	blobShares, err := SplitBlobs(blob)
+	require.NoError(b, err)
+	sequence := ShareSequence{Namespace: ns1, Shares: blobShares}
+	b.Run("RawData", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := sequence.RawData()
+			require.NoError(b, err)
+			_, _ = io.Discard.Write(data)
+		}
+	})
+	b.Run("Reader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := sequence.Reader()
+			require.NoError(b, err)
+			_, err = io.Copy(io.Discard, r)
+			require.NoError(b, err)
+		}
+	})
+}
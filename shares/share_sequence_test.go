@@ -3,8 +3,12 @@ package shares
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
 	"testing"
 
+	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,7 +21,7 @@ func TestShareSequenceRawData(t *testing.T) {
 		want          []byte
 		wantErr       bool
 	}
-	blobNamespace := namespace.RandomBlobNamespace()
+	blobNamespace := namespace.RandomBlobNamespace(rand.New(rand.NewSource(1)))
 
 	testCases := []testCase{
 		{
@@ -77,6 +81,179 @@ func TestShareSequenceRawData(t *testing.T) {
 	}
 }
 
+func TestShareSequenceRawDataStrict(t *testing.T) {
+	blobNamespace := namespace.RandomBlobNamespace(rand.New(rand.NewSource(1)))
+	data := bytes.Repeat([]byte{0xf}, FirstSparseShareContentSize+1)
+	sequence := ShareSequence{
+		Namespace: blobNamespace,
+		Shares: []Share{
+			shareWithData(blobNamespace, true, uint32(len(data)), data[:FirstSparseShareContentSize]),
+			shareWithData(blobNamespace, false, 0, data[FirstSparseShareContentSize:]),
+		},
+	}
+	got, err := sequence.RawDataStrict()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// garbage in the padding of the last share is silently dropped by RawData
+	// but rejected by RawDataStrict
+	sequence.Shares[1] = shareWithData(blobNamespace, false, 0, []byte{0xf, 0, 0xa})
+	got, err = sequence.RawData()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	_, err = sequence.RawDataStrict()
+	assert.ErrorIs(t, err, ErrNonZeroPadding)
+	assert.ErrorContains(t, err, fmt.Sprintf("offset %d", FirstSparseShareContentSize+2))
+}
+
+func TestExpectedSequenceLen(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{0x2}, namespace.NamespaceVersionZeroIDSize))
+	sizes := []int{1, FirstSparseShareContentSize, FirstSparseShareContentSize + 1, 5 * ContinuationSparseShareContentSize}
+	for _, size := range sizes {
+		blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xff}, size), ShareVersionZero))
+		require.NoError(t, err)
+		got, err := ExpectedSequenceLen(blobShares)
+		require.NoError(t, err)
+		declared, err := blobShares[0].SequenceLen()
+		require.NoError(t, err)
+		assert.Equal(t, declared, got, "size %d", size)
+	}
+
+	txs := [][]byte{bytes.Repeat([]byte{0xff}, 600), bytes.Repeat([]byte{0xff}, 700)}
+	txShares, _, _, err := SplitTxs(txs)
+	require.NoError(t, err)
+	got, err := ExpectedSequenceLen(txShares)
+	require.NoError(t, err)
+	declared, err := txShares[0].SequenceLen()
+	require.NoError(t, err)
+	assert.Equal(t, declared, got)
+
+	// a forged sequence length no longer matches the re-derived one
+	forged := shareWithData(ns1, true, FirstSparseShareContentSize+1, bytes.Repeat([]byte{0xf}, 10))
+	got, err = ExpectedSequenceLen([]Share{forged})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), got)
+
+	// a fully padded final share contributes no data
+	full := shareWithData(ns1, true, FirstSparseShareContentSize, bytes.Repeat([]byte{0xf}, FirstSparseShareContentSize))
+	got, err = ExpectedSequenceLen([]Share{full, shareWithData(ns1, false, 0, nil)})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(FirstSparseShareContentSize), got)
+
+	errCases := map[string][]Share{
+		"no shares":                nil,
+		"starts with continuation": {shareWithData(ns1, false, 0, []byte{1})},
+		"second sequence start":    {full, shareWithData(ns1, true, 1, []byte{1})},
+		"mixed namespaces":         {full, shareWithData(ns2, false, 0, []byte{1})},
+	}
+	for name, shares := range errCases {
+		_, err := ExpectedSequenceLen(shares)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestShareSequenceValidate(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xf}, 1000), ShareVersionZero))
+	require.NoError(t, err)
+	txShares, _, _, err := SplitTxs([][]byte{bytes.Repeat([]byte{0xa}, 1000)})
+	require.NoError(t, err)
+
+	// modify returns a copy of blobShares where the share at index has been
+	// changed by fn.
+	modify := func(index int, fn func(share *Share)) []Share {
+		shares := append([]Share{}, blobShares...)
+		fn(&shares[index])
+		return shares
+	}
+	// tooSmallSequenceLen declares 5 bytes but carries 10 bytes of data
+	tooSmallSequenceLen := shareWithData(ns1, true, 5, bytes.Repeat([]byte{0xf}, 10))
+
+	type testCase struct {
+		name      string
+		sequence  ShareSequence
+		wantErr   error
+		wantIndex int
+	}
+	testCases := []testCase{
+		{
+			name:     "valid blob sequence",
+			sequence: ShareSequence{Namespace: ns1, Shares: blobShares},
+		},
+		{
+			name:     "valid tx sequence",
+			sequence: ShareSequence{Namespace: namespace.TxNamespace, Shares: txShares},
+		},
+		{
+			name:     "tail padding",
+			sequence: ShareSequence{Namespace: namespace.TailPaddingNamespace, Shares: TailPaddingShares(1)},
+		},
+		{
+			name:     "first share is not a sequence start",
+			sequence: ShareSequence{Namespace: ns1, Shares: blobShares[1:]},
+			wantErr:  ErrMissingSequenceStart,
+		},
+		{
+			name:      "continuation share is a sequence start",
+			sequence:  ShareSequence{Namespace: ns1, Shares: modify(1, func(share *Share) { share.data[namespace.NamespaceSize] |= 1 })},
+			wantErr:   ErrUnexpectedSequenceStart,
+			wantIndex: 1,
+		},
+		{
+			name:      "namespace mismatch",
+			sequence:  ShareSequence{Namespace: ns1, Shares: modify(2, func(share *Share) { copy(share.data[:], ns2.Bytes()) })},
+			wantErr:   ErrNamespaceMismatch,
+			wantIndex: 2,
+		},
+		{
+			name: "share version mismatch",
+			sequence: ShareSequence{Namespace: ns1, Shares: modify(1, func(share *Share) {
+				share.data[namespace.NamespaceSize] = byte(ShareVersionOne << 1)
+			})},
+			wantErr:   ErrShareVersionMismatch,
+			wantIndex: 1,
+		},
+		{
+			name:     "too many shares for the sequence length",
+			sequence: ShareSequence{Namespace: ns1, Shares: append(append([]Share{}, blobShares...), blobShares[1])},
+			wantErr:  ErrInvalidSequenceLen,
+		},
+		{
+			name:     "data past the sequence length",
+			sequence: ShareSequence{Namespace: ns1, Shares: []Share{tooSmallSequenceLen}},
+			wantErr:  ErrInvalidSequenceLen,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.sequence.Validate()
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tc.wantIndex, parseErr.Index)
+		})
+	}
+
+	t.Run("ParseShares validates sequences", func(t *testing.T) {
+		shares := append(append([]Share{}, blobShares...), tooSmallSequenceLen)
+		_, err := ParseShares(shares, false)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, len(blobShares), parseErr.Index)
+		assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+
+		sequences, err := ParseShares(shares, false, WithoutSequenceValidation())
+		require.NoError(t, err)
+		assert.Len(t, sequences, 2)
+	})
+}
+
 func TestCompactSharesNeeded(t *testing.T) {
 	type testCase struct {
 		sequenceLen int
@@ -121,6 +298,86 @@ func TestSparseSharesNeeded(t *testing.T) {
 	}
 }
 
+func TestSparseSharesNeededForVersion(t *testing.T) {
+	type testCase struct {
+		sequenceLen  uint32
+		shareVersion uint8
+		want         int
+	}
+	testCases := []testCase{
+		{0, ShareVersionOne, 0},
+		{1, ShareVersionOne, 1},
+		{FirstSparseShareWithSignerContentSize, ShareVersionOne, 1},
+		{FirstSparseShareWithSignerContentSize + 1, ShareVersionOne, 2},
+		{FirstSparseShareContentSize, ShareVersionZero, 1},
+		{FirstSparseShareContentSize, ShareVersionOne, 2},
+		{FirstSparseShareWithSignerContentSize + ContinuationSparseShareContentSize, ShareVersionOne, 2},
+	}
+	for _, tc := range testCases {
+		got := SparseSharesNeededForVersion(tc.sequenceLen, tc.shareVersion)
+		assert.Equal(t, tc.want, got)
+	}
+}
+
+// TestMaxDataSizeInSquare checks that blob.MaxDataSizeInSquare, which mirrors
+// the share layout of this package, fills exactly a square.
+func TestMaxDataSizeInSquare(t *testing.T) {
+	for _, squareSize := range []int{1, 2, 64, 128} {
+		for _, shareVersion := range []uint8{ShareVersionZero, ShareVersionOne} {
+			maxSize := blob.MaxDataSizeInSquare(squareSize, shareVersion)
+			assert.Equal(t, squareSize*squareSize, SparseSharesNeededForVersion(uint32(maxSize), shareVersion))
+			assert.Equal(t, squareSize*squareSize+1, SparseSharesNeededForVersion(uint32(maxSize)+1, shareVersion))
+		}
+	}
+}
+
+func TestTotalSparseSharesNeeded(t *testing.T) {
+	// the number of shares needed for a blob of math.MaxUint32 bytes
+	maxBlobShares := 1 + (math.MaxUint32-FirstSparseShareContentSize+ContinuationSparseShareContentSize-1)/ContinuationSparseShareContentSize
+
+	type testCase struct {
+		name      string
+		blobSizes []uint32
+		want      int
+	}
+	testCases := []testCase{
+		{"no blobs", nil, 0},
+		{"one blob", []uint32{1000}, 3},
+		{"every blob starts a new share", []uint32{1, 1, 1}, 3},
+		{"blobs filling whole shares", []uint32{FirstSparseShareContentSize, FirstSparseShareContentSize + ContinuationSparseShareContentSize}, 3},
+		{"empty blobs", []uint32{0, 1}, 1},
+		{"pathological sizes", []uint32{math.MaxUint32, math.MaxUint32}, 2 * maxBlobShares},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, TotalSparseSharesNeeded(tc.blobSizes))
+		})
+	}
+
+	assert.Equal(t, math.MaxInt, addSaturating(math.MaxInt, 1))
+	assert.Equal(t, math.MaxInt, addSaturating(math.MaxInt-1, 2))
+	assert.Equal(t, math.MaxInt, addSaturating(math.MaxInt-1, 1))
+}
+
+func TestTotalSparseSharesNeededWithPadding(t *testing.T) {
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+
+	got, err := TotalSparseSharesNeededWithPadding([]namespace.Namespace{ns1, ns1, ns2, ns3}, []uint32{1, 1, 1000, 1})
+	require.NoError(t, err)
+	assert.Equal(t, 6+2, got)
+
+	got, err = TotalSparseSharesNeededWithPadding(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got)
+
+	_, err = TotalSparseSharesNeededWithPadding([]namespace.Namespace{ns2, ns1}, []uint32{1, 1})
+	assert.ErrorIs(t, err, ErrNamespaceOrder)
+
+	_, err = TotalSparseSharesNeededWithPadding([]namespace.Namespace{ns1}, []uint32{1, 1})
+	assert.Error(t, err)
+}
+
 func shareWithData(namespace namespace.Namespace, isSequenceStart bool, sequenceLen uint32, data []byte) (rawShare Share) {
 	infoByte, _ := NewInfoByte(ShareVersionZero, isSequenceStart)
 	rawShareBytes := make([]byte, 0, ShareSize)
@@ -133,7 +390,7 @@ func shareWithData(namespace namespace.Namespace, isSequenceStart bool, sequence
 	}
 	rawShareBytes = append(rawShareBytes, data...)
 
-	return padShare(Share{data: rawShareBytes})
+	return padShare(rawShareBytes)
 }
 
 func Test_validSequenceLen(t *testing.T) {
@@ -251,3 +508,149 @@ func FuzzValidSequenceLen(f *testing.F) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestShareSequenceBinaryRoundTrip(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	blobShares := mustSplitBlobs(t, generateRandomBlobWithNamespace(ns1, 2*ShareSize))
+	sequence := ShareSequence{Namespace: ns1, Shares: blobShares}
+
+	data, err := sequence.MarshalBinary()
+	require.NoError(t, err)
+	assert.Len(t, data, shareSequenceHeaderSize+len(blobShares)*ShareSize)
+
+	var got ShareSequence
+	require.NoError(t, got.UnmarshalBinary(data))
+	assert.Equal(t, sequence, got)
+
+	empty := ShareSequence{Namespace: ns1, Shares: []Share{}}
+	data, err = empty.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, got.UnmarshalBinary(data))
+	assert.Equal(t, empty, got)
+}
+
+func TestShareSequenceUnmarshalBinaryErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	data, err := ShareSequence{Namespace: ns1, Shares: mustSplitBlobs(t, generateRandomBlobWithNamespace(ns1, 2*ShareSize))}.MarshalBinary()
+	require.NoError(t, err)
+
+	mismatched := append([]byte(nil), data...)
+	copy(mismatched, ns2.Bytes())
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "empty", data: nil},
+		{name: "truncated header", data: data[:shareSequenceHeaderSize-1]},
+		{name: "truncated shares", data: data[:len(data)-1]},
+		{name: "trailing bytes", data: append(append([]byte(nil), data...), 0)},
+		{name: "invalid namespace", data: append([]byte{0xFE}, data[1:]...)},
+		{name: "namespace mismatch", data: mismatched, wantErr: ErrNamespaceMismatch},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got ShareSequence
+			err := got.UnmarshalBinary(tc.data)
+			require.Error(t, err)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+			assert.Equal(t, ShareSequence{}, got)
+		})
+	}
+
+	t.Run("marshal namespace mismatch", func(t *testing.T) {
+		_, err := ShareSequence{Namespace: ns2, Shares: mustSplitBlobs(t, generateRandomBlobWithNamespace(ns1, ShareSize))}.MarshalBinary()
+		assert.ErrorIs(t, err, ErrNamespaceMismatch)
+	})
+}
+
+func FuzzShareSequenceUnmarshalBinary(f *testing.F) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	seed, err := ShareSequence{Namespace: ns1, Shares: mustSplitBlobs(f, generateRandomBlobWithNamespace(ns1, ShareSize))}.MarshalBinary()
+	require.NoError(f, err)
+	f.Add(seed)
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var sequence ShareSequence
+		if err := sequence.UnmarshalBinary(data); err != nil {
+			return
+		}
+		got, err := sequence.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+}
+
+func mustSplitBlobs(t testing.TB, blobs ...*blob.Blob) []Share {
+	shares, err := SplitBlobs(blobs...)
+	require.NoError(t, err)
+	return shares
+}
+
+func TestShareSequencePayloadLayout(t *testing.T) {
+	signer := bytes.Repeat([]byte{1}, SignerSize)
+	sizes := []int{1, FirstSparseShareWithSignerContentSize, FirstSparseShareContentSize, FirstSparseShareContentSize + 1, 10_000}
+	for _, shareVersion := range []uint8{ShareVersionZero, ShareVersionOne} {
+		for _, size := range sizes {
+			b := generateRandomBlobWithNamespace(ns1, size)
+			b.ShareVersion = uint32(shareVersion)
+			if shareVersion == ShareVersionOne {
+				b.Signer = signer
+			}
+			sequence := ShareSequence{Namespace: ns1, Shares: mustSplitBlobs(t, b)}
+
+			segments, err := sequence.PayloadLayout()
+			require.NoError(t, err)
+			require.Len(t, segments, len(sequence.Shares))
+
+			var data []byte
+			for i, segment := range segments {
+				assert.Equal(t, i, segment.ShareIndex)
+				if i > 0 {
+					assert.Equal(t, segments[i-1].BlobByteEnd, segment.BlobByteStart)
+				}
+				assert.Equal(t, segment.ShareDataEnd-segment.ShareDataStart, segment.BlobByteEnd-segment.BlobByteStart)
+				data = append(data, sequence.Shares[i].ToBytes()[segment.ShareDataStart:segment.ShareDataEnd]...)
+			}
+			assert.Equal(t, b.Data, data, "share version %d, size %d", shareVersion, size)
+			assert.Equal(t, 0, segments[0].BlobByteStart)
+			assert.Equal(t, size, segments[len(segments)-1].BlobByteEnd)
+		}
+	}
+
+	t.Run("compact sequence", func(t *testing.T) {
+		txShares, _, _, err := SplitTxs(generateRandomTxs(10, 200))
+		require.NoError(t, err)
+		sequence := ShareSequence{Namespace: namespace.TxNamespace, Shares: txShares}
+		segments, err := sequence.PayloadLayout()
+		require.NoError(t, err)
+		assert.Equal(t, ShareSize-FirstCompactShareContentSize, segments[0].ShareDataStart)
+		assert.Equal(t, ShareSize-ContinuationCompactShareContentSize, segments[1].ShareDataStart)
+		want, err := sequence.RawDataStrict()
+		require.NoError(t, err)
+		var data []byte
+		for i, segment := range segments {
+			data = append(data, txShares[i].ToBytes()[segment.ShareDataStart:segment.ShareDataEnd]...)
+		}
+		assert.Equal(t, want, data)
+	})
+
+	t.Run("padding sequence", func(t *testing.T) {
+		segments, err := ShareSequence{Namespace: ns1, Shares: mustNamespacePaddingShares(t, ns1, 1)}.PayloadLayout()
+		require.NoError(t, err)
+		require.Len(t, segments, 1)
+		assert.Equal(t, segments[0].ShareDataStart, segments[0].ShareDataEnd)
+		assert.Equal(t, 0, segments[0].BlobByteEnd)
+	})
+
+	t.Run("missing share", func(t *testing.T) {
+		blobShares := mustSplitBlobs(t, generateRandomBlobWithNamespace(ns1, 2000))
+		_, err := ShareSequence{Namespace: ns1, Shares: blobShares[:len(blobShares)-1]}.PayloadLayout()
+		assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+	})
+}
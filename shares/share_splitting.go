@@ -3,6 +3,8 @@ package shares
 import (
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"math"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
@@ -17,6 +19,26 @@ var (
 	)
 )
 
+// Splitter splits data into shares. It is implemented by
+// SparseShareSplitter and CompactShareSplitter. Writing data is left to the
+// concrete types because sparse splitters take blobs while compact splitters
+// take transactions.
+type Splitter interface {
+	// Count returns the number of shares that Export would return if it was
+	// called now, including a pending partially filled share.
+	Count() int
+	// Export finalizes and returns the shares.
+	Export() ([]Share, error)
+	// ForEachShare calls fn for every share that Export would return. It
+	// stops at the first error returned by fn and returns that error.
+	ForEachShare(fn func(Share) error) error
+}
+
+var (
+	_ Splitter = (*SparseShareSplitter)(nil)
+	_ Splitter = (*CompactShareSplitter)(nil)
+)
+
 // ExtractShareIndexes iterates over the transactions and extracts the share
 // indexes from wrapped transactions. It returns nil if the transactions are
 // from an old block that did not have share indexes in the wrapped txs.
@@ -45,8 +67,21 @@ func SplitTxs(txs [][]byte) (txShares []Share, pfbShares []Share, shareRanges ma
 	txWriter := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
 	pfbTxWriter := NewCompactShareSplitter(namespace.PayForBlobNamespace, ShareVersionZero)
 
-	for _, tx := range txs {
-		if _, isIndexWrapper := blob.UnmarshalIndexWrapper(tx); isIndexWrapper {
+	isIndexWrapper := make([]bool, len(txs))
+	var txsLen, pfbTxsLen int
+	for i, tx := range txs {
+		_, isIndexWrapper[i] = blob.UnmarshalIndexWrapper(tx)
+		if isIndexWrapper[i] {
+			pfbTxsLen += DelimLen(uint64(len(tx))) + len(tx)
+		} else {
+			txsLen += DelimLen(uint64(len(tx))) + len(tx)
+		}
+	}
+	txWriter.grow(CompactSharesNeeded(txsLen))
+	pfbTxWriter.grow(CompactSharesNeeded(pfbTxsLen))
+
+	for i, tx := range txs {
+		if isIndexWrapper[i] {
 			err = pfbTxWriter.WriteTx(tx)
 		} else {
 			err = txWriter.WriteTx(tx)
@@ -73,13 +108,41 @@ func SplitTxs(txs [][]byte) (txShares []Share, pfbShares []Share, shareRanges ma
 
 // SplitBlobs splits the provided blobs into shares.
 func SplitBlobs(blobs ...*blob.Blob) ([]Share, error) {
+	sharesNeeded := 0
+	for _, blob := range blobs {
+		// the getters are nil safe, Write returns the error of a nil blob
+		sharesNeeded += SparseSharesNeededForVersion(uint32(len(blob.GetData())), uint8(blob.GetShareVersion()))
+	}
 	writer := NewSparseShareSplitter()
+	writer.shares = make([]Share, 0, sharesNeeded)
 	for _, blob := range blobs {
 		if err := writer.Write(blob); err != nil {
 			return nil, err
 		}
 	}
-	return writer.Export(), nil
+	return writer.Export()
+}
+
+// SplitBlobSources is like SplitBlobs but reads the data of the blobs from
+// sources, see SplitSourceFunc.
+func SplitBlobSources(sources ...blob.BlobSource) ([]Share, error) {
+	sharesNeeded := 0
+	for _, src := range sources {
+		if src != nil && src.Len() > 0 && uint64(src.Len()) <= math.MaxUint32 {
+			sharesNeeded += SparseSharesNeededForVersion(uint32(src.Len()), src.ShareVersion())
+		}
+	}
+	shares := make([]Share, 0, sharesNeeded)
+	for i, src := range sources {
+		err := SplitSourceFunc(src, func(share Share) error {
+			shares = append(shares, share)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("blob %d: %w", i, err)
+		}
+	}
+	return shares, nil
 }
 
 // mergeMaps merges two maps into a new map. If there are any duplicate keys,
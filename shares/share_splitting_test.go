@@ -3,6 +3,8 @@ package shares
 import (
 	"bytes"
 	"crypto/sha256"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 
@@ -32,140 +34,119 @@ func TestSplitTxs_forTxShares(t *testing.T) {
 			name: "one small tx",
 			txs:  [][]byte{smallTransactionA},
 			want: []Share{
-				padShare(Share{
-					data: append(
-						namespace.TxNamespace.Bytes(),
-						[]byte{
-							0x1,                // info byte
-							0x0, 0x0, 0x0, 0x2, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
-							0x0, 0x0, 0x0, 0x26, // reserved bytes
-							0x1, // unit length of first transaction
-							0xa, // data of first transaction
-						}...,
-					),
-				},
-				),
+				padShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x1,                // info byte
+						0x0, 0x0, 0x0, 0x2, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
+						0x0, 0x0, 0x0, 0x26, // reserved bytes
+						0x1, // unit length of first transaction
+						0xa, // data of first transaction
+					}...,
+				)),
 			},
 		},
 		{
 			name: "two small txs",
 			txs:  [][]byte{smallTransactionA, smallTransactionB},
 			want: []Share{
-				padShare(Share{
-					data: append(
-						namespace.TxNamespace.Bytes(),
-						[]byte{
-							0x1,                // info byte
-							0x0, 0x0, 0x0, 0x4, // 2 bytes (first transaction) + 2 bytes (second transaction) = 4 bytes sequence length
-							0x0, 0x0, 0x0, 0x26, // reserved bytes
-							0x1, // unit length of first transaction
-							0xa, // data of first transaction
-							0x1, // unit length of second transaction
-							0xb, // data of second transaction
-						}...,
-					),
-				},
-				),
+				padShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x1,                // info byte
+						0x0, 0x0, 0x0, 0x4, // 2 bytes (first transaction) + 2 bytes (second transaction) = 4 bytes sequence length
+						0x0, 0x0, 0x0, 0x26, // reserved bytes
+						0x1, // unit length of first transaction
+						0xa, // data of first transaction
+						0x1, // unit length of second transaction
+						0xb, // data of second transaction
+					}...,
+				)),
 			},
 		},
 		{
 			name: "one large tx that spans two shares",
 			txs:  [][]byte{largeTransaction},
 			want: []Share{
-				fillShare(Share{
-					data: append(
+				fillShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x1,                // info byte
+						0x0, 0x0, 0x2, 0x2, // 512 (unit) + 2 (unit length) = 514 sequence length
+						0x0, 0x0, 0x0, 0x26, // reserved bytes
+						128, 4, // unit length of transaction is 512
+					}...,
+				),
+					0xc, // data of transaction
+				),
+				padShare(append(
+					append(
 						namespace.TxNamespace.Bytes(),
 						[]byte{
-							0x1,                // info byte
-							0x0, 0x0, 0x2, 0x2, // 512 (unit) + 2 (unit length) = 514 sequence length
-							0x0, 0x0, 0x0, 0x26, // reserved bytes
-							128, 4, // unit length of transaction is 512
+							0x0,                // info byte
+							0x0, 0x0, 0x0, 0x0, // reserved bytes
 						}...,
 					),
-				},
-					0xc, // data of transaction
-				),
-				padShare(Share{
-					data: append(
-						append(
-							namespace.TxNamespace.Bytes(),
-							[]byte{
-								0x0,                // info byte
-								0x0, 0x0, 0x0, 0x0, // reserved bytes
-							}...,
-						),
-						bytes.Repeat([]byte{0xc}, 40)..., // continuation data of transaction
-					),
-				},
-				),
+					bytes.Repeat([]byte{0xc}, 40)..., // continuation data of transaction
+				)),
 			},
 		},
 		{
 			name: "one small tx then one large tx that spans two shares",
 			txs:  [][]byte{smallTransactionA, largeTransaction},
 			want: []Share{
-				fillShare(Share{
-					data: append(
+				fillShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x1,                // info byte
+						0x0, 0x0, 0x2, 0x4, // 2 bytes (first transaction) + 514 bytes (second transaction) = 516 bytes sequence length
+						0x0, 0x0, 0x0, 0x26, // reserved bytes
+						1,      // unit length of first transaction
+						0xa,    // data of first transaction
+						128, 4, // unit length of second transaction is 512
+					}...,
+				),
+					0xc, // data of second transaction
+				),
+				padShare(append(
+					append(
 						namespace.TxNamespace.Bytes(),
 						[]byte{
-							0x1,                // info byte
-							0x0, 0x0, 0x2, 0x4, // 2 bytes (first transaction) + 514 bytes (second transaction) = 516 bytes sequence length
-							0x0, 0x0, 0x0, 0x26, // reserved bytes
-							1,      // unit length of first transaction
-							0xa,    // data of first transaction
-							128, 4, // unit length of second transaction is 512
+							0x0,                // info byte
+							0x0, 0x0, 0x0, 0x0, // reserved bytes
 						}...,
 					),
-				},
-					0xc, // data of second transaction
-				),
-				padShare(Share{
-					data: append(
-						append(
-							namespace.TxNamespace.Bytes(),
-							[]byte{
-								0x0,                // info byte
-								0x0, 0x0, 0x0, 0x0, // reserved bytes
-							}...,
-						),
-						bytes.Repeat([]byte{0xc}, 42)..., // continuation data of second transaction
-					),
-				},
-				),
+					bytes.Repeat([]byte{0xc}, 42)..., // continuation data of second transaction
+				)),
 			},
 		},
 		{
 			name: "one large tx that spans two shares then one small tx",
 			txs:  [][]byte{largeTransaction, smallTransactionA},
 			want: []Share{
-				fillShare(Share{
-					data: append(
-						namespace.TxNamespace.Bytes(),
-						[]byte{
-							0x1,                // info byte
-							0x0, 0x0, 0x2, 0x4, // 514 bytes (first transaction) + 2 bytes (second transaction) = 516 bytes sequence length
-							0x0, 0x0, 0x0, 0x26, // reserved bytes
-							128, 4, // unit length of first transaction is 512
-						}...,
-					),
-				},
-					0xc, // data of first transaction
+				fillShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x1,                // info byte
+						0x0, 0x0, 0x2, 0x4, // 514 bytes (first transaction) + 2 bytes (second transaction) = 516 bytes sequence length
+						0x0, 0x0, 0x0, 0x26, // reserved bytes
+						128, 4, // unit length of first transaction is 512
+					}...,
 				),
-				padShare(Share{
-					data: append(
-						namespace.TxNamespace.Bytes(),
-						[]byte{
-							0x0,                 // info byte
-							0x0, 0x0, 0x0, 0x4a, // reserved bytes
-							0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
-							0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
-							0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
-							1,   // unit length of second transaction
-							0xa, // data of second transaction
-						}...,
-					),
-				},
+					0xc, // data of first transaction
 				),
+				padShare(append(
+					namespace.TxNamespace.Bytes(),
+					[]byte{
+						0x0,                 // info byte
+						0x0, 0x0, 0x0, 0x4a, // reserved bytes
+						0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
+						0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
+						0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, 0xc, // continuation data of first transaction
+						1,   // unit length of second transaction
+						0xa, // data of second transaction
+					}...,
+				)),
 			},
 		},
 	}
@@ -191,64 +172,53 @@ func TestSplitTxs(t *testing.T) {
 
 	smallTx := []byte{0xa} // spans one share
 	smallTxShares := []Share{
-		padShare(Share{
-			data: append(namespace.TxNamespace.Bytes(),
-				[]byte{
-					0x1,                // info byte
-					0x0, 0x0, 0x0, 0x2, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
-					0x0, 0x0, 0x0, 0x26, // reserved bytes
-					0x1, // unit length of first transaction
-					0xa, // data of first transaction
-				}...,
-			),
-		},
-		),
+		padShare(append(namespace.TxNamespace.Bytes(),
+			[]byte{
+				0x1,                // info byte
+				0x0, 0x0, 0x0, 0x2, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
+				0x0, 0x0, 0x0, 0x26, // reserved bytes
+				0x1, // unit length of first transaction
+				0xa, // data of first transaction
+			}...,
+		)),
 	}
 
 	pfbTx, err := blob.MarshalIndexWrapper([]byte{0xb}, 10) // spans one share
 	require.NoError(t, err)
 	pfbTxShares := []Share{
-		padShare(Share{
-			data: append(
-				namespace.PayForBlobNamespace.Bytes(),
-				[]uint8{
-					0x1,               // info byte
-					0x0, 0x0, 0x0, 13, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
-					0x0, 0x0, 0x0, 0x26, // reserved bytes
-					12,                                                               // unit length of first transaction
-					0xa, 0x1, 0xb, 0x12, 0x1, 0xa, 0x1a, 0x4, 0x49, 0x4e, 0x44, 0x58, // data of first transaction
-				}...,
-			),
-		},
-		),
+		padShare(append(
+			namespace.PayForBlobNamespace.Bytes(),
+			[]uint8{
+				0x1,               // info byte
+				0x0, 0x0, 0x0, 13, // 1 byte (unit) + 1 byte (unit length) = 2 bytes sequence length
+				0x0, 0x0, 0x0, 0x26, // reserved bytes
+				12,                                                               // unit length of first transaction
+				0xa, 0x1, 0xb, 0x12, 0x1, 0xa, 0x1a, 0x4, 0x49, 0x4e, 0x44, 0x58, // data of first transaction
+			}...,
+		)),
 	}
 
 	largeTx := bytes.Repeat([]byte{0xc}, ShareSize) // spans two shares
 	largeTxShares := []Share{
-		fillShare(Share{
-			data: append(namespace.TxNamespace.Bytes(),
+		fillShare(append(namespace.TxNamespace.Bytes(),
+			[]uint8{
+				0x1,                // info byte
+				0x0, 0x0, 0x2, 0x2, // 512 (unit) + 2 (unit length) = 514 sequence length
+				0x0, 0x0, 0x0, 0x26, // reserved bytes
+				128, 4, // unit length of transaction is 512
+			}...,
+		),
+			0xc), // data of transaction
+		padShare(append(
+			append(
+				namespace.TxNamespace.Bytes(),
 				[]uint8{
-					0x1,                // info byte
-					0x0, 0x0, 0x2, 0x2, // 512 (unit) + 2 (unit length) = 514 sequence length
-					0x0, 0x0, 0x0, 0x26, // reserved bytes
-					128, 4, // unit length of transaction is 512
+					0x0,                // info byte
+					0x0, 0x0, 0x0, 0x0, // reserved bytes
 				}...,
 			),
-		},
-			0xc), // data of transaction
-		padShare(Share{
-			data: append(
-				append(
-					namespace.TxNamespace.Bytes(),
-					[]uint8{
-						0x0,                // info byte
-						0x0, 0x0, 0x0, 0x0, // reserved bytes
-					}...,
-				),
-				bytes.Repeat([]byte{0xc}, 40)..., // continuation data of transaction
-			),
-		},
-		),
+			bytes.Repeat([]byte{0xc}, 40)..., // continuation data of transaction
+		)),
 	}
 
 	testCases := []testCase{
@@ -309,15 +279,15 @@ func TestSplitTxs(t *testing.T) {
 	}
 }
 
-// padShare returns a share padded with trailing zeros.
-func padShare(share Share) (paddedShare Share) {
-	return fillShare(share, 0)
+// padShare returns a share of the raw share data padded with trailing zeros.
+func padShare(rawShare []byte) (paddedShare Share) {
+	return fillShare(rawShare, 0)
 }
 
-// fillShare returns a share filled with filler so that the share length
-// is equal to ShareSize.
-func fillShare(share Share, filler byte) (paddedShare Share) {
-	return Share{data: append(share.data, bytes.Repeat([]byte{filler}, ShareSize-len(share.data))...)}
+// fillShare returns a share of the raw share data filled with filler so that
+// the share length is equal to ShareSize.
+func fillShare(rawShare []byte, filler byte) (paddedShare Share) {
+	return shareFromBytes(append(rawShare, bytes.Repeat([]byte{filler}, ShareSize-len(rawShare))...))
 }
 
 func Test_mergeMaps(t *testing.T) {
@@ -385,3 +355,175 @@ func Test_mergeMaps(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitterCount(t *testing.T) {
+	type testCase struct {
+		name        string
+		newSplitter func() Splitter
+		// write writes the i-th piece of data to the splitter.
+		write func(t *testing.T, s Splitter, i int)
+	}
+	testCases := []testCase{
+		{
+			name:        "compact share splitter",
+			newSplitter: func() Splitter { return NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero) },
+			write: func(t *testing.T, s Splitter, i int) {
+				require.NoError(t, s.(*CompactShareSplitter).WriteTx(bytes.Repeat([]byte{byte(i)}, 300)))
+			},
+		},
+		{
+			name:        "sparse share splitter",
+			newSplitter: func() Splitter { return NewSparseShareSplitter() },
+			write: func(t *testing.T, s Splitter, i int) {
+				require.NoError(t, s.(*SparseShareSplitter).Write(blob.New(ns1, bytes.Repeat([]byte{byte(i)}, 300), ShareVersionZero)))
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.newSplitter()
+			assert.Zero(t, s.Count())
+
+			for i := 0; i < 5; i++ {
+				tc.write(t, s, i)
+				// Count includes the pending partially filled share
+				count := s.Count()
+				shares, err := s.Export()
+				require.NoError(t, err)
+				assert.Len(t, shares, count)
+				assert.Equal(t, count, s.Count())
+
+				var streamed []Share
+				require.NoError(t, s.ForEachShare(func(share Share) error {
+					streamed = append(streamed, share)
+					return nil
+				}))
+				assert.Equal(t, shares, streamed)
+			}
+
+			errStop := errors.New("stop")
+			calls := 0
+			err := s.ForEachShare(func(Share) error {
+				calls++
+				return errStop
+			})
+			assert.ErrorIs(t, err, errStop)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func BenchmarkSplitBlobs(b *testing.B) {
+	blobs := []*blob.Blob{blob.New(ns1, bytes.Repeat([]byte{0xf}, 8*1024*1024), ShareVersionZero)}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := SplitBlobs(blobs...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSplitTxs(b *testing.B) {
+	txs := GenerateRandomTxs(4000, 2000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := SplitTxs(txs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestSplitBlobsUnsupportedNamespaceVersion verifies that a blob with a
+// namespace version that isn't defined is rejected before any share is built
+// and that the error is about the namespace version, not the share version.
+func TestSplitBlobsUnsupportedNamespaceVersion(t *testing.T) {
+	id := append(namespace.NamespaceVersionZeroPrefix, bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize)...)
+	b := &blob.Blob{NamespaceId: id, NamespaceVersion: 1, Data: []byte("data")}
+
+	got, err := SplitBlobs(b)
+	assert.ErrorIs(t, err, namespace.ErrInvalidVersion)
+	assert.NotErrorIs(t, err, ErrUnsupportedShareVersion)
+	assert.Nil(t, got)
+
+	_, err = NewBuilder(b.Namespace(), ShareVersionZero, true)
+	assert.ErrorIs(t, err, ErrInvalidShareNamespace)
+	assert.ErrorIs(t, err, namespace.ErrInvalidVersion)
+	assert.NotErrorIs(t, err, ErrUnsupportedShareVersion)
+
+	// shares carrying the namespace version are rejected by the parser
+	valid, err := SplitBlobs(blob.New(namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize)), []byte("data"), ShareVersionZero))
+	require.NoError(t, err)
+	raw := valid[0].ToBytes()
+	raw[0] = 1
+	_, err = ParseShares([]Share{shareFromBytes(raw)}, false)
+	assert.ErrorIs(t, err, ErrInvalidShareNamespace)
+	assert.ErrorIs(t, err, namespace.ErrInvalidVersion)
+	_, err = ParseBlobs([]Share{shareFromBytes(raw)})
+	assert.ErrorIs(t, err, namespace.ErrInvalidVersion)
+}
+
+func TestSplitBlobSources(t *testing.T) {
+	signer := bytes.Repeat([]byte{0xa}, SignerSize)
+	v1, err := blob.NewV1(ns1, bytes.Repeat([]byte{2}, 3*ShareSize), signer)
+	require.NoError(t, err)
+	blobs := []*blob.Blob{
+		blob.New(ns1, []byte{1}, ShareVersionZero),
+		v1,
+		blob.New(ns1, bytes.Repeat([]byte{3}, FirstSparseShareContentSize), ShareVersionZero),
+		blob.New(ns1, bytes.Repeat([]byte{4}, FirstSparseShareContentSize+ContinuationSparseShareContentSize+1), ShareVersionZero),
+	}
+	sources := make([]blob.BlobSource, len(blobs))
+	for i, b := range blobs {
+		sources[i] = bytesSource(b)
+	}
+
+	want, err := SplitBlobs(blobs...)
+	require.NoError(t, err)
+	got, err := SplitBlobSources(sources...)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	empty := blob.NewSource(ns1, ShareVersionZero, nil, 0, nil)
+	_, err = SplitBlobSources(empty)
+	assert.ErrorIs(t, err, blob.ErrEmptyData)
+	tooShort := blob.NewSource(ns1, ShareVersionZero, nil, 2, func() (io.Reader, error) {
+		return bytes.NewReader([]byte{1}), nil
+	})
+	_, err = SplitBlobSources(tooShort)
+	assert.ErrorIs(t, err, blob.ErrSourceLength)
+}
+
+func bytesSource(b *blob.Blob) blob.BlobSource {
+	return blob.NewSource(b.Namespace(), uint8(b.ShareVersion), b.Signer, len(b.Data), func() (io.Reader, error) {
+		return bytes.NewReader(b.Data), nil
+	})
+}
+
+// BenchmarkSplitBlobSources allocates little more than the returned shares
+// because the data is streamed into the shares. Compare with
+// BenchmarkSplitBlobs, which also has to hold the data of the blob.
+func BenchmarkSplitBlobSources(b *testing.B) {
+	const size = 6 * 1024 * 1024
+	src := blob.NewSource(ns1, ShareVersionZero, nil, size, func() (io.Reader, error) {
+		return io.LimitReader(constantReader(0xf), size), nil
+	})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := SplitBlobSources(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// constantReader is an io.Reader that provides an endless stream of its value.
+type constantReader byte
+
+func (r constantReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,128 @@
+package shares
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// ErrShareWriterClosed is returned when data is written to a ShareWriter that
+// has been closed.
+var ErrShareWriterClosed = errors.New("the share writer is closed")
+
+// ShareWriter splits the data of a single blob into sparse shares as it is
+// written so that the blob doesn't have to be buffered before it is split. The
+// sequence length isn't known until Close is called so the first share is
+// retained and its sequence length is written by Close. The shares returned by
+// Close are identical to the shares SplitBlobs returns for the same data.
+type ShareWriter struct {
+	namespace    namespace.Namespace
+	shareVersion uint8
+	builder      *Builder
+	shares       []Share
+	sequenceLen  uint64
+	closed       bool
+}
+
+// NewShareWriter returns a ShareWriter that writes shares of the provided
+// namespace and share version. Share version 1 shares contain a signer so they
+// must be written with a writer returned by NewShareWriterWithSigner.
+func NewShareWriter(ns namespace.Namespace, shareVersion uint8) (*ShareWriter, error) {
+	if shareVersion == ShareVersionOne {
+		return nil, errors.New("share version 1 requires a signer, use NewShareWriterWithSigner")
+	}
+	return newShareWriter(ns, shareVersion, nil)
+}
+
+// NewShareWriterWithSigner returns a ShareWriter that writes share version 1
+// shares of the provided namespace whose first share contains signer.
+func NewShareWriterWithSigner(ns namespace.Namespace, signer []byte) (*ShareWriter, error) {
+	return newShareWriter(ns, ShareVersionOne, signer)
+}
+
+func newShareWriter(ns namespace.Namespace, shareVersion uint8, signer []byte) (*ShareWriter, error) {
+	if isCompactShare(ns) {
+		return nil, fmt.Errorf("namespace %s uses compact shares but a share writer only writes sparse shares", ns)
+	}
+	b, err := NewBuilder(ns, shareVersion, true)
+	if err != nil {
+		return nil, err
+	}
+	// the sequence length is rewritten by Close once it is known
+	if err := b.WriteSequenceLen(0); err != nil {
+		return nil, err
+	}
+	if shareVersion == ShareVersionOne {
+		if err := b.WriteSigner(signer); err != nil {
+			return nil, err
+		}
+	}
+	return &ShareWriter{
+		namespace:    ns,
+		shareVersion: shareVersion,
+		builder:      b,
+	}, nil
+}
+
+// Write splits p into shares. Every share except the pending, partially filled
+// one is complete once Write returns. It implements the io.Writer interface.
+func (w *ShareWriter) Write(p []byte) (n int, err error) {
+	if w.closed {
+		return 0, ErrShareWriterClosed
+	}
+	if w.sequenceLen+uint64(len(p)) > math.MaxUint32 {
+		return 0, fmt.Errorf("writing %d bytes would exceed the maximum sequence length of %d bytes", len(p), uint32(math.MaxUint32))
+	}
+	for len(p) > 0 {
+		// a full share is only stacked once there is more data so that Close
+		// can finish the last share
+		if w.builder.AvailableBytes() == 0 {
+			if err := w.stackPending(); err != nil {
+				return n, err
+			}
+		}
+		written, leftover, err := w.builder.AddData(p)
+		if err != nil {
+			return n, err
+		}
+		n += written
+		w.sequenceLen += uint64(written)
+		p = leftover
+	}
+	return n, nil
+}
+
+// stackPending builds the pending share and resets the builder for the next
+// continuation share.
+func (w *ShareWriter) stackPending() error {
+	share, err := w.builder.build()
+	if err != nil {
+		return err
+	}
+	w.shares = append(w.shares, share)
+	return w.builder.Reset(w.namespace, w.shareVersion, false)
+}
+
+// Close pads the last share, writes the sequence length to the first share and
+// returns the shares. It returns an error if no data has been written because
+// a blob can't be empty. The writer can't be used after Close.
+func (w *ShareWriter) Close() ([]Share, error) {
+	if w.closed {
+		return nil, ErrShareWriterClosed
+	}
+	w.closed = true
+	if w.sequenceLen == 0 {
+		return nil, errors.New("no data has been written to the share writer")
+	}
+
+	w.builder.ZeroPadIfNecessary()
+	if err := w.stackPending(); err != nil {
+		return nil, err
+	}
+	if err := writeSequenceLen(w.shares, uint32(w.sequenceLen)); err != nil {
+		return nil, err
+	}
+	return w.shares, nil
+}
@@ -0,0 +1,102 @@
+package shares
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareWriterMatchesSplitBlobs(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	signer := bytes.Repeat([]byte{0xa}, SignerSize)
+
+	dataSizes := []int{
+		1,
+		FirstSparseShareContentSize - 1,
+		FirstSparseShareContentSize,
+		FirstSparseShareContentSize + 1,
+		FirstSparseShareContentSize + ContinuationSparseShareContentSize,
+		ShareSize,
+		10 * ShareSize,
+	}
+	chunkSizes := []int{1, ShareSize - 1, ShareSize, FirstSparseShareContentSize, ContinuationSparseShareContentSize, 1000}
+
+	for _, shareVersion := range []uint8{ShareVersionZero, ShareVersionOne} {
+		for _, dataSize := range dataSizes {
+			data := make([]byte, dataSize)
+			_, err := rand.Read(data)
+			require.NoError(t, err)
+
+			b := blob.New(ns1, data, shareVersion)
+			if shareVersion == ShareVersionOne {
+				b.Signer = signer
+			}
+			want, err := SplitBlobs(b)
+			require.NoError(t, err)
+
+			for _, chunkSize := range chunkSizes {
+				var w *ShareWriter
+				if shareVersion == ShareVersionOne {
+					w, err = NewShareWriterWithSigner(ns1, signer)
+				} else {
+					w, err = NewShareWriter(ns1, shareVersion)
+				}
+				require.NoError(t, err)
+
+				for rest := data; len(rest) > 0; {
+					chunk := rest[:min(chunkSize, len(rest))]
+					n, err := w.Write(chunk)
+					require.NoError(t, err)
+					require.Equal(t, len(chunk), n)
+					rest = rest[n:]
+				}
+				got, err := w.Close()
+				require.NoError(t, err)
+				assert.Equal(t, want, got, "share version %d, data size %d, chunk size %d", shareVersion, dataSize, chunkSize)
+			}
+		}
+	}
+}
+
+func TestShareWriterErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	t.Run("compact namespace", func(t *testing.T) {
+		_, err := NewShareWriter(namespace.TxNamespace, ShareVersionZero)
+		assert.Error(t, err)
+	})
+	t.Run("unsupported share version", func(t *testing.T) {
+		_, err := NewShareWriter(ns1, 2)
+		assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	})
+	t.Run("share version 1 without signer", func(t *testing.T) {
+		_, err := NewShareWriter(ns1, ShareVersionOne)
+		assert.Error(t, err)
+		_, err = NewShareWriterWithSigner(ns1, []byte{1})
+		assert.Error(t, err)
+	})
+	t.Run("no data", func(t *testing.T) {
+		w, err := NewShareWriter(ns1, ShareVersionZero)
+		require.NoError(t, err)
+		_, err = w.Close()
+		assert.Error(t, err)
+	})
+	t.Run("write after close", func(t *testing.T) {
+		w, err := NewShareWriter(ns1, ShareVersionZero)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("data"))
+		require.NoError(t, err)
+		_, err = w.Close()
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("data"))
+		assert.ErrorIs(t, err, ErrShareWriterClosed)
+		_, err = w.Close()
+		assert.ErrorIs(t, err, ErrShareWriterClosed)
+	})
+}
@@ -8,41 +8,112 @@ import (
 	"github.com/celestiaorg/go-square/namespace"
 )
 
-// Share contains the raw share data (including namespace ID).
+// Share contains the raw share data (including namespace ID). Shares are
+// fixed size values so they can be compared with == and used as map keys. A
+// share never aliases the byte slice it was constructed from.
 type Share struct {
-	data []byte
+	data [ShareSize]byte
 }
 
+// Namespace returns the namespace of the share after validating it with
+// namespace.ParseNamespace. The namespace doesn't reference the share so it
+// remains valid if the share is modified or goes out of scope.
 func (s *Share) Namespace() (namespace.Namespace, error) {
-	if len(s.data) < namespace.NamespaceSize {
-		panic(fmt.Sprintf("share %s is too short to contain a namespace", s))
+	return namespace.ParseNamespace(s.NamespaceBytes())
+}
+
+// RawNamespace returns the namespace of the share without validating it so
+// that invalid shares can be inspected. The namespace doesn't reference the
+// share.
+func (s *Share) RawNamespace() namespace.Namespace {
+	return namespace.Namespace{
+		Version: s.data[0],
+		ID:      append([]byte(nil), s.data[namespace.NamespaceVersionSize:namespace.NamespaceSize]...),
 	}
-	return namespace.From(s.data[:namespace.NamespaceSize])
+}
+
+// NamespaceBytes returns the raw namespace of the share without copying or
+// validating it. The returned slice aliases the share so it must not be
+// modified and is only valid as long as the share is.
+func (s *Share) NamespaceBytes() []byte {
+	return s.data[:namespace.NamespaceSize]
+}
+
+// CompareNamespace compares the namespace of the share with ns without
+// allocating. The result is 0 if they are equal, -1 if the namespace of the
+// share is lower than ns and +1 if it is greater.
+func (s *Share) CompareNamespace(ns namespace.Namespace) int {
+	switch {
+	case s.data[0] < ns.Version:
+		return -1
+	case s.data[0] > ns.Version:
+		return 1
+	}
+	return bytes.Compare(s.data[namespace.NamespaceVersionSize:namespace.NamespaceSize], ns.ID)
+}
+
+// validateNamespace returns an error if the namespace of the share is invalid.
+// Unlike Namespace it doesn't copy the namespace.
+func (s *Share) validateNamespace() error {
+	_, err := namespace.From(s.NamespaceBytes())
+	return err
+}
+
+// compareNamespaces compares the namespaces of two shares without allocating.
+func compareNamespaces(a, b *Share) int {
+	return bytes.Compare(a.NamespaceBytes(), b.NamespaceBytes())
 }
 
 func (s *Share) InfoByte() (InfoByte, error) {
-	if len(s.data) < namespace.NamespaceSize+ShareInfoBytes {
-		return 0, fmt.Errorf("share %s is too short to contain an info byte", s)
-	}
 	// the info byte is the first byte after the namespace
 	unparsed := s.data[namespace.NamespaceSize]
 	return ParseInfoByte(unparsed)
 }
 
+// NewShare returns a share that holds a copy of the provided data. The data
+// must be exactly ShareSize bytes.
 func NewShare(data []byte) (*Share, error) {
 	if err := validateSize(data); err != nil {
 		return nil, err
 	}
-	return &Share{data}, nil
+	share := &Share{}
+	copy(share.data[:], data)
+	return share, nil
 }
 
 func (s *Share) Validate() error {
-	return validateSize(s.data)
+	return validateSize(s.data[:])
+}
+
+// ValidateShares validates every provided share and returns one entry per
+// share. A nil entry means the share at that index is valid. Errors include the
+// index and, where it can be read, the namespace of the offending share.
+func ValidateShares(shares []Share) []error {
+	errs := make([]error, len(shares))
+	for i := range shares {
+		errs[i] = shares[i].validateFull()
+		if errs[i] == nil {
+			continue
+		}
+		errs[i] = newParseError(i, &shares[i], errs[i])
+	}
+	return errs
+}
+
+// validateFull checks the size, namespace, and share version of the share.
+func (s *Share) validateFull() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.Namespace(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidShareNamespace, err)
+	}
+	return s.DoesSupportVersions(SupportedShareVersions)
 }
 
 func validateSize(data []byte) error {
 	if len(data) != ShareSize {
-		return fmt.Errorf("share data must be %d bytes, got %d", ShareSize, len(data))
+		return fmt.Errorf("%w: share data must be %d bytes, got %d", ErrInvalidShareSize, ShareSize, len(data))
 	}
 	return nil
 }
@@ -64,10 +135,7 @@ func (s *Share) DoesSupportVersions(supportedShareVersions []uint8) error {
 	if err != nil {
 		return err
 	}
-	if !bytes.Contains(supportedShareVersions, []byte{ver}) {
-		return fmt.Errorf("unsupported share version %v is not present in the list of supported share versions %v", ver, supportedShareVersions)
-	}
-	return nil
+	return checkShareVersion(ver, supportedShareVersions)
 }
 
 // IsSequenceStart returns true if this is the first share in a sequence.
@@ -85,8 +153,7 @@ func (s Share) IsCompactShare() (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	isCompact := ns.IsTx() || ns.IsPayForBlob()
-	return isCompact, nil
+	return isCompactShare(ns), nil
 }
 
 // SequenceLen returns the sequence length of this *share and optionally an
@@ -103,13 +170,29 @@ func (s *Share) SequenceLen() (sequenceLen uint32, err error) {
 
 	start := namespace.NamespaceSize + ShareInfoBytes
 	end := start + SequenceLenBytes
-	if len(s.data) < end {
-		return 0, fmt.Errorf("share %s with length %d is too short to contain a sequence length",
-			s, len(s.data))
-	}
 	return binary.BigEndian.Uint32(s.data[start:end]), nil
 }
 
+// Signer returns the signer of the blob that this share belongs to. Only the
+// first share of a share version 1 sequence contains a signer so an error is
+// returned for any other share.
+func (s *Share) Signer() ([]byte, error) {
+	infoByte, err := s.InfoByte()
+	if err != nil {
+		return nil, err
+	}
+	if infoByte.Version() != ShareVersionOne {
+		return nil, fmt.Errorf("share version %d does not contain a signer", infoByte.Version())
+	}
+	if !infoByte.IsSequenceStart() {
+		return nil, fmt.Errorf("continuation share does not contain a signer")
+	}
+
+	start := namespace.NamespaceSize + ShareInfoBytes + SequenceLenBytes
+	end := start + SignerSize
+	return append([]byte(nil), s.data[start:end]...), nil
+}
+
 // IsPadding returns whether this *share is padding or not.
 func (s *Share) IsPadding() (bool, error) {
 	isNamespacePadding, err := s.isNamespacePadding()
@@ -156,18 +239,16 @@ func (s *Share) isPrimaryReservedPadding() (bool, error) {
 	return ns.IsPrimaryReservedPadding(), nil
 }
 
+// ToBytes returns a copy of the raw share bytes.
 func (s *Share) ToBytes() []byte {
-	return s.data
+	return append([]byte(nil), s.data[:]...)
 }
 
-// RawData returns the raw share data. The raw share data does not contain the
-// namespace ID, info byte, sequence length, or reserved bytes.
+// RawData returns a copy of the raw share data. The raw share data does not
+// contain the namespace ID, info byte, sequence length, signer, or reserved
+// bytes.
 func (s *Share) RawData() (rawData []byte, err error) {
-	if len(s.data) < s.rawDataStartIndex() {
-		return rawData, fmt.Errorf("share %s is too short to contain raw data", s)
-	}
-
-	return s.data[s.rawDataStartIndex():], nil
+	return append([]byte(nil), s.data[s.rawDataStartIndex():]...), nil
 }
 
 func (s *Share) rawDataStartIndex() int {
@@ -180,17 +261,32 @@ func (s *Share) rawDataStartIndex() int {
 		panic(err)
 	}
 
+	version, err := s.Version()
+	if err != nil {
+		panic(err)
+	}
+
 	index := namespace.NamespaceSize + ShareInfoBytes
 	if isStart {
 		index += SequenceLenBytes
 	}
+	if containsSigner(version, isStart, isCompact) {
+		index += SignerSize
+	}
 	if isCompact {
 		index += CompactShareReservedBytes
 	}
 	return index
 }
 
-// RawDataUsingReserved returns the raw share data while taking reserved bytes into account.
+// containsSigner returns whether a share with the provided share version,
+// sequence start indicator and compactness contains a signer. Only the first
+// share of a share version 1 sparse sequence contains a signer.
+func containsSigner(shareVersion uint8, isSequenceStart, isCompact bool) bool {
+	return shareVersion == ShareVersionOne && isSequenceStart && !isCompact
+}
+
+// RawDataUsingReserved returns a copy of the raw share data while taking reserved bytes into account.
 func (s *Share) RawDataUsingReserved() (rawData []byte, err error) {
 	rawDataStartIndexUsingReserved, err := s.rawDataStartIndexUsingReserved()
 	if err != nil {
@@ -201,11 +297,7 @@ func (s *Share) RawDataUsingReserved() (rawData []byte, err error) {
 	if rawDataStartIndexUsingReserved == 0 {
 		return []byte{}, nil
 	}
-	if len(s.data) < rawDataStartIndexUsingReserved {
-		return rawData, fmt.Errorf("share %s is too short to contain raw data", s)
-	}
-
-	return s.data[rawDataStartIndexUsingReserved:], nil
+	return append([]byte(nil), s.data[rawDataStartIndexUsingReserved:]...), nil
 }
 
 // rawDataStartIndexUsingReserved returns the start index of raw data while accounting for
@@ -235,21 +327,78 @@ func (s *Share) rawDataStartIndexUsingReserved() (int, error) {
 	return index, nil
 }
 
+// MarshalBinary returns a copy of the raw share bytes. It implements the
+// encoding.BinaryMarshaler interface.
+func (s *Share) MarshalBinary() ([]byte, error) {
+	return s.ToBytes(), nil
+}
+
+// UnmarshalBinary sets the share to a copy of the provided raw share bytes. It
+// implements the encoding.BinaryUnmarshaler interface. It returns an error,
+// leaving the share unchanged, if the share has an invalid namespace or an
+// unsupported share version.
+func (s *Share) UnmarshalBinary(data []byte) error {
+	if err := validateSize(data); err != nil {
+		return err
+	}
+	var share Share
+	copy(share.data[:], data)
+	if err := share.validateFull(); err != nil {
+		return err
+	}
+	*s = share
+	return nil
+}
+
+// ToBytes returns a copy of the raw bytes of every share.
 func ToBytes(shares []Share) (bytes [][]byte) {
 	bytes = make([][]byte, len(shares))
-	for i, share := range shares {
-		bytes[i] = share.data
+	for i := range shares {
+		bytes[i] = shares[i].ToBytes()
 	}
 	return bytes
 }
 
+// ToContiguousBytes copies the raw bytes of the shares back to back into buf
+// and returns the number of bytes written. It avoids allocating a slice per
+// share like ToBytes does. It returns an error if buf is shorter than
+// len(shares) * ShareSize bytes.
+func ToContiguousBytes(shares []Share, buf []byte) (n int, err error) {
+	if len(buf) < len(shares)*ShareSize {
+		return 0, fmt.Errorf("buffer of %d bytes is too short for %d shares", len(buf), len(shares))
+	}
+	for i := range shares {
+		n += copy(buf[n:], shares[i].data[:])
+	}
+	return n, nil
+}
+
+// FromBytes returns shares that hold a copy of every provided raw share. It
+// returns an error wrapping ErrInvalidShareSize if an element isn't exactly
+// ShareSize bytes.
 func FromBytes(bytes [][]byte) (shares []Share, err error) {
-	for _, b := range bytes {
-		share, err := NewShare(b)
-		if err != nil {
-			return nil, err
+	// allocate all shares at once so they are stored contiguously
+	shares = make([]Share, len(bytes))
+	for i, b := range bytes {
+		if err := validateSize(b); err != nil {
+			return nil, fmt.Errorf("share %d: %w", i, err)
 		}
-		shares = append(shares, *share)
+		copy(shares[i].data[:], b)
+	}
+	return shares, nil
+}
+
+// FromContiguousBytes returns shares that hold a copy of buf, which contains
+// raw shares back to back, e.g. a data square received from the network. It
+// returns an error wrapping ErrInvalidShareSize if the length of buf is not a
+// multiple of ShareSize.
+func FromContiguousBytes(buf []byte) ([]Share, error) {
+	if len(buf)%ShareSize != 0 {
+		return nil, fmt.Errorf("%w: buffer of %d bytes is not a multiple of %d bytes", ErrInvalidShareSize, len(buf), ShareSize)
+	}
+	shares := make([]Share, len(buf)/ShareSize)
+	for i := range shares {
+		copy(shares[i].data[:], buf[i*ShareSize:])
 	}
 	return shares, nil
 }
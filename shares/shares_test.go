@@ -2,8 +2,15 @@ package shares
 
 import (
 	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,48 +44,31 @@ func TestSequenceLen(t *testing.T) {
 			1,           // info byte
 			0, 0, 0, 10, // sequence len
 		}...)
-	noInfoByte := namespace.TxNamespace.Bytes()
-	noSequenceLen := append(namespace.TxNamespace.Bytes(),
-		[]byte{
-			1, // info byte
-		}...)
 	testCases := []testCase{
 		{
 			name:    "first share",
-			share:   Share{data: firstShare},
+			share:   shareFromBytes(firstShare),
 			wantLen: 10,
 			wantErr: false,
 		},
 		{
 			name:    "first share with long sequence",
-			share:   Share{data: firstShareWithLongSequence},
+			share:   shareFromBytes(firstShareWithLongSequence),
 			wantLen: 323,
 			wantErr: false,
 		},
 		{
 			name:    "continuation share",
-			share:   Share{data: continuationShare},
+			share:   shareFromBytes(continuationShare),
 			wantLen: 0,
 			wantErr: false,
 		},
 		{
 			name:    "compact share",
-			share:   Share{data: compactShare},
+			share:   shareFromBytes(compactShare),
 			wantLen: 10,
 			wantErr: false,
 		},
-		{
-			name:    "no info byte returns error",
-			share:   Share{data: noInfoByte},
-			wantLen: 0,
-			wantErr: true,
-		},
-		{
-			name:    "no sequence len returns error",
-			share:   Share{data: noSequenceLen},
-			wantLen: 0,
-			wantErr: true,
-		},
 	}
 
 	for _, tc := range testCases {
@@ -130,56 +120,87 @@ func TestRawData(t *testing.T) {
 			0, 0, 0, 0, // reserved bytes
 			1, 2, 3, 4, 5, 6, 7, 8, 9, 10, // data
 		}...)
-	noSequenceLen := append(namespace.TxNamespace.Bytes(),
-		[]byte{
-			1, // info byte
-		}...)
-	notEnoughSequenceLenBytes := append(namespace.TxNamespace.Bytes(),
-		[]byte{
-			1,        // info byte
-			0, 0, 10, // sequence len
-		}...)
 	testCases := []testCase{
 		{
 			name:  "first sparse share",
-			share: Share{data: firstSparseShare},
+			share: shareFromBytes(firstSparseShare),
 			want:  []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
 		},
 		{
 			name:  "continuation sparse share",
-			share: Share{data: continuationSparseShare},
+			share: shareFromBytes(continuationSparseShare),
 			want:  []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
 		},
 		{
 			name:  "first compact share",
-			share: Share{data: firstCompactShare},
+			share: shareFromBytes(firstCompactShare),
 			want:  []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
 		},
 		{
 			name:  "continuation compact share",
-			share: Share{data: continuationCompactShare},
+			share: shareFromBytes(continuationCompactShare),
 			want:  []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
 		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawData, err := tc.share.RawData()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			// the remainder of the share is padded with zeros
+			assert.Equal(t, tc.want, rawData[:len(tc.want)])
+			assert.True(t, IsAllZero(rawData[len(tc.want):]))
+		})
+	}
+}
+
+func TestSigner(t *testing.T) {
+	ns := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	signer := bytes.Repeat([]byte{0xaa}, SignerSize)
+	share := func(version uint8, isSequenceStart bool, rest []byte) *Share {
+		infoByte, err := NewInfoByte(version, isSequenceStart)
+		require.NoError(t, err)
+		data := append(ns.Bytes(), byte(infoByte))
+		share := shareFromBytes(append(data, rest...))
+		return &share
+	}
+	sequenceLen := []byte{0, 0, 0, 1}
+
+	type testCase struct {
+		name    string
+		share   *Share
+		want    []byte
+		wantErr bool
+	}
+	testCases := []testCase{
+		{
+			name:  "first share of version 1",
+			share: share(ShareVersionOne, true, append(append(sequenceLen, signer...), 0xf)),
+			want:  signer,
+		},
 		{
-			name:    "no sequence len returns error",
-			share:   Share{data: noSequenceLen},
+			name:    "first share of version 0",
+			share:   share(ShareVersionZero, true, append(append(sequenceLen, signer...), 0xf)),
 			wantErr: true,
 		},
 		{
-			name:    "not enough sequence len bytes returns error",
-			share:   Share{data: notEnoughSequenceLenBytes},
+			name:    "continuation share of version 1",
+			share:   share(ShareVersionOne, false, signer),
 			wantErr: true,
 		},
 	}
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			rawData, err := tc.share.RawData()
+			got, err := tc.share.Signer()
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
 			}
-			assert.Equal(t, tc.want, rawData)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
 		})
 	}
 }
@@ -199,17 +220,17 @@ func TestIsCompactShare(t *testing.T) {
 	testCases := []testCase{
 		{
 			name:  "tx share",
-			share: Share{data: txShare},
+			share: shareFromBytes(txShare),
 			want:  true,
 		},
 		{
 			name:  "pfb tx share",
-			share: Share{data: pfbTxShare},
+			share: shareFromBytes(pfbTxShare),
 			want:  true,
 		},
 		{
 			name:  "blob share",
-			share: Share{data: blobShare},
+			share: shareFromBytes(blobShare),
 			want:  false,
 		},
 	}
@@ -228,7 +249,6 @@ func TestIsPadding(t *testing.T) {
 		want    bool
 		wantErr bool
 	}
-	emptyShare := Share{}
 	blobShare, _ := zeroPadIfNecessary(
 		append(
 			ns1.Bytes(),
@@ -245,13 +265,13 @@ func TestIsPadding(t *testing.T) {
 
 	testCases := []testCase{
 		{
-			name:    "empty share",
-			share:   emptyShare,
-			wantErr: true,
+			name:  "zero value share",
+			share: Share{},
+			want:  false,
 		},
 		{
 			name:  "blob share",
-			share: Share{data: blobShare},
+			share: shareFromBytes(blobShare),
 			want:  false,
 		},
 		{
@@ -283,3 +303,319 @@ func TestIsPadding(t *testing.T) {
 		})
 	}
 }
+
+func TestShareString(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	blobShares, err := SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{0xaa}, 600), ShareVersionZero))
+	require.NoError(t, err)
+	txShares, _, _, err := SplitTxs([][]byte{{1, 2, 3}})
+	require.NoError(t, err)
+
+	type testCase struct {
+		name  string
+		share Share
+		want  string
+	}
+	testCases := []testCase{
+		{
+			name:  "first sparse share",
+			share: blobShares[0],
+			want:  "namespace=0000000000000000000000000000000000000001010101010101010101 version=0 first=true compact=false seqlen=600 reserved=- datalen=478 payloadlen=478",
+		},
+		{
+			name:  "continuation sparse share",
+			share: blobShares[1],
+			want:  "namespace=0000000000000000000000000000000000000001010101010101010101 version=0 first=false compact=false seqlen=- reserved=- datalen=482 payloadlen=122",
+		},
+		{
+			name:  "first compact share",
+			share: txShares[0],
+			want:  "namespace=0000000000000000000000000000000000000000000000000000000001 version=0 first=true compact=true seqlen=4 reserved=38 datalen=474 payloadlen=4",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.share.String())
+			assert.Equal(t, tc.want, fmt.Sprintf("%v", &tc.share))
+		})
+	}
+}
+
+func TestShareFormatVerbose(t *testing.T) {
+	txShares, _, _, err := SplitTxs([][]byte{bytes.Repeat([]byte{0xaa}, 40)})
+	require.NoError(t, err)
+
+	want := `namespace=0000000000000000000000000000000000000000000000000000000001 version=0 first=true compact=true seqlen=41 reserved=38 datalen=474 payloadlen=41
+  namespace      [  0: 29] 0000000000000000000000000000000000000000000000000000000001
+  info byte      [ 29: 30] 01 (version 0, sequence start true)
+  sequence len   [ 30: 34] 00000029 (41)
+  reserved bytes [ 34: 38] 00000026 (38)
+  payload        [ 38:512]
+    0026  28 aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa aa
+    0046  aa aa aa aa aa aa aa aa aa 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00
+    0066  410 zero bytes`
+	assert.Equal(t, want, fmt.Sprintf("%+v", &txShares[0]))
+	assert.Equal(t, hex.EncodeToString(txShares[0].ToBytes()), fmt.Sprintf("%x", &txShares[0]))
+}
+
+func TestValidateShares(t *testing.T) {
+	valid := TailPaddingShare()
+	unsupportedVersion := TailPaddingShare()
+	unsupportedVersion.data[namespace.NamespaceSize] = 0xFF
+	invalidNamespace := TailPaddingShare()
+	invalidNamespace.data[0] = 1
+
+	errs := ValidateShares([]Share{
+		valid,
+		unsupportedVersion,
+		invalidNamespace,
+		valid,
+	})
+	require.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.ErrorContains(t, errs[1], "share 1 with namespace fffffffffffffffffffffffffffffffffffffffffffffffffffffffffe")
+	assert.ErrorContains(t, errs[2], "share 2 with namespace 01fffffffffffffffffffffffffffffffffffffffffffffffffffffffe")
+	assert.NoError(t, errs[3])
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestShareCBORGolden pins the CBOR encoding of a share, as hex, so that
+// clients in other languages can match it. Run the test with -update to
+// rewrite the golden file after an intentional change.
+func TestShareCBORGolden(t *testing.T) {
+	ns := namespace.MustNewV0([]byte("sample"))
+	b, err := blob.NewV1(ns, []byte("hello, world"), bytes.Repeat([]byte{0xab}, SignerSize))
+	require.NoError(t, err)
+	blobShares, err := SplitBlobs(b)
+	require.NoError(t, err)
+	require.Len(t, blobShares, 1)
+
+	encoded, err := blobShares[0].MarshalCBOR()
+	require.NoError(t, err)
+	got := hex.EncodeToString(encoded) + "\n"
+	path := filepath.Join("testdata", "share.cbor.hex")
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+
+	var decoded Share
+	require.NoError(t, decoded.UnmarshalCBOR(encoded))
+	assert.Equal(t, blobShares[0], decoded)
+}
+
+func TestShareCBORRoundTrip(t *testing.T) {
+	share := TailPaddingShare()
+	encoded, err := share.MarshalCBOR()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x59, 0x02, 0x00}, encoded[:3])
+
+	var decoded Share
+	require.NoError(t, decoded.UnmarshalCBOR(encoded))
+	assert.Equal(t, share, decoded)
+
+	t.Run("wrong length", func(t *testing.T) {
+		var s Share
+		assert.Error(t, s.UnmarshalCBOR([]byte{0x43, 1, 2, 3}))
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		invalid := append([]byte{}, encoded...)
+		invalid[3+namespace.NamespaceSize] = 0xFF
+		var s Share
+		assert.Error(t, s.UnmarshalCBOR(invalid))
+	})
+}
+
+// TestShareCBORInvalidNamespace checks that CBOR decoding applies the
+// namespace validation of the native constructors.
+func TestShareCBORInvalidNamespace(t *testing.T) {
+	share := TailPaddingShare()
+	encoded, err := share.MarshalCBOR()
+	require.NoError(t, err)
+	// the share bytes follow a 3 byte CBOR header
+	const offset = 3
+
+	testCases := []struct {
+		name   string
+		mutate func(raw []byte)
+	}{
+		{name: "unsupported namespace version", mutate: func(raw []byte) { raw[offset] = 7 }},
+		{name: "malformed version 0 prefix", mutate: func(raw []byte) {
+			// a version 0 namespace whose ID doesn't start with the
+			// leading zeros
+			raw[offset] = namespace.NamespaceVersionZero
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			invalid := append([]byte{}, encoded...)
+			tc.mutate(invalid)
+			var s Share
+			assert.ErrorIs(t, s.UnmarshalCBOR(invalid), ErrInvalidShareNamespace)
+			assert.Equal(t, Share{}, s)
+		})
+	}
+}
+
+func TestShareBinaryRoundTrip(t *testing.T) {
+	want := TailPaddingShares(3)
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(want))
+	var got []Share
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&got))
+	assert.Equal(t, want, got)
+
+	var s Share
+	assert.Error(t, s.UnmarshalBinary(make([]byte, ShareSize-1)))
+	assert.Error(t, s.UnmarshalBinary(make([]byte, ShareSize+1)))
+
+	// invalid namespaces and share versions are rejected like by NewShare
+	tailPadding := TailPaddingShare()
+	invalidNamespace := tailPadding.ToBytes()
+	invalidNamespace[0] = 7
+	assert.ErrorIs(t, s.UnmarshalBinary(invalidNamespace), ErrInvalidShareNamespace)
+	invalidVersion := tailPadding.ToBytes()
+	invalidVersion[namespace.NamespaceSize] = 0xFF
+	assert.Error(t, s.UnmarshalBinary(invalidVersion))
+	assert.Equal(t, Share{}, s)
+}
+
+// shareFromBytes returns a share holding a copy of data. Data shorter than
+// ShareSize is padded with trailing zeros.
+func shareFromBytes(data []byte) Share {
+	var share Share
+	copy(share.data[:], data)
+	return share
+}
+
+func TestShareDoesNotAliasInput(t *testing.T) {
+	tailPadding := TailPaddingShare()
+	raw := tailPadding.ToBytes()
+	share, err := NewShare(raw)
+	require.NoError(t, err)
+	fromBytes, err := FromBytes([][]byte{raw})
+	require.NoError(t, err)
+
+	raw[0] = 0xaa
+	assert.Equal(t, TailPaddingShare(), *share)
+	assert.Equal(t, TailPaddingShare(), fromBytes[0])
+
+	exported := share.ToBytes()
+	exported[0] = 0xaa
+	assert.Equal(t, TailPaddingShare(), *share)
+}
+
+func TestFromBytes(t *testing.T) {
+	want := append(TailPaddingShares(2), ReservedPaddingShare())
+	got, err := FromBytes(ToBytes(want))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	raw := ToBytes(want)
+	raw[1] = raw[1][:ShareSize-1]
+	_, err = FromBytes(raw)
+	assert.ErrorIs(t, err, ErrInvalidShareSize)
+	assert.Contains(t, err.Error(), "share 1")
+}
+
+func TestContiguousBytes(t *testing.T) {
+	want := append(TailPaddingShares(2), ReservedPaddingShare())
+	buf := make([]byte, len(want)*ShareSize+1)
+	n, err := ToContiguousBytes(want, buf)
+	require.NoError(t, err)
+	assert.Equal(t, len(want)*ShareSize, n)
+	assert.Equal(t, bytes.Join(ToBytes(want), nil), buf[:n])
+
+	got, err := FromContiguousBytes(buf[:n])
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	// the shares don't alias the buffer
+	buf[0] = 0xaa
+	assert.Equal(t, want, got)
+
+	got, err = FromContiguousBytes(nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = FromContiguousBytes(buf)
+	assert.ErrorIs(t, err, ErrInvalidShareSize)
+	_, err = ToContiguousBytes(want, buf[:n-1])
+	assert.Error(t, err)
+}
+
+func BenchmarkFromBytes(b *testing.B) {
+	buf := make([]byte, 128*128*ShareSize)
+	b.Run("two step", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			raw := make([][]byte, len(buf)/ShareSize)
+			for j := range raw {
+				raw[j] = append([]byte(nil), buf[j*ShareSize:(j+1)*ShareSize]...)
+			}
+			if _, err := FromBytes(raw); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("contiguous", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := FromContiguousBytes(buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkToBytes(b *testing.B) {
+	shares := TailPaddingShares(128 * 128)
+	b.Run("two step", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = bytes.Join(ToBytes(shares), nil)
+		}
+	})
+	b.Run("contiguous", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, len(shares)*ShareSize)
+		for i := 0; i < b.N; i++ {
+			if _, err := ToContiguousBytes(shares, buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestShareComparable(t *testing.T) {
+	assert.True(t, TailPaddingShare() == TailPaddingShare())
+	assert.False(t, TailPaddingShare() == ReservedPaddingShare())
+
+	seen := map[Share]int{
+		TailPaddingShare():     1,
+		ReservedPaddingShare(): 2,
+	}
+	assert.Equal(t, 1, seen[TailPaddingShare()])
+	assert.Equal(t, 2, seen[ReservedPaddingShare()])
+}
+
+func TestRawNamespace(t *testing.T) {
+	raw := make([]byte, ShareSize)
+	raw[0] = 1
+	raw[1] = 0xa
+	share := shareFromBytes(raw)
+
+	_, err := share.Namespace()
+	assert.ErrorIs(t, err, namespace.ErrInvalidVersion)
+
+	ns := share.RawNamespace()
+	assert.Equal(t, uint8(1), ns.Version)
+	assert.Equal(t, raw[1:namespace.NamespaceSize], ns.ID)
+	// the namespace doesn't alias the share
+	ns.ID[0] = 0
+	assert.Equal(t, byte(0xa), share.ToBytes()[1])
+}
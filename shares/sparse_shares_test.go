@@ -40,7 +40,8 @@ func TestSparseShareContainsInfoByte(t *testing.T) {
 			sss := NewSparseShareSplitter()
 			err := sss.Write(blob)
 			assert.NoError(t, err)
-			shares := sss.Export()
+			shares, err := sss.Export()
+			require.NoError(t, err)
 			got, err := shares[tc.shareIndex].InfoByte()
 			require.NoError(t, err)
 			assert.Equal(t, tc.expected, got)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/celestiaorg/go-square/namespace"
+	"golang.org/x/exp/slices"
 )
 
 // CompactShareSplitter will write raw data compactly across a progressively
@@ -16,7 +17,6 @@ type CompactShareSplitter struct {
 	// pendingShare Share
 	shareBuilder *Builder
 	namespace    namespace.Namespace
-	done         bool
 	shareVersion uint8
 	// shareRanges is a map from a transaction key to the range of shares it
 	// occupies. The range assumes this compact share splitter is the only
@@ -42,17 +42,92 @@ func NewCompactShareSplitter(ns namespace.Namespace, shareVersion uint8) *Compac
 	}
 }
 
+// NewCompactShareSplitterFromShares returns a CompactShareSplitter that
+// resumes writing after the provided shares, e.g. shares that were returned by
+// Export of a splitter with the same namespace and share version. Writing to
+// the returned splitter produces the same shares as writing all data to a
+// single splitter. It returns an error if shares are not a valid compact share
+// sequence of ns and shareVersion.
+func NewCompactShareSplitterFromShares(ns namespace.Namespace, shareVersion uint8, shares []Share) (*CompactShareSplitter, error) {
+	if err := checkShareVersion(shareVersion, SupportedShareVersions); err != nil {
+		return nil, err
+	}
+	if !isCompactShare(ns) {
+		return nil, fmt.Errorf("%w: namespace %s does not use compact shares", ErrNotCompactShare, ns)
+	}
+	css := NewCompactShareSplitter(ns, shareVersion)
+	if len(shares) == 0 {
+		return css, nil
+	}
+
+	sequence := ShareSequence{Namespace: ns, Shares: shares}
+	if err := sequence.Validate(); err != nil {
+		return nil, err
+	}
+	version, err := shares[0].Version()
+	if err != nil {
+		return nil, err
+	}
+	if version != shareVersion {
+		return nil, newParseError(0, &shares[0], fmt.Errorf("%w: share version %d differs from the share version %d of the splitter", ErrShareVersionMismatch, version, shareVersion))
+	}
+	if err := ValidateReservedBytes(shares); err != nil {
+		return nil, err
+	}
+	positions, err := ParseTxsWithRanges(shares)
+	if err != nil {
+		return nil, err
+	}
+	for _, position := range positions {
+		css.shareRanges[sha256.Sum256(position.Tx)] = position.ShareRange
+	}
+
+	seqLen, err := shares[0].SequenceLen()
+	if err != nil {
+		return nil, err
+	}
+	// the data of the last share ends where the sequence length ends
+	last := len(shares) - 1
+	dataEnd := ShareSize - FirstCompactShareContentSize + int(seqLen)
+	if last > 0 {
+		dataEnd = continuationCompactShareHeaderLen + int(seqLen) - FirstCompactShareContentSize - (last-1)*ContinuationCompactShareContentSize
+	}
+	css.shares = append(css.shares, shares[:last]...)
+	if dataEnd == ShareSize {
+		css.shares = append(css.shares, shares[last])
+		if err := css.shareBuilder.Reset(ns, shareVersion, false); err != nil {
+			return nil, err
+		}
+	} else if _, err := css.shareBuilder.ImportRawShare(shares[last].data[:dataEnd]); err != nil {
+		return nil, err
+	}
+	// the sequence length is a placeholder until it is written by Export
+	if len(css.shares) == 0 {
+		err = css.shareBuilder.RewriteSequenceLen(0)
+	} else {
+		err = writeSequenceLen(css.shares, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return css, nil
+}
+
 // WriteTx adds the delimited data for the provided tx to the underlying compact
 // share splitter.
 func (css *CompactShareSplitter) WriteTx(tx []byte) error {
-	rawData, err := MarshalDelimitedTx(tx)
-	if err != nil {
-		return fmt.Errorf("included Tx in mem-pool that can not be encoded %v", tx)
+	// the tx must start in a share with room for it so that the reserved bytes
+	// point into the share that contains the start of the tx
+	if err := css.stackIfFull(); err != nil {
+		return err
 	}
-
 	startShare := len(css.shares)
 
-	if err := css.write(rawData); err != nil {
+	leftover, err := css.shareBuilder.AddTransaction(tx)
+	if err != nil {
+		return err
+	}
+	if err := css.spill(leftover); err != nil {
 		return err
 	}
 	endShare := css.Count()
@@ -61,79 +136,106 @@ func (css *CompactShareSplitter) WriteTx(tx []byte) error {
 	return nil
 }
 
-// write adds the delimited data to the underlying compact shares.
+// write adds delimited data to the underlying compact shares.
 func (css *CompactShareSplitter) write(rawData []byte) error {
-	if css.done {
-		// remove the last element
-		if !css.shareBuilder.IsEmptyShare() {
-			css.shares = css.shares[:len(css.shares)-1]
-		}
-		css.done = false
+	if err := css.stackIfFull(); err != nil {
+		return err
 	}
-
 	if err := css.shareBuilder.MaybeWriteReservedBytes(); err != nil {
 		return err
 	}
+	return css.spill(rawData)
+}
 
-	for {
-		rawDataLeftOver := css.shareBuilder.AddData(rawData)
-		if rawDataLeftOver == nil {
-			break
+// spill writes rawData to the pending share and as many continuation shares as
+// needed, stacking every share that becomes full.
+func (css *CompactShareSplitter) spill(rawData []byte) error {
+	for len(rawData) > 0 {
+		if err := css.stackIfFull(); err != nil {
+			return err
 		}
-		if err := css.stackPending(); err != nil {
+		var err error
+		_, rawData, err = css.shareBuilder.AddData(rawData)
+		if err != nil {
 			return err
 		}
-
-		rawData = rawDataLeftOver
 	}
+	return css.stackIfFull()
+}
 
-	if css.shareBuilder.AvailableBytes() == 0 {
-		if err := css.stackPending(); err != nil {
-			return err
-		}
+// stackIfFull stacks the pending share if it is full.
+func (css *CompactShareSplitter) stackIfFull() error {
+	if css.shareBuilder.AvailableBytes() > 0 {
+		return nil
 	}
-	return nil
+	return css.stackPending()
 }
 
 // stackPending will build & add the pending share to accumulated shares
 func (css *CompactShareSplitter) stackPending() error {
-	pendingShare, err := css.shareBuilder.Build()
+	pendingShare, err := css.shareBuilder.build()
 	if err != nil {
 		return err
 	}
-	css.shares = append(css.shares, *pendingShare)
+	css.shares = append(css.shares, pendingShare)
 
-	// Now we need to create a new builder
-	css.shareBuilder, err = NewBuilder(css.namespace, css.shareVersion, false)
-	return err
+	// Now we need to reset the builder
+	return css.shareBuilder.Reset(css.namespace, css.shareVersion, false)
 }
 
-// Export returns the underlying compact shares
+// Export returns the underlying compact shares. Export does not modify the
+// splitter so more data may be written afterwards and Export called again.
 func (css *CompactShareSplitter) Export() ([]Share, error) {
 	if css.isEmpty() {
 		return []Share{}, nil
 	}
+	shares := make([]Share, css.Count())
+	if _, err := css.ExportInto(shares); err != nil {
+		return []Share{}, err
+	}
+	return shares, nil
+}
 
-	// in case Export is called multiple times
-	if css.done {
-		return css.shares, nil
+// ExportInto is like Export but writes the shares to dst instead of allocating
+// them. It returns the number of shares written, which is Count, or an error if
+// dst has room for fewer shares.
+func (css *CompactShareSplitter) ExportInto(dst []Share) (int, error) {
+	count := css.Count()
+	if len(dst) < count {
+		return 0, fmt.Errorf("can not export %d shares into room for %d shares", count, len(dst))
 	}
+	copy(dst, css.shares)
 
 	var bytesOfPadding int
-	// add the pending share to the current shares before returning
+	// add a zero padded copy of the pending share
 	if !css.shareBuilder.IsEmptyShare() {
-		bytesOfPadding = css.shareBuilder.ZeroPadIfNecessary()
-		if err := css.stackPending(); err != nil {
-			return []Share{}, err
-		}
+		pending := &dst[len(css.shares)]
+		n := copy(pending.data[:], css.shareBuilder.rawShareData)
+		clear(pending.data[n:])
+		bytesOfPadding = ShareSize - n
 	}
 
-	sequenceLen := css.sequenceLen(bytesOfPadding)
-	if err := css.writeSequenceLen(sequenceLen); err != nil {
-		return []Share{}, err
+	if err := writeSequenceLen(dst[:count], sequenceLen(count, bytesOfPadding)); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Reset empties the splitter so that it can split other data while reusing
+// the memory of the shares written so far. Shares returned by Export remain
+// valid.
+func (css *CompactShareSplitter) Reset() {
+	css.shares = css.shares[:0]
+	clear(css.shareRanges)
+	// the namespace and share version were checked by NewCompactShareSplitter
+	if err := css.shareBuilder.Reset(css.namespace, css.shareVersion, true); err != nil {
+		panic(err)
 	}
-	css.done = true
-	return css.shares, nil
+}
+
+// grow preallocates room for n more shares.
+func (css *CompactShareSplitter) grow(n int) {
+	css.shares = slices.Grow(css.shares, n)
 }
 
 // ShareRanges returns a map of share ranges to the corresponding tx keys. All
@@ -155,19 +257,18 @@ func (css *CompactShareSplitter) ShareRanges(shareRangeOffset int) map[[sha256.S
 	return shareRanges
 }
 
-// writeSequenceLen writes the sequence length to the first share.
-func (css *CompactShareSplitter) writeSequenceLen(sequenceLen uint32) error {
-	if css.isEmpty() {
+// writeSequenceLen writes the sequence length to the first of the shares.
+func writeSequenceLen(shares []Share, sequenceLen uint32) error {
+	if len(shares) == 0 {
 		return nil
 	}
 
 	// We may find a more efficient way to write seqLen
-	b, err := NewBuilder(css.namespace, css.shareVersion, true)
+	b, err := NewEmptyBuilder().ImportRawShare(shares[0].ToBytes())
 	if err != nil {
 		return err
 	}
-	b.ImportRawShare(css.shares[0].ToBytes())
-	if err := b.WriteSequenceLen(sequenceLen); err != nil {
+	if err := b.RewriteSequenceLen(sequenceLen); err != nil {
 		return err
 	}
 
@@ -177,25 +278,26 @@ func (css *CompactShareSplitter) writeSequenceLen(sequenceLen uint32) error {
 	}
 
 	// replace existing first share with new first share
-	css.shares[0] = *firstShare
+	shares[0] = *firstShare
 
 	return nil
 }
 
 // sequenceLen returns the total length in bytes of all units (transactions or
-// intermediate state roots) written to this splitter. sequenceLen does not
-// include the number of bytes occupied by the namespace ID, the share info
-// byte, or the reserved bytes. sequenceLen does include the unit length
-// delimiter prefixed to each unit.
-func (css *CompactShareSplitter) sequenceLen(bytesOfPadding int) uint32 {
-	if len(css.shares) == 0 {
+// intermediate state roots) written to shareCount compact shares whose last
+// share contains bytesOfPadding bytes of padding. sequenceLen does not include
+// the number of bytes occupied by the namespace ID, the share info byte, or
+// the reserved bytes. sequenceLen does include the unit length delimiter
+// prefixed to each unit.
+func sequenceLen(shareCount int, bytesOfPadding int) uint32 {
+	if shareCount == 0 {
 		return 0
 	}
-	if len(css.shares) == 1 {
+	if shareCount == 1 {
 		return uint32(FirstCompactShareContentSize) - uint32(bytesOfPadding)
 	}
 
-	continuationSharesCount := len(css.shares) - 1
+	continuationSharesCount := shareCount - 1
 	continuationSharesSequenceLen := continuationSharesCount * ContinuationCompactShareContentSize
 	return uint32(FirstCompactShareContentSize + continuationSharesSequenceLen - bytesOfPadding)
 }
@@ -206,15 +308,37 @@ func (css *CompactShareSplitter) isEmpty() bool {
 }
 
 // Count returns the number of shares that would be made if `Export` was invoked
-// on this compact share splitter.
+// on this compact share splitter. It doesn't modify the splitter.
 func (css *CompactShareSplitter) Count() int {
-	if !css.shareBuilder.IsEmptyShare() && !css.done {
+	if !css.shareBuilder.IsEmptyShare() {
 		// pending share is non-empty, so it will be zero padded and added to shares during export
 		return len(css.shares) + 1
 	}
 	return len(css.shares)
 }
 
+// PendingBytes returns the number of data bytes written to the pending,
+// partially filled share. It is 0 if there is no pending share.
+func (css *CompactShareSplitter) PendingBytes() int {
+	return css.shareBuilder.DataLen()
+}
+
+// ForEachShare calls fn for every share that Export would return, including
+// the padded pending share. It stops at the first error returned by fn and
+// returns that error.
+func (css *CompactShareSplitter) ForEachShare(fn func(Share) error) error {
+	shares, err := css.Export()
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if err := fn(share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MarshalDelimitedTx prefixes a transaction with the length of the transaction
 // encoded as a varint.
 func MarshalDelimitedTx(tx []byte) ([]byte, error) {
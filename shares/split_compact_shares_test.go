@@ -3,6 +3,7 @@ package shares
 import (
 	"bytes"
 	"crypto/sha256"
+	"math/rand"
 	"testing"
 
 	"github.com/celestiaorg/go-square/namespace"
@@ -72,14 +73,14 @@ func TestExport_write(t *testing.T) {
 		),
 		ShareSize)
 
-	firstShare := fillShare(Share{data: append(
+	firstShare := fillShare(append(
 		namespace.TxNamespace.Bytes(),
 		[]byte{
 			0x1,                // info byte
 			0x0, 0x0, 0x2, 0x0, // sequence len
 			0x0, 0x0, 0x0, 0x26, // reserved bytes
 		}...,
-	)}, 0xf)
+	), 0xf)
 
 	continuationShare, _ := zeroPadIfNecessary(
 		append(
@@ -101,7 +102,7 @@ func TestExport_write(t *testing.T) {
 		{
 			name: "one share with small sequence len",
 			want: []Share{
-				{data: oneShare},
+				shareFromBytes(oneShare),
 			},
 			writeBytes: [][]byte{{0xf}},
 		},
@@ -109,7 +110,7 @@ func TestExport_write(t *testing.T) {
 			name: "two shares with big sequence len",
 			want: []Share{
 				firstShare,
-				{data: continuationShare},
+				shareFromBytes(continuationShare),
 			},
 			writeBytes: [][]byte{bytes.Repeat([]byte{0xf}, 512)},
 		},
@@ -374,3 +375,258 @@ func TestWriteAfterExport(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 5, len(shares))
 }
+
+func TestCompactShareSplitterReset(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	reused := NewCompactShareSplitter(namespace.PayForBlobNamespace, ShareVersionZero)
+	for i := 0; i < 10; i++ {
+		fresh := NewCompactShareSplitter(namespace.PayForBlobNamespace, ShareVersionZero)
+		reused.Reset()
+		for j := rng.Intn(20); j > 0; j-- {
+			tx := make([]byte, 1+rng.Intn(2000))
+			_, _ = rng.Read(tx)
+			require.NoError(t, fresh.WriteTx(tx))
+			require.NoError(t, reused.WriteTx(tx))
+		}
+		want, err := fresh.Export()
+		require.NoError(t, err)
+		got, err := reused.Export()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.Equal(t, fresh.ShareRanges(0), reused.ShareRanges(0))
+
+		// ExportInto writes the same shares without allocating them
+		dst := make([]Share, len(want)+1)
+		n, err := reused.ExportInto(dst)
+		require.NoError(t, err)
+		assert.Equal(t, want, dst[:n])
+		if len(want) > 0 {
+			_, err = reused.ExportInto(dst[:len(want)-1])
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestCountDoesNotFinalize(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+		var txs [][]byte
+		for i := 0; i < 30; i++ {
+			tx := bytes.Repeat([]byte{byte(i + 1)}, 1+r.Intn(2*ShareSize))
+			require.NoError(t, css.WriteTx(tx))
+			txs = append(txs, tx)
+
+			count := css.Count()
+			assert.Equal(t, count, css.Count())
+			if r.Intn(2) == 0 {
+				continue
+			}
+			shares, err := css.Export()
+			require.NoError(t, err)
+			require.Len(t, shares, count)
+			assert.Equal(t, count, css.Count())
+
+			// the pending share is the last exported share
+			rawData, err := shares[len(shares)-1].RawData()
+			require.NoError(t, err)
+			if css.PendingBytes() > 0 {
+				assert.True(t, IsAllZero(rawData[css.PendingBytes():]))
+			}
+
+			got, err := ParseTxs(shares)
+			require.NoError(t, err)
+			assert.Equal(t, txs, got)
+		}
+	}
+}
+
+func TestPendingBytes(t *testing.T) {
+	css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+	assert.Zero(t, css.PendingBytes())
+
+	require.NoError(t, css.WriteTx([]byte{1, 2, 3}))
+	assert.Equal(t, 4, css.PendingBytes())
+
+	// fill the first share exactly
+	require.NoError(t, css.WriteTx(bytes.Repeat([]byte{1}, RawTxSize(FirstCompactShareContentSize-4))))
+	assert.Zero(t, css.PendingBytes())
+	assert.Equal(t, 1, css.Count())
+
+	require.NoError(t, css.WriteTx([]byte{1}))
+	assert.Equal(t, 2, css.PendingBytes())
+	assert.Equal(t, 2, css.Count())
+}
+
+func BenchmarkCompactShareSplitter(b *testing.B) {
+	txs := GenerateRandomTxs(1000, 2000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+		for _, tx := range txs {
+			if err := css.WriteTx(tx); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if _, err := css.Export(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestReservedBytesAtShareBoundary verifies that when a tx ends exactly at the
+// end of a share, the reserved bytes point to the start of the next unit in
+// the share it starts in and shares in which no unit starts have zero reserved
+// bytes.
+func TestReservedBytesAtShareBoundary(t *testing.T) {
+	firstHeaderLen := namespace.NamespaceSize + ShareInfoBytes + SequenceLenBytes + CompactShareReservedBytes
+	continuationHeaderLen := namespace.NamespaceSize + ShareInfoBytes + CompactShareReservedBytes
+
+	txs := [][]byte{
+		// ends exactly at the end of the first share
+		generateTx(1),
+		// starts at the beginning of the second share and ends exactly at the
+		// end of the third share
+		bytes.Repeat([]byte{2}, RawTxSize(2*ContinuationCompactShareContentSize)),
+		// starts at the beginning of the fourth share
+		{3},
+	}
+	css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+	for _, tx := range txs {
+		require.NoError(t, css.WriteTx(tx))
+	}
+	shares, err := css.Export()
+	require.NoError(t, err)
+	require.Len(t, shares, 4)
+
+	reservedBytes := func(share Share, headerLen int) uint32 {
+		start := headerLen - CompactShareReservedBytes
+		reserved, err := ParseReservedBytes(share.data[start:headerLen])
+		require.NoError(t, err)
+		return reserved
+	}
+	assert.Equal(t, uint32(firstHeaderLen), reservedBytes(shares[0], firstHeaderLen))
+	assert.Equal(t, uint32(continuationHeaderLen), reservedBytes(shares[1], continuationHeaderLen))
+	assert.Equal(t, uint32(0), reservedBytes(shares[2], continuationHeaderLen))
+	assert.Equal(t, uint32(continuationHeaderLen), reservedBytes(shares[3], continuationHeaderLen))
+
+	assert.Equal(t, NewRange(0, 1), css.shareRanges[sha256.Sum256(txs[0])])
+	assert.Equal(t, NewRange(1, 3), css.shareRanges[sha256.Sum256(txs[1])])
+	assert.Equal(t, NewRange(3, 4), css.shareRanges[sha256.Sum256(txs[2])])
+
+	got, err := ParseTxs(shares)
+	require.NoError(t, err)
+	assert.Equal(t, txs, got)
+
+	// every share can be used as the starting point of a reader
+	for i := range shares {
+		if i == 2 {
+			continue
+		}
+		_, err := NewCompactSequenceReader(shares[i:])
+		require.NoError(t, err)
+	}
+}
+
+func TestWriteTxDelimiterAcrossShares(t *testing.T) {
+	// the first tx leaves a single byte in the first share so the two byte
+	// delimiter of the second tx spans the share boundary
+	first := bytes.Repeat([]byte{1}, RawTxSize(FirstCompactShareContentSize-1))
+	second := bytes.Repeat([]byte{2}, 200)
+	// a tx that spans more than two shares
+	third := bytes.Repeat([]byte{3}, 3*ShareSize)
+	txs := [][]byte{first, second, third}
+
+	css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+	for _, tx := range txs {
+		require.NoError(t, css.WriteTx(tx))
+	}
+	shares, err := css.Export()
+	require.NoError(t, err)
+
+	assert.Equal(t, NewRange(0, 2), css.shareRanges[sha256.Sum256(second)])
+	firstHeaderLen := namespace.NamespaceSize + ShareInfoBytes + SequenceLenBytes + CompactShareReservedBytes
+	reserved, err := ParseReservedBytes(shares[0].data[firstHeaderLen-CompactShareReservedBytes : firstHeaderLen])
+	require.NoError(t, err)
+	assert.Equal(t, uint32(firstHeaderLen), reserved)
+
+	got, err := ParseTxs(shares)
+	require.NoError(t, err)
+	assert.Equal(t, txs, got)
+
+	reader, err := NewCompactSequenceReader(shares)
+	require.NoError(t, err)
+	for i, tx := range txs {
+		unit, err := reader.SeekToUnit(i)
+		require.NoError(t, err)
+		assert.Equal(t, tx, unit)
+	}
+}
+
+func TestNewCompactShareSplitterFromShares(t *testing.T) {
+	txs := generateRandomTxs(100, 150)
+	txs = append(txs, generateTx(1), generateTx(3), []byte{1})
+
+	oneSession := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+	for _, tx := range txs {
+		require.NoError(t, oneSession.WriteTx(tx))
+	}
+	want, err := oneSession.Export()
+	require.NoError(t, err)
+
+	// resume after every number of txs so that the first session ends in
+	// the first share, on a share boundary and in the middle of a share
+	for split := 0; split <= len(txs); split++ {
+		first := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+		for _, tx := range txs[:split] {
+			require.NoError(t, first.WriteTx(tx))
+		}
+		exported, err := first.Export()
+		require.NoError(t, err)
+
+		resumed, err := NewCompactShareSplitterFromShares(namespace.TxNamespace, ShareVersionZero, exported)
+		require.NoError(t, err, "split %d", split)
+		assert.Equal(t, first.Count(), resumed.Count(), "split %d", split)
+		for _, tx := range txs[split:] {
+			require.NoError(t, resumed.WriteTx(tx))
+		}
+		got, err := resumed.Export()
+		require.NoError(t, err)
+		require.Equal(t, want, got, "split %d", split)
+		assert.Equal(t, oneSession.ShareRanges(0), resumed.ShareRanges(0), "split %d", split)
+	}
+}
+
+func TestNewCompactShareSplitterFromSharesErrors(t *testing.T) {
+	css := NewCompactShareSplitter(namespace.TxNamespace, ShareVersionZero)
+	for _, tx := range generateRandomTxs(10, 150) {
+		require.NoError(t, css.WriteTx(tx))
+	}
+	txShares, err := css.Export()
+	require.NoError(t, err)
+	require.Greater(t, len(txShares), 1)
+
+	t.Run("different namespace", func(t *testing.T) {
+		_, err := NewCompactShareSplitterFromShares(namespace.PayForBlobNamespace, ShareVersionZero, txShares)
+		assert.ErrorIs(t, err, ErrNamespaceMismatch)
+	})
+	t.Run("sparse namespace", func(t *testing.T) {
+		_, err := NewCompactShareSplitterFromShares(ns1, ShareVersionZero, txShares)
+		assert.ErrorIs(t, err, ErrNotCompactShare)
+	})
+	t.Run("different share version", func(t *testing.T) {
+		_, err := NewCompactShareSplitterFromShares(namespace.TxNamespace, ShareVersionOne, txShares)
+		assert.ErrorIs(t, err, ErrShareVersionMismatch)
+	})
+	t.Run("missing share", func(t *testing.T) {
+		_, err := NewCompactShareSplitterFromShares(namespace.TxNamespace, ShareVersionZero, txShares[:len(txShares)-1])
+		assert.ErrorIs(t, err, ErrInvalidSequenceLen)
+	})
+	t.Run("invalid reserved bytes", func(t *testing.T) {
+		shares := append([]Share(nil), txShares...)
+		shares[1].data[continuationCompactShareHeaderLen-1]++
+		_, err := NewCompactShareSplitterFromShares(namespace.TxNamespace, ShareVersionZero, shares)
+		assert.ErrorIs(t, err, ErrInvalidReservedBytes)
+	})
+}
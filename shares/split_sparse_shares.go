@@ -2,7 +2,6 @@ package shares
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/celestiaorg/go-square/blob"
 	"golang.org/x/exp/slices"
@@ -20,14 +19,30 @@ func NewSparseShareSplitter() *SparseShareSplitter {
 }
 
 // Write writes the provided blob to this sparse share splitter. It returns an
-// error or nil if no error is encountered.
+// error, e.g. blob.ErrNilBlob for a nil blob, or nil if no error is
+// encountered.
 func (sss *SparseShareSplitter) Write(blob *blob.Blob) error {
 	if err := blob.Validate(); err != nil {
 		return err
 	}
+	sss.shares = slices.Grow(sss.shares, SparseSharesNeededForVersion(uint32(len(blob.Data)), uint8(blob.ShareVersion)))
+	return SplitSparseFunc(blob, func(share Share) error {
+		sss.shares = append(sss.shares, share)
+		return nil
+	})
+}
 
-	if !slices.Contains(SupportedShareVersions, uint8(blob.ShareVersion)) {
-		return fmt.Errorf("unsupported share version: %d", blob.ShareVersion)
+// SplitSparseFunc splits the provided blob into sparse shares and passes each
+// share to yield as soon as it is complete. Shares are not retained so memory
+// use does not grow with the number of shares. Splitting stops at the first
+// error returned by yield and that error is returned.
+func SplitSparseFunc(blob *blob.Blob, yield func(Share) error) error {
+	if err := blob.Validate(); err != nil {
+		return err
+	}
+
+	if err := checkShareVersion(uint8(blob.ShareVersion), SupportedShareVersions); err != nil {
+		return err
 	}
 
 	rawData := blob.Data
@@ -41,35 +56,121 @@ func (sss *SparseShareSplitter) Write(blob *blob.Blob) error {
 	if err := b.WriteSequenceLen(uint32(len(rawData))); err != nil {
 		return err
 	}
+	if uint8(blob.ShareVersion) == ShareVersionOne {
+		if err := b.WriteSigner(blob.Signer); err != nil {
+			return err
+		}
+	}
 
 	for rawData != nil {
 
-		rawDataLeftOver := b.AddData(rawData)
+		_, rawDataLeftOver, err := b.AddData(rawData)
+		if err != nil {
+			return err
+		}
 		if rawDataLeftOver == nil {
 			// Just call it on the latest share
 			b.ZeroPadIfNecessary()
 		}
 
-		share, err := b.Build()
+		share, err := b.build()
 		if err != nil {
 			return err
 		}
-		sss.shares = append(sss.shares, *share)
-
-		b, err = NewBuilder(blobNamespace, uint8(blob.ShareVersion), false)
-		if err != nil {
+		if err := yield(share); err != nil {
 			return err
 		}
 		rawData = rawDataLeftOver
+		if rawData == nil {
+			break
+		}
+
+		// reuse the builder for the next share
+		if err := b.Reset(blobNamespace, uint8(blob.ShareVersion), false); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// WriteNamespacePaddingShares adds padding shares with the namespace of the
-// last written share. This is useful to follow the non-interactive default
-// rules. This function assumes that at least one share has already been
-// written.
+// SplitSourceFunc is like SplitSparseFunc but reads the data of the blob from
+// src so that the data doesn't have to be held in memory. It returns an error
+// wrapping blob.ErrSourceLength if src doesn't provide exactly src.Len() bytes,
+// in which case some shares may already have been passed to yield.
+func SplitSourceFunc(src blob.BlobSource, yield func(Share) error) error {
+	if err := blob.ValidateSource(src); err != nil {
+		return err
+	}
+	if err := checkShareVersion(src.ShareVersion(), SupportedShareVersions); err != nil {
+		return err
+	}
+
+	b, err := NewBuilder(src.Namespace(), src.ShareVersion(), true)
+	if err != nil {
+		return err
+	}
+	if err := b.WriteSequenceLen(uint32(src.Len())); err != nil {
+		return err
+	}
+	if src.ShareVersion() == ShareVersionOne {
+		if err := b.WriteSigner(src.Signer()); err != nil {
+			return err
+		}
+	}
+
+	w := &sourceShareWriter{builder: b, src: src, yield: yield}
+	if err := blob.CopySource(w, src); err != nil {
+		return err
+	}
+	b.ZeroPadIfNecessary()
+	share, err := b.build()
+	if err != nil {
+		return err
+	}
+	return yield(share)
+}
+
+// sourceShareWriter adds the data written to it to the shares of a blob and
+// passes every share but the last one to yield once it is full.
+type sourceShareWriter struct {
+	builder *Builder
+	src     blob.BlobSource
+	yield   func(Share) error
+}
+
+func (w *sourceShareWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// a full share is only yielded once there is more data so that the
+		// last share can be padded
+		if w.builder.AvailableBytes() == 0 {
+			share, err := w.builder.build()
+			if err != nil {
+				return n, err
+			}
+			if err := w.yield(share); err != nil {
+				return n, err
+			}
+			if err := w.builder.Reset(w.src.Namespace(), w.src.ShareVersion(), false); err != nil {
+				return n, err
+			}
+		}
+		written, leftover, err := w.builder.AddData(p)
+		if err != nil {
+			return n, err
+		}
+		n += written
+		p = leftover
+	}
+	return n, nil
+}
+
+// WriteNamespacePaddingShares appends count padding shares with the namespace
+// and share version of the last written share. This is useful to follow the
+// non-interactive default rules or to implement custom layouts. Every padding
+// share starts its own sequence with a sequence length of 0 and is reflected
+// in Count and Export. It returns an error if count is negative or if no blob
+// has been written yet; a count of 0 is a no-op.
 func (sss *SparseShareSplitter) WriteNamespacePaddingShares(count int) error {
 	if count < 0 {
 		return errors.New("cannot write negative namespaced shares")
@@ -98,12 +199,24 @@ func (sss *SparseShareSplitter) WriteNamespacePaddingShares(count int) error {
 	return nil
 }
 
-// Export finalizes and returns the underlying shares.
-func (sss *SparseShareSplitter) Export() []Share {
-	return sss.shares
+// Export finalizes and returns the underlying shares. It never returns an
+// error; the error is part of the signature to satisfy Splitter.
+func (sss *SparseShareSplitter) Export() ([]Share, error) {
+	return sss.shares, nil
 }
 
 // Count returns the current number of shares that will be made if exporting.
 func (sss *SparseShareSplitter) Count() int {
 	return len(sss.shares)
 }
+
+// ForEachShare calls fn for every share that Export would return. It stops at
+// the first error returned by fn and returns that error.
+func (sss *SparseShareSplitter) ForEachShare(fn func(Share) error) error {
+	for _, share := range sss.shares {
+		if err := fn(share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
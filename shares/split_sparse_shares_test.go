@@ -2,11 +2,16 @@ package shares
 
 import (
 	"bytes"
+	"errors"
+	"math/rand"
 	"testing"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
 )
 
 // TestSparseShareSplitter tests that the spare share splitter can split blobs
@@ -25,10 +30,151 @@ func TestSparseShareSplitter(t *testing.T) {
 	err = sss.Write(blob2)
 	assert.NoError(t, err)
 
-	got := sss.Export()
+	got, err := sss.Export()
+	assert.NoError(t, err)
 	assert.Len(t, got, 2)
 }
 
+func TestSplitSparseFunc(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	b := blob.New(ns1, bytes.Repeat([]byte{0xf}, 4*ShareSize), ShareVersionZero)
+
+	sss := NewSparseShareSplitter()
+	assert.NoError(t, sss.Write(b))
+
+	var got []Share
+	err := SplitSparseFunc(b, func(share Share) error {
+		got = append(got, share)
+		return nil
+	})
+	assert.NoError(t, err)
+	want, err := sss.Export()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = SplitSparseFunc(b, func(Share) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSplitBlobsUnsupportedShareVersion(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	_, err := SplitBlobs(newBlob(ns1, 5))
+	assert.ErrorIs(t, err, ErrUnsupportedShareVersion)
+	assert.ErrorContains(t, err, "5")
+
+	assert.ErrorIs(t, NewSparseShareSplitter().Write(newBlob(ns1, 5)), ErrUnsupportedShareVersion)
+}
+
+func TestSplitBlobsNilBlob(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+
+	_, err := SplitBlobs(nil)
+	assert.ErrorIs(t, err, blob.ErrNilBlob)
+	_, err = SplitBlobs(newBlob(ns1, ShareVersionZero), nil)
+	assert.ErrorIs(t, err, blob.ErrNilBlob)
+
+	assert.ErrorIs(t, NewSparseShareSplitter().Write(nil), blob.ErrNilBlob)
+}
+
+// TestTotalSparseSharesNeededMatchesSplitter checks that the share count
+// estimates match the number of shares written by the splitter for random
+// namespace sorted blobs.
+func TestTotalSparseSharesNeededMatchesSplitter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		count := 1 + r.Intn(10)
+		namespaces := make([]namespace.Namespace, count)
+		sizes := make([]uint32, count)
+		blobs := make([]*blob.Blob, count)
+		for j := range blobs {
+			// few distinct namespaces so that some blobs share a namespace
+			namespaces[j] = namespace.MustNewV0(bytes.Repeat([]byte{byte(1 + r.Intn(3))}, namespace.NamespaceVersionZeroIDSize))
+		}
+		slices.SortFunc(namespaces, func(a, b namespace.Namespace) int { return bytes.Compare(a.Bytes(), b.Bytes()) })
+		for j := range blobs {
+			sizes[j] = uint32(1 + r.Intn(5*ShareSize))
+			blobs[j] = blob.New(namespaces[j], bytes.Repeat([]byte{0xf}, int(sizes[j])), ShareVersionZero)
+		}
+
+		shares, err := SplitBlobs(blobs...)
+		require.NoError(t, err)
+		assert.Equal(t, len(shares), TotalSparseSharesNeeded(sizes))
+
+		withPadding, err := TotalSparseSharesNeededWithPadding(namespaces, sizes)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, withPadding, len(shares))
+		if namespaces[0].Equals(namespaces[count-1]) {
+			assert.Equal(t, len(shares), withPadding)
+		}
+	}
+}
+
+func TestSplitBlobsShareVersionOneRoundTrip(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	v0 := blob.New(ns1, bytes.Repeat([]byte{0xe}, ShareSize), ShareVersionZero)
+	var blobs []*blob.Blob
+	for _, size := range []int{1, FirstSparseShareWithSignerContentSize, FirstSparseShareWithSignerContentSize + 1, 3 * ShareSize} {
+		b, err := blob.NewV1(ns1, bytes.Repeat([]byte{0xf}, size), bytes.Repeat([]byte{byte(size)}, SignerSize))
+		require.NoError(t, err)
+		blobs = append(blobs, b, v0)
+	}
+
+	shares, err := SplitBlobs(blobs...)
+	require.NoError(t, err)
+	parsed, err := ParseBlobs(shares)
+	require.NoError(t, err)
+	require.Len(t, parsed, len(blobs))
+	for i := range blobs {
+		assert.True(t, proto.Equal(blobs[i], parsed[i]), "blob %d", i)
+	}
+}
+
+func TestSparseShareSplitterShareVersionOne(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	signer := bytes.Repeat([]byte{0xaa}, SignerSize)
+	b := blob.New(ns1, bytes.Repeat([]byte{0xf}, 2*ShareSize), ShareVersionOne)
+	b.Signer = signer
+
+	sss := NewSparseShareSplitter()
+	require.NoError(t, sss.Write(b))
+	got, err := sss.Export()
+	require.NoError(t, err)
+	assert.Len(t, got, SparseSharesNeededForVersion(uint32(len(b.Data)), ShareVersionOne))
+
+	gotSigner, err := got[0].Signer()
+	require.NoError(t, err)
+	assert.Equal(t, signer, gotSigner)
+
+	parsed, err := ParseBlobs(got)
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+	assert.Equal(t, b.Data, parsed[0].Data)
+	assert.Equal(t, signer, parsed[0].Signer)
+	assert.Equal(t, uint32(ShareVersionOne), parsed[0].ShareVersion)
+
+	sequences, err := ParseShares(got, false)
+	require.NoError(t, err)
+	require.Len(t, sequences, 1)
+	data, err := sequences[0].RawData()
+	require.NoError(t, err)
+	assert.Equal(t, b.Data, data)
+
+	t.Run("missing signer", func(t *testing.T) {
+		b := blob.New(ns1, []byte{1}, ShareVersionOne)
+		assert.Error(t, NewSparseShareSplitter().Write(b))
+	})
+}
+
 func TestWriteNamespacePaddingShares(t *testing.T) {
 	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
 	blob1 := newBlob(ns1, ShareVersionZero)
@@ -41,7 +187,8 @@ func TestWriteNamespacePaddingShares(t *testing.T) {
 	assert.NoError(t, err)
 
 	// got is expected to be [blob1, padding]
-	got := sss.Export()
+	got, err := sss.Export()
+	assert.NoError(t, err)
 	assert.Len(t, got, 2)
 
 	// verify that the second share is padding
@@ -53,8 +200,76 @@ func TestWriteNamespacePaddingShares(t *testing.T) {
 	info, err := got[1].InfoByte()
 	assert.NoError(t, err)
 	assert.Equal(t, info.Version(), ShareVersionZero)
+
+	t.Run("share version one", func(t *testing.T) {
+		blob1 := newBlob(ns1, ShareVersionOne)
+		blob1.Signer = bytes.Repeat([]byte{1}, SignerSize)
+		sss := NewSparseShareSplitter()
+		require.NoError(t, sss.Write(blob1))
+		require.NoError(t, sss.WriteNamespacePaddingShares(2))
+
+		got, err := sss.Export()
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+		for _, share := range got[1:] {
+			assert.NoError(t, ValidatePaddingShare(share))
+			version, err := share.Version()
+			require.NoError(t, err)
+			assert.Equal(t, ShareVersionOne, version)
+		}
+
+		parsed, err := ParseBlobs(got)
+		require.NoError(t, err)
+		require.Len(t, parsed, 1)
+		assert.Equal(t, blob1.Data, parsed[0].Data)
+	})
+}
+
+func TestWriteNamespacePaddingSharesCount(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	sss := NewSparseShareSplitter()
+	assert.Error(t, sss.WriteNamespacePaddingShares(1), "no blob has been written")
+
+	require.NoError(t, sss.Write(newBlob(ns1, ShareVersionZero)))
+	assert.Error(t, sss.WriteNamespacePaddingShares(-1))
+	require.NoError(t, sss.WriteNamespacePaddingShares(0))
+	assert.Equal(t, 1, sss.Count())
+
+	require.NoError(t, sss.WriteNamespacePaddingShares(3))
+	assert.Equal(t, 4, sss.Count())
+	got, err := sss.Export()
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+	for _, share := range got[1:] {
+		ns, err := share.Namespace()
+		require.NoError(t, err)
+		assert.Equal(t, ns1, ns)
+		isStart, err := share.IsSequenceStart()
+		require.NoError(t, err)
+		assert.True(t, isStart)
+		sequenceLen, err := share.SequenceLen()
+		require.NoError(t, err)
+		assert.Zero(t, sequenceLen)
+	}
+
+	sequences, err := ParseShares(got, false)
+	require.NoError(t, err)
+	assert.Len(t, sequences, 4)
 }
 
 func newBlob(ns namespace.Namespace, shareVersion uint8) *blob.Blob {
 	return blob.New(ns, []byte("data"), shareVersion)
 }
+
+func BenchmarkSparseShareSplitter(b *testing.B) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	blob := blob.New(ns1, bytes.Repeat([]byte{0xf}, 2*1024*1024), ShareVersionZero)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sss := NewSparseShareSplitter()
+		if err := sss.Write(blob); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package testfactory provides deterministic generators of valid blobs and
+// shares for use in tests. All generators take a *rand.Rand so that failures
+// can be reproduced from the seed.
+package testfactory
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+)
+
+// RandomBlobNamespace returns a random version 0 namespace that is not
+// reserved. It is a shorthand for namespace.RandomBlobNamespace.
+func RandomBlobNamespace(rng *rand.Rand) namespace.Namespace {
+	return namespace.RandomBlobNamespace(rng)
+}
+
+// RandomBlob returns a valid share version 0 blob with a random namespace and
+// between 1 and maxSize bytes of random data.
+func RandomBlob(rng *rand.Rand, maxSize int) *blob.Blob {
+	if maxSize < 1 {
+		panic("maxSize must be positive")
+	}
+	return randomBlobOfSize(rng, 1+rng.Intn(maxSize))
+}
+
+// RandomBlobWithNamespace returns a valid blob in ns with the provided share
+// version that holds size bytes of random data. Share version 1 blobs get a
+// random signer. ns must be a namespace that blobs can use.
+func RandomBlobWithNamespace(rng *rand.Rand, ns namespace.Namespace, size int, shareVersion uint8) *blob.Blob {
+	b := blob.New(ns, RandomBytes(rng, size), shareVersion)
+	if shareVersion == shares.ShareVersionOne {
+		b.Signer = RandomBytes(rng, shares.SignerSize)
+	}
+	return b
+}
+
+// RandomBytes returns size random bytes.
+func RandomBytes(rng *rand.Rand, size int) []byte {
+	b := make([]byte, size)
+	_, _ = rng.Read(b)
+	return b
+}
+
+// RandomShares returns n valid sparse shares that hold randomly sized blobs
+// sorted by namespace.
+func RandomShares(rng *rand.Rand, n int) []shares.Share {
+	blobs := make([]*blob.Blob, 0)
+	for remaining := n; remaining > 0; {
+		shareCount := 1 + rng.Intn(remaining)
+		minSize := capacity(shareCount-1) + 1
+		size := minSize + rng.Intn(capacity(shareCount)-minSize+1)
+		blobs = append(blobs, randomBlobOfSize(rng, size))
+		remaining -= shareCount
+	}
+	blob.Sort(blobs)
+
+	out, err := shares.SplitBlobs(blobs...)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// RandomBlobSequence returns the shares of a share version 0 blob in ns that
+// holds byteLen bytes of random data. ns must be a namespace that blobs can
+// use.
+func RandomBlobSequence(rng *rand.Rand, ns namespace.Namespace, byteLen int) []shares.Share {
+	out, err := shares.SplitBlobs(RandomBlobWithNamespace(rng, ns, byteLen, shares.ShareVersionZero))
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// RandomCompactSequence returns the compact shares of the transaction
+// namespace that hold random transactions with the provided sizes, together
+// with the transactions.
+func RandomCompactSequence(rng *rand.Rand, txSizes []int) ([]shares.Share, [][]byte) {
+	txs := make([][]byte, len(txSizes))
+	css := shares.NewCompactShareSplitter(namespace.TxNamespace, shares.ShareVersionZero)
+	for i, size := range txSizes {
+		txs[i] = RandomBytes(rng, size)
+		if err := css.WriteTx(txs[i]); err != nil {
+			panic(err)
+		}
+	}
+	out, err := css.Export()
+	if err != nil {
+		panic(err)
+	}
+	return out, txs
+}
+
+// MutateShare returns a copy of share in which every byte of region is
+// inverted, e.g. to test that a corrupted region is detected. It panics if the
+// share doesn't contain region.
+func MutateShare(share shares.Share, region shares.ShareRegion) shares.Share {
+	start, end, ok := share.RegionBounds(region)
+	if !ok {
+		panic(fmt.Sprintf("share does not contain the %s region", region))
+	}
+	data := share.ToBytes()
+	for i := start; i < end; i++ {
+		data[i] = ^data[i]
+	}
+	mutated, err := shares.NewShare(data)
+	if err != nil {
+		panic(err)
+	}
+	return *mutated
+}
+
+func randomBlobOfSize(rng *rand.Rand, size int) *blob.Blob {
+	return RandomBlobWithNamespace(rng, RandomBlobNamespace(rng), size, shares.ShareVersionZero)
+}
+
+// capacity returns the number of data bytes that fit in shareCount sparse
+// shares.
+func capacity(shareCount int) int {
+	if shareCount == 0 {
+		return 0
+	}
+	return shares.FirstSparseShareContentSize + (shareCount-1)*shares.ContinuationSparseShareContentSize
+}
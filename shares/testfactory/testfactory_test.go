@@ -0,0 +1,135 @@
+package testfactory
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomBlob(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		b := RandomBlob(rng, 1000)
+		require.NoError(t, b.Validate())
+		assert.LessOrEqual(t, len(b.Data), 1000)
+	}
+	assert.Equal(t, RandomBlob(rand.New(rand.NewSource(2)), 100), RandomBlob(rand.New(rand.NewSource(2)), 100))
+}
+
+func TestRandomBlobWithNamespace(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ns := RandomBlobNamespace(rng)
+	for _, shareVersion := range shares.SupportedShareVersions {
+		b := RandomBlobWithNamespace(rng, ns, 100, shareVersion)
+		require.NoError(t, b.Validate())
+		assert.Equal(t, ns, b.Namespace())
+		assert.Len(t, b.Data, 100)
+		assert.Equal(t, uint32(shareVersion), b.ShareVersion)
+	}
+	assert.Equal(t, RandomBytes(rand.New(rand.NewSource(2)), 10), RandomBytes(rand.New(rand.NewSource(2)), 10))
+}
+
+func TestRandomShares(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 10, 100} {
+		got := RandomShares(rng, n)
+		assert.Len(t, got, n)
+		for _, err := range shares.ValidateShares(got) {
+			assert.NoError(t, err)
+		}
+		for i := 1; i < len(got); i++ {
+			prev, err := got[i-1].Namespace()
+			require.NoError(t, err)
+			ns, err := got[i].Namespace()
+			require.NoError(t, err)
+			assert.True(t, prev.IsLessOrEqualThan(ns))
+		}
+		sequences, err := shares.ParseShares(got, false)
+		require.NoError(t, err)
+		for _, sequence := range sequences {
+			_, err := sequence.RawData()
+			assert.NoError(t, err)
+		}
+	}
+	assert.Equal(t, RandomShares(rand.New(rand.NewSource(2)), 20), RandomShares(rand.New(rand.NewSource(2)), 20))
+}
+
+func TestRandomBlobSequence(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ns := RandomBlobNamespace(rng)
+	for _, byteLen := range []int{1, shares.FirstSparseShareContentSize, shares.FirstSparseShareContentSize + 1, 10_000} {
+		got := RandomBlobSequence(rng, ns, byteLen)
+		assert.Len(t, got, shares.SparseSharesNeeded(uint32(byteLen)))
+		sequences, err := shares.ParseShares(got, false)
+		require.NoError(t, err)
+		require.Len(t, sequences, 1)
+		assert.Equal(t, ns, sequences[0].Namespace)
+		data, err := sequences[0].RawDataStrict()
+		require.NoError(t, err)
+		assert.Len(t, data, byteLen)
+	}
+	assert.Equal(t, RandomBlobSequence(rand.New(rand.NewSource(2)), ns, 2000), RandomBlobSequence(rand.New(rand.NewSource(2)), ns, 2000))
+}
+
+func TestRandomCompactSequence(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	txSizes := []int{1, 100, shares.FirstCompactShareContentSize, 2000, 3}
+	got, txs := RandomCompactSequence(rng, txSizes)
+	require.Len(t, txs, len(txSizes))
+	for i, tx := range txs {
+		assert.Len(t, tx, txSizes[i])
+	}
+	_, err := shares.ParseShares(got, false)
+	require.NoError(t, err)
+	parsed, err := shares.ParseTxs(got)
+	require.NoError(t, err)
+	assert.Equal(t, txs, parsed)
+
+	want, _ := RandomCompactSequence(rand.New(rand.NewSource(2)), txSizes)
+	again, _ := RandomCompactSequence(rand.New(rand.NewSource(2)), txSizes)
+	assert.Equal(t, want, again)
+}
+
+func TestMutateShare(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	compact, _ := RandomCompactSequence(rng, []int{100, 2000})
+	sparse := RandomBlobSequence(rng, RandomBlobNamespace(rng), 1000)
+
+	testCases := []struct {
+		name     string
+		sequence []shares.Share
+		index    int
+		region   shares.ShareRegion
+	}{
+		{"namespace", sparse, 0, shares.RegionNamespace},
+		{"info byte", sparse, 1, shares.RegionInfoByte},
+		{"sequence length", sparse, 0, shares.RegionSequenceLen},
+		{"reserved bytes", compact, 1, shares.RegionReservedBytes},
+		{"payload", sparse, len(sparse) - 1, shares.RegionPayload},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := tc.sequence[tc.index]
+			mutated := MutateShare(original, tc.region)
+
+			start, end, ok := original.RegionBounds(tc.region)
+			require.True(t, ok)
+			before, after := original.ToBytes(), mutated.ToBytes()
+			assert.Equal(t, before[:start], after[:start])
+			assert.Equal(t, before[end:], after[end:])
+			for i := start; i < end; i++ {
+				assert.Equal(t, ^before[i], after[i])
+			}
+
+			corrupted := append([]shares.Share(nil), tc.sequence...)
+			corrupted[tc.index] = mutated
+			_, err := shares.ParseShares(corrupted, false)
+			assert.Error(t, err)
+		})
+	}
+
+	assert.Panics(t, func() { MutateShare(sparse[1], shares.RegionSequenceLen) })
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	"encoding/binary"
+	"fmt"
 	"math/rand"
 )
 
@@ -31,18 +32,61 @@ func zeroPadIfNecessary(share []byte, width int) (padded []byte, bytesOfPadding
 	}
 
 	missingBytes := width - oldLen
-	padByte := []byte{0}
-	padding := bytes.Repeat(padByte, missingBytes)
-	share = append(share, padding...)
+	share = append(share, make([]byte, missingBytes)...)
 	return share, missingBytes
 }
 
+// Unpad returns the first sequenceLen bytes of data. It is the inverse of zero
+// padding: an error wrapping ErrNonZeroPadding that includes the offset of the
+// first non-zero byte in data is returned if any byte after sequenceLen is not
+// zero. An error is also returned if data is shorter than sequenceLen.
+func Unpad(data []byte, sequenceLen uint64) ([]byte, error) {
+	if sequenceLen > uint64(len(data)) {
+		return nil, fmt.Errorf("sequence length %d exceeds data length %d", sequenceLen, len(data))
+	}
+	if !IsAllZero(data[sequenceLen:]) {
+		for i := int(sequenceLen); i < len(data); i++ {
+			if data[i] != 0 {
+				return nil, fmt.Errorf("%w at offset %d", ErrNonZeroPadding, i)
+			}
+		}
+	}
+	return data[:sequenceLen], nil
+}
+
+// IsAllZero returns true if every byte in b is zero. It compares eight bytes at
+// a time which is considerably faster than a byte by byte loop on a share of
+// padding.
+func IsAllZero(b []byte) bool {
+	var acc uint64
+	for len(b) >= 32 {
+		acc |= binary.LittleEndian.Uint64(b) |
+			binary.LittleEndian.Uint64(b[8:]) |
+			binary.LittleEndian.Uint64(b[16:]) |
+			binary.LittleEndian.Uint64(b[24:])
+		if acc != 0 {
+			return false
+		}
+		b = b[32:]
+	}
+	for len(b) >= 8 {
+		acc |= binary.LittleEndian.Uint64(b)
+		b = b[8:]
+	}
+	for _, v := range b {
+		acc |= uint64(v)
+	}
+	return acc == 0
+}
+
 // ParseDelimiter attempts to parse a varint length delimiter from the input
 // provided. It returns the input without the len delimiter bytes, the length
 // parsed from the varint optionally an error. Unit length delimiters are used
 // in compact shares where units (i.e. a transaction) are prefixed with a length
 // delimiter that is encoded as a varint. Input should not contain the namespace
-// ID or info byte of a share.
+// ID or info byte of a share. A delimiter that is cut off by the end of input
+// is read as if it were zero padded. ErrDelimiterOverflow is returned if the
+// delimiter doesn't fit in a uint64.
 func ParseDelimiter(input []byte) (inputWithoutLenDelimiter []byte, unitLen uint64, err error) {
 	if len(input) == 0 {
 		return input, 0, nil
@@ -59,7 +103,7 @@ func ParseDelimiter(input []byte) (inputWithoutLenDelimiter []byte, unitLen uint
 	r := bytes.NewBuffer(delimiter)
 	dataLen, err := binary.ReadUvarint(r)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, fmt.Errorf("%w: %v", ErrDelimiterOverflow, err)
 	}
 
 	// calculate the number of bytes used by the delimiter
@@ -1,6 +1,7 @@
 package shares
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -51,3 +52,85 @@ func TestParseDelimiter(t *testing.T) {
 		assert.Equal(t, tx, res)
 	}
 }
+
+func TestParseDelimiterOverflow(t *testing.T) {
+	// a delimiter that doesn't terminate within binary.MaxVarintLen64 bytes
+	_, _, err := ParseDelimiter(bytes.Repeat([]byte{0xFF}, 20))
+	assert.ErrorIs(t, err, ErrDelimiterOverflow)
+
+	// a 10 byte delimiter that encodes more than 64 bits
+	_, _, err = ParseDelimiter(append(bytes.Repeat([]byte{0xFF}, 9), 0x02))
+	assert.ErrorIs(t, err, ErrDelimiterOverflow)
+}
+
+func TestIsAllZero(t *testing.T) {
+	assert.True(t, IsAllZero(nil))
+	for size := 1; size <= 70; size++ {
+		b := make([]byte, size)
+		assert.True(t, IsAllZero(b), size)
+		for i := range b {
+			b[i] = 1
+			assert.False(t, IsAllZero(b), "size %d index %d", size, i)
+			b[i] = 0
+		}
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	type testCase struct {
+		name        string
+		data        []byte
+		sequenceLen uint64
+		want        []byte
+		wantErr     string
+	}
+	testCases := []testCase{
+		{"no padding", []byte{1, 2, 3}, 3, []byte{1, 2, 3}, ""},
+		{"zero padding", []byte{1, 2, 3, 0, 0}, 3, []byte{1, 2, 3}, ""},
+		{"trailing zero data", []byte{1, 0, 0, 0}, 2, []byte{1, 0}, ""},
+		{"empty", []byte{0, 0}, 0, []byte{}, ""},
+		{"non-zero padding", []byte{1, 2, 3, 0, 0, 7, 0}, 3, nil, "non-zero byte in padding at offset 5"},
+		{"sequence length exceeds data", []byte{1, 2}, 3, nil, "sequence length 3 exceeds data length 2"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Unpad(tc.data, tc.sequenceLen)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := Unpad([]byte{1, 1}, 1)
+	assert.ErrorIs(t, err, ErrNonZeroPadding)
+}
+
+func isAllZeroNaive(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkIsAllZero(b *testing.B) {
+	padding := make([]byte, ShareSize)
+	b.Run("words", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !IsAllZero(padding) {
+				b.Fatal("expected padding to be all zero")
+			}
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !isAllZeroNaive(padding) {
+				b.Fatal("expected padding to be all zero")
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package square
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/blob"
+)
+
+// BatchResult is one of the squares constructed by ConstructBatch.
+type BatchResult struct {
+	Square Square
+	// Txs are the transactions included in the square in the order
+	// Deconstruct returns them: the normal transactions followed by the blob
+	// transactions.
+	Txs [][]byte
+	// Indexes are the indexes in the txs passed to ConstructBatch of Txs.
+	Indexes []int
+}
+
+// ConstructBatch is like Build but, instead of dropping the transactions that
+// don't fit in the square, spills them into the next square, up to maxSquares
+// squares. Every square is built from the transactions that didn't fit in the
+// previous squares, in the order of txs, so a square only holds transactions
+// that come after those of the previous square if they didn't fit there. A
+// blob transaction and its blobs always end up in the same square.
+//
+// The transactions that don't fit in maxSquares squares are returned
+// unmodified in the order of txs. It returns a *TxError wrapping ErrTxTooLarge
+// for a transaction that doesn't fit in an empty square, which would never be
+// included, and wrapping ErrMalformedBlobTx for an invalid blob transaction,
+// or ErrCommitmentMismatch for a blob transaction whose commitments don't
+// match if WithCommitmentVerification is passed. OnDropped has no effect.
+func ConstructBatch(txs [][]byte, maxSquareSize, subtreeRootThreshold, maxSquares int, opts ...BuildOption) ([]BatchResult, [][]byte, error) {
+	if maxSquares <= 0 {
+		return nil, nil, fmt.Errorf("max squares %d must be strictly positive", maxSquares)
+	}
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	builder.commitments = newBuildConfig(opts).commitments
+	blobTxs := make([]*blob.BlobTx, len(txs))
+	pending := make([]int, len(txs))
+	for i, tx := range txs {
+		if blobTx, isBlobTx := blob.UnmarshalBlobTx(tx); isBlobTx {
+			blobTxs[i] = blobTx
+		}
+		pending[i] = i
+	}
+	// whether a transaction that didn't fit was checked to fit in an empty
+	// square, so that it is only checked once
+	checked := make([]bool, len(txs))
+
+	var results []BatchResult
+	for len(pending) > 0 && len(results) < maxSquares {
+		builder.reset()
+		var normalIdxs, blobIdxs []int
+		// the transactions that don't fit are moved to the front of pending
+		spilled := pending[:0]
+		for _, idx := range pending {
+			if blobTxs[idx] != nil {
+				if builder.AppendBlobTx(blobTxs[idx]) {
+					blobIdxs = append(blobIdxs, idx)
+					continue
+				}
+				if !checked[idx] {
+					if err := builder.blobTxError(blobTxs[idx]); !errors.Is(err, ErrSquareSizeExceeded) {
+						return nil, nil, &TxError{TxIndex: idx, Err: err}
+					}
+				}
+			} else {
+				if builder.AppendTx(txs[idx]) {
+					normalIdxs = append(normalIdxs, idx)
+					continue
+				}
+				if !checked[idx] {
+					if err := builder.txError(txs[idx]); !errors.Is(err, ErrSquareSizeExceeded) {
+						return nil, nil, &TxError{TxIndex: idx, Err: err}
+					}
+				}
+			}
+			checked[idx] = true
+			spilled = append(spilled, idx)
+		}
+
+		square, err := builder.Export()
+		if err != nil {
+			return nil, nil, err
+		}
+		result := BatchResult{Square: square, Indexes: append(normalIdxs, blobIdxs...)}
+		result.Txs = make([][]byte, len(result.Indexes))
+		for i, idx := range result.Indexes {
+			result.Txs[i] = txs[idx]
+		}
+		results = append(results, result)
+		pending = spilled
+	}
+
+	remaining := make([][]byte, len(pending))
+	for i, idx := range pending {
+		remaining[i] = txs[idx]
+	}
+	return results, remaining, nil
+}
@@ -0,0 +1,117 @@
+package square_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fullSquareBlobTx returns a blob tx whose PFB and blob take exactly all
+// shares of a square of squareSize.
+func fullSquareBlobTx(t *testing.T, rng *rand.Rand, squareSize int) []byte {
+	blobShares := squareSize*squareSize - 1
+	size := shares.FirstSparseShareContentSize + (blobShares-1)*shares.ContinuationSparseShareContentSize
+	ns := namespace.RandomBlobNamespaces(rng, 1)[0]
+	tx, err := blob.MarshalBlobTx(testutil.MockPFB(rng, []uint32{uint32(size)}), blob.New(ns, make([]byte, size), 0))
+	require.NoError(t, err)
+	return tx
+}
+
+func TestConstructBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	t.Run("exactly fills two squares", func(t *testing.T) {
+		txs := [][]byte{fullSquareBlobTx(t, rng, 8), fullSquareBlobTx(t, rng, 8)}
+		results, remaining, err := square.ConstructBatch(txs, 8, defaultSubtreeRootThreshold, 3)
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+		require.Len(t, results, 2)
+		for i, result := range results {
+			assert.Equal(t, 8, result.Square.Size())
+			assert.Zero(t, countTailPadding(result.Square))
+			assert.Equal(t, []int{i}, result.Indexes)
+			assert.Equal(t, [][]byte{txs[i]}, result.Txs)
+		}
+	})
+
+	t.Run("oversized blob tx", func(t *testing.T) {
+		txs := [][]byte{testutil.RandomNormalTx(rng, 100), fullSquareBlobTx(t, rng, 16)}
+		results, remaining, err := square.ConstructBatch(txs, 8, defaultSubtreeRootThreshold, 3)
+		assert.ErrorIs(t, err, square.ErrTxTooLarge)
+		var txErr *square.TxError
+		require.ErrorAs(t, err, &txErr)
+		assert.Equal(t, 1, txErr.TxIndex)
+		assert.Nil(t, results)
+		assert.Nil(t, remaining)
+	})
+
+	t.Run("stops at max squares", func(t *testing.T) {
+		normalTx := testutil.RandomNormalTx(rng, 100)
+		txs := [][]byte{normalTx, fullSquareBlobTx(t, rng, 8), fullSquareBlobTx(t, rng, 8), fullSquareBlobTx(t, rng, 8)}
+		results, remaining, err := square.ConstructBatch(txs, 8, defaultSubtreeRootThreshold, 2)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		// the normal tx takes a share so every blob tx spills from the first
+		// square
+		assert.Equal(t, []int{0}, results[0].Indexes)
+		assert.Equal(t, []int{1}, results[1].Indexes)
+		assert.Equal(t, txs[2:], remaining)
+	})
+
+	t.Run("invalid max squares", func(t *testing.T) {
+		_, _, err := square.ConstructBatch(nil, 8, defaultSubtreeRootThreshold, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestConstructBatchRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		txs := randomTxSet(rng, 16)
+		maxSquares := 1 + rng.Intn(4)
+		results, remaining, err := square.ConstructBatch(txs, 16, defaultSubtreeRootThreshold, maxSquares)
+		if err != nil {
+			// some generated blob txs don't fit in any square
+			require.ErrorIs(t, err, square.ErrTxTooLarge)
+			continue
+		}
+		require.LessOrEqual(t, len(results), maxSquares)
+
+		var included []int
+		for _, result := range results {
+			require.Len(t, result.Txs, len(result.Indexes))
+			for j, idx := range result.Indexes {
+				assert.Equal(t, txs[idx], result.Txs[j])
+			}
+			// every square is the square of its transactions
+			want, err := square.Construct(result.Txs, 16, defaultSubtreeRootThreshold)
+			require.NoError(t, err)
+			assert.True(t, want.Equals(result.Square))
+			included = append(included, result.Indexes...)
+		}
+		if len(remaining) > 0 {
+			assert.Len(t, results, maxSquares)
+		}
+
+		// every tx is either included exactly once or remains in order
+		var notIncluded [][]byte
+		for idx, tx := range txs {
+			if !slices.Contains(included, idx) {
+				notIncluded = append(notIncluded, tx)
+			}
+		}
+		assert.Len(t, included, len(txs)-len(notIncluded))
+		assert.Equal(t, len(notIncluded), len(remaining))
+		for j := range remaining {
+			assert.Equal(t, notIncluded[j], remaining[j])
+		}
+	}
+}
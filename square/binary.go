@@ -0,0 +1,96 @@
+package square
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/shares"
+)
+
+const (
+	// binaryFormatVersion is the version of the binary encoding of a square
+	// written by MarshalBinary.
+	binaryFormatVersion = 1
+	// binaryHeaderSize is the size of the header of the binary encoding of a
+	// square: the format version followed by the big endian uint32 square
+	// size.
+	binaryHeaderSize = 1 + 4
+	// maxSupportedSquareSize is the largest square size that can be encoded
+	// or imported. Share indexes are uint32 so a square has at most 2^32
+	// shares.
+	maxSupportedSquareSize = 1 << 16
+)
+
+var (
+	// ErrUnsupportedBinaryVersion is returned when the binary encoding of a
+	// square has an unknown format version.
+	ErrUnsupportedBinaryVersion = errors.New("unsupported square binary format version")
+	// ErrInvalidSquareSize is returned when a square size isn't a power of
+	// two within bounds or doesn't match the number of shares.
+	ErrInvalidSquareSize = errors.New("invalid square size")
+	// ErrTruncatedBinary is returned when the binary encoding of a square is
+	// shorter than its header or than the shares its header describes.
+	ErrTruncatedBinary = errors.New("square binary is truncated")
+	// ErrTrailingBinary is returned when the binary encoding of a square is
+	// followed by more bytes than the shares its header describes.
+	ErrTrailingBinary = errors.New("square binary has trailing bytes")
+)
+
+// MarshalBinary encodes the square as a header holding the format version and
+// the square size followed by the raw shares in row-major order. It returns an
+// error wrapping ErrInvalidSquareSize if the number of shares isn't the
+// square of a power of two. It implements encoding.BinaryMarshaler.
+func (s Square) MarshalBinary() ([]byte, error) {
+	if err := validateShareCount(len(s)); err != nil {
+		return nil, err
+	}
+	squareSize := s.Size()
+	b := make([]byte, binaryHeaderSize+len(s)*shares.ShareSize)
+	b[0] = binaryFormatVersion
+	binary.BigEndian.PutUint32(b[1:binaryHeaderSize], uint32(squareSize))
+	if _, err := shares.ToContiguousBytes(s, b[binaryHeaderSize:]); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// UnmarshalSquareBinary decodes a square encoded by MarshalBinary. The shares
+// of the returned square are a copy of b. It returns an error wrapping
+// ErrUnsupportedBinaryVersion, ErrInvalidSquareSize, ErrTruncatedBinary or
+// ErrTrailingBinary if b isn't a well formed encoding.
+func UnmarshalSquareBinary(b []byte) (Square, error) {
+	if len(b) < binaryHeaderSize {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the %d byte header", ErrTruncatedBinary, len(b), binaryHeaderSize)
+	}
+	if b[0] != binaryFormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBinaryVersion, b[0])
+	}
+	squareSize := binary.BigEndian.Uint32(b[1:binaryHeaderSize])
+	if squareSize < shares.MinSquareSize || squareSize > maxSupportedSquareSize || !shares.IsPowerOfTwo(squareSize) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSquareSize, squareSize)
+	}
+
+	// the bounds on the square size keep the expected length well within
+	// uint64
+	wantLen := uint64(squareSize) * uint64(squareSize) * shares.ShareSize
+	gotLen := uint64(len(b) - binaryHeaderSize)
+	switch {
+	case gotLen < wantLen:
+		return nil, fmt.Errorf("%w: square size %d needs %d bytes of shares, got %d", ErrTruncatedBinary, squareSize, wantLen, gotLen)
+	case gotLen > wantLen:
+		return nil, fmt.Errorf("%w: %d bytes after the shares of a square of size %d", ErrTrailingBinary, gotLen-wantLen, squareSize)
+	}
+	return shares.FromContiguousBytes(b[binaryHeaderSize:])
+}
+
+// validateShareCount returns an error wrapping ErrInvalidSquareSize if count
+// shares don't form a square whose size is a power of two of at most
+// maxSupportedSquareSize.
+func validateShareCount(count int) error {
+	squareSize := Size(count)
+	if squareSize*squareSize != count || !shares.IsPowerOfTwo(squareSize) || squareSize > maxSupportedSquareSize {
+		return fmt.Errorf("%w: %d shares don't form a square", ErrInvalidSquareSize, count)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package square_test
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquareBinaryRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	squares := []square.Square{square.EmptySquare()}
+	for i := 0; i < 10; i++ {
+		squares = append(squares, testutil.RandomSquare(rng, rng.Float64(), 64, defaultSubtreeRootThreshold).Square)
+	}
+
+	for _, dataSquare := range squares {
+		encoded, err := dataSquare.MarshalBinary()
+		require.NoError(t, err)
+		require.Len(t, encoded, 5+len(dataSquare)*shares.ShareSize)
+
+		decoded, err := square.UnmarshalSquareBinary(encoded)
+		require.NoError(t, err)
+		require.Equal(t, dataSquare, decoded)
+	}
+}
+
+func TestSquareBinaryErrors(t *testing.T) {
+	dataSquare, _, err := square.Build(generateMixedTxs(10, 5, 2, 1000), defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	encoded, err := dataSquare.MarshalBinary()
+	require.NoError(t, err)
+	withSize := func(size uint32) []byte {
+		b := append([]byte(nil), encoded...)
+		binary.BigEndian.PutUint32(b[1:5], size)
+		return b
+	}
+
+	testCases := []struct {
+		name    string
+		input   []byte
+		wantErr error
+	}{
+		{"empty", nil, square.ErrTruncatedBinary},
+		{"truncated header", encoded[:3], square.ErrTruncatedBinary},
+		{"header only", encoded[:5], square.ErrTruncatedBinary},
+		{"truncated share", encoded[:len(encoded)-1], square.ErrTruncatedBinary},
+		{"trailing bytes", append(append([]byte(nil), encoded...), 0), square.ErrTrailingBinary},
+		{"unknown version", append([]byte{2}, encoded[1:]...), square.ErrUnsupportedBinaryVersion},
+		{"zero square size", withSize(0), square.ErrInvalidSquareSize},
+		{"square size not a power of two", withSize(uint32(dataSquare.Size()) + 1), square.ErrInvalidSquareSize},
+		{"square size too large", withSize(1 << 17), square.ErrInvalidSquareSize},
+		{"smaller square size", withSize(uint32(dataSquare.Size()) / 2), square.ErrTrailingBinary},
+		{"larger square size", withSize(uint32(dataSquare.Size()) * 2), square.ErrTruncatedBinary},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decoded, err := square.UnmarshalSquareBinary(tc.input)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Nil(t, decoded)
+		})
+	}
+
+	t.Run("marshal shares that don't form a square", func(t *testing.T) {
+		_, err := square.Square(shares.TailPaddingShares(3)).MarshalBinary()
+		assert.ErrorIs(t, err, square.ErrInvalidSquareSize)
+		_, err = square.Square(nil).MarshalBinary()
+		assert.ErrorIs(t, err, square.ErrInvalidSquareSize)
+	})
+}
+
+func BenchmarkSquareBinary(b *testing.B) {
+	dataSquare, _, err := square.Build(generateMixedTxs(0, 100, 1, 150_000), defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(b, err)
+
+	b.Run("MarshalBinary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			encoded, err := dataSquare.MarshalBinary()
+			require.NoError(b, err)
+			_, err = square.UnmarshalSquareBinary(encoded)
+			require.NoError(b, err)
+		}
+	})
+	b.Run("ToBytes", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := shares.FromBytes(shares.ToBytes(dataSquare))
+			require.NoError(b, err)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package square_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compactTxOfShares returns the size of a normal tx that, together with its
+// length delimiter, exactly fills n compact shares.
+func compactTxOfShares(t *testing.T, n int) int {
+	for size := (n - 1) * shares.ContinuationCompactShareContentSize; ; size++ {
+		counter := shares.NewCompactShareCounter()
+		counter.Add(size)
+		if counter.Size() > n {
+			t.Fatalf("no tx exactly fills %d compact shares", n)
+		}
+		if counter.Size() == n && counter.Remainder() == 0 {
+			return size
+		}
+	}
+}
+
+// TestCompactSectionBoundaries constructs squares whose compact section ends
+// at every share index in a range, both exactly at the end of a share and one
+// byte into it, so that it ends on row ends and subtree width boundaries, and
+// checks that the layout, the share ranges and Deconstruct agree.
+func TestCompactSectionBoundaries(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	newBlobTx := func(blobShares ...int) []byte {
+		namespaces := namespace.RandomBlobNamespaces(rng, len(blobShares))
+		blobs := make([]*blob.Blob, len(blobShares))
+		sizes := make([]uint32, len(blobShares))
+		for i, n := range blobShares {
+			size := shares.FirstSparseShareContentSize + (n-1)*shares.ContinuationSparseShareContentSize
+			blobs[i] = blob.New(namespaces[i], make([]byte, size), shares.ShareVersionZero)
+			sizes[i] = uint32(size)
+		}
+		tx, err := blob.MarshalBlobTx(testutil.MockPFB(rng, sizes), blobs...)
+		require.NoError(t, err)
+		return tx
+	}
+
+	hit := map[string]bool{}
+	for _, threshold := range []int{1, 4, defaultSubtreeRootThreshold} {
+		for _, blobShares := range [][]int{{1}, {4}, {7, 2}, {16}} {
+			for txShares := 0; txShares <= 20; txShares++ {
+				for _, extra := range []int{0, 1} {
+					if txShares == 0 && extra == 1 {
+						continue
+					}
+					var txs [][]byte
+					if txShares > 0 {
+						// a normal tx that ends exactly at the end of a share or
+						// one byte into the next share
+						txs = append(txs, testutil.RandomNormalTx(rng, compactTxOfShares(t, txShares)+extra))
+					}
+					txs = append(txs, newBlobTx(blobShares...))
+					name := fmt.Sprintf("threshold %d blobs %v tx shares %d extra %d", threshold, blobShares, txShares, extra)
+					t.Run(name, func(t *testing.T) {
+						compactEnd := checkBoundaryLayout(t, txs, threshold)
+						hit["index 0"] = hit["index 0"] || len(txs) == 1
+						dataSquare, err := square.Construct(txs, defaultMaxSquareSize, threshold)
+						require.NoError(t, err)
+						size := dataSquare.Size()
+						hit["row end"] = hit["row end"] || compactEnd%size == 0 && compactEnd > 0
+					})
+				}
+			}
+		}
+	}
+	assert.True(t, hit["row end"], "no compact section ended at a row end")
+	assert.True(t, hit["index 0"], "no square without normal txs")
+}
+
+// checkBoundaryLayout constructs a square from txs and checks it against the
+// share range functions and Deconstruct. It returns the number of compact
+// shares.
+func checkBoundaryLayout(t *testing.T, txs [][]byte, threshold int) int {
+	dataSquare, err := square.Construct(txs, defaultMaxSquareSize, threshold)
+	require.NoError(t, err)
+	require.NoError(t, square.ValidateSquare(dataSquare, threshold))
+
+	got, err := square.Deconstruct(dataSquare, testutil.DecodeMockPFB)
+	require.NoError(t, err)
+	require.Equal(t, txs, got)
+
+	txRanges, blobRanges, err := square.ShareRanges(txs, defaultMaxSquareSize, threshold)
+	require.NoError(t, err)
+	compactEnd := 0
+	for i := range txs {
+		txRange, err := square.TxShareRange(txs, i, defaultMaxSquareSize, threshold)
+		require.NoError(t, err)
+		assert.Equal(t, txRange, txRanges[i])
+		compactEnd = max(compactEnd, txRange.End)
+	}
+	firstBlobStart := len(dataSquare)
+	for txIndex, ranges := range blobRanges {
+		for blobIndex, blobRange := range ranges {
+			r, err := square.BlobShareRange(txs, txIndex, blobIndex, defaultMaxSquareSize, threshold)
+			require.NoError(t, err)
+			assert.Equal(t, blobRange, r)
+			require.GreaterOrEqual(t, r.Start, compactEnd)
+			require.LessOrEqual(t, r.End, len(dataSquare))
+			first := dataSquare[r.Start]
+			isStart, err := first.IsSequenceStart()
+			require.NoError(t, err)
+			assert.True(t, isStart, "blob %d of tx %d starts at share %d which doesn't start a sequence", blobIndex, txIndex, r.Start)
+			ns, err := first.Namespace()
+			require.NoError(t, err)
+			assert.False(t, ns.IsReserved())
+			firstBlobStart = min(firstBlobStart, r.Start)
+		}
+	}
+	// the shares between the compact section and the first blob are reserved
+	// padding
+	for i := compactEnd; i < firstBlobStart; i++ {
+		assert.True(t, dataSquare[i].RawNamespace().IsPrimaryReservedPadding(), "share %d before the first blob at %d isn't reserved padding", i, firstBlobStart)
+	}
+	return compactEnd
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"runtime"
+	"slices"
 	"sort"
+	"sync"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/inclusion"
@@ -30,14 +34,45 @@ type Builder struct {
 
 	done                 bool
 	subtreeRootThreshold int
+	// fixedSquareSize, if non zero, is the size of the square Export
+	// constructs regardless of how many shares are used.
+	fixedSquareSize int
+
+	// txAppends and pfbAppends record how every appended tx and blob tx
+	// changed the builder so that they can be reverted.
+	txAppends  []appendRecord
+	pfbAppends []appendRecord
+
+	// exportWorkers is the number of blobs Export splits into shares
+	// concurrently, see SetExportWorkers.
+	exportWorkers int
+
+	// txWriter and pfbWriter are reset and reused by every Export.
+	txWriter  *shares.CompactShareSplitter
+	pfbWriter *shares.CompactShareSplitter
+	// reuseSquare makes Export write the square into squareBuf instead of a
+	// new slice, see ConstructSession.
+	reuseSquare bool
+	squareBuf   []shares.Share
+
+	// commitments, if not nil, verifies the share commitments of every
+	// appended blob transaction, see SetCommitmentVerification.
+	commitments *commitmentVerifier
+}
+
+// appendRecord holds the state of a compact share counter before a tx was
+// appended and the number of shares the tx added to the current size.
+type appendRecord struct {
+	counter   shares.CompactShareCounter
+	sizeDelta int
 }
 
 func NewBuilder(maxSquareSize int, subtreeRootThreshold int, txs ...[]byte) (*Builder, error) {
 	if maxSquareSize <= 0 {
-		return nil, errors.New("max square size must be strictly positive")
+		return nil, fmt.Errorf("%w: max square size %d must be strictly positive", ErrInvalidSquareSize, maxSquareSize)
 	}
 	if !shares.IsPowerOfTwo(maxSquareSize) {
-		return nil, errors.New("max square size must be a power of two")
+		return nil, fmt.Errorf("%w: max square size %d must be a power of two", ErrInvalidSquareSize, maxSquareSize)
 	}
 	builder := &Builder{
 		maxSquareSize:        maxSquareSize,
@@ -48,34 +83,65 @@ func NewBuilder(maxSquareSize int, subtreeRootThreshold int, txs ...[]byte) (*Bu
 		TxCounter:            shares.NewCompactShareCounter(),
 		PfbCounter:           shares.NewCompactShareCounter(),
 	}
+	if err := builder.appendOrdered(txs); err != nil {
+		return nil, err
+	}
+	return builder, nil
+}
+
+// appendOrdered appends txs, which must have all blob transactions trailing
+// the normal transactions, and returns a *TxError for the first tx that
+// doesn't fit or is out of order.
+func (b *Builder) appendOrdered(txs [][]byte) error {
 	seenFirstBlobTx := false
 	for idx, tx := range txs {
 		blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
 		if isBlobTx {
 			seenFirstBlobTx = true
-			if !builder.AppendBlobTx(blobTx) {
-				return nil, fmt.Errorf("not enough space to append blob tx at index %d", idx)
+			if !b.AppendBlobTx(blobTx) {
+				return &TxError{TxIndex: idx, Err: b.blobTxError(blobTx)}
 			}
 		} else {
 			if seenFirstBlobTx {
-				return nil, fmt.Errorf("normal tx at index %d can not be appended after blob tx", idx)
+				return &TxError{TxIndex: idx, Err: ErrTxOrder}
 			}
-			if !builder.AppendTx(tx) {
-				return nil, fmt.Errorf("not enough space to append tx at index %d", idx)
+			if !b.AppendTx(tx) {
+				return &TxError{TxIndex: idx, Err: b.txError(tx)}
 			}
 		}
 	}
-	return builder, nil
+	return nil
+}
+
+// reset removes every transaction from the builder, leaving it as returned by
+// NewBuilder but keeping the memory it allocated.
+func (b *Builder) reset() {
+	b.currentSize = 0
+	// drop the references to the transactions of the caller
+	clear(b.Txs)
+	clear(b.Pfbs)
+	clear(b.Blobs)
+	b.Txs = b.Txs[:0]
+	b.Pfbs = b.Pfbs[:0]
+	b.Blobs = b.Blobs[:0]
+	*b.TxCounter = shares.CompactShareCounter{}
+	*b.PfbCounter = shares.CompactShareCounter{}
+	b.txAppends = b.txAppends[:0]
+	b.pfbAppends = b.pfbAppends[:0]
+	b.done = false
 }
 
 // AppendTx attempts to allocate the transaction to the square. It returns false if there is not
 // enough space in the square to fit the transaction.
 func (b *Builder) AppendTx(tx []byte) bool {
+	record := appendRecord{counter: *b.TxCounter}
 	lenChange := b.TxCounter.Add(len(tx))
 	if b.canFit(lenChange) {
 		b.Txs = append(b.Txs, tx)
 		b.currentSize += lenChange
 		b.done = false
+		record.sizeDelta = lenChange
+		b.txAppends = append(b.txAppends, record)
 		return true
 	}
 	b.TxCounter.Revert()
@@ -83,15 +149,14 @@ func (b *Builder) AppendTx(tx []byte) bool {
 }
 
 // AppendBlobTx attempts to allocate the blob transaction to the square. It returns false if there is not
-// enough space in the square to fit the transaction.
+// enough space in the square to fit the transaction or if it fails blob.BlobTx.Validate, e.g. because
+// one of its blobs uses a reserved namespace. Appending is all or nothing: if false is returned the
+// builder is left exactly as it was.
 func (b *Builder) AppendBlobTx(blobTx *blob.BlobTx) bool {
-	iw := &blob.IndexWrapper{
-		Tx:           blobTx.Tx,
-		TypeId:       blob.ProtoIndexWrapperTypeID,
-		ShareIndexes: worstCaseShareIndexes(len(blobTx.Blobs), b.maxSquareSize),
+	if validateBlobTx(blobTx) != nil {
+		return false
 	}
-	size := proto.Size(iw)
-	pfbShareDiff := b.PfbCounter.Add(size)
+	iw := b.newIndexWrapper(blobTx.Tx, len(blobTx.Blobs))
 
 	// create a new blob element for each blob and track the worst-case share count
 	blobElements := make([]*Element, len(blobTx.Blobs))
@@ -100,29 +165,160 @@ func (b *Builder) AppendBlobTx(blobTx *blob.BlobTx) bool {
 		blobElements[idx] = newElement(blob, len(b.Pfbs), idx, b.subtreeRootThreshold)
 		maxBlobShareCount += blobElements[idx].maxShareOffset()
 	}
+	if b.commitments != nil {
+		// only hash the blobs of a transaction that fits
+		if !b.canFit(maxBlobShareCount) {
+			return false
+		}
+		blobShares, err := b.commitments.verify(blobTx, b.subtreeRootThreshold)
+		if err != nil {
+			return false
+		}
+		for idx, element := range blobElements {
+			element.shares = blobShares[idx]
+		}
+	}
 
-	if b.canFit(pfbShareDiff + maxBlobShareCount) {
-		b.Blobs = append(b.Blobs, blobElements...)
-		b.Pfbs = append(b.Pfbs, iw)
-		b.currentSize += (pfbShareDiff + maxBlobShareCount)
-		b.done = false
-		return true
+	record := appendRecord{counter: *b.PfbCounter, sizeDelta: b.currentSize}
+	if !b.reserveBlobTx(iw, maxBlobShareCount) {
+		return false
 	}
-	b.PfbCounter.Revert()
-	return false
+	record.sizeDelta = b.currentSize - record.sizeDelta
+	b.pfbAppends = append(b.pfbAppends, record)
+	b.Blobs = append(b.Blobs, blobElements...)
+	b.Pfbs = append(b.Pfbs, iw)
+	return true
+}
+
+// validateBlobTx is like blob.BlobTx.Validate but wraps the error in
+// ErrMalformedBlobTx and returns a *ReservedNamespaceError if the first
+// invalid blob uses a reserved namespace.
+func validateBlobTx(blobTx *blob.BlobTx) error {
+	err := blobTx.Validate()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, blob.ErrReservedNamespace) {
+		for i, b := range blobTx.Blobs {
+			if blobErr := b.Validate(); errors.Is(blobErr, blob.ErrReservedNamespace) {
+				err = &ReservedNamespaceError{BlobIndex: i, Namespace: b.Namespace(), Err: blobErr}
+				break
+			}
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrMalformedBlobTx, err)
+}
+
+// txError returns why the normal transaction tx can't be appended to the
+// builder: ErrTxTooLarge if it doesn't fit in an empty square and
+// ErrSquareSizeExceeded otherwise.
+func (b *Builder) txError(tx []byte) error {
+	empty := b.emptyCopy()
+	if !empty.AppendTx(tx) {
+		return fmt.Errorf("%w: tx of %d bytes", ErrTxTooLarge, len(tx))
+	}
+	return ErrSquareSizeExceeded
+}
+
+// blobTxError is like txError for a blob transaction, which can also fail
+// validateBlobTx or, if commitments are verified, return a *CommitmentError.
+func (b *Builder) blobTxError(blobTx *blob.BlobTx) error {
+	if err := validateBlobTx(blobTx); err != nil {
+		return err
+	}
+	if b.commitments != nil {
+		if _, err := b.commitments.verify(blobTx, b.subtreeRootThreshold); err != nil {
+			return err
+		}
+	}
+	empty := b.emptyCopy()
+	if !empty.AppendBlobTx(blobTx) {
+		return fmt.Errorf("%w: blob tx with %d blobs", ErrTxTooLarge, len(blobTx.Blobs))
+	}
+	return ErrSquareSizeExceeded
+}
+
+// emptyCopy returns a builder without transactions with the same square size
+// and subtree root threshold as b.
+func (b *Builder) emptyCopy() *Builder {
+	empty, _ := NewBuilder(b.maxSquareSize, b.subtreeRootThreshold)
+	empty.fixedSquareSize = b.fixedSquareSize
+	return empty
+}
+
+// RevertLastTx removes the most recently appended normal transaction, leaving
+// the builder exactly as it was before that transaction was appended. It
+// returns false if there is no normal transaction to remove.
+func (b *Builder) RevertLastTx() bool {
+	if len(b.txAppends) == 0 {
+		return false
+	}
+	record := b.txAppends[len(b.txAppends)-1]
+	b.txAppends = b.txAppends[:len(b.txAppends)-1]
+	*b.TxCounter = record.counter
+	b.currentSize -= record.sizeDelta
+	b.Txs = b.Txs[:len(b.Txs)-1]
+	b.done = false
+	return true
+}
+
+// RevertLastBlobTx removes the most recently appended blob transaction and its
+// blobs, leaving the builder exactly as it was before that transaction was
+// appended. It returns false if there is no blob transaction to remove.
+func (b *Builder) RevertLastBlobTx() bool {
+	if len(b.pfbAppends) == 0 {
+		return false
+	}
+	record := b.pfbAppends[len(b.pfbAppends)-1]
+	b.pfbAppends = b.pfbAppends[:len(b.pfbAppends)-1]
+	*b.PfbCounter = record.counter
+	b.currentSize -= record.sizeDelta
+	pfbIndex := len(b.Pfbs) - 1
+	b.Pfbs = b.Pfbs[:pfbIndex]
+	// Export sorts the blobs by namespace so the blobs of the PFB aren't
+	// necessarily the last ones
+	b.Blobs = slices.DeleteFunc(b.Blobs, func(element *Element) bool {
+		return element.PfbIndex == pfbIndex
+	})
+	b.done = false
+	return true
+}
+
+// newIndexWrapper returns the index wrapper of a PFB with the worst case share
+// indexes for blobs blobs.
+func (b *Builder) newIndexWrapper(tx []byte, blobs int) *blob.IndexWrapper {
+	return &blob.IndexWrapper{
+		Tx:           tx,
+		TypeId:       blob.ProtoIndexWrapperTypeID,
+		ShareIndexes: worstCaseShareIndexes(blobs, b.maxSquareSize),
+	}
+}
+
+// reserveBlobTx accounts for the shares of the index wrapper iw and of blobs
+// that occupy at most maxBlobShareCount shares including their padding. It
+// returns false, without modifying the builder, if they don't fit.
+func (b *Builder) reserveBlobTx(iw *blob.IndexWrapper, maxBlobShareCount int) bool {
+	// the footprint of the PFB is computed on a copy of the counter so that
+	// nothing is modified until the whole transaction is known to fit
+	pfbCounter := *b.PfbCounter
+	pfbShareDiff := pfbCounter.Add(proto.Size(iw))
+	if !b.canFit(pfbShareDiff + maxBlobShareCount) {
+		return false
+	}
+	*b.PfbCounter = pfbCounter
+	b.currentSize += (pfbShareDiff + maxBlobShareCount)
+	b.done = false
+	return true
 }
 
 // Export constructs the square.
 func (b *Builder) Export() (Square, error) {
 	// if there are no transactions, return an empty square
-	if b.IsEmpty() {
+	if b.IsEmpty() && b.fixedSquareSize == 0 {
 		return EmptySquare(), nil
 	}
 
-	// calculate the square size.
-	// NOTE: A future optimization could be to recalculate the currentSize based on the actual
-	// interblob padding used when the blobs are correctly ordered instead of using worst case padding.
-	ss := inclusion.BlobMinSquareSize(b.currentSize)
+	ss := b.squareSize()
 
 	// Sort the blobs by namespace. This uses SliceStable to preserve the order
 	// of blobs within a namespace because b.Blobs are already ordered by tx
@@ -132,18 +328,18 @@ func (b *Builder) Export() (Square, error) {
 	})
 
 	// write all the regular transactions into compact shares
-	txWriter := shares.NewCompactShareSplitter(namespace.TxNamespace, shares.ShareVersionZero)
+	txWriter := resetSplitter(&b.txWriter, namespace.TxNamespace)
 	for _, tx := range b.Txs {
 		if err := txWriter.WriteTx(tx); err != nil {
 			return nil, fmt.Errorf("writing tx into compact shares: %w", err)
 		}
 	}
 
-	// begin to iteratively add blobs to the sparse share splitter calculating the actual padding
+	// begin to iteratively lay out the blobs calculating the actual padding
 	nonReservedStart := b.TxCounter.Size() + b.PfbCounter.Size()
 	cursor := nonReservedStart
 	endOfLastBlob := nonReservedStart
-	blobWriter := shares.NewSparseShareSplitter()
+	blobStarts := make([]int, len(b.Blobs))
 	for i, element := range b.Blobs {
 		// NextShareIndex returned where the next blob should start so as to comply with the share commitment rules
 		// We fill out the remaining
@@ -160,25 +356,18 @@ func (b *Builder) Export() (Square, error) {
 
 		// record the starting share index of the blob in the PFB that paid for it
 		b.Pfbs[element.PfbIndex].ShareIndexes[element.BlobIndex] = uint32(cursor)
-		// If this is not the first blob, we add padding by writing padded shares to the previous blob
-		// (which could be of a different namespace)
-		if i > 0 {
-			if err := blobWriter.WriteNamespacePaddingShares(padding); err != nil {
-				return nil, fmt.Errorf("writing padding into sparse shares: %w", err)
-			}
-		}
-		// Finally write the blob itself
-		if err := blobWriter.Write(element.Blob); err != nil {
-			return nil, fmt.Errorf("writing blob into sparse shares: %w", err)
-		}
+		blobStarts[i] = cursor
 		// increment the cursor by the size of the blob
 		cursor += element.NumShares
 		endOfLastBlob = cursor
 	}
+	if endOfLastBlob > ss*ss {
+		return nil, fmt.Errorf("writing square: square size %d is too small to fit all blobs, which end at share %d", ss, endOfLastBlob)
+	}
 
 	// write all the pay for blob transactions into compact shares. We need to do this after allocating the blobs to their
 	// appropriate shares as the starting index of each blob needs to be included in the PFB transaction
-	pfbWriter := shares.NewCompactShareSplitter(namespace.PayForBlobNamespace, shares.ShareVersionZero)
+	pfbWriter := resetSplitter(&b.pfbWriter, namespace.PayForBlobNamespace)
 	for _, iw := range b.Pfbs {
 		iwBytes, err := proto.Marshal(iw)
 		if err != nil {
@@ -194,29 +383,161 @@ func (b *Builder) Export() (Square, error) {
 		return nil, fmt.Errorf("pfbCounter.Size() < pfbTxWriter.Count(): %d < %d", b.PfbCounter.Size(), pfbWriter.Count())
 	}
 
-	// Write out the square
-	square, err := WriteSquare(txWriter, pfbWriter, blobWriter, nonReservedStart, ss)
+	// Write out the square. The compact shares and the padding are written in
+	// order, the blobs are split into shares concurrently since the layout
+	// determines where each of them goes.
+	paddingStartIndex := txWriter.Count() + pfbWriter.Count()
+	if nonReservedStart < paddingStartIndex {
+		return nil, fmt.Errorf("writing square: nonReservedStart %d is too small to fit all PFBs and txs", nonReservedStart)
+	}
+	square := b.newSquare(ss * ss)
+	txShareCount, err := txWriter.ExportInto(square)
 	if err != nil {
-		return nil, fmt.Errorf("writing square: %w", err)
+		return nil, fmt.Errorf("writing square: failed to export tx shares: %w", err)
+	}
+	if _, err := pfbWriter.ExportInto(square[txShareCount:]); err != nil {
+		return nil, fmt.Errorf("writing square: failed to export pfb shares: %w", err)
+	}
+	if len(b.Blobs) > 0 {
+		fillShares(square[paddingStartIndex:nonReservedStart], shares.ReservedPaddingShare())
+	}
+	for i := 1; i < len(b.Blobs); i++ {
+		// the padding before a blob belongs to the namespace of the previous
+		// blob (which could be of a different namespace)
+		previous := b.Blobs[i-1]
+		endOfPrevious := blobStarts[i-1] + previous.NumShares
+		if endOfPrevious == blobStarts[i] {
+			continue
+		}
+		padding, err := shares.NamespacePaddingShare(previous.Blob.Namespace(), uint8(previous.Blob.ShareVersion))
+		if err != nil {
+			return nil, fmt.Errorf("writing padding into sparse shares: %w", err)
+		}
+		fillShares(square[endOfPrevious:blobStarts[i]], padding)
 	}
+	if err := b.writeBlobShares(square, blobStarts); err != nil {
+		return nil, err
+	}
+	fillShares(square[endOfLastBlob:], shares.TailPaddingShare())
 
 	b.done = true
 
 	return square, nil
 }
 
+// newSquare returns a square of n shares. Every share is overwritten by
+// Export.
+func (b *Builder) newSquare(n int) []shares.Share {
+	if !b.reuseSquare {
+		return make([]shares.Share, n)
+	}
+	if cap(b.squareBuf) < n {
+		b.squareBuf = make([]shares.Share, n)
+	}
+	return b.squareBuf[:n]
+}
+
+// resetSplitter resets the compact share splitter of ns in *css, creating it
+// if it doesn't exist yet.
+func resetSplitter(css **shares.CompactShareSplitter, ns namespace.Namespace) *shares.CompactShareSplitter {
+	if *css == nil {
+		*css = shares.NewCompactShareSplitter(ns, shares.ShareVersionZero)
+	} else {
+		(*css).Reset()
+	}
+	return *css
+}
+
+// fillShares sets every share of dst to share.
+func fillShares(dst []shares.Share, share shares.Share) {
+	for i := range dst {
+		dst[i] = share
+	}
+}
+
+// SetExportWorkers sets the number of blobs Export splits into shares
+// concurrently. A value of 0 or less, the default, uses runtime.GOMAXPROCS.
+func (b *Builder) SetExportWorkers(workers int) {
+	b.exportWorkers = workers
+}
+
+// writeBlobShares splits every blob into shares and writes them to square
+// starting at the share index in blobStarts. Blobs are split on a pool of up
+// to exportWorkers goroutines, each writing to its own part of the square.
+func (b *Builder) writeBlobShares(square []shares.Share, blobStarts []int) error {
+	errs := make([]error, len(b.Blobs))
+	split := func(i int) {
+		element := b.Blobs[i]
+		cursor, end := blobStarts[i], blobStarts[i]+element.NumShares
+		if element.shares != nil {
+			// the blob was already split to verify its commitment
+			if len(element.shares) != element.NumShares {
+				errs[i] = fmt.Errorf("blob has %d shares but %d were allocated", len(element.shares), element.NumShares)
+				return
+			}
+			copy(square[cursor:end], element.shares)
+			return
+		}
+		errs[i] = shares.SplitSparseFunc(element.Blob, func(share shares.Share) error {
+			// defensively check that the blob doesn't overwrite the padding
+			// or blob that follows it
+			if cursor == end {
+				return fmt.Errorf("blob has more than the %d shares it was allocated", element.NumShares)
+			}
+			square[cursor] = share
+			cursor++
+			return nil
+		})
+	}
+
+	workers := b.exportWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(b.Blobs))
+	if workers <= 1 {
+		for i := range b.Blobs {
+			split(i)
+		}
+	} else {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					split(i)
+				}
+			}()
+		}
+		for i := range b.Blobs {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("writing blob into sparse shares: %w", err)
+		}
+	}
+	return nil
+}
+
 // FindBlobStartingIndex returns the starting share index of the blob in the square. It takes
 // the index of the pfb in the tx set and the index of the blob within the PFB.
 func (b *Builder) FindBlobStartingIndex(pfbIndex, blobIndex int) (int, error) {
 	if pfbIndex < len(b.Txs) {
-		return 0, fmt.Errorf("pfbIndex %d does not match a pfb", pfbIndex)
+		return 0, fmt.Errorf("%w: pfbIndex %d does not match a pfb", ErrIndexOutOfRange, pfbIndex)
 	}
 	pfbIndex -= len(b.Txs)
 	if pfbIndex >= len(b.Pfbs) {
-		return 0, fmt.Errorf("pfbIndex %d out of range", pfbIndex)
+		return 0, fmt.Errorf("%w: pfbIndex %d", ErrIndexOutOfRange, pfbIndex)
 	}
 	if blobIndex < 0 {
-		return 0, fmt.Errorf("blobIndex %d must not be negative", blobIndex)
+		return 0, fmt.Errorf("%w: blobIndex %d must not be negative", ErrIndexOutOfRange, blobIndex)
 	}
 
 	// The share indexes of each blob needs to be computed thus we need to ensure
@@ -229,7 +550,7 @@ func (b *Builder) FindBlobStartingIndex(pfbIndex, blobIndex int) (int, error) {
 	}
 
 	if blobIndex >= len(b.Pfbs[pfbIndex].ShareIndexes) {
-		return 0, fmt.Errorf("blobIndex %d out of range", blobIndex)
+		return 0, fmt.Errorf("%w: blobIndex %d", ErrIndexOutOfRange, blobIndex)
 	}
 
 	return int(b.Pfbs[pfbIndex].ShareIndexes[blobIndex]), nil
@@ -241,14 +562,14 @@ func (b *Builder) FindBlobStartingIndex(pfbIndex, blobIndex int) (int, error) {
 // numbers of blobs
 func (b *Builder) BlobShareLength(pfbIndex, blobIndex int) (int, error) {
 	if pfbIndex < len(b.Txs) {
-		return 0, fmt.Errorf("pfbIndex %d does not match a pfb", pfbIndex)
+		return 0, fmt.Errorf("%w: pfbIndex %d does not match a pfb", ErrIndexOutOfRange, pfbIndex)
 	}
 	pfbIndex -= len(b.Txs)
 	if pfbIndex >= len(b.Pfbs) {
-		return 0, fmt.Errorf("pfbIndex %d out of range", pfbIndex)
+		return 0, fmt.Errorf("%w: pfbIndex %d", ErrIndexOutOfRange, pfbIndex)
 	}
 	if blobIndex < 0 {
-		return 0, fmt.Errorf("blobIndex %d must not be negative", blobIndex)
+		return 0, fmt.Errorf("%w: blobIndex %d must not be negative", ErrIndexOutOfRange, blobIndex)
 	}
 
 	for _, blob := range b.Blobs {
@@ -256,11 +577,11 @@ func (b *Builder) BlobShareLength(pfbIndex, blobIndex int) (int, error) {
 			return blob.NumShares, nil
 		}
 	}
-	return 0, fmt.Errorf("blob not found")
+	return 0, fmt.Errorf("%w: blobIndex %d", ErrIndexOutOfRange, blobIndex)
 }
 
 // FindTxShareRange returns the range of shares occupied by the tx at txIndex.
-// The indexes are both inclusive.
+// The range is end exclusive.
 func (b *Builder) FindTxShareRange(txIndex int) (shares.Range, error) {
 	// the square must be built before we can find the share range as we need to compute
 	// the wrapped indexes for the PFBs. NOTE: If a tx isn't a PFB, we could theoretically
@@ -272,11 +593,11 @@ func (b *Builder) FindTxShareRange(txIndex int) (shares.Range, error) {
 		}
 	}
 	if txIndex < 0 {
-		return shares.Range{}, fmt.Errorf("txIndex %d must not be negative", txIndex)
+		return shares.Range{}, fmt.Errorf("%w: txIndex %d must not be negative", ErrIndexOutOfRange, txIndex)
 	}
 
 	if txIndex >= len(b.Txs)+len(b.Pfbs) {
-		return shares.Range{}, fmt.Errorf("txIndex %d out of range", txIndex)
+		return shares.Range{}, fmt.Errorf("%w: txIndex %d", ErrIndexOutOfRange, txIndex)
 	}
 
 	txWriter := shares.NewCompactShareCounter()
@@ -314,17 +635,68 @@ func (b *Builder) FindTxShareRange(txIndex int) (shares.Range, error) {
 	return shares.NewRange(start, end), nil
 }
 
+// ShareRanges returns the range of shares occupied by every tx and, for every
+// PFB, the range of shares occupied by each of its blobs in one pass over the
+// layout. txRanges[i] equals FindTxShareRange(i) and blobRanges[i][j] is the
+// range of the blob at index j of the PFB at index i. blobRanges[i] is nil if
+// the tx at index i isn't a PFB. The ranges are end exclusive.
+func (b *Builder) ShareRanges() (txRanges []shares.Range, blobRanges [][]shares.Range, err error) {
+	if !b.done {
+		_, err := b.Export()
+		if err != nil {
+			return nil, nil, fmt.Errorf("building square: %w", err)
+		}
+	}
+
+	txRanges = make([]shares.Range, 0, len(b.Txs)+len(b.Pfbs))
+	txCounter := shares.NewCompactShareCounter()
+	for _, tx := range b.Txs {
+		txRanges = append(txRanges, nextCompactShareRange(txCounter, len(tx), 0))
+	}
+	// the PFBs follow the shares of the regular txs
+	pfbCounter := shares.NewCompactShareCounter()
+	for _, pfb := range b.Pfbs {
+		txRanges = append(txRanges, nextCompactShareRange(pfbCounter, proto.Size(pfb), txCounter.Size()))
+	}
+
+	blobRanges = make([][]shares.Range, len(b.Txs)+len(b.Pfbs))
+	for i, pfb := range b.Pfbs {
+		blobRanges[len(b.Txs)+i] = make([]shares.Range, len(pfb.ShareIndexes))
+	}
+	for _, element := range b.Blobs {
+		start := int(b.Pfbs[element.PfbIndex].ShareIndexes[element.BlobIndex])
+		blobRanges[len(b.Txs)+element.PfbIndex][element.BlobIndex] = shares.NewRange(start, start+element.NumShares)
+	}
+	return txRanges, blobRanges, nil
+}
+
+// nextCompactShareRange adds a unit of size bytes to counter and returns the
+// range of shares it occupies, offset by offset shares. Like
+// FindTxShareRange, the range starts at the share that contains the first byte
+// of the delimiter of the unit, which can be the share before the one the data
+// of the unit starts in.
+func nextCompactShareRange(counter *shares.CompactShareCounter, size, offset int) shares.Range {
+	start := counter.Size() - 1
+	// If the remainder is 0, it means the unit will begin with the next share
+	// so we need to increment the start index.
+	if counter.Remainder() == 0 {
+		start++
+	}
+	_ = counter.Add(size)
+	return shares.NewRange(offset+start, offset+counter.Size())
+}
+
 func (b *Builder) GetWrappedPFB(txIndex int) (*blob.IndexWrapper, error) {
 	if txIndex < 0 {
-		return nil, fmt.Errorf("txIndex %d must not be negative", txIndex)
+		return nil, fmt.Errorf("%w: txIndex %d must not be negative", ErrIndexOutOfRange, txIndex)
 	}
 
 	if txIndex < len(b.Txs) {
-		return nil, fmt.Errorf("txIndex %d does not match a pfb", txIndex)
+		return nil, fmt.Errorf("%w: txIndex %d does not match a pfb", ErrIndexOutOfRange, txIndex)
 	}
 
 	if txIndex >= len(b.Txs)+len(b.Pfbs) {
-		return nil, fmt.Errorf("txIndex %d out of range", txIndex)
+		return nil, fmt.Errorf("%w: txIndex %d", ErrIndexOutOfRange, txIndex)
 	}
 
 	if !b.done {
@@ -341,6 +713,37 @@ func (b *Builder) CurrentSize() int {
 	return b.currentSize
 }
 
+// CurrentSquareSize returns the size of the square that Export would construct
+// from what has been appended so far and the number of shares reserved for it.
+// The number of used shares is an overestimate as blobs reserve their worst
+// case padding.
+func (b *Builder) CurrentSquareSize() (squareSize, usedShares int) {
+	return b.squareSize(), b.currentSize
+}
+
+// RemainingSparseShares reports whether a share version zero blob of blobSize
+// bytes in namespace ns can still be admitted to the square and the number of
+// shares it reserves, which includes the worst case padding needed to align
+// the blob with the non-interactive default rules. The worst case padding is
+// reserved regardless of where the blob lands once blobs are sorted by
+// namespace, so the answer doesn't depend on the other blobs in the square.
+// Admitting a blob may increase the square size reported by
+// CurrentSquareSize; the reservation is checked against the max square size,
+// just like AppendBlobTx does.
+//
+// The PFB that pays for the blob also occupies compact shares which can only
+// be known once the PFB is. AppendBlobTx can therefore still reject a blob
+// reported to fit if its PFB needs an additional compact share that doesn't
+// fit.
+func (b *Builder) RemainingSparseShares(ns namespace.Namespace, blobSize int) (fits bool, sharesNeeded int) {
+	if blobSize <= 0 || uint64(blobSize) > math.MaxUint32 || ns.ValidateForBlob() != nil {
+		return false, 0
+	}
+	numShares := shares.SparseSharesNeededForVersion(uint32(blobSize), shares.ShareVersionZero)
+	sharesNeeded = numShares + maxPadding(numShares, b.subtreeRootThreshold)
+	return b.canFit(sharesNeeded), sharesNeeded
+}
+
 func (b *Builder) SubtreeRootThreshold() int {
 	return b.subtreeRootThreshold
 }
@@ -357,6 +760,19 @@ func (b *Builder) canFit(shareNum int) bool {
 	return b.currentSize+shareNum <= (b.maxSquareSize * b.maxSquareSize)
 }
 
+// squareSize returns the size of the square that Export constructs.
+func (b *Builder) squareSize() int {
+	if b.fixedSquareSize != 0 {
+		return b.fixedSquareSize
+	}
+	if b.IsEmpty() {
+		return EmptySquare().Size()
+	}
+	// NOTE: A future optimization could be to recalculate the currentSize based on the actual
+	// interblob padding used when the blobs are correctly ordered instead of using worst case padding.
+	return inclusion.BlobMinSquareSize(b.currentSize)
+}
+
 func (b *Builder) IsEmpty() bool {
 	return b.TxCounter.Size() == 0 && b.PfbCounter.Size() == 0
 }
@@ -367,42 +783,51 @@ type Element struct {
 	BlobIndex  int
 	NumShares  int
 	MaxPadding int
+
+	// shares are the shares of the blob if it was split to verify its share
+	// commitment.
+	shares []shares.Share
 }
 
 func newElement(blob *blob.Blob, pfbIndex, blobIndex, subtreeRootThreshold int) *Element {
-	numShares := shares.SparseSharesNeeded(uint32(len(blob.Data)))
+	numShares := shares.SparseSharesNeededForVersion(uint32(len(blob.Data)), uint8(blob.ShareVersion))
 	return &Element{
-		Blob:      blob,
-		PfbIndex:  pfbIndex,
-		BlobIndex: blobIndex,
-		NumShares: numShares,
-		//
-		// For calculating the maximum possible padding consider the following tree
-		// where each leaf corresponds to a share.
-		//
-		//	Depth       Position
-		//	0              0
-		//	              / \
-		//	             /   \
-		//	1           0     1
-		//	           /\     /\
-		//	2         0  1   2  3
-		//	         /\  /\ /\  /\
-		//	3       0 1 2 3 4 5 6 7
-		//
-		// Imagine if, according to the share commitment rules, a transcation took up 11 shares
-		// and had the merkle mountain tree commitment of 4,4,2,1. The first part of the share commitment
-		// would then be something that spans 4 shares and has a depth of 1. The worst case padding
-		// would be if the last transaction had a share at leaf index 0. Thus three padding shares would
-		// be needed to start the transaction at index 4 and be aligned with the first commitment.
-		// Thus the rule is to take the subtreewidh of the share size and subtract 1.
-		//
-		// Note that the padding would actually belong to the namespace of the transaction before it, but
-		// this makes no difference to the total share size.
-		MaxPadding: inclusion.SubTreeWidth(numShares, subtreeRootThreshold) - 1,
+		Blob:       blob,
+		PfbIndex:   pfbIndex,
+		BlobIndex:  blobIndex,
+		NumShares:  numShares,
+		MaxPadding: maxPadding(numShares, subtreeRootThreshold),
 	}
 }
 
+// maxPadding returns the maximum number of padding shares that can precede a
+// blob of numShares shares.
+func maxPadding(numShares, subtreeRootThreshold int) int {
+	// For calculating the maximum possible padding consider the following tree
+	// where each leaf corresponds to a share.
+	//
+	//	Depth       Position
+	//	0              0
+	//	              / \
+	//	             /   \
+	//	1           0     1
+	//	           /\     /\
+	//	2         0  1   2  3
+	//	         /\  /\ /\  /\
+	//	3       0 1 2 3 4 5 6 7
+	//
+	// Imagine if, according to the share commitment rules, a transcation took up 11 shares
+	// and had the merkle mountain tree commitment of 4,4,2,1. The first part of the share commitment
+	// would then be something that spans 4 shares and has a depth of 1. The worst case padding
+	// would be if the last transaction had a share at leaf index 0. Thus three padding shares would
+	// be needed to start the transaction at index 4 and be aligned with the first commitment.
+	// Thus the rule is to take the subtreewidh of the share size and subtract 1.
+	//
+	// Note that the padding would actually belong to the namespace of the transaction before it, but
+	// this makes no difference to the total share size.
+	return inclusion.SubTreeWidth(numShares, subtreeRootThreshold) - 1
+}
+
 func (e Element) maxShareOffset() int {
 	return e.NumShares + e.MaxPadding
 }
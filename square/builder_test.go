@@ -2,8 +2,12 @@ package square_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/celestiaorg/go-square/blob"
@@ -11,8 +15,10 @@ import (
 	"github.com/celestiaorg/go-square/namespace"
 	"github.com/celestiaorg/go-square/shares"
 	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestBuilderSquareSizeEstimation(t *testing.T) {
@@ -92,6 +98,268 @@ func TestBuilderRejectsBlobTransactions(t *testing.T) {
 	}
 }
 
+func TestBuilderRemainingSparseShares(t *testing.T) {
+	const (
+		maxSquareSize = 16
+		maxShares     = maxSquareSize * maxSquareSize
+		// a low threshold makes blobs of a few shares require padding
+		subtreeRootThreshold = 2
+	)
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	rng := rand.New(rand.NewSource(42))
+	blobSizes := []int{
+		1,
+		shares.AvailableBytesFromSparseShares(1),
+		shares.AvailableBytesFromSparseShares(1) + 1,
+		shares.AvailableBytesFromSparseShares(5),
+		shares.AvailableBytesFromSparseShares(17),
+		shares.AvailableBytesFromSparseShares(64),
+		shares.AvailableBytesFromSparseShares(maxShares),
+	}
+
+	// fill the square one blob tx at a time and compare the prediction at
+	// every fill level against appending to a builder of the same txs
+	var txs [][]byte
+	for {
+		builder, err := square.NewBuilder(maxSquareSize, subtreeRootThreshold, txs...)
+		require.NoError(t, err)
+		squareSize, usedShares := builder.CurrentSquareSize()
+		assert.Equal(t, builder.CurrentSize(), usedShares)
+
+		for _, blobSize := range append(blobSizes, 1+rng.Intn(shares.AvailableBytesFromSparseShares(20))) {
+			fits, sharesNeeded := builder.RemainingSparseShares(ns1, blobSize)
+
+			clone, err := square.NewBuilder(maxSquareSize, subtreeRootThreshold, txs...)
+			require.NoError(t, err)
+			blobTx, isBlobTx := blob.UnmarshalBlobTx(test.GenerateBlobTxWithNamespace([]namespace.Namespace{ns1}, []int{blobSize}))
+			require.True(t, isBlobTx)
+			// the compact shares the PFB adds are known once the PFB is
+			pfbCounter := *clone.PfbCounter
+			pfbShares := pfbCounter.Add(proto.Size(&blob.IndexWrapper{
+				Tx:           blobTx.Tx,
+				ShareIndexes: []uint32{maxShares},
+				TypeId:       blob.ProtoIndexWrapperTypeID,
+			}))
+
+			appended := clone.AppendBlobTx(blobTx)
+			require.Equal(t, fits && usedShares+sharesNeeded+pfbShares <= maxShares, appended,
+				"%d used shares, blob of %d bytes", usedShares, blobSize)
+			if !appended {
+				continue
+			}
+			newSquareSize, newUsedShares := clone.CurrentSquareSize()
+			assert.Equal(t, usedShares+sharesNeeded+pfbShares, newUsedShares)
+			assert.GreaterOrEqual(t, newSquareSize, squareSize)
+			dataSquare, err := clone.Export()
+			require.NoError(t, err)
+			assert.Equal(t, newSquareSize, dataSquare.Size())
+		}
+
+		next := test.GenerateBlobTxWithNamespace([]namespace.Namespace{ns1}, []int{1 + rng.Intn(shares.AvailableBytesFromSparseShares(10))})
+		nextBlobTx, _ := blob.UnmarshalBlobTx(next)
+		if !builder.AppendBlobTx(nextBlobTx) {
+			break
+		}
+		txs = append(txs, next)
+	}
+	require.NotEmpty(t, txs)
+
+	builder, err := square.NewBuilder(maxSquareSize, subtreeRootThreshold)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name     string
+		ns       namespace.Namespace
+		blobSize int
+	}{
+		{"empty blob", ns1, 0},
+		{"negative size", ns1, -1},
+		{"reserved namespace", namespace.TxNamespace, 1},
+	} {
+		fits, sharesNeeded := builder.RemainingSparseShares(tc.ns, tc.blobSize)
+		assert.False(t, fits, tc.name)
+		assert.Zero(t, sharesNeeded, tc.name)
+	}
+}
+
+func TestBuilderRevert(t *testing.T) {
+	const maxSquareSize = 8
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+
+	for seed := int64(0); seed < 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		builder, err := square.NewBuilder(maxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		// the txs that remain in the builder
+		var txs, blobTxs [][]byte
+
+		for op := 0; op < 100; op++ {
+			switch rng.Intn(5) {
+			case 0:
+				tx := testutil.RandomNormalTx(rng, 1+rng.Intn(1000))
+				if builder.AppendTx(tx) {
+					txs = append(txs, tx)
+				}
+			case 1:
+				nss := []namespace.Namespace{ns1, ns2}[:1+rng.Intn(2)]
+				rng.Shuffle(len(nss), func(i, j int) { nss[i], nss[j] = nss[j], nss[i] })
+				sizes := make([]int, len(nss))
+				for i := range sizes {
+					sizes[i] = 1 + rng.Intn(3000)
+				}
+				rawTx := test.GenerateBlobTxWithNamespace(nss, sizes)
+				blobTx, isBlobTx := blob.UnmarshalBlobTx(rawTx)
+				require.True(t, isBlobTx)
+				if builder.AppendBlobTx(blobTx) {
+					blobTxs = append(blobTxs, rawTx)
+				}
+			case 2:
+				require.Equal(t, len(txs) > 0, builder.RevertLastTx())
+				if len(txs) > 0 {
+					txs = txs[:len(txs)-1]
+				}
+			case 3:
+				require.Equal(t, len(blobTxs) > 0, builder.RevertLastBlobTx())
+				if len(blobTxs) > 0 {
+					blobTxs = blobTxs[:len(blobTxs)-1]
+				}
+			case 4:
+				// exporting reorders the blobs of the builder
+				_, err := builder.Export()
+				require.NoError(t, err)
+			}
+
+			want, err := square.NewBuilder(maxSquareSize, defaultSubtreeRootThreshold, append(slices.Clone(txs), blobTxs...)...)
+			require.NoError(t, err)
+			require.Equal(t, want.CurrentSize(), builder.CurrentSize(), "seed %d op %d", seed, op)
+			require.Equal(t, want.TxCounter.Size(), builder.TxCounter.Size(), "seed %d op %d", seed, op)
+			require.Equal(t, want.PfbCounter.Size(), builder.PfbCounter.Size(), "seed %d op %d", seed, op)
+		}
+
+		want, err := square.Construct(append(slices.Clone(txs), blobTxs...), maxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		got, err := builder.Export()
+		require.NoError(t, err)
+		require.Equal(t, want, got, "seed %d", seed)
+	}
+}
+
+func TestBuilderAppendBlobTxIsAtomic(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	const maxSquareSize = 4
+	smallBlobSize := shares.AvailableBytesFromSparseShares(1)
+	unmarshal := func(rawTx []byte) *blob.BlobTx {
+		blobTx, isBlobTx := blob.UnmarshalBlobTx(rawTx)
+		require.True(t, isBlobTx)
+		return blobTx
+	}
+	smallTx := func() *blob.BlobTx {
+		return unmarshal(test.GenerateBlobTxWithNamespace([]namespace.Namespace{ns1}, []int{smallBlobSize}))
+	}
+
+	// find out how many single share blob txs fit in the square
+	probe, err := square.NewBuilder(maxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	fit := 0
+	for probe.AppendBlobTx(smallTx()) {
+		fit++
+	}
+	require.Greater(t, fit, 1)
+
+	// leave room for exactly one more single share blob tx
+	builder, err := square.NewBuilder(maxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	for i := 0; i < fit-1; i++ {
+		require.True(t, builder.AppendBlobTx(smallTx()))
+	}
+	before, err := builder.Export()
+	require.NoError(t, err)
+
+	// the first blob of the tx fits but the second one doesn't
+	tooLarge := unmarshal(test.GenerateBlobTxWithNamespace(
+		[]namespace.Namespace{ns1, ns2},
+		[]int{smallBlobSize, shares.AvailableBytesFromSparseShares(maxSquareSize * maxSquareSize)},
+	))
+	require.False(t, builder.AppendBlobTx(tooLarge))
+	assert.Equal(t, fit-1, builder.NumPFBs())
+
+	after, err := builder.Export()
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// the capacity of the builder is unchanged
+	require.True(t, builder.AppendBlobTx(smallTx()))
+	require.False(t, builder.AppendBlobTx(smallTx()))
+}
+
+// TestBuilderExportGolden pins the squares exported for deterministic sets of
+// txs so that changes to how Export writes shares can't alter the square,
+// regardless of how many blobs are split into shares concurrently. Run the
+// test with -update to rewrite the golden file after an intentional change.
+func TestBuilderExportGolden(t *testing.T) {
+	path := filepath.Join("testdata", "export_golden.txt")
+	for _, workers := range []int{1, 2, 8} {
+		var got bytes.Buffer
+		for _, subtreeRootThreshold := range []int{2, defaultSubtreeRootThreshold} {
+			for seed := int64(0); seed < 4; seed++ {
+				builder, err := square.NewBuilder(defaultMaxSquareSize, subtreeRootThreshold, deterministicTxs(seed)...)
+				require.NoError(t, err)
+				builder.SetExportWorkers(workers)
+				dataSquare, err := builder.Export()
+				require.NoError(t, err)
+				encoded, err := dataSquare.MarshalBinary()
+				require.NoError(t, err)
+				fmt.Fprintf(&got, "threshold=%d seed=%d %x\n", subtreeRootThreshold, seed, sha256.Sum256(encoded))
+			}
+		}
+
+		if *update && workers == 1 {
+			require.NoError(t, os.WriteFile(path, got.Bytes(), 0o644))
+		}
+		want, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), got.String(), "%d workers", workers)
+	}
+}
+
+// deterministicTxs returns normal txs followed by blob txs with share version
+// 0 and 1 blobs of several namespaces and sizes derived from seed.
+func deterministicTxs(seed int64) [][]byte {
+	rng := rand.New(rand.NewSource(seed))
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+	var txs [][]byte
+	for i := 0; i < 5+rng.Intn(20); i++ {
+		txs = append(txs, randomBytes(1+rng.Intn(1000)))
+	}
+	for i := 0; i < 5+rng.Intn(20); i++ {
+		blobs := make([]*blob.Blob, 1+rng.Intn(3))
+		for j := range blobs {
+			ns := namespace.MustNewV0(bytes.Repeat([]byte{byte(1 + rng.Intn(4))}, namespace.NamespaceVersionZeroIDSize))
+			data := randomBytes(1 + rng.Intn(20_000))
+			if rng.Intn(2) == 0 {
+				blobs[j] = blob.New(ns, data, shares.ShareVersionZero)
+				continue
+			}
+			var err error
+			blobs[j], err = blob.NewV1(ns, data, randomBytes(shares.SignerSize))
+			if err != nil {
+				panic(err)
+			}
+		}
+		blobTx, err := blob.MarshalBlobTx(randomBytes(100+rng.Intn(400)), blobs...)
+		if err != nil {
+			panic(err)
+		}
+		txs = append(txs, blobTx)
+	}
+	return txs
+}
+
 func TestBuilderInvalidConstructor(t *testing.T) {
 	_, err := square.NewBuilder(-4, 64)
 	require.Error(t, err)
@@ -341,3 +609,62 @@ func generateBlobTxsWithNamespaces(namespaces []namespace.Namespace, blobSizes [
 	}
 	return txs
 }
+
+func TestBuilderRejectsInvalidBlobs(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	firstUserNamespace, err := namespace.MaxPrimaryReservedNamespace.Next()
+	require.NoError(t, err)
+	normalTx := test.GenerateTxs(100, 101, 1)[0]
+
+	testCases := []struct {
+		name     string
+		ns       namespace.Namespace
+		reserved bool
+	}{
+		{"tx namespace", namespace.TxNamespace, true},
+		{"pfb namespace", namespace.PayForBlobNamespace, true},
+		{"tail padding namespace", namespace.TailPaddingNamespace, true},
+		{"parity namespace", namespace.ParitySharesNamespace, true},
+		{"first user namespace", firstUserNamespace, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := blob.New(ns1, []byte{1}, shares.ShareVersionZero)
+			other := blob.New(tc.ns, []byte{1}, shares.ShareVersionZero)
+			// MarshalBlobTx rejects invalid blobs so the blob tx is encoded directly
+			rawTx, err := proto.Marshal(&blob.BlobTx{Tx: []byte("tx"), Blobs: []*blob.Blob{valid, other}, TypeId: blob.ProtoBlobTxTypeID})
+			require.NoError(t, err)
+			blobTx, isBlobTx := blob.UnmarshalBlobTx(rawTx)
+			require.True(t, isBlobTx)
+
+			builder, err := square.NewBuilder(64, 64)
+			require.NoError(t, err)
+			assert.Equal(t, !tc.reserved, builder.AppendBlobTx(blobTx))
+			if tc.reserved {
+				assert.True(t, builder.IsEmpty())
+				assert.Zero(t, builder.NumPFBs())
+				assert.Empty(t, builder.Blobs)
+			}
+
+			txs := [][]byte{normalTx, rawTx}
+			_, err = square.NewBuilder(64, 64, txs...)
+			_, constructErr := square.Construct(txs, 64, 64)
+			_, _, fixedErr := square.BuildFixed(txs, 64, 64)
+			for _, err := range []error{err, constructErr, fixedErr} {
+				if !tc.reserved {
+					require.NoError(t, err)
+					continue
+				}
+				assert.ErrorIs(t, err, blob.ErrReservedNamespace)
+				assert.ErrorIs(t, err, namespace.ErrReservedNamespace)
+				var txErr *square.TxError
+				require.ErrorAs(t, err, &txErr)
+				assert.Equal(t, 1, txErr.TxIndex)
+				var nsErr *square.ReservedNamespaceError
+				require.ErrorAs(t, err, &nsErr)
+				assert.Equal(t, 1, nsErr.BlobIndex)
+				assert.Equal(t, tc.ns, nsErr.Namespace)
+			}
+		})
+	}
+}
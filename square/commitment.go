@@ -0,0 +1,102 @@
+package square
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/shares"
+)
+
+// ErrCommitmentMismatch is returned, wrapped in a *CommitmentError, when the
+// share commitments declared by the PFB of a blob transaction don't match its
+// blobs.
+var ErrCommitmentMismatch = errors.New("share commitment doesn't match the blob")
+
+// CommitmentError is returned, wrapped in a *TxError naming the blob
+// transaction, when the share commitment of the blob at BlobIndex doesn't
+// match the one declared by its PFB. BlobIndex is -1 if the commitments
+// couldn't be extracted from the PFB or there isn't one per blob. Err wraps
+// ErrCommitmentMismatch.
+type CommitmentError struct {
+	BlobIndex int
+	Err       error
+}
+
+func (e *CommitmentError) Error() string {
+	if e.BlobIndex < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("blob %d: %v", e.BlobIndex, e.Err)
+}
+
+func (e *CommitmentError) Unwrap() error {
+	return e.Err
+}
+
+// CommitmentExtractor returns the share commitments declared by the PFB
+// pfbTx of a blob transaction, one per blob in the order of the blobs.
+type CommitmentExtractor func(pfbTx []byte) ([][]byte, error)
+
+// commitmentVerifier checks the share commitments of blob transactions.
+type commitmentVerifier struct {
+	extractCommitments CommitmentExtractor
+	merkleRootFn       inclusion.MerkleRootFn
+}
+
+// WithCommitmentVerification makes blob transactions whose blobs don't match
+// the share commitments declared by their PFB be rejected like invalid blob
+// transactions: Build and ConstructWithPolicy drop them and Construct,
+// BuildFixed and ConstructBatch return an error. extractCommitments pulls the commitments out of the PFB, which
+// depends on the app, and the commitments of the blobs are computed with
+// merkleRootFn and the subtree root threshold of the square. A mismatch is
+// reported in a *TxError wrapping a *CommitmentError. The shares the blobs
+// are split into to compute their commitments are reused to write the square.
+// By default commitments aren't verified.
+func WithCommitmentVerification(extractCommitments CommitmentExtractor, merkleRootFn inclusion.MerkleRootFn) BuildOption {
+	return func(c *buildConfig) {
+		c.commitments = &commitmentVerifier{extractCommitments: extractCommitments, merkleRootFn: merkleRootFn}
+	}
+}
+
+// SetCommitmentVerification makes AppendBlobTx reject blob transactions whose
+// blobs don't match the share commitments declared by their PFB, see
+// WithCommitmentVerification. A nil extractCommitments turns verification off,
+// which is the default.
+func (b *Builder) SetCommitmentVerification(extractCommitments CommitmentExtractor, merkleRootFn inclusion.MerkleRootFn) {
+	if extractCommitments == nil {
+		b.commitments = nil
+		return
+	}
+	b.commitments = &commitmentVerifier{extractCommitments: extractCommitments, merkleRootFn: merkleRootFn}
+}
+
+// verify splits the blobs of blobTx into shares and checks that their share
+// commitments match those declared by its PFB. It returns the shares of every
+// blob, or a *CommitmentError if a commitment doesn't match.
+func (v *commitmentVerifier) verify(blobTx *blob.BlobTx, subtreeRootThreshold int) ([][]shares.Share, error) {
+	commitments, err := v.extractCommitments(blobTx.Tx)
+	if err != nil {
+		return nil, &CommitmentError{BlobIndex: -1, Err: fmt.Errorf("%w: extracting commitments: %w", ErrCommitmentMismatch, err)}
+	}
+	if len(commitments) != len(blobTx.Blobs) {
+		return nil, &CommitmentError{BlobIndex: -1, Err: fmt.Errorf("%w: %d commitments for %d blobs", ErrCommitmentMismatch, len(commitments), len(blobTx.Blobs))}
+	}
+	blobShares := make([][]shares.Share, len(blobTx.Blobs))
+	for i, b := range blobTx.Blobs {
+		blobShares[i], err = shares.SplitBlobs(b)
+		if err != nil {
+			return nil, &CommitmentError{BlobIndex: i, Err: fmt.Errorf("%w: %w", ErrCommitmentMismatch, err)}
+		}
+		commitment, err := inclusion.CreateCommitmentFromShares(blobShares[i], v.merkleRootFn, subtreeRootThreshold)
+		if err != nil {
+			return nil, &CommitmentError{BlobIndex: i, Err: fmt.Errorf("%w: %w", ErrCommitmentMismatch, err)}
+		}
+		if !bytes.Equal(commitment, commitments[i]) {
+			return nil, &CommitmentError{BlobIndex: i, Err: fmt.Errorf("%w: declared %X but computed %X", ErrCommitmentMismatch, commitments[i], commitment)}
+		}
+	}
+	return blobShares, nil
+}
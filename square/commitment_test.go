@@ -0,0 +1,235 @@
+package square_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/merkle"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitmentPFB returns a PFB that declares commitments, which
+// extractCommitments extracts.
+func commitmentPFB(commitments ...[]byte) []byte {
+	var pfb []byte
+	for _, c := range commitments {
+		pfb = append(pfb, c...)
+	}
+	return pfb
+}
+
+func extractCommitments(pfb []byte) ([][]byte, error) {
+	if len(pfb)%32 != 0 {
+		return nil, fmt.Errorf("PFB of %d bytes isn't a list of commitments", len(pfb))
+	}
+	var commitments [][]byte
+	for i := 0; i < len(pfb); i += 32 {
+		commitments = append(commitments, pfb[i:i+32])
+	}
+	return commitments, nil
+}
+
+// committedBlobTx returns a blob tx of blobs whose PFB declares the
+// commitments of the blobs, except for the blob at wrongIndex, whose
+// commitment is computed over different data.
+func committedBlobTx(t *testing.T, rng *rand.Rand, threshold, wrongIndex int, sizes ...int) []byte {
+	namespaces := namespace.RandomBlobNamespaces(rng, len(sizes))
+	blobs := make([]*blob.Blob, len(sizes))
+	commitments := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		data := make([]byte, size)
+		_, _ = rng.Read(data)
+		blobs[i] = blob.New(namespaces[i], data, shares.ShareVersionZero)
+		committed := blobs[i]
+		if i == wrongIndex {
+			other := append([]byte(nil), data...)
+			other[len(other)-1] ^= 1
+			committed = blob.New(namespaces[i], other, shares.ShareVersionZero)
+		}
+		var err error
+		commitments[i], err = inclusion.CreateCommitment(committed, merkle.HashFromByteSlices, threshold)
+		require.NoError(t, err)
+	}
+	tx, err := blob.MarshalBlobTx(commitmentPFB(commitments...), blobs...)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestBuildWithCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	normalTx := testutil.RandomNormalTx(rng, 100)
+	valid := committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 1000, 5000)
+	wrong := committedBlobTx(t, rng, defaultSubtreeRootThreshold, 1, 2000, 3000)
+	valid2 := committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 70000)
+	txs := [][]byte{normalTx, valid, wrong, valid2}
+
+	var dropped []*square.TxError
+	verify := square.WithCommitmentVerification(extractCommitments, merkle.HashFromByteSlices)
+	dataSquare, included, err := square.Build(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, verify, square.OnDropped(func(err *square.TxError) {
+		dropped = append(dropped, err)
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{normalTx, valid, valid2}, included)
+	require.Len(t, dropped, 1)
+	assert.Equal(t, 2, dropped[0].TxIndex)
+	assert.ErrorIs(t, dropped[0], square.ErrCommitmentMismatch)
+	var commitmentErr *square.CommitmentError
+	require.True(t, errors.As(dropped[0], &commitmentErr))
+	assert.Equal(t, 1, commitmentErr.BlobIndex)
+
+	// the shares split to verify the commitments make the same square
+	want, err := square.Construct(included, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.True(t, want.Equals(dataSquare))
+
+	// without verification the tx is included
+	_, included, err = square.Build(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{normalTx, valid, wrong, valid2}, included)
+}
+
+func TestConstructWithCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	valid := committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 1000)
+	// the commitments are computed with another threshold
+	otherThreshold := committedBlobTx(t, rng, 1, -1, 100000)
+	wrong := committedBlobTx(t, rng, defaultSubtreeRootThreshold, 0, 1000, 1000)
+
+	testCases := []struct {
+		name          string
+		txs           [][]byte
+		extract       square.CommitmentExtractor
+		wantTxIndex   int
+		wantBlobIndex int
+	}{
+		{name: "valid", txs: [][]byte{valid}, extract: extractCommitments},
+		{name: "wrong commitment", txs: [][]byte{valid, wrong}, extract: extractCommitments, wantTxIndex: 1, wantBlobIndex: 0},
+		{name: "other threshold", txs: [][]byte{otherThreshold}, extract: extractCommitments, wantTxIndex: 0, wantBlobIndex: 0},
+		{
+			name: "extraction fails", txs: [][]byte{valid}, wantTxIndex: 0, wantBlobIndex: -1,
+			extract: func([]byte) ([][]byte, error) { return nil, errors.New("not a PFB") },
+		},
+		{
+			name: "too few commitments", txs: [][]byte{wrong}, wantTxIndex: 0, wantBlobIndex: -1,
+			extract: func(pfb []byte) ([][]byte, error) {
+				commitments, err := extractCommitments(pfb)
+				return commitments[:1], err
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			verify := square.WithCommitmentVerification(tc.extract, merkle.HashFromByteSlices)
+			dataSquare, err := square.Construct(tc.txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, verify)
+			if tc.name == "valid" {
+				require.NoError(t, err)
+				want, err := square.Construct(tc.txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				require.NoError(t, err)
+				assert.True(t, want.Equals(dataSquare))
+				return
+			}
+			assert.ErrorIs(t, err, square.ErrCommitmentMismatch)
+			var txErr *square.TxError
+			require.True(t, errors.As(err, &txErr))
+			assert.Equal(t, tc.wantTxIndex, txErr.TxIndex)
+			var commitmentErr *square.CommitmentError
+			require.True(t, errors.As(err, &commitmentErr))
+			assert.Equal(t, tc.wantBlobIndex, commitmentErr.BlobIndex)
+		})
+	}
+}
+
+func TestBuilderCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	valid, _ := blob.UnmarshalBlobTx(committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 1000))
+	wrong, _ := blob.UnmarshalBlobTx(committedBlobTx(t, rng, defaultSubtreeRootThreshold, 0, 1000))
+
+	builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	builder.SetCommitmentVerification(extractCommitments, merkle.HashFromByteSlices)
+	assert.True(t, builder.AppendBlobTx(valid))
+	assert.False(t, builder.AppendBlobTx(wrong))
+	assert.Equal(t, 1, builder.NumPFBs())
+
+	// turning verification off accepts the blob tx
+	builder.SetCommitmentVerification(nil, nil)
+	assert.True(t, builder.AppendBlobTx(wrong))
+	_, err = builder.Export()
+	require.NoError(t, err)
+}
+
+func TestSessionCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	wrong := committedBlobTx(t, rng, defaultSubtreeRootThreshold, 0, 1000)
+	session, err := square.NewConstructSession(defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+
+	verify := square.WithCommitmentVerification(extractCommitments, merkle.HashFromByteSlices)
+	_, included, err := session.Build([][]byte{wrong}, verify)
+	require.NoError(t, err)
+	assert.Empty(t, included)
+	// the option only applies to the call it is passed to
+	_, err = session.Construct([][]byte{wrong})
+	require.NoError(t, err)
+	_, err = session.Construct([][]byte{wrong}, verify)
+	assert.ErrorIs(t, err, square.ErrCommitmentMismatch)
+}
+
+func TestBuildFixedCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	normalTx := testutil.RandomNormalTx(rng, 100)
+	valid := committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 1000)
+	wrong := committedBlobTx(t, rng, defaultSubtreeRootThreshold, 1, 1000, 2000)
+	txs := [][]byte{normalTx, valid, wrong}
+	verify := square.WithCommitmentVerification(extractCommitments, merkle.HashFromByteSlices)
+
+	_, _, err := square.BuildFixed(txs, 16, defaultSubtreeRootThreshold, verify)
+	assert.ErrorIs(t, err, square.ErrCommitmentMismatch)
+	var txErr *square.TxError
+	require.True(t, errors.As(err, &txErr))
+	assert.Equal(t, 2, txErr.TxIndex)
+	var commitmentErr *square.CommitmentError
+	require.True(t, errors.As(err, &commitmentErr))
+	assert.Equal(t, 1, commitmentErr.BlobIndex)
+
+	_, included, err := square.BuildFixed(txs[:2], 16, defaultSubtreeRootThreshold, verify)
+	require.NoError(t, err)
+	assert.Equal(t, txs[:2], included)
+	// without verification the tx is included
+	_, included, err = square.BuildFixed(txs, 16, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.Equal(t, txs, included)
+}
+
+func TestConstructBatchCommitmentVerification(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	valid := committedBlobTx(t, rng, defaultSubtreeRootThreshold, -1, 1000)
+	wrong := committedBlobTx(t, rng, defaultSubtreeRootThreshold, 0, 1000)
+	txs := [][]byte{valid, wrong}
+	verify := square.WithCommitmentVerification(extractCommitments, merkle.HashFromByteSlices)
+
+	_, _, err := square.ConstructBatch(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, 2, verify)
+	assert.ErrorIs(t, err, square.ErrCommitmentMismatch)
+	var txErr *square.TxError
+	require.True(t, errors.As(err, &txErr))
+	assert.Equal(t, 1, txErr.TxIndex)
+	var commitmentErr *square.CommitmentError
+	require.True(t, errors.As(err, &commitmentErr))
+	assert.Equal(t, 0, commitmentErr.BlobIndex)
+
+	// without verification the tx is included
+	results, remaining, err := square.ConstructBatch(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, txs, results[0].Txs)
+	assert.Empty(t, remaining)
+}
@@ -0,0 +1,185 @@
+package square
+
+import (
+	"unicode/utf8"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// EstimateSquareSize returns the size of the square that Build returns for txs
+// without constructing it. The transactions are allocated exactly as Build
+// allocates them, including dropping the transactions that don't fit, but no
+// shares are written and the data of the blobs isn't decoded. Build sizes the
+// square from the worst case padding of every blob rather than from the
+// padding of the final layout, so the estimate is always equal to the size of
+// the square returned by Build.
+func EstimateSquareSize(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (int, error) {
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		return 0, err
+	}
+	var scratch blob.Blob
+	for _, tx := range txs {
+		footprint, ok := scanBlobTx(tx, &scratch, subtreeRootThreshold)
+		switch {
+		case !ok:
+			// fall back to decoding encodings the scanner doesn't handle
+			if blobTx, isBlobTx := blob.UnmarshalBlobTx(tx); isBlobTx {
+				builder.AppendBlobTx(blobTx)
+			} else {
+				builder.AppendTx(tx)
+			}
+		case !footprint.isBlobTx:
+			builder.AppendTx(tx)
+		case footprint.valid:
+			builder.reserveBlobTx(builder.newIndexWrapper(footprint.tx, footprint.blobs), footprint.maxBlobShareCount)
+		}
+	}
+	return builder.squareSize(), nil
+}
+
+// blobTxFootprint is the part of a blob transaction that determines the
+// number of shares it occupies.
+type blobTxFootprint struct {
+	isBlobTx bool
+	// valid is false if the blob transaction fails blob.BlobTx.Validate
+	valid             bool
+	tx                []byte
+	blobs             int
+	maxBlobShareCount int
+}
+
+// scanResult is the outcome of scanning an encoded message.
+type scanResult int
+
+const (
+	scanned scanResult = iota
+	// malformed means that proto.Unmarshal fails to decode the message
+	malformed
+	// unsupported means that the scanner doesn't know how proto.Unmarshal
+	// decodes the message, e.g. because a field has an unexpected wire type
+	unsupported
+)
+
+// scanBlobTx reads the footprint of the encoded transaction tx without
+// copying the data of its blobs. isBlobTx is set as blob.UnmarshalBlobTx
+// would return it. The fields of every blob are read into scratch to validate
+// it. It returns false if tx is an encoding that proto.Unmarshal may decode
+// differently so that the caller can decode it instead.
+func scanBlobTx(tx []byte, scratch *blob.Blob, subtreeRootThreshold int) (footprint blobTxFootprint, ok bool) {
+	// a transaction that can't be decoded isn't a blob transaction
+	notBlobTx := blobTxFootprint{}
+	var typeID []byte
+	footprint.valid = true
+	validNamespaceIDs := true
+	for b := tx; len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return notBlobTx, true
+		}
+		b = b[n:]
+		switch num {
+		case 1, 2, 3:
+			if typ != protowire.BytesType {
+				return footprint, false
+			}
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return notBlobTx, true
+			}
+			b = b[n:]
+			switch num {
+			case 1:
+				footprint.tx = v
+			case 2:
+				switch scanBlob(v, scratch) {
+				case malformed:
+					return notBlobTx, true
+				case unsupported:
+					return footprint, false
+				}
+				footprint.blobs++
+				if len(scratch.NamespaceId) != namespace.NamespaceIDSize {
+					validNamespaceIDs = false
+				}
+				if scratch.Validate() != nil {
+					footprint.valid = false
+					continue
+				}
+				numShares := shares.SparseSharesNeededForVersion(uint32(len(scratch.Data)), uint8(scratch.ShareVersion))
+				footprint.maxBlobShareCount += numShares + maxPadding(numShares, subtreeRootThreshold)
+			case 3:
+				// type_id is a string which must be valid UTF-8
+				if !utf8.Valid(v) {
+					return notBlobTx, true
+				}
+				typeID = v
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return notBlobTx, true
+			}
+			b = b[n:]
+		}
+	}
+	footprint.isBlobTx = string(typeID) == blob.ProtoBlobTxTypeID && footprint.blobs > 0 && validNamespaceIDs
+	return footprint, true
+}
+
+// scanBlob reads the fields of the encoded Blob message b into scratch. The
+// byte fields of scratch alias b.
+func scanBlob(b []byte, scratch *blob.Blob) scanResult {
+	scratch.NamespaceId, scratch.Data, scratch.Signer = nil, nil, nil
+	scratch.ShareVersion, scratch.NamespaceVersion = 0, 0
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return malformed
+		}
+		b = b[n:]
+		switch num {
+		case 1, 2, 5:
+			if typ != protowire.BytesType {
+				return unsupported
+			}
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return malformed
+			}
+			b = b[n:]
+			switch num {
+			case 1:
+				scratch.NamespaceId = v
+			case 2:
+				scratch.Data = v
+			case 5:
+				scratch.Signer = v
+			}
+		case 3, 4:
+			if typ != protowire.VarintType {
+				return unsupported
+			}
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return malformed
+			}
+			b = b[n:]
+			if num == 3 {
+				scratch.ShareVersion = uint32(v)
+			} else {
+				scratch.NamespaceVersion = uint32(v)
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return malformed
+			}
+			b = b[n:]
+		}
+	}
+	return scanned
+}
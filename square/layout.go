@@ -0,0 +1,137 @@
+package square
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/shares"
+)
+
+// LayoutBuilder lays out blobs in the non-reserved section of a square. Each
+// blob is placed at the next share index permitted by the blob share
+// commitment rules and the gap before it is filled with namespace padding
+// shares (or reserved padding shares for the first blob).
+type LayoutBuilder struct {
+	// startIndex is the index in the square of the first share of the layout.
+	startIndex int
+	// cursor is the index in the square after the end of the last blob.
+	cursor               int
+	subtreeRootThreshold int
+	reservedPadding      int
+	blobWriter           *shares.SparseShareSplitter
+}
+
+// NewLayoutBuilder returns a LayoutBuilder whose first share is at startIndex
+// in the square. startIndex is typically the number of compact shares.
+func NewLayoutBuilder(startIndex, subtreeRootThreshold int) (*LayoutBuilder, error) {
+	if startIndex < 0 {
+		return nil, fmt.Errorf("start index %d must not be negative", startIndex)
+	}
+	if subtreeRootThreshold <= 0 {
+		return nil, errors.New("subtree root threshold must be strictly positive")
+	}
+	return &LayoutBuilder{
+		startIndex:           startIndex,
+		cursor:               startIndex,
+		subtreeRootThreshold: subtreeRootThreshold,
+		blobWriter:           shares.NewSparseShareSplitter(),
+	}, nil
+}
+
+// AppendBlob writes the blob after any padding required to align it and
+// returns the index in the square of the blob's first share.
+func (lb *LayoutBuilder) AppendBlob(b *blob.Blob) (startIndex int, err error) {
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	numShares := shares.SparseSharesNeededForVersion(uint32(len(b.Data)), uint8(b.ShareVersion))
+	padding, end := BlobSharePadding(lb.cursor, numShares, lb.subtreeRootThreshold)
+	start := end - numShares
+
+	if lb.blobWriter.Count() == 0 {
+		lb.reservedPadding = padding
+	} else if err := lb.blobWriter.WriteNamespacePaddingShares(padding); err != nil {
+		return 0, err
+	}
+	if err := lb.blobWriter.Write(b); err != nil {
+		return 0, err
+	}
+	lb.cursor = end
+	return start, nil
+}
+
+// Export returns the shares of the layout, starting at the start index
+// provided to NewLayoutBuilder.
+func (lb *LayoutBuilder) Export() ([]shares.Share, error) {
+	out := make([]shares.Share, 0, lb.NumShares())
+	out = append(out, shares.ReservedPaddingShares(lb.reservedPadding)...)
+	blobShares, err := lb.blobWriter.Export()
+	if err != nil {
+		return nil, err
+	}
+	return append(out, blobShares...), nil
+}
+
+// NumShares returns the number of shares, including padding, that Export
+// returns.
+func (lb *LayoutBuilder) NumShares() int {
+	return lb.cursor - lb.startIndex
+}
+
+// SubtreeWidth returns the width of the subtrees in the share commitment of a
+// blob that spans shareCount shares. A blob must start at an index that is a
+// multiple of this width. It is the canonical implementation of the blob share
+// commitment rules and should be used by anyone laying out a square.
+func SubtreeWidth(shareCount, subtreeRootThreshold int) int {
+	return inclusion.SubTreeWidth(shareCount, subtreeRootThreshold)
+}
+
+// BlobStartIndex returns the first index at or after currentIndex at which a
+// blob that spans shareCount shares can start. currentIndex is expected to be
+// the index after the end of the previous blob. The difference between the
+// returned index and currentIndex is the number of padding shares required.
+func BlobStartIndex(currentIndex, shareCount, subtreeRootThreshold int) int {
+	return inclusion.NextShareIndex(currentIndex, shareCount, subtreeRootThreshold)
+}
+
+// BlobSharePadding returns the number of padding shares that precede a blob
+// that spans blobShareLen shares when the previous blob ends at cursor, and the
+// index after the end of the blob. The padding doesn't depend on the square
+// size: the subtree width is capped by the smallest square the blob fits in,
+// not by the square it is placed in.
+func BlobSharePadding(cursor, blobShareLen, subtreeRootThreshold int) (padding, newCursor int) {
+	start := BlobStartIndex(cursor, blobShareLen, subtreeRootThreshold)
+	return start - cursor, start + blobShareLen
+}
+
+// BlobPlacement describes where a blob is placed in a square.
+type BlobPlacement struct {
+	// Start is the index of the first share of the blob.
+	Start int
+	// Padding is the number of padding shares that precede the blob.
+	Padding int
+	// Shares is the number of shares the blob spans.
+	Shares int
+}
+
+// End returns the index after the last share of the blob.
+func (p BlobPlacement) End() int {
+	return p.Start + p.Shares
+}
+
+// PlaceBlobs returns where blobs that span blobShareLens shares are placed, in
+// order, when the first one may start at cursor. Export places the blobs of a
+// square in this way after sorting them by namespace, starting at the index
+// after the compact shares. The number of shares used by the blobs, including
+// padding, is the End of the last placement minus cursor.
+func PlaceBlobs(cursor, subtreeRootThreshold int, blobShareLens ...int) []BlobPlacement {
+	placements := make([]BlobPlacement, len(blobShareLens))
+	for i, blobShareLen := range blobShareLens {
+		padding, end := BlobSharePadding(cursor, blobShareLen, subtreeRootThreshold)
+		placements[i] = BlobPlacement{Start: cursor + padding, Padding: padding, Shares: blobShareLen}
+		cursor = end
+	}
+	return placements
+}
@@ -0,0 +1,158 @@
+package square_test
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/internal/test"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutBuilder(t *testing.T) {
+	const subtreeRootThreshold = 2
+	startIndex := 3
+	blobSizes := []int{100, 5_000, 1_000, 20_000}
+
+	lb, err := square.NewLayoutBuilder(startIndex, subtreeRootThreshold)
+	require.NoError(t, err)
+
+	blobs := make([]*blob.Blob, len(blobSizes))
+	shareLens := make([]int, len(blobSizes))
+	starts := make([]uint32, len(blobSizes))
+	for i, size := range blobSizes {
+		blobs[i] = blob.New(test.DefaultTestNamespace, test.RandomBytes(size), shares.ShareVersionZero)
+		shareLens[i] = shares.SparseSharesNeeded(uint32(size))
+		start, err := lb.AppendBlob(blobs[i])
+		require.NoError(t, err)
+		starts[i] = uint32(start)
+	}
+
+	wantUsed, wantStarts := inclusion.BlobSharesUsedNonInteractiveDefaults(startIndex, subtreeRootThreshold, shareLens...)
+	assert.Equal(t, wantStarts, starts)
+	assert.Equal(t, wantUsed, lb.NumShares())
+
+	layout, err := lb.Export()
+	require.NoError(t, err)
+	require.Len(t, layout, lb.NumShares())
+
+	firstBlobOffset := int(starts[0]) - startIndex
+	for _, share := range layout[:firstBlobOffset] {
+		ns, err := share.Namespace()
+		require.NoError(t, err)
+		assert.True(t, ns.IsPrimaryReservedPadding())
+	}
+	parsed, err := shares.ParseBlobs(layout)
+	require.NoError(t, err)
+	assert.Equal(t, blobs, parsed)
+
+	for i, start := range starts {
+		offset := int(start) - startIndex
+		isStart, err := layout[offset].IsSequenceStart()
+		require.NoError(t, err)
+		assert.True(t, isStart)
+		seqLen, err := layout[offset].SequenceLen()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(blobSizes[i]), seqLen)
+	}
+}
+
+func TestLayoutBuilderInvalidArgs(t *testing.T) {
+	_, err := square.NewLayoutBuilder(-1, 64)
+	assert.Error(t, err)
+	_, err = square.NewLayoutBuilder(0, 0)
+	assert.Error(t, err)
+
+	lb, err := square.NewLayoutBuilder(0, 64)
+	require.NoError(t, err)
+	_, err = lb.AppendBlob(blob.New(namespace.RandomBlobNamespace(rand.New(rand.NewSource(1))), nil, shares.ShareVersionZero))
+	assert.Error(t, err)
+	assert.Equal(t, 0, lb.NumShares())
+}
+
+func TestSubtreeWidthAndBlobStartIndex(t *testing.T) {
+	type testCase struct {
+		currentIndex         int
+		shareCount           int
+		subtreeRootThreshold int
+		wantWidth            int
+		wantStart            int
+	}
+	testCases := []testCase{
+		{currentIndex: 0, shareCount: 1, subtreeRootThreshold: 64, wantWidth: 1, wantStart: 0},
+		{currentIndex: 3, shareCount: 64, subtreeRootThreshold: 64, wantWidth: 1, wantStart: 3},
+		{currentIndex: 3, shareCount: 65, subtreeRootThreshold: 64, wantWidth: 2, wantStart: 4},
+		{currentIndex: 5, shareCount: 129, subtreeRootThreshold: 64, wantWidth: 4, wantStart: 8},
+		{currentIndex: 8, shareCount: 129, subtreeRootThreshold: 64, wantWidth: 4, wantStart: 8},
+		{currentIndex: 17, shareCount: 1000, subtreeRootThreshold: 64, wantWidth: 16, wantStart: 32},
+		// the width is capped by the minimum square size of the blob
+		{currentIndex: 1, shareCount: 10, subtreeRootThreshold: 1, wantWidth: 4, wantStart: 4},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.wantWidth, square.SubtreeWidth(tc.shareCount, tc.subtreeRootThreshold), tc)
+		assert.Equal(t, tc.wantStart, square.BlobStartIndex(tc.currentIndex, tc.shareCount, tc.subtreeRootThreshold), tc)
+	}
+}
+
+func TestPlaceBlobsMatchesExport(t *testing.T) {
+	const maxSquareSize = 16
+	rng := rand.New(rand.NewSource(1))
+	namespaces := []namespace.Namespace{
+		namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize)),
+		namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize)),
+		namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize)),
+	}
+	// blob share lengths around the width of a row, including blobs that
+	// span several rows
+	shareLens := []int{1, 2, 3, maxSquareSize - 1, maxSquareSize, maxSquareSize + 1, 2*maxSquareSize + 3, 70}
+
+	for _, subtreeRootThreshold := range []int{1, 2, 4, defaultSubtreeRootThreshold} {
+		for i := 0; i < 20; i++ {
+			builder, err := square.NewBuilder(maxSquareSize, subtreeRootThreshold, test.GenerateTxs(10, 600, rng.Intn(10))...)
+			require.NoError(t, err)
+			for {
+				shareLen := shareLens[rng.Intn(len(shareLens))]
+				ns := namespaces[rng.Intn(len(namespaces))]
+				rawTx := test.GenerateBlobTxWithNamespace([]namespace.Namespace{ns}, []int{shares.AvailableBytesFromSparseShares(shareLen)})
+				blobTx, _ := blob.UnmarshalBlobTx(rawTx)
+				if !builder.AppendBlobTx(blobTx) {
+					break
+				}
+			}
+			cursor := builder.TxCounter.Size() + builder.PfbCounter.Size()
+			_, blobRanges, err := builder.ShareRanges()
+			require.NoError(t, err)
+
+			// Export places the blobs sorted by namespace, i.e. in the order of
+			// their start index
+			var got []shares.Range
+			for _, ranges := range blobRanges {
+				got = append(got, ranges...)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Start < got[j].Start })
+			lens := make([]int, len(got))
+			for i, r := range got {
+				lens[i] = r.End - r.Start
+			}
+
+			placements := square.PlaceBlobs(cursor, subtreeRootThreshold, lens...)
+			end := cursor
+			for i, placement := range placements {
+				assert.Equal(t, got[i].Start, placement.Start)
+				assert.Equal(t, got[i].End, placement.End())
+				assert.Equal(t, placement.Start-end, placement.Padding)
+				padding, newCursor := square.BlobSharePadding(end, lens[i], subtreeRootThreshold)
+				assert.Equal(t, placement.Padding, padding)
+				assert.Equal(t, placement.End(), newCursor)
+				end = placement.End()
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package square
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/params"
+)
+
+// ConstructWithParams is like Construct but takes the square size upper bound
+// and subtree root threshold from p, e.g. the params.GetParams of the app
+// version of the block. It also returns a *TxError wrapping
+// blob.ErrUnsupportedShareVersion if a blob uses a share version that p
+// doesn't support, and an error if p fails Validate.
+func ConstructWithParams(txs [][]byte, p params.Params) (Square, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	for idx, tx := range txs {
+		if err := checkShareVersions(tx, p); err != nil {
+			return nil, &TxError{TxIndex: idx, Err: err}
+		}
+	}
+	return Construct(txs, p.SquareSizeUpperBound, p.SubtreeRootThreshold)
+}
+
+// BuildWithParams is like Build but takes the square size upper bound and
+// subtree root threshold from p. Blob transactions with a blob of a share
+// version that p doesn't support are dropped, like other invalid blob
+// transactions, and reported to OnDropped wrapping
+// blob.ErrUnsupportedShareVersion. It returns an error if p fails Validate.
+func BuildWithParams(txs [][]byte, p params.Params, opts ...BuildOption) (Square, [][]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+	config := newBuildConfig(opts)
+	supported := make([][]byte, 0, len(txs))
+	// indexes maps the indexes of supported to those of txs
+	indexes := make([]int, 0, len(txs))
+	for idx, tx := range txs {
+		if err := checkShareVersions(tx, p); err != nil {
+			if config.onDropped != nil {
+				config.onDropped(&TxError{TxIndex: idx, Err: err})
+			}
+			continue
+		}
+		supported = append(supported, tx)
+		indexes = append(indexes, idx)
+	}
+	if config.onDropped != nil {
+		onDropped := config.onDropped
+		opts = append(opts, OnDropped(func(err *TxError) {
+			onDropped(&TxError{TxIndex: indexes[err.TxIndex], Err: err.Err})
+		}))
+	}
+	return Build(supported, p.SquareSizeUpperBound, p.SubtreeRootThreshold, opts...)
+}
+
+// checkShareVersions returns an error wrapping blob.ErrUnsupportedShareVersion
+// if tx is a blob transaction with a blob of a share version that p doesn't
+// support.
+func checkShareVersions(tx []byte, p params.Params) error {
+	blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
+	if !isBlobTx {
+		return nil
+	}
+	for i, b := range blobTx.Blobs {
+		if b.ShareVersion > math.MaxUint8 || !p.SupportsShareVersion(uint8(b.ShareVersion)) {
+			return fmt.Errorf("%w: blob %d has share version %d but app version %d supports %v", blob.ErrUnsupportedShareVersion, i, b.ShareVersion, p.AppVersion, p.SupportedShareVersions)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package square_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/internal/test"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/params"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConstructWithParams(t *testing.T) {
+	latest, err := params.GetParams(params.LatestAppVersion)
+	require.NoError(t, err)
+	txs := generateOrderedTxs(20, 20, 2, 2000)
+
+	// the latest params are the loose arguments used everywhere today
+	want, err := square.Construct(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	got, err := square.ConstructWithParams(txs, latest)
+	require.NoError(t, err)
+	assert.True(t, want.Equals(got))
+
+	wantSquare, wantTxs, err := square.Build(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	gotSquare, gotTxs, err := square.BuildWithParams(txs, latest)
+	require.NoError(t, err)
+	assert.True(t, wantSquare.Equals(gotSquare))
+	assert.Equal(t, wantTxs, gotTxs)
+
+	_, err = square.ConstructWithParams(txs, params.Params{})
+	assert.Error(t, err)
+	_, _, err = square.BuildWithParams(txs, params.Params{})
+	assert.Error(t, err)
+}
+
+func TestConstructWithParamsShareVersions(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	v1Blob, err := blob.NewV1(ns1, bytes.Repeat([]byte{0xAB}, 1000), bytes.Repeat([]byte{0x5}, blob.SignerSize))
+	require.NoError(t, err)
+	v1BlobTx, err := blob.MarshalBlobTx(test.MockPFB([]uint32{1000}), v1Blob)
+	require.NoError(t, err)
+	txs := append(generateOrderedTxs(5, 3, 1, 1000), v1BlobTx)
+
+	v2, err := params.GetParams(2)
+	require.NoError(t, err)
+	_, err = square.ConstructWithParams(txs, v2)
+	var txErr *square.TxError
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, len(txs)-1, txErr.TxIndex)
+	assert.ErrorIs(t, err, blob.ErrUnsupportedShareVersion)
+
+	// Build drops the blob tx instead
+	_, included, err := square.BuildWithParams(txs, v2)
+	require.NoError(t, err)
+	assert.Equal(t, txs[:len(txs)-1], included)
+
+	// dropped txs are reported by their index in txs
+	emptyBlobTx, err := proto.Marshal(&blob.BlobTx{Tx: []byte("tx"), Blobs: []*blob.Blob{blob.New(ns1, nil, 0)}, TypeId: blob.ProtoBlobTxTypeID})
+	require.NoError(t, err)
+	var dropped []*square.TxError
+	_, _, err = square.BuildWithParams([][]byte{v1BlobTx, emptyBlobTx}, v2, square.OnDropped(func(err *square.TxError) {
+		dropped = append(dropped, err)
+	}))
+	require.NoError(t, err)
+	require.Len(t, dropped, 2)
+	assert.Equal(t, 0, dropped[0].TxIndex)
+	assert.ErrorIs(t, dropped[0], blob.ErrUnsupportedShareVersion)
+	assert.Equal(t, 1, dropped[1].TxIndex)
+	assert.ErrorIs(t, dropped[1], square.ErrMalformedBlobTx)
+
+	v3, err := params.GetParams(3)
+	require.NoError(t, err)
+	_, err = square.ConstructWithParams(txs, v3)
+	require.NoError(t, err)
+	_, included, err = square.BuildWithParams(txs, v3)
+	require.NoError(t, err)
+	assert.Equal(t, txs, included)
+}
@@ -0,0 +1,45 @@
+package square
+
+// ConstructSession constructs squares of the same max square size and subtree
+// root threshold over and over again, e.g. while a block proposer tunes the
+// transactions of a proposal. It keeps its builder, compact share splitters
+// and the shares of the square between calls so that a construction allocates
+// little more than what parsing the transactions requires. The squares are
+// identical to those of the package level functions.
+//
+// A square returned by a session is only valid until the next call to the
+// session, which overwrites its shares: use slices.Clone to keep it. A session
+// must not be used concurrently.
+type ConstructSession struct {
+	builder *Builder
+}
+
+// NewConstructSession returns a session that constructs squares of at most
+// maxSquareSize rows and columns. It returns an error wrapping
+// ErrInvalidSquareSize if maxSquareSize is not a positive power of two.
+func NewConstructSession(maxSquareSize, subtreeRootThreshold int) (*ConstructSession, error) {
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		return nil, err
+	}
+	builder.reuseSquare = true
+	return &ConstructSession{builder: builder}, nil
+}
+
+// Construct is like the package level Construct with the max square size and
+// subtree root threshold of the session.
+func (s *ConstructSession) Construct(txs [][]byte, opts ...BuildOption) (Square, error) {
+	s.builder.reset()
+	s.builder.commitments = newBuildConfig(opts).commitments
+	if err := s.builder.appendOrdered(txs); err != nil {
+		return nil, err
+	}
+	return s.builder.Export()
+}
+
+// Build is like the package level Build with the max square size and subtree
+// root threshold of the session. The returned transactions are not reused.
+func (s *ConstructSession) Build(txs [][]byte, opts ...BuildOption) (Square, [][]byte, error) {
+	s.builder.reset()
+	return buildWithPolicy(s.builder, txs, nil, opts)
+}
@@ -0,0 +1,126 @@
+package square_test
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomTxSet returns normal txs followed by blob txs that may or may not fit
+// in a square of maxSquareSize.
+func randomTxSet(rng *rand.Rand, maxSquareSize int) [][]byte {
+	var txs [][]byte
+	for i := rng.Intn(30); i > 0; i-- {
+		txs = append(txs, testutil.RandomNormalTx(rng, 1+rng.Intn(2000)))
+	}
+	maxBlobSize := maxSquareSize * maxSquareSize * 100
+	for _, tx := range testutil.RandomBlobTxs(rng, rng.Intn(30), 1+rng.Intn(3), testutil.SizeRange{Min: 1, Max: maxBlobSize}) {
+		txs = append(txs, tx.Tx)
+	}
+	return txs
+}
+
+func TestConstructSession(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, maxSquareSize := range []int{1, 8, 64} {
+		for _, subtreeRootThreshold := range []int{1, defaultSubtreeRootThreshold} {
+			session, err := square.NewConstructSession(maxSquareSize, subtreeRootThreshold)
+			require.NoError(t, err)
+			for i := 0; i < 20; i++ {
+				txs := randomTxSet(rng, maxSquareSize)
+
+				want, wantErr := square.Construct(txs, maxSquareSize, subtreeRootThreshold)
+				got, err := session.Construct(txs)
+				if wantErr != nil {
+					assert.Equal(t, wantErr, err)
+				} else {
+					require.NoError(t, err)
+					assert.True(t, want.Equals(got), "max square size %d threshold %d", maxSquareSize, subtreeRootThreshold)
+				}
+
+				// Build drops the txs that don't fit
+				rng.Shuffle(len(txs), func(i, j int) { txs[i], txs[j] = txs[j], txs[i] })
+				var wantDropped, gotDropped []*square.TxError
+				want, wantTxs, err := square.Build(txs, maxSquareSize, subtreeRootThreshold, square.OnDropped(func(err *square.TxError) {
+					wantDropped = append(wantDropped, err)
+				}))
+				require.NoError(t, err)
+				got, gotTxs, err := session.Build(txs, square.OnDropped(func(err *square.TxError) {
+					gotDropped = append(gotDropped, err)
+				}))
+				require.NoError(t, err)
+				assert.True(t, want.Equals(got))
+				assert.Equal(t, wantTxs, gotTxs)
+				assert.Equal(t, wantDropped, gotDropped)
+			}
+		}
+	}
+
+	_, err := square.NewConstructSession(3, defaultSubtreeRootThreshold)
+	assert.ErrorIs(t, err, square.ErrInvalidSquareSize)
+}
+
+func TestConstructSessionReusesSquare(t *testing.T) {
+	session, err := square.NewConstructSession(defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(1))
+	first := testutil.RandomSquare(rng, 0.5, 32, defaultSubtreeRootThreshold)
+	second := testutil.RandomSquare(rng, 0.5, 32, defaultSubtreeRootThreshold)
+
+	got, err := session.Construct(first.Txs)
+	require.NoError(t, err)
+	kept := slices.Clone(got)
+	assert.True(t, first.Square.Equals(kept))
+
+	// the next construction overwrites the shares of the previous square
+	got, err = session.Construct(second.Txs)
+	require.NoError(t, err)
+	assert.True(t, second.Square.Equals(got))
+	assert.True(t, first.Square.Equals(kept))
+
+	// an empty square doesn't depend on the shares of the previous square
+	got, err = session.Construct(nil)
+	require.NoError(t, err)
+	assert.True(t, square.EmptySquare().Equals(got))
+	got, err = session.Construct([][]byte{bytes.Repeat([]byte{1}, 10)})
+	require.NoError(t, err)
+	want, err := square.Construct([][]byte{bytes.Repeat([]byte{1}, 10)}, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.True(t, want.Equals(got))
+}
+
+// BenchmarkConstructSession constructs 100 squares from different tx sets per
+// iteration, as a block proposer tuning a proposal would.
+func BenchmarkConstructSession(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	txSets := make([][][]byte, 100)
+	for i := range txSets {
+		txSets[i] = testutil.RandomSquare(rng, 0.5, defaultMaxSquareSize, defaultSubtreeRootThreshold).Txs
+	}
+	b.Run("stateless", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, txs := range txSets {
+				_, err := square.Construct(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				require.NoError(b, err)
+			}
+		}
+	})
+	b.Run("session", func(b *testing.B) {
+		b.ReportAllocs()
+		session, err := square.NewConstructSession(defaultMaxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(b, err)
+		for i := 0; i < b.N; i++ {
+			for _, txs := range txSets {
+				_, err := session.Construct(txs)
+				require.NoError(b, err)
+			}
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package square
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 
@@ -15,22 +17,164 @@ import (
 // in the square and which have all PFBs trailing regular transactions. Note, this function does
 // not check the underlying validity of the transactions.
 // Errors should not occur and would reflect a violation in an invariant.
-func Build(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (Square, [][]byte, error) {
-	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
+func Build(txs [][]byte, maxSquareSize, subtreeRootThreshold int, opts ...BuildOption) (Square, [][]byte, error) {
+	return ConstructWithPolicy(txs, maxSquareSize, subtreeRootThreshold, nil, opts...)
+}
+
+var (
+	// ErrSquareSizeExceeded is returned when a transaction doesn't fit in the
+	// square next to the transactions before it.
+	ErrSquareSizeExceeded = errors.New("transactions exceed the square size")
+	// ErrTxTooLarge is returned when a transaction doesn't even fit in an
+	// empty square of the maximum size, so it can never be included.
+	ErrTxTooLarge = errors.New("tx is too large to fit in a square")
+	// ErrMalformedBlobTx is returned when a blob transaction fails
+	// blob.BlobTx.Validate. It is blob.ErrMalformedBlobTx so errors.Is works
+	// regardless of which package the error came from.
+	ErrMalformedBlobTx = blob.ErrMalformedBlobTx
+	// ErrTxOrder is returned by Construct when a normal transaction follows a
+	// blob transaction.
+	ErrTxOrder = errors.New("normal tx can not be appended after blob tx")
+	// ErrDecodeTx is returned by Deconstruct when a transaction in the PFB
+	// namespace isn't a wrapped PFB or the PFBDecoder fails to decode it.
+	ErrDecodeTx = errors.New("failed to decode tx")
+	// ErrIndexOutOfRange is returned when a tx, PFB, blob or share index
+	// doesn't match a tx, PFB, blob or share.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrInvalidPolicyOrder is returned by ConstructWithPolicy when the policy
+	// returns an index that is out of range or returns an index twice.
+	ErrInvalidPolicyOrder = errors.New("invalid policy order")
+)
+
+// BuildOption configures Build, BuildWithParams, ConstructWithPolicy,
+// Construct, BuildFixed and ConstructBatch.
+type BuildOption func(*buildConfig)
+
+type buildConfig struct {
+	onDropped   func(*TxError)
+	commitments *commitmentVerifier
+}
+
+// newBuildConfig returns the configuration set by opts.
+func newBuildConfig(opts []BuildOption) buildConfig {
+	var config buildConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// OnDropped makes the transactions that aren't included in the square be
+// reported to fn, e.g. so that a block proposer can log them. It is called for
+// every dropped transaction, in the order they were allocated, with a
+// *TxError holding the index of the transaction in txs and wrapping the reason
+// it was dropped: ErrSquareSizeExceeded, ErrTxTooLarge or ErrMalformedBlobTx.
+func OnDropped(fn func(*TxError)) BuildOption {
+	return func(c *buildConfig) {
+		c.onDropped = fn
+	}
+}
+
+// BuildFixed is like Build but builds a square of exactly squareSize rows and
+// columns, filling the shares after the last blob with tail padding, instead
+// of the smallest square the transactions fit in. Every transaction must fit:
+// if one doesn't, a *TxError with the index of that transaction wrapping
+// ErrSquareSizeExceeded, or ErrTxTooLarge if it doesn't fit in an empty square
+// of squareSize, is returned rather than the transaction being dropped. An
+// invalid blob transaction is reported in a *TxError wrapping
+// ErrMalformedBlobTx, or ErrCommitmentMismatch if WithCommitmentVerification
+// is passed. OnDropped has no effect since no transaction is dropped.
+// The returned transactions are txs with all blob transactions trailing the
+// normal transactions, which is the order Deconstruct returns them in.
+func BuildFixed(txs [][]byte, squareSize, subtreeRootThreshold int, opts ...BuildOption) (Square, [][]byte, error) {
+	builder, err := NewBuilder(squareSize, subtreeRootThreshold)
 	if err != nil {
 		return nil, nil, err
 	}
+	builder.fixedSquareSize = squareSize
+	builder.commitments = newBuildConfig(opts).commitments
 	normalTxs := make([][]byte, 0, len(txs))
 	blobTxs := make([][]byte, 0, len(txs))
-	for _, tx := range txs {
+	for idx, tx := range txs {
+		blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
+		if isBlobTx {
+			if !builder.AppendBlobTx(blobTx) {
+				return nil, nil, &TxError{TxIndex: idx, Err: builder.blobTxError(blobTx)}
+			}
+			blobTxs = append(blobTxs, tx)
+		} else {
+			if !builder.AppendTx(tx) {
+				return nil, nil, &TxError{TxIndex: idx, Err: builder.txError(tx)}
+			}
+			normalTxs = append(normalTxs, tx)
+		}
+	}
+	square, err := builder.Export()
+	if err != nil {
+		return nil, nil, err
+	}
+	return square, append(normalTxs, blobTxs...), nil
+}
+
+// TxInfo describes a candidate transaction to a Policy.
+type TxInfo struct {
+	// Size is the size of the transaction in bytes.
+	Size int
+	// IsBlobTx is true if the transaction is a blob transaction.
+	IsBlobTx bool
+	// BlobSizes are the sizes in bytes of the blobs of a blob transaction.
+	BlobSizes []uint32
+}
+
+// Policy decides in which order the transactions described by candidates are
+// allocated to a square. It returns the indexes of the candidates in that
+// order. Candidates that are omitted are not included in the square.
+type Policy func(candidates []TxInfo) []int
+
+// ConstructWithPolicy is like Build but allocates the transactions in the
+// order returned by policy instead of the order of txs. Every transaction is
+// appended to the square if it fits, so a transaction that doesn't fit is
+// dropped rather than ending the square. The returned transactions are the
+// ones included in the square with all blob transactions trailing the normal
+// transactions, each in the order they were allocated, which is also the
+// order of their PFBs in the square. A nil policy allocates the transactions
+// in the order of txs, exactly as Build does. It returns an error if policy
+// returns an index that is out of range or returns an index twice, wrapping
+// ErrInvalidPolicyOrder. See OnDropped to learn which transactions are
+// dropped.
+func ConstructWithPolicy(txs [][]byte, maxSquareSize, subtreeRootThreshold int, policy Policy, opts ...BuildOption) (Square, [][]byte, error) {
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buildWithPolicy(builder, txs, policy, opts)
+}
+
+// buildWithPolicy appends txs to the empty builder in the order returned by
+// policy and exports the square. See ConstructWithPolicy.
+func buildWithPolicy(builder *Builder, txs [][]byte, policy Policy, opts []BuildOption) (Square, [][]byte, error) {
+	config := newBuildConfig(opts)
+	builder.commitments = config.commitments
+	order, err := policyOrder(txs, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalTxs := make([][]byte, 0, len(order))
+	blobTxs := make([][]byte, 0, len(order))
+	for _, idx := range order {
+		tx := txs[idx]
 		blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
 		if isBlobTx {
 			if builder.AppendBlobTx(blobTx) {
 				blobTxs = append(blobTxs, tx)
+			} else if config.onDropped != nil {
+				config.onDropped(&TxError{TxIndex: idx, Err: builder.blobTxError(blobTx)})
 			}
 		} else {
 			if builder.AppendTx(tx) {
 				normalTxs = append(normalTxs, tx)
+			} else if config.onDropped != nil {
+				config.onDropped(&TxError{TxIndex: idx, Err: builder.txError(tx)})
 			}
 		}
 	}
@@ -38,17 +182,57 @@ func Build(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (Square, [][]b
 	return square, append(normalTxs, blobTxs...), err
 }
 
+// policyOrder returns the order in which policy allocates txs.
+func policyOrder(txs [][]byte, policy Policy) ([]int, error) {
+	if policy == nil {
+		order := make([]int, len(txs))
+		for i := range order {
+			order[i] = i
+		}
+		return order, nil
+	}
+
+	candidates := make([]TxInfo, len(txs))
+	for i, tx := range txs {
+		candidates[i] = TxInfo{Size: len(tx)}
+		if blobTx, isBlobTx := blob.UnmarshalBlobTx(tx); isBlobTx {
+			candidates[i].IsBlobTx = true
+			candidates[i].BlobSizes = make([]uint32, len(blobTx.Blobs))
+			for j, b := range blobTx.Blobs {
+				candidates[i].BlobSizes[j] = uint32(len(b.Data))
+			}
+		}
+	}
+	order := policy(candidates)
+	seen := make([]bool, len(txs))
+	for _, idx := range order {
+		if idx < 0 || idx >= len(txs) {
+			return nil, fmt.Errorf("%w: policy returned index %d but there are %d txs", ErrInvalidPolicyOrder, idx, len(txs))
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("%w: policy returned index %d more than once", ErrInvalidPolicyOrder, idx)
+		}
+		seen[idx] = true
+	}
+	return order, nil
+}
+
 // Construct takes the exact list of ordered transactions and constructs a square, validating that
 //   - all blobTxs are ordered after non-blob transactions
 //   - the transactions don't collectively exceed the maxSquareSize.
 //
 // Note that this function does not check the underlying validity of
-// the transactions.
-func Construct(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (Square, error) {
-	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold, txs...)
+// the transactions, unless WithCommitmentVerification is passed. OnDropped
+// has no effect since no transaction is dropped.
+func Construct(txs [][]byte, maxSquareSize, subtreeRootThreshold int, opts ...BuildOption) (Square, error) {
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold)
 	if err != nil {
 		return nil, err
 	}
+	builder.commitments = newBuildConfig(opts).commitments
+	if err := builder.appendOrdered(txs); err != nil {
+		return nil, err
+	}
 	return builder.Export()
 }
 
@@ -57,89 +241,261 @@ func Construct(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (Square, e
 //
 // This method uses the wrapped pfbs in the PFB namespace to identify and
 // decode the blobs. Data that may be included in the square but isn't
-// recognised by the square construction algorithm will be ignored
+// recognised by the square construction algorithm will be ignored. Errors are
+// a *DeconstructError locating the failure in the square.
 func Deconstruct(s Square, decoder PFBDecoder) ([][]byte, error) {
+	deconstructed, err := DeconstructWithMapping(s, decoder)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([][]byte, len(deconstructed))
+	for i, dtx := range deconstructed {
+		if dtx.Blobs == nil {
+			txs[i] = dtx.Tx
+			continue
+		}
+		txs[i], err = blob.MarshalBlobTx(dtx.Tx, dtx.Blobs...)
+		if err != nil {
+			return nil, &DeconstructError{Section: SectionBlobs, TxIndex: i, Err: err}
+		}
+	}
+	return txs, nil
+}
+
+var (
+	// ErrShareIndexPadding is returned when a share index of a wrapped PFB
+	// points at a padding share.
+	ErrShareIndexPadding = errors.New("share index points at a padding share")
+	// ErrShareIndexNotSequenceStart is returned when a share index of a
+	// wrapped PFB points at a share that doesn't start a blob.
+	ErrShareIndexNotSequenceStart = errors.New("share index doesn't point at the first share of a blob")
+)
+
+// TxError is an error of the transaction at TxIndex in the list of block
+// transactions.
+type TxError struct {
+	TxIndex int
+	Err     error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("tx index %d: %v", e.TxIndex, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// ReservedNamespaceError is returned, wrapped in a *TxError naming the blob
+// transaction, when the blob at BlobIndex of a blob transaction uses a
+// reserved namespace. Err wraps blob.ErrReservedNamespace.
+type ReservedNamespaceError struct {
+	BlobIndex int
+	Namespace namespace.Namespace
+	Err       error
+}
+
+func (e *ReservedNamespaceError) Error() string {
+	return fmt.Sprintf("blob %d uses reserved namespace %s: %v", e.BlobIndex, e.Namespace, e.Err)
+}
+
+func (e *ReservedNamespaceError) Unwrap() error {
+	return e.Err
+}
+
+// DeconstructedTx is a block transaction recovered from a square.
+type DeconstructedTx struct {
+	// Tx is the transaction. For a blob transaction it is the PFB without the
+	// blobs.
+	Tx []byte
+	// Blobs are the blobs paid for by a PFB in the order of its share
+	// indexes, which is the order the blobs were submitted in. They are nil
+	// for a normal transaction.
+	Blobs []*blob.Blob
+}
+
+// DeconstructSection is the part of a square that a DeconstructError
+// concerns.
+type DeconstructSection int
+
+const (
+	// SectionTxs is the compact shares of the normal transactions.
+	SectionTxs DeconstructSection = iota
+	// SectionPFBs is the compact shares of the wrapped PFBs.
+	SectionPFBs
+	// SectionBlobs is the sparse shares of the blobs.
+	SectionBlobs
+)
+
+func (s DeconstructSection) String() string {
+	switch s {
+	case SectionTxs:
+		return "txs"
+	case SectionPFBs:
+		return "PFBs"
+	case SectionBlobs:
+		return "blobs"
+	default:
+		return fmt.Sprintf("DeconstructSection(%d)", int(s))
+	}
+}
+
+// DeconstructError is returned by Deconstruct and DeconstructWithMapping. It
+// locates the failure in the square; Err is the underlying error, which for a
+// failure of the PFBDecoder wraps ErrDecodeTx and the error it returned.
+type DeconstructError struct {
+	Section DeconstructSection
+	// TxIndex is the index of the transaction in the list of block
+	// transactions, or -1 if the failure concerns a whole section, e.g. the
+	// compact shares of the section can't be parsed.
+	TxIndex int
+	// Range is the range of shares that was being parsed: the shares of the
+	// section, of the wrapped PFB or of the blob. The index of a
+	// *shares.ParseError in Err is relative to Range.Start. Range is empty if
+	// the failure doesn't concern specific shares.
+	Range shares.Range
+	Err   error
+}
+
+func (e *DeconstructError) Error() string {
+	if e.TxIndex < 0 {
+		return fmt.Sprintf("deconstructing %s in shares [%d, %d): %v", e.Section, e.Range.Start, e.Range.End, e.Err)
+	}
+	return fmt.Sprintf("deconstructing %s of tx index %d in shares [%d, %d): %v", e.Section, e.TxIndex, e.Range.Start, e.Range.End, e.Err)
+}
+
+func (e *DeconstructError) Unwrap() error {
+	return e.Err
+}
+
+// DeconstructWithMapping is like Deconstruct but returns every PFB together
+// with its blobs instead of encoding them as a blob transaction. The blobs are
+// located through the share indexes of the wrapped PFB. Errors are a
+// *DeconstructError. A PFB whose number of blobs doesn't match its share
+// indexes wraps blob.ErrShareIndexCountMismatch and a share index that points
+// at padding or into the middle of a blob wraps ErrShareIndexPadding or
+// ErrShareIndexNotSequenceStart.
+func DeconstructWithMapping(s Square, decoder PFBDecoder) ([]DeconstructedTx, error) {
 	if s.IsEmpty() {
-		return [][]byte{}, nil
+		return []DeconstructedTx{}, nil
 	}
 
 	// Work out which range of shares are non-pfb transactions
 	// and which ones are pfb transactions
 	txShareRange, err := shares.GetShareRangeForNamespace(s, namespace.TxNamespace)
 	if err != nil {
-		return nil, err
+		return nil, &DeconstructError{Section: SectionTxs, TxIndex: -1, Range: shares.NewRange(0, len(s)), Err: err}
 	}
 	if txShareRange.Start != 0 {
-		return nil, fmt.Errorf("expected txs to start at index 0, but got %d", txShareRange.Start)
+		return nil, &DeconstructError{Section: SectionTxs, TxIndex: -1, Range: txShareRange, Err: fmt.Errorf("expected txs to start at index 0, but got %d", txShareRange.Start)}
 	}
 
 	wpfbShareRange, err := shares.GetShareRangeForNamespace(s[txShareRange.End:], namespace.PayForBlobNamespace)
 	if err != nil {
-		return nil, err
+		return nil, &DeconstructError{Section: SectionPFBs, TxIndex: -1, Range: shares.NewRange(txShareRange.End, len(s)), Err: err}
+	}
+
+	// Parse the non-pfb transactions
+	txs, err := shares.ParseTxs(s[txShareRange.Start:txShareRange.End])
+	if err != nil {
+		return nil, &DeconstructError{Section: SectionTxs, TxIndex: -1, Range: txShareRange, Err: err}
+	}
+	deconstructed := make([]DeconstructedTx, len(txs))
+	for i, tx := range txs {
+		deconstructed[i] = DeconstructedTx{Tx: tx}
 	}
 
 	// If there are no pfb transactions, then we can just return the txs
 	if wpfbShareRange.IsEmpty() {
-		return shares.ParseTxs(s[txShareRange.Start:txShareRange.End])
+		return deconstructed, nil
 	}
+	wpfbShareRange = wpfbShareRange.Shift(txShareRange.End)
 
 	// We expect pfb transactions to come directly after non-pfb transactions
-	if wpfbShareRange.Start != 0 {
-		return nil, fmt.Errorf("expected PFBs to start directly after non PFBs at index %d, but got %d", txShareRange.End, wpfbShareRange.Start)
+	if wpfbShareRange.Start != txShareRange.End {
+		return nil, &DeconstructError{Section: SectionPFBs, TxIndex: -1, Range: wpfbShareRange, Err: fmt.Errorf("expected PFBs to start directly after non PFBs at index %d, but got %d", txShareRange.End, wpfbShareRange.Start)}
 	}
-	wpfbShareRange.Add(txShareRange.End)
 
-	// Parse both txs
-	txs, err := shares.ParseTxs(s[txShareRange.Start:txShareRange.End])
+	wpfbs, err := shares.ParseTxsWithRanges(s[wpfbShareRange.Start:wpfbShareRange.End])
 	if err != nil {
-		return nil, err
+		return nil, &DeconstructError{Section: SectionPFBs, TxIndex: -1, Range: wpfbShareRange, Err: err}
+	}
+
+	// loop through the wrapped pfbs and locate the blobs they pay for
+	for _, wpfb := range wpfbs {
+		blobs, tx, err := pfbBlobs(s, wpfb.Tx, decoder)
+		if err != nil {
+			err.TxIndex = len(deconstructed)
+			if err.Section == SectionPFBs {
+				err.Range = wpfb.ShareRange.Shift(wpfbShareRange.Start)
+			}
+			return nil, err
+		}
+		deconstructed = append(deconstructed, DeconstructedTx{Tx: tx, Blobs: blobs})
 	}
 
-	wpfbs, err := shares.ParseTxs(s[wpfbShareRange.Start:wpfbShareRange.End])
+	return deconstructed, nil
+}
+
+// pfbBlobs returns the blobs paid for by the wrapped PFB wpfbBytes and the
+// unwrapped PFB. The returned error is in SectionBlobs with the range of the
+// offending blob or in SectionPFBs, in which case the caller sets the range of
+// the wrapped PFB. The caller sets the tx index.
+func pfbBlobs(s Square, wpfbBytes []byte, decoder PFBDecoder) ([]*blob.Blob, []byte, *DeconstructError) {
+	pfbErr := func(err error) *DeconstructError {
+		return &DeconstructError{Section: SectionPFBs, Err: err}
+	}
+	blobErr := func(r shares.Range, err error) *DeconstructError {
+		return &DeconstructError{Section: SectionBlobs, Range: r, Err: err}
+	}
+	wpfb, isWpfb := blob.UnmarshalIndexWrapper(wpfbBytes)
+	if !isWpfb {
+		return nil, nil, pfbErr(fmt.Errorf("%w: expected wrapped PFB", ErrDecodeTx))
+	}
+	blobSizes, err := decoder(wpfb.Tx)
 	if err != nil {
-		return nil, err
+		return nil, nil, pfbErr(fmt.Errorf("%w: %w", ErrDecodeTx, err))
+	}
+	if err := blob.ValidateIndexWrapper(wpfb, s.Size(), len(blobSizes)); err != nil {
+		return nil, nil, pfbErr(fmt.Errorf("invalid wrapped PFB: %w", err))
 	}
 
-	// loop through the wrapped pfbs and generate the original
-	// blobTx that they derive from
-	for i, wpfbBytes := range wpfbs {
-		wpfb, isWpfb := blob.UnmarshalIndexWrapper(wpfbBytes)
-		if !isWpfb {
-			return nil, fmt.Errorf("expected wrapped PFB at index %d", i)
+	blobs := make([]*blob.Blob, len(wpfb.ShareIndexes))
+	for j, shareIndex := range wpfb.ShareIndexes {
+		shareRange := shares.NewRange(int(shareIndex), int(shareIndex)+1)
+		if int(shareIndex) >= len(s) {
+			return nil, nil, blobErr(shareRange, fmt.Errorf("%w: share index %d of blob %d", ErrIndexOutOfRange, shareIndex, j))
 		}
-		if len(wpfb.ShareIndexes) == 0 {
-			return nil, fmt.Errorf("wrapped PFB %d has no blobs attached", i)
+		share := s[shareIndex]
+		if isPadding, err := share.IsPadding(); err != nil {
+			return nil, nil, blobErr(shareRange, err)
+		} else if isPadding {
+			return nil, nil, blobErr(shareRange, fmt.Errorf("%w: share index %d of blob %d", ErrShareIndexPadding, shareIndex, j))
 		}
-		blobSizes, err := decoder(wpfb.Tx)
-		if err != nil {
-			return nil, err
+		if isStart, err := share.IsSequenceStart(); err != nil {
+			return nil, nil, blobErr(shareRange, err)
+		} else if !isStart {
+			return nil, nil, blobErr(shareRange, fmt.Errorf("%w: share index %d of blob %d", ErrShareIndexNotSequenceStart, shareIndex, j))
 		}
-		if len(blobSizes) != len(wpfb.ShareIndexes) {
-			return nil, fmt.Errorf("expected PFB to have %d blob sizes, but got %d", len(wpfb.ShareIndexes), len(blobSizes))
+		shareVersion, err := share.Version()
+		if err != nil {
+			return nil, nil, blobErr(shareRange, err)
 		}
-
-		blobs := make([]*blob.Blob, len(wpfb.ShareIndexes))
-		for j, shareIndex := range wpfb.ShareIndexes {
-			end := int(shareIndex) + shares.SparseSharesNeeded(blobSizes[j])
-			parsedBlobs, err := shares.ParseBlobs(s[shareIndex:end])
-			if err != nil {
-				return nil, err
-			}
-			if len(parsedBlobs) != 1 {
-				return nil, fmt.Errorf("expected to parse a single blob, but got %d", len(blobs))
-			}
-
-			blobs[j] = parsedBlobs[0]
+		shareRange.End = int(shareIndex) + shares.SparseSharesNeededForVersion(blobSizes[j], shareVersion)
+		if shareRange.End > len(s) {
+			return nil, nil, blobErr(shareRange, fmt.Errorf("blob %d of %d bytes starting at share %d exceeds the square", j, blobSizes[j], shareIndex))
 		}
-
-		tx, err := blob.MarshalBlobTx(wpfb.Tx, blobs...)
+		parsedBlobs, err := shares.ParseBlobs(s[shareRange.Start:shareRange.End])
 		if err != nil {
-			return nil, err
+			return nil, nil, blobErr(shareRange, err)
+		}
+		if len(parsedBlobs) != 1 {
+			return nil, nil, blobErr(shareRange, fmt.Errorf("expected to parse a single blob, but got %d", len(parsedBlobs)))
 		}
-		txs = append(txs, tx)
-	}
 
-	return txs, nil
+		blobs[j] = parsedBlobs[0]
+	}
+	return blobs, wpfb.Tx, nil
 }
 
 // TxShareRange returns the range of share indexes that the tx, specified by txIndex, occupies.
@@ -175,6 +531,20 @@ func BlobShareRange(txs [][]byte, txIndex, blobIndex, maxSquareSize, subtreeRoot
 	return shares.NewRange(start, end), nil
 }
 
+// ShareRanges returns the range of share indexes occupied by every tx and, for
+// every PFB, the range of share indexes occupied by each of its blobs. It
+// computes the layout once, so it is equivalent to, but much cheaper than,
+// calling TxShareRange for every tx and BlobShareRange for every blob.
+// blobRanges[i] is nil if the tx at index i isn't a PFB. The ranges are end
+// exclusive.
+func ShareRanges(txs [][]byte, maxSquareSize, subtreeRootThreshold int) (txRanges []shares.Range, blobRanges [][]shares.Range, err error) {
+	builder, err := NewBuilder(maxSquareSize, subtreeRootThreshold, txs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return builder.ShareRanges()
+}
+
 // Square is a 2D square of shares with symmetrical sides that are always a power of 2.
 type Square []shares.Share
 
@@ -191,19 +561,77 @@ func Size(len int) int {
 	return shares.RoundUpPowerOfTwo(int(math.Ceil(math.Sqrt(float64(len)))))
 }
 
+// Row returns the shares of row i of the square. The returned slice aliases
+// the square, but appending to it never overwrites the next row. It panics if
+// i is out of range, like indexing a slice.
+func (s Square) Row(i int) []shares.Share {
+	size := s.Size()
+	if i < 0 || i >= size {
+		panic(fmt.Sprintf("row %d out of range for a square of size %d", i, size))
+	}
+	return s[i*size : (i+1)*size : (i+1)*size]
+}
+
+// Col returns a copy of the shares of column i of the square. It panics if i
+// is out of range, like indexing a slice.
+func (s Square) Col(i int) []shares.Share {
+	size := s.Size()
+	if i < 0 || i >= size {
+		panic(fmt.Sprintf("column %d out of range for a square of size %d", i, size))
+	}
+	col := make([]shares.Share, size)
+	for row := range col {
+		col[row] = s[row*size+i]
+	}
+	return col
+}
+
+// PosFromIndex returns the row and column of the share at index idx of the
+// square in row-major order. It returns an error if idx is out of range.
+func (s Square) PosFromIndex(idx int) (row, col int, err error) {
+	if idx < 0 || idx >= len(s) {
+		return 0, 0, fmt.Errorf("%w: share index %d for a square of %d shares", ErrIndexOutOfRange, idx, len(s))
+	}
+	size := s.Size()
+	return idx / size, idx % size, nil
+}
+
+// IndexFromPos returns the index in row-major order of the share at row and
+// col of the square. It returns an error if row or col is out of range.
+func (s Square) IndexFromPos(row, col int) (int, error) {
+	size := s.Size()
+	if row < 0 || row >= size || col < 0 || col >= size {
+		return 0, fmt.Errorf("%w: position (%d, %d) for a square of size %d", ErrIndexOutOfRange, row, col, size)
+	}
+	return row*size + col, nil
+}
+
 // Equals returns true if two squares are equal
 func (s Square) Equals(other Square) bool {
 	if len(s) != len(other) {
 		return false
 	}
 	for i := range s {
-		if !bytes.Equal(s[i].ToBytes(), other[i].ToBytes()) {
+		if s[i] != other[i] {
 			return false
 		}
 	}
 	return true
 }
 
+// Hash returns the SHA-256 hash of the square size, as a big endian uint64,
+// followed by the shares in row-major order. It is a cheap fingerprint of the
+// square for logging and debugging and is not the data root of the square,
+// which is computed over the erasure coded square using NMTs.
+func (s Square) Hash() []byte {
+	h := sha256.New()
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(s.Size())))
+	for i := range s {
+		h.Write(s[i].ToBytes())
+	}
+	return h.Sum(nil)
+}
+
 // WrappedPFBs returns the wrapped PFBs in a square
 func (s Square) WrappedPFBs() ([][]byte, error) {
 	wpfbShareRange, err := shares.GetShareRangeForNamespace(s, namespace.PayForBlobNamespace)
@@ -213,18 +641,119 @@ func (s Square) WrappedPFBs() ([][]byte, error) {
 	return shares.ParseTxs(s[wpfbShareRange.Start:wpfbShareRange.End])
 }
 
+// Namespaces returns the distinct namespaces of the shares of the square in
+// the order they appear, including the reserved namespaces of txs, PFBs and
+// padding. Shares whose namespace can't be decoded are skipped.
+func (s Square) Namespaces() []namespace.Namespace {
+	var namespaces []namespace.Namespace
+	for i := range s {
+		if len(namespaces) > 0 && s[i].CompareNamespace(namespaces[len(namespaces)-1]) == 0 {
+			continue
+		}
+		ns, err := s[i].Namespace()
+		if err != nil {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// SharesForNamespace returns the range and the shares of the square in
+// namespace ns, or an empty range if ns isn't present. The returned shares
+// alias the square. Reserved namespaces, e.g. namespace.TxNamespace or the
+// padding namespaces, can be queried too. It returns an error wrapping
+// shares.ErrNamespaceOrder if the shares around ns aren't sorted by namespace.
+func (s Square) SharesForNamespace(ns namespace.Namespace) (shares.Range, []shares.Share, error) {
+	return shares.GetSharesByNamespace(s, ns)
+}
+
+// BlobsForNamespace returns the blobs of the square in namespace ns. It
+// returns an empty list if ns isn't present or is only present as padding.
+// Reserved namespaces hold txs or padding rather than blobs so they return an
+// error wrapping blob.ErrReservedNamespace; use SharesForNamespace to read
+// their raw shares.
+func (s Square) BlobsForNamespace(ns namespace.Namespace) ([]*blob.Blob, error) {
+	if ns.IsReserved() {
+		return nil, fmt.Errorf("%w: %s holds no blobs, use SharesForNamespace", blob.ErrReservedNamespace, ns)
+	}
+	_, nsShares, err := s.SharesForNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+	return shares.ParseBlobs(nsShares)
+}
+
+// IsEmpty returns true if every share of the square is a tail padding share,
+// i.e. the square holds no transactions or blobs. This is true for
+// EmptySquare and for a square of a fixed size built without transactions. A
+// square without shares is not a valid square and is not empty.
 func (s Square) IsEmpty() bool {
-	return s.Equals(EmptySquare())
+	if len(s) == 0 {
+		return false
+	}
+	tailPadding := shares.TailPaddingShare()
+	for _, share := range s {
+		if share != tailPadding {
+			return false
+		}
+	}
+	return true
 }
 
-// EmptySquare returns a 1x1 square with a single tail padding share
+// EmptySquare returns a 1x1 square with a single tail padding share. It is the
+// canonical square of a block without transactions, e.g. a genesis block, and
+// is what Construct and Build return when there are no transactions.
 func EmptySquare() Square {
 	return shares.TailPaddingShares(shares.MinShareCount)
 }
 
+// ImportOption configures ImportShares.
+type ImportOption func(*importConfig)
+
+type importConfig struct {
+	validateShares bool
+}
+
+// WithShareValidation makes ImportShares validate the namespace and share
+// version of every share.
+func WithShareValidation() ImportOption {
+	return func(c *importConfig) {
+		c.validateShares = true
+	}
+}
+
+// ImportShares returns the shares of a committed square, e.g. received from a
+// peer, as a Square. The returned square aliases s. It returns an error
+// wrapping ErrInvalidSquareSize if the number of shares isn't the square of a
+// power of two within the maximum supported square size. With
+// WithShareValidation it also returns the *shares.ParseError of the first
+// share with an invalid namespace or share version. It doesn't check that the
+// shares are ordered or laid out like Construct does, use ValidateSquare for
+// that, so it can also be used to inspect malformed squares.
+func ImportShares(s []shares.Share, opts ...ImportOption) (Square, error) {
+	var config importConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := validateShareCount(len(s)); err != nil {
+		return nil, err
+	}
+	if config.validateShares {
+		for _, err := range shares.ValidateShares(s) {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// WriteSquare writes the shares of the provided splitters into a square of
+// squareSize. The shares of txWriter and pfbWriter are followed by reserved
+// padding up to nonReservedStart, the shares of blobWriter, and tail padding.
 func WriteSquare(
-	txWriter, pfbWriter *shares.CompactShareSplitter,
-	blobWriter *shares.SparseShareSplitter,
+	txWriter, pfbWriter, blobWriter shares.Splitter,
 	nonReservedStart, squareSize int,
 ) (Square, error) {
 	totalShares := squareSize * squareSize
@@ -254,7 +783,15 @@ func WriteSquare(
 	copy(square[pfbStartIndex:], pfbShares)
 	if blobWriter.Count() > 0 {
 		copy(square[paddingStartIndex:], padding)
-		copy(square[nonReservedStart:], blobWriter.Export())
+		cursor := nonReservedStart
+		err := blobWriter.ForEachShare(func(share shares.Share) error {
+			square[cursor] = share
+			cursor++
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export blob shares: %w", err)
+		}
 	}
 	if totalShares > endOfLastBlob {
 		copy(square[endOfLastBlob:], shares.TailPaddingShares(totalShares-endOfLastBlob))
@@ -44,3 +44,37 @@ func BenchmarkSquareBuild(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkEstimateSquareSize is comparable to the txCount cases of
+// BenchmarkSquareBuild.
+func BenchmarkEstimateSquareSize(b *testing.B) {
+	for _, txCount := range []int{10, 100, 1000, 10000} {
+		b.Run(fmt.Sprintf("txCount=%d", txCount), func(b *testing.B) {
+			txs := generateMixedTxs(txCount/2, txCount/2, 1, 1024)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := square.EstimateSquareSize(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func BenchmarkBuilderExportWorkers(b *testing.B) {
+	// 32 blobs of 2 MB need a square larger than the default max square size
+	const maxSquareSize = 512
+	txs := generateOrderedTxs(0, 32, 1, 2_000_000)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				builder, err := square.NewBuilder(maxSquareSize, defaultSubtreeRootThreshold, txs...)
+				require.NoError(b, err)
+				builder.SetExportWorkers(workers)
+				b.StartTimer()
+				_, err = builder.Export()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
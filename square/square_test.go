@@ -2,17 +2,31 @@ package square_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/celestiaorg/go-square/blob"
 	"github.com/celestiaorg/go-square/internal/test"
+	"github.com/celestiaorg/go-square/namespace"
 	"github.com/celestiaorg/go-square/shares"
 	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
 const (
 	mebibyte                    = 1_048_576 // one mebibyte in bytes
 	defaultMaxSquareSize        = 128
@@ -148,6 +162,64 @@ func TestSquareBlobShareRange(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestShareRanges(t *testing.T) {
+	testCases := []struct {
+		name string
+		txs  [][]byte
+	}{
+		{"no txs", nil},
+		{"only normal txs", generateOrderedTxs(20, 0, 0, 0)},
+		{"only pfbs", generateOrderedTxs(0, 10, 3, 1000)},
+		{"mixed", generateOrderedTxs(30, 20, 4, 700)},
+	}
+	// The delimiter of a tx of 128 bytes or more is 2 bytes long, so a tx of
+	// firstSize bytes leaves 0, 1 or 2 bytes of the first compact share for the
+	// delimiter of the next tx which then starts in the next share, straddles
+	// the boundary or fits in the first share.
+	for firstSize := shares.FirstCompactShareContentSize - 6; firstSize <= shares.FirstCompactShareContentSize; firstSize++ {
+		txs := [][]byte{
+			bytes.Repeat([]byte{1}, firstSize),
+			bytes.Repeat([]byte{2}, shares.ContinuationCompactShareContentSize-2),
+			bytes.Repeat([]byte{3}, 300),
+		}
+		txs = append(txs, generateOrderedTxs(0, 2, 2, 100)...)
+		testCases = append(testCases, struct {
+			name string
+			txs  [][]byte
+		}{fmt.Sprintf("delimiter at share boundary with first tx of %d bytes", firstSize), txs})
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			txRanges, blobRanges, err := square.ShareRanges(tc.txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+			require.NoError(t, err)
+			require.Len(t, txRanges, len(tc.txs))
+			require.Len(t, blobRanges, len(tc.txs))
+
+			for i, tx := range tc.txs {
+				want, err := square.TxShareRange(tc.txs, i, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				require.NoError(t, err)
+				assert.Equal(t, want, txRanges[i], "tx %d", i)
+
+				blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
+				if !isBlobTx {
+					assert.Nil(t, blobRanges[i], "tx %d", i)
+					continue
+				}
+				require.Len(t, blobRanges[i], len(blobTx.Blobs))
+				for j := range blobTx.Blobs {
+					want, err := square.BlobShareRange(tc.txs, i, j, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+					require.NoError(t, err)
+					assert.Equal(t, want, blobRanges[i][j], "tx %d blob %d", i, j)
+				}
+			}
+		})
+	}
+
+	_, _, err := square.ShareRanges([][]byte{{1}}, 0, defaultSubtreeRootThreshold)
+	require.Error(t, err)
+}
+
 func TestSquareDeconstruct(t *testing.T) {
 	t.Run("ConstructDeconstructParity", func(t *testing.T) {
 		// 8192 -> square size 128
@@ -179,6 +251,17 @@ func TestSquareDeconstruct(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, txs, recomputedTxs)
 	})
+	t.Run("ShareIndexOutOfRange", func(t *testing.T) {
+		wpfb, err := blob.MarshalIndexWrapper(test.MockPFB([]uint32{10}), 1000)
+		require.NoError(t, err)
+		txShares, pfbShares, _, err := shares.SplitTxs([][]byte{test.GenerateRandomTx(10, 10), wpfb})
+		require.NoError(t, err)
+		dataSquare := append(append(txShares, pfbShares...), shares.TailPaddingShares(2)...)
+
+		_, err = square.Deconstruct(dataSquare, test.DecodeMockPFB)
+		assert.ErrorIs(t, err, blob.ErrShareIndexOutOfRange)
+		assert.ErrorContains(t, err, "tx index 1")
+	})
 	t.Run("EmptySquare", func(t *testing.T) {
 		tx, err := square.Deconstruct(square.EmptySquare(), test.DecodeMockPFB)
 		require.NoError(t, err)
@@ -186,6 +269,792 @@ func TestSquareDeconstruct(t *testing.T) {
 	})
 }
 
+// TestEmptySquareGolden pins the exact bytes of the empty square because the
+// data root of empty blocks is derived from it. Run the test with -update to
+// rewrite the golden file after an intentional change.
+func TestEmptySquareGolden(t *testing.T) {
+	var got bytes.Buffer
+	for _, share := range square.EmptySquare() {
+		got.WriteString(hex.EncodeToString(share.ToBytes()))
+		got.WriteByte('\n')
+	}
+
+	path := filepath.Join("testdata", "empty_square.hex")
+	if *update {
+		require.NoError(t, os.WriteFile(path, got.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got.String())
+}
+
+func TestEmptySquare(t *testing.T) {
+	empty := square.EmptySquare()
+	require.Equal(t, 1, empty.Size())
+	require.True(t, empty.IsEmpty())
+
+	constructed, err := square.Construct(nil, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.Equal(t, empty, constructed)
+
+	built, txs, err := square.Build(nil, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.Equal(t, empty, built)
+	require.Empty(t, txs)
+
+	fixed, _, err := square.BuildFixed(nil, 4, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.True(t, fixed.IsEmpty())
+
+	deconstructed, err := square.DeconstructWithMapping(empty, test.DecodeMockPFB)
+	require.NoError(t, err)
+	require.Equal(t, []square.DeconstructedTx{}, deconstructed)
+
+	nonEmpty, err := square.Construct([][]byte{{1}}, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.False(t, nonEmpty.IsEmpty())
+	require.False(t, square.Square(append(shares.TailPaddingShares(3), shares.ReservedPaddingShare())).IsEmpty())
+	// a square without shares isn't the empty square
+	require.False(t, square.Square(nil).IsEmpty())
+	require.False(t, square.Square{}.IsEmpty())
+}
+
+func TestEstimateSquareSize(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		maxSquareSize := 1 << rng.Intn(6)
+		var txs [][]byte
+		for j := rng.Intn(50); j > 0; j-- {
+			txs = append(txs, testutil.RandomNormalTx(rng, 200+rng.Intn(200)))
+		}
+		for _, tx := range testutil.RandomBlobTxs(rng, rng.Intn(50), 1+rng.Intn(3), testutil.SizeRange{Min: 1, Max: 4000}) {
+			txs = append(txs, tx.Tx)
+		}
+		// Build moves blob txs after normal txs and drops txs that don't fit
+		rng.Shuffle(len(txs), func(i, j int) { txs[i], txs[j] = txs[j], txs[i] })
+		t.Run(fmt.Sprintf("%d txs in max square size %d", len(txs), maxSquareSize), func(t *testing.T) {
+			dataSquare, _, err := square.Build(txs, maxSquareSize, defaultSubtreeRootThreshold)
+			require.NoError(t, err)
+			got, err := square.EstimateSquareSize(txs, maxSquareSize, defaultSubtreeRootThreshold)
+			require.NoError(t, err)
+			assert.Equal(t, dataSquare.Size(), got)
+		})
+	}
+
+	size, err := square.EstimateSquareSize(nil, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.Equal(t, square.EmptySquare().Size(), size)
+	_, err = square.EstimateSquareSize(nil, 3, defaultSubtreeRootThreshold)
+	assert.Error(t, err)
+}
+
+func TestConstructWithPolicy(t *testing.T) {
+	txs := generateMixedTxs(20, 20, 2, 1000)
+
+	t.Run("nil policy is Build", func(t *testing.T) {
+		wantSquare, wantTxs, err := square.Build(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		gotSquare, gotTxs, err := square.ConstructWithPolicy(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, nil)
+		require.NoError(t, err)
+		assert.Equal(t, wantSquare, gotSquare)
+		assert.Equal(t, wantTxs, gotTxs)
+	})
+
+	t.Run("reverse order", func(t *testing.T) {
+		var candidates []square.TxInfo
+		reverse := func(c []square.TxInfo) []int {
+			candidates = c
+			order := make([]int, len(c))
+			for i := range order {
+				order[i] = len(c) - 1 - i
+			}
+			return order
+		}
+		// a small square so that some transactions are dropped
+		const maxSquareSize = 8
+		dataSquare, included, err := square.ConstructWithPolicy(txs, maxSquareSize, defaultSubtreeRootThreshold, reverse)
+		require.NoError(t, err)
+		require.NotEmpty(t, included)
+		assert.Less(t, len(included), len(txs))
+
+		require.Len(t, candidates, len(txs))
+		for i, c := range candidates {
+			assert.Equal(t, len(txs[i]), c.Size)
+			blobTx, isBlobTx := blob.UnmarshalBlobTx(txs[i])
+			require.Equal(t, isBlobTx, c.IsBlobTx)
+			if isBlobTx {
+				assert.Equal(t, []uint32{uint32(len(blobTx.Blobs[0].Data)), uint32(len(blobTx.Blobs[1].Data))}, c.BlobSizes)
+			}
+		}
+
+		// the included transactions construct the same square
+		constructed, err := square.Construct(included, maxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		assert.Equal(t, dataSquare, constructed)
+		deconstructed, err := square.Deconstruct(dataSquare, test.DecodeMockPFB)
+		require.NoError(t, err)
+		assert.Equal(t, included, deconstructed)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		for _, order := range [][]int{{0, 0}, {-1}, {len(txs)}} {
+			_, _, err := square.ConstructWithPolicy(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold, func([]square.TxInfo) []int {
+				return order
+			})
+			assert.Error(t, err)
+		}
+	})
+}
+
+// FuzzEstimateSquareSize checks that the estimate agrees with Build for
+// arbitrary, mostly malformed, encodings of blob transactions.
+func TestBuildFixed(t *testing.T) {
+	const fixedSquareSize = 64
+	txs := generateMixedTxs(20, 10, 2, 1000)
+
+	built, builtTxs, err := square.Build(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.Less(t, built.Size(), fixedSquareSize)
+
+	fixed, fixedTxs, err := square.BuildFixed(txs, fixedSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.Equal(t, fixedSquareSize, fixed.Size())
+	require.Equal(t, builtTxs, fixedTxs)
+
+	// the content is laid out identically, only the tail padding differs
+	require.Equal(t, []shares.Share(built[:len(built)-countTailPadding(built)]), []shares.Share(fixed[:len(fixed)-countTailPadding(fixed)]))
+	for _, share := range fixed[len(built)-countTailPadding(built):] {
+		isPadding, err := share.IsPadding()
+		require.NoError(t, err)
+		require.True(t, isPadding)
+	}
+
+	builtDeconstructed, err := square.Deconstruct(built, test.DecodeMockPFB)
+	require.NoError(t, err)
+	fixedDeconstructed, err := square.Deconstruct(fixed, test.DecodeMockPFB)
+	require.NoError(t, err)
+	require.Equal(t, builtDeconstructed, fixedDeconstructed)
+	require.Equal(t, fixedTxs, fixedDeconstructed)
+
+	t.Run("no txs", func(t *testing.T) {
+		empty, emptyTxs, err := square.BuildFixed(nil, fixedSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		require.Equal(t, fixedSquareSize, empty.Size())
+		require.Empty(t, emptyTxs)
+		deconstructed, err := square.Deconstruct(empty, test.DecodeMockPFB)
+		require.NoError(t, err)
+		require.Empty(t, deconstructed)
+	})
+
+	t.Run("content exceeds the square size", func(t *testing.T) {
+		_, _, err := square.BuildFixed(txs, 8, defaultSubtreeRootThreshold)
+		require.ErrorIs(t, err, square.ErrSquareSizeExceeded)
+		var txErr *square.TxError
+		require.ErrorAs(t, err, &txErr)
+
+		// every tx before the overflowing one fits in the square
+		_, _, err = square.BuildFixed(txs[:txErr.TxIndex], 8, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+	})
+
+	t.Run("square size isn't a power of two", func(t *testing.T) {
+		_, _, err := square.BuildFixed(txs, 63, defaultSubtreeRootThreshold)
+		require.Error(t, err)
+	})
+}
+
+func TestSentinelErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	emptyBlobTx, err := proto.Marshal(&blob.BlobTx{Tx: []byte("tx"), Blobs: []*blob.Blob{blob.New(ns1, nil, 0)}, TypeId: blob.ProtoBlobTxTypeID})
+	require.NoError(t, err)
+	normalTx := bytes.Repeat([]byte{0xAB}, 1000)
+	hugeTx := bytes.Repeat([]byte{0xAB}, 5000)
+	validSquare, err := square.Construct(generateOrderedTxs(2, 2, 1, 100), defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold, normalTx)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		err         func() error
+		wantErr     error
+		wantTxIndex int // -1 if the error isn't a *TxError
+	}{
+		{
+			name: "square size exceeded",
+			err: func() error {
+				_, err := square.Construct([][]byte{normalTx, normalTx}, 2, defaultSubtreeRootThreshold)
+				return err
+			},
+			wantErr:     square.ErrSquareSizeExceeded,
+			wantTxIndex: 1,
+		},
+		{
+			name:        "tx too large",
+			err:         func() error { _, err := square.Construct([][]byte{hugeTx}, 2, defaultSubtreeRootThreshold); return err },
+			wantErr:     square.ErrTxTooLarge,
+			wantTxIndex: 0,
+		},
+		{
+			name: "tx too large for a fixed square",
+			err: func() error {
+				_, _, err := square.BuildFixed([][]byte{normalTx, hugeTx}, 2, defaultSubtreeRootThreshold)
+				return err
+			},
+			wantErr:     square.ErrTxTooLarge,
+			wantTxIndex: 1,
+		},
+		{
+			name: "malformed blob tx",
+			err: func() error {
+				_, err := square.Construct([][]byte{normalTx, emptyBlobTx}, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				return err
+			},
+			wantErr:     square.ErrMalformedBlobTx,
+			wantTxIndex: 1,
+		},
+		{
+			name: "normal tx after blob tx",
+			err: func() error {
+				_, err := square.Construct(append(test.GenerateBlobTxs(1, 1, 100), normalTx), defaultMaxSquareSize, defaultSubtreeRootThreshold)
+				return err
+			},
+			wantErr:     square.ErrTxOrder,
+			wantTxIndex: 1,
+		},
+		{
+			name: "decoder failure",
+			err: func() error {
+				_, err := square.Deconstruct(validSquare, func([]byte) ([]uint32, error) { return nil, errors.New("decode failure") })
+				return err
+			},
+			wantErr:     square.ErrDecodeTx,
+			wantTxIndex: -1,
+		},
+		{
+			name:        "invalid max square size",
+			err:         func() error { _, err := square.NewBuilder(3, defaultSubtreeRootThreshold); return err },
+			wantErr:     square.ErrInvalidSquareSize,
+			wantTxIndex: -1,
+		},
+		{
+			name:        "tx index out of range",
+			err:         func() error { _, err := builder.FindTxShareRange(1); return err },
+			wantErr:     square.ErrIndexOutOfRange,
+			wantTxIndex: -1,
+		},
+		{
+			name:        "pfb index out of range",
+			err:         func() error { _, err := builder.GetWrappedPFB(0); return err },
+			wantErr:     square.ErrIndexOutOfRange,
+			wantTxIndex: -1,
+		},
+		{
+			name:        "share index out of range",
+			err:         func() error { _, _, err := validSquare.PosFromIndex(len(validSquare)); return err },
+			wantErr:     square.ErrIndexOutOfRange,
+			wantTxIndex: -1,
+		},
+		{
+			name: "invalid policy order",
+			err: func() error {
+				_, _, err := square.ConstructWithPolicy([][]byte{normalTx}, defaultMaxSquareSize, defaultSubtreeRootThreshold, func([]square.TxInfo) []int { return []int{0, 0} })
+				return err
+			},
+			wantErr:     square.ErrInvalidPolicyOrder,
+			wantTxIndex: -1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err()
+			require.ErrorIs(t, err, tc.wantErr)
+			var txErr *square.TxError
+			if tc.wantTxIndex < 0 {
+				assert.False(t, errors.As(err, &txErr))
+				return
+			}
+			require.ErrorAs(t, err, &txErr)
+			assert.Equal(t, tc.wantTxIndex, txErr.TxIndex)
+		})
+	}
+}
+
+func TestBuildOnDropped(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{0x1}, namespace.NamespaceVersionZeroIDSize))
+	emptyBlobTx, err := proto.Marshal(&blob.BlobTx{Tx: []byte("tx"), Blobs: []*blob.Blob{blob.New(ns1, nil, 0)}, TypeId: blob.ProtoBlobTxTypeID})
+	require.NoError(t, err)
+	normalTx := bytes.Repeat([]byte{0xAB}, 1000)
+	hugeTx := bytes.Repeat([]byte{0xAB}, 5000)
+	txs := [][]byte{normalTx, hugeTx, emptyBlobTx, normalTx, test.GenerateBlobTxs(1, 1, 100)[0]}
+
+	var dropped []*square.TxError
+	_, included, err := square.Build(txs, 2, defaultSubtreeRootThreshold, square.OnDropped(func(err *square.TxError) {
+		dropped = append(dropped, err)
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{normalTx}, included)
+	require.Len(t, dropped, 4)
+	for i, want := range []struct {
+		txIndex int
+		err     error
+	}{
+		{1, square.ErrTxTooLarge},
+		{2, square.ErrMalformedBlobTx},
+		{3, square.ErrSquareSizeExceeded},
+		{4, square.ErrSquareSizeExceeded},
+	} {
+		assert.Equal(t, want.txIndex, dropped[i].TxIndex)
+		assert.ErrorIs(t, dropped[i], want.err)
+	}
+
+	// without the option the same square is built
+	_, withoutOption, err := square.Build(txs, 2, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	assert.Equal(t, included, withoutOption)
+}
+
+// countTailPadding returns the number of tail padding shares at the end of s.
+func countTailPadding(s square.Square) int {
+	count := 0
+	for i := len(s) - 1; i >= 0 && bytes.Equal(s[i].NamespaceBytes(), namespace.TailPaddingNamespace.Bytes()); i-- {
+		count++
+	}
+	return count
+}
+
+func FuzzEstimateSquareSize(f *testing.F) {
+	rng := rand.New(rand.NewSource(1))
+	blobTx := testutil.RandomBlobTx(rng, 2, testutil.SizeRange{Min: 100, Max: 2000}).Tx
+	f.Add(blobTx)
+	f.Add(blobTx[:len(blobTx)-1])
+	f.Add(append(bytes.Clone(blobTx), 0x78, 0x01))
+	f.Add(testutil.RandomNormalTx(rng, 100))
+
+	f.Fuzz(func(t *testing.T, tx []byte) {
+		const maxSquareSize = 8
+		txs := [][]byte{tx, blobTx}
+		dataSquare, _, err := square.Build(txs, maxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		got, err := square.EstimateSquareSize(txs, maxSquareSize, defaultSubtreeRootThreshold)
+		require.NoError(t, err)
+		require.Equal(t, dataSquare.Size(), got)
+	})
+}
+
+func TestDeconstructWithMapping(t *testing.T) {
+	txs := generateOrderedTxs(10, 10, 3, 800)
+	dataSquare, err := square.Construct(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+
+	deconstructed, err := square.DeconstructWithMapping(dataSquare, test.DecodeMockPFB)
+	require.NoError(t, err)
+	require.Len(t, deconstructed, len(txs))
+	for i, tx := range txs {
+		blobTx, isBlobTx := blob.UnmarshalBlobTx(tx)
+		if !isBlobTx {
+			assert.Equal(t, tx, deconstructed[i].Tx)
+			assert.Nil(t, deconstructed[i].Blobs)
+			continue
+		}
+		assert.Equal(t, blobTx.Tx, deconstructed[i].Tx)
+		require.Len(t, deconstructed[i].Blobs, len(blobTx.Blobs))
+		for j, b := range blobTx.Blobs {
+			assert.True(t, b.Equal(deconstructed[i].Blobs[j]), "blob %d of tx %d", j, i)
+		}
+	}
+}
+
+func TestDeconstructWithMappingErrors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	// a blob that spans the shares at index 2 and 3
+	blobShares, err := shares.SplitBlobs(blob.New(ns1, bytes.Repeat([]byte{1}, shares.FirstSparseShareContentSize+1), shares.ShareVersionZero))
+	require.NoError(t, err)
+	require.Len(t, blobShares, 2)
+	blobSize := uint32(shares.FirstSparseShareContentSize + 1)
+
+	testCases := []struct {
+		name         string
+		blobSizes    []uint32
+		shareIndexes []uint32
+		wantErr      error
+	}{
+		{name: "valid", blobSizes: []uint32{blobSize}, shareIndexes: []uint32{2}},
+		{name: "more blobs than share indexes", blobSizes: []uint32{blobSize, blobSize}, shareIndexes: []uint32{2}, wantErr: blob.ErrShareIndexCountMismatch},
+		{name: "share index in the middle of a blob", blobSizes: []uint32{blobSize}, shareIndexes: []uint32{3}, wantErr: square.ErrShareIndexNotSequenceStart},
+		{name: "share index at padding", blobSizes: []uint32{blobSize}, shareIndexes: []uint32{4}, wantErr: square.ErrShareIndexPadding},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wpfb, err := blob.MarshalIndexWrapper(test.MockPFB(tc.blobSizes), tc.shareIndexes...)
+			require.NoError(t, err)
+			txShares, pfbShares, _, err := shares.SplitTxs([][]byte{test.GenerateRandomTx(10, 10), wpfb})
+			require.NoError(t, err)
+			require.Len(t, append(txShares, pfbShares...), 2)
+			dataSquare := append(append(append(txShares, pfbShares...), blobShares...), shares.TailPaddingShares(12)...)
+
+			deconstructed, err := square.DeconstructWithMapping(dataSquare, test.DecodeMockPFB)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				require.Len(t, deconstructed, 2)
+				require.Len(t, deconstructed[1].Blobs, 1)
+				assert.Equal(t, bytes.Repeat([]byte{1}, int(blobSize)), deconstructed[1].Blobs[0].Data)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+			var deconstructErr *square.DeconstructError
+			require.ErrorAs(t, err, &deconstructErr)
+			assert.Equal(t, 1, deconstructErr.TxIndex)
+		})
+	}
+}
+
+func TestDeconstructErrors(t *testing.T) {
+	txs := generateOrderedTxs(3, 3, 1, 1000)
+	builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold, txs...)
+	require.NoError(t, err)
+	dataSquare, err := builder.Export()
+	require.NoError(t, err)
+	txRanges, blobRanges, err := builder.ShareRanges()
+	require.NoError(t, err)
+	txSection := shares.NewRange(0, txRanges[2].End)
+
+	// corrupt returns a copy of dataSquare in which infoByte rewrites the info
+	// byte of the share at index
+	corrupt := func(index int, infoByte func(byte) byte) square.Square {
+		corrupted := slices.Clone(dataSquare)
+		raw := corrupted[index].ToBytes()
+		raw[namespace.NamespaceSize] = infoByte(raw[namespace.NamespaceSize])
+		share, err := shares.NewShare(raw)
+		require.NoError(t, err)
+		corrupted[index] = *share
+		return corrupted
+	}
+	errDecode := errors.New("decode failure")
+	failSecondPFB := func() square.PFBDecoder {
+		calls := 0
+		return func(tx []byte) ([]uint32, error) {
+			calls++
+			if calls == 2 {
+				return nil, errDecode
+			}
+			return test.DecodeMockPFB(tx)
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		square      square.Square
+		decoder     square.PFBDecoder
+		wantErr     error
+		wantSection square.DeconstructSection
+		wantTxIndex int
+		wantRange   shares.Range
+	}{
+		{
+			name:        "unsupported share version in the txs",
+			square:      corrupt(txSection.End-1, func(byte) byte { return 7<<1 | 1 }),
+			decoder:     test.DecodeMockPFB,
+			wantErr:     shares.ErrUnsupportedShareVersion,
+			wantSection: square.SectionTxs,
+			wantTxIndex: -1,
+			wantRange:   txSection,
+		},
+		{
+			name:        "decoder failure",
+			square:      dataSquare,
+			decoder:     failSecondPFB(),
+			wantErr:     errDecode,
+			wantSection: square.SectionPFBs,
+			wantTxIndex: 4,
+			wantRange:   txRanges[4],
+		},
+		{
+			name:        "blob doesn't start a sequence",
+			square:      corrupt(blobRanges[5][0].Start, func(b byte) byte { return b &^ 1 }),
+			decoder:     test.DecodeMockPFB,
+			wantErr:     square.ErrShareIndexNotSequenceStart,
+			wantSection: square.SectionBlobs,
+			wantTxIndex: 5,
+			wantRange:   shares.NewRange(blobRanges[5][0].Start, blobRanges[5][0].Start+1),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := square.Deconstruct(tc.square, tc.decoder)
+			require.ErrorIs(t, err, tc.wantErr)
+			var deconstructErr *square.DeconstructError
+			require.ErrorAs(t, err, &deconstructErr)
+			assert.Equal(t, tc.wantSection, deconstructErr.Section)
+			assert.Equal(t, tc.wantTxIndex, deconstructErr.TxIndex)
+			assert.Equal(t, tc.wantRange, deconstructErr.Range)
+			if tc.wantErr == errDecode {
+				// the decoder error is wrapped together with ErrDecodeTx
+				assert.ErrorIs(t, deconstructErr.Err, square.ErrDecodeTx)
+			}
+		})
+	}
+}
+
+func TestSquareRowsAndColumns(t *testing.T) {
+	for _, size := range []int{1, 2, 4, 16} {
+		// every share holds its index so that rows and columns can be checked
+		dataSquare := make(square.Square, size*size)
+		for i := range dataSquare {
+			raw := make([]byte, shares.ShareSize)
+			binary.BigEndian.PutUint32(raw[shares.ShareSize-4:], uint32(i))
+			share, err := shares.NewShare(raw)
+			require.NoError(t, err)
+			dataSquare[i] = *share
+		}
+
+		require.Equal(t, size, dataSquare.Size())
+		for i := range dataSquare {
+			row, col, err := dataSquare.PosFromIndex(i)
+			require.NoError(t, err)
+			assert.Equal(t, dataSquare[i], dataSquare.Row(row)[col])
+			assert.Equal(t, dataSquare[i], dataSquare.Col(col)[row])
+			idx, err := dataSquare.IndexFromPos(row, col)
+			require.NoError(t, err)
+			assert.Equal(t, i, idx)
+		}
+		for i := 0; i < size; i++ {
+			assert.Equal(t, []shares.Share(dataSquare[i*size:(i+1)*size]), dataSquare.Row(i))
+			col := make([]shares.Share, 0, size)
+			for j := i; j < len(dataSquare); j += size {
+				col = append(col, dataSquare[j])
+			}
+			assert.Equal(t, col, dataSquare.Col(i))
+		}
+
+		_, _, err := dataSquare.PosFromIndex(-1)
+		assert.Error(t, err)
+		_, _, err = dataSquare.PosFromIndex(len(dataSquare))
+		assert.Error(t, err)
+		for _, pos := range [][2]int{{-1, 0}, {0, -1}, {size, 0}, {0, size}} {
+			_, err := dataSquare.IndexFromPos(pos[0], pos[1])
+			assert.Error(t, err, "position %v", pos)
+		}
+		assert.Panics(t, func() { dataSquare.Row(size) })
+		assert.Panics(t, func() { dataSquare.Col(-1) })
+	}
+
+	t.Run("Col copies and Row doesn't overwrite the next row", func(t *testing.T) {
+		dataSquare := square.Square(shares.TailPaddingShares(4))
+		want := slices.Clone(dataSquare)
+		col := dataSquare.Col(0)
+		col[0] = shares.ReservedPaddingShare()
+		_ = append(dataSquare.Row(0), shares.ReservedPaddingShare())
+		assert.Equal(t, want, dataSquare)
+	})
+}
+
+func TestImportShares(t *testing.T) {
+	txs := generateOrderedTxs(10, 10, 2, 2000)
+	dataSquare, err := square.Construct(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+
+	imported, err := square.ImportShares([]shares.Share(dataSquare), square.WithShareValidation())
+	require.NoError(t, err)
+	require.Equal(t, dataSquare, imported)
+	deconstructed, err := square.Deconstruct(imported, test.DecodeMockPFB)
+	require.NoError(t, err)
+	require.Equal(t, txs, deconstructed)
+
+	for _, count := range []int{0, 2, 3, 8} {
+		_, err := square.ImportShares(shares.TailPaddingShares(count))
+		assert.ErrorIs(t, err, square.ErrInvalidSquareSize, "%d shares", count)
+	}
+
+	// shares out of namespace order are imported as is
+	unordered := slices.Clone([]shares.Share(dataSquare))
+	slices.Reverse(unordered)
+	imported, err = square.ImportShares(unordered, square.WithShareValidation())
+	require.NoError(t, err)
+	require.Equal(t, square.Square(unordered), imported)
+
+	// a share with an unsupported share version is only rejected with share
+	// validation
+	invalid := slices.Clone([]shares.Share(dataSquare))
+	raw := invalid[5].ToBytes()
+	raw[namespace.NamespaceSize] = 7<<1 | 1
+	share, err := shares.NewShare(raw)
+	require.NoError(t, err)
+	invalid[5] = *share
+	_, err = square.ImportShares(invalid)
+	require.NoError(t, err)
+	_, err = square.ImportShares(invalid, square.WithShareValidation())
+	require.ErrorIs(t, err, shares.ErrUnsupportedShareVersion)
+	var parseErr *shares.ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, 5, parseErr.Index)
+}
+
+func TestSquareNamespaceAccessors(t *testing.T) {
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+	ns3 := namespace.MustNewV0(bytes.Repeat([]byte{3}, namespace.NamespaceVersionZeroIDSize))
+	absent := namespace.MustNewV0(bytes.Repeat([]byte{4}, namespace.NamespaceVersionZeroIDSize))
+
+	wpfb, err := blob.MarshalIndexWrapper([]byte("pfb"), 2)
+	require.NoError(t, err)
+	txShares, pfbShares, _, err := shares.SplitTxs([][]byte{[]byte("tx"), wpfb})
+	require.NoError(t, err)
+	// the blobs of ns1 span shares 2 to 21 which crosses two rows of a square
+	// of size 8
+	ns1Blobs := []*blob.Blob{
+		blob.New(ns1, bytes.Repeat([]byte{1}, shares.AvailableBytesFromSparseShares(15)), shares.ShareVersionZero),
+		blob.New(ns1, bytes.Repeat([]byte{2}, shares.AvailableBytesFromSparseShares(5)), shares.ShareVersionZero),
+	}
+	ns1Shares, err := shares.SplitBlobs(ns1Blobs...)
+	require.NoError(t, err)
+	// ns2 is only present as padding
+	ns2Padding, err := shares.NamespacePaddingShares(ns2, shares.ShareVersionZero, 2)
+	require.NoError(t, err)
+	ns3Blob := blob.New(ns3, []byte("ns3"), shares.ShareVersionZero)
+	ns3Shares, err := shares.SplitBlobs(ns3Blob)
+	require.NoError(t, err)
+
+	var raw []shares.Share
+	for _, part := range [][]shares.Share{txShares, pfbShares, ns1Shares, ns2Padding, ns3Shares} {
+		raw = append(raw, part...)
+	}
+	raw = append(raw, shares.TailPaddingShares(64-len(raw))...)
+	dataSquare, err := square.ImportShares(raw)
+	require.NoError(t, err)
+	require.Equal(t, 8, dataSquare.Size())
+
+	assert.Equal(t, []namespace.Namespace{namespace.TxNamespace, namespace.PayForBlobNamespace, ns1, ns2, ns3, namespace.TailPaddingNamespace}, dataSquare.Namespaces())
+
+	shareRange, nsShares, err := dataSquare.SharesForNamespace(ns1)
+	require.NoError(t, err)
+	assert.Equal(t, shares.NewRange(2, 22), shareRange)
+	assert.Equal(t, ns1Shares, nsShares)
+	blobs, err := dataSquare.BlobsForNamespace(ns1)
+	require.NoError(t, err)
+	assert.Equal(t, ns1Blobs, blobs)
+
+	shareRange, nsShares, err = dataSquare.SharesForNamespace(ns2)
+	require.NoError(t, err)
+	assert.Equal(t, shares.NewRange(22, 24), shareRange)
+	assert.Equal(t, ns2Padding, nsShares)
+	blobs, err = dataSquare.BlobsForNamespace(ns2)
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+
+	blobs, err = dataSquare.BlobsForNamespace(ns3)
+	require.NoError(t, err)
+	assert.Equal(t, []*blob.Blob{ns3Blob}, blobs)
+
+	shareRange, nsShares, err = dataSquare.SharesForNamespace(absent)
+	require.NoError(t, err)
+	assert.True(t, shareRange.IsEmpty())
+	assert.Empty(t, nsShares)
+	blobs, err = dataSquare.BlobsForNamespace(absent)
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+
+	// reserved namespaces return raw shares but no blobs
+	shareRange, nsShares, err = dataSquare.SharesForNamespace(namespace.TxNamespace)
+	require.NoError(t, err)
+	assert.Equal(t, shares.NewRange(0, 1), shareRange)
+	assert.Equal(t, txShares, nsShares)
+	for _, ns := range []namespace.Namespace{namespace.TxNamespace, namespace.PayForBlobNamespace, namespace.TailPaddingNamespace} {
+		_, err = dataSquare.BlobsForNamespace(ns)
+		assert.ErrorIs(t, err, blob.ErrReservedNamespace)
+	}
+}
+
+func TestSquareEqualsAndHash(t *testing.T) {
+	txs := generateOrderedTxs(4, 2, 1, 300)
+	export := func(workers int) square.Square {
+		builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold, txs...)
+		require.NoError(t, err)
+		builder.SetExportWorkers(workers)
+		dataSquare, err := builder.Export()
+		require.NoError(t, err)
+		return dataSquare
+	}
+	dataSquare := export(1)
+	require.Equal(t, 4, dataSquare.Size())
+	hash := dataSquare.Hash()
+	require.Len(t, hash, sha256.Size)
+
+	// stable across exports of the same txs
+	for _, workers := range []int{1, 4} {
+		other := export(workers)
+		assert.True(t, dataSquare.Equals(other))
+		assert.Equal(t, hash, other.Hash())
+	}
+
+	// every byte of every share contributes to the hash
+	for i := range dataSquare {
+		raw := dataSquare[i].ToBytes()
+		for j := range raw {
+			mutated := slices.Clone(dataSquare)
+			changed := slices.Clone(raw)
+			changed[j] ^= 0x01
+			share, err := shares.NewShare(changed)
+			require.NoError(t, err)
+			mutated[i] = *share
+			require.False(t, dataSquare.Equals(mutated), "share %d byte %d", i, j)
+			require.NotEqual(t, hash, mutated.Hash(), "share %d byte %d", i, j)
+		}
+	}
+
+	empty := square.EmptySquare()
+	assert.True(t, empty.Equals(square.EmptySquare()))
+	assert.Equal(t, empty.Hash(), square.EmptySquare().Hash())
+	assert.NotEqual(t, hash, empty.Hash())
+	assert.False(t, empty.Equals(dataSquare))
+	assert.False(t, empty.Equals(nil))
+	assert.True(t, square.Square(nil).Equals(nil))
+	assert.Len(t, square.Square(nil).Hash(), sha256.Size)
+}
+
+func TestSquareCountPaddingShares(t *testing.T) {
+	for _, numTxs := range []int{0, 2, 20, 200, 2000} {
+		t.Run(fmt.Sprintf("%d", numTxs), func(t *testing.T) {
+			txs := generateOrderedTxs(numTxs/2, numTxs/2, 1, 800)
+			dataSquare, err := square.Construct(txs, defaultMaxSquareSize, defaultSubtreeRootThreshold)
+			require.NoError(t, err)
+
+			tail, namespacePadding, reserved, err := shares.CountPaddingShares(dataSquare)
+			require.NoError(t, err)
+
+			// cross check the classification against the parsed sequences
+			sequences, err := shares.ParseShares(dataSquare, false)
+			require.NoError(t, err)
+			var wantTail, wantNamespacePadding, wantReserved, used int
+			for _, sequence := range sequences {
+				sequenceLen, err := sequence.SequenceLen()
+				require.NoError(t, err)
+				switch {
+				case sequence.Namespace.IsTailPadding():
+					wantTail++
+				case sequence.Namespace.IsPrimaryReservedPadding():
+					wantReserved++
+				case sequenceLen == 0:
+					wantNamespacePadding++
+				default:
+					used += len(sequence.Shares)
+				}
+			}
+			assert.Equal(t, wantTail, tail)
+			assert.Equal(t, wantNamespacePadding, namespacePadding)
+			assert.Equal(t, wantReserved, reserved)
+			assert.Equal(t, len(dataSquare), used+tail+namespacePadding+reserved)
+
+			utilization, err := shares.UtilizationRatio(dataSquare)
+			require.NoError(t, err)
+			assert.InDelta(t, float64(used)/float64(len(dataSquare)), utilization, 1e-9)
+			if numTxs == 0 {
+				assert.Equal(t, len(dataSquare), tail)
+			}
+		})
+	}
+}
+
 func TestSize(t *testing.T) {
 	type test struct {
 		input  int
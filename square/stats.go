@@ -0,0 +1,109 @@
+package square
+
+import (
+	"fmt"
+
+	"github.com/celestiaorg/go-square/namespace"
+)
+
+// SquareStats describes how the shares of a square are used.
+type SquareStats struct {
+	// SquareSize is the number of rows (or columns) of the square.
+	SquareSize int
+	// TxShares is the number of shares holding normal transactions.
+	TxShares int
+	// PFBShares is the number of shares holding PFB transactions.
+	PFBShares int
+	// BlobShares is the number of shares holding blob data.
+	BlobShares int
+	// NamespacePaddingShares is the number of padding shares between blobs.
+	NamespacePaddingShares int
+	// ReservedPaddingShares is the number of padding shares between the
+	// compact shares and the first blob.
+	ReservedPaddingShares int
+	// TailPaddingShares is the number of padding shares after the last blob.
+	TailPaddingShares int
+	// Blobs is the number of blobs.
+	Blobs int
+	// Namespaces is the number of distinct namespaces with at least one blob.
+	Namespaces int
+}
+
+// Utilization returns the fraction of the shares of the square that aren't
+// tail padding.
+func (s SquareStats) Utilization() float64 {
+	total := s.SquareSize * s.SquareSize
+	if total == 0 {
+		return 0
+	}
+	return float64(total-s.TailPaddingShares) / float64(total)
+}
+
+// Stats classifies every share of the square by its namespace and, for blob
+// namespaces, by whether it starts a blob, continues one or is namespace
+// padding. It returns an error wrapping ErrInvalidSquareSize if the number of
+// shares doesn't form a square, and a *ValidationError if a share can't be
+// classified.
+func (s Square) Stats() (SquareStats, error) {
+	if err := validateShareCount(len(s)); err != nil {
+		return SquareStats{}, err
+	}
+	stats := SquareStats{SquareSize: s.Size()}
+	var (
+		ns            namespace.Namespace
+		kind          namespace.NamespaceKind
+		lastBlobNs    namespace.Namespace
+		seenBlob      bool
+		nsInitialized bool
+	)
+	for i := range s {
+		share := &s[i]
+		if !nsInitialized || share.CompareNamespace(ns) != 0 {
+			var err error
+			if ns, err = share.Namespace(); err != nil {
+				return SquareStats{}, &ValidationError{Index: i, Err: err}
+			}
+			kind = namespace.Classify(ns)
+			nsInitialized = true
+		}
+
+		switch kind {
+		case namespace.TxKind:
+			stats.TxShares++
+		case namespace.PayForBlobKind:
+			stats.PFBShares++
+		case namespace.PaddingKind:
+			if ns.IsTailPadding() {
+				stats.TailPaddingShares++
+			} else {
+				stats.ReservedPaddingShares++
+			}
+		case namespace.UserBlobKind:
+			isStart, err := share.IsSequenceStart()
+			if err != nil {
+				return SquareStats{}, &ValidationError{Index: i, Err: err}
+			}
+			if !isStart {
+				stats.BlobShares++
+				continue
+			}
+			sequenceLen, err := share.SequenceLen()
+			if err != nil {
+				return SquareStats{}, &ValidationError{Index: i, Err: err}
+			}
+			if sequenceLen == 0 {
+				stats.NamespacePaddingShares++
+				continue
+			}
+			stats.BlobShares++
+			stats.Blobs++
+			if !seenBlob || !ns.Equals(lastBlobNs) {
+				stats.Namespaces++
+				lastBlobNs, seenBlob = ns, true
+			}
+		default:
+			return SquareStats{}, &ValidationError{Index: i, Err: fmt.Errorf("%w: %s", ErrUnexpectedNamespace, ns)}
+		}
+	}
+	return stats, nil
+}
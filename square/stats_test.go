@@ -0,0 +1,92 @@
+package square_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquareStats(t *testing.T) {
+	testCases := []struct {
+		normalTxs, pfbTxs, blobsPerPfb, blobSize int
+	}{
+		{0, 0, 0, 0},
+		{5, 0, 0, 0},
+		{0, 1, 1, 100},
+		{10, 10, 2, 1000},
+		{50, 50, 3, 5000},
+		{20, 120, 1, 40000},
+	}
+	for _, tc := range testCases {
+		name := fmt.Sprintf("%d txs %d pfbs %dx%d bytes", tc.normalTxs, tc.pfbTxs, tc.blobsPerPfb, tc.blobSize)
+		t.Run(name, func(t *testing.T) {
+			txs := generateOrderedTxs(tc.normalTxs, tc.pfbTxs, tc.blobsPerPfb, tc.blobSize)
+			builder, err := square.NewBuilder(defaultMaxSquareSize, defaultSubtreeRootThreshold, txs...)
+			require.NoError(t, err)
+			dataSquare, err := builder.Export()
+			require.NoError(t, err)
+
+			stats, err := dataSquare.Stats()
+			require.NoError(t, err)
+			squareSize := dataSquare.Size()
+			assert.Equal(t, squareSize, stats.SquareSize)
+			assert.Equal(t, squareSize*squareSize, stats.TxShares+stats.PFBShares+stats.BlobShares+
+				stats.NamespacePaddingShares+stats.ReservedPaddingShares+stats.TailPaddingShares)
+			assert.Equal(t, countTailPadding(dataSquare), stats.TailPaddingShares)
+
+			// cross check against the layout the builder chose
+			txRanges, blobRanges, err := builder.ShareRanges()
+			require.NoError(t, err)
+			var txShares, pfbShares, blobShares, blobs, end int
+			for i, txRange := range txRanges {
+				if blobRanges[i] == nil {
+					txShares = max(txShares, txRange.End)
+				} else {
+					pfbShares = max(pfbShares, txRange.End)
+				}
+				for _, blobRange := range blobRanges[i] {
+					blobShares += blobRange.End - blobRange.Start
+					blobs++
+					end = max(end, blobRange.End)
+				}
+			}
+			if pfbShares > 0 {
+				pfbShares -= txShares
+			}
+			assert.Equal(t, txShares, stats.TxShares)
+			assert.Equal(t, pfbShares, stats.PFBShares)
+			assert.Equal(t, blobShares, stats.BlobShares)
+			assert.Equal(t, blobs, stats.Blobs)
+			if blobs > 0 {
+				assert.Equal(t, end, stats.TxShares+stats.PFBShares+stats.ReservedPaddingShares+stats.BlobShares+stats.NamespacePaddingShares)
+			}
+
+			_, _, reserved, err := shares.CountPaddingShares(dataSquare)
+			require.NoError(t, err)
+			assert.Equal(t, reserved, stats.ReservedPaddingShares)
+
+			assert.Equal(t, tc.pfbTxs*tc.blobsPerPfb, stats.Blobs)
+			assert.LessOrEqual(t, stats.Namespaces, stats.Blobs)
+			if stats.Blobs > 0 {
+				assert.Positive(t, stats.Namespaces)
+			}
+			assert.InDelta(t, float64(squareSize*squareSize-stats.TailPaddingShares)/float64(squareSize*squareSize), stats.Utilization(), 1e-9)
+		})
+	}
+
+	t.Run("empty square", func(t *testing.T) {
+		stats, err := square.EmptySquare().Stats()
+		require.NoError(t, err)
+		assert.Equal(t, square.SquareStats{SquareSize: 1, TailPaddingShares: 1}, stats)
+		assert.Zero(t, stats.Utilization())
+	})
+
+	t.Run("shares that don't form a square", func(t *testing.T) {
+		_, err := square.Square(shares.TailPaddingShares(3)).Stats()
+		assert.ErrorIs(t, err, square.ErrInvalidSquareSize)
+	})
+}
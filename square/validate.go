@@ -0,0 +1,169 @@
+package square
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/go-square/inclusion"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+)
+
+var (
+	// ErrCompactSequence is returned by ValidateSquare when the compact shares
+	// of a namespace don't form a single sequence.
+	ErrCompactSequence = errors.New("compact shares of a namespace must form a single sequence")
+	// ErrUnexpectedNamespace is returned by ValidateSquare when a share has a
+	// namespace that Construct never writes, e.g. a reserved namespace
+	// without a defined use or the parity shares namespace.
+	ErrUnexpectedNamespace = errors.New("namespace can't appear in a data square")
+	// ErrBlobNotAligned is returned by ValidateSquare when a blob doesn't
+	// start at the share index that the non-interactive default rules place
+	// it at.
+	ErrBlobNotAligned = errors.New("blob doesn't start where the non-interactive default rules place it")
+	// ErrUnexpectedPadding is returned by ValidateSquare when a padding share
+	// appears where Construct never writes padding.
+	ErrUnexpectedPadding = errors.New("padding share where padding isn't allowed")
+)
+
+// ValidationError is returned by ValidateSquare. Err wraps the violated rule,
+// e.g. ErrBlobNotAligned.
+type ValidationError struct {
+	// Index is the index of the first share that violates the rule.
+	Index int
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("share %d: %v", e.Index, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateSquare returns an error if s can't have been produced by Construct
+// with subtreeRootThreshold. It checks that:
+//   - the number of shares is the square of a power of two, otherwise it
+//     returns an error wrapping ErrInvalidSquareSize
+//   - every share and sequence is well formed, including the reserved bytes
+//     of compact shares, otherwise it returns the *shares.ParseError of
+//     shares.ParseShares
+//
+// and otherwise returns a *ValidationError naming the first share that
+// violates one of the following rules:
+//   - shares are ordered by namespace (shares.ErrNamespaceOrder)
+//   - the txs and the PFBs each form a single compact sequence
+//     (ErrCompactSequence)
+//   - only namespaces that Construct writes appear (ErrUnexpectedNamespace)
+//   - the first blob starts at an index aligned to its subtree width and
+//     every other blob starts right after the padding the non-interactive
+//     default rules require after the previous blob (ErrBlobNotAligned)
+//   - reserved padding only precedes the first blob, namespace padding only
+//     sits between two blobs in the namespace and share version of the blob
+//     before it, and tail padding only follows everything else
+//     (ErrUnexpectedPadding)
+//
+// ValidateSquare doesn't decode transactions, so it doesn't check that blobs
+// are paid for by the PFBs of the square.
+func ValidateSquare(s Square, subtreeRootThreshold int) error {
+	if err := validateShareCount(len(s)); err != nil {
+		return err
+	}
+	sequences, err := shares.ParseShares(s, false)
+	if err != nil {
+		return err
+	}
+
+	var (
+		prevNs namespace.Namespace
+		// lastBlob is the index of the first share of the last blob, or -1
+		lastBlob = -1
+		// blobEnd is the index after the last share of the last blob
+		blobEnd int
+		// firstPadding is the index of the first reserved or namespace
+		// padding share that still has to be followed by a blob, or -1
+		firstPadding = -1
+	)
+	start := 0
+	for _, sequence := range sequences {
+		ns := sequence.Namespace
+		violation := func(err error) error {
+			return &ValidationError{Index: start, Err: err}
+		}
+		if start > 0 && ns.IsLessThan(prevNs) {
+			return violation(fmt.Errorf("%w: namespace %s follows namespace %s", shares.ErrNamespaceOrder, ns, prevNs))
+		}
+
+		switch namespace.Classify(ns) {
+		case namespace.TxKind, namespace.PayForBlobKind:
+			if start > 0 && ns.Equals(prevNs) {
+				return violation(fmt.Errorf("%w: second sequence in namespace %s", ErrCompactSequence, ns))
+			}
+		case namespace.PaddingKind:
+			if ns.IsTailPadding() {
+				if firstPadding >= 0 {
+					return &ValidationError{Index: firstPadding, Err: fmt.Errorf("%w: padding isn't followed by a blob", ErrUnexpectedPadding)}
+				}
+			} else if firstPadding < 0 {
+				firstPadding = start
+			}
+		case namespace.UserBlobKind:
+			isPadding, err := s[start].IsPadding()
+			if err != nil {
+				return violation(err)
+			}
+			if isPadding {
+				if err := validateNamespacePadding(s, lastBlob, start); err != nil {
+					return violation(err)
+				}
+				if firstPadding < 0 {
+					firstPadding = start
+				}
+				break
+			}
+			numShares := len(sequence.Shares)
+			want := inclusion.NextShareIndex(start, numShares, subtreeRootThreshold)
+			if lastBlob >= 0 {
+				want = inclusion.NextShareIndex(blobEnd, numShares, subtreeRootThreshold)
+			}
+			if start != want {
+				return violation(fmt.Errorf("%w: blob of %d shares starts at share %d instead of %d", ErrBlobNotAligned, numShares, start, want))
+			}
+			lastBlob, blobEnd, firstPadding = start, start+numShares, -1
+		default:
+			return violation(fmt.Errorf("%w: %s", ErrUnexpectedNamespace, ns))
+		}
+		prevNs = ns
+		start += len(sequence.Shares)
+	}
+	if firstPadding >= 0 {
+		return &ValidationError{Index: firstPadding, Err: fmt.Errorf("%w: padding isn't followed by a blob", ErrUnexpectedPadding)}
+	}
+	return nil
+}
+
+// validateNamespacePadding returns an error if the namespace padding share at
+// index i of s doesn't follow a blob, starting at index lastBlob, or padding of
+// that blob in the same namespace and share version.
+func validateNamespacePadding(s Square, lastBlob, i int) error {
+	if lastBlob < 0 {
+		return fmt.Errorf("%w: namespace padding before the first blob", ErrUnexpectedPadding)
+	}
+	if !bytes.Equal(s[i].NamespaceBytes(), s[lastBlob].NamespaceBytes()) {
+		return fmt.Errorf("%w: namespace padding in a different namespace than the blob before it", ErrUnexpectedPadding)
+	}
+	paddingVersion, err := s[i].Version()
+	if err != nil {
+		return err
+	}
+	blobVersion, err := s[lastBlob].Version()
+	if err != nil {
+		return err
+	}
+	if paddingVersion != blobVersion {
+		return fmt.Errorf("%w: namespace padding with share version %d after a blob with share version %d", ErrUnexpectedPadding, paddingVersion, blobVersion)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package square_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/celestiaorg/go-square/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSquareAcceptsConstructedSquares(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, subtreeRootThreshold := range []int{1, 2, defaultSubtreeRootThreshold} {
+		for seed := int64(0); seed < 4; seed++ {
+			dataSquare, err := square.Construct(deterministicTxs(seed), defaultMaxSquareSize, subtreeRootThreshold)
+			require.NoError(t, err)
+			require.NoError(t, square.ValidateSquare(dataSquare, subtreeRootThreshold), "threshold %d seed %d", subtreeRootThreshold, seed)
+		}
+		for _, fillRatio := range []float64{0.05, 0.3, 1} {
+			generated := testutil.RandomSquare(rng, fillRatio, 32, subtreeRootThreshold)
+			require.NoError(t, square.ValidateSquare(generated.Square, subtreeRootThreshold), "threshold %d fill ratio %v", subtreeRootThreshold, fillRatio)
+		}
+	}
+
+	require.NoError(t, square.ValidateSquare(square.EmptySquare(), defaultSubtreeRootThreshold))
+	fixed, _, err := square.BuildFixed(generateOrderedTxs(5, 5, 2, 1000), 32, defaultSubtreeRootThreshold)
+	require.NoError(t, err)
+	require.NoError(t, square.ValidateSquare(fixed, defaultSubtreeRootThreshold))
+}
+
+func TestValidateSquareErrors(t *testing.T) {
+	// with a threshold of 1 a blob of 2 shares must start at an even index
+	const subtreeRootThreshold = 1
+	ns1 := namespace.MustNewV0(bytes.Repeat([]byte{1}, namespace.NamespaceVersionZeroIDSize))
+	ns2 := namespace.MustNewV0(bytes.Repeat([]byte{2}, namespace.NamespaceVersionZeroIDSize))
+
+	wpfb, err := blob.MarshalIndexWrapper([]byte("pfb"), 0)
+	require.NoError(t, err)
+	txShares, pfbShares, _, err := shares.SplitTxs([][]byte{[]byte("tx"), wpfb})
+	require.NoError(t, err)
+	require.Len(t, txShares, 1)
+	require.Len(t, pfbShares, 1)
+	tx, pfb := txShares[0], pfbShares[0]
+	blobShares := func(ns namespace.Namespace, numShares int) []shares.Share {
+		s, err := shares.SplitBlobs(blob.New(ns, bytes.Repeat([]byte{0xab}, shares.AvailableBytesFromSparseShares(numShares)), shares.ShareVersionZero))
+		require.NoError(t, err)
+		require.Len(t, s, numShares)
+		return s
+	}
+	nsPadding := func(ns namespace.Namespace) shares.Share {
+		s, err := shares.NamespacePaddingShare(ns, shares.ShareVersionZero)
+		require.NoError(t, err)
+		return s
+	}
+	reservedPadding := shares.ReservedPaddingShare()
+	// a single share sequence in a reserved namespace that has no use
+	raw := blobShares(ns1, 1)[0].ToBytes()
+	copy(raw, namespace.MustNewV0(append(make([]byte, namespace.NamespaceVersionZeroIDSize-1), 3)).Bytes())
+	unused, err := shares.NewShare(raw)
+	require.NoError(t, err)
+	// a share with an unsupported share version
+	raw = blobShares(ns1, 1)[0].ToBytes()
+	raw[namespace.NamespaceSize] = 7<<1 | 1
+	invalid, err := shares.NewShare(raw)
+	require.NoError(t, err)
+
+	// layout appends tail padding to parts up to the next square
+	layout := func(parts ...any) square.Square {
+		var s square.Square
+		for _, part := range parts {
+			switch part := part.(type) {
+			case shares.Share:
+				s = append(s, part)
+			case []shares.Share:
+				s = append(s, part...)
+			}
+		}
+		size := 1
+		for size*size < len(s) {
+			size *= 2
+		}
+		return append(s, shares.TailPaddingShares(size*size-len(s))...)
+	}
+
+	testCases := []struct {
+		name      string
+		square    square.Square
+		wantErr   error
+		wantIndex int
+	}{
+		{
+			name:      "valid",
+			square:    layout(tx, pfb, reservedPadding, reservedPadding, blobShares(ns1, 2), blobShares(ns2, 1), nsPadding(ns2), blobShares(ns2, 2)),
+			wantIndex: -1,
+		},
+		{
+			name:      "not a square",
+			square:    layout(tx, pfb, blobShares(ns1, 1))[:3],
+			wantErr:   square.ErrInvalidSquareSize,
+			wantIndex: -1,
+		},
+		{
+			name:      "invalid share",
+			square:    layout(tx, pfb, *invalid),
+			wantErr:   shares.ErrUnsupportedShareVersion,
+			wantIndex: -1,
+		},
+		{
+			name:      "namespace order",
+			square:    layout(tx, pfb, blobShares(ns2, 1), blobShares(ns1, 1)),
+			wantErr:   shares.ErrNamespaceOrder,
+			wantIndex: 3,
+		},
+		{
+			name:      "split compact sequence",
+			square:    layout(tx, tx, pfb),
+			wantErr:   square.ErrCompactSequence,
+			wantIndex: 1,
+		},
+		{
+			name:      "unused reserved namespace",
+			square:    layout(tx, *unused, pfb),
+			wantErr:   square.ErrUnexpectedNamespace,
+			wantIndex: 1,
+		},
+		{
+			name:      "first blob not aligned",
+			square:    layout(tx, pfb, reservedPadding, blobShares(ns1, 2)),
+			wantErr:   square.ErrBlobNotAligned,
+			wantIndex: 3,
+		},
+		{
+			name:      "too much padding between blobs",
+			square:    layout(tx, pfb, blobShares(ns1, 1), nsPadding(ns1), blobShares(ns2, 1)),
+			wantErr:   square.ErrBlobNotAligned,
+			wantIndex: 4,
+		},
+		{
+			name:      "missing padding between blobs",
+			square:    layout(tx, pfb, blobShares(ns1, 1), blobShares(ns2, 2)),
+			wantErr:   square.ErrBlobNotAligned,
+			wantIndex: 3,
+		},
+		{
+			name:      "reserved padding without blobs",
+			square:    layout(tx, pfb, reservedPadding),
+			wantErr:   square.ErrUnexpectedPadding,
+			wantIndex: 2,
+		},
+		{
+			name:      "namespace padding before the first blob",
+			square:    layout(tx, pfb, nsPadding(ns1), blobShares(ns1, 1)),
+			wantErr:   square.ErrUnexpectedPadding,
+			wantIndex: 2,
+		},
+		{
+			name:      "namespace padding of the next blob",
+			square:    layout(tx, pfb, blobShares(ns1, 1), nsPadding(ns2), blobShares(ns2, 2)),
+			wantErr:   square.ErrUnexpectedPadding,
+			wantIndex: 3,
+		},
+		{
+			name:      "namespace padding after the last blob",
+			square:    layout(tx, pfb, blobShares(ns1, 1), nsPadding(ns1)),
+			wantErr:   square.ErrUnexpectedPadding,
+			wantIndex: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := square.ValidateSquare(tc.square, subtreeRootThreshold)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.wantErr)
+			var validationErr *square.ValidationError
+			if tc.wantIndex < 0 {
+				assert.False(t, errors.As(err, &validationErr))
+				return
+			}
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.wantIndex, validationErr.Index)
+		})
+	}
+}
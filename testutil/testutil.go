@@ -0,0 +1,192 @@
+// Package testutil provides deterministic generators of valid transactions and
+// data squares for use in tests, including those of other modules. It builds
+// on the blobs and shares of shares/testfactory. All generators take a
+// *rand.Rand so that failures can be reproduced from the seed, and their
+// output is built with the same rules as the square package so that it passes
+// its validation.
+package testutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/namespace"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/shares/testfactory"
+	"github.com/celestiaorg/go-square/square"
+)
+
+// SizeRange is an inclusive range of sizes in bytes.
+type SizeRange struct {
+	Min, Max int
+}
+
+// rand returns a random size in the range. It panics if the range is empty or
+// contains non-positive sizes.
+func (s SizeRange) rand(rng *rand.Rand) int {
+	if s.Min < 1 || s.Max < s.Min {
+		panic(fmt.Sprintf("invalid size range [%d, %d]", s.Min, s.Max))
+	}
+	return s.Min + rng.Intn(s.Max-s.Min+1)
+}
+
+// BlobTx is a generated blob transaction.
+type BlobTx struct {
+	// Tx is the marshalled blob transaction as it is passed to
+	// square.Construct.
+	Tx []byte
+	// PFB is the transaction that pays for the blobs. It is a mock PFB that
+	// can be decoded with DecodeMockPFB.
+	PFB []byte
+	// Blobs are the blobs of the transaction and Namespaces their namespaces.
+	Blobs      []*blob.Blob
+	Namespaces []namespace.Namespace
+}
+
+// RandomBlobTx returns a blob transaction with one blob in each of nsCount
+// distinct random namespaces. The size of every blob is drawn from
+// blobSizeRange and its share version from shares.SupportedShareVersions;
+// share version 1 blobs get a random signer.
+func RandomBlobTx(rng *rand.Rand, nsCount int, blobSizeRange SizeRange) BlobTx {
+	if nsCount < 1 {
+		panic("nsCount must be positive")
+	}
+	namespaces := namespace.RandomBlobNamespaces(rng, nsCount)
+	blobs := make([]*blob.Blob, nsCount)
+	blobSizes := make([]uint32, nsCount)
+	for i, ns := range namespaces {
+		shareVersion := shares.SupportedShareVersions[rng.Intn(len(shares.SupportedShareVersions))]
+		blobs[i] = testfactory.RandomBlobWithNamespace(rng, ns, blobSizeRange.rand(rng), shareVersion)
+		blobSizes[i] = uint32(len(blobs[i].Data))
+	}
+	pfb := MockPFB(rng, blobSizes)
+	tx, err := blob.MarshalBlobTx(pfb, blobs...)
+	if err != nil {
+		panic(err)
+	}
+	return BlobTx{Tx: tx, PFB: pfb, Blobs: blobs, Namespaces: namespaces}
+}
+
+// RandomBlobTxs returns n blob transactions generated by RandomBlobTx.
+func RandomBlobTxs(rng *rand.Rand, n, nsCount int, blobSizeRange SizeRange) []BlobTx {
+	txs := make([]BlobTx, n)
+	for i := range txs {
+		txs[i] = RandomBlobTx(rng, nsCount, blobSizeRange)
+	}
+	return txs
+}
+
+// RandomNormalTx returns a transaction of size random bytes that is not a blob
+// transaction.
+func RandomNormalTx(rng *rand.Rand, size int) []byte {
+	for {
+		tx := testfactory.RandomBytes(rng, size)
+		if _, isBlobTx := blob.UnmarshalBlobTx(tx); !isBlobTx {
+			return tx
+		}
+	}
+}
+
+// Square is a generated data square.
+type Square struct {
+	Square square.Square
+	// Txs are the transactions the square was constructed from: NormalTxs
+	// followed by the Tx of every BlobTxs.
+	Txs       [][]byte
+	NormalTxs [][]byte
+	BlobTxs   []BlobTx
+}
+
+// maxGeneratedBlobSize caps the size of the blobs of RandomSquare so that a
+// square is filled by several blobs.
+const maxGeneratedBlobSize = 64 * shares.ContinuationSparseShareContentSize
+
+// RandomSquare returns a square constructed by square.Construct from random
+// normal and blob transactions. Transactions are added until about fillRatio
+// of the shares of a square of maxSquareSize are used, so the square size
+// depends on fillRatio. It panics if fillRatio is not between 0 and 1 or if
+// the square can't be constructed.
+func RandomSquare(rng *rand.Rand, fillRatio float64, maxSquareSize, subtreeRootThreshold int) Square {
+	if fillRatio < 0 || fillRatio > 1 {
+		panic(fmt.Sprintf("fill ratio %v must be between 0 and 1", fillRatio))
+	}
+	builder, err := square.NewBuilder(maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		panic(err)
+	}
+	target := int(fillRatio * float64(maxSquareSize*maxSquareSize))
+
+	var out Square
+	// stop once several transactions in a row no longer fit
+	for rejected := 0; rejected < 10; {
+		_, used := builder.CurrentSquareSize()
+		if used >= target {
+			break
+		}
+		maxSize := max(1, min(maxGeneratedBlobSize, (target-used)*shares.ContinuationSparseShareContentSize))
+		if rng.Intn(3) == 0 {
+			tx := RandomNormalTx(rng, 1+rng.Intn(min(maxSize, 2000)))
+			if !builder.AppendTx(tx) {
+				rejected++
+				continue
+			}
+			out.NormalTxs = append(out.NormalTxs, tx)
+		} else {
+			tx := RandomBlobTx(rng, 1+rng.Intn(3), SizeRange{Min: 1, Max: maxSize})
+			blobTx, isBlobTx := blob.UnmarshalBlobTx(tx.Tx)
+			if !isBlobTx {
+				panic("generated blob tx can not be unmarshalled")
+			}
+			if !builder.AppendBlobTx(blobTx) {
+				rejected++
+				continue
+			}
+			out.BlobTxs = append(out.BlobTxs, tx)
+		}
+		rejected = 0
+	}
+
+	out.Txs = make([][]byte, 0, len(out.NormalTxs)+len(out.BlobTxs))
+	out.Txs = append(out.Txs, out.NormalTxs...)
+	for _, tx := range out.BlobTxs {
+		out.Txs = append(out.Txs, tx.Tx)
+	}
+	out.Square, err = square.Construct(out.Txs, maxSquareSize, subtreeRootThreshold)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// mockPFBExtraBytes is the number of random bytes that precede the blob sizes
+// of a mock PFB, roughly the size of a real PFB.
+const mockPFBExtraBytes = 329
+
+// MockPFB returns a stand-in for a PFB transaction that pays for blobs of the
+// provided sizes. It panics if no blob sizes are provided.
+func MockPFB(rng *rand.Rand, blobSizes []uint32) []byte {
+	if len(blobSizes) == 0 {
+		panic("must have at least one blob")
+	}
+	tx := testfactory.RandomBytes(rng, mockPFBExtraBytes)
+	for _, size := range blobSizes {
+		tx = binary.BigEndian.AppendUint32(tx, size)
+	}
+	return tx
+}
+
+// DecodeMockPFB returns the blob sizes of a PFB returned by MockPFB. It can be
+// used as the square.PFBDecoder of squares generated by this package.
+func DecodeMockPFB(pfb []byte) ([]uint32, error) {
+	if len(pfb) < mockPFBExtraBytes+4 {
+		return nil, fmt.Errorf("must have a length of at least %d bytes, got %d", mockPFBExtraBytes+4, len(pfb))
+	}
+	pfb = pfb[mockPFBExtraBytes:]
+	blobSizes := make([]uint32, len(pfb)/4)
+	for i := range blobSizes {
+		blobSizes[i] = binary.BigEndian.Uint32(pfb[i*4 : (i+1)*4])
+	}
+	return blobSizes, nil
+}
@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/celestiaorg/go-square/blob"
+	"github.com/celestiaorg/go-square/shares"
+	"github.com/celestiaorg/go-square/shares/testfactory"
+	"github.com/celestiaorg/go-square/square"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomBlobTx(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sizes := SizeRange{Min: 1, Max: 5000}
+	for _, tx := range RandomBlobTxs(rng, 50, 3, sizes) {
+		blobTx, isBlobTx := blob.UnmarshalBlobTx(tx.Tx)
+		require.True(t, isBlobTx)
+		require.NoError(t, blobTx.Validate())
+		assert.Equal(t, tx.PFB, blobTx.Tx)
+		require.Len(t, tx.Blobs, 3)
+		require.Len(t, tx.Namespaces, 3)
+		blobSizes, err := DecodeMockPFB(tx.PFB)
+		require.NoError(t, err)
+		for i, b := range tx.Blobs {
+			assert.True(t, b.Equal(blobTx.Blobs[i]))
+			assert.Equal(t, tx.Namespaces[i], b.Namespace())
+			assert.Equal(t, uint32(len(b.Data)), blobSizes[i])
+			assert.GreaterOrEqual(t, len(b.Data), sizes.Min)
+			assert.LessOrEqual(t, len(b.Data), sizes.Max)
+		}
+		assert.NotEqual(t, tx.Namespaces[0], tx.Namespaces[1])
+	}
+	assert.Equal(t, RandomBlobTx(rand.New(rand.NewSource(2)), 2, sizes), RandomBlobTx(rand.New(rand.NewSource(2)), 2, sizes))
+	assert.Panics(t, func() { RandomBlobTx(rng, 0, sizes) })
+	assert.Panics(t, func() { RandomBlobTx(rng, 1, SizeRange{Min: 10, Max: 9}) })
+}
+
+func TestRandomNormalTx(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 100, 10_000} {
+		tx := RandomNormalTx(rng, size)
+		assert.Len(t, tx, size)
+		_, isBlobTx := blob.UnmarshalBlobTx(tx)
+		assert.False(t, isBlobTx)
+	}
+	assert.Equal(t, RandomNormalTx(rand.New(rand.NewSource(2)), 100), RandomNormalTx(rand.New(rand.NewSource(2)), 100))
+}
+
+func TestRandomSquare(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, maxSquareSize := range []int{1, 8, 64} {
+		for _, fillRatio := range []float64{0, 0.1, 0.5, 1} {
+			got := RandomSquare(rng, fillRatio, maxSquareSize, 64)
+			require.NoError(t, square.ValidateSquare(got.Square, 64))
+			assert.Len(t, got.Txs, len(got.NormalTxs)+len(got.BlobTxs))
+			assert.LessOrEqual(t, got.Square.Size(), maxSquareSize)
+			if fillRatio == 1 {
+				// a full square can't fit another share
+				assert.Equal(t, maxSquareSize, got.Square.Size(), "max square size %d", maxSquareSize)
+			}
+
+			txs, err := square.Deconstruct(got.Square, DecodeMockPFB)
+			require.NoError(t, err)
+			assert.Equal(t, got.Txs, txs)
+		}
+	}
+	assert.True(t, RandomSquare(rng, 0, 8, 64).Square.IsEmpty())
+
+	a := RandomSquare(rand.New(rand.NewSource(2)), 0.5, 32, 64)
+	b := RandomSquare(rand.New(rand.NewSource(2)), 0.5, 32, 64)
+	assert.True(t, a.Square.Equals(b.Square))
+	assert.Equal(t, a.Txs, b.Txs)
+
+	assert.Panics(t, func() { RandomSquare(rng, 1.5, 8, 64) })
+	assert.Panics(t, func() { RandomSquare(rng, 0.5, 3, 64) })
+}
+
+func TestMockPFB(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	blobSizes := []uint32{20, 30, shares.ShareSize}
+	got, err := DecodeMockPFB(MockPFB(rng, blobSizes))
+	require.NoError(t, err)
+	assert.Equal(t, blobSizes, got)
+	assert.Panics(t, func() { MockPFB(rng, nil) })
+	_, err = DecodeMockPFB(testfactory.RandomBytes(rng, 20))
+	assert.Error(t, err)
+}